@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	pb "example/grpc_demo/library"
+
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// runAuditLog prints the changes made through the API; the logged-in user must be an admin
+func runAuditLog(conn *grpc.ClientConn, args []string) {
+	fs := flag.NewFlagSet("audit-log", flag.ExitOnError)
+	username := fs.String("username", "testUser", "Username to authenticate with")
+	password := fs.String("password", "password123", "Password to authenticate with")
+	userID := fs.Int("user-id", 0, "Only list changes by this user ID")
+	method := fs.String("method", "", "Only list calls of this method, e.g. AddBook or /library.LibraryService/AddBook")
+	resource := fs.String("resource", "", "Only list changes of this book, shelf or other resource ID")
+	page := fs.Int("page", 1, "Page number")
+	pageSize := fs.Int("page-size", 20, "Entries per page")
+	fs.Parse(args)
+
+	req := &pb.ListAuditLogRequest{UserId: int32(*userID), Method: *method, ResourceId: *resource,
+		Page: int32(*page), PageSize: int32(*pageSize)}
+	if req.Method != "" && !strings.HasPrefix(req.Method, "/") {
+		req.Method = fullMethodName(req.Method)
+	}
+
+	if err := login(conn, *username, *password); err != nil {
+		log.Fatalf("could not login: %v", err)
+	}
+	ctx := context.Background()
+
+	resp, err := pb.NewUserServiceClient(conn).ListAuditLog(ctx, req)
+	if err != nil {
+		log.Fatalf("could not list audit log: %v", err)
+	}
+	fmt.Printf("ListAuditLog Response: %d total\n", resp.GetTotalCount())
+	for _, e := range resp.GetEntries() {
+		resourceID := ""
+		if e.GetResourceId() != "" {
+			resourceID = " " + e.GetResourceId()
+		}
+		fmt.Printf("[%s] %s %s%s: %s\n", e.GetCreatedAt().AsTime().Local().Format(time.DateTime), e.GetUsername(),
+			e.GetMethod(), resourceID, e.GetRequestSummary())
+	}
+}
+
+// fullMethodName finds the service of a bare method name such as "AddBook"
+func fullMethodName(method string) string {
+	services := pb.File_library_proto.Services()
+	for i := 0; i < services.Len(); i++ {
+		if service := services.Get(i); service.Methods().ByName(protoreflect.Name(method)) != nil {
+			return fmt.Sprintf("/%s/%s", service.FullName(), method)
+		}
+	}
+	log.Fatalf("unknown method %q", method)
+	return ""
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	pb "example/grpc_demo/library"
+
+	"google.golang.org/grpc"
+)
+
+// runAuthEvents prints the login history of the logged-in user, or of another user for admins
+func runAuthEvents(conn *grpc.ClientConn, args []string) {
+	fs := flag.NewFlagSet("auth-events", flag.ExitOnError)
+	username := fs.String("username", "testUser", "Username to authenticate with")
+	password := fs.String("password", "password123", "Password to authenticate with")
+	user := fs.String("user", "", "Username whose events to list (admins only; defaults to your own)")
+	eventType := fs.String("type", "", "Only list events of this type, e.g. login_failed")
+	page := fs.Int("page", 1, "Page number")
+	pageSize := fs.Int("page-size", 20, "Events per page")
+	fs.Parse(args)
+
+	req := &pb.ListAuthEventsRequest{Username: *user, Page: int32(*page), PageSize: int32(*pageSize)}
+	if *eventType != "" {
+		t, ok := pb.AuthEventType_value["AUTH_EVENT_TYPE_"+strings.ToUpper(*eventType)]
+		if !ok {
+			log.Fatalf("unknown event type %q", *eventType)
+		}
+		req.Type = pb.AuthEventType(t)
+	}
+
+	if err := login(conn, *username, *password); err != nil {
+		log.Fatalf("could not login: %v", err)
+	}
+	ctx := context.Background()
+
+	resp, err := pb.NewUserServiceClient(conn).ListAuthEvents(ctx, req)
+	if err != nil {
+		log.Fatalf("could not list auth events: %v", err)
+	}
+	fmt.Printf("ListAuthEvents Response: %d total\n", resp.GetTotalCount())
+	for _, e := range resp.GetEvents() {
+		label := strings.ToLower(strings.TrimPrefix(e.GetType().String(), "AUTH_EVENT_TYPE_"))
+		detail := ""
+		if e.GetDetail() != "" {
+			detail = " (" + e.GetDetail() + ")"
+		}
+		fmt.Printf("[%s] %s %s%s from %s, %s\n", e.GetCreatedAt().AsTime().Local().Format(time.DateTime), label,
+			e.GetUsername(), detail, e.GetIpAddress(), e.GetUserAgent())
+	}
+}
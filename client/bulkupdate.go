@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	pb "example/grpc_demo/library"
+
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// runBulkUpdate applies a JSON-lines file of book updates in a single all-or-nothing call
+func runBulkUpdate(conn *grpc.ClientConn, args []string) {
+	fs := flag.NewFlagSet("bulk-update", flag.ExitOnError)
+	username := fs.String("username", "testUser", "Username to authenticate with")
+	password := fs.String("password", "password123", "Password to authenticate with")
+	in := fs.String("in", "", "JSON-lines file with one full book per line (defaults to stdin)")
+	fs.Parse(args)
+
+	r := os.Stdin
+	if *in != "" {
+		f, err := os.Open(*in)
+		if err != nil {
+			log.Fatalf("could not open input file: %v", err)
+		}
+		defer f.Close()
+		r = f
+	}
+
+	req := &pb.BulkUpdateRequest{}
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		book := &pb.Book{}
+		if err := protojson.Unmarshal([]byte(text), book); err != nil {
+			log.Fatalf("line %d: invalid book JSON: %v", line, err)
+		}
+		req.Books = append(req.Books, book)
+	}
+	if err := scanner.Err(); err != nil {
+		log.Fatalf("failed to read input: %v", err)
+	}
+
+	if err := login(conn, *username, *password); err != nil {
+		log.Fatalf("could not login: %v", err)
+	}
+
+	resp, err := pb.NewLibraryServiceClient(conn).BulkUpdateBooks(context.Background(), req)
+	if err != nil {
+		log.Fatalf("could not update books: %v", err)
+	}
+	for _, r := range resp.GetResponses() {
+		fmt.Printf("BulkUpdateBooks Response: %s, ID: %s\n", bookResult(r), r.GetId())
+	}
+}
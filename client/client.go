@@ -2,32 +2,156 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
+	"io"
 	"log"
+	"os"
+	"time"
 
 	pb "example/grpc_demo/library"
 
+	"github.com/google/uuid"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
 )
 
-// AuthenticatedClient wraps the gRPC client with authentication
-type AuthenticatedClient struct {
-	token string
+// login authenticates against the UserService; the tokens are then sent with every call on conn
+func login(conn *grpc.ClientConn, username, password string) error {
+	resp, err := pb.NewUserServiceClient(conn).Login(context.Background(), &pb.UserCredentials{
+		Username: username,
+		Password: password,
+	})
+	if err != nil {
+		return err
+	}
+	tokens.set(resp)
+	return nil
 }
 
-// addAuthToContext adds the authentication token to the context
-func (a *AuthenticatedClient) addAuthToContext(ctx context.Context) context.Context {
-	return metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+a.token)
+// withIdempotencyKey tags a call with a new idempotency key, so the server runs it once even if it is retried
+func withIdempotencyKey(ctx context.Context) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, "idempotency-key", uuid.NewString())
+}
+
+// bookResult describes the outcome of one book in a BatchResponse
+func bookResult(r *pb.BookResponse) string {
+	if r.GetError() == nil {
+		return "OK"
+	}
+	return fmt.Sprintf("%s: %s", codes.Code(r.GetError().GetCode()), r.GetError().GetMessage())
 }
 
 func main() {
-	conn, err := grpc.NewClient("localhost:50051", grpc.WithTransportCredentials(insecure.NewCredentials()))
+	creds, err := transportCredentials()
+	if err != nil {
+		log.Fatalf("invalid TLS configuration: %v", err)
+	}
+	tokens.requireTLS = creds.Info().SecurityProtocol != "insecure"
+	creds, err = xdsCredentials(creds)
+	if err != nil {
+		log.Fatalf("invalid xDS configuration: %v", err)
+	}
+	opts := append([]grpc.DialOption{grpc.WithTransportCredentials(creds), grpc.WithPerRPCCredentials(tokens)}, deviceDialOptions()...)
+	opts = append(opts, localeDialOptions()...)
+	opts = append(opts, tenantDialOptions()...)
+	opts = append(opts, retryDialOptions()...)
+	conn, err := grpc.NewClient(serverTarget(), opts...)
 	if err != nil {
 		log.Fatalf("did not connect: %v", err)
 	}
 	defer conn.Close()
+	tokens.users = pb.NewUserServiceClient(conn)
+
+	// Subcommands; with no arguments the client runs the full demo below
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "register":
+			runRegister(conn, os.Args[2:])
+		case "verify-email":
+			runVerifyEmail(conn, os.Args[2:])
+		case "login-oidc":
+			runLoginOIDC(conn, os.Args[2:])
+		case "refresh":
+			runRefresh(conn, os.Args[2:])
+		case "logout":
+			runLogout(conn, os.Args[2:])
+		case "sessions":
+			runSessions(conn, os.Args[2:])
+		case "auth-events":
+			runAuthEvents(conn, os.Args[2:])
+		case "audit-log":
+			runAuditLog(conn, os.Args[2:])
+		case "scoped-token":
+			runScopedToken(conn, os.Args[2:])
+		case "change-password":
+			runChangePassword(conn, os.Args[2:])
+		case "reset-password":
+			runResetPassword(conn, os.Args[2:])
+		case "profile":
+			runProfile(conn, os.Args[2:])
+		case "export":
+			runExport(conn, os.Args[2:])
+		case "import":
+			runImport(conn, os.Args[2:])
+		case "bulk-update":
+			runBulkUpdate(conn, os.Args[2:])
+		case "upload-cover":
+			runUploadCover(conn, os.Args[2:])
+		case "download-cover":
+			runDownloadCover(conn, os.Args[2:])
+		case "lookup":
+			runLookup(conn, os.Args[2:])
+		case "list":
+			runList(conn, os.Args[2:])
+		case "search":
+			runSearch(conn, os.Args[2:])
+		case "get":
+			runGet(conn, os.Args[2:])
+		case "copies":
+			runCopies(conn, os.Args[2:])
+		case "barcode":
+			runBarcode(conn, os.Args[2:])
+		case "acquisitions":
+			runAcquisitions(conn, os.Args[2:])
+		case "locate":
+			runLocate(conn, os.Args[2:])
+		case "series":
+			runSeries(conn, os.Args[2:])
+		case "translations":
+			runTranslations(conn, os.Args[2:])
+		case "tags":
+			runTags(conn, os.Args[2:])
+		case "publishers":
+			runPublishers(conn, os.Args[2:])
+		case "reviews":
+			runReviews(conn, os.Args[2:])
+		case "favorites":
+			runFavorites(conn, os.Args[2:])
+		case "shelves":
+			runShelves(conn, os.Args[2:])
+		case "recommend":
+			runRecommend(conn, os.Args[2:])
+		case "related":
+			runRelated(conn, os.Args[2:])
+		case "loans":
+			runLoans(conn, os.Args[2:])
+		case "reservations":
+			runReservations(conn, os.Args[2:])
+		case "fines":
+			runFines(conn, os.Args[2:])
+		case "notifications":
+			runNotifications(conn, os.Args[2:])
+		case "version":
+			runVersion(conn, os.Args[2:])
+		case "tenants":
+			runTenants(conn, os.Args[2:])
+		default:
+			log.Fatalf("unknown command %q (available: register, verify-email, login-oidc, refresh, logout, sessions, auth-events, audit-log, scoped-token, change-password, reset-password, profile, export, import, bulk-update, upload-cover, download-cover, lookup, list, get, copies, barcode, acquisitions, locate, series, translations, tags, publishers, reviews, favorites, shelves, recommend, related, loans, reservations, fines, notifications, version, tenants)", os.Args[1])
+		}
+		return
+	}
 
 	client := pb.NewUserServiceClient(conn)
 
@@ -52,10 +176,10 @@ func main() {
 	if err != nil {
 		log.Fatalf("could not login: %v", err)
 	}
-	fmt.Printf("Login Response: %s, Token: %s\n", loginResp.GetMessage(), loginResp.GetToken())
+	fmt.Printf("Login Response: %s, Token: %s, Refresh token: %s\n", loginResp.GetMessage(), loginResp.GetToken(), loginResp.GetRefreshToken())
 
-	// Create authenticated client wrapper
-	authClient := &AuthenticatedClient{token: loginResp.GetToken()}
+	// Every call from here on is authenticated
+	tokens.set(loginResp)
 
 	// Book management tests (all require authentication)
 	libraryClient := pb.NewLibraryServiceClient(conn)
@@ -66,26 +190,43 @@ func main() {
 	}
 
 	// AddBook (with authentication)
-	addResp, err := libraryClient.AddBook(authClient.addAuthToContext(context.Background()), book)
+	addResp, err := libraryClient.AddBook(withIdempotencyKey(context.Background()), book)
 	if err != nil {
 		log.Fatalf("could not add book: %v", err)
 	}
-	fmt.Printf("AddBook Response: %s, ID: %s\n", addResp.GetMessage(), addResp.GetId())
+	fmt.Printf("AddBook Response: book added, ID: %s\n", addResp.GetId())
 
 	// UpdateBook (with authentication)
 	book.Title = "Advanced Go Programming"
-	updateResp, err := libraryClient.UpdateBook(authClient.addAuthToContext(context.Background()), book)
+	updateResp, err := libraryClient.UpdateBook(context.Background(), book)
 	if err != nil {
 		log.Fatalf("could not update book: %v", err)
 	}
-	fmt.Printf("UpdateBook Response: %s, ID: %s\n", updateResp.GetMessage(), updateResp.GetId())
+	fmt.Printf("UpdateBook Response: book updated, ID: %s\n", updateResp.GetId())
 
 	// DeleteBook (with authentication)
-	deleteResp, err := libraryClient.DeleteBook(authClient.addAuthToContext(context.Background()), &pb.BookRequest{Id: book.GetId()})
+	deleteResp, err := libraryClient.DeleteBook(withIdempotencyKey(context.Background()), &pb.BookRequest{Id: book.GetId()})
 	if err != nil {
 		log.Fatalf("could not delete book: %v", err)
 	}
-	fmt.Printf("DeleteBook Response: %s, ID: %s\n", deleteResp.GetMessage(), deleteResp.GetId())
+	fmt.Printf("DeleteBook Response: book deleted, ID: %s\n", deleteResp.GetId())
+
+	// GetBookHistory (server-side streaming with authentication)
+	historyStream, err := libraryClient.GetBookHistory(context.Background(), &pb.BookRequest{Id: book.GetId()})
+	if err != nil {
+		log.Fatalf("could not get book history: %v", err)
+	}
+	fmt.Println("GetBookHistory Response:")
+	for {
+		rev, err := historyStream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			log.Fatalf("failed to receive book revision: %v", err)
+		}
+		fmt.Printf("  Revision %d: %s by %s at %s\n", rev.GetId(), rev.GetAction(), rev.GetUsername(), rev.GetChangedAt().AsTime().Format(time.RFC3339))
+	}
 
 	// Add multiple books for pagination test (with authentication)
 	for i := 1; i <= 10; i++ {
@@ -94,14 +235,14 @@ func main() {
 			Title:  fmt.Sprintf("Book Title %d", i),
 			Author: fmt.Sprintf("Author %d", i),
 		}
-		_, err := libraryClient.AddBook(authClient.addAuthToContext(context.Background()), b)
+		_, err := libraryClient.AddBook(withIdempotencyKey(context.Background()), b)
 		if err != nil {
 			log.Printf("could not add book %d: %v", i, err)
 		}
 	}
 
 	// ListBooks (with authentication)
-	listResp, err := libraryClient.ListBooks(authClient.addAuthToContext(context.Background()), &pb.ListBookRequest{
+	listResp, err := libraryClient.ListBooks(context.Background(), &pb.ListBookRequest{
 		Page:     1,
 		PageSize: 5,
 	})
@@ -113,8 +254,9 @@ func main() {
 		fmt.Printf("Book %d: ID=%s, Title=%s, Author=%s\n", i+1, b.GetId(), b.GetTitle(), b.GetAuthor())
 	}
 
-	// BatchAddBooks (client-side streaming with authentication)
-	batchStream, err := libraryClient.BatchAddBooks(authClient.addAuthToContext(context.Background()))
+	// BatchAddBooks (client-side streaming with authentication), adding all the books or none
+	batchCtx := metadata.AppendToOutgoingContext(withIdempotencyKey(context.Background()), "x-batch-atomic", "true")
+	batchStream, err := libraryClient.BatchAddBooks(batchCtx)
 	if err != nil {
 		log.Fatalf("could not start batch add books: %v", err)
 	}
@@ -134,14 +276,14 @@ func main() {
 	}
 	fmt.Println("BatchAddBooks Response:")
 	for i, r := range batchResp.GetResponses() {
-		fmt.Printf("  Book %d: ID=%s, Message=%s\n", i+1, r.GetId(), r.GetMessage())
+		fmt.Printf("  Book %d: ID=%s, Result=%s\n", i+1, r.GetId(), bookResult(r))
 	}
 
 	fmt.Println("All tests completed successfully!")
 
 	// Test unauthorized access (optional - to demonstrate authentication works)
 	fmt.Println("\n--- Testing unauthorized access ---")
-	_, err = libraryClient.AddBook(context.Background(), &pb.Book{
+	_, err = libraryClient.AddBook(withIdempotencyKey(context.Background()), &pb.Book{
 		Id:     "unauthorized-book",
 		Title:  "This should fail",
 		Author: "Anonymous",
@@ -152,3 +294,143 @@ func main() {
 		fmt.Println("WARNING: Unauthorized request succeeded (this should not happen)")
 	}
 }
+
+// runRefresh exchanges a refresh token for a new access token and refresh token
+func runRefresh(conn *grpc.ClientConn, args []string) {
+	if len(args) != 1 {
+		log.Fatal("usage: refresh REFRESH_TOKEN")
+	}
+	resp, err := pb.NewUserServiceClient(conn).RefreshToken(context.Background(), &pb.RefreshTokenRequest{RefreshToken: args[0]})
+	if err != nil {
+		log.Fatalf("could not refresh token: %v", err)
+	}
+	fmt.Printf("RefreshToken Response: %s, Token: %s, Expires: %s\n",
+		resp.GetMessage(), resp.GetToken(), resp.GetExpiresAt().AsTime().Format(time.RFC3339))
+	fmt.Printf("New refresh token: %s (expires %s)\n",
+		resp.GetRefreshToken(), resp.GetRefreshExpiresAt().AsTime().Format(time.RFC3339))
+}
+
+// runLoginOIDC exchanges an ID token from the configured OpenID Connect provider for the service's own tokens
+func runLoginOIDC(conn *grpc.ClientConn, args []string) {
+	if len(args) != 1 {
+		log.Fatal("usage: login-oidc ID_TOKEN")
+	}
+	resp, err := pb.NewUserServiceClient(conn).LoginWithIdToken(context.Background(), &pb.IdTokenLoginRequest{IdToken: args[0]})
+	if err != nil {
+		log.Fatalf("could not log in: %v", err)
+	}
+	fmt.Printf("LoginWithIdToken Response: %s, Token: %s, Expires: %s\n",
+		resp.GetMessage(), resp.GetToken(), resp.GetExpiresAt().AsTime().Format(time.RFC3339))
+	fmt.Printf("Refresh token: %s (expires %s)\n",
+		resp.GetRefreshToken(), resp.GetRefreshExpiresAt().AsTime().Format(time.RFC3339))
+}
+
+// runLogout signs in and then revokes that session, or every session of the user with --all
+func runLogout(conn *grpc.ClientConn, args []string) {
+	fs := flag.NewFlagSet("logout", flag.ExitOnError)
+	username := fs.String("username", "testUser", "Username to authenticate with")
+	password := fs.String("password", "password123", "Password to authenticate with")
+	all := fs.Bool("all", false, "Log out everywhere, revoking every token issued so far")
+	fs.Parse(args)
+
+	userClient := pb.NewUserServiceClient(conn)
+	loginResp, err := userClient.Login(context.Background(), &pb.UserCredentials{Username: *username, Password: *password})
+	if err != nil {
+		log.Fatalf("could not login: %v", err)
+	}
+	tokens.set(loginResp)
+	ctx := context.Background()
+
+	var resp *pb.LogoutResponse
+	if *all {
+		resp, err = userClient.RevokeAllSessions(ctx, &pb.RevokeAllSessionsRequest{})
+	} else {
+		resp, err = userClient.Logout(ctx, &pb.LogoutRequest{RefreshToken: loginResp.GetRefreshToken()})
+	}
+	if err != nil {
+		log.Fatalf("could not log out: %v", err)
+	}
+	fmt.Printf("Logout Response: %s, revoked refresh tokens: %d\n", resp.GetMessage(), resp.GetRevokedRefreshTokens())
+}
+
+const resetPasswordUsage = "usage: reset-password request USERNAME | reset-password confirm TOKEN NEW_PASSWORD"
+
+// runResetPassword requests a password reset token or sets a new password with one
+func runResetPassword(conn *grpc.ClientConn, args []string) {
+	userClient := pb.NewUserServiceClient(conn)
+	var (
+		resp *pb.PasswordResetResponse
+		err  error
+	)
+	switch {
+	case len(args) == 2 && args[0] == "request":
+		resp, err = userClient.RequestPasswordReset(context.Background(), &pb.PasswordResetRequest{Username: args[1]})
+	case len(args) == 3 && args[0] == "confirm":
+		resp, err = userClient.ConfirmPasswordReset(context.Background(), &pb.ConfirmPasswordResetRequest{Token: args[1], NewPassword: args[2]})
+	default:
+		log.Fatal(resetPasswordUsage)
+	}
+	if err != nil {
+		log.Fatalf("could not reset password: %v", err)
+	}
+	fmt.Printf("PasswordReset Response: %s\n", resp.GetMessage())
+}
+
+// runChangePassword logs in with the current password and replaces it; other sessions are logged out
+func runChangePassword(conn *grpc.ClientConn, args []string) {
+	fs := flag.NewFlagSet("change-password", flag.ExitOnError)
+	username := fs.String("username", "testUser", "Username to authenticate with")
+	password := fs.String("password", "password123", "Current password")
+	newPassword := fs.String("new-password", "", "New password; it must differ from the recent ones")
+	fs.Parse(args)
+	if *newPassword == "" {
+		log.Fatal("usage: change-password -username USER -password CURRENT -new-password NEW")
+	}
+
+	if err := login(conn, *username, *password); err != nil {
+		log.Fatalf("could not login: %v", err)
+	}
+	ctx := context.Background()
+
+	resp, err := pb.NewUserServiceClient(conn).ChangePassword(ctx, &pb.ChangePasswordRequest{
+		CurrentPassword: *password,
+		NewPassword:     *newPassword,
+	})
+	if err != nil {
+		log.Fatalf("could not change password: %v", err)
+	}
+	fmt.Printf("ChangePassword Response: %s, other sessions logged out: %d\n", resp.GetMessage(), resp.GetRevokedSessions())
+}
+
+// runRegister creates an account, optionally with an email address to verify
+func runRegister(conn *grpc.ClientConn, args []string) {
+	fs := flag.NewFlagSet("register", flag.ExitOnError)
+	username := fs.String("username", "testUser", "Username for the new account")
+	password := fs.String("password", "password123", "Password for the new account")
+	email := fs.String("email", "", "Email address; a verification token is sent to it")
+	challenge := fs.String("challenge-token", "", "Bot challenge response token, when the server requires one")
+	fs.Parse(args)
+
+	resp, err := pb.NewUserServiceClient(conn).Register(context.Background(), &pb.User{
+		Username:       *username,
+		Password:       *password,
+		Email:          *email,
+		ChallengeToken: *challenge,
+	})
+	if err != nil {
+		log.Fatalf("could not register: %v", err)
+	}
+	fmt.Printf("Register Response: %s, Token: %s\n", resp.GetMessage(), resp.GetToken())
+}
+
+// runVerifyEmail confirms an email address with the token sent to it
+func runVerifyEmail(conn *grpc.ClientConn, args []string) {
+	if len(args) != 1 {
+		log.Fatal("usage: verify-email TOKEN")
+	}
+	resp, err := pb.NewUserServiceClient(conn).VerifyEmail(context.Background(), &pb.VerifyEmailRequest{Token: args[0]})
+	if err != nil {
+		log.Fatalf("could not verify email: %v", err)
+	}
+	fmt.Printf("VerifyEmail Response: %s, Email: %s\n", resp.GetMessage(), resp.GetEmail())
+}
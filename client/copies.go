@@ -0,0 +1,193 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	pb "example/grpc_demo/library"
+
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const copiesUsage = "usage: copies list BOOK | copies add BOOK"
+
+// runCopies registers and lists the barcoded physical copies of a book
+func runCopies(conn *grpc.ClientConn, args []string) {
+	fs := flag.NewFlagSet("copies", flag.ExitOnError)
+	username := fs.String("username", "testUser", "Username to authenticate with")
+	password := fs.String("password", "password123", "Password to authenticate with")
+	code := fs.String("barcode", "", "Barcode for the new copy (generated when empty)")
+	condition := fs.String("condition", "", "Condition of the new copy: new, good, fair, poor or damaged")
+	priceCents := fs.Int64("price-cents", 0, "Purchase price of the new copy in cents")
+	acquired := fs.String("acquired", "", "Acquisition date of the new copy (YYYY-MM-DD, default today)")
+	donor := fs.String("donor", "", "Who donated the new copy")
+	fs.Parse(args)
+
+	if fs.NArg() != 2 {
+		log.Fatal(copiesUsage)
+	}
+
+	if err := login(conn, *username, *password); err != nil {
+		log.Fatalf("could not login: %v", err)
+	}
+	ctx := context.Background()
+	libraryClient := pb.NewLibraryServiceClient(conn)
+
+	switch fs.Arg(0) {
+	case "list":
+		resp, err := libraryClient.ListCopies(ctx, &pb.BookRequest{Id: fs.Arg(1)})
+		if err != nil {
+			log.Fatalf("could not list copies: %v", err)
+		}
+		fmt.Printf("ListCopies Response: %d copies\n", len(resp.GetCopies()))
+		for _, c := range resp.GetCopies() {
+			fmt.Printf("  Copy %d: %s", c.GetId(), c.GetBarcode())
+			printAcquisition(c)
+		}
+	case "add":
+		req := &pb.CopyRequest{
+			BookId:     fs.Arg(1),
+			Barcode:    *code,
+			Condition:  parseCondition(*condition),
+			PriceCents: *priceCents,
+			AcquiredAt: parseDate("acquired", *acquired),
+			Donor:      *donor,
+		}
+		c, err := libraryClient.AddCopy(ctx, req)
+		if err != nil {
+			log.Fatalf("could not add copy: %v", err)
+		}
+		fmt.Printf("AddCopy Response: barcode %s, ID: %d\n", c.GetBarcode(), c.GetId())
+	default:
+		log.Fatal(copiesUsage)
+	}
+}
+
+const barcodeUsage = "usage: barcode lookup CODE | barcode image CODE"
+
+// runBarcode finds the book behind a scanned barcode or renders a barcode label
+func runBarcode(conn *grpc.ClientConn, args []string) {
+	fs := flag.NewFlagSet("barcode", flag.ExitOnError)
+	username := fs.String("username", "testUser", "Username to authenticate with")
+	password := fs.String("password", "password123", "Password to authenticate with")
+	out := fs.String("out", "barcode.png", "Output file for the rendered label")
+	ean13 := fs.Bool("ean13", false, "Render as EAN-13 instead of Code 128")
+	fs.Parse(args)
+
+	if fs.NArg() != 2 {
+		log.Fatal(barcodeUsage)
+	}
+
+	if err := login(conn, *username, *password); err != nil {
+		log.Fatalf("could not login: %v", err)
+	}
+	ctx := context.Background()
+	libraryClient := pb.NewLibraryServiceClient(conn)
+
+	switch fs.Arg(0) {
+	case "lookup":
+		b, err := libraryClient.GetBookByBarcode(ctx, &pb.BarcodeRequest{Barcode: fs.Arg(1)})
+		if err != nil {
+			log.Fatalf("could not look up barcode: %v", err)
+		}
+		fmt.Printf("ID=%s, Title=%s, Author=%s", b.GetId(), b.GetTitle(), b.GetAuthor())
+		printBookDetails(b)
+	case "image":
+		req := &pb.BarcodeRequest{Barcode: fs.Arg(1)}
+		if *ean13 {
+			req.Symbology = pb.BarcodeSymbology_BARCODE_SYMBOLOGY_EAN13
+		}
+		img, err := libraryClient.GetBarcodeImage(ctx, req)
+		if err != nil {
+			log.Fatalf("could not render barcode: %v", err)
+		}
+		if err := os.WriteFile(*out, img.GetData(), 0o644); err != nil {
+			log.Fatalf("could not write %s: %v", *out, err)
+		}
+		fmt.Printf("Wrote %s label for %s to %s (%d bytes)\n", img.GetContentType(), img.GetBarcode(), *out, len(img.GetData()))
+	default:
+		log.Fatal(barcodeUsage)
+	}
+}
+
+// runAcquisitions prints the inventory report of copies acquired in a date range
+func runAcquisitions(conn *grpc.ClientConn, args []string) {
+	fs := flag.NewFlagSet("acquisitions", flag.ExitOnError)
+	username := fs.String("username", "testUser", "Username to authenticate with")
+	password := fs.String("password", "password123", "Password to authenticate with")
+	from := fs.String("from", "", "Only copies acquired on or after this date (YYYY-MM-DD)")
+	to := fs.String("to", "", "Only copies acquired before this date (YYYY-MM-DD)")
+	condition := fs.String("condition", "", "Only copies in this condition")
+	donated := fs.Bool("donated", false, "Only donated copies")
+	page := fs.Int("page", 1, "Page number")
+	pageSize := fs.Int("page-size", 10, "Copies per page")
+	fs.Parse(args)
+
+	if err := login(conn, *username, *password); err != nil {
+		log.Fatalf("could not login: %v", err)
+	}
+
+	libraryClient := pb.NewLibraryServiceClient(conn)
+	resp, err := libraryClient.ListAcquisitions(context.Background(), &pb.ListAcquisitionsRequest{
+		AcquiredFrom: parseDate("from", *from),
+		AcquiredTo:   parseDate("to", *to),
+		Condition:    parseCondition(*condition),
+		DonatedOnly:  *donated,
+		Page:         int32(*page),
+		PageSize:     int32(*pageSize),
+	})
+	if err != nil {
+		log.Fatalf("could not list acquisitions: %v", err)
+	}
+	fmt.Printf("ListAcquisitions Response: total=%d, spent=%s, donated=%d\n",
+		resp.GetTotalCount(), formatCents(resp.GetTotalPriceCents()), resp.GetDonatedCount())
+	for _, a := range resp.GetAcquisitions() {
+		c := a.GetCopy()
+		fmt.Printf("  Copy %d: %s, Book=%s (%s by %s)", c.GetId(), c.GetBarcode(), c.GetBookId(), a.GetTitle(), a.GetAuthor())
+		printAcquisition(c)
+	}
+}
+
+// printAcquisition finishes a copy line with its acquisition details
+func printAcquisition(c *pb.BookCopy) {
+	fmt.Printf(", Acquired=%s", c.GetAcquiredAt().AsTime().Format(time.DateOnly))
+	if c.GetCondition() != pb.CopyCondition_COPY_CONDITION_UNSPECIFIED {
+		fmt.Printf(", Condition=%s", strings.ToLower(strings.TrimPrefix(c.GetCondition().String(), "COPY_CONDITION_")))
+	}
+	if c.GetDonor() != "" {
+		fmt.Printf(", Donor=%s", c.GetDonor())
+	} else if c.GetPriceCents() > 0 {
+		fmt.Printf(", Price=%s", formatCents(c.GetPriceCents()))
+	}
+	fmt.Println()
+}
+
+// parseCondition converts a condition flag such as "good" into its enum value
+func parseCondition(name string) pb.CopyCondition {
+	if name == "" {
+		return pb.CopyCondition_COPY_CONDITION_UNSPECIFIED
+	}
+	cond, ok := pb.CopyCondition_value["COPY_CONDITION_"+strings.ToUpper(name)]
+	if !ok {
+		log.Fatalf("unknown condition %q (want new, good, fair, poor or damaged)", name)
+	}
+	return pb.CopyCondition(cond)
+}
+
+// parseDate converts a YYYY-MM-DD flag into a timestamp at midnight UTC, or nil when empty
+func parseDate(flagName, value string) *timestamppb.Timestamp {
+	if value == "" {
+		return nil
+	}
+	t, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		log.Fatalf("invalid --%s date %q (want YYYY-MM-DD)", flagName, value)
+	}
+	return timestamppb.New(t)
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"os"
+	"path/filepath"
+
+	pb "example/grpc_demo/library"
+
+	"google.golang.org/grpc"
+)
+
+// coverChunkSize is the number of image bytes sent per streamed message
+const coverChunkSize = 64 * 1024
+
+// runUploadCover streams an image file to the server as a book cover
+func runUploadCover(conn *grpc.ClientConn, args []string) {
+	fs := flag.NewFlagSet("upload-cover", flag.ExitOnError)
+	username := fs.String("username", "testUser", "Username to authenticate with")
+	password := fs.String("password", "password123", "Password to authenticate with")
+	bookID := fs.String("book", "", "ID of the book the cover belongs to")
+	file := fs.String("file", "", "Path to the cover image")
+	fs.Parse(args)
+
+	if *bookID == "" || *file == "" {
+		log.Fatal("both --book and --file are required")
+	}
+
+	f, err := os.Open(*file)
+	if err != nil {
+		log.Fatalf("could not open cover image: %v", err)
+	}
+	defer f.Close()
+
+	if err := login(conn, *username, *password); err != nil {
+		log.Fatalf("could not login: %v", err)
+	}
+
+	libraryClient := pb.NewLibraryServiceClient(conn)
+	stream, err := libraryClient.UploadCover(context.Background())
+	if err != nil {
+		log.Fatalf("could not start cover upload: %v", err)
+	}
+
+	// The first chunk carries the book ID and content type
+	first := &pb.CoverChunk{BookId: *bookID, ContentType: mime.TypeByExtension(filepath.Ext(*file))}
+	buf := make([]byte, coverChunkSize)
+	for {
+		n, err := f.Read(buf)
+		if n > 0 {
+			chunk := &pb.CoverChunk{Data: buf[:n]}
+			if first != nil {
+				first.Data = buf[:n]
+				chunk, first = first, nil
+			}
+			if err := stream.Send(chunk); err != nil {
+				log.Fatalf("failed to send cover chunk: %v", err)
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			log.Fatalf("failed to read cover image: %v", err)
+		}
+	}
+
+	resp, err := stream.CloseAndRecv()
+	if err != nil {
+		log.Fatalf("cover upload failed: %v", err)
+	}
+	fmt.Printf("UploadCover Response: cover uploaded, URL: %s, Size: %d bytes\n", resp.GetCoverUrl(), resp.GetSize())
+}
+
+// runDownloadCover streams a book cover from the server into a local file
+func runDownloadCover(conn *grpc.ClientConn, args []string) {
+	fs := flag.NewFlagSet("download-cover", flag.ExitOnError)
+	username := fs.String("username", "testUser", "Username to authenticate with")
+	password := fs.String("password", "password123", "Password to authenticate with")
+	bookID := fs.String("book", "", "ID of the book whose cover to download")
+	out := fs.String("out", "", "Output file for the cover image")
+	fs.Parse(args)
+
+	if *bookID == "" || *out == "" {
+		log.Fatal("both --book and --out are required")
+	}
+
+	if err := login(conn, *username, *password); err != nil {
+		log.Fatalf("could not login: %v", err)
+	}
+
+	libraryClient := pb.NewLibraryServiceClient(conn)
+	stream, err := libraryClient.DownloadCover(context.Background(), &pb.BookRequest{Id: *bookID})
+	if err != nil {
+		log.Fatalf("could not start cover download: %v", err)
+	}
+
+	f, err := os.Create(*out)
+	if err != nil {
+		log.Fatalf("could not create output file: %v", err)
+	}
+	defer f.Close()
+
+	var contentType string
+	var size int
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			log.Fatalf("cover download failed: %v", err)
+		}
+		if chunk.GetContentType() != "" {
+			contentType = chunk.GetContentType()
+		}
+		n, err := f.Write(chunk.GetData())
+		if err != nil {
+			log.Fatalf("failed to write cover: %v", err)
+		}
+		size += n
+	}
+	fmt.Printf("Downloaded cover for %s: %d bytes (%s)\n", *bookID, size, contentType)
+}
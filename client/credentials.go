@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	pb "example/grpc_demo/library"
+
+	"google.golang.org/grpc/credentials"
+)
+
+// tokenRenewMargin is how long before it expires the access token is renewed
+const tokenRenewMargin = 30 * time.Second
+
+// tokens are the credentials of the logged-in user, sent with every call on the connection
+var tokens = &tokenCredentials{}
+
+// tokenCredentials attaches the access token to every unary and streaming call once a user has logged in,
+// renewing it with the refresh token shortly before it expires
+type tokenCredentials struct {
+	mu           sync.Mutex
+	token        string
+	refreshToken string
+	expiresAt    time.Time
+	// users renews the tokens; set once the connection they are used on exists
+	users pb.UserServiceClient
+	// requireTLS refuses to send the token over a connection without TLS
+	requireTLS bool
+}
+
+// set replaces the tokens with those of a Login, LoginWithIdToken or RefreshToken response
+func (c *tokenCredentials) set(resp *pb.AuthResponse) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.token, c.refreshToken = resp.GetToken(), resp.GetRefreshToken()
+	c.expiresAt = resp.GetExpiresAt().AsTime()
+}
+
+func (c *tokenCredentials) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	// RefreshToken is authenticated by its refresh token; skipping it also keeps the renewal below from recursing
+	if info, ok := credentials.RequestInfoFromContext(ctx); ok && info.Method == pb.UserService_RefreshToken_FullMethodName {
+		return nil, nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.token == "" {
+		return nil, nil
+	}
+	if c.refreshToken != "" && c.users != nil && time.Until(c.expiresAt) < tokenRenewMargin {
+		resp, err := c.users.RefreshToken(ctx, &pb.RefreshTokenRequest{RefreshToken: c.refreshToken})
+		if err != nil {
+			return nil, fmt.Errorf("could not refresh access token: %w", err)
+		}
+		c.token, c.refreshToken = resp.GetToken(), resp.GetRefreshToken()
+		c.expiresAt = resp.GetExpiresAt().AsTime()
+	}
+	return map[string]string{"authorization": "Bearer " + c.token}, nil
+}
+
+func (c *tokenCredentials) RequireTransportSecurity() bool {
+	return c.requireTLS
+}
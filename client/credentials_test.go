@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	pb "example/grpc_demo/library"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// fakeUsers answers RefreshToken with resp or err, recording the refresh tokens it is given
+type fakeUsers struct {
+	pb.UserServiceClient
+	resp      *pb.AuthResponse
+	err       error
+	refreshed []string
+}
+
+func (f *fakeUsers) RefreshToken(ctx context.Context, req *pb.RefreshTokenRequest, opts ...grpc.CallOption) (*pb.AuthResponse, error) {
+	f.refreshed = append(f.refreshed, req.GetRefreshToken())
+	return f.resp, f.err
+}
+
+func authResponse(token, refreshToken string, expiresIn time.Duration) *pb.AuthResponse {
+	return &pb.AuthResponse{Token: token, RefreshToken: refreshToken, ExpiresAt: timestamppb.New(time.Now().Add(expiresIn))}
+}
+
+func TestTokenCredentials(t *testing.T) {
+	ctx := context.Background()
+	users := &fakeUsers{resp: authResponse("renewed", "refresh-2", time.Hour)}
+	c := &tokenCredentials{users: users}
+
+	// Nothing is sent before logging in
+	if md, err := c.GetRequestMetadata(ctx); md != nil || err != nil {
+		t.Errorf("GetRequestMetadata() before login = %v, %v, want nothing", md, err)
+	}
+
+	c.set(authResponse("access", "refresh-1", time.Hour))
+	md, err := c.GetRequestMetadata(ctx)
+	if err != nil || md["authorization"] != "Bearer access" {
+		t.Errorf("GetRequestMetadata() = %v, %v, want the access token", md, err)
+	}
+	if len(users.refreshed) != 0 {
+		t.Error("a token valid for an hour was refreshed")
+	}
+
+	// A token about to expire is renewed with the refresh token, and the new tokens used from then on
+	c.set(authResponse("access", "refresh-1", tokenRenewMargin/2))
+	for range 2 {
+		md, err = c.GetRequestMetadata(ctx)
+		if err != nil || md["authorization"] != "Bearer renewed" {
+			t.Errorf("GetRequestMetadata() of an expiring token = %v, %v, want the renewed token", md, err)
+		}
+	}
+	if len(users.refreshed) != 1 || users.refreshed[0] != "refresh-1" {
+		t.Errorf("refreshed with %v, want refresh-1 once", users.refreshed)
+	}
+	if c.refreshToken != "refresh-2" {
+		t.Errorf("refresh token = %q after renewal, want refresh-2", c.refreshToken)
+	}
+
+	// RefreshToken calls carry no access token, and renew nothing
+	c.set(authResponse("access", "refresh-1", 0))
+	refreshCall := credentials.NewContextWithRequestInfo(ctx, credentials.RequestInfo{Method: pb.UserService_RefreshToken_FullMethodName})
+	if md, err := c.GetRequestMetadata(refreshCall); md != nil || err != nil {
+		t.Errorf("GetRequestMetadata() of RefreshToken = %v, %v, want nothing", md, err)
+	}
+
+	users.err = errors.New("refresh token revoked")
+	if _, err := c.GetRequestMetadata(ctx); !errors.Is(err, users.err) {
+		t.Errorf("GetRequestMetadata() with a failed renewal error = %v, want %v", err, users.err)
+	}
+
+	// Without a refresh token, or before the connection exists, the token is sent as it is
+	c.set(authResponse("access", "", 0))
+	if md, err := c.GetRequestMetadata(ctx); err != nil || md["authorization"] != "Bearer access" {
+		t.Errorf("GetRequestMetadata() without a refresh token = %v, %v", md, err)
+	}
+	c = &tokenCredentials{}
+	c.set(authResponse("access", "refresh-1", 0))
+	if md, err := c.GetRequestMetadata(ctx); err != nil || md["authorization"] != "Bearer access" {
+		t.Errorf("GetRequestMetadata() without a connection = %v, %v", md, err)
+	}
+}
+
+func TestTokenCredentialsRequireTransportSecurity(t *testing.T) {
+	if (&tokenCredentials{}).RequireTransportSecurity() {
+		t.Error("RequireTransportSecurity() = true without requireTLS")
+	}
+	if !(&tokenCredentials{requireTLS: true}).RequireTransportSecurity() {
+		t.Error("RequireTransportSecurity() = false with requireTLS")
+	}
+}
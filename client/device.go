@@ -0,0 +1,26 @@
+package main
+
+import (
+	"context"
+	"os"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// deviceDialOptions sends LIBRARY_DEVICE_ID as the x-device-id header on every call, so the tokens issued
+// to this client only work when presented with the same ID. Nothing is sent when it is unset.
+func deviceDialOptions() []grpc.DialOption {
+	id := os.Getenv("LIBRARY_DEVICE_ID")
+	if id == "" {
+		return nil
+	}
+	return []grpc.DialOption{
+		grpc.WithChainUnaryInterceptor(func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+			return invoker(metadata.AppendToOutgoingContext(ctx, "x-device-id", id), method, req, reply, cc, opts...)
+		}),
+		grpc.WithChainStreamInterceptor(func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+			return streamer(metadata.AppendToOutgoingContext(ctx, "x-device-id", id), desc, cc, method, opts...)
+		}),
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	pb "example/grpc_demo/library"
+
+	"google.golang.org/grpc"
+)
+
+// runExport streams the whole catalog to a file (or stdout) in CSV or JSON-lines format
+func runExport(conn *grpc.ClientConn, args []string) {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	username := fs.String("username", "testUser", "Username to authenticate with")
+	password := fs.String("password", "password123", "Password to authenticate with")
+	format := fs.String("format", "csv", "Export format: csv or json")
+	out := fs.String("out", "", "Output file (defaults to stdout)")
+	chunkSize := fs.Int("chunk-size", 0, "Books per streamed chunk (server default when 0)")
+	fs.Parse(args)
+
+	var exportFormat pb.ExportFormat
+	switch *format {
+	case "csv":
+		exportFormat = pb.ExportFormat_EXPORT_FORMAT_CSV
+	case "json":
+		exportFormat = pb.ExportFormat_EXPORT_FORMAT_JSON
+	default:
+		log.Fatalf("unsupported export format %q (use csv or json)", *format)
+	}
+
+	if err := login(conn, *username, *password); err != nil {
+		log.Fatalf("could not login: %v", err)
+	}
+
+	var w io.Writer = os.Stdout
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			log.Fatalf("could not create output file: %v", err)
+		}
+		defer f.Close()
+		w = f
+	}
+
+	libraryClient := pb.NewLibraryServiceClient(conn)
+	stream, err := libraryClient.ExportBooks(context.Background(), &pb.ExportRequest{
+		Format:    exportFormat,
+		ChunkSize: int32(*chunkSize),
+	})
+	if err != nil {
+		log.Fatalf("could not start export: %v", err)
+	}
+
+	var total int32
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			log.Fatalf("failed to receive export chunk: %v", err)
+		}
+		if _, err := w.Write(chunk.GetData()); err != nil {
+			log.Fatalf("failed to write export: %v", err)
+		}
+		total += chunk.GetRowCount()
+	}
+	fmt.Fprintf(os.Stderr, "Exported %d books\n", total)
+}
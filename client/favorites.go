@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+
+	pb "example/grpc_demo/library"
+
+	"google.golang.org/grpc"
+)
+
+const favoritesUsage = "usage: favorites list | favorites add BOOK | favorites remove BOOK"
+
+// runFavorites manages the logged-in user's favorite books
+func runFavorites(conn *grpc.ClientConn, args []string) {
+	fs := flag.NewFlagSet("favorites", flag.ExitOnError)
+	username := fs.String("username", "testUser", "Username to authenticate with")
+	password := fs.String("password", "password123", "Password to authenticate with")
+	page := fs.Int("page", 1, "Page number for list")
+	pageSize := fs.Int("page-size", 10, "Books per page for list")
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		log.Fatal(favoritesUsage)
+	}
+
+	if err := login(conn, *username, *password); err != nil {
+		log.Fatalf("could not login: %v", err)
+	}
+	ctx := context.Background()
+	favoriteClient := pb.NewFavoriteServiceClient(conn)
+
+	switch sub := fs.Arg(0); {
+	case sub == "list":
+		resp, err := favoriteClient.ListFavorites(ctx, &pb.ListFavoritesRequest{Page: int32(*page), PageSize: int32(*pageSize)})
+		if err != nil {
+			log.Fatalf("could not list favorites: %v", err)
+		}
+		fmt.Printf("ListFavorites Response: total=%d\n", resp.GetTotalCount())
+		for i, b := range resp.GetBooks() {
+			fmt.Printf("Book %d: ID=%s, Title=%s, Author=%s", i+1, b.GetId(), b.GetTitle(), b.GetAuthor())
+			printBookDetails(b)
+		}
+	case sub == "add" && fs.NArg() == 2:
+		resp, err := favoriteClient.AddFavorite(ctx, &pb.FavoriteRequest{BookId: fs.Arg(1)})
+		if err != nil {
+			log.Fatalf("could not add favorite: %v", err)
+		}
+		fmt.Printf("AddFavorite Response: book added to favorites, ID: %s\n", resp.GetId())
+	case sub == "remove" && fs.NArg() == 2:
+		resp, err := favoriteClient.RemoveFavorite(ctx, &pb.FavoriteRequest{BookId: fs.Arg(1)})
+		if err != nil {
+			log.Fatalf("could not remove favorite: %v", err)
+		}
+		fmt.Printf("RemoveFavorite Response: book removed from favorites, ID: %s\n", resp.GetId())
+	default:
+		log.Fatal(favoritesUsage)
+	}
+}
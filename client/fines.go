@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"strconv"
+
+	pb "example/grpc_demo/library"
+
+	"google.golang.org/grpc"
+)
+
+const finesUsage = "usage: fines list | fines pay FINE_ID"
+
+// runFines lists and pays the logged-in user's overdue fines
+func runFines(conn *grpc.ClientConn, args []string) {
+	fs := flag.NewFlagSet("fines", flag.ExitOnError)
+	username := fs.String("username", "testUser", "Username to authenticate with")
+	password := fs.String("password", "password123", "Password to authenticate with")
+	amount := fs.Int64("amount", 0, "Amount to pay in cents (remaining balance when 0)")
+	all := fs.Bool("all", false, "Include fines that have been paid in full when listing")
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		log.Fatal(finesUsage)
+	}
+
+	if err := login(conn, *username, *password); err != nil {
+		log.Fatalf("could not login: %v", err)
+	}
+	ctx := context.Background()
+	loanClient := pb.NewLoanServiceClient(conn)
+
+	switch sub := fs.Arg(0); {
+	case sub == "list":
+		resp, err := loanClient.ListMyFines(ctx, &pb.ListFinesRequest{IncludePaid: *all})
+		if err != nil {
+			log.Fatalf("could not list fines: %v", err)
+		}
+		fmt.Printf("ListMyFines Response: outstanding=%s\n", formatCents(resp.GetOutstandingCents()))
+		for _, f := range resp.GetFines() {
+			printFine(f)
+		}
+	case sub == "pay" && fs.NArg() == 2:
+		id, err := strconv.ParseInt(fs.Arg(1), 10, 64)
+		if err != nil {
+			log.Fatalf("invalid fine ID %q: %v", fs.Arg(1), err)
+		}
+		fine, err := loanClient.PayFine(ctx, &pb.PayFineRequest{Id: id, AmountCents: *amount})
+		if err != nil {
+			log.Fatalf("could not pay fine: %v", err)
+		}
+		printFine(fine)
+	default:
+		log.Fatal(finesUsage)
+	}
+}
+
+// printFine prints one fine line
+func printFine(f *pb.Fine) {
+	fmt.Printf("  Fine %d: %s (%s), %d day(s) late, %s, paid %s",
+		f.GetId(), f.GetBookTitle(), f.GetBookId(), f.GetDaysOverdue(), formatCents(f.GetAmountCents()), formatCents(f.GetPaidCents()))
+	if f.GetPaidAt() != nil {
+		fmt.Print(" [settled]")
+	}
+	fmt.Println()
+}
+
+// formatCents renders an amount in cents as a decimal string
+func formatCents(cents int64) string {
+	return fmt.Sprintf("%d.%02d", cents/100, cents%100)
+}
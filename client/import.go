@@ -0,0 +1,73 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	pb "example/grpc_demo/library"
+
+	"google.golang.org/grpc"
+)
+
+// runImport streams a CSV or JSON-lines file to the server line by line
+func runImport(conn *grpc.ClientConn, args []string) {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	username := fs.String("username", "testUser", "Username to authenticate with")
+	password := fs.String("password", "password123", "Password to authenticate with")
+	format := fs.String("format", "csv", "Import format: csv or json")
+	in := fs.String("in", "", "Input file (defaults to stdin)")
+	fs.Parse(args)
+
+	var importFormat pb.ExportFormat
+	switch *format {
+	case "csv":
+		importFormat = pb.ExportFormat_EXPORT_FORMAT_CSV
+	case "json":
+		importFormat = pb.ExportFormat_EXPORT_FORMAT_JSON
+	default:
+		log.Fatalf("unsupported import format %q (use csv or json)", *format)
+	}
+
+	if err := login(conn, *username, *password); err != nil {
+		log.Fatalf("could not login: %v", err)
+	}
+
+	r := os.Stdin
+	if *in != "" {
+		f, err := os.Open(*in)
+		if err != nil {
+			log.Fatalf("could not open input file: %v", err)
+		}
+		defer f.Close()
+		r = f
+	}
+
+	libraryClient := pb.NewLibraryServiceClient(conn)
+	stream, err := libraryClient.ImportBooks(context.Background())
+	if err != nil {
+		log.Fatalf("could not start import: %v", err)
+	}
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		if err := stream.Send(&pb.ImportRequest{Format: importFormat, Line: scanner.Text()}); err != nil {
+			log.Fatalf("failed to send import line: %v", err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		log.Fatalf("failed to read input: %v", err)
+	}
+
+	resp, err := stream.CloseAndRecv()
+	if err != nil {
+		log.Fatalf("failed to receive import summary: %v", err)
+	}
+	fmt.Printf("Imported %d books, skipped %d duplicates, %d failed\n", resp.GetInserted(), resp.GetSkipped(), resp.GetFailed())
+	for _, f := range resp.GetFailures() {
+		fmt.Printf("  Line %d (ID=%s): %s\n", f.GetLineNumber(), f.GetId(), f.GetReason())
+	}
+}
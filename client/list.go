@@ -0,0 +1,215 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"strings"
+
+	pb "example/grpc_demo/library"
+
+	"google.golang.org/grpc"
+)
+
+// runList prints a page of books, optionally filtered
+func runList(conn *grpc.ClientConn, args []string) {
+	fs := flag.NewFlagSet("list", flag.ExitOnError)
+	username := fs.String("username", "testUser", "Username to authenticate with")
+	password := fs.String("password", "password123", "Password to authenticate with")
+	page := fs.Int("page", 1, "Page number")
+	pageSize := fs.Int("page-size", 10, "Books per page")
+	tags := fs.String("tags", "", "Comma-separated tags the books must all carry")
+	locale := fs.String("locale", "", "Preferred language for titles, e.g. pt-BR")
+	branch := fs.String("branch", "", "Only list books shelved at this branch")
+	section := fs.String("section", "", "Only list books shelved in this section")
+	publisher := fs.Int("publisher", 0, "Only list books from this publisher ID")
+	author := fs.String("author", "", "Only list books by this author")
+	newest := fs.Bool("newest", false, "List the most recently added books first")
+	exactCount := fs.Bool("exact-count", false, "Count every book for the total rather than estimating a large catalog")
+	pageToken := fs.String("page-token", "", "Token of the page to list, printed with the page before; replaces --page")
+	fs.Parse(args)
+
+	if err := login(conn, *username, *password); err != nil {
+		log.Fatalf("could not login: %v", err)
+	}
+
+	req := &pb.ListBookRequest{Page: int32(*page), PageSize: int32(*pageSize), Locale: *locale, Branch: *branch, Section: *section,
+		PublisherId: int32(*publisher), Author: *author, NewestFirst: *newest, ExactCount: *exactCount, PageToken: *pageToken}
+	if *tags != "" {
+		req.Tags = strings.Split(*tags, ",")
+	}
+
+	libraryClient := pb.NewLibraryServiceClient(conn)
+	resp, err := libraryClient.ListBooks(context.Background(), req)
+	if err != nil {
+		log.Fatalf("could not list books: %v", err)
+	}
+	if resp.GetTotalCountEstimated() {
+		fmt.Printf("ListBooks Response: total=~%d (estimated)\n", resp.GetTotalCount())
+	} else {
+		fmt.Printf("ListBooks Response: total=%d\n", resp.GetTotalCount())
+	}
+	for i, b := range resp.GetBooks() {
+		fmt.Printf("Book %d: ID=%s, Title=%s, Author=%s", i+1, b.GetId(), b.GetTitle(), b.GetAuthor())
+		printBookDetails(b)
+	}
+	if resp.GetNextPageToken() != "" {
+		fmt.Printf("Next page: --page-token=%s\n", resp.GetNextPageToken())
+	}
+}
+
+// runSearch prints the books best matching words of their title, author or description
+func runSearch(conn *grpc.ClientConn, args []string) {
+	fs := flag.NewFlagSet("search", flag.ExitOnError)
+	username := fs.String("username", "testUser", "Username to authenticate with")
+	password := fs.String("password", "password123", "Password to authenticate with")
+	page := fs.Int("page", 1, "Page number")
+	pageSize := fs.Int("page-size", 10, "Books per page")
+	locale := fs.String("locale", "", "Preferred language for titles, e.g. pt-BR")
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		log.Fatal("usage: search WORDS...")
+	}
+
+	if err := login(conn, *username, *password); err != nil {
+		log.Fatalf("could not login: %v", err)
+	}
+
+	libraryClient := pb.NewLibraryServiceClient(conn)
+	resp, err := libraryClient.SearchBooks(context.Background(), &pb.SearchBooksRequest{
+		Query: strings.Join(fs.Args(), " "), Page: int32(*page), PageSize: int32(*pageSize), Locale: *locale})
+	if err != nil {
+		log.Fatalf("could not search books: %v", err)
+	}
+	fmt.Printf("SearchBooks Response: total=%d\n", resp.GetTotalCount())
+	for i, b := range resp.GetBooks() {
+		fmt.Printf("Book %d: ID=%s, Title=%s, Author=%s", i+1, b.GetId(), b.GetTitle(), b.GetAuthor())
+		printBookDetails(b)
+	}
+}
+
+// runGet prints a single book
+func runGet(conn *grpc.ClientConn, args []string) {
+	fs := flag.NewFlagSet("get", flag.ExitOnError)
+	username := fs.String("username", "testUser", "Username to authenticate with")
+	password := fs.String("password", "password123", "Password to authenticate with")
+	locale := fs.String("locale", "", "Preferred language for the title, e.g. pt-BR")
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		log.Fatal("usage: get BOOK_ID")
+	}
+
+	if err := login(conn, *username, *password); err != nil {
+		log.Fatalf("could not login: %v", err)
+	}
+
+	libraryClient := pb.NewLibraryServiceClient(conn)
+	b, err := libraryClient.GetBook(context.Background(), &pb.BookRequest{Id: fs.Arg(0), Locale: *locale})
+	if err != nil {
+		log.Fatalf("could not get book: %v", err)
+	}
+	fmt.Printf("ID=%s, Title=%s, Author=%s", b.GetId(), b.GetTitle(), b.GetAuthor())
+	printBookDetails(b)
+	if b.GetDescription() != "" {
+		fmt.Printf("  %s\n", b.GetDescription())
+	}
+}
+
+// runSeries prints the books of a series in volume order
+func runSeries(conn *grpc.ClientConn, args []string) {
+	fs := flag.NewFlagSet("series", flag.ExitOnError)
+	username := fs.String("username", "testUser", "Username to authenticate with")
+	password := fs.String("password", "password123", "Password to authenticate with")
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		log.Fatal("usage: series NAME")
+	}
+
+	if err := login(conn, *username, *password); err != nil {
+		log.Fatalf("could not login: %v", err)
+	}
+
+	libraryClient := pb.NewLibraryServiceClient(conn)
+	resp, err := libraryClient.ListBooksInSeries(context.Background(), &pb.SeriesRequest{Name: fs.Arg(0)})
+	if err != nil {
+		log.Fatalf("could not list series: %v", err)
+	}
+	fmt.Printf("ListBooksInSeries Response: total=%d\n", resp.GetTotalCount())
+	for _, b := range resp.GetBooks() {
+		fmt.Printf("Volume %d: ID=%s, Title=%s, Author=%s", b.GetSeries().GetVolume(), b.GetId(), b.GetTitle(), b.GetAuthor())
+		printBookDetails(b)
+	}
+}
+
+// runLocate prints where a book's physical copies are shelved
+func runLocate(conn *grpc.ClientConn, args []string) {
+	fs := flag.NewFlagSet("locate", flag.ExitOnError)
+	username := fs.String("username", "testUser", "Username to authenticate with")
+	password := fs.String("password", "password123", "Password to authenticate with")
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		log.Fatal("usage: locate BOOK_ID")
+	}
+
+	if err := login(conn, *username, *password); err != nil {
+		log.Fatalf("could not login: %v", err)
+	}
+
+	libraryClient := pb.NewLibraryServiceClient(conn)
+	loc, err := libraryClient.FindBookLocation(context.Background(), &pb.BookRequest{Id: fs.Arg(0)})
+	if err != nil {
+		log.Fatalf("could not locate book: %v", err)
+	}
+	where := "not recorded"
+	if loc.GetLocation() != nil {
+		where = formatLocation(loc.GetLocation())
+	}
+	fmt.Printf("FindBookLocation Response: %s (%s) at %s, %d of %d copies on hand\n",
+		loc.GetTitle(), loc.GetBookId(), where, loc.GetAvailableCopies(), loc.GetTotalCopies())
+}
+
+// formatLocation renders the recorded parts of a location as branch / section / shelf
+func formatLocation(loc *pb.Location) string {
+	var parts []string
+	for _, p := range []string{loc.GetBranch(), loc.GetSection(), loc.GetShelf()} {
+		if p != "" {
+			parts = append(parts, p)
+		}
+	}
+	return strings.Join(parts, " / ")
+}
+
+// printBookDetails finishes a book line with its optional attributes
+func printBookDetails(b *pb.Book) {
+	if b.GetPublisher() != "" {
+		fmt.Printf(", Publisher=%s", b.GetPublisher())
+	}
+	if series := b.GetSeries(); series != nil {
+		if series.GetVolume() > 0 {
+			fmt.Printf(", Series=%s #%d", series.GetName(), series.GetVolume())
+		} else {
+			fmt.Printf(", Series=%s", series.GetName())
+		}
+	}
+	if len(b.GetTags()) > 0 {
+		fmt.Printf(", Tags=%s", strings.Join(b.GetTags(), ","))
+	}
+	if b.GetLanguage() != "" {
+		fmt.Printf(", Language=%s", b.GetLanguage())
+	}
+	if loc := b.GetLocation(); loc != nil {
+		fmt.Printf(", Location=%s", formatLocation(loc))
+	}
+	if b.GetTotalCopies() > 0 {
+		fmt.Printf(", Available=%d/%d", b.GetAvailableCopies(), b.GetTotalCopies())
+	}
+	if b.GetReviewCount() > 0 {
+		fmt.Printf(", Rating=%.1f (%d reviews)", b.GetAverageRating(), b.GetReviewCount())
+	}
+	fmt.Println()
+}
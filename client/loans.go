@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	pb "example/grpc_demo/library"
+
+	"google.golang.org/grpc"
+)
+
+const loansUsage = "usage: loans list | loans borrow BOOK | loans return LOAN_ID"
+
+// runLoans borrows, returns and lists books on loan to the logged-in user
+func runLoans(conn *grpc.ClientConn, args []string) {
+	fs := flag.NewFlagSet("loans", flag.ExitOnError)
+	username := fs.String("username", "testUser", "Username to authenticate with")
+	password := fs.String("password", "password123", "Password to authenticate with")
+	days := fs.Int("days", 0, "Loan period in days when borrowing (server default when 0)")
+	all := fs.Bool("all", false, "Include returned loans when listing")
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		log.Fatal(loansUsage)
+	}
+
+	if err := login(conn, *username, *password); err != nil {
+		log.Fatalf("could not login: %v", err)
+	}
+	ctx := context.Background()
+	loanClient := pb.NewLoanServiceClient(conn)
+
+	switch sub := fs.Arg(0); {
+	case sub == "list":
+		resp, err := loanClient.ListMyLoans(ctx, &pb.ListLoansRequest{IncludeReturned: *all})
+		if err != nil {
+			log.Fatalf("could not list loans: %v", err)
+		}
+		fmt.Printf("ListMyLoans Response: total=%d\n", resp.GetTotalCount())
+		for _, l := range resp.GetLoans() {
+			printLoan(l)
+		}
+	case sub == "borrow" && fs.NArg() == 2:
+		loan, err := loanClient.BorrowBook(ctx, &pb.BorrowRequest{BookId: fs.Arg(1), LoanDays: int32(*days)})
+		if err != nil {
+			log.Fatalf("could not borrow book: %v", err)
+		}
+		printLoan(loan)
+	case sub == "return" && fs.NArg() == 2:
+		id, err := strconv.ParseInt(fs.Arg(1), 10, 64)
+		if err != nil {
+			log.Fatalf("invalid loan ID %q: %v", fs.Arg(1), err)
+		}
+		loan, err := loanClient.ReturnBook(ctx, &pb.LoanRequest{Id: id})
+		if err != nil {
+			log.Fatalf("could not return book: %v", err)
+		}
+		printLoan(loan)
+	default:
+		log.Fatal(loansUsage)
+	}
+}
+
+// printLoan prints one loan line
+func printLoan(l *pb.Loan) {
+	fmt.Printf("  Loan %d: %s (%s), due %s", l.GetId(), l.GetBookTitle(), l.GetBookId(), l.GetDueAt().AsTime().Format(time.DateOnly))
+	if l.GetReturnedAt() != nil {
+		fmt.Printf(", returned %s", l.GetReturnedAt().AsTime().Format(time.DateOnly))
+	}
+	if l.GetOverdue() {
+		fmt.Print(" [overdue]")
+	}
+	fmt.Println()
+}
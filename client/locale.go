@@ -0,0 +1,41 @@
+package main
+
+import (
+	"context"
+	"os"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// clientLanguage is the language to ask the server's messages in: LIBRARY_LANGUAGE (an Accept-Language
+// value such as "pt-BR"), or else the language of the POSIX locale in LANG, e.g. pt_BR.UTF-8
+func clientLanguage() string {
+	if lang := os.Getenv("LIBRARY_LANGUAGE"); lang != "" {
+		return lang
+	}
+	lang, _, _ := strings.Cut(os.Getenv("LANG"), ".")
+	lang, _, _ = strings.Cut(lang, "@")
+	if lang == "" || lang == "C" || lang == "POSIX" {
+		return ""
+	}
+	return strings.ReplaceAll(lang, "_", "-")
+}
+
+// localeDialOptions sends clientLanguage as the accept-language header on every call. Nothing is sent when
+// there is none, and the server answers in English.
+func localeDialOptions() []grpc.DialOption {
+	lang := clientLanguage()
+	if lang == "" {
+		return nil
+	}
+	return []grpc.DialOption{
+		grpc.WithChainUnaryInterceptor(func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+			return invoker(metadata.AppendToOutgoingContext(ctx, "accept-language", lang), method, req, reply, cc, opts...)
+		}),
+		grpc.WithChainStreamInterceptor(func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+			return streamer(metadata.AppendToOutgoingContext(ctx, "accept-language", lang), desc, cc, method, opts...)
+		}),
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+
+	pb "example/grpc_demo/library"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// runLookup fetches metadata for an ISBN and optionally adds it to the catalog
+func runLookup(conn *grpc.ClientConn, args []string) {
+	fs := flag.NewFlagSet("lookup", flag.ExitOnError)
+	username := fs.String("username", "testUser", "Username to authenticate with")
+	password := fs.String("password", "password123", "Password to authenticate with")
+	isbn := fs.String("isbn", "", "ISBN-10 or ISBN-13 to look up")
+	add := fs.Bool("add", false, "Add the book to the catalog under an ID generated by the server")
+	addAs := fs.String("add-as", "", "If set, add the book to the catalog under this ID")
+	allowDuplicate := fs.Bool("allow-duplicate", false, "Add the book even if one with the same ISBN or title and author exists")
+	fs.Parse(args)
+
+	if *isbn == "" {
+		log.Fatal("--isbn is required")
+	}
+
+	if err := login(conn, *username, *password); err != nil {
+		log.Fatalf("could not login: %v", err)
+	}
+
+	libraryClient := pb.NewLibraryServiceClient(conn)
+	book, err := libraryClient.LookupByISBN(context.Background(), &pb.IsbnRequest{Isbn: *isbn})
+	if err != nil {
+		log.Fatalf("could not look up ISBN: %v", err)
+	}
+	fmt.Printf("ISBN %s: Title=%s, Author=%s, Publisher=%s\n", book.GetIsbn(), book.GetTitle(), book.GetAuthor(), book.GetPublisher())
+
+	if !*add && *addAs == "" {
+		return
+	}
+	book.Id = *addAs
+	book.AllowDuplicate = *allowDuplicate
+	resp, err := libraryClient.AddBook(withIdempotencyKey(context.Background()), book)
+	if status.Code(err) == codes.AlreadyExists {
+		for _, d := range status.Convert(err).Details() {
+			if info, ok := d.(*errdetails.ResourceInfo); ok {
+				log.Fatalf("book already in the catalog as %s (%s); rerun with --allow-duplicate to add it anyway", info.GetResourceName(), info.GetDescription())
+			}
+		}
+	}
+	if err != nil {
+		log.Fatalf("could not add book: %v", err)
+	}
+	fmt.Printf("AddBook Response: book added, ID: %s\n", resp.GetId())
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"time"
+
+	pb "example/grpc_demo/library"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// runNotifications prints the logged-in user's notifications as they arrive
+func runNotifications(conn *grpc.ClientConn, args []string) {
+	fs := flag.NewFlagSet("notifications", flag.ExitOnError)
+	username := fs.String("username", "testUser", "Username to authenticate with")
+	password := fs.String("password", "password123", "Password to authenticate with")
+	typeList := fs.String("types", "", "Comma-separated notification types to receive, e.g. hold_ready,loan_overdue (all when empty)")
+	resume := fs.String("resume", "", "Resume token of the last notification received, to get those published since")
+	fs.Parse(args)
+
+	req := &pb.NotificationRequest{ResumeToken: *resume}
+	for _, name := range strings.Split(*typeList, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		t, ok := pb.NotificationType_value["NOTIFICATION_TYPE_"+strings.ToUpper(name)]
+		if !ok {
+			log.Fatalf("unknown notification type %q", name)
+		}
+		req.Types = append(req.Types, pb.NotificationType(t))
+	}
+
+	if err := login(conn, *username, *password); err != nil {
+		log.Fatalf("could not login: %v", err)
+	}
+	ctx := context.Background()
+	client := pb.NewNotificationServiceClient(conn)
+
+	fmt.Println("Subscribed to notifications; press Ctrl+C to stop")
+	backoff := minResubscribeDelay
+	for {
+		err := receiveNotifications(ctx, client, req)
+		if err == nil {
+			return
+		}
+		switch status.Code(err) {
+		case codes.Unavailable, codes.Unknown, codes.Internal, codes.ResourceExhausted:
+		default:
+			log.Fatalf("notification stream failed: %v", err)
+		}
+		// The server replays what was published meanwhile when resuming with the last token
+		log.Printf("notification stream interrupted (%v); reconnecting in %s", err, backoff)
+		time.Sleep(backoff)
+		backoff = min(2*backoff, maxResubscribeDelay)
+	}
+}
+
+const (
+	minResubscribeDelay = time.Second
+	maxResubscribeDelay = time.Minute
+)
+
+// receiveNotifications prints the notifications of one Subscribe stream until it ends, keeping the
+// latest resume token in req so that the next stream picks up where this one stopped
+func receiveNotifications(ctx context.Context, client pb.NotificationServiceClient, req *pb.NotificationRequest) error {
+	stream, err := client.Subscribe(ctx, req)
+	if err != nil {
+		return err
+	}
+	for {
+		n, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if token := n.GetResumeToken(); token != "" {
+			req.ResumeToken = token
+		}
+		switch n.GetType() {
+		case pb.NotificationType_NOTIFICATION_TYPE_KEEPALIVE:
+			continue
+		case pb.NotificationType_NOTIFICATION_TYPE_EVENTS_MISSED:
+			fmt.Println("Some notifications were missed while disconnected")
+			continue
+		}
+		label := strings.ToLower(strings.TrimPrefix(n.GetType().String(), "NOTIFICATION_TYPE_"))
+		fmt.Printf("[%s] %s: %s (book %s)\n", n.GetCreatedAt().AsTime().Local().Format(time.DateTime), label, n.GetMessage(), n.GetBookId())
+	}
+}
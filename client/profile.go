@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"time"
+
+	pb "example/grpc_demo/library"
+
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
+// profileFlagPaths maps the profile flags to the field mask paths they update
+var profileFlagPaths = map[string]string{
+	"display-name":        "display_name",
+	"email":               "email",
+	"language":            "preferences.language",
+	"page-size":           "preferences.page_size",
+	"email-notifications": "preferences.email_notifications",
+}
+
+// runProfile shows the logged-in user's profile, updating only the fields whose flags are set
+func runProfile(conn *grpc.ClientConn, args []string) {
+	fs := flag.NewFlagSet("profile", flag.ExitOnError)
+	username := fs.String("username", "testUser", "Username to authenticate with")
+	password := fs.String("password", "password123", "Password to authenticate with")
+	displayName := fs.String("display-name", "", "New display name")
+	email := fs.String("email", "", "New email address; an empty value removes it")
+	language := fs.String("language", "", "Preferred language tag, e.g. en or pt-BR")
+	pageSize := fs.Int("page-size", 0, "Preferred page size (0 for the server default)")
+	emailNotifications := fs.Bool("email-notifications", false, "Whether to receive notifications by email")
+	fs.Parse(args)
+
+	if fs.NArg() != 0 {
+		log.Fatal("usage: profile [--display-name NAME] [--email ADDRESS] [--language TAG] [--page-size N] [--email-notifications=BOOL]")
+	}
+
+	err := login(conn, *username, *password)
+	if err != nil {
+		log.Fatalf("could not login: %v", err)
+	}
+	ctx := context.Background()
+	userClient := pb.NewUserServiceClient(conn)
+
+	var paths []string
+	fs.Visit(func(f *flag.Flag) {
+		if path, ok := profileFlagPaths[f.Name]; ok {
+			paths = append(paths, path)
+		}
+	})
+
+	var p *pb.Profile
+	if len(paths) == 0 {
+		p, err = userClient.GetProfile(ctx, &pb.GetProfileRequest{})
+	} else {
+		p, err = userClient.UpdateProfile(ctx, &pb.UpdateProfileRequest{
+			Profile: &pb.Profile{
+				DisplayName: *displayName,
+				Email:       *email,
+				Preferences: &pb.ProfilePreferences{
+					Language:           *language,
+					PageSize:           int32(*pageSize),
+					EmailNotifications: *emailNotifications,
+				},
+			},
+			UpdateMask: &fieldmaskpb.FieldMask{Paths: paths},
+		})
+	}
+	if err != nil {
+		log.Fatalf("could not load profile: %v", err)
+	}
+
+	fmt.Printf("Username: %s\n", p.GetUsername())
+	fmt.Printf("Display name: %s\n", p.GetDisplayName())
+	fmt.Printf("Email: %s (verified: %t)\n", p.GetEmail(), p.GetEmailVerified())
+	prefs := p.GetPreferences()
+	fmt.Printf("Preferences: language=%q, page size=%d, email notifications=%t\n", prefs.GetLanguage(), prefs.GetPageSize(), prefs.GetEmailNotifications())
+	if p.GetLastLoginAt() != nil {
+		fmt.Printf("Last login: %s from %s (%d logins)\n", p.GetLastLoginAt().AsTime().Local().Format(time.DateTime), p.GetLastLoginIp(), p.GetLoginCount())
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	pb "example/grpc_demo/library"
+
+	"google.golang.org/grpc"
+)
+
+const publishersUsage = "usage: publishers list | publishers create NAME | publishers rename ID NAME | publishers books ID"
+
+// runPublishers manages publishers: "publishers list", "publishers create NAME",
+// "publishers rename ID NAME", "publishers books ID"
+func runPublishers(conn *grpc.ClientConn, args []string) {
+	fs := flag.NewFlagSet("publishers", flag.ExitOnError)
+	username := fs.String("username", "testUser", "Username to authenticate with")
+	password := fs.String("password", "password123", "Password to authenticate with")
+	website := fs.String("website", "", "Publisher website for create and rename")
+	page := fs.Int("page", 1, "Page number")
+	pageSize := fs.Int("page-size", 10, "Results per page")
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		log.Fatal(publishersUsage)
+	}
+
+	if err := login(conn, *username, *password); err != nil {
+		log.Fatalf("could not login: %v", err)
+	}
+	ctx := context.Background()
+	publisherClient := pb.NewPublisherServiceClient(conn)
+
+	switch sub := fs.Arg(0); {
+	case sub == "list":
+		resp, err := publisherClient.ListPublishers(ctx, &pb.ListPublishersRequest{Page: int32(*page), PageSize: int32(*pageSize)})
+		if err != nil {
+			log.Fatalf("could not list publishers: %v", err)
+		}
+		fmt.Printf("ListPublishers Response: total=%d\n", resp.GetTotalCount())
+		for _, p := range resp.GetPublishers() {
+			fmt.Printf("%d: %s (%d books)", p.GetId(), p.GetName(), p.GetBookCount())
+			if p.GetWebsite() != "" {
+				fmt.Printf(" %s", p.GetWebsite())
+			}
+			fmt.Println()
+		}
+	case sub == "create" && fs.NArg() >= 2:
+		p, err := publisherClient.CreatePublisher(ctx, &pb.Publisher{Name: strings.Join(fs.Args()[1:], " "), Website: *website})
+		if err != nil {
+			log.Fatalf("could not create publisher: %v", err)
+		}
+		fmt.Printf("CreatePublisher Response: ID=%d, Name=%s\n", p.GetId(), p.GetName())
+	case sub == "rename" && fs.NArg() >= 3:
+		p, err := publisherClient.UpdatePublisher(ctx, &pb.Publisher{Id: publisherID(fs.Arg(1)), Name: strings.Join(fs.Args()[2:], " "), Website: *website})
+		if err != nil {
+			log.Fatalf("could not update publisher: %v", err)
+		}
+		fmt.Printf("UpdatePublisher Response: ID=%d, Name=%s, Books=%d\n", p.GetId(), p.GetName(), p.GetBookCount())
+	case sub == "books" && fs.NArg() == 2:
+		resp, err := publisherClient.ListBooksByPublisher(ctx, &pb.PublisherBooksRequest{
+			Id: publisherID(fs.Arg(1)), Page: int32(*page), PageSize: int32(*pageSize),
+		})
+		if err != nil {
+			log.Fatalf("could not list books: %v", err)
+		}
+		fmt.Printf("ListBooksByPublisher Response: total=%d\n", resp.GetTotalCount())
+		for i, b := range resp.GetBooks() {
+			fmt.Printf("Book %d: ID=%s, Title=%s, Author=%s", i+1, b.GetId(), b.GetTitle(), b.GetAuthor())
+			printBookDetails(b)
+		}
+	default:
+		log.Fatal(publishersUsage)
+	}
+}
+
+// publisherID parses a publisher ID argument
+func publisherID(arg string) int32 {
+	id, err := strconv.ParseInt(arg, 10, 32)
+	if err != nil {
+		log.Fatalf("invalid publisher ID %q", arg)
+	}
+	return int32(id)
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+
+	pb "example/grpc_demo/library"
+
+	"google.golang.org/grpc"
+)
+
+// runRecommend prints books suggested from the user's favorites, reviews and shelves
+func runRecommend(conn *grpc.ClientConn, args []string) {
+	fs := flag.NewFlagSet("recommend", flag.ExitOnError)
+	username := fs.String("username", "testUser", "Username to authenticate with")
+	password := fs.String("password", "password123", "Password to authenticate with")
+	limit := fs.Int("limit", 10, "Maximum number of recommendations")
+	fs.Parse(args)
+
+	if err := login(conn, *username, *password); err != nil {
+		log.Fatalf("could not login: %v", err)
+	}
+
+	libraryClient := pb.NewLibraryServiceClient(conn)
+	resp, err := libraryClient.GetRecommendations(context.Background(), &pb.RecommendationRequest{Limit: int32(*limit)})
+	if err != nil {
+		log.Fatalf("could not get recommendations: %v", err)
+	}
+	fmt.Printf("GetRecommendations Response: %d books\n", resp.GetTotalCount())
+	for i, b := range resp.GetBooks() {
+		fmt.Printf("Book %d: ID=%s, Title=%s, Author=%s", i+1, b.GetId(), b.GetTitle(), b.GetAuthor())
+		printBookDetails(b)
+	}
+}
+
+// runRelated prints books by the same author, in the same series or sharing tags with a book
+func runRelated(conn *grpc.ClientConn, args []string) {
+	fs := flag.NewFlagSet("related", flag.ExitOnError)
+	username := fs.String("username", "testUser", "Username to authenticate with")
+	password := fs.String("password", "password123", "Password to authenticate with")
+	limit := fs.Int("limit", 10, "Maximum number of related books")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		log.Fatal("usage: related [--limit=N] BOOK")
+	}
+
+	if err := login(conn, *username, *password); err != nil {
+		log.Fatalf("could not login: %v", err)
+	}
+
+	libraryClient := pb.NewLibraryServiceClient(conn)
+	resp, err := libraryClient.GetRelatedBooks(context.Background(), &pb.BookRequest{Id: fs.Arg(0), Limit: int32(*limit)})
+	if err != nil {
+		log.Fatalf("could not get related books: %v", err)
+	}
+	fmt.Printf("GetRelatedBooks Response: %d books\n", resp.GetTotalCount())
+	for i, b := range resp.GetBooks() {
+		fmt.Printf("Book %d: ID=%s, Title=%s, Author=%s", i+1, b.GetId(), b.GetTitle(), b.GetAuthor())
+		printBookDetails(b)
+	}
+}
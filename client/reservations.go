@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	pb "example/grpc_demo/library"
+
+	"google.golang.org/grpc"
+)
+
+const reservationsUsage = "usage: reservations list | reservations reserve BOOK | reservations cancel RESERVATION_ID"
+
+// runReservations places, cancels and lists holds on books with no copies left
+func runReservations(conn *grpc.ClientConn, args []string) {
+	fs := flag.NewFlagSet("reservations", flag.ExitOnError)
+	username := fs.String("username", "testUser", "Username to authenticate with")
+	password := fs.String("password", "password123", "Password to authenticate with")
+	all := fs.Bool("all", false, "Include fulfilled, cancelled and expired reservations when listing")
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		log.Fatal(reservationsUsage)
+	}
+
+	if err := login(conn, *username, *password); err != nil {
+		log.Fatalf("could not login: %v", err)
+	}
+	ctx := context.Background()
+	loanClient := pb.NewLoanServiceClient(conn)
+
+	switch sub := fs.Arg(0); {
+	case sub == "list":
+		resp, err := loanClient.ListReservations(ctx, &pb.ListReservationsRequest{IncludeClosed: *all})
+		if err != nil {
+			log.Fatalf("could not list reservations: %v", err)
+		}
+		fmt.Printf("ListReservations Response: %d reservation(s)\n", len(resp.GetReservations()))
+		for _, r := range resp.GetReservations() {
+			printReservation(r)
+		}
+	case sub == "reserve" && fs.NArg() == 2:
+		r, err := loanClient.ReserveBook(ctx, &pb.ReserveRequest{BookId: fs.Arg(1)})
+		if err != nil {
+			log.Fatalf("could not reserve book: %v", err)
+		}
+		printReservation(r)
+	case sub == "cancel" && fs.NArg() == 2:
+		id, err := strconv.ParseInt(fs.Arg(1), 10, 64)
+		if err != nil {
+			log.Fatalf("invalid reservation ID %q: %v", fs.Arg(1), err)
+		}
+		r, err := loanClient.CancelReservation(ctx, &pb.ReservationRequest{Id: id})
+		if err != nil {
+			log.Fatalf("could not cancel reservation: %v", err)
+		}
+		printReservation(r)
+	default:
+		log.Fatal(reservationsUsage)
+	}
+}
+
+// printReservation prints one reservation line
+func printReservation(r *pb.Reservation) {
+	fmt.Printf("  Reservation %d: %s (%s), %s", r.GetId(), r.GetBookTitle(), r.GetBookId(), reservationStatusLabel(r.GetStatus()))
+	switch r.GetStatus() {
+	case pb.ReservationStatus_RESERVATION_STATUS_WAITING:
+		fmt.Printf(", position %d", r.GetQueuePosition())
+	case pb.ReservationStatus_RESERVATION_STATUS_READY:
+		fmt.Printf(", pick up by %s", r.GetExpiresAt().AsTime().Format(time.DateOnly))
+	}
+	fmt.Println()
+}
+
+// reservationStatusLabel returns a short lowercase name for a reservation status
+func reservationStatusLabel(st pb.ReservationStatus) string {
+	switch st {
+	case pb.ReservationStatus_RESERVATION_STATUS_WAITING:
+		return "waiting"
+	case pb.ReservationStatus_RESERVATION_STATUS_READY:
+		return "ready"
+	case pb.ReservationStatus_RESERVATION_STATUS_FULFILLED:
+		return "fulfilled"
+	case pb.ReservationStatus_RESERVATION_STATUS_CANCELLED:
+		return "cancelled"
+	case pb.ReservationStatus_RESERVATION_STATUS_EXPIRED:
+		return "expired"
+	default:
+		return "unknown"
+	}
+}
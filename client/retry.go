@@ -0,0 +1,27 @@
+package main
+
+import (
+	_ "embed"
+
+	"google.golang.org/grpc"
+)
+
+// serviceConfig retries calls that are safe to run again when the server is briefly unreachable, such as
+// during a restart or a dropped connection:
+//   - ListBooks and GetBook only read, and are retried on UNAVAILABLE.
+//   - AddBook, DeleteBook and BatchAddBooks change data, and are only safe to retry because every call
+//     carries an idempotency key (see withIdempotencyKey): a retry of a call that did reach the server
+//     gets its original result. They are also retried on ABORTED, which the server returns while the
+//     first attempt is still running.
+//
+// Every other method, UpdateBook and the user and loan methods included, is not retried: running it twice
+// may not give the same result. retryThrottling stops retrying when most calls fail, so an outage doesn't
+// multiply the load on the server.
+//
+//go:embed service_config.json
+var serviceConfig string
+
+// retryDialOptions applies serviceConfig unless the name resolver provides a service config of its own
+func retryDialOptions() []grpc.DialOption {
+	return []grpc.DialOption{grpc.WithDefaultServiceConfig(serviceConfig)}
+}
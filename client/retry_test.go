@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+
+	pb "example/grpc_demo/library"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// flakyLibrary fails the first attempts of every call with code, recording the idempotency key of each attempt
+type flakyLibrary struct {
+	pb.UnimplementedLibraryServiceServer
+	code     codes.Code
+	failures int
+
+	mu       sync.Mutex
+	attempts int
+	keys     []string
+}
+
+// attempt records an attempt and fails it while failures remain
+func (f *flakyLibrary) attempt(ctx context.Context) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.attempts++
+	md, _ := metadata.FromIncomingContext(ctx)
+	f.keys = append(f.keys, md.Get("idempotency-key")...)
+	if f.attempts <= f.failures {
+		return status.Error(f.code, "try again")
+	}
+	return nil
+}
+
+func (f *flakyLibrary) GetBook(ctx context.Context, req *pb.BookRequest) (*pb.Book, error) {
+	if err := f.attempt(ctx); err != nil {
+		return nil, err
+	}
+	return &pb.Book{Id: req.GetId()}, nil
+}
+
+func (f *flakyLibrary) AddBook(ctx context.Context, book *pb.Book) (*pb.BookResponse, error) {
+	if err := f.attempt(ctx); err != nil {
+		return nil, err
+	}
+	return &pb.BookResponse{Id: book.GetId()}, nil
+}
+
+func (f *flakyLibrary) UpdateBook(ctx context.Context, book *pb.Book) (*pb.BookResponse, error) {
+	if err := f.attempt(ctx); err != nil {
+		return nil, err
+	}
+	return &pb.BookResponse{Id: book.GetId()}, nil
+}
+
+// dialFlaky serves library and connects to it with retryDialOptions; each test dials its own connection,
+// as retries are throttled per connection
+func dialFlaky(t *testing.T, library *flakyLibrary) pb.LibraryServiceClient {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := grpc.NewServer()
+	pb.RegisterLibraryServiceServer(s, library)
+	go s.Serve(lis)
+	t.Cleanup(s.Stop)
+
+	opts := append([]grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}, retryDialOptions()...)
+	conn, err := grpc.NewClient(lis.Addr().String(), opts...)
+	if err != nil {
+		t.Fatalf("grpc.NewClient() with the service config error = %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return pb.NewLibraryServiceClient(conn)
+}
+
+func TestServiceConfigRetries(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("reads on UNAVAILABLE", func(t *testing.T) {
+		library := &flakyLibrary{code: codes.Unavailable, failures: 2}
+		if _, err := dialFlaky(t, library).GetBook(ctx, &pb.BookRequest{Id: "b1"}); err != nil || library.attempts != 3 {
+			t.Errorf("GetBook() = %v after %d attempts, want success after 3", err, library.attempts)
+		}
+	})
+
+	t.Run("reads not on other codes", func(t *testing.T) {
+		library := &flakyLibrary{code: codes.Aborted, failures: 1}
+		if _, err := dialFlaky(t, library).GetBook(ctx, &pb.BookRequest{Id: "b1"}); status.Code(err) != codes.Aborted || library.attempts != 1 {
+			t.Errorf("GetBook() = %v after %d attempts, want Aborted after 1", err, library.attempts)
+		}
+	})
+
+	t.Run("keyed mutations with their key", func(t *testing.T) {
+		library := &flakyLibrary{code: codes.Aborted, failures: 2}
+		client := dialFlaky(t, library)
+		if _, err := client.AddBook(withIdempotencyKey(ctx), &pb.Book{Id: "b1"}); err != nil || library.attempts != 3 {
+			t.Fatalf("AddBook() = %v after %d attempts, want success after 3", err, library.attempts)
+		}
+		// Every attempt carries the key of the call, and another call a key of its own
+		if _, err := client.AddBook(withIdempotencyKey(ctx), &pb.Book{Id: "b2"}); err != nil {
+			t.Fatalf("AddBook() error = %v", err)
+		}
+		keys := library.keys
+		if len(keys) != 4 || keys[0] == "" || keys[1] != keys[0] || keys[2] != keys[0] || keys[3] == keys[0] {
+			t.Errorf("idempotency keys of the attempts = %q, want one per call, repeated by its retries", keys)
+		}
+	})
+
+	t.Run("not other mutations", func(t *testing.T) {
+		library := &flakyLibrary{code: codes.Unavailable, failures: 1}
+		if _, err := dialFlaky(t, library).UpdateBook(ctx, &pb.Book{Id: "b1"}); status.Code(err) != codes.Unavailable || library.attempts != 1 {
+			t.Errorf("UpdateBook() = %v after %d attempts, want Unavailable after 1", err, library.attempts)
+		}
+	})
+}
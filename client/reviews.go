@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	pb "example/grpc_demo/library"
+
+	"google.golang.org/grpc"
+)
+
+const reviewsUsage = "usage: reviews list BOOK | reviews add BOOK RATING [COMMENT...] | reviews delete REVIEW_ID"
+
+// runReviews lists, adds and deletes book reviews for the logged-in user
+func runReviews(conn *grpc.ClientConn, args []string) {
+	fs := flag.NewFlagSet("reviews", flag.ExitOnError)
+	username := fs.String("username", "testUser", "Username to authenticate with")
+	password := fs.String("password", "password123", "Password to authenticate with")
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		log.Fatal(reviewsUsage)
+	}
+
+	if err := login(conn, *username, *password); err != nil {
+		log.Fatalf("could not login: %v", err)
+	}
+	ctx := context.Background()
+	reviewClient := pb.NewReviewServiceClient(conn)
+
+	switch sub := fs.Arg(0); {
+	case sub == "list" && fs.NArg() == 2:
+		resp, err := reviewClient.ListReviews(ctx, &pb.ListReviewsRequest{BookId: fs.Arg(1)})
+		if err != nil {
+			log.Fatalf("could not list reviews: %v", err)
+		}
+		fmt.Printf("ListReviews Response: total=%d, average=%.2f\n", resp.GetTotalCount(), resp.GetAverageRating())
+		for _, r := range resp.GetReviews() {
+			fmt.Printf("  Review %d by %s: %d/5 %s\n", r.GetId(), r.GetUsername(), r.GetRating(), r.GetComment())
+		}
+	case sub == "add" && fs.NArg() >= 3:
+		rating, err := strconv.Atoi(fs.Arg(2))
+		if err != nil {
+			log.Fatalf("invalid rating %q: %v", fs.Arg(2), err)
+		}
+		resp, err := reviewClient.AddReview(ctx, &pb.Review{
+			BookId:  fs.Arg(1),
+			Rating:  int32(rating),
+			Comment: strings.Join(fs.Args()[3:], " "),
+		})
+		if err != nil {
+			log.Fatalf("could not add review: %v", err)
+		}
+		fmt.Printf("AddReview Response: review saved, ID: %d\n", resp.GetId())
+	case sub == "delete" && fs.NArg() == 2:
+		id, err := strconv.ParseInt(fs.Arg(1), 10, 64)
+		if err != nil {
+			log.Fatalf("invalid review ID %q: %v", fs.Arg(1), err)
+		}
+		resp, err := reviewClient.DeleteReview(ctx, &pb.ReviewRequest{Id: id})
+		if err != nil {
+			log.Fatalf("could not delete review: %v", err)
+		}
+		fmt.Printf("DeleteReview Response: review deleted, ID: %d\n", resp.GetId())
+	default:
+		log.Fatal(reviewsUsage)
+	}
+}
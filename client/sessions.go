@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	pb "example/grpc_demo/library"
+
+	"google.golang.org/grpc"
+)
+
+const sessionsUsage = "usage: sessions [list] | sessions revoke ID"
+
+// runSessions lists where the user is logged in, or ends one session: "sessions", "sessions revoke ID"
+func runSessions(conn *grpc.ClientConn, args []string) {
+	fs := flag.NewFlagSet("sessions", flag.ExitOnError)
+	username := fs.String("username", "testUser", "Username to authenticate with")
+	password := fs.String("password", "password123", "Password to authenticate with")
+	fs.Parse(args)
+
+	if err := login(conn, *username, *password); err != nil {
+		log.Fatalf("could not login: %v", err)
+	}
+	ctx := context.Background()
+	userClient := pb.NewUserServiceClient(conn)
+
+	switch {
+	case fs.NArg() == 0 || (fs.NArg() == 1 && fs.Arg(0) == "list"):
+		resp, err := userClient.ListSessions(ctx, &pb.ListSessionsRequest{})
+		if err != nil {
+			log.Fatalf("could not list sessions: %v", err)
+		}
+		fmt.Printf("ListSessions Response: %d active\n", len(resp.GetSessions()))
+		for _, sess := range resp.GetSessions() {
+			marker := ""
+			if sess.GetCurrent() {
+				marker = " (this session)"
+			}
+			fmt.Printf("%d: %s from %s, logged in %s, last seen %s%s\n", sess.GetId(), sess.GetUserAgent(), sess.GetIpAddress(),
+				sess.GetIssuedAt().AsTime().Local().Format(time.DateTime), sess.GetLastSeenAt().AsTime().Local().Format(time.DateTime), marker)
+		}
+	case fs.NArg() == 2 && fs.Arg(0) == "revoke":
+		id, err := strconv.ParseInt(fs.Arg(1), 10, 64)
+		if err != nil {
+			log.Fatal(sessionsUsage)
+		}
+		resp, err := userClient.RevokeSession(ctx, &pb.RevokeSessionRequest{Id: id})
+		if err != nil {
+			log.Fatalf("could not revoke session: %v", err)
+		}
+		fmt.Printf("RevokeSession Response: %s, revoked refresh tokens: %d\n", resp.GetMessage(), resp.GetRevokedRefreshTokens())
+	default:
+		log.Fatal(sessionsUsage)
+	}
+}
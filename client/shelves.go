@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	pb "example/grpc_demo/library"
+
+	"google.golang.org/grpc"
+)
+
+const shelvesUsage = "usage: shelves list | shelves create NAME [DESCRIPTION...] | shelves show SHELF_ID | shelves add SHELF_ID BOOK | shelves remove SHELF_ID BOOK"
+
+// runShelves manages the logged-in user's reading lists
+func runShelves(conn *grpc.ClientConn, args []string) {
+	fs := flag.NewFlagSet("shelves", flag.ExitOnError)
+	username := fs.String("username", "testUser", "Username to authenticate with")
+	password := fs.String("password", "password123", "Password to authenticate with")
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		log.Fatal(shelvesUsage)
+	}
+
+	if err := login(conn, *username, *password); err != nil {
+		log.Fatalf("could not login: %v", err)
+	}
+	ctx := context.Background()
+	shelfClient := pb.NewShelfServiceClient(conn)
+
+	shelfID := func(arg string) int64 {
+		id, err := strconv.ParseInt(arg, 10, 64)
+		if err != nil {
+			log.Fatalf("invalid shelf ID %q: %v", arg, err)
+		}
+		return id
+	}
+
+	switch sub := fs.Arg(0); {
+	case sub == "list":
+		resp, err := shelfClient.ListShelves(ctx, &pb.ListShelvesRequest{})
+		if err != nil {
+			log.Fatalf("could not list shelves: %v", err)
+		}
+		for _, sh := range resp.GetShelves() {
+			fmt.Printf("Shelf %d: %s (%d books) %s\n", sh.GetId(), sh.GetName(), sh.GetBookCount(), sh.GetDescription())
+		}
+	case sub == "create" && fs.NArg() >= 2:
+		resp, err := shelfClient.CreateShelf(ctx, &pb.Shelf{Name: fs.Arg(1), Description: strings.Join(fs.Args()[2:], " ")})
+		if err != nil {
+			log.Fatalf("could not create shelf: %v", err)
+		}
+		fmt.Printf("CreateShelf Response: shelf created, ID: %d\n", resp.GetId())
+	case sub == "show" && fs.NArg() == 2:
+		sh, err := shelfClient.GetShelf(ctx, &pb.ShelfRequest{Id: shelfID(fs.Arg(1))})
+		if err != nil {
+			log.Fatalf("could not get shelf: %v", err)
+		}
+		fmt.Printf("Shelf %d: %s (%d books)\n", sh.GetId(), sh.GetName(), sh.GetBookCount())
+		for i, b := range sh.GetBooks() {
+			fmt.Printf("Book %d: ID=%s, Title=%s, Author=%s", i+1, b.GetId(), b.GetTitle(), b.GetAuthor())
+			printBookDetails(b)
+		}
+	case sub == "add" && fs.NArg() == 3:
+		resp, err := shelfClient.AddBookToShelf(ctx, &pb.ShelfBookRequest{ShelfId: shelfID(fs.Arg(1)), BookId: fs.Arg(2)})
+		if err != nil {
+			log.Fatalf("could not add book to shelf: %v", err)
+		}
+		fmt.Printf("AddBookToShelf Response: book added to shelf, ID: %d\n", resp.GetId())
+	case sub == "remove" && fs.NArg() == 3:
+		resp, err := shelfClient.RemoveBookFromShelf(ctx, &pb.ShelfBookRequest{ShelfId: shelfID(fs.Arg(1)), BookId: fs.Arg(2)})
+		if err != nil {
+			log.Fatalf("could not remove book from shelf: %v", err)
+		}
+		fmt.Printf("RemoveBookFromShelf Response: book removed from shelf, ID: %d\n", resp.GetId())
+	default:
+		log.Fatal(shelvesUsage)
+	}
+}
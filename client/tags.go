@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+
+	pb "example/grpc_demo/library"
+
+	"google.golang.org/grpc"
+)
+
+// runTags manages tags: "tags list", "tags create NAME", "tags add BOOK TAG", "tags remove BOOK TAG"
+func runTags(conn *grpc.ClientConn, args []string) {
+	fs := flag.NewFlagSet("tags", flag.ExitOnError)
+	username := fs.String("username", "testUser", "Username to authenticate with")
+	password := fs.String("password", "password123", "Password to authenticate with")
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		log.Fatal("usage: tags list | tags create NAME | tags add BOOK TAG | tags remove BOOK TAG")
+	}
+
+	if err := login(conn, *username, *password); err != nil {
+		log.Fatalf("could not login: %v", err)
+	}
+	ctx := context.Background()
+	tagClient := pb.NewTagServiceClient(conn)
+
+	switch sub := fs.Arg(0); {
+	case sub == "list":
+		resp, err := tagClient.ListTags(ctx, &pb.ListTagsRequest{})
+		if err != nil {
+			log.Fatalf("could not list tags: %v", err)
+		}
+		for _, t := range resp.GetTags() {
+			fmt.Printf("%s (%d books)\n", t.GetName(), t.GetBookCount())
+		}
+	case sub == "create" && fs.NArg() == 2:
+		resp, err := tagClient.CreateTag(ctx, &pb.Tag{Name: fs.Arg(1)})
+		if err != nil {
+			log.Fatalf("could not create tag: %v", err)
+		}
+		fmt.Printf("CreateTag Response: tag %q created, ID: %d\n", resp.GetTag().GetName(), resp.GetTag().GetId())
+	case sub == "add" && fs.NArg() == 3:
+		resp, err := tagClient.TagBook(ctx, &pb.BookTagRequest{BookId: fs.Arg(1), Tag: fs.Arg(2)})
+		if err != nil {
+			log.Fatalf("could not tag book: %v", err)
+		}
+		fmt.Printf("TagBook Response: book tagged, ID: %s\n", resp.GetId())
+	case sub == "remove" && fs.NArg() == 3:
+		resp, err := tagClient.UntagBook(ctx, &pb.BookTagRequest{BookId: fs.Arg(1), Tag: fs.Arg(2)})
+		if err != nil {
+			log.Fatalf("could not untag book: %v", err)
+		}
+		fmt.Printf("UntagBook Response: book untagged, ID: %s\n", resp.GetId())
+	default:
+		log.Fatal("usage: tags list | tags create NAME | tags add BOOK TAG | tags remove BOOK TAG")
+	}
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"context"
+	"os"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// tenantDialOptions sends LIBRARY_TENANT as the x-tenant-id header on every call, to use a library hosted
+// besides the default one. Nothing is sent when it is unset, and calls are for the default library.
+func tenantDialOptions() []grpc.DialOption {
+	id := os.Getenv("LIBRARY_TENANT")
+	if id == "" {
+		return nil
+	}
+	return []grpc.DialOption{
+		grpc.WithChainUnaryInterceptor(func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+			return invoker(metadata.AppendToOutgoingContext(ctx, "x-tenant-id", id), method, req, reply, cc, opts...)
+		}),
+		grpc.WithChainStreamInterceptor(func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+			return streamer(metadata.AppendToOutgoingContext(ctx, "x-tenant-id", id), desc, cc, method, opts...)
+		}),
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	pb "example/grpc_demo/library"
+
+	"google.golang.org/grpc"
+)
+
+const tenantsUsage = "usage: tenants list | tenants create ID NAME --admin-username USER --admin-password PASSWORD"
+
+// runTenants manages the libraries the server hosts besides the default one, as an admin of the default
+// library: "tenants list", "tenants create ID NAME"
+func runTenants(conn *grpc.ClientConn, args []string) {
+	fs := flag.NewFlagSet("tenants", flag.ExitOnError)
+	username := fs.String("username", "testUser", "Username to authenticate with")
+	password := fs.String("password", "password123", "Password to authenticate with")
+	adminUsername := fs.String("admin-username", "", "Username of the new library's admin, for create")
+	adminPassword := fs.String("admin-password", "", "Password of the new library's admin, for create")
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		log.Fatal(tenantsUsage)
+	}
+
+	if err := login(conn, *username, *password); err != nil {
+		log.Fatalf("could not login: %v", err)
+	}
+	ctx := context.Background()
+	tenantClient := pb.NewTenantServiceClient(conn)
+
+	switch sub := fs.Arg(0); {
+	case sub == "list":
+		resp, err := tenantClient.ListTenants(ctx, &pb.ListTenantsRequest{})
+		if err != nil {
+			log.Fatalf("could not list libraries: %v", err)
+		}
+		fmt.Printf("ListTenants Response: %d libraries\n", len(resp.GetTenants()))
+		for _, t := range resp.GetTenants() {
+			fmt.Printf("%s: %s (created %s)\n", t.GetId(), t.GetName(), t.GetCreatedAt().AsTime().Format(time.RFC3339))
+		}
+	case sub == "create" && fs.NArg() >= 3:
+		t, err := tenantClient.CreateTenant(ctx, &pb.CreateTenantRequest{
+			Id:            fs.Arg(1),
+			Name:          strings.Join(fs.Args()[2:], " "),
+			AdminUsername: *adminUsername,
+			AdminPassword: *adminPassword,
+		})
+		if err != nil {
+			log.Fatalf("could not create library: %v", err)
+		}
+		fmt.Printf("CreateTenant Response: ID=%s, Name=%s\n", t.GetId(), t.GetName())
+		fmt.Printf("Use it with LIBRARY_TENANT=%s; log in as %s\n", t.GetId(), *adminUsername)
+	default:
+		log.Fatal(tenantsUsage)
+	}
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// transportCredentials connects with TLS when TLS_CA_FILE is set, presenting the client certificate
+// from TLS_CLIENT_CERT_FILE and TLS_CLIENT_KEY_FILE when the server requires mutual TLS
+func transportCredentials() (credentials.TransportCredentials, error) {
+	caFile := os.Getenv("TLS_CA_FILE")
+	certFile, keyFile := os.Getenv("TLS_CLIENT_CERT_FILE"), os.Getenv("TLS_CLIENT_KEY_FILE")
+	if caFile == "" {
+		if certFile != "" || keyFile != "" {
+			return nil, errors.New("TLS_CLIENT_CERT_FILE and TLS_CLIENT_KEY_FILE require TLS_CA_FILE")
+		}
+		return insecure.NewCredentials(), nil
+	}
+
+	pemData, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, err
+	}
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(pemData) {
+		return nil, fmt.Errorf("no certificates found in %s", caFile)
+	}
+	config := &tls.Config{RootCAs: roots, MinVersion: tls.VersionTLS12}
+	if certFile != "" || keyFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, err
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	return credentials.NewTLS(config), nil
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+
+	pb "example/grpc_demo/library"
+
+	"google.golang.org/grpc"
+)
+
+const translationsUsage = "usage: translations list BOOK | translations set BOOK LANGUAGE TITLE | translations delete BOOK LANGUAGE"
+
+// runTranslations manages localized titles and descriptions of a book
+func runTranslations(conn *grpc.ClientConn, args []string) {
+	fs := flag.NewFlagSet("translations", flag.ExitOnError)
+	username := fs.String("username", "testUser", "Username to authenticate with")
+	password := fs.String("password", "password123", "Password to authenticate with")
+	description := fs.String("description", "", "Translated description when setting a translation")
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		log.Fatal(translationsUsage)
+	}
+
+	if err := login(conn, *username, *password); err != nil {
+		log.Fatalf("could not login: %v", err)
+	}
+	ctx := context.Background()
+	libraryClient := pb.NewLibraryServiceClient(conn)
+
+	switch sub := fs.Arg(0); {
+	case sub == "list" && fs.NArg() == 2:
+		resp, err := libraryClient.ListBookTranslations(ctx, &pb.BookRequest{Id: fs.Arg(1)})
+		if err != nil {
+			log.Fatalf("could not list translations: %v", err)
+		}
+		fmt.Printf("ListBookTranslations Response: %d translation(s)\n", len(resp.GetTranslations()))
+		for _, t := range resp.GetTranslations() {
+			fmt.Printf("  [%s] %s\n", t.GetLanguage(), t.GetTitle())
+		}
+	case sub == "set" && fs.NArg() == 4:
+		t, err := libraryClient.SetBookTranslation(ctx, &pb.BookTranslation{
+			BookId:      fs.Arg(1),
+			Language:    fs.Arg(2),
+			Title:       fs.Arg(3),
+			Description: *description,
+		})
+		if err != nil {
+			log.Fatalf("could not set translation: %v", err)
+		}
+		fmt.Printf("SetBookTranslation Response: [%s] %s, ID: %s\n", t.GetLanguage(), t.GetTitle(), t.GetBookId())
+	case sub == "delete" && fs.NArg() == 3:
+		resp, err := libraryClient.DeleteBookTranslation(ctx, &pb.BookTranslationRequest{BookId: fs.Arg(1), Language: fs.Arg(2)})
+		if err != nil {
+			log.Fatalf("could not delete translation: %v", err)
+		}
+		fmt.Printf("DeleteBookTranslation Response: translation deleted, ID: %s\n", resp.GetId())
+	default:
+		log.Fatal(translationsUsage)
+	}
+}
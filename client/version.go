@@ -0,0 +1,28 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	pb "example/grpc_demo/library"
+
+	"google.golang.org/grpc"
+)
+
+// runVersion prints what the server is running; no login is needed
+func runVersion(conn *grpc.ClientConn, args []string) {
+	info, err := pb.NewServerInfoServiceClient(conn).GetServerInfo(context.Background(), &pb.ServerInfoRequest{})
+	if err != nil {
+		log.Fatalf("could not get server info: %v", err)
+	}
+	fmt.Printf("Version: %s\n", info.GetVersion())
+	fmt.Printf("Commit: %s\n", info.GetGitCommit())
+	if info.GetBuildTime() != nil {
+		fmt.Printf("Built: %s\n", info.GetBuildTime().AsTime().Format(time.RFC3339))
+	}
+	fmt.Printf("Go: %s\n", info.GetGoVersion())
+	fmt.Printf("Features: %s\n", strings.Join(info.GetEnabledFeatures(), ", "))
+}
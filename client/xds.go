@@ -0,0 +1,31 @@
+package main
+
+import (
+	"os"
+
+	"google.golang.org/grpc/credentials"
+	xdscreds "google.golang.org/grpc/credentials/xds"
+	_ "google.golang.org/grpc/xds" // registers the xds:/// resolver and the xDS load balancers
+)
+
+// defaultServerTarget is the server the client connects to unless LIBRARY_SERVER_TARGET is set
+const defaultServerTarget = "localhost:50051"
+
+// serverTarget is the gRPC target to dial from LIBRARY_SERVER_TARGET, e.g. xds:///library-service in xDS mode
+func serverTarget() string {
+	if target := os.Getenv("LIBRARY_SERVER_TARGET"); target != "" {
+		return target
+	}
+	return defaultServerTarget
+}
+
+// xdsCredentials wraps creds for xDS mode, enabled when GRPC_XDS_BOOTSTRAP names a bootstrap file or
+// GRPC_XDS_BOOTSTRAP_CONFIG holds one: connections to xds:/// targets then use the security configuration
+// of the control plane, such as mesh mTLS, and fall back to creds when it sends none. Routing, load
+// balancing and retries of xds:/// targets come from the control plane too, overriding service_config.json.
+func xdsCredentials(creds credentials.TransportCredentials) (credentials.TransportCredentials, error) {
+	if os.Getenv("GRPC_XDS_BOOTSTRAP") == "" && os.Getenv("GRPC_XDS_BOOTSTRAP_CONFIG") == "" {
+		return creds, nil
+	}
+	return xdscreds.NewClientCredentials(xdscreds.ClientOptions{FallbackCreds: creds})
+}
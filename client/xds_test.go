@@ -0,0 +1,40 @@
+package main
+
+import (
+	"testing"
+
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+func TestServerTarget(t *testing.T) {
+	t.Setenv("LIBRARY_SERVER_TARGET", "")
+	if got := serverTarget(); got != defaultServerTarget {
+		t.Errorf("serverTarget() = %q, want %q", got, defaultServerTarget)
+	}
+	t.Setenv("LIBRARY_SERVER_TARGET", "xds:///library-service")
+	if got := serverTarget(); got != "xds:///library-service" {
+		t.Errorf("serverTarget() = %q, want the target of LIBRARY_SERVER_TARGET", got)
+	}
+}
+
+func TestXDSCredentials(t *testing.T) {
+	creds := insecure.NewCredentials()
+	t.Setenv("GRPC_XDS_BOOTSTRAP", "")
+	t.Setenv("GRPC_XDS_BOOTSTRAP_CONFIG", "")
+	if got, err := xdsCredentials(creds); err != nil || got != creds {
+		t.Errorf("xdsCredentials() without a bootstrap = %v, %v, want the credentials unchanged", got, err)
+	}
+
+	for _, env := range []string{"GRPC_XDS_BOOTSTRAP", "GRPC_XDS_BOOTSTRAP_CONFIG"} {
+		t.Run(env, func(t *testing.T) {
+			t.Setenv(env, "bootstrap")
+			got, err := xdsCredentials(creds)
+			if err != nil {
+				t.Fatalf("xdsCredentials() error = %v", err)
+			}
+			if got == creds || got.Info().SecurityProtocol == creds.Info().SecurityProtocol {
+				t.Errorf("xdsCredentials() = %v, want xDS credentials falling back to the given ones", got.Info())
+			}
+		})
+	}
+}
@@ -12,13 +12,13 @@ function App() {
   useEffect(() => {
     const checkAuthStatus = async () => {
       const isAuthenticated = authAPI.isAuthenticated();
-      const currentToken = authAPI.getCurrentToken();
       
-      if (isAuthenticated && currentToken) {
-        // Also validate with server to ensure user still exists
+      if (isAuthenticated) {
+        // Also validate with server to ensure user still exists; after a reload the token is
+        // only in the session cookie, so the in-memory one may be missing
         const isValidOnServer = await authAPI.validateTokenWithServer();
         if (isValidOnServer) {
-          setToken(currentToken);
+          setToken(authAPI.getCurrentToken() ?? 'cookie');
         } else {
           // Token is invalid on server side (e.g., user deleted, DB cleared)
           authAPI.logout();
@@ -194,7 +194,7 @@ export const Auth: React.FC<AuthProps> = ({ onLogin }) => {
           fontSize: '12px',
           color: '#6c757d'
         }}>
-          <strong>🔒 Security Notice:</strong> Your password is securely hashed and your session uses JWT tokens that expire in 24 hours.
+          <strong>🔒 Security Notice:</strong> Your password is securely hashed and your session uses short-lived JWT tokens that are renewed automatically.
         </div>
       </div>
     </div>
@@ -8,6 +8,7 @@ interface BookManagerProps {
 export const BookManager: React.FC<BookManagerProps> = ({ onLogout }) => {
   const [books, setBooks] = useState<Book[]>([]);
   const [totalCount, setTotalCount] = useState(0);
+  const [totalEstimated, setTotalEstimated] = useState(false);
   const [currentPage, setCurrentPage] = useState(1);
   const [pageSize] = useState(5);
   const [loading, setLoading] = useState(false);
@@ -59,8 +60,8 @@ export const BookManager: React.FC<BookManagerProps> = ({ onLogout }) => {
     }
   };
 
-  const handleLogout = () => {
-    authAPI.logout(); // Clear the token
+  const handleLogout = async () => {
+    await authAPI.logout(); // Revoke the session and clear the tokens
     onLogout();
   };
 
@@ -71,6 +72,7 @@ export const BookManager: React.FC<BookManagerProps> = ({ onLogout }) => {
       const response = await bookAPI.listBooks(currentPage, pageSize);
       setBooks(response.books || []);
       setTotalCount(response.totalCount || 0);
+      setTotalEstimated(response.totalCountEstimated || false);
     } catch (err) {
       if (err instanceof Error) {
         handleAuthError(err);
@@ -444,7 +446,7 @@ export const BookManager: React.FC<BookManagerProps> = ({ onLogout }) => {
           </button>
           
           <span style={{ padding: '8px 16px', color: '#6c757d' }}>
-            Page {currentPage} of {totalPages} ({totalCount} total books)
+            Page {currentPage} of {totalPages} ({totalEstimated ? 'about ' : ''}{totalCount} total books)
           </span>
           
           <button
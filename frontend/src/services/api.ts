@@ -5,37 +5,145 @@ const API_BASE_URL = 'http://localhost:8080/api/v1';
 export interface User {
   username: string;
   password: string;
+  email?: string;
+  // Response token of the Turnstile / hCaptcha widget, when the server requires a challenge
+  challengeToken?: string;
 }
 
 export interface AuthResponse {
   token: string;
   message: string;
+  refreshToken?: string;
+  expiresAt?: string;
+  refreshExpiresAt?: string;
+}
+
+export interface Session {
+  id: string;
+  userAgent?: string;
+  ipAddress?: string;
+  issuedAt?: string;
+  lastSeenAt?: string;
+  current?: boolean;
+}
+
+export type AuthEventType =
+  | 'AUTH_EVENT_TYPE_REGISTER'
+  | 'AUTH_EVENT_TYPE_LOGIN'
+  | 'AUTH_EVENT_TYPE_LOGIN_FAILED'
+  | 'AUTH_EVENT_TYPE_TOKEN_REFRESH'
+  | 'AUTH_EVENT_TYPE_TOKEN_REFRESH_FAILED';
+
+export interface AuthEvent {
+  id: string;
+  type: AuthEventType;
+  username?: string;
+  detail?: string;
+  ipAddress?: string;
+  userAgent?: string;
+  createdAt?: string;
+}
+
+export interface AuditLogEntry {
+  id: string;
+  method: string;
+  userId?: number;
+  username?: string;
+  resourceId?: string;
+  requestSummary?: string;
+  createdAt?: string;
+}
+
+export interface ScopedToken {
+  token: string;
+  scopes: string[];
+  expiresAt?: string;
+  sessionId: string;
+}
+
+export interface ProfilePreferences {
+  language?: string;
+  pageSize?: number;
+  emailNotifications?: boolean;
+}
+
+export interface Profile {
+  username: string;
+  displayName?: string;
+  email?: string;
+  emailVerified?: boolean;
+  preferences?: ProfilePreferences;
+  // Read-only login metadata
+  lastLoginAt?: string;
+  lastLoginIp?: string;
+  loginCount?: string;
 }
 
 export interface Book {
   id: string;
   title: string;
   author: string;
+  isbn?: string;
+  coverUrl?: string;
+  publisher?: string;
+  publisherId?: number;
+  ownerId?: number;
+  tags?: string[];
+  averageRating?: number;
+  reviewCount?: number;
+  totalCopies?: number;
+  availableCopies?: number;
+  series?: BookSeries;
+  description?: string;
+  language?: string;
+  location?: Location;
+}
+
+export interface Location {
+  branch?: string;
+  section?: string;
+  shelf?: string;
+}
+
+export interface BookSeries {
+  name: string;
+  volume?: number;
+}
+
+export interface Publisher {
+  id: number;
+  name: string;
+  website?: string;
+  bookCount?: number;
 }
 
+// Failures are reported as gRPC statuses with an error HTTP code, so a BookResponse always means success
 export interface BookResponse {
   id: string;
-  message: string;
 }
 
 export interface ListBooksResponse {
   books: Book[];
   totalCount: number;
+  // Set when totalCount is the server's estimate of a large catalog rather than a count
+  totalCountEstimated?: boolean;
 }
 
 // Token Management
+// The gateway keeps the tokens in HttpOnly cookies, so they are only held in memory here for the
+// Authorization header; across reloads only the session's expiry is remembered.
 class TokenManager {
   private static instance: TokenManager;
   private token: string | null = null;
+  private refreshToken: string | null = null;
+  private expiresAt: number | null = null;
 
   private constructor() {
-    // Load token from localStorage on initialization
-    this.token = localStorage.getItem('authToken');
+    const stored = localStorage.getItem('sessionExpiresAt');
+    this.expiresAt = stored ? Number(stored) : null;
+    // Tokens stored by earlier versions are no longer used
+    localStorage.removeItem('authToken');
+    localStorage.removeItem('refreshToken');
   }
 
   public static getInstance(): TokenManager {
@@ -45,23 +153,14 @@ class TokenManager {
     return TokenManager.instance;
   }
 
-  public setToken(token: string): void {
+  public setToken(token: string, expiresAt?: string): void {
     this.token = token;
-    localStorage.setItem('authToken', token);
-    
-    // Debug: Log token info
-    try {
-      const payload = JSON.parse(atob(token.split('.')[1]));
-      const currentTime = Math.floor(Date.now() / 1000);
-      const timeUntilExpiry = payload.exp - currentTime;
-      console.log('Token set:', {
-        exp: payload.exp,
-        currentTime,
-        timeUntilExpiry,
-        expiresAt: new Date(payload.exp * 1000).toLocaleString()
-      });
-    } catch (error) {
-      console.error('Error parsing token:', error);
+    this.expiresAt = expiresAt ? Date.parse(expiresAt) : null;
+    if (this.expiresAt) {
+      localStorage.setItem('sessionExpiresAt', String(this.expiresAt));
+      console.log('Token set:', { expiresAt: new Date(this.expiresAt).toLocaleString() });
+    } else {
+      localStorage.removeItem('sessionExpiresAt');
     }
   }
 
@@ -69,42 +168,34 @@ class TokenManager {
     return this.token;
   }
 
+  public setRefreshToken(refreshToken: string): void {
+    this.refreshToken = refreshToken;
+  }
+
+  public getRefreshToken(): string | null {
+    return this.refreshToken;
+  }
+
   public clearToken(): void {
     this.token = null;
-    localStorage.removeItem('authToken');
+    this.refreshToken = null;
+    this.expiresAt = null;
+    localStorage.removeItem('sessionExpiresAt');
   }
 
   public isTokenValid(): boolean {
-    if (!this.token) return false;
-    
-    try {
-      // Decode JWT payload (basic validation)
-      const payload = JSON.parse(atob(this.token.split('.')[1]));
-      const currentTime = Math.floor(Date.now() / 1000);
-      
-      // Check if token is expired (with small buffer for clock skew)
-      // Use smaller buffer (30 seconds) or 10% of token lifetime, whichever is smaller
-      const tokenLifetime = payload.exp - payload.iat; // Token lifetime in seconds
-      const bufferTime = Math.min(30, Math.floor(tokenLifetime * 0.1)); // 30s or 10% of lifetime
-      const isExpired = payload.exp <= (currentTime + bufferTime);
-      
-      if (isExpired) {
-        console.log('Token expired, clearing...', {
-          exp: payload.exp,
-          currentTime,
-          bufferTime,
-          timeUntilExpiry: payload.exp - currentTime
-        });
-        this.clearToken();
-        return false;
-      }
-      
-      return true;
-    } catch (error) {
-      console.error('Invalid token format:', error);
+    if (!this.expiresAt) return false;
+
+    // Check if the session is expired (with a 30 second buffer for clock skew)
+    const isExpired = this.expiresAt <= Date.now() + 30 * 1000;
+    if (isExpired) {
+      console.log('Session expired, clearing...', {
+        expiresAt: new Date(this.expiresAt).toLocaleString()
+      });
       this.clearToken();
       return false;
     }
+    return true;
   }
 }
 
@@ -116,10 +207,25 @@ class AuthenticationError extends Error {
   }
 }
 
+// deviceId identifies this browser profile; tokens issued to it are rejected when sent from elsewhere
+const deviceId = (): string => {
+  let id = localStorage.getItem('deviceId');
+  if (!id) {
+    id = crypto.randomUUID();
+    localStorage.setItem('deviceId', id);
+  }
+  return id;
+};
+
+// apiFetch sends the auth cookies along with every request to the gateway
+const apiFetch = (input: string, init: RequestInit = {}): Promise<Response> =>
+  fetch(input, { credentials: 'include', ...init });
+
 // Helper function to create authenticated headers
 const createAuthHeaders = (includeAuth: boolean = true): HeadersInit => {
   const headers: HeadersInit = {
     'Content-Type': 'application/json',
+    'X-Device-Id': deviceId(),
   };
   
   if (includeAuth) {
@@ -132,40 +238,62 @@ const createAuthHeaders = (includeAuth: boolean = true): HeadersInit => {
   return headers;
 };
 
+// idempotentHeaders adds a new idempotency key to the auth headers: if the request is sent again, say by a
+// proxy after a dropped connection, the server returns the first result instead of running it twice
+const idempotentHeaders = (): HeadersInit => ({
+  ...createAuthHeaders(true),
+  'Idempotency-Key': crypto.randomUUID(),
+});
+
+// errorMessage extracts the text of an error returned by the gateway
+// ({ error: { code, status, message, requestId, fieldViolations, details } }), preferring the
+// LocalizedMessage detail meant for display, then the first invalid field
+const errorMessage = async (response: Response): Promise<string> => {
+  const text = await response.text();
+  try {
+    const error = JSON.parse(text).error || {};
+    const localized = (error.details || []).find(
+      (d: any) => d['@type'] === 'type.googleapis.com/google.rpc.LocalizedMessage'
+    );
+    const violation = error.fieldViolations?.[0];
+    if (!localized && violation) {
+      return `${violation.field}: ${violation.description}`;
+    }
+    return localized?.message || error.message || response.statusText;
+  } catch {
+    return text || response.statusText;
+  }
+};
+
 // Helper function to handle API responses
 const handleResponse = async (response: Response): Promise<any> => {
   if (response.status === 401) {
-    // Token expired or invalid - clear it and throw auth error
+    // Token expired or invalid (or wrong credentials on login) - clear it and throw auth error
     TokenManager.getInstance().clearToken();
-    throw new AuthenticationError('Authentication failed. Please login again.');
+    throw new AuthenticationError(await errorMessage(response) || 'Authentication failed. Please login again.');
   }
   
   if (!response.ok) {
-    const errorText = await response.text();
-    throw new Error(`API Error (${response.status}): ${errorText || response.statusText}`);
+    throw new Error(`API Error (${response.status}): ${await errorMessage(response)}`);
   }
   
-  const data = await response.json();
-  
-  // CRITICAL: Check for server-side error messages in successful responses
-  // The server may return 200 OK but with an error message for invalid credentials
-  if (data.message && !data.token) {
-    // If there's a message but no token, it's likely an error
-    if (data.message.includes('Invalid username or password') || 
-        data.message.includes('Username already exists') || 
-        data.message.includes('Username and password are required') ||
-        data.message.includes('Failed to')) {
-      throw new Error(data.message);
-    }
+  return response.json();
+};
+
+// storeTokens keeps the access and refresh tokens from an AuthResponse
+const storeTokens = (data: AuthResponse): void => {
+  if (data.token) {
+    TokenManager.getInstance().setToken(data.token, data.expiresAt);
+  }
+  if (data.refreshToken) {
+    TokenManager.getInstance().setRefreshToken(data.refreshToken);
   }
-  
-  return data;
 };
 
 // User Authentication
 export const authAPI = {
   register: async (user: User): Promise<AuthResponse> => {
-    const response = await fetch(`${API_BASE_URL}/auth/register`, {
+    const response = await apiFetch(`${API_BASE_URL}/auth/register`, {
       method: 'POST',
       headers: createAuthHeaders(false), // No auth required for registration
       body: JSON.stringify(user),
@@ -173,16 +301,14 @@ export const authAPI = {
     
     const data = await handleResponse(response);
     
-    // Automatically store token if registration returns one
-    if (data.token) {
-      TokenManager.getInstance().setToken(data.token);
-    }
+    // Automatically store tokens if registration returns them
+    storeTokens(data);
     
     return data;
   },
 
   login: async (credentials: User): Promise<AuthResponse> => {
-    const response = await fetch(`${API_BASE_URL}/auth/login`, {
+    const response = await apiFetch(`${API_BASE_URL}/auth/login`, {
       method: 'POST',
       headers: createAuthHeaders(false), // No auth required for login
       body: JSON.stringify(credentials),
@@ -190,33 +316,151 @@ export const authAPI = {
     
     const data = await handleResponse(response);
     
-    // Store token on successful login
-    if (data.token) {
-      TokenManager.getInstance().setToken(data.token);
-    }
+    // Store tokens on successful login
+    storeTokens(data);
     
     return data;
   },
 
-  logout: (): void => {
+  // Log in with an ID token obtained from the OpenID Connect provider
+  loginWithIdToken: async (idToken: string): Promise<AuthResponse> => {
+    const response = await apiFetch(`${API_BASE_URL}/auth/oidc`, {
+      method: 'POST',
+      headers: createAuthHeaders(false),
+      body: JSON.stringify({ idToken }),
+    });
+
+    const data = await handleResponse(response);
+    storeTokens(data);
+    return data;
+  },
+
+  // Exchange the refresh token for new tokens; returns false when the user must log in again.
+  // After a reload the token is only in the refresh cookie, which the gateway passes on.
+  refresh: async (): Promise<boolean> => {
+    const refreshToken = TokenManager.getInstance().getRefreshToken() ?? undefined;
+
+    const response = await apiFetch(`${API_BASE_URL}/auth/refresh`, {
+      method: 'POST',
+      headers: createAuthHeaders(false),
+      body: JSON.stringify({ refreshToken }),
+    });
+    if (!response.ok) {
+      TokenManager.getInstance().clearToken();
+      return false;
+    }
+    storeTokens(await response.json());
+    return true;
+  },
+
+  // Revoke the session on the server, then forget the tokens locally even if that fails
+  logout: async (): Promise<void> => {
+    const refreshToken = TokenManager.getInstance().getRefreshToken();
+    try {
+      await apiFetch(`${API_BASE_URL}/auth/logout`, {
+        method: 'POST',
+        headers: createAuthHeaders(true),
+        body: JSON.stringify({ refreshToken }),
+      });
+    } catch (error) {
+      console.error('Logout request failed:', error);
+    }
+    TokenManager.getInstance().clearToken();
+  },
+
+  // Log out on every device
+  logoutEverywhere: async (): Promise<void> => {
+    const response = await apiFetch(`${API_BASE_URL}/me/sessions:revokeAll`, {
+      method: 'POST',
+      headers: createAuthHeaders(true),
+      body: JSON.stringify({}),
+    });
+    await handleResponse(response);
     TokenManager.getInstance().clearToken();
   },
 
+  // Sessions of the logged-in user, one per login
+  listSessions: async (): Promise<Session[]> => {
+    const response = await apiFetch(`${API_BASE_URL}/me/sessions`, {
+      method: 'GET',
+      headers: createAuthHeaders(true),
+    });
+    const data = await handleResponse(response);
+    return data.sessions || [];
+  },
+
+  revokeSession: async (id: string): Promise<void> => {
+    const response = await apiFetch(`${API_BASE_URL}/me/sessions/${id}`, {
+      method: 'DELETE',
+      headers: createAuthHeaders(true),
+    });
+    await handleResponse(response);
+  },
+
+  // Mint a token restricted to scopes (e.g. 'books:read'); ttl is a duration such as '86400s'
+  createScopedToken: async (scopes: string[], options: { ttl?: string; name?: string } = {}): Promise<ScopedToken> => {
+    const response = await apiFetch(`${API_BASE_URL}/me/tokens`, {
+      method: 'POST',
+      headers: createAuthHeaders(true),
+      body: JSON.stringify({ scopes, ...options }),
+    });
+    return handleResponse(response);
+  },
+
+  // Change the password; it must differ from the recent ones, and other sessions are logged out
+  changePassword: async (currentPassword: string, newPassword: string): Promise<{ message: string; revokedSessions?: number }> => {
+    const response = await apiFetch(`${API_BASE_URL}/me/password`, {
+      method: 'POST',
+      headers: createAuthHeaders(true),
+      body: JSON.stringify({ currentPassword, newPassword }),
+    });
+    return handleResponse(response);
+  },
+
+  // Login history of the logged-in user, or of another user for admins
+  listAuthEvents: async (params: { username?: string; type?: AuthEventType; page?: number; pageSize?: number } = {}): Promise<{ events: AuthEvent[]; totalCount: number }> => {
+    const query = new URLSearchParams();
+    if (params.type) query.set('type', params.type);
+    if (params.page) query.set('page', String(params.page));
+    if (params.pageSize) query.set('pageSize', String(params.pageSize));
+    const path = params.username ? `/users/${encodeURIComponent(params.username)}/auth-events` : '/me/auth-events';
+    const response = await apiFetch(`${API_BASE_URL}${path}?${query}`, {
+      method: 'GET',
+      headers: createAuthHeaders(true),
+    });
+    const data = await handleResponse(response);
+    return { events: data.events || [], totalCount: data.totalCount || 0 };
+  },
+
+  // Successful changes made through the API, newest first (admins only)
+  listAuditLog: async (params: { userId?: number; method?: string; resourceId?: string; page?: number; pageSize?: number } = {}): Promise<{ entries: AuditLogEntry[]; totalCount: number }> => {
+    const query = new URLSearchParams();
+    if (params.userId) query.set('userId', String(params.userId));
+    if (params.method) query.set('method', params.method);
+    if (params.resourceId) query.set('resourceId', params.resourceId);
+    if (params.page) query.set('page', String(params.page));
+    if (params.pageSize) query.set('pageSize', String(params.pageSize));
+    const response = await apiFetch(`${API_BASE_URL}/audit-log?${query}`, {
+      method: 'GET',
+      headers: createAuthHeaders(true),
+    });
+    const data = await handleResponse(response);
+    return { entries: data.entries || [], totalCount: data.totalCount || 0 };
+  },
+
   isAuthenticated: (): boolean => {
     const isValid = TokenManager.getInstance().isTokenValid();
     console.log('Auth check:', { isValid });
     return isValid;
   },
 
-  // Validate token with server (checks if user still exists in DB)
+  // Validate the session cookie with the server (checks if user still exists in DB)
   validateTokenWithServer: async (): Promise<boolean> => {
-    const token = TokenManager.getInstance().getToken();
-    console.log('validateTokenWithServer called, token exists:', !!token);
-    if (!token) return false;
+    console.log('validateTokenWithServer called');
 
     try {
       // Try to make a simple authenticated request to verify token is still valid
-      const response = await fetch(`${API_BASE_URL}/books?page=1&page_size=1`, {
+      const response = await apiFetch(`${API_BASE_URL}/books?page=1&page_size=1`, {
         method: 'GET',
         headers: createAuthHeaders(true),
       });
@@ -249,9 +493,9 @@ export const authAPI = {
 // Book Management (All endpoints require authentication)
 export const bookAPI = {
   addBook: async (book: Book): Promise<BookResponse> => {
-    const response = await fetch(`${API_BASE_URL}/books`, {
+    const response = await apiFetch(`${API_BASE_URL}/books`, {
       method: 'POST',
-      headers: createAuthHeaders(true), // Auth required
+      headers: idempotentHeaders(), // Auth required
       body: JSON.stringify(book),
     });
     
@@ -259,7 +503,7 @@ export const bookAPI = {
   },
 
   updateBook: async (book: Book): Promise<BookResponse> => {
-    const response = await fetch(`${API_BASE_URL}/books/${book.id}`, {
+    const response = await apiFetch(`${API_BASE_URL}/books/${book.id}`, {
       method: 'PUT',
       headers: createAuthHeaders(true), // Auth required
       body: JSON.stringify(book),
@@ -269,16 +513,16 @@ export const bookAPI = {
   },
 
   deleteBook: async (id: string): Promise<BookResponse> => {
-    const response = await fetch(`${API_BASE_URL}/books/${id}`, {
+    const response = await apiFetch(`${API_BASE_URL}/books/${id}`, {
       method: 'DELETE',
-      headers: createAuthHeaders(true), // Auth required
+      headers: idempotentHeaders(), // Auth required
     });
     
     return handleResponse(response);
   },
 
   listBooks: async (page: number = 1, pageSize: number = 10): Promise<ListBooksResponse> => {
-    const response = await fetch(
+    const response = await apiFetch(
       `${API_BASE_URL}/books?page=${page}&page_size=${pageSize}`,
       {
         method: 'GET',
@@ -290,5 +534,28 @@ export const bookAPI = {
   },
 };
 
+// Profile of the logged-in user
+export const profileAPI = {
+  getProfile: async (): Promise<Profile> => {
+    const response = await apiFetch(`${API_BASE_URL}/me/profile`, {
+      method: 'GET',
+      headers: createAuthHeaders(true),
+    });
+
+    return handleResponse(response);
+  },
+
+  // Only the fields present in the update are changed
+  updateProfile: async (update: Partial<Omit<Profile, 'username' | 'emailVerified'>>): Promise<Profile> => {
+    const response = await apiFetch(`${API_BASE_URL}/me/profile`, {
+      method: 'PATCH',
+      headers: createAuthHeaders(true),
+      body: JSON.stringify(update),
+    });
+
+    return handleResponse(response);
+  },
+};
+
 // Export token manager for use in components
 export { TokenManager, AuthenticationError }; 
\ No newline at end of file
@@ -8,8 +8,12 @@ package library
 
 import (
 	_ "google.golang.org/genproto/googleapis/api/annotations"
+	status "google.golang.org/genproto/googleapis/rpc/status"
 	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	durationpb "google.golang.org/protobuf/types/known/durationpb"
+	fieldmaskpb "google.golang.org/protobuf/types/known/fieldmaskpb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
 	reflect "reflect"
 	sync "sync"
 	unsafe "unsafe"
@@ -22,12 +26,415 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+type RevisionAction int32
+
+const (
+	RevisionAction_REVISION_ACTION_UNSPECIFIED RevisionAction = 0
+	RevisionAction_REVISION_ACTION_CREATE      RevisionAction = 1
+	RevisionAction_REVISION_ACTION_UPDATE      RevisionAction = 2
+	RevisionAction_REVISION_ACTION_DELETE      RevisionAction = 3
+)
+
+// Enum value maps for RevisionAction.
+var (
+	RevisionAction_name = map[int32]string{
+		0: "REVISION_ACTION_UNSPECIFIED",
+		1: "REVISION_ACTION_CREATE",
+		2: "REVISION_ACTION_UPDATE",
+		3: "REVISION_ACTION_DELETE",
+	}
+	RevisionAction_value = map[string]int32{
+		"REVISION_ACTION_UNSPECIFIED": 0,
+		"REVISION_ACTION_CREATE":      1,
+		"REVISION_ACTION_UPDATE":      2,
+		"REVISION_ACTION_DELETE":      3,
+	}
+)
+
+func (x RevisionAction) Enum() *RevisionAction {
+	p := new(RevisionAction)
+	*p = x
+	return p
+}
+
+func (x RevisionAction) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (RevisionAction) Descriptor() protoreflect.EnumDescriptor {
+	return file_library_proto_enumTypes[0].Descriptor()
+}
+
+func (RevisionAction) Type() protoreflect.EnumType {
+	return &file_library_proto_enumTypes[0]
+}
+
+func (x RevisionAction) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use RevisionAction.Descriptor instead.
+func (RevisionAction) EnumDescriptor() ([]byte, []int) {
+	return file_library_proto_rawDescGZIP(), []int{0}
+}
+
+type ExportFormat int32
+
+const (
+	ExportFormat_EXPORT_FORMAT_UNSPECIFIED ExportFormat = 0
+	ExportFormat_EXPORT_FORMAT_CSV         ExportFormat = 1
+	ExportFormat_EXPORT_FORMAT_JSON        ExportFormat = 2
+)
+
+// Enum value maps for ExportFormat.
+var (
+	ExportFormat_name = map[int32]string{
+		0: "EXPORT_FORMAT_UNSPECIFIED",
+		1: "EXPORT_FORMAT_CSV",
+		2: "EXPORT_FORMAT_JSON",
+	}
+	ExportFormat_value = map[string]int32{
+		"EXPORT_FORMAT_UNSPECIFIED": 0,
+		"EXPORT_FORMAT_CSV":         1,
+		"EXPORT_FORMAT_JSON":        2,
+	}
+)
+
+func (x ExportFormat) Enum() *ExportFormat {
+	p := new(ExportFormat)
+	*p = x
+	return p
+}
+
+func (x ExportFormat) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ExportFormat) Descriptor() protoreflect.EnumDescriptor {
+	return file_library_proto_enumTypes[1].Descriptor()
+}
+
+func (ExportFormat) Type() protoreflect.EnumType {
+	return &file_library_proto_enumTypes[1]
+}
+
+func (x ExportFormat) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ExportFormat.Descriptor instead.
+func (ExportFormat) EnumDescriptor() ([]byte, []int) {
+	return file_library_proto_rawDescGZIP(), []int{1}
+}
+
+type ReservationStatus int32
+
+const (
+	ReservationStatus_RESERVATION_STATUS_UNSPECIFIED ReservationStatus = 0
+	// Waiting in the queue for a copy
+	ReservationStatus_RESERVATION_STATUS_WAITING ReservationStatus = 1
+	// A returned copy is being held for the user until expires_at
+	ReservationStatus_RESERVATION_STATUS_READY     ReservationStatus = 2
+	ReservationStatus_RESERVATION_STATUS_FULFILLED ReservationStatus = 3
+	ReservationStatus_RESERVATION_STATUS_CANCELLED ReservationStatus = 4
+	ReservationStatus_RESERVATION_STATUS_EXPIRED   ReservationStatus = 5
+)
+
+// Enum value maps for ReservationStatus.
+var (
+	ReservationStatus_name = map[int32]string{
+		0: "RESERVATION_STATUS_UNSPECIFIED",
+		1: "RESERVATION_STATUS_WAITING",
+		2: "RESERVATION_STATUS_READY",
+		3: "RESERVATION_STATUS_FULFILLED",
+		4: "RESERVATION_STATUS_CANCELLED",
+		5: "RESERVATION_STATUS_EXPIRED",
+	}
+	ReservationStatus_value = map[string]int32{
+		"RESERVATION_STATUS_UNSPECIFIED": 0,
+		"RESERVATION_STATUS_WAITING":     1,
+		"RESERVATION_STATUS_READY":       2,
+		"RESERVATION_STATUS_FULFILLED":   3,
+		"RESERVATION_STATUS_CANCELLED":   4,
+		"RESERVATION_STATUS_EXPIRED":     5,
+	}
+)
+
+func (x ReservationStatus) Enum() *ReservationStatus {
+	p := new(ReservationStatus)
+	*p = x
+	return p
+}
+
+func (x ReservationStatus) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ReservationStatus) Descriptor() protoreflect.EnumDescriptor {
+	return file_library_proto_enumTypes[2].Descriptor()
+}
+
+func (ReservationStatus) Type() protoreflect.EnumType {
+	return &file_library_proto_enumTypes[2]
+}
+
+func (x ReservationStatus) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ReservationStatus.Descriptor instead.
+func (ReservationStatus) EnumDescriptor() ([]byte, []int) {
+	return file_library_proto_rawDescGZIP(), []int{2}
+}
+
+type NotificationType int32
+
+const (
+	NotificationType_NOTIFICATION_TYPE_UNSPECIFIED NotificationType = 0
+	// A returned copy is being held for one of the user's reservations
+	NotificationType_NOTIFICATION_TYPE_HOLD_READY NotificationType = 1
+	// A held copy was not collected in time
+	NotificationType_NOTIFICATION_TYPE_HOLD_EXPIRED NotificationType = 2
+	// A loan is due within a day
+	NotificationType_NOTIFICATION_TYPE_LOAN_DUE_SOON NotificationType = 3
+	// A loan has passed its due date
+	NotificationType_NOTIFICATION_TYPE_LOAN_OVERDUE NotificationType = 4
+	// Sent while a stream is otherwise idle, so proxies with idle timeouts keep it open; it carries the
+	// resume_token of the stream's position and is sent whatever the requested types
+	NotificationType_NOTIFICATION_TYPE_KEEPALIVE NotificationType = 5
+	// Some events since the resume_token are no longer kept, e.g. after a server restart, and were not
+	// delivered; clients should reload what the notifications keep them up to date on
+	NotificationType_NOTIFICATION_TYPE_EVENTS_MISSED NotificationType = 6
+)
+
+// Enum value maps for NotificationType.
+var (
+	NotificationType_name = map[int32]string{
+		0: "NOTIFICATION_TYPE_UNSPECIFIED",
+		1: "NOTIFICATION_TYPE_HOLD_READY",
+		2: "NOTIFICATION_TYPE_HOLD_EXPIRED",
+		3: "NOTIFICATION_TYPE_LOAN_DUE_SOON",
+		4: "NOTIFICATION_TYPE_LOAN_OVERDUE",
+		5: "NOTIFICATION_TYPE_KEEPALIVE",
+		6: "NOTIFICATION_TYPE_EVENTS_MISSED",
+	}
+	NotificationType_value = map[string]int32{
+		"NOTIFICATION_TYPE_UNSPECIFIED":   0,
+		"NOTIFICATION_TYPE_HOLD_READY":    1,
+		"NOTIFICATION_TYPE_HOLD_EXPIRED":  2,
+		"NOTIFICATION_TYPE_LOAN_DUE_SOON": 3,
+		"NOTIFICATION_TYPE_LOAN_OVERDUE":  4,
+		"NOTIFICATION_TYPE_KEEPALIVE":     5,
+		"NOTIFICATION_TYPE_EVENTS_MISSED": 6,
+	}
+)
+
+func (x NotificationType) Enum() *NotificationType {
+	p := new(NotificationType)
+	*p = x
+	return p
+}
+
+func (x NotificationType) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (NotificationType) Descriptor() protoreflect.EnumDescriptor {
+	return file_library_proto_enumTypes[3].Descriptor()
+}
+
+func (NotificationType) Type() protoreflect.EnumType {
+	return &file_library_proto_enumTypes[3]
+}
+
+func (x NotificationType) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use NotificationType.Descriptor instead.
+func (NotificationType) EnumDescriptor() ([]byte, []int) {
+	return file_library_proto_rawDescGZIP(), []int{3}
+}
+
+type BarcodeSymbology int32
+
+const (
+	// Code 128
+	BarcodeSymbology_BARCODE_SYMBOLOGY_UNSPECIFIED BarcodeSymbology = 0
+	BarcodeSymbology_BARCODE_SYMBOLOGY_CODE128     BarcodeSymbology = 1
+	// Requires 12 or 13 digits, e.g. an ISBN-13
+	BarcodeSymbology_BARCODE_SYMBOLOGY_EAN13 BarcodeSymbology = 2
+)
+
+// Enum value maps for BarcodeSymbology.
+var (
+	BarcodeSymbology_name = map[int32]string{
+		0: "BARCODE_SYMBOLOGY_UNSPECIFIED",
+		1: "BARCODE_SYMBOLOGY_CODE128",
+		2: "BARCODE_SYMBOLOGY_EAN13",
+	}
+	BarcodeSymbology_value = map[string]int32{
+		"BARCODE_SYMBOLOGY_UNSPECIFIED": 0,
+		"BARCODE_SYMBOLOGY_CODE128":     1,
+		"BARCODE_SYMBOLOGY_EAN13":       2,
+	}
+)
+
+func (x BarcodeSymbology) Enum() *BarcodeSymbology {
+	p := new(BarcodeSymbology)
+	*p = x
+	return p
+}
+
+func (x BarcodeSymbology) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (BarcodeSymbology) Descriptor() protoreflect.EnumDescriptor {
+	return file_library_proto_enumTypes[4].Descriptor()
+}
+
+func (BarcodeSymbology) Type() protoreflect.EnumType {
+	return &file_library_proto_enumTypes[4]
+}
+
+func (x BarcodeSymbology) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use BarcodeSymbology.Descriptor instead.
+func (BarcodeSymbology) EnumDescriptor() ([]byte, []int) {
+	return file_library_proto_rawDescGZIP(), []int{4}
+}
+
+// CopyCondition is the physical state of a copy when it was acquired
+type CopyCondition int32
+
+const (
+	// Not recorded
+	CopyCondition_COPY_CONDITION_UNSPECIFIED CopyCondition = 0
+	CopyCondition_COPY_CONDITION_NEW         CopyCondition = 1
+	CopyCondition_COPY_CONDITION_GOOD        CopyCondition = 2
+	CopyCondition_COPY_CONDITION_FAIR        CopyCondition = 3
+	CopyCondition_COPY_CONDITION_POOR        CopyCondition = 4
+	CopyCondition_COPY_CONDITION_DAMAGED     CopyCondition = 5
+)
+
+// Enum value maps for CopyCondition.
+var (
+	CopyCondition_name = map[int32]string{
+		0: "COPY_CONDITION_UNSPECIFIED",
+		1: "COPY_CONDITION_NEW",
+		2: "COPY_CONDITION_GOOD",
+		3: "COPY_CONDITION_FAIR",
+		4: "COPY_CONDITION_POOR",
+		5: "COPY_CONDITION_DAMAGED",
+	}
+	CopyCondition_value = map[string]int32{
+		"COPY_CONDITION_UNSPECIFIED": 0,
+		"COPY_CONDITION_NEW":         1,
+		"COPY_CONDITION_GOOD":        2,
+		"COPY_CONDITION_FAIR":        3,
+		"COPY_CONDITION_POOR":        4,
+		"COPY_CONDITION_DAMAGED":     5,
+	}
+)
+
+func (x CopyCondition) Enum() *CopyCondition {
+	p := new(CopyCondition)
+	*p = x
+	return p
+}
+
+func (x CopyCondition) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (CopyCondition) Descriptor() protoreflect.EnumDescriptor {
+	return file_library_proto_enumTypes[5].Descriptor()
+}
+
+func (CopyCondition) Type() protoreflect.EnumType {
+	return &file_library_proto_enumTypes[5]
+}
+
+func (x CopyCondition) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use CopyCondition.Descriptor instead.
+func (CopyCondition) EnumDescriptor() ([]byte, []int) {
+	return file_library_proto_rawDescGZIP(), []int{5}
+}
+
+type AuthEventType int32
+
+const (
+	AuthEventType_AUTH_EVENT_TYPE_UNSPECIFIED          AuthEventType = 0
+	AuthEventType_AUTH_EVENT_TYPE_REGISTER             AuthEventType = 1
+	AuthEventType_AUTH_EVENT_TYPE_LOGIN                AuthEventType = 2
+	AuthEventType_AUTH_EVENT_TYPE_LOGIN_FAILED         AuthEventType = 3
+	AuthEventType_AUTH_EVENT_TYPE_TOKEN_REFRESH        AuthEventType = 4
+	AuthEventType_AUTH_EVENT_TYPE_TOKEN_REFRESH_FAILED AuthEventType = 5
+)
+
+// Enum value maps for AuthEventType.
+var (
+	AuthEventType_name = map[int32]string{
+		0: "AUTH_EVENT_TYPE_UNSPECIFIED",
+		1: "AUTH_EVENT_TYPE_REGISTER",
+		2: "AUTH_EVENT_TYPE_LOGIN",
+		3: "AUTH_EVENT_TYPE_LOGIN_FAILED",
+		4: "AUTH_EVENT_TYPE_TOKEN_REFRESH",
+		5: "AUTH_EVENT_TYPE_TOKEN_REFRESH_FAILED",
+	}
+	AuthEventType_value = map[string]int32{
+		"AUTH_EVENT_TYPE_UNSPECIFIED":          0,
+		"AUTH_EVENT_TYPE_REGISTER":             1,
+		"AUTH_EVENT_TYPE_LOGIN":                2,
+		"AUTH_EVENT_TYPE_LOGIN_FAILED":         3,
+		"AUTH_EVENT_TYPE_TOKEN_REFRESH":        4,
+		"AUTH_EVENT_TYPE_TOKEN_REFRESH_FAILED": 5,
+	}
+)
+
+func (x AuthEventType) Enum() *AuthEventType {
+	p := new(AuthEventType)
+	*p = x
+	return p
+}
+
+func (x AuthEventType) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (AuthEventType) Descriptor() protoreflect.EnumDescriptor {
+	return file_library_proto_enumTypes[6].Descriptor()
+}
+
+func (AuthEventType) Type() protoreflect.EnumType {
+	return &file_library_proto_enumTypes[6]
+}
+
+func (x AuthEventType) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use AuthEventType.Descriptor instead.
+func (AuthEventType) EnumDescriptor() ([]byte, []int) {
+	return file_library_proto_rawDescGZIP(), []int{6}
+}
+
 type User struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Username      string                 `protobuf:"bytes,1,opt,name=username,proto3" json:"username,omitempty"`
-	Password      string                 `protobuf:"bytes,2,opt,name=password,proto3" json:"password,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Username string                 `protobuf:"bytes,1,opt,name=username,proto3" json:"username,omitempty"`
+	Password string                 `protobuf:"bytes,2,opt,name=password,proto3" json:"password,omitempty"`
+	// Optional unless the server requires verified emails; a verification token is sent to it
+	Email string `protobuf:"bytes,3,opt,name=email,proto3" json:"email,omitempty"`
+	// Response token of the bot challenge widget (Turnstile or hCaptcha), required when the server enables one
+	ChallengeToken string `protobuf:"bytes,4,opt,name=challenge_token,json=challengeToken,proto3" json:"challenge_token,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
 }
 
 func (x *User) Reset() {
@@ -74,6 +481,20 @@ func (x *User) GetPassword() string {
 	return ""
 }
 
+func (x *User) GetEmail() string {
+	if x != nil {
+		return x.Email
+	}
+	return ""
+}
+
+func (x *User) GetChallengeToken() string {
+	if x != nil {
+		return x.ChallengeToken
+	}
+	return ""
+}
+
 type UserCredentials struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Username      string                 `protobuf:"bytes,1,opt,name=username,proto3" json:"username,omitempty"`
@@ -127,11 +548,16 @@ func (x *UserCredentials) GetPassword() string {
 }
 
 type AuthResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Token         string                 `protobuf:"bytes,1,opt,name=token,proto3" json:"token,omitempty"`
-	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Token   string                 `protobuf:"bytes,1,opt,name=token,proto3" json:"token,omitempty"`
+	Message string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	// Long-lived token for RefreshToken; single use
+	RefreshToken string `protobuf:"bytes,3,opt,name=refresh_token,json=refreshToken,proto3" json:"refresh_token,omitempty"`
+	// When token expires
+	ExpiresAt        *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
+	RefreshExpiresAt *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=refresh_expires_at,json=refreshExpiresAt,proto3" json:"refresh_expires_at,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
 }
 
 func (x *AuthResponse) Reset() {
@@ -178,9 +604,34 @@ func (x *AuthResponse) GetMessage() string {
 	return ""
 }
 
+func (x *AuthResponse) GetRefreshToken() string {
+	if x != nil {
+		return x.RefreshToken
+	}
+	return ""
+}
+
+func (x *AuthResponse) GetExpiresAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return nil
+}
+
+func (x *AuthResponse) GetRefreshExpiresAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.RefreshExpiresAt
+	}
+	return nil
+}
+
 type BookRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Id    string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	// Preferred language (BCP 47, e.g. "pt-BR") for GetBook; defaults to the accept-language header
+	Locale string `protobuf:"bytes,2,opt,name=locale,proto3" json:"locale,omitempty"`
+	// Maximum number of books returned by GetRelatedBooks
+	Limit         int32 `protobuf:"varint,3,opt,name=limit,proto3" json:"limit,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -222,10 +673,27 @@ func (x *BookRequest) GetId() string {
 	return ""
 }
 
+func (x *BookRequest) GetLocale() string {
+	if x != nil {
+		return x.Locale
+	}
+	return ""
+}
+
+func (x *BookRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
 type BookResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
-	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The id of the book, generated by the server for books added without one
+	Id string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	// Why this book failed, in BatchResponse entries; unset for books that succeeded. Single-book RPCs
+	// fail with the status itself.
+	Error         *status.Status `protobuf:"bytes,3,opt,name=error,proto3" json:"error,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -267,11 +735,11 @@ func (x *BookResponse) GetId() string {
 	return ""
 }
 
-func (x *BookResponse) GetMessage() string {
+func (x *BookResponse) GetError() *status.Status {
 	if x != nil {
-		return x.Message
+		return x.Error
 	}
-	return ""
+	return nil
 }
 
 type Book struct {
@@ -279,6 +747,34 @@ type Book struct {
 	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
 	Title         string                 `protobuf:"bytes,2,opt,name=title,proto3" json:"title,omitempty"`
 	Author        string                 `protobuf:"bytes,3,opt,name=author,proto3" json:"author,omitempty"`
+	Isbn          string                 `protobuf:"bytes,4,opt,name=isbn,proto3" json:"isbn,omitempty"`
+	CoverUrl      string                 `protobuf:"bytes,5,opt,name=cover_url,json=coverUrl,proto3" json:"cover_url,omitempty"`
+	Publisher     string                 `protobuf:"bytes,6,opt,name=publisher,proto3" json:"publisher,omitempty"`
+	Tags          []string               `protobuf:"bytes,7,rep,name=tags,proto3" json:"tags,omitempty"`
+	AverageRating float64                `protobuf:"fixed64,8,opt,name=average_rating,json=averageRating,proto3" json:"average_rating,omitempty"`
+	ReviewCount   int32                  `protobuf:"varint,9,opt,name=review_count,json=reviewCount,proto3" json:"review_count,omitempty"`
+	// Number of physical copies owned; defaults to 1 when adding a book
+	TotalCopies int32 `protobuf:"varint,10,opt,name=total_copies,json=totalCopies,proto3" json:"total_copies,omitempty"`
+	// Copies not currently on loan (read-only)
+	AvailableCopies int32 `protobuf:"varint,11,opt,name=available_copies,json=availableCopies,proto3" json:"available_copies,omitempty"`
+	// Request-only: lets AddBook and BatchAddBooks add a book matching an existing one by ISBN or title and author
+	AllowDuplicate bool `protobuf:"varint,12,opt,name=allow_duplicate,json=allowDuplicate,proto3" json:"allow_duplicate,omitempty"`
+	// Unset when the book is not part of a series
+	Series      *BookSeries `protobuf:"bytes,13,opt,name=series,proto3" json:"series,omitempty"`
+	Description string      `protobuf:"bytes,14,opt,name=description,proto3" json:"description,omitempty"`
+	// Language of title and description when a translation was returned (read-only); empty for the original
+	Language string `protobuf:"bytes,15,opt,name=language,proto3" json:"language,omitempty"`
+	// Where the physical copies are kept; unset when not recorded
+	Location *Location `protobuf:"bytes,16,opt,name=location,proto3" json:"location,omitempty"`
+	// Publisher record the book references; when 0, publisher is matched by name or a new publisher is created
+	PublisherId int32 `protobuf:"varint,17,opt,name=publisher_id,json=publisherId,proto3" json:"publisher_id,omitempty"`
+	// When the book was added (read-only)
+	CreatedAt *timestamppb.Timestamp `protobuf:"bytes,18,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	// When the book's details or copies were last edited (read-only); loans and returns don't change it
+	UpdatedAt *timestamppb.Timestamp `protobuf:"bytes,19,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	// User who added the book, who may update and delete it besides admins (read-only); 0 when it was
+	// added by a service or before owners were recorded, which leaves it to admins
+	OwnerId       int32 `protobuf:"varint,20,opt,name=owner_id,json=ownerId,proto3" json:"owner_id,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -334,81 +830,6043 @@ func (x *Book) GetAuthor() string {
 	return ""
 }
 
-type ListBookRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Page          int32                  `protobuf:"varint,1,opt,name=page,proto3" json:"page,omitempty"`
-	PageSize      int32                  `protobuf:"varint,2,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+func (x *Book) GetIsbn() string {
+	if x != nil {
+		return x.Isbn
+	}
+	return ""
 }
 
-func (x *ListBookRequest) Reset() {
-	*x = ListBookRequest{}
-	mi := &file_library_proto_msgTypes[6]
-	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-	ms.StoreMessageInfo(mi)
+func (x *Book) GetCoverUrl() string {
+	if x != nil {
+		return x.CoverUrl
+	}
+	return ""
 }
 
-func (x *ListBookRequest) String() string {
-	return protoimpl.X.MessageStringOf(x)
+func (x *Book) GetPublisher() string {
+	if x != nil {
+		return x.Publisher
+	}
+	return ""
 }
 
-func (*ListBookRequest) ProtoMessage() {}
-
-func (x *ListBookRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_library_proto_msgTypes[6]
+func (x *Book) GetTags() []string {
 	if x != nil {
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		if ms.LoadMessageInfo() == nil {
-			ms.StoreMessageInfo(mi)
-		}
-		return ms
+		return x.Tags
 	}
-	return mi.MessageOf(x)
+	return nil
 }
 
-// Deprecated: Use ListBookRequest.ProtoReflect.Descriptor instead.
-func (*ListBookRequest) Descriptor() ([]byte, []int) {
-	return file_library_proto_rawDescGZIP(), []int{6}
+func (x *Book) GetAverageRating() float64 {
+	if x != nil {
+		return x.AverageRating
+	}
+	return 0
 }
 
-func (x *ListBookRequest) GetPage() int32 {
+func (x *Book) GetReviewCount() int32 {
 	if x != nil {
-		return x.Page
+		return x.ReviewCount
 	}
 	return 0
 }
 
-func (x *ListBookRequest) GetPageSize() int32 {
+func (x *Book) GetTotalCopies() int32 {
 	if x != nil {
-		return x.PageSize
+		return x.TotalCopies
 	}
 	return 0
 }
 
-type ListBookResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Books         []*Book                `protobuf:"bytes,1,rep,name=books,proto3" json:"books,omitempty"`
-	TotalCount    int32                  `protobuf:"varint,2,opt,name=total_count,json=totalCount,proto3" json:"total_count,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+func (x *Book) GetAvailableCopies() int32 {
+	if x != nil {
+		return x.AvailableCopies
+	}
+	return 0
 }
 
-func (x *ListBookResponse) Reset() {
-	*x = ListBookResponse{}
+func (x *Book) GetAllowDuplicate() bool {
+	if x != nil {
+		return x.AllowDuplicate
+	}
+	return false
+}
+
+func (x *Book) GetSeries() *BookSeries {
+	if x != nil {
+		return x.Series
+	}
+	return nil
+}
+
+func (x *Book) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *Book) GetLanguage() string {
+	if x != nil {
+		return x.Language
+	}
+	return ""
+}
+
+func (x *Book) GetLocation() *Location {
+	if x != nil {
+		return x.Location
+	}
+	return nil
+}
+
+func (x *Book) GetPublisherId() int32 {
+	if x != nil {
+		return x.PublisherId
+	}
+	return 0
+}
+
+func (x *Book) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *Book) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+func (x *Book) GetOwnerId() int32 {
+	if x != nil {
+		return x.OwnerId
+	}
+	return 0
+}
+
+// Location identifies where a book's physical copies are shelved
+type Location struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Branch        string                 `protobuf:"bytes,1,opt,name=branch,proto3" json:"branch,omitempty"`
+	Section       string                 `protobuf:"bytes,2,opt,name=section,proto3" json:"section,omitempty"`
+	Shelf         string                 `protobuf:"bytes,3,opt,name=shelf,proto3" json:"shelf,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Location) Reset() {
+	*x = Location{}
+	mi := &file_library_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Location) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Location) ProtoMessage() {}
+
+func (x *Location) ProtoReflect() protoreflect.Message {
+	mi := &file_library_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Location.ProtoReflect.Descriptor instead.
+func (*Location) Descriptor() ([]byte, []int) {
+	return file_library_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *Location) GetBranch() string {
+	if x != nil {
+		return x.Branch
+	}
+	return ""
+}
+
+func (x *Location) GetSection() string {
+	if x != nil {
+		return x.Section
+	}
+	return ""
+}
+
+func (x *Location) GetShelf() string {
+	if x != nil {
+		return x.Shelf
+	}
+	return ""
+}
+
+// BookSeries places a book in a named series
+type BookSeries struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Name  string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// Position within the series; 0 when unnumbered
+	Volume        int32 `protobuf:"varint,2,opt,name=volume,proto3" json:"volume,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BookSeries) Reset() {
+	*x = BookSeries{}
+	mi := &file_library_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BookSeries) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BookSeries) ProtoMessage() {}
+
+func (x *BookSeries) ProtoReflect() protoreflect.Message {
 	mi := &file_library_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BookSeries.ProtoReflect.Descriptor instead.
+func (*BookSeries) Descriptor() ([]byte, []int) {
+	return file_library_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *BookSeries) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *BookSeries) GetVolume() int32 {
+	if x != nil {
+		return x.Volume
+	}
+	return 0
+}
+
+type ListBookRequest struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Page     int32                  `protobuf:"varint,1,opt,name=page,proto3" json:"page,omitempty"`
+	PageSize int32                  `protobuf:"varint,2,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	// Only return books carrying all of these tags
+	Tags []string `protobuf:"bytes,3,rep,name=tags,proto3" json:"tags,omitempty"`
+	// Preferred language (BCP 47) for titles and descriptions; defaults to the accept-language header
+	Locale string `protobuf:"bytes,4,opt,name=locale,proto3" json:"locale,omitempty"`
+	// Only return books shelved at this branch
+	Branch string `protobuf:"bytes,5,opt,name=branch,proto3" json:"branch,omitempty"`
+	// Only return books shelved in this section
+	Section string `protobuf:"bytes,6,opt,name=section,proto3" json:"section,omitempty"`
+	// Only return books from this publisher
+	PublisherId int32 `protobuf:"varint,7,opt,name=publisher_id,json=publisherId,proto3" json:"publisher_id,omitempty"`
+	// Only return books by this author, as stored
+	Author string `protobuf:"bytes,8,opt,name=author,proto3" json:"author,omitempty"`
+	// List the most recently added books first, by created_at, rather than by ID
+	NewestFirst bool `protobuf:"varint,9,opt,name=newest_first,json=newestFirst,proto3" json:"newest_first,omitempty"`
+	// Count every book for total_count even when no filter is set, rather than estimating a large catalog
+	ExactCount bool `protobuf:"varint,10,opt,name=exact_count,json=exactCount,proto3" json:"exact_count,omitempty"`
+	// Token of the page to return, from next_page_token of the previous one; takes the place of page
+	PageToken     string `protobuf:"bytes,11,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListBookRequest) Reset() {
+	*x = ListBookRequest{}
+	mi := &file_library_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListBookRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListBookRequest) ProtoMessage() {}
+
+func (x *ListBookRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_library_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListBookRequest.ProtoReflect.Descriptor instead.
+func (*ListBookRequest) Descriptor() ([]byte, []int) {
+	return file_library_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *ListBookRequest) GetPage() int32 {
+	if x != nil {
+		return x.Page
+	}
+	return 0
+}
+
+func (x *ListBookRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *ListBookRequest) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *ListBookRequest) GetLocale() string {
+	if x != nil {
+		return x.Locale
+	}
+	return ""
+}
+
+func (x *ListBookRequest) GetBranch() string {
+	if x != nil {
+		return x.Branch
+	}
+	return ""
+}
+
+func (x *ListBookRequest) GetSection() string {
+	if x != nil {
+		return x.Section
+	}
+	return ""
+}
+
+func (x *ListBookRequest) GetPublisherId() int32 {
+	if x != nil {
+		return x.PublisherId
+	}
+	return 0
+}
+
+func (x *ListBookRequest) GetAuthor() string {
+	if x != nil {
+		return x.Author
+	}
+	return ""
+}
+
+func (x *ListBookRequest) GetNewestFirst() bool {
+	if x != nil {
+		return x.NewestFirst
+	}
+	return false
+}
+
+func (x *ListBookRequest) GetExactCount() bool {
+	if x != nil {
+		return x.ExactCount
+	}
+	return false
+}
+
+func (x *ListBookRequest) GetPageToken() string {
+	if x != nil {
+		return x.PageToken
+	}
+	return ""
+}
+
+type SearchBooksRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Words to find; each matches words starting with it, and books must match them all
+	Query    string `protobuf:"bytes,1,opt,name=query,proto3" json:"query,omitempty"`
+	Page     int32  `protobuf:"varint,2,opt,name=page,proto3" json:"page,omitempty"`
+	PageSize int32  `protobuf:"varint,3,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	// Preferred language (BCP 47) for titles and descriptions; defaults to the accept-language header
+	Locale        string `protobuf:"bytes,4,opt,name=locale,proto3" json:"locale,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SearchBooksRequest) Reset() {
+	*x = SearchBooksRequest{}
+	mi := &file_library_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SearchBooksRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchBooksRequest) ProtoMessage() {}
+
+func (x *SearchBooksRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_library_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchBooksRequest.ProtoReflect.Descriptor instead.
+func (*SearchBooksRequest) Descriptor() ([]byte, []int) {
+	return file_library_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *SearchBooksRequest) GetQuery() string {
+	if x != nil {
+		return x.Query
+	}
+	return ""
+}
+
+func (x *SearchBooksRequest) GetPage() int32 {
+	if x != nil {
+		return x.Page
+	}
+	return 0
+}
+
+func (x *SearchBooksRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *SearchBooksRequest) GetLocale() string {
+	if x != nil {
+		return x.Locale
+	}
+	return ""
+}
+
+type ListBookResponse struct {
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	Books      []*Book                `protobuf:"bytes,1,rep,name=books,proto3" json:"books,omitempty"`
+	TotalCount int32                  `protobuf:"varint,2,opt,name=total_count,json=totalCount,proto3" json:"total_count,omitempty"`
+	// Set when total_count is an estimate, which can be off by the books added or deleted lately
+	TotalCountEstimated bool `protobuf:"varint,3,opt,name=total_count_estimated,json=totalCountEstimated,proto3" json:"total_count_estimated,omitempty"`
+	// Token of the next page, empty on the last one and from SearchBooks, which pages by number only
+	NextPageToken string `protobuf:"bytes,4,opt,name=next_page_token,json=nextPageToken,proto3" json:"next_page_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListBookResponse) Reset() {
+	*x = ListBookResponse{}
+	mi := &file_library_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListBookResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListBookResponse) ProtoMessage() {}
+
+func (x *ListBookResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_library_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListBookResponse.ProtoReflect.Descriptor instead.
+func (*ListBookResponse) Descriptor() ([]byte, []int) {
+	return file_library_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *ListBookResponse) GetBooks() []*Book {
+	if x != nil {
+		return x.Books
+	}
+	return nil
+}
+
+func (x *ListBookResponse) GetTotalCount() int32 {
+	if x != nil {
+		return x.TotalCount
+	}
+	return 0
+}
+
+func (x *ListBookResponse) GetTotalCountEstimated() bool {
+	if x != nil {
+		return x.TotalCountEstimated
+	}
+	return false
+}
+
+func (x *ListBookResponse) GetNextPageToken() string {
+	if x != nil {
+		return x.NextPageToken
+	}
+	return ""
+}
+
+type BatchResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Responses     []*BookResponse        `protobuf:"bytes,1,rep,name=responses,proto3" json:"responses,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchResponse) Reset() {
+	*x = BatchResponse{}
+	mi := &file_library_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchResponse) ProtoMessage() {}
+
+func (x *BatchResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_library_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchResponse.ProtoReflect.Descriptor instead.
+func (*BatchResponse) Descriptor() ([]byte, []int) {
+	return file_library_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *BatchResponse) GetResponses() []*BookResponse {
+	if x != nil {
+		return x.Responses
+	}
+	return nil
+}
+
+type BulkUpdateRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Books         []*Book                `protobuf:"bytes,1,rep,name=books,proto3" json:"books,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BulkUpdateRequest) Reset() {
+	*x = BulkUpdateRequest{}
+	mi := &file_library_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BulkUpdateRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BulkUpdateRequest) ProtoMessage() {}
+
+func (x *BulkUpdateRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_library_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BulkUpdateRequest.ProtoReflect.Descriptor instead.
+func (*BulkUpdateRequest) Descriptor() ([]byte, []int) {
+	return file_library_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *BulkUpdateRequest) GetBooks() []*Book {
+	if x != nil {
+		return x.Books
+	}
+	return nil
+}
+
+type PatchBookRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Id    string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	// New values of the fields to change; its id is ignored
+	Book *Book `protobuf:"bytes,2,opt,name=book,proto3" json:"book,omitempty"`
+	// Fields to change: title, author, isbn, publisher, publisher_id, description, total_copies, location or
+	// series; empty changes those of them set in book. The gateway fills it in with the fields of the JSON body.
+	UpdateMask    *fieldmaskpb.FieldMask `protobuf:"bytes,3,opt,name=update_mask,json=updateMask,proto3" json:"update_mask,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PatchBookRequest) Reset() {
+	*x = PatchBookRequest{}
+	mi := &file_library_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PatchBookRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PatchBookRequest) ProtoMessage() {}
+
+func (x *PatchBookRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_library_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PatchBookRequest.ProtoReflect.Descriptor instead.
+func (*PatchBookRequest) Descriptor() ([]byte, []int) {
+	return file_library_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *PatchBookRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *PatchBookRequest) GetBook() *Book {
+	if x != nil {
+		return x.Book
+	}
+	return nil
+}
+
+func (x *PatchBookRequest) GetUpdateMask() *fieldmaskpb.FieldMask {
+	if x != nil {
+		return x.UpdateMask
+	}
+	return nil
+}
+
+type BookRevision struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	BookId        string                 `protobuf:"bytes,2,opt,name=book_id,json=bookId,proto3" json:"book_id,omitempty"`
+	Action        RevisionAction         `protobuf:"varint,3,opt,name=action,proto3,enum=library.RevisionAction" json:"action,omitempty"`
+	UserId        int32                  `protobuf:"varint,4,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Username      string                 `protobuf:"bytes,5,opt,name=username,proto3" json:"username,omitempty"`
+	ChangedAt     *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=changed_at,json=changedAt,proto3" json:"changed_at,omitempty"`
+	OldBook       *Book                  `protobuf:"bytes,7,opt,name=old_book,json=oldBook,proto3" json:"old_book,omitempty"`
+	NewBook       *Book                  `protobuf:"bytes,8,opt,name=new_book,json=newBook,proto3" json:"new_book,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BookRevision) Reset() {
+	*x = BookRevision{}
+	mi := &file_library_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BookRevision) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BookRevision) ProtoMessage() {}
+
+func (x *BookRevision) ProtoReflect() protoreflect.Message {
+	mi := &file_library_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BookRevision.ProtoReflect.Descriptor instead.
+func (*BookRevision) Descriptor() ([]byte, []int) {
+	return file_library_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *BookRevision) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *BookRevision) GetBookId() string {
+	if x != nil {
+		return x.BookId
+	}
+	return ""
+}
+
+func (x *BookRevision) GetAction() RevisionAction {
+	if x != nil {
+		return x.Action
+	}
+	return RevisionAction_REVISION_ACTION_UNSPECIFIED
+}
+
+func (x *BookRevision) GetUserId() int32 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+func (x *BookRevision) GetUsername() string {
+	if x != nil {
+		return x.Username
+	}
+	return ""
+}
+
+func (x *BookRevision) GetChangedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ChangedAt
+	}
+	return nil
+}
+
+func (x *BookRevision) GetOldBook() *Book {
+	if x != nil {
+		return x.OldBook
+	}
+	return nil
+}
+
+func (x *BookRevision) GetNewBook() *Book {
+	if x != nil {
+		return x.NewBook
+	}
+	return nil
+}
+
+type ExportRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Format        ExportFormat           `protobuf:"varint,1,opt,name=format,proto3,enum=library.ExportFormat" json:"format,omitempty"`
+	ChunkSize     int32                  `protobuf:"varint,2,opt,name=chunk_size,json=chunkSize,proto3" json:"chunk_size,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExportRequest) Reset() {
+	*x = ExportRequest{}
+	mi := &file_library_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExportRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExportRequest) ProtoMessage() {}
+
+func (x *ExportRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_library_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExportRequest.ProtoReflect.Descriptor instead.
+func (*ExportRequest) Descriptor() ([]byte, []int) {
+	return file_library_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *ExportRequest) GetFormat() ExportFormat {
+	if x != nil {
+		return x.Format
+	}
+	return ExportFormat_EXPORT_FORMAT_UNSPECIFIED
+}
+
+func (x *ExportRequest) GetChunkSize() int32 {
+	if x != nil {
+		return x.ChunkSize
+	}
+	return 0
+}
+
+type ExportChunk struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Data          []byte                 `protobuf:"bytes,1,opt,name=data,proto3" json:"data,omitempty"`
+	RowCount      int32                  `protobuf:"varint,2,opt,name=row_count,json=rowCount,proto3" json:"row_count,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExportChunk) Reset() {
+	*x = ExportChunk{}
+	mi := &file_library_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExportChunk) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExportChunk) ProtoMessage() {}
+
+func (x *ExportChunk) ProtoReflect() protoreflect.Message {
+	mi := &file_library_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExportChunk.ProtoReflect.Descriptor instead.
+func (*ExportChunk) Descriptor() ([]byte, []int) {
+	return file_library_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *ExportChunk) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+func (x *ExportChunk) GetRowCount() int32 {
+	if x != nil {
+		return x.RowCount
+	}
+	return 0
+}
+
+type ImportRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Format of line; only needs to be set on the first message
+	Format ExportFormat `protobuf:"varint,1,opt,name=format,proto3,enum=library.ExportFormat" json:"format,omitempty"`
+	// One CSV row or one JSON object per message
+	Line          string `protobuf:"bytes,2,opt,name=line,proto3" json:"line,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ImportRequest) Reset() {
+	*x = ImportRequest{}
+	mi := &file_library_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ImportRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ImportRequest) ProtoMessage() {}
+
+func (x *ImportRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_library_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ImportRequest.ProtoReflect.Descriptor instead.
+func (*ImportRequest) Descriptor() ([]byte, []int) {
+	return file_library_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *ImportRequest) GetFormat() ExportFormat {
+	if x != nil {
+		return x.Format
+	}
+	return ExportFormat_EXPORT_FORMAT_UNSPECIFIED
+}
+
+func (x *ImportRequest) GetLine() string {
+	if x != nil {
+		return x.Line
+	}
+	return ""
+}
+
+type ImportFailure struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	LineNumber    int32                  `protobuf:"varint,1,opt,name=line_number,json=lineNumber,proto3" json:"line_number,omitempty"`
+	Id            string                 `protobuf:"bytes,2,opt,name=id,proto3" json:"id,omitempty"`
+	Reason        string                 `protobuf:"bytes,3,opt,name=reason,proto3" json:"reason,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ImportFailure) Reset() {
+	*x = ImportFailure{}
+	mi := &file_library_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ImportFailure) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ImportFailure) ProtoMessage() {}
+
+func (x *ImportFailure) ProtoReflect() protoreflect.Message {
+	mi := &file_library_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ImportFailure.ProtoReflect.Descriptor instead.
+func (*ImportFailure) Descriptor() ([]byte, []int) {
+	return file_library_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *ImportFailure) GetLineNumber() int32 {
+	if x != nil {
+		return x.LineNumber
+	}
+	return 0
+}
+
+func (x *ImportFailure) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *ImportFailure) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+type ImportResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Inserted      int32                  `protobuf:"varint,1,opt,name=inserted,proto3" json:"inserted,omitempty"`
+	Skipped       int32                  `protobuf:"varint,2,opt,name=skipped,proto3" json:"skipped,omitempty"`
+	Failed        int32                  `protobuf:"varint,3,opt,name=failed,proto3" json:"failed,omitempty"`
+	Failures      []*ImportFailure       `protobuf:"bytes,4,rep,name=failures,proto3" json:"failures,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ImportResponse) Reset() {
+	*x = ImportResponse{}
+	mi := &file_library_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ImportResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ImportResponse) ProtoMessage() {}
+
+func (x *ImportResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_library_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ImportResponse.ProtoReflect.Descriptor instead.
+func (*ImportResponse) Descriptor() ([]byte, []int) {
+	return file_library_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *ImportResponse) GetInserted() int32 {
+	if x != nil {
+		return x.Inserted
+	}
+	return 0
+}
+
+func (x *ImportResponse) GetSkipped() int32 {
+	if x != nil {
+		return x.Skipped
+	}
+	return 0
+}
+
+func (x *ImportResponse) GetFailed() int32 {
+	if x != nil {
+		return x.Failed
+	}
+	return 0
+}
+
+func (x *ImportResponse) GetFailures() []*ImportFailure {
+	if x != nil {
+		return x.Failures
+	}
+	return nil
+}
+
+type CoverChunk struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// book_id and content_type only need to be set on the first chunk
+	BookId        string `protobuf:"bytes,1,opt,name=book_id,json=bookId,proto3" json:"book_id,omitempty"`
+	ContentType   string `protobuf:"bytes,2,opt,name=content_type,json=contentType,proto3" json:"content_type,omitempty"`
+	Data          []byte `protobuf:"bytes,3,opt,name=data,proto3" json:"data,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CoverChunk) Reset() {
+	*x = CoverChunk{}
+	mi := &file_library_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CoverChunk) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CoverChunk) ProtoMessage() {}
+
+func (x *CoverChunk) ProtoReflect() protoreflect.Message {
+	mi := &file_library_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CoverChunk.ProtoReflect.Descriptor instead.
+func (*CoverChunk) Descriptor() ([]byte, []int) {
+	return file_library_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *CoverChunk) GetBookId() string {
+	if x != nil {
+		return x.BookId
+	}
+	return ""
+}
+
+func (x *CoverChunk) GetContentType() string {
+	if x != nil {
+		return x.ContentType
+	}
+	return ""
+}
+
+func (x *CoverChunk) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+type CoverResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	BookId        string                 `protobuf:"bytes,1,opt,name=book_id,json=bookId,proto3" json:"book_id,omitempty"`
+	CoverUrl      string                 `protobuf:"bytes,2,opt,name=cover_url,json=coverUrl,proto3" json:"cover_url,omitempty"`
+	Size          int64                  `protobuf:"varint,3,opt,name=size,proto3" json:"size,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CoverResponse) Reset() {
+	*x = CoverResponse{}
+	mi := &file_library_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CoverResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CoverResponse) ProtoMessage() {}
+
+func (x *CoverResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_library_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CoverResponse.ProtoReflect.Descriptor instead.
+func (*CoverResponse) Descriptor() ([]byte, []int) {
+	return file_library_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *CoverResponse) GetBookId() string {
+	if x != nil {
+		return x.BookId
+	}
+	return ""
+}
+
+func (x *CoverResponse) GetCoverUrl() string {
+	if x != nil {
+		return x.CoverUrl
+	}
+	return ""
+}
+
+func (x *CoverResponse) GetSize() int64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+type IsbnRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Isbn          string                 `protobuf:"bytes,1,opt,name=isbn,proto3" json:"isbn,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *IsbnRequest) Reset() {
+	*x = IsbnRequest{}
+	mi := &file_library_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *IsbnRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*IsbnRequest) ProtoMessage() {}
+
+func (x *IsbnRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_library_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use IsbnRequest.ProtoReflect.Descriptor instead.
+func (*IsbnRequest) Descriptor() ([]byte, []int) {
+	return file_library_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *IsbnRequest) GetIsbn() string {
+	if x != nil {
+		return x.Isbn
+	}
+	return ""
+}
+
+type Tag struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int32                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	BookCount     int32                  `protobuf:"varint,3,opt,name=book_count,json=bookCount,proto3" json:"book_count,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Tag) Reset() {
+	*x = Tag{}
+	mi := &file_library_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Tag) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Tag) ProtoMessage() {}
+
+func (x *Tag) ProtoReflect() protoreflect.Message {
+	mi := &file_library_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Tag.ProtoReflect.Descriptor instead.
+func (*Tag) Descriptor() ([]byte, []int) {
+	return file_library_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *Tag) GetId() int32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Tag) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Tag) GetBookCount() int32 {
+	if x != nil {
+		return x.BookCount
+	}
+	return 0
+}
+
+type TagResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Tag           *Tag                   `protobuf:"bytes,1,opt,name=tag,proto3" json:"tag,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TagResponse) Reset() {
+	*x = TagResponse{}
+	mi := &file_library_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TagResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TagResponse) ProtoMessage() {}
+
+func (x *TagResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_library_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TagResponse.ProtoReflect.Descriptor instead.
+func (*TagResponse) Descriptor() ([]byte, []int) {
+	return file_library_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *TagResponse) GetTag() *Tag {
+	if x != nil {
+		return x.Tag
+	}
+	return nil
+}
+
+type ListTagsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListTagsRequest) Reset() {
+	*x = ListTagsRequest{}
+	mi := &file_library_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListTagsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListTagsRequest) ProtoMessage() {}
+
+func (x *ListTagsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_library_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListTagsRequest.ProtoReflect.Descriptor instead.
+func (*ListTagsRequest) Descriptor() ([]byte, []int) {
+	return file_library_proto_rawDescGZIP(), []int{25}
+}
+
+type ListTagsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Tags          []*Tag                 `protobuf:"bytes,1,rep,name=tags,proto3" json:"tags,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListTagsResponse) Reset() {
+	*x = ListTagsResponse{}
+	mi := &file_library_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListTagsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListTagsResponse) ProtoMessage() {}
+
+func (x *ListTagsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_library_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListTagsResponse.ProtoReflect.Descriptor instead.
+func (*ListTagsResponse) Descriptor() ([]byte, []int) {
+	return file_library_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *ListTagsResponse) GetTags() []*Tag {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+type BookTagRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	BookId        string                 `protobuf:"bytes,1,opt,name=book_id,json=bookId,proto3" json:"book_id,omitempty"`
+	Tag           string                 `protobuf:"bytes,2,opt,name=tag,proto3" json:"tag,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BookTagRequest) Reset() {
+	*x = BookTagRequest{}
+	mi := &file_library_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BookTagRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BookTagRequest) ProtoMessage() {}
+
+func (x *BookTagRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_library_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BookTagRequest.ProtoReflect.Descriptor instead.
+func (*BookTagRequest) Descriptor() ([]byte, []int) {
+	return file_library_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *BookTagRequest) GetBookId() string {
+	if x != nil {
+		return x.BookId
+	}
+	return ""
+}
+
+func (x *BookTagRequest) GetTag() string {
+	if x != nil {
+		return x.Tag
+	}
+	return ""
+}
+
+type Review struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Id       int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	BookId   string                 `protobuf:"bytes,2,opt,name=book_id,json=bookId,proto3" json:"book_id,omitempty"`
+	UserId   int32                  `protobuf:"varint,3,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Username string                 `protobuf:"bytes,4,opt,name=username,proto3" json:"username,omitempty"`
+	// Star rating from 1 to 5
+	Rating        int32                  `protobuf:"varint,5,opt,name=rating,proto3" json:"rating,omitempty"`
+	Comment       string                 `protobuf:"bytes,6,opt,name=comment,proto3" json:"comment,omitempty"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Review) Reset() {
+	*x = Review{}
+	mi := &file_library_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Review) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Review) ProtoMessage() {}
+
+func (x *Review) ProtoReflect() protoreflect.Message {
+	mi := &file_library_proto_msgTypes[28]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Review.ProtoReflect.Descriptor instead.
+func (*Review) Descriptor() ([]byte, []int) {
+	return file_library_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *Review) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Review) GetBookId() string {
+	if x != nil {
+		return x.BookId
+	}
+	return ""
+}
+
+func (x *Review) GetUserId() int32 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+func (x *Review) GetUsername() string {
+	if x != nil {
+		return x.Username
+	}
+	return ""
+}
+
+func (x *Review) GetRating() int32 {
+	if x != nil {
+		return x.Rating
+	}
+	return 0
+}
+
+func (x *Review) GetComment() string {
+	if x != nil {
+		return x.Comment
+	}
+	return ""
+}
+
+func (x *Review) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+type ReviewRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReviewRequest) Reset() {
+	*x = ReviewRequest{}
+	mi := &file_library_proto_msgTypes[29]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReviewRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReviewRequest) ProtoMessage() {}
+
+func (x *ReviewRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_library_proto_msgTypes[29]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReviewRequest.ProtoReflect.Descriptor instead.
+func (*ReviewRequest) Descriptor() ([]byte, []int) {
+	return file_library_proto_rawDescGZIP(), []int{29}
+}
+
+func (x *ReviewRequest) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+type ReviewResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReviewResponse) Reset() {
+	*x = ReviewResponse{}
+	mi := &file_library_proto_msgTypes[30]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReviewResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReviewResponse) ProtoMessage() {}
+
+func (x *ReviewResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_library_proto_msgTypes[30]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReviewResponse.ProtoReflect.Descriptor instead.
+func (*ReviewResponse) Descriptor() ([]byte, []int) {
+	return file_library_proto_rawDescGZIP(), []int{30}
+}
+
+func (x *ReviewResponse) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+type ListReviewsRequest struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	BookId   string                 `protobuf:"bytes,1,opt,name=book_id,json=bookId,proto3" json:"book_id,omitempty"`
+	Page     int32                  `protobuf:"varint,2,opt,name=page,proto3" json:"page,omitempty"`
+	PageSize int32                  `protobuf:"varint,3,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	// Token of the page to return, from next_page_token of the previous one; takes the place of page
+	PageToken     string `protobuf:"bytes,4,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListReviewsRequest) Reset() {
+	*x = ListReviewsRequest{}
+	mi := &file_library_proto_msgTypes[31]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListReviewsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListReviewsRequest) ProtoMessage() {}
+
+func (x *ListReviewsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_library_proto_msgTypes[31]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListReviewsRequest.ProtoReflect.Descriptor instead.
+func (*ListReviewsRequest) Descriptor() ([]byte, []int) {
+	return file_library_proto_rawDescGZIP(), []int{31}
+}
+
+func (x *ListReviewsRequest) GetBookId() string {
+	if x != nil {
+		return x.BookId
+	}
+	return ""
+}
+
+func (x *ListReviewsRequest) GetPage() int32 {
+	if x != nil {
+		return x.Page
+	}
+	return 0
+}
+
+func (x *ListReviewsRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *ListReviewsRequest) GetPageToken() string {
+	if x != nil {
+		return x.PageToken
+	}
+	return ""
+}
+
+type ListReviewsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Reviews       []*Review              `protobuf:"bytes,1,rep,name=reviews,proto3" json:"reviews,omitempty"`
+	TotalCount    int32                  `protobuf:"varint,2,opt,name=total_count,json=totalCount,proto3" json:"total_count,omitempty"`
+	AverageRating float64                `protobuf:"fixed64,3,opt,name=average_rating,json=averageRating,proto3" json:"average_rating,omitempty"`
+	// Token of the next page, empty on the last one
+	NextPageToken string `protobuf:"bytes,4,opt,name=next_page_token,json=nextPageToken,proto3" json:"next_page_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListReviewsResponse) Reset() {
+	*x = ListReviewsResponse{}
+	mi := &file_library_proto_msgTypes[32]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListReviewsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListReviewsResponse) ProtoMessage() {}
+
+func (x *ListReviewsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_library_proto_msgTypes[32]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListReviewsResponse.ProtoReflect.Descriptor instead.
+func (*ListReviewsResponse) Descriptor() ([]byte, []int) {
+	return file_library_proto_rawDescGZIP(), []int{32}
+}
+
+func (x *ListReviewsResponse) GetReviews() []*Review {
+	if x != nil {
+		return x.Reviews
+	}
+	return nil
+}
+
+func (x *ListReviewsResponse) GetTotalCount() int32 {
+	if x != nil {
+		return x.TotalCount
+	}
+	return 0
+}
+
+func (x *ListReviewsResponse) GetAverageRating() float64 {
+	if x != nil {
+		return x.AverageRating
+	}
+	return 0
+}
+
+func (x *ListReviewsResponse) GetNextPageToken() string {
+	if x != nil {
+		return x.NextPageToken
+	}
+	return ""
+}
+
+type FavoriteRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	BookId        string                 `protobuf:"bytes,1,opt,name=book_id,json=bookId,proto3" json:"book_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FavoriteRequest) Reset() {
+	*x = FavoriteRequest{}
+	mi := &file_library_proto_msgTypes[33]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FavoriteRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FavoriteRequest) ProtoMessage() {}
+
+func (x *FavoriteRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_library_proto_msgTypes[33]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FavoriteRequest.ProtoReflect.Descriptor instead.
+func (*FavoriteRequest) Descriptor() ([]byte, []int) {
+	return file_library_proto_rawDescGZIP(), []int{33}
+}
+
+func (x *FavoriteRequest) GetBookId() string {
+	if x != nil {
+		return x.BookId
+	}
+	return ""
+}
+
+type ListFavoritesRequest struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Page     int32                  `protobuf:"varint,1,opt,name=page,proto3" json:"page,omitempty"`
+	PageSize int32                  `protobuf:"varint,2,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	// Token of the page to return, from next_page_token of the previous one; takes the place of page
+	PageToken     string `protobuf:"bytes,3,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListFavoritesRequest) Reset() {
+	*x = ListFavoritesRequest{}
+	mi := &file_library_proto_msgTypes[34]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListFavoritesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListFavoritesRequest) ProtoMessage() {}
+
+func (x *ListFavoritesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_library_proto_msgTypes[34]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListFavoritesRequest.ProtoReflect.Descriptor instead.
+func (*ListFavoritesRequest) Descriptor() ([]byte, []int) {
+	return file_library_proto_rawDescGZIP(), []int{34}
+}
+
+func (x *ListFavoritesRequest) GetPage() int32 {
+	if x != nil {
+		return x.Page
+	}
+	return 0
+}
+
+func (x *ListFavoritesRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *ListFavoritesRequest) GetPageToken() string {
+	if x != nil {
+		return x.PageToken
+	}
+	return ""
+}
+
+type Shelf struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	Id          int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name        string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Description string                 `protobuf:"bytes,3,opt,name=description,proto3" json:"description,omitempty"`
+	BookCount   int32                  `protobuf:"varint,4,opt,name=book_count,json=bookCount,proto3" json:"book_count,omitempty"`
+	// Only populated by GetShelf
+	Books         []*Book                `protobuf:"bytes,5,rep,name=books,proto3" json:"books,omitempty"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Shelf) Reset() {
+	*x = Shelf{}
+	mi := &file_library_proto_msgTypes[35]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Shelf) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Shelf) ProtoMessage() {}
+
+func (x *Shelf) ProtoReflect() protoreflect.Message {
+	mi := &file_library_proto_msgTypes[35]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Shelf.ProtoReflect.Descriptor instead.
+func (*Shelf) Descriptor() ([]byte, []int) {
+	return file_library_proto_rawDescGZIP(), []int{35}
+}
+
+func (x *Shelf) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Shelf) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Shelf) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *Shelf) GetBookCount() int32 {
+	if x != nil {
+		return x.BookCount
+	}
+	return 0
+}
+
+func (x *Shelf) GetBooks() []*Book {
+	if x != nil {
+		return x.Books
+	}
+	return nil
+}
+
+func (x *Shelf) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+type ShelfRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ShelfRequest) Reset() {
+	*x = ShelfRequest{}
+	mi := &file_library_proto_msgTypes[36]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ShelfRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ShelfRequest) ProtoMessage() {}
+
+func (x *ShelfRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_library_proto_msgTypes[36]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ShelfRequest.ProtoReflect.Descriptor instead.
+func (*ShelfRequest) Descriptor() ([]byte, []int) {
+	return file_library_proto_rawDescGZIP(), []int{36}
+}
+
+func (x *ShelfRequest) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+type ShelfResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ShelfResponse) Reset() {
+	*x = ShelfResponse{}
+	mi := &file_library_proto_msgTypes[37]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ShelfResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ShelfResponse) ProtoMessage() {}
+
+func (x *ShelfResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_library_proto_msgTypes[37]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ShelfResponse.ProtoReflect.Descriptor instead.
+func (*ShelfResponse) Descriptor() ([]byte, []int) {
+	return file_library_proto_rawDescGZIP(), []int{37}
+}
+
+func (x *ShelfResponse) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+type ListShelvesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListShelvesRequest) Reset() {
+	*x = ListShelvesRequest{}
+	mi := &file_library_proto_msgTypes[38]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListShelvesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListShelvesRequest) ProtoMessage() {}
+
+func (x *ListShelvesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_library_proto_msgTypes[38]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListShelvesRequest.ProtoReflect.Descriptor instead.
+func (*ListShelvesRequest) Descriptor() ([]byte, []int) {
+	return file_library_proto_rawDescGZIP(), []int{38}
+}
+
+type ListShelvesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Shelves       []*Shelf               `protobuf:"bytes,1,rep,name=shelves,proto3" json:"shelves,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListShelvesResponse) Reset() {
+	*x = ListShelvesResponse{}
+	mi := &file_library_proto_msgTypes[39]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListShelvesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListShelvesResponse) ProtoMessage() {}
+
+func (x *ListShelvesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_library_proto_msgTypes[39]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListShelvesResponse.ProtoReflect.Descriptor instead.
+func (*ListShelvesResponse) Descriptor() ([]byte, []int) {
+	return file_library_proto_rawDescGZIP(), []int{39}
+}
+
+func (x *ListShelvesResponse) GetShelves() []*Shelf {
+	if x != nil {
+		return x.Shelves
+	}
+	return nil
+}
+
+type ShelfBookRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ShelfId       int64                  `protobuf:"varint,1,opt,name=shelf_id,json=shelfId,proto3" json:"shelf_id,omitempty"`
+	BookId        string                 `protobuf:"bytes,2,opt,name=book_id,json=bookId,proto3" json:"book_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ShelfBookRequest) Reset() {
+	*x = ShelfBookRequest{}
+	mi := &file_library_proto_msgTypes[40]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ShelfBookRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ShelfBookRequest) ProtoMessage() {}
+
+func (x *ShelfBookRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_library_proto_msgTypes[40]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ShelfBookRequest.ProtoReflect.Descriptor instead.
+func (*ShelfBookRequest) Descriptor() ([]byte, []int) {
+	return file_library_proto_rawDescGZIP(), []int{40}
+}
+
+func (x *ShelfBookRequest) GetShelfId() int64 {
+	if x != nil {
+		return x.ShelfId
+	}
+	return 0
+}
+
+func (x *ShelfBookRequest) GetBookId() string {
+	if x != nil {
+		return x.BookId
+	}
+	return ""
+}
+
+type RecommendationRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Maximum number of books to return (default 10, max 50)
+	Limit         int32 `protobuf:"varint,1,opt,name=limit,proto3" json:"limit,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RecommendationRequest) Reset() {
+	*x = RecommendationRequest{}
+	mi := &file_library_proto_msgTypes[41]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RecommendationRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RecommendationRequest) ProtoMessage() {}
+
+func (x *RecommendationRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_library_proto_msgTypes[41]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RecommendationRequest.ProtoReflect.Descriptor instead.
+func (*RecommendationRequest) Descriptor() ([]byte, []int) {
+	return file_library_proto_rawDescGZIP(), []int{41}
+}
+
+func (x *RecommendationRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+type Loan struct {
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	Id         int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	BookId     string                 `protobuf:"bytes,2,opt,name=book_id,json=bookId,proto3" json:"book_id,omitempty"`
+	BookTitle  string                 `protobuf:"bytes,3,opt,name=book_title,json=bookTitle,proto3" json:"book_title,omitempty"`
+	UserId     int32                  `protobuf:"varint,4,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	BorrowedAt *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=borrowed_at,json=borrowedAt,proto3" json:"borrowed_at,omitempty"`
+	DueAt      *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=due_at,json=dueAt,proto3" json:"due_at,omitempty"`
+	// Unset while the book is still on loan
+	ReturnedAt *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=returned_at,json=returnedAt,proto3" json:"returned_at,omitempty"`
+	// Set by the circulation job once the loan passes its due date unreturned
+	Overdue       bool `protobuf:"varint,8,opt,name=overdue,proto3" json:"overdue,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Loan) Reset() {
+	*x = Loan{}
+	mi := &file_library_proto_msgTypes[42]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Loan) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Loan) ProtoMessage() {}
+
+func (x *Loan) ProtoReflect() protoreflect.Message {
+	mi := &file_library_proto_msgTypes[42]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Loan.ProtoReflect.Descriptor instead.
+func (*Loan) Descriptor() ([]byte, []int) {
+	return file_library_proto_rawDescGZIP(), []int{42}
+}
+
+func (x *Loan) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Loan) GetBookId() string {
+	if x != nil {
+		return x.BookId
+	}
+	return ""
+}
+
+func (x *Loan) GetBookTitle() string {
+	if x != nil {
+		return x.BookTitle
+	}
+	return ""
+}
+
+func (x *Loan) GetUserId() int32 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+func (x *Loan) GetBorrowedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.BorrowedAt
+	}
+	return nil
+}
+
+func (x *Loan) GetDueAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.DueAt
+	}
+	return nil
+}
+
+func (x *Loan) GetReturnedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ReturnedAt
+	}
+	return nil
+}
+
+func (x *Loan) GetOverdue() bool {
+	if x != nil {
+		return x.Overdue
+	}
+	return false
+}
+
+type BorrowRequest struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	BookId string                 `protobuf:"bytes,1,opt,name=book_id,json=bookId,proto3" json:"book_id,omitempty"`
+	// Loan period in days (default 14, max 60)
+	LoanDays      int32 `protobuf:"varint,2,opt,name=loan_days,json=loanDays,proto3" json:"loan_days,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BorrowRequest) Reset() {
+	*x = BorrowRequest{}
+	mi := &file_library_proto_msgTypes[43]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BorrowRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BorrowRequest) ProtoMessage() {}
+
+func (x *BorrowRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_library_proto_msgTypes[43]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BorrowRequest.ProtoReflect.Descriptor instead.
+func (*BorrowRequest) Descriptor() ([]byte, []int) {
+	return file_library_proto_rawDescGZIP(), []int{43}
+}
+
+func (x *BorrowRequest) GetBookId() string {
+	if x != nil {
+		return x.BookId
+	}
+	return ""
+}
+
+func (x *BorrowRequest) GetLoanDays() int32 {
+	if x != nil {
+		return x.LoanDays
+	}
+	return 0
+}
+
+type LoanRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LoanRequest) Reset() {
+	*x = LoanRequest{}
+	mi := &file_library_proto_msgTypes[44]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LoanRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LoanRequest) ProtoMessage() {}
+
+func (x *LoanRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_library_proto_msgTypes[44]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LoanRequest.ProtoReflect.Descriptor instead.
+func (*LoanRequest) Descriptor() ([]byte, []int) {
+	return file_library_proto_rawDescGZIP(), []int{44}
+}
+
+func (x *LoanRequest) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+type ListLoansRequest struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	IncludeReturned bool                   `protobuf:"varint,1,opt,name=include_returned,json=includeReturned,proto3" json:"include_returned,omitempty"`
+	Page            int32                  `protobuf:"varint,2,opt,name=page,proto3" json:"page,omitempty"`
+	PageSize        int32                  `protobuf:"varint,3,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	// Token of the page to return, from next_page_token of the previous one; takes the place of page
+	PageToken     string `protobuf:"bytes,4,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListLoansRequest) Reset() {
+	*x = ListLoansRequest{}
+	mi := &file_library_proto_msgTypes[45]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListLoansRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListLoansRequest) ProtoMessage() {}
+
+func (x *ListLoansRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_library_proto_msgTypes[45]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListLoansRequest.ProtoReflect.Descriptor instead.
+func (*ListLoansRequest) Descriptor() ([]byte, []int) {
+	return file_library_proto_rawDescGZIP(), []int{45}
+}
+
+func (x *ListLoansRequest) GetIncludeReturned() bool {
+	if x != nil {
+		return x.IncludeReturned
+	}
+	return false
+}
+
+func (x *ListLoansRequest) GetPage() int32 {
+	if x != nil {
+		return x.Page
+	}
+	return 0
+}
+
+func (x *ListLoansRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *ListLoansRequest) GetPageToken() string {
+	if x != nil {
+		return x.PageToken
+	}
+	return ""
+}
+
+type ListLoansResponse struct {
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	Loans      []*Loan                `protobuf:"bytes,1,rep,name=loans,proto3" json:"loans,omitempty"`
+	TotalCount int32                  `protobuf:"varint,2,opt,name=total_count,json=totalCount,proto3" json:"total_count,omitempty"`
+	// Token of the next page, empty on the last one
+	NextPageToken string `protobuf:"bytes,3,opt,name=next_page_token,json=nextPageToken,proto3" json:"next_page_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListLoansResponse) Reset() {
+	*x = ListLoansResponse{}
+	mi := &file_library_proto_msgTypes[46]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListLoansResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListLoansResponse) ProtoMessage() {}
+
+func (x *ListLoansResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_library_proto_msgTypes[46]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListLoansResponse.ProtoReflect.Descriptor instead.
+func (*ListLoansResponse) Descriptor() ([]byte, []int) {
+	return file_library_proto_rawDescGZIP(), []int{46}
+}
+
+func (x *ListLoansResponse) GetLoans() []*Loan {
+	if x != nil {
+		return x.Loans
+	}
+	return nil
+}
+
+func (x *ListLoansResponse) GetTotalCount() int32 {
+	if x != nil {
+		return x.TotalCount
+	}
+	return 0
+}
+
+func (x *ListLoansResponse) GetNextPageToken() string {
+	if x != nil {
+		return x.NextPageToken
+	}
+	return ""
+}
+
+type Reservation struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	Id        int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	BookId    string                 `protobuf:"bytes,2,opt,name=book_id,json=bookId,proto3" json:"book_id,omitempty"`
+	BookTitle string                 `protobuf:"bytes,3,opt,name=book_title,json=bookTitle,proto3" json:"book_title,omitempty"`
+	Status    ReservationStatus      `protobuf:"varint,4,opt,name=status,proto3,enum=library.ReservationStatus" json:"status,omitempty"`
+	// 1-based position in the book's queue while waiting
+	QueuePosition int32                  `protobuf:"varint,5,opt,name=queue_position,json=queuePosition,proto3" json:"queue_position,omitempty"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	// When a ready hold lapses
+	ExpiresAt     *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Reservation) Reset() {
+	*x = Reservation{}
+	mi := &file_library_proto_msgTypes[47]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Reservation) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Reservation) ProtoMessage() {}
+
+func (x *Reservation) ProtoReflect() protoreflect.Message {
+	mi := &file_library_proto_msgTypes[47]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Reservation.ProtoReflect.Descriptor instead.
+func (*Reservation) Descriptor() ([]byte, []int) {
+	return file_library_proto_rawDescGZIP(), []int{47}
+}
+
+func (x *Reservation) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Reservation) GetBookId() string {
+	if x != nil {
+		return x.BookId
+	}
+	return ""
+}
+
+func (x *Reservation) GetBookTitle() string {
+	if x != nil {
+		return x.BookTitle
+	}
+	return ""
+}
+
+func (x *Reservation) GetStatus() ReservationStatus {
+	if x != nil {
+		return x.Status
+	}
+	return ReservationStatus_RESERVATION_STATUS_UNSPECIFIED
+}
+
+func (x *Reservation) GetQueuePosition() int32 {
+	if x != nil {
+		return x.QueuePosition
+	}
+	return 0
+}
+
+func (x *Reservation) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *Reservation) GetExpiresAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return nil
+}
+
+type ReserveRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	BookId        string                 `protobuf:"bytes,1,opt,name=book_id,json=bookId,proto3" json:"book_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReserveRequest) Reset() {
+	*x = ReserveRequest{}
+	mi := &file_library_proto_msgTypes[48]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReserveRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReserveRequest) ProtoMessage() {}
+
+func (x *ReserveRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_library_proto_msgTypes[48]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReserveRequest.ProtoReflect.Descriptor instead.
+func (*ReserveRequest) Descriptor() ([]byte, []int) {
+	return file_library_proto_rawDescGZIP(), []int{48}
+}
+
+func (x *ReserveRequest) GetBookId() string {
+	if x != nil {
+		return x.BookId
+	}
+	return ""
+}
+
+type ReservationRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReservationRequest) Reset() {
+	*x = ReservationRequest{}
+	mi := &file_library_proto_msgTypes[49]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReservationRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReservationRequest) ProtoMessage() {}
+
+func (x *ReservationRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_library_proto_msgTypes[49]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReservationRequest.ProtoReflect.Descriptor instead.
+func (*ReservationRequest) Descriptor() ([]byte, []int) {
+	return file_library_proto_rawDescGZIP(), []int{49}
+}
+
+func (x *ReservationRequest) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+type ListReservationsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Also return fulfilled, cancelled and expired reservations
+	IncludeClosed bool `protobuf:"varint,1,opt,name=include_closed,json=includeClosed,proto3" json:"include_closed,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListReservationsRequest) Reset() {
+	*x = ListReservationsRequest{}
+	mi := &file_library_proto_msgTypes[50]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListReservationsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListReservationsRequest) ProtoMessage() {}
+
+func (x *ListReservationsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_library_proto_msgTypes[50]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListReservationsRequest.ProtoReflect.Descriptor instead.
+func (*ListReservationsRequest) Descriptor() ([]byte, []int) {
+	return file_library_proto_rawDescGZIP(), []int{50}
+}
+
+func (x *ListReservationsRequest) GetIncludeClosed() bool {
+	if x != nil {
+		return x.IncludeClosed
+	}
+	return false
+}
+
+type ListReservationsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Reservations  []*Reservation         `protobuf:"bytes,1,rep,name=reservations,proto3" json:"reservations,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListReservationsResponse) Reset() {
+	*x = ListReservationsResponse{}
+	mi := &file_library_proto_msgTypes[51]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListReservationsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListReservationsResponse) ProtoMessage() {}
+
+func (x *ListReservationsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_library_proto_msgTypes[51]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListReservationsResponse.ProtoReflect.Descriptor instead.
+func (*ListReservationsResponse) Descriptor() ([]byte, []int) {
+	return file_library_proto_rawDescGZIP(), []int{51}
+}
+
+func (x *ListReservationsResponse) GetReservations() []*Reservation {
+	if x != nil {
+		return x.Reservations
+	}
+	return nil
+}
+
+// Fine is charged per day a loan was kept past its due date
+type Fine struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	Id          int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	LoanId      int64                  `protobuf:"varint,2,opt,name=loan_id,json=loanId,proto3" json:"loan_id,omitempty"`
+	BookId      string                 `protobuf:"bytes,3,opt,name=book_id,json=bookId,proto3" json:"book_id,omitempty"`
+	BookTitle   string                 `protobuf:"bytes,4,opt,name=book_title,json=bookTitle,proto3" json:"book_title,omitempty"`
+	DaysOverdue int32                  `protobuf:"varint,5,opt,name=days_overdue,json=daysOverdue,proto3" json:"days_overdue,omitempty"`
+	// Amounts are in cents
+	AmountCents int64                  `protobuf:"varint,6,opt,name=amount_cents,json=amountCents,proto3" json:"amount_cents,omitempty"`
+	PaidCents   int64                  `protobuf:"varint,7,opt,name=paid_cents,json=paidCents,proto3" json:"paid_cents,omitempty"`
+	CreatedAt   *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	// Unset until the fine is paid in full
+	PaidAt        *timestamppb.Timestamp `protobuf:"bytes,9,opt,name=paid_at,json=paidAt,proto3" json:"paid_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Fine) Reset() {
+	*x = Fine{}
+	mi := &file_library_proto_msgTypes[52]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Fine) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Fine) ProtoMessage() {}
+
+func (x *Fine) ProtoReflect() protoreflect.Message {
+	mi := &file_library_proto_msgTypes[52]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Fine.ProtoReflect.Descriptor instead.
+func (*Fine) Descriptor() ([]byte, []int) {
+	return file_library_proto_rawDescGZIP(), []int{52}
+}
+
+func (x *Fine) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Fine) GetLoanId() int64 {
+	if x != nil {
+		return x.LoanId
+	}
+	return 0
+}
+
+func (x *Fine) GetBookId() string {
+	if x != nil {
+		return x.BookId
+	}
+	return ""
+}
+
+func (x *Fine) GetBookTitle() string {
+	if x != nil {
+		return x.BookTitle
+	}
+	return ""
+}
+
+func (x *Fine) GetDaysOverdue() int32 {
+	if x != nil {
+		return x.DaysOverdue
+	}
+	return 0
+}
+
+func (x *Fine) GetAmountCents() int64 {
+	if x != nil {
+		return x.AmountCents
+	}
+	return 0
+}
+
+func (x *Fine) GetPaidCents() int64 {
+	if x != nil {
+		return x.PaidCents
+	}
+	return 0
+}
+
+func (x *Fine) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *Fine) GetPaidAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.PaidAt
+	}
+	return nil
+}
+
+type ListFinesRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Also return fines that have been paid in full
+	IncludePaid   bool `protobuf:"varint,1,opt,name=include_paid,json=includePaid,proto3" json:"include_paid,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListFinesRequest) Reset() {
+	*x = ListFinesRequest{}
+	mi := &file_library_proto_msgTypes[53]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListFinesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListFinesRequest) ProtoMessage() {}
+
+func (x *ListFinesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_library_proto_msgTypes[53]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListFinesRequest.ProtoReflect.Descriptor instead.
+func (*ListFinesRequest) Descriptor() ([]byte, []int) {
+	return file_library_proto_rawDescGZIP(), []int{53}
+}
+
+func (x *ListFinesRequest) GetIncludePaid() bool {
+	if x != nil {
+		return x.IncludePaid
+	}
+	return false
+}
+
+type ListFinesResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Fines []*Fine                `protobuf:"bytes,1,rep,name=fines,proto3" json:"fines,omitempty"`
+	// Unpaid balance across all of the user's fines, in cents
+	OutstandingCents int64 `protobuf:"varint,2,opt,name=outstanding_cents,json=outstandingCents,proto3" json:"outstanding_cents,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *ListFinesResponse) Reset() {
+	*x = ListFinesResponse{}
+	mi := &file_library_proto_msgTypes[54]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListFinesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListFinesResponse) ProtoMessage() {}
+
+func (x *ListFinesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_library_proto_msgTypes[54]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListFinesResponse.ProtoReflect.Descriptor instead.
+func (*ListFinesResponse) Descriptor() ([]byte, []int) {
+	return file_library_proto_rawDescGZIP(), []int{54}
+}
+
+func (x *ListFinesResponse) GetFines() []*Fine {
+	if x != nil {
+		return x.Fines
+	}
+	return nil
+}
+
+func (x *ListFinesResponse) GetOutstandingCents() int64 {
+	if x != nil {
+		return x.OutstandingCents
+	}
+	return 0
+}
+
+type PayFineRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Id    int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	// Amount to pay in cents; 0 pays the remaining balance
+	AmountCents   int64 `protobuf:"varint,2,opt,name=amount_cents,json=amountCents,proto3" json:"amount_cents,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PayFineRequest) Reset() {
+	*x = PayFineRequest{}
+	mi := &file_library_proto_msgTypes[55]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PayFineRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PayFineRequest) ProtoMessage() {}
+
+func (x *PayFineRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_library_proto_msgTypes[55]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PayFineRequest.ProtoReflect.Descriptor instead.
+func (*PayFineRequest) Descriptor() ([]byte, []int) {
+	return file_library_proto_rawDescGZIP(), []int{55}
+}
+
+func (x *PayFineRequest) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *PayFineRequest) GetAmountCents() int64 {
+	if x != nil {
+		return x.AmountCents
+	}
+	return 0
+}
+
+type NotificationRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Only deliver these types; empty subscribes to all of them
+	Types []NotificationType `protobuf:"varint,1,rep,packed,name=types,proto3,enum=library.NotificationType" json:"types,omitempty"`
+	// resume_token of the last notification received on an earlier stream: the events published since then
+	// are delivered first. Empty starts with the events published from now on.
+	ResumeToken   string `protobuf:"bytes,2,opt,name=resume_token,json=resumeToken,proto3" json:"resume_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *NotificationRequest) Reset() {
+	*x = NotificationRequest{}
+	mi := &file_library_proto_msgTypes[56]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *NotificationRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*NotificationRequest) ProtoMessage() {}
+
+func (x *NotificationRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_library_proto_msgTypes[56]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use NotificationRequest.ProtoReflect.Descriptor instead.
+func (*NotificationRequest) Descriptor() ([]byte, []int) {
+	return file_library_proto_rawDescGZIP(), []int{56}
+}
+
+func (x *NotificationRequest) GetTypes() []NotificationType {
+	if x != nil {
+		return x.Types
+	}
+	return nil
+}
+
+func (x *NotificationRequest) GetResumeToken() string {
+	if x != nil {
+		return x.ResumeToken
+	}
+	return ""
+}
+
+type Notification struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Type    NotificationType       `protobuf:"varint,1,opt,name=type,proto3,enum=library.NotificationType" json:"type,omitempty"`
+	Message string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	BookId  string                 `protobuf:"bytes,3,opt,name=book_id,json=bookId,proto3" json:"book_id,omitempty"`
+	// ID of the reservation or loan the event is about
+	ReferenceId int64                  `protobuf:"varint,4,opt,name=reference_id,json=referenceId,proto3" json:"reference_id,omitempty"`
+	CreatedAt   *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	// Opaque position of the notification in the user's events, to resume a stream after it
+	ResumeToken   string `protobuf:"bytes,6,opt,name=resume_token,json=resumeToken,proto3" json:"resume_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Notification) Reset() {
+	*x = Notification{}
+	mi := &file_library_proto_msgTypes[57]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Notification) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Notification) ProtoMessage() {}
+
+func (x *Notification) ProtoReflect() protoreflect.Message {
+	mi := &file_library_proto_msgTypes[57]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Notification.ProtoReflect.Descriptor instead.
+func (*Notification) Descriptor() ([]byte, []int) {
+	return file_library_proto_rawDescGZIP(), []int{57}
+}
+
+func (x *Notification) GetType() NotificationType {
+	if x != nil {
+		return x.Type
+	}
+	return NotificationType_NOTIFICATION_TYPE_UNSPECIFIED
+}
+
+func (x *Notification) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *Notification) GetBookId() string {
+	if x != nil {
+		return x.BookId
+	}
+	return ""
+}
+
+func (x *Notification) GetReferenceId() int64 {
+	if x != nil {
+		return x.ReferenceId
+	}
+	return 0
+}
+
+func (x *Notification) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *Notification) GetResumeToken() string {
+	if x != nil {
+		return x.ResumeToken
+	}
+	return ""
+}
+
+type SeriesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SeriesRequest) Reset() {
+	*x = SeriesRequest{}
+	mi := &file_library_proto_msgTypes[58]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SeriesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SeriesRequest) ProtoMessage() {}
+
+func (x *SeriesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_library_proto_msgTypes[58]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SeriesRequest.ProtoReflect.Descriptor instead.
+func (*SeriesRequest) Descriptor() ([]byte, []int) {
+	return file_library_proto_rawDescGZIP(), []int{58}
+}
+
+func (x *SeriesRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+// BookTranslation holds a book's title and description in another language
+type BookTranslation struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	BookId string                 `protobuf:"bytes,1,opt,name=book_id,json=bookId,proto3" json:"book_id,omitempty"`
+	// BCP 47 language tag, e.g. "pt" or "pt-BR"
+	Language      string `protobuf:"bytes,2,opt,name=language,proto3" json:"language,omitempty"`
+	Title         string `protobuf:"bytes,3,opt,name=title,proto3" json:"title,omitempty"`
+	Description   string `protobuf:"bytes,4,opt,name=description,proto3" json:"description,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BookTranslation) Reset() {
+	*x = BookTranslation{}
+	mi := &file_library_proto_msgTypes[59]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BookTranslation) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BookTranslation) ProtoMessage() {}
+
+func (x *BookTranslation) ProtoReflect() protoreflect.Message {
+	mi := &file_library_proto_msgTypes[59]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BookTranslation.ProtoReflect.Descriptor instead.
+func (*BookTranslation) Descriptor() ([]byte, []int) {
+	return file_library_proto_rawDescGZIP(), []int{59}
+}
+
+func (x *BookTranslation) GetBookId() string {
+	if x != nil {
+		return x.BookId
+	}
+	return ""
+}
+
+func (x *BookTranslation) GetLanguage() string {
+	if x != nil {
+		return x.Language
+	}
+	return ""
+}
+
+func (x *BookTranslation) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *BookTranslation) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+type BookTranslationRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	BookId        string                 `protobuf:"bytes,1,opt,name=book_id,json=bookId,proto3" json:"book_id,omitempty"`
+	Language      string                 `protobuf:"bytes,2,opt,name=language,proto3" json:"language,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BookTranslationRequest) Reset() {
+	*x = BookTranslationRequest{}
+	mi := &file_library_proto_msgTypes[60]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BookTranslationRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BookTranslationRequest) ProtoMessage() {}
+
+func (x *BookTranslationRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_library_proto_msgTypes[60]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BookTranslationRequest.ProtoReflect.Descriptor instead.
+func (*BookTranslationRequest) Descriptor() ([]byte, []int) {
+	return file_library_proto_rawDescGZIP(), []int{60}
+}
+
+func (x *BookTranslationRequest) GetBookId() string {
+	if x != nil {
+		return x.BookId
+	}
+	return ""
+}
+
+func (x *BookTranslationRequest) GetLanguage() string {
+	if x != nil {
+		return x.Language
+	}
+	return ""
+}
+
+type ListBookTranslationsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Translations  []*BookTranslation     `protobuf:"bytes,1,rep,name=translations,proto3" json:"translations,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListBookTranslationsResponse) Reset() {
+	*x = ListBookTranslationsResponse{}
+	mi := &file_library_proto_msgTypes[61]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListBookTranslationsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListBookTranslationsResponse) ProtoMessage() {}
+
+func (x *ListBookTranslationsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_library_proto_msgTypes[61]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListBookTranslationsResponse.ProtoReflect.Descriptor instead.
+func (*ListBookTranslationsResponse) Descriptor() ([]byte, []int) {
+	return file_library_proto_rawDescGZIP(), []int{61}
+}
+
+func (x *ListBookTranslationsResponse) GetTranslations() []*BookTranslation {
+	if x != nil {
+		return x.Translations
+	}
+	return nil
+}
+
+type BookLocation struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	BookId          string                 `protobuf:"bytes,1,opt,name=book_id,json=bookId,proto3" json:"book_id,omitempty"`
+	Title           string                 `protobuf:"bytes,2,opt,name=title,proto3" json:"title,omitempty"`
+	Location        *Location              `protobuf:"bytes,3,opt,name=location,proto3" json:"location,omitempty"`
+	AvailableCopies int32                  `protobuf:"varint,4,opt,name=available_copies,json=availableCopies,proto3" json:"available_copies,omitempty"`
+	TotalCopies     int32                  `protobuf:"varint,5,opt,name=total_copies,json=totalCopies,proto3" json:"total_copies,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *BookLocation) Reset() {
+	*x = BookLocation{}
+	mi := &file_library_proto_msgTypes[62]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BookLocation) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BookLocation) ProtoMessage() {}
+
+func (x *BookLocation) ProtoReflect() protoreflect.Message {
+	mi := &file_library_proto_msgTypes[62]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BookLocation.ProtoReflect.Descriptor instead.
+func (*BookLocation) Descriptor() ([]byte, []int) {
+	return file_library_proto_rawDescGZIP(), []int{62}
+}
+
+func (x *BookLocation) GetBookId() string {
+	if x != nil {
+		return x.BookId
+	}
+	return ""
+}
+
+func (x *BookLocation) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *BookLocation) GetLocation() *Location {
+	if x != nil {
+		return x.Location
+	}
+	return nil
+}
+
+func (x *BookLocation) GetAvailableCopies() int32 {
+	if x != nil {
+		return x.AvailableCopies
+	}
+	return 0
+}
+
+func (x *BookLocation) GetTotalCopies() int32 {
+	if x != nil {
+		return x.TotalCopies
+	}
+	return 0
+}
+
+// BookCopy is one physical copy of a book, identified by its barcode
+type BookCopy struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	Id        int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	BookId    string                 `protobuf:"bytes,2,opt,name=book_id,json=bookId,proto3" json:"book_id,omitempty"`
+	Barcode   string                 `protobuf:"bytes,3,opt,name=barcode,proto3" json:"barcode,omitempty"`
+	CreatedAt *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	Condition CopyCondition          `protobuf:"varint,5,opt,name=condition,proto3,enum=library.CopyCondition" json:"condition,omitempty"`
+	// Purchase price in cents; 0 for donations
+	PriceCents int64                  `protobuf:"varint,6,opt,name=price_cents,json=priceCents,proto3" json:"price_cents,omitempty"`
+	AcquiredAt *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=acquired_at,json=acquiredAt,proto3" json:"acquired_at,omitempty"`
+	// Who donated the copy; empty for purchases
+	Donor         string `protobuf:"bytes,8,opt,name=donor,proto3" json:"donor,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BookCopy) Reset() {
+	*x = BookCopy{}
+	mi := &file_library_proto_msgTypes[63]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BookCopy) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BookCopy) ProtoMessage() {}
+
+func (x *BookCopy) ProtoReflect() protoreflect.Message {
+	mi := &file_library_proto_msgTypes[63]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BookCopy.ProtoReflect.Descriptor instead.
+func (*BookCopy) Descriptor() ([]byte, []int) {
+	return file_library_proto_rawDescGZIP(), []int{63}
+}
+
+func (x *BookCopy) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *BookCopy) GetBookId() string {
+	if x != nil {
+		return x.BookId
+	}
+	return ""
+}
+
+func (x *BookCopy) GetBarcode() string {
+	if x != nil {
+		return x.Barcode
+	}
+	return ""
+}
+
+func (x *BookCopy) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *BookCopy) GetCondition() CopyCondition {
+	if x != nil {
+		return x.Condition
+	}
+	return CopyCondition_COPY_CONDITION_UNSPECIFIED
+}
+
+func (x *BookCopy) GetPriceCents() int64 {
+	if x != nil {
+		return x.PriceCents
+	}
+	return 0
+}
+
+func (x *BookCopy) GetAcquiredAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.AcquiredAt
+	}
+	return nil
+}
+
+func (x *BookCopy) GetDonor() string {
+	if x != nil {
+		return x.Donor
+	}
+	return ""
+}
+
+type CopyRequest struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	BookId string                 `protobuf:"bytes,1,opt,name=book_id,json=bookId,proto3" json:"book_id,omitempty"`
+	// Printable ASCII; generated when empty
+	Barcode    string        `protobuf:"bytes,2,opt,name=barcode,proto3" json:"barcode,omitempty"`
+	Condition  CopyCondition `protobuf:"varint,3,opt,name=condition,proto3,enum=library.CopyCondition" json:"condition,omitempty"`
+	PriceCents int64         `protobuf:"varint,4,opt,name=price_cents,json=priceCents,proto3" json:"price_cents,omitempty"`
+	// Defaults to now; cannot be in the future
+	AcquiredAt    *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=acquired_at,json=acquiredAt,proto3" json:"acquired_at,omitempty"`
+	Donor         string                 `protobuf:"bytes,6,opt,name=donor,proto3" json:"donor,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CopyRequest) Reset() {
+	*x = CopyRequest{}
+	mi := &file_library_proto_msgTypes[64]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CopyRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CopyRequest) ProtoMessage() {}
+
+func (x *CopyRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_library_proto_msgTypes[64]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CopyRequest.ProtoReflect.Descriptor instead.
+func (*CopyRequest) Descriptor() ([]byte, []int) {
+	return file_library_proto_rawDescGZIP(), []int{64}
+}
+
+func (x *CopyRequest) GetBookId() string {
+	if x != nil {
+		return x.BookId
+	}
+	return ""
+}
+
+func (x *CopyRequest) GetBarcode() string {
+	if x != nil {
+		return x.Barcode
+	}
+	return ""
+}
+
+func (x *CopyRequest) GetCondition() CopyCondition {
+	if x != nil {
+		return x.Condition
+	}
+	return CopyCondition_COPY_CONDITION_UNSPECIFIED
+}
+
+func (x *CopyRequest) GetPriceCents() int64 {
+	if x != nil {
+		return x.PriceCents
+	}
+	return 0
+}
+
+func (x *CopyRequest) GetAcquiredAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.AcquiredAt
+	}
+	return nil
+}
+
+func (x *CopyRequest) GetDonor() string {
+	if x != nil {
+		return x.Donor
+	}
+	return ""
+}
+
+type ListCopiesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Copies        []*BookCopy            `protobuf:"bytes,1,rep,name=copies,proto3" json:"copies,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListCopiesResponse) Reset() {
+	*x = ListCopiesResponse{}
+	mi := &file_library_proto_msgTypes[65]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListCopiesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListCopiesResponse) ProtoMessage() {}
+
+func (x *ListCopiesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_library_proto_msgTypes[65]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListCopiesResponse.ProtoReflect.Descriptor instead.
+func (*ListCopiesResponse) Descriptor() ([]byte, []int) {
+	return file_library_proto_rawDescGZIP(), []int{65}
+}
+
+func (x *ListCopiesResponse) GetCopies() []*BookCopy {
+	if x != nil {
+		return x.Copies
+	}
+	return nil
+}
+
+type BarcodeRequest struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Barcode string                 `protobuf:"bytes,1,opt,name=barcode,proto3" json:"barcode,omitempty"`
+	// Only used by GetBarcodeImage
+	Symbology     BarcodeSymbology `protobuf:"varint,2,opt,name=symbology,proto3,enum=library.BarcodeSymbology" json:"symbology,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BarcodeRequest) Reset() {
+	*x = BarcodeRequest{}
+	mi := &file_library_proto_msgTypes[66]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BarcodeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BarcodeRequest) ProtoMessage() {}
+
+func (x *BarcodeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_library_proto_msgTypes[66]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BarcodeRequest.ProtoReflect.Descriptor instead.
+func (*BarcodeRequest) Descriptor() ([]byte, []int) {
+	return file_library_proto_rawDescGZIP(), []int{66}
+}
+
+func (x *BarcodeRequest) GetBarcode() string {
+	if x != nil {
+		return x.Barcode
+	}
+	return ""
+}
+
+func (x *BarcodeRequest) GetSymbology() BarcodeSymbology {
+	if x != nil {
+		return x.Symbology
+	}
+	return BarcodeSymbology_BARCODE_SYMBOLOGY_UNSPECIFIED
+}
+
+type BarcodeImage struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Barcode       string                 `protobuf:"bytes,1,opt,name=barcode,proto3" json:"barcode,omitempty"`
+	ContentType   string                 `protobuf:"bytes,2,opt,name=content_type,json=contentType,proto3" json:"content_type,omitempty"`
+	Data          []byte                 `protobuf:"bytes,3,opt,name=data,proto3" json:"data,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BarcodeImage) Reset() {
+	*x = BarcodeImage{}
+	mi := &file_library_proto_msgTypes[67]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BarcodeImage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BarcodeImage) ProtoMessage() {}
+
+func (x *BarcodeImage) ProtoReflect() protoreflect.Message {
+	mi := &file_library_proto_msgTypes[67]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BarcodeImage.ProtoReflect.Descriptor instead.
+func (*BarcodeImage) Descriptor() ([]byte, []int) {
+	return file_library_proto_rawDescGZIP(), []int{67}
+}
+
+func (x *BarcodeImage) GetBarcode() string {
+	if x != nil {
+		return x.Barcode
+	}
+	return ""
+}
+
+func (x *BarcodeImage) GetContentType() string {
+	if x != nil {
+		return x.ContentType
+	}
+	return ""
+}
+
+func (x *BarcodeImage) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+// Publisher is a canonical publisher name referenced by books
+type Publisher struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Id      int32                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name    string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Website string                 `protobuf:"bytes,3,opt,name=website,proto3" json:"website,omitempty"`
+	// Number of books referencing the publisher (read-only)
+	BookCount     int32 `protobuf:"varint,4,opt,name=book_count,json=bookCount,proto3" json:"book_count,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Publisher) Reset() {
+	*x = Publisher{}
+	mi := &file_library_proto_msgTypes[68]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Publisher) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Publisher) ProtoMessage() {}
+
+func (x *Publisher) ProtoReflect() protoreflect.Message {
+	mi := &file_library_proto_msgTypes[68]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Publisher.ProtoReflect.Descriptor instead.
+func (*Publisher) Descriptor() ([]byte, []int) {
+	return file_library_proto_rawDescGZIP(), []int{68}
+}
+
+func (x *Publisher) GetId() int32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Publisher) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Publisher) GetWebsite() string {
+	if x != nil {
+		return x.Website
+	}
+	return ""
+}
+
+func (x *Publisher) GetBookCount() int32 {
+	if x != nil {
+		return x.BookCount
+	}
+	return 0
+}
+
+type ListPublishersRequest struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Page     int32                  `protobuf:"varint,1,opt,name=page,proto3" json:"page,omitempty"`
+	PageSize int32                  `protobuf:"varint,2,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	// Token of the page to return, from next_page_token of the previous one; takes the place of page
+	PageToken     string `protobuf:"bytes,3,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListPublishersRequest) Reset() {
+	*x = ListPublishersRequest{}
+	mi := &file_library_proto_msgTypes[69]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListPublishersRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListPublishersRequest) ProtoMessage() {}
+
+func (x *ListPublishersRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_library_proto_msgTypes[69]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListPublishersRequest.ProtoReflect.Descriptor instead.
+func (*ListPublishersRequest) Descriptor() ([]byte, []int) {
+	return file_library_proto_rawDescGZIP(), []int{69}
+}
+
+func (x *ListPublishersRequest) GetPage() int32 {
+	if x != nil {
+		return x.Page
+	}
+	return 0
+}
+
+func (x *ListPublishersRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *ListPublishersRequest) GetPageToken() string {
+	if x != nil {
+		return x.PageToken
+	}
+	return ""
+}
+
+type ListPublishersResponse struct {
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	Publishers []*Publisher           `protobuf:"bytes,1,rep,name=publishers,proto3" json:"publishers,omitempty"`
+	TotalCount int32                  `protobuf:"varint,2,opt,name=total_count,json=totalCount,proto3" json:"total_count,omitempty"`
+	// Token of the next page, empty on the last one
+	NextPageToken string `protobuf:"bytes,3,opt,name=next_page_token,json=nextPageToken,proto3" json:"next_page_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListPublishersResponse) Reset() {
+	*x = ListPublishersResponse{}
+	mi := &file_library_proto_msgTypes[70]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListPublishersResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListPublishersResponse) ProtoMessage() {}
+
+func (x *ListPublishersResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_library_proto_msgTypes[70]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListPublishersResponse.ProtoReflect.Descriptor instead.
+func (*ListPublishersResponse) Descriptor() ([]byte, []int) {
+	return file_library_proto_rawDescGZIP(), []int{70}
+}
+
+func (x *ListPublishersResponse) GetPublishers() []*Publisher {
+	if x != nil {
+		return x.Publishers
+	}
+	return nil
+}
+
+func (x *ListPublishersResponse) GetTotalCount() int32 {
+	if x != nil {
+		return x.TotalCount
+	}
+	return 0
+}
+
+func (x *ListPublishersResponse) GetNextPageToken() string {
+	if x != nil {
+		return x.NextPageToken
+	}
+	return ""
+}
+
+type PublisherBooksRequest struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Id       int32                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Page     int32                  `protobuf:"varint,2,opt,name=page,proto3" json:"page,omitempty"`
+	PageSize int32                  `protobuf:"varint,3,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	// Token of the page to return, from next_page_token of the previous one; takes the place of page
+	PageToken     string `protobuf:"bytes,4,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PublisherBooksRequest) Reset() {
+	*x = PublisherBooksRequest{}
+	mi := &file_library_proto_msgTypes[71]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PublisherBooksRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PublisherBooksRequest) ProtoMessage() {}
+
+func (x *PublisherBooksRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_library_proto_msgTypes[71]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PublisherBooksRequest.ProtoReflect.Descriptor instead.
+func (*PublisherBooksRequest) Descriptor() ([]byte, []int) {
+	return file_library_proto_rawDescGZIP(), []int{71}
+}
+
+func (x *PublisherBooksRequest) GetId() int32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *PublisherBooksRequest) GetPage() int32 {
+	if x != nil {
+		return x.Page
+	}
+	return 0
+}
+
+func (x *PublisherBooksRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *PublisherBooksRequest) GetPageToken() string {
+	if x != nil {
+		return x.PageToken
+	}
+	return ""
+}
+
+type ListAcquisitionsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Only copies acquired at or after this time
+	AcquiredFrom *timestamppb.Timestamp `protobuf:"bytes,1,opt,name=acquired_from,json=acquiredFrom,proto3" json:"acquired_from,omitempty"`
+	// Only copies acquired before this time
+	AcquiredTo *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=acquired_to,json=acquiredTo,proto3" json:"acquired_to,omitempty"`
+	// Only copies in this condition; unspecified matches all
+	Condition CopyCondition `protobuf:"varint,3,opt,name=condition,proto3,enum=library.CopyCondition" json:"condition,omitempty"`
+	// Only donated copies
+	DonatedOnly bool  `protobuf:"varint,4,opt,name=donated_only,json=donatedOnly,proto3" json:"donated_only,omitempty"`
+	Page        int32 `protobuf:"varint,5,opt,name=page,proto3" json:"page,omitempty"`
+	PageSize    int32 `protobuf:"varint,6,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	// Token of the page to return, from next_page_token of the previous one; takes the place of page
+	PageToken     string `protobuf:"bytes,7,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListAcquisitionsRequest) Reset() {
+	*x = ListAcquisitionsRequest{}
+	mi := &file_library_proto_msgTypes[72]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListAcquisitionsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListAcquisitionsRequest) ProtoMessage() {}
+
+func (x *ListAcquisitionsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_library_proto_msgTypes[72]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListAcquisitionsRequest.ProtoReflect.Descriptor instead.
+func (*ListAcquisitionsRequest) Descriptor() ([]byte, []int) {
+	return file_library_proto_rawDescGZIP(), []int{72}
+}
+
+func (x *ListAcquisitionsRequest) GetAcquiredFrom() *timestamppb.Timestamp {
+	if x != nil {
+		return x.AcquiredFrom
+	}
+	return nil
+}
+
+func (x *ListAcquisitionsRequest) GetAcquiredTo() *timestamppb.Timestamp {
+	if x != nil {
+		return x.AcquiredTo
+	}
+	return nil
+}
+
+func (x *ListAcquisitionsRequest) GetCondition() CopyCondition {
+	if x != nil {
+		return x.Condition
+	}
+	return CopyCondition_COPY_CONDITION_UNSPECIFIED
+}
+
+func (x *ListAcquisitionsRequest) GetDonatedOnly() bool {
+	if x != nil {
+		return x.DonatedOnly
+	}
+	return false
+}
+
+func (x *ListAcquisitionsRequest) GetPage() int32 {
+	if x != nil {
+		return x.Page
+	}
+	return 0
+}
+
+func (x *ListAcquisitionsRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *ListAcquisitionsRequest) GetPageToken() string {
+	if x != nil {
+		return x.PageToken
+	}
+	return ""
+}
+
+// Acquisition is a copy together with the book it belongs to
+type Acquisition struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Copy          *BookCopy              `protobuf:"bytes,1,opt,name=copy,proto3" json:"copy,omitempty"`
+	Title         string                 `protobuf:"bytes,2,opt,name=title,proto3" json:"title,omitempty"`
+	Author        string                 `protobuf:"bytes,3,opt,name=author,proto3" json:"author,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Acquisition) Reset() {
+	*x = Acquisition{}
+	mi := &file_library_proto_msgTypes[73]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Acquisition) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Acquisition) ProtoMessage() {}
+
+func (x *Acquisition) ProtoReflect() protoreflect.Message {
+	mi := &file_library_proto_msgTypes[73]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Acquisition.ProtoReflect.Descriptor instead.
+func (*Acquisition) Descriptor() ([]byte, []int) {
+	return file_library_proto_rawDescGZIP(), []int{73}
+}
+
+func (x *Acquisition) GetCopy() *BookCopy {
+	if x != nil {
+		return x.Copy
+	}
+	return nil
+}
+
+func (x *Acquisition) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *Acquisition) GetAuthor() string {
+	if x != nil {
+		return x.Author
+	}
+	return ""
+}
+
+type ListAcquisitionsResponse struct {
+	state        protoimpl.MessageState `protogen:"open.v1"`
+	Acquisitions []*Acquisition         `protobuf:"bytes,1,rep,name=acquisitions,proto3" json:"acquisitions,omitempty"`
+	// Totals cover every matching copy, not just this page
+	TotalCount      int32 `protobuf:"varint,2,opt,name=total_count,json=totalCount,proto3" json:"total_count,omitempty"`
+	TotalPriceCents int64 `protobuf:"varint,3,opt,name=total_price_cents,json=totalPriceCents,proto3" json:"total_price_cents,omitempty"`
+	DonatedCount    int32 `protobuf:"varint,4,opt,name=donated_count,json=donatedCount,proto3" json:"donated_count,omitempty"`
+	// Token of the next page, empty on the last one
+	NextPageToken string `protobuf:"bytes,5,opt,name=next_page_token,json=nextPageToken,proto3" json:"next_page_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListAcquisitionsResponse) Reset() {
+	*x = ListAcquisitionsResponse{}
+	mi := &file_library_proto_msgTypes[74]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListAcquisitionsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListAcquisitionsResponse) ProtoMessage() {}
+
+func (x *ListAcquisitionsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_library_proto_msgTypes[74]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListAcquisitionsResponse.ProtoReflect.Descriptor instead.
+func (*ListAcquisitionsResponse) Descriptor() ([]byte, []int) {
+	return file_library_proto_rawDescGZIP(), []int{74}
+}
+
+func (x *ListAcquisitionsResponse) GetAcquisitions() []*Acquisition {
+	if x != nil {
+		return x.Acquisitions
+	}
+	return nil
+}
+
+func (x *ListAcquisitionsResponse) GetTotalCount() int32 {
+	if x != nil {
+		return x.TotalCount
+	}
+	return 0
+}
+
+func (x *ListAcquisitionsResponse) GetTotalPriceCents() int64 {
+	if x != nil {
+		return x.TotalPriceCents
+	}
+	return 0
+}
+
+func (x *ListAcquisitionsResponse) GetDonatedCount() int32 {
+	if x != nil {
+		return x.DonatedCount
+	}
+	return 0
+}
+
+func (x *ListAcquisitionsResponse) GetNextPageToken() string {
+	if x != nil {
+		return x.NextPageToken
+	}
+	return ""
+}
+
+type RefreshTokenRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RefreshToken  string                 `protobuf:"bytes,1,opt,name=refresh_token,json=refreshToken,proto3" json:"refresh_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RefreshTokenRequest) Reset() {
+	*x = RefreshTokenRequest{}
+	mi := &file_library_proto_msgTypes[75]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RefreshTokenRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RefreshTokenRequest) ProtoMessage() {}
+
+func (x *RefreshTokenRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_library_proto_msgTypes[75]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RefreshTokenRequest.ProtoReflect.Descriptor instead.
+func (*RefreshTokenRequest) Descriptor() ([]byte, []int) {
+	return file_library_proto_rawDescGZIP(), []int{75}
+}
+
+func (x *RefreshTokenRequest) GetRefreshToken() string {
+	if x != nil {
+		return x.RefreshToken
+	}
+	return ""
+}
+
+type LogoutRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Refresh token issued with the access token, revoked along with it
+	RefreshToken  string `protobuf:"bytes,1,opt,name=refresh_token,json=refreshToken,proto3" json:"refresh_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LogoutRequest) Reset() {
+	*x = LogoutRequest{}
+	mi := &file_library_proto_msgTypes[76]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LogoutRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LogoutRequest) ProtoMessage() {}
+
+func (x *LogoutRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_library_proto_msgTypes[76]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LogoutRequest.ProtoReflect.Descriptor instead.
+func (*LogoutRequest) Descriptor() ([]byte, []int) {
+	return file_library_proto_rawDescGZIP(), []int{76}
+}
+
+func (x *LogoutRequest) GetRefreshToken() string {
+	if x != nil {
+		return x.RefreshToken
+	}
+	return ""
+}
+
+type RevokeAllSessionsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RevokeAllSessionsRequest) Reset() {
+	*x = RevokeAllSessionsRequest{}
+	mi := &file_library_proto_msgTypes[77]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RevokeAllSessionsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RevokeAllSessionsRequest) ProtoMessage() {}
+
+func (x *RevokeAllSessionsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_library_proto_msgTypes[77]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RevokeAllSessionsRequest.ProtoReflect.Descriptor instead.
+func (*RevokeAllSessionsRequest) Descriptor() ([]byte, []int) {
+	return file_library_proto_rawDescGZIP(), []int{77}
+}
+
+type LogoutResponse struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Message string                 `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
+	// Number of refresh tokens revoked
+	RevokedRefreshTokens int32 `protobuf:"varint,2,opt,name=revoked_refresh_tokens,json=revokedRefreshTokens,proto3" json:"revoked_refresh_tokens,omitempty"`
+	unknownFields        protoimpl.UnknownFields
+	sizeCache            protoimpl.SizeCache
+}
+
+func (x *LogoutResponse) Reset() {
+	*x = LogoutResponse{}
+	mi := &file_library_proto_msgTypes[78]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LogoutResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LogoutResponse) ProtoMessage() {}
+
+func (x *LogoutResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_library_proto_msgTypes[78]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LogoutResponse.ProtoReflect.Descriptor instead.
+func (*LogoutResponse) Descriptor() ([]byte, []int) {
+	return file_library_proto_rawDescGZIP(), []int{78}
+}
+
+func (x *LogoutResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *LogoutResponse) GetRevokedRefreshTokens() int32 {
+	if x != nil {
+		return x.RevokedRefreshTokens
+	}
+	return 0
+}
+
+type PasswordResetRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Username      string                 `protobuf:"bytes,1,opt,name=username,proto3" json:"username,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PasswordResetRequest) Reset() {
+	*x = PasswordResetRequest{}
+	mi := &file_library_proto_msgTypes[79]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PasswordResetRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PasswordResetRequest) ProtoMessage() {}
+
+func (x *PasswordResetRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_library_proto_msgTypes[79]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PasswordResetRequest.ProtoReflect.Descriptor instead.
+func (*PasswordResetRequest) Descriptor() ([]byte, []int) {
+	return file_library_proto_rawDescGZIP(), []int{79}
+}
+
+func (x *PasswordResetRequest) GetUsername() string {
+	if x != nil {
+		return x.Username
+	}
+	return ""
+}
+
+type ConfirmPasswordResetRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Token         string                 `protobuf:"bytes,1,opt,name=token,proto3" json:"token,omitempty"`
+	NewPassword   string                 `protobuf:"bytes,2,opt,name=new_password,json=newPassword,proto3" json:"new_password,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ConfirmPasswordResetRequest) Reset() {
+	*x = ConfirmPasswordResetRequest{}
+	mi := &file_library_proto_msgTypes[80]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ConfirmPasswordResetRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ConfirmPasswordResetRequest) ProtoMessage() {}
+
+func (x *ConfirmPasswordResetRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_library_proto_msgTypes[80]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ConfirmPasswordResetRequest.ProtoReflect.Descriptor instead.
+func (*ConfirmPasswordResetRequest) Descriptor() ([]byte, []int) {
+	return file_library_proto_rawDescGZIP(), []int{80}
+}
+
+func (x *ConfirmPasswordResetRequest) GetToken() string {
+	if x != nil {
+		return x.Token
+	}
+	return ""
+}
+
+func (x *ConfirmPasswordResetRequest) GetNewPassword() string {
+	if x != nil {
+		return x.NewPassword
+	}
+	return ""
+}
+
+type PasswordResetResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Message       string                 `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PasswordResetResponse) Reset() {
+	*x = PasswordResetResponse{}
+	mi := &file_library_proto_msgTypes[81]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PasswordResetResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PasswordResetResponse) ProtoMessage() {}
+
+func (x *PasswordResetResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_library_proto_msgTypes[81]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PasswordResetResponse.ProtoReflect.Descriptor instead.
+func (*PasswordResetResponse) Descriptor() ([]byte, []int) {
+	return file_library_proto_rawDescGZIP(), []int{81}
+}
+
+func (x *PasswordResetResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+type VerifyEmailRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Token         string                 `protobuf:"bytes,1,opt,name=token,proto3" json:"token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *VerifyEmailRequest) Reset() {
+	*x = VerifyEmailRequest{}
+	mi := &file_library_proto_msgTypes[82]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *VerifyEmailRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VerifyEmailRequest) ProtoMessage() {}
+
+func (x *VerifyEmailRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_library_proto_msgTypes[82]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VerifyEmailRequest.ProtoReflect.Descriptor instead.
+func (*VerifyEmailRequest) Descriptor() ([]byte, []int) {
+	return file_library_proto_rawDescGZIP(), []int{82}
+}
+
+func (x *VerifyEmailRequest) GetToken() string {
+	if x != nil {
+		return x.Token
+	}
+	return ""
+}
+
+type VerifyEmailResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Message       string                 `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
+	Email         string                 `protobuf:"bytes,2,opt,name=email,proto3" json:"email,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *VerifyEmailResponse) Reset() {
+	*x = VerifyEmailResponse{}
+	mi := &file_library_proto_msgTypes[83]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *VerifyEmailResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VerifyEmailResponse) ProtoMessage() {}
+
+func (x *VerifyEmailResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_library_proto_msgTypes[83]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VerifyEmailResponse.ProtoReflect.Descriptor instead.
+func (*VerifyEmailResponse) Descriptor() ([]byte, []int) {
+	return file_library_proto_rawDescGZIP(), []int{83}
+}
+
+func (x *VerifyEmailResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *VerifyEmailResponse) GetEmail() string {
+	if x != nil {
+		return x.Email
+	}
+	return ""
+}
+
+// Profile is the signed-in user's account details
+type Profile struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Read-only
+	Username    string `protobuf:"bytes,1,opt,name=username,proto3" json:"username,omitempty"`
+	DisplayName string `protobuf:"bytes,2,opt,name=display_name,json=displayName,proto3" json:"display_name,omitempty"`
+	Email       string `protobuf:"bytes,3,opt,name=email,proto3" json:"email,omitempty"`
+	// Read-only; cleared when the email address changes
+	EmailVerified bool                `protobuf:"varint,4,opt,name=email_verified,json=emailVerified,proto3" json:"email_verified,omitempty"`
+	Preferences   *ProfilePreferences `protobuf:"bytes,5,opt,name=preferences,proto3" json:"preferences,omitempty"`
+	// Read-only; the most recent successful login, to help spot access the user does not recognize
+	LastLoginAt *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=last_login_at,json=lastLoginAt,proto3" json:"last_login_at,omitempty"`
+	// Read-only
+	LastLoginIp string `protobuf:"bytes,7,opt,name=last_login_ip,json=lastLoginIp,proto3" json:"last_login_ip,omitempty"`
+	// Read-only; successful logins since the counter was added
+	LoginCount int64 `protobuf:"varint,8,opt,name=login_count,json=loginCount,proto3" json:"login_count,omitempty"`
+	// Read-only; when the account was registered
+	CreatedAt *timestamppb.Timestamp `protobuf:"bytes,9,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	// Read-only; when the account's details, password, role or state last changed; logins don't change it
+	UpdatedAt     *timestamppb.Timestamp `protobuf:"bytes,10,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Profile) Reset() {
+	*x = Profile{}
+	mi := &file_library_proto_msgTypes[84]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Profile) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Profile) ProtoMessage() {}
+
+func (x *Profile) ProtoReflect() protoreflect.Message {
+	mi := &file_library_proto_msgTypes[84]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Profile.ProtoReflect.Descriptor instead.
+func (*Profile) Descriptor() ([]byte, []int) {
+	return file_library_proto_rawDescGZIP(), []int{84}
+}
+
+func (x *Profile) GetUsername() string {
+	if x != nil {
+		return x.Username
+	}
+	return ""
+}
+
+func (x *Profile) GetDisplayName() string {
+	if x != nil {
+		return x.DisplayName
+	}
+	return ""
+}
+
+func (x *Profile) GetEmail() string {
+	if x != nil {
+		return x.Email
+	}
+	return ""
+}
+
+func (x *Profile) GetEmailVerified() bool {
+	if x != nil {
+		return x.EmailVerified
+	}
+	return false
+}
+
+func (x *Profile) GetPreferences() *ProfilePreferences {
+	if x != nil {
+		return x.Preferences
+	}
+	return nil
+}
+
+func (x *Profile) GetLastLoginAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.LastLoginAt
+	}
+	return nil
+}
+
+func (x *Profile) GetLastLoginIp() string {
+	if x != nil {
+		return x.LastLoginIp
+	}
+	return ""
+}
+
+func (x *Profile) GetLoginCount() int64 {
+	if x != nil {
+		return x.LoginCount
+	}
+	return 0
+}
+
+func (x *Profile) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *Profile) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+type ProfilePreferences struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Preferred language (BCP 47) for book titles and descriptions
+	Language string `protobuf:"bytes,1,opt,name=language,proto3" json:"language,omitempty"`
+	// Default number of results per page, 0 for the server default
+	PageSize int32 `protobuf:"varint,2,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	// Receive circulation notices by email
+	EmailNotifications bool `protobuf:"varint,3,opt,name=email_notifications,json=emailNotifications,proto3" json:"email_notifications,omitempty"`
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *ProfilePreferences) Reset() {
+	*x = ProfilePreferences{}
+	mi := &file_library_proto_msgTypes[85]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ProfilePreferences) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProfilePreferences) ProtoMessage() {}
+
+func (x *ProfilePreferences) ProtoReflect() protoreflect.Message {
+	mi := &file_library_proto_msgTypes[85]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ProfilePreferences.ProtoReflect.Descriptor instead.
+func (*ProfilePreferences) Descriptor() ([]byte, []int) {
+	return file_library_proto_rawDescGZIP(), []int{85}
+}
+
+func (x *ProfilePreferences) GetLanguage() string {
+	if x != nil {
+		return x.Language
+	}
+	return ""
+}
+
+func (x *ProfilePreferences) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *ProfilePreferences) GetEmailNotifications() bool {
+	if x != nil {
+		return x.EmailNotifications
+	}
+	return false
+}
+
+type GetProfileRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetProfileRequest) Reset() {
+	*x = GetProfileRequest{}
+	mi := &file_library_proto_msgTypes[86]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetProfileRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetProfileRequest) ProtoMessage() {}
+
+func (x *GetProfileRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_library_proto_msgTypes[86]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetProfileRequest.ProtoReflect.Descriptor instead.
+func (*GetProfileRequest) Descriptor() ([]byte, []int) {
+	return file_library_proto_rawDescGZIP(), []int{86}
+}
+
+type UpdateProfileRequest struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Profile *Profile               `protobuf:"bytes,1,opt,name=profile,proto3" json:"profile,omitempty"`
+	// Fields to change: display_name, email, preferences or preferences.<field>; empty changes all of them
+	UpdateMask    *fieldmaskpb.FieldMask `protobuf:"bytes,2,opt,name=update_mask,json=updateMask,proto3" json:"update_mask,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateProfileRequest) Reset() {
+	*x = UpdateProfileRequest{}
+	mi := &file_library_proto_msgTypes[87]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateProfileRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateProfileRequest) ProtoMessage() {}
+
+func (x *UpdateProfileRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_library_proto_msgTypes[87]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateProfileRequest.ProtoReflect.Descriptor instead.
+func (*UpdateProfileRequest) Descriptor() ([]byte, []int) {
+	return file_library_proto_rawDescGZIP(), []int{87}
+}
+
+func (x *UpdateProfileRequest) GetProfile() *Profile {
+	if x != nil {
+		return x.Profile
+	}
+	return nil
+}
+
+func (x *UpdateProfileRequest) GetUpdateMask() *fieldmaskpb.FieldMask {
+	if x != nil {
+		return x.UpdateMask
+	}
+	return nil
+}
+
+// A login on one device; refreshing tokens keeps the same session
+type Session struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Id    int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	// User agent of the client that logged in
+	UserAgent string                 `protobuf:"bytes,2,opt,name=user_agent,json=userAgent,proto3" json:"user_agent,omitempty"`
+	IpAddress string                 `protobuf:"bytes,3,opt,name=ip_address,json=ipAddress,proto3" json:"ip_address,omitempty"`
+	IssuedAt  *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=issued_at,json=issuedAt,proto3" json:"issued_at,omitempty"`
+	// Last authenticated request, updated at most once a minute
+	LastSeenAt *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=last_seen_at,json=lastSeenAt,proto3" json:"last_seen_at,omitempty"`
+	// Whether this is the session of the token making the request
+	Current       bool `protobuf:"varint,6,opt,name=current,proto3" json:"current,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Session) Reset() {
+	*x = Session{}
+	mi := &file_library_proto_msgTypes[88]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Session) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Session) ProtoMessage() {}
+
+func (x *Session) ProtoReflect() protoreflect.Message {
+	mi := &file_library_proto_msgTypes[88]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Session.ProtoReflect.Descriptor instead.
+func (*Session) Descriptor() ([]byte, []int) {
+	return file_library_proto_rawDescGZIP(), []int{88}
+}
+
+func (x *Session) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Session) GetUserAgent() string {
+	if x != nil {
+		return x.UserAgent
+	}
+	return ""
+}
+
+func (x *Session) GetIpAddress() string {
+	if x != nil {
+		return x.IpAddress
+	}
+	return ""
+}
+
+func (x *Session) GetIssuedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.IssuedAt
+	}
+	return nil
+}
+
+func (x *Session) GetLastSeenAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.LastSeenAt
+	}
+	return nil
+}
+
+func (x *Session) GetCurrent() bool {
+	if x != nil {
+		return x.Current
+	}
+	return false
+}
+
+type ListSessionsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListSessionsRequest) Reset() {
+	*x = ListSessionsRequest{}
+	mi := &file_library_proto_msgTypes[89]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListSessionsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListSessionsRequest) ProtoMessage() {}
+
+func (x *ListSessionsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_library_proto_msgTypes[89]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListSessionsRequest.ProtoReflect.Descriptor instead.
+func (*ListSessionsRequest) Descriptor() ([]byte, []int) {
+	return file_library_proto_rawDescGZIP(), []int{89}
+}
+
+type ListSessionsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Sessions      []*Session             `protobuf:"bytes,1,rep,name=sessions,proto3" json:"sessions,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListSessionsResponse) Reset() {
+	*x = ListSessionsResponse{}
+	mi := &file_library_proto_msgTypes[90]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListSessionsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListSessionsResponse) ProtoMessage() {}
+
+func (x *ListSessionsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_library_proto_msgTypes[90]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListSessionsResponse.ProtoReflect.Descriptor instead.
+func (*ListSessionsResponse) Descriptor() ([]byte, []int) {
+	return file_library_proto_rawDescGZIP(), []int{90}
+}
+
+func (x *ListSessionsResponse) GetSessions() []*Session {
+	if x != nil {
+		return x.Sessions
+	}
+	return nil
+}
+
+type RevokeSessionRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RevokeSessionRequest) Reset() {
+	*x = RevokeSessionRequest{}
+	mi := &file_library_proto_msgTypes[91]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RevokeSessionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RevokeSessionRequest) ProtoMessage() {}
+
+func (x *RevokeSessionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_library_proto_msgTypes[91]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RevokeSessionRequest.ProtoReflect.Descriptor instead.
+func (*RevokeSessionRequest) Descriptor() ([]byte, []int) {
+	return file_library_proto_rawDescGZIP(), []int{91}
+}
+
+func (x *RevokeSessionRequest) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+type IdTokenLoginRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// ID token issued by the OpenID Connect provider to this service's client ID
+	IdToken       string `protobuf:"bytes,1,opt,name=id_token,json=idToken,proto3" json:"id_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *IdTokenLoginRequest) Reset() {
+	*x = IdTokenLoginRequest{}
+	mi := &file_library_proto_msgTypes[92]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *IdTokenLoginRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*IdTokenLoginRequest) ProtoMessage() {}
+
+func (x *IdTokenLoginRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_library_proto_msgTypes[92]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use IdTokenLoginRequest.ProtoReflect.Descriptor instead.
+func (*IdTokenLoginRequest) Descriptor() ([]byte, []int) {
+	return file_library_proto_rawDescGZIP(), []int{92}
+}
+
+func (x *IdTokenLoginRequest) GetIdToken() string {
+	if x != nil {
+		return x.IdToken
+	}
+	return ""
+}
+
+type AuthEvent struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Id    int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Type  AuthEventType          `protobuf:"varint,2,opt,name=type,proto3,enum=library.AuthEventType" json:"type,omitempty"`
+	// Username given in the request; for failed logins it may not belong to any user
+	Username string `protobuf:"bytes,3,opt,name=username,proto3" json:"username,omitempty"`
+	// Why the attempt failed, or how a login was made (e.g. "oidc")
+	Detail        string                 `protobuf:"bytes,4,opt,name=detail,proto3" json:"detail,omitempty"`
+	IpAddress     string                 `protobuf:"bytes,5,opt,name=ip_address,json=ipAddress,proto3" json:"ip_address,omitempty"`
+	UserAgent     string                 `protobuf:"bytes,6,opt,name=user_agent,json=userAgent,proto3" json:"user_agent,omitempty"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AuthEvent) Reset() {
+	*x = AuthEvent{}
+	mi := &file_library_proto_msgTypes[93]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AuthEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AuthEvent) ProtoMessage() {}
+
+func (x *AuthEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_library_proto_msgTypes[93]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AuthEvent.ProtoReflect.Descriptor instead.
+func (*AuthEvent) Descriptor() ([]byte, []int) {
+	return file_library_proto_rawDescGZIP(), []int{93}
+}
+
+func (x *AuthEvent) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *AuthEvent) GetType() AuthEventType {
+	if x != nil {
+		return x.Type
+	}
+	return AuthEventType_AUTH_EVENT_TYPE_UNSPECIFIED
+}
+
+func (x *AuthEvent) GetUsername() string {
+	if x != nil {
+		return x.Username
+	}
+	return ""
+}
+
+func (x *AuthEvent) GetDetail() string {
+	if x != nil {
+		return x.Detail
+	}
+	return ""
+}
+
+func (x *AuthEvent) GetIpAddress() string {
+	if x != nil {
+		return x.IpAddress
+	}
+	return ""
+}
+
+func (x *AuthEvent) GetUserAgent() string {
+	if x != nil {
+		return x.UserAgent
+	}
+	return ""
+}
+
+func (x *AuthEvent) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+type ListAuthEventsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// User whose events to list; only admins may name another user. Empty lists the caller's own events.
+	Username string `protobuf:"bytes,1,opt,name=username,proto3" json:"username,omitempty"`
+	// Only events of this type when set
+	Type     AuthEventType `protobuf:"varint,2,opt,name=type,proto3,enum=library.AuthEventType" json:"type,omitempty"`
+	Page     int32         `protobuf:"varint,3,opt,name=page,proto3" json:"page,omitempty"`
+	PageSize int32         `protobuf:"varint,4,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	// Token of the page to return, from next_page_token of the previous one; takes the place of page
+	PageToken     string `protobuf:"bytes,5,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListAuthEventsRequest) Reset() {
+	*x = ListAuthEventsRequest{}
+	mi := &file_library_proto_msgTypes[94]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListAuthEventsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListAuthEventsRequest) ProtoMessage() {}
+
+func (x *ListAuthEventsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_library_proto_msgTypes[94]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListAuthEventsRequest.ProtoReflect.Descriptor instead.
+func (*ListAuthEventsRequest) Descriptor() ([]byte, []int) {
+	return file_library_proto_rawDescGZIP(), []int{94}
+}
+
+func (x *ListAuthEventsRequest) GetUsername() string {
+	if x != nil {
+		return x.Username
+	}
+	return ""
+}
+
+func (x *ListAuthEventsRequest) GetType() AuthEventType {
+	if x != nil {
+		return x.Type
+	}
+	return AuthEventType_AUTH_EVENT_TYPE_UNSPECIFIED
+}
+
+func (x *ListAuthEventsRequest) GetPage() int32 {
+	if x != nil {
+		return x.Page
+	}
+	return 0
+}
+
+func (x *ListAuthEventsRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *ListAuthEventsRequest) GetPageToken() string {
+	if x != nil {
+		return x.PageToken
+	}
+	return ""
+}
+
+type ListAuthEventsResponse struct {
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	Events     []*AuthEvent           `protobuf:"bytes,1,rep,name=events,proto3" json:"events,omitempty"`
+	TotalCount int32                  `protobuf:"varint,2,opt,name=total_count,json=totalCount,proto3" json:"total_count,omitempty"`
+	// Token of the next page, empty on the last one
+	NextPageToken string `protobuf:"bytes,3,opt,name=next_page_token,json=nextPageToken,proto3" json:"next_page_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListAuthEventsResponse) Reset() {
+	*x = ListAuthEventsResponse{}
+	mi := &file_library_proto_msgTypes[95]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListAuthEventsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListAuthEventsResponse) ProtoMessage() {}
+
+func (x *ListAuthEventsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_library_proto_msgTypes[95]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListAuthEventsResponse.ProtoReflect.Descriptor instead.
+func (*ListAuthEventsResponse) Descriptor() ([]byte, []int) {
+	return file_library_proto_rawDescGZIP(), []int{95}
+}
+
+func (x *ListAuthEventsResponse) GetEvents() []*AuthEvent {
+	if x != nil {
+		return x.Events
+	}
+	return nil
+}
+
+func (x *ListAuthEventsResponse) GetTotalCount() int32 {
+	if x != nil {
+		return x.TotalCount
+	}
+	return 0
+}
+
+func (x *ListAuthEventsResponse) GetNextPageToken() string {
+	if x != nil {
+		return x.NextPageToken
+	}
+	return ""
+}
+
+// A successful call of a method that changes data
+type AuditLogEntry struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Id    int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	// Full method name, e.g. "/library.LibraryService/AddBook"
+	Method string `protobuf:"bytes,2,opt,name=method,proto3" json:"method,omitempty"`
+	// The caller; 0 for services authenticated by client certificate
+	UserId   int32  `protobuf:"varint,3,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Username string `protobuf:"bytes,4,opt,name=username,proto3" json:"username,omitempty"`
+	// ID of the book, shelf, loan or other resource changed, when the call names one
+	ResourceId string `protobuf:"bytes,5,opt,name=resource_id,json=resourceId,proto3" json:"resource_id,omitempty"`
+	// The request as JSON, without passwords, tokens or binary data, and truncated; the number of
+	// messages for client-streaming methods
+	RequestSummary string                 `protobuf:"bytes,6,opt,name=request_summary,json=requestSummary,proto3" json:"request_summary,omitempty"`
+	CreatedAt      *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *AuditLogEntry) Reset() {
+	*x = AuditLogEntry{}
+	mi := &file_library_proto_msgTypes[96]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AuditLogEntry) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AuditLogEntry) ProtoMessage() {}
+
+func (x *AuditLogEntry) ProtoReflect() protoreflect.Message {
+	mi := &file_library_proto_msgTypes[96]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AuditLogEntry.ProtoReflect.Descriptor instead.
+func (*AuditLogEntry) Descriptor() ([]byte, []int) {
+	return file_library_proto_rawDescGZIP(), []int{96}
+}
+
+func (x *AuditLogEntry) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *AuditLogEntry) GetMethod() string {
+	if x != nil {
+		return x.Method
+	}
+	return ""
+}
+
+func (x *AuditLogEntry) GetUserId() int32 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+func (x *AuditLogEntry) GetUsername() string {
+	if x != nil {
+		return x.Username
+	}
+	return ""
+}
+
+func (x *AuditLogEntry) GetResourceId() string {
+	if x != nil {
+		return x.ResourceId
+	}
+	return ""
+}
+
+func (x *AuditLogEntry) GetRequestSummary() string {
+	if x != nil {
+		return x.RequestSummary
+	}
+	return ""
+}
+
+func (x *AuditLogEntry) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+type ListAuditLogRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Only entries of this user when set
+	UserId int32 `protobuf:"varint,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	// Only entries of this full method name when set
+	Method string `protobuf:"bytes,2,opt,name=method,proto3" json:"method,omitempty"`
+	// Only entries about this resource when set
+	ResourceId string `protobuf:"bytes,3,opt,name=resource_id,json=resourceId,proto3" json:"resource_id,omitempty"`
+	Page       int32  `protobuf:"varint,4,opt,name=page,proto3" json:"page,omitempty"`
+	PageSize   int32  `protobuf:"varint,5,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	// Token of the page to return, from next_page_token of the previous one; takes the place of page
+	PageToken     string `protobuf:"bytes,6,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListAuditLogRequest) Reset() {
+	*x = ListAuditLogRequest{}
+	mi := &file_library_proto_msgTypes[97]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListAuditLogRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListAuditLogRequest) ProtoMessage() {}
+
+func (x *ListAuditLogRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_library_proto_msgTypes[97]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListAuditLogRequest.ProtoReflect.Descriptor instead.
+func (*ListAuditLogRequest) Descriptor() ([]byte, []int) {
+	return file_library_proto_rawDescGZIP(), []int{97}
+}
+
+func (x *ListAuditLogRequest) GetUserId() int32 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+func (x *ListAuditLogRequest) GetMethod() string {
+	if x != nil {
+		return x.Method
+	}
+	return ""
+}
+
+func (x *ListAuditLogRequest) GetResourceId() string {
+	if x != nil {
+		return x.ResourceId
+	}
+	return ""
+}
+
+func (x *ListAuditLogRequest) GetPage() int32 {
+	if x != nil {
+		return x.Page
+	}
+	return 0
+}
+
+func (x *ListAuditLogRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *ListAuditLogRequest) GetPageToken() string {
+	if x != nil {
+		return x.PageToken
+	}
+	return ""
+}
+
+type ListAuditLogResponse struct {
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	Entries    []*AuditLogEntry       `protobuf:"bytes,1,rep,name=entries,proto3" json:"entries,omitempty"`
+	TotalCount int32                  `protobuf:"varint,2,opt,name=total_count,json=totalCount,proto3" json:"total_count,omitempty"`
+	// Token of the next page, empty on the last one
+	NextPageToken string `protobuf:"bytes,3,opt,name=next_page_token,json=nextPageToken,proto3" json:"next_page_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListAuditLogResponse) Reset() {
+	*x = ListAuditLogResponse{}
+	mi := &file_library_proto_msgTypes[98]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListAuditLogResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListAuditLogResponse) ProtoMessage() {}
+
+func (x *ListAuditLogResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_library_proto_msgTypes[98]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListAuditLogResponse.ProtoReflect.Descriptor instead.
+func (*ListAuditLogResponse) Descriptor() ([]byte, []int) {
+	return file_library_proto_rawDescGZIP(), []int{98}
+}
+
+func (x *ListAuditLogResponse) GetEntries() []*AuditLogEntry {
+	if x != nil {
+		return x.Entries
+	}
+	return nil
+}
+
+func (x *ListAuditLogResponse) GetTotalCount() int32 {
+	if x != nil {
+		return x.TotalCount
+	}
+	return 0
+}
+
+func (x *ListAuditLogResponse) GetNextPageToken() string {
+	if x != nil {
+		return x.NextPageToken
+	}
+	return ""
+}
+
+type CreateScopedTokenRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// At least one of the scopes known to the server, e.g. "books:read"
+	Scopes []string `protobuf:"bytes,1,rep,name=scopes,proto3" json:"scopes,omitempty"`
+	// Lifetime of the token; defaults to 30 days, at most 90 days
+	Ttl *durationpb.Duration `protobuf:"bytes,2,opt,name=ttl,proto3" json:"ttl,omitempty"`
+	// Shown in ListSessions to tell tokens apart, e.g. "kitchen dashboard"
+	Name          string `protobuf:"bytes,3,opt,name=name,proto3" json:"name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateScopedTokenRequest) Reset() {
+	*x = CreateScopedTokenRequest{}
+	mi := &file_library_proto_msgTypes[99]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateScopedTokenRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateScopedTokenRequest) ProtoMessage() {}
+
+func (x *CreateScopedTokenRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_library_proto_msgTypes[99]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateScopedTokenRequest.ProtoReflect.Descriptor instead.
+func (*CreateScopedTokenRequest) Descriptor() ([]byte, []int) {
+	return file_library_proto_rawDescGZIP(), []int{99}
+}
+
+func (x *CreateScopedTokenRequest) GetScopes() []string {
+	if x != nil {
+		return x.Scopes
+	}
+	return nil
+}
+
+func (x *CreateScopedTokenRequest) GetTtl() *durationpb.Duration {
+	if x != nil {
+		return x.Ttl
+	}
+	return nil
+}
+
+func (x *CreateScopedTokenRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+type ScopedToken struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	Token     string                 `protobuf:"bytes,1,opt,name=token,proto3" json:"token,omitempty"`
+	Scopes    []string               `protobuf:"bytes,2,rep,name=scopes,proto3" json:"scopes,omitempty"`
+	ExpiresAt *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
+	// The session to revoke to invalidate the token
+	SessionId     int64 `protobuf:"varint,4,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ScopedToken) Reset() {
+	*x = ScopedToken{}
+	mi := &file_library_proto_msgTypes[100]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ScopedToken) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ScopedToken) ProtoMessage() {}
+
+func (x *ScopedToken) ProtoReflect() protoreflect.Message {
+	mi := &file_library_proto_msgTypes[100]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ScopedToken.ProtoReflect.Descriptor instead.
+func (*ScopedToken) Descriptor() ([]byte, []int) {
+	return file_library_proto_rawDescGZIP(), []int{100}
+}
+
+func (x *ScopedToken) GetToken() string {
+	if x != nil {
+		return x.Token
+	}
+	return ""
+}
+
+func (x *ScopedToken) GetScopes() []string {
+	if x != nil {
+		return x.Scopes
+	}
+	return nil
+}
+
+func (x *ScopedToken) GetExpiresAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return nil
+}
+
+func (x *ScopedToken) GetSessionId() int64 {
+	if x != nil {
+		return x.SessionId
+	}
+	return 0
+}
+
+type ChangePasswordRequest struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	CurrentPassword string                 `protobuf:"bytes,1,opt,name=current_password,json=currentPassword,proto3" json:"current_password,omitempty"`
+	NewPassword     string                 `protobuf:"bytes,2,opt,name=new_password,json=newPassword,proto3" json:"new_password,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *ChangePasswordRequest) Reset() {
+	*x = ChangePasswordRequest{}
+	mi := &file_library_proto_msgTypes[101]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ChangePasswordRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ChangePasswordRequest) ProtoMessage() {}
+
+func (x *ChangePasswordRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_library_proto_msgTypes[101]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ChangePasswordRequest.ProtoReflect.Descriptor instead.
+func (*ChangePasswordRequest) Descriptor() ([]byte, []int) {
+	return file_library_proto_rawDescGZIP(), []int{101}
+}
+
+func (x *ChangePasswordRequest) GetCurrentPassword() string {
+	if x != nil {
+		return x.CurrentPassword
+	}
+	return ""
+}
+
+func (x *ChangePasswordRequest) GetNewPassword() string {
+	if x != nil {
+		return x.NewPassword
+	}
+	return ""
+}
+
+type ChangePasswordResponse struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Message string                 `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
+	// Other sessions of the user that were logged out
+	RevokedSessions int32 `protobuf:"varint,2,opt,name=revoked_sessions,json=revokedSessions,proto3" json:"revoked_sessions,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *ChangePasswordResponse) Reset() {
+	*x = ChangePasswordResponse{}
+	mi := &file_library_proto_msgTypes[102]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ChangePasswordResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ChangePasswordResponse) ProtoMessage() {}
+
+func (x *ChangePasswordResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_library_proto_msgTypes[102]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ChangePasswordResponse.ProtoReflect.Descriptor instead.
+func (*ChangePasswordResponse) Descriptor() ([]byte, []int) {
+	return file_library_proto_rawDescGZIP(), []int{102}
+}
+
+func (x *ChangePasswordResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *ChangePasswordResponse) GetRevokedSessions() int32 {
+	if x != nil {
+		return x.RevokedSessions
+	}
+	return 0
+}
+
+type ServerInfoRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ServerInfoRequest) Reset() {
+	*x = ServerInfoRequest{}
+	mi := &file_library_proto_msgTypes[103]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ListBookResponse) String() string {
+func (x *ServerInfoRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ListBookResponse) ProtoMessage() {}
+func (*ServerInfoRequest) ProtoMessage() {}
 
-func (x *ListBookResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_library_proto_msgTypes[7]
+func (x *ServerInfoRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_library_proto_msgTypes[103]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -419,47 +6877,243 @@ func (x *ListBookResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ListBookResponse.ProtoReflect.Descriptor instead.
-func (*ListBookResponse) Descriptor() ([]byte, []int) {
-	return file_library_proto_rawDescGZIP(), []int{7}
+// Deprecated: Use ServerInfoRequest.ProtoReflect.Descriptor instead.
+func (*ServerInfoRequest) Descriptor() ([]byte, []int) {
+	return file_library_proto_rawDescGZIP(), []int{103}
 }
 
-func (x *ListBookResponse) GetBooks() []*Book {
+type ServerInfo struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Semantic version of the build, e.g. "1.4.0"; "dev" for builds that didn't set one
+	Version string `protobuf:"bytes,1,opt,name=version,proto3" json:"version,omitempty"`
+	// Commit the server was built from, with a "-dirty" suffix if the tree had uncommitted changes
+	GitCommit string                 `protobuf:"bytes,2,opt,name=git_commit,json=gitCommit,proto3" json:"git_commit,omitempty"`
+	BuildTime *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=build_time,json=buildTime,proto3" json:"build_time,omitempty"`
+	// Go toolchain the server was built with, e.g. "go1.24.4"
+	GoVersion string `protobuf:"bytes,4,opt,name=go_version,json=goVersion,proto3" json:"go_version,omitempty"`
+	// Optional features turned on by the server's configuration, e.g. "tls", "oidc", "response_cache"
+	EnabledFeatures []string `protobuf:"bytes,5,rep,name=enabled_features,json=enabledFeatures,proto3" json:"enabled_features,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *ServerInfo) Reset() {
+	*x = ServerInfo{}
+	mi := &file_library_proto_msgTypes[104]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ServerInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ServerInfo) ProtoMessage() {}
+
+func (x *ServerInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_library_proto_msgTypes[104]
 	if x != nil {
-		return x.Books
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ServerInfo.ProtoReflect.Descriptor instead.
+func (*ServerInfo) Descriptor() ([]byte, []int) {
+	return file_library_proto_rawDescGZIP(), []int{104}
+}
+
+func (x *ServerInfo) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+func (x *ServerInfo) GetGitCommit() string {
+	if x != nil {
+		return x.GitCommit
+	}
+	return ""
+}
+
+func (x *ServerInfo) GetBuildTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.BuildTime
 	}
 	return nil
 }
 
-func (x *ListBookResponse) GetTotalCount() int32 {
+func (x *ServerInfo) GetGoVersion() string {
 	if x != nil {
-		return x.TotalCount
+		return x.GoVersion
 	}
-	return 0
+	return ""
 }
 
-type BatchResponse struct {
+func (x *ServerInfo) GetEnabledFeatures() []string {
+	if x != nil {
+		return x.EnabledFeatures
+	}
+	return nil
+}
+
+type Tenant struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Lowercase letters, digits and underscores, starting with a letter, e.g. "riverside"; the x-tenant-id
+	// header of calls to the library
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Tenant) Reset() {
+	*x = Tenant{}
+	mi := &file_library_proto_msgTypes[105]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Tenant) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Tenant) ProtoMessage() {}
+
+func (x *Tenant) ProtoReflect() protoreflect.Message {
+	mi := &file_library_proto_msgTypes[105]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Tenant.ProtoReflect.Descriptor instead.
+func (*Tenant) Descriptor() ([]byte, []int) {
+	return file_library_proto_rawDescGZIP(), []int{105}
+}
+
+func (x *Tenant) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Tenant) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Tenant) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+type CreateTenantRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Id    string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name  string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	// The library's first user, an admin, who can then manage its catalog and accounts
+	AdminUsername string `protobuf:"bytes,3,opt,name=admin_username,json=adminUsername,proto3" json:"admin_username,omitempty"`
+	AdminPassword string `protobuf:"bytes,4,opt,name=admin_password,json=adminPassword,proto3" json:"admin_password,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateTenantRequest) Reset() {
+	*x = CreateTenantRequest{}
+	mi := &file_library_proto_msgTypes[106]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateTenantRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateTenantRequest) ProtoMessage() {}
+
+func (x *CreateTenantRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_library_proto_msgTypes[106]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateTenantRequest.ProtoReflect.Descriptor instead.
+func (*CreateTenantRequest) Descriptor() ([]byte, []int) {
+	return file_library_proto_rawDescGZIP(), []int{106}
+}
+
+func (x *CreateTenantRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *CreateTenantRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *CreateTenantRequest) GetAdminUsername() string {
+	if x != nil {
+		return x.AdminUsername
+	}
+	return ""
+}
+
+func (x *CreateTenantRequest) GetAdminPassword() string {
+	if x != nil {
+		return x.AdminPassword
+	}
+	return ""
+}
+
+type ListTenantsRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Responses     []*BookResponse        `protobuf:"bytes,1,rep,name=responses,proto3" json:"responses,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *BatchResponse) Reset() {
-	*x = BatchResponse{}
-	mi := &file_library_proto_msgTypes[8]
+func (x *ListTenantsRequest) Reset() {
+	*x = ListTenantsRequest{}
+	mi := &file_library_proto_msgTypes[107]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *BatchResponse) String() string {
+func (x *ListTenantsRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*BatchResponse) ProtoMessage() {}
+func (*ListTenantsRequest) ProtoMessage() {}
 
-func (x *BatchResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_library_proto_msgTypes[8]
+func (x *ListTenantsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_library_proto_msgTypes[107]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -470,14 +7124,51 @@ func (x *BatchResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use BatchResponse.ProtoReflect.Descriptor instead.
-func (*BatchResponse) Descriptor() ([]byte, []int) {
-	return file_library_proto_rawDescGZIP(), []int{8}
+// Deprecated: Use ListTenantsRequest.ProtoReflect.Descriptor instead.
+func (*ListTenantsRequest) Descriptor() ([]byte, []int) {
+	return file_library_proto_rawDescGZIP(), []int{107}
 }
 
-func (x *BatchResponse) GetResponses() []*BookResponse {
+type ListTenantsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Tenants       []*Tenant              `protobuf:"bytes,1,rep,name=tenants,proto3" json:"tenants,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListTenantsResponse) Reset() {
+	*x = ListTenantsResponse{}
+	mi := &file_library_proto_msgTypes[108]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListTenantsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListTenantsResponse) ProtoMessage() {}
+
+func (x *ListTenantsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_library_proto_msgTypes[108]
 	if x != nil {
-		return x.Responses
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListTenantsResponse.ProtoReflect.Descriptor instead.
+func (*ListTenantsResponse) Descriptor() ([]byte, []int) {
+	return file_library_proto_rawDescGZIP(), []int{108}
+}
+
+func (x *ListTenantsResponse) GetTenants() []*Tenant {
+	if x != nil {
+		return x.Tenants
 	}
 	return nil
 }
@@ -486,45 +7177,660 @@ var File_library_proto protoreflect.FileDescriptor
 
 const file_library_proto_rawDesc = "" +
 	"\n" +
-	"\rlibrary.proto\x12\alibrary\x1a\x1cgoogle/api/annotations.proto\">\n" +
+	"\rlibrary.proto\x12\alibrary\x1a\x1cgoogle/api/annotations.proto\x1a\x1egoogle/protobuf/duration.proto\x1a google/protobuf/field_mask.proto\x1a\x1fgoogle/protobuf/timestamp.proto\x1a\x17google/rpc/status.proto\"}\n" +
 	"\x04User\x12\x1a\n" +
 	"\busername\x18\x01 \x01(\tR\busername\x12\x1a\n" +
-	"\bpassword\x18\x02 \x01(\tR\bpassword\"I\n" +
+	"\bpassword\x18\x02 \x01(\tR\bpassword\x12\x14\n" +
+	"\x05email\x18\x03 \x01(\tR\x05email\x12'\n" +
+	"\x0fchallenge_token\x18\x04 \x01(\tR\x0echallengeToken\"I\n" +
 	"\x0fUserCredentials\x12\x1a\n" +
 	"\busername\x18\x01 \x01(\tR\busername\x12\x1a\n" +
-	"\bpassword\x18\x02 \x01(\tR\bpassword\">\n" +
+	"\bpassword\x18\x02 \x01(\tR\bpassword\"\xe8\x01\n" +
 	"\fAuthResponse\x12\x14\n" +
 	"\x05token\x18\x01 \x01(\tR\x05token\x12\x18\n" +
-	"\amessage\x18\x02 \x01(\tR\amessage\"\x1d\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\x12#\n" +
+	"\rrefresh_token\x18\x03 \x01(\tR\frefreshToken\x129\n" +
+	"\n" +
+	"expires_at\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\texpiresAt\x12H\n" +
+	"\x12refresh_expires_at\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\x10refreshExpiresAt\"K\n" +
 	"\vBookRequest\x12\x0e\n" +
-	"\x02id\x18\x01 \x01(\tR\x02id\"8\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x16\n" +
+	"\x06locale\x18\x02 \x01(\tR\x06locale\x12\x14\n" +
+	"\x05limit\x18\x03 \x01(\x05R\x05limit\"W\n" +
 	"\fBookResponse\x12\x0e\n" +
-	"\x02id\x18\x01 \x01(\tR\x02id\x12\x18\n" +
-	"\amessage\x18\x02 \x01(\tR\amessage\"D\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12(\n" +
+	"\x05error\x18\x03 \x01(\v2\x12.google.rpc.StatusR\x05errorJ\x04\b\x02\x10\x03R\amessage\"\xb6\x05\n" +
 	"\x04Book\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\tR\x02id\x12\x14\n" +
 	"\x05title\x18\x02 \x01(\tR\x05title\x12\x16\n" +
-	"\x06author\x18\x03 \x01(\tR\x06author\"B\n" +
+	"\x06author\x18\x03 \x01(\tR\x06author\x12\x12\n" +
+	"\x04isbn\x18\x04 \x01(\tR\x04isbn\x12\x1b\n" +
+	"\tcover_url\x18\x05 \x01(\tR\bcoverUrl\x12\x1c\n" +
+	"\tpublisher\x18\x06 \x01(\tR\tpublisher\x12\x12\n" +
+	"\x04tags\x18\a \x03(\tR\x04tags\x12%\n" +
+	"\x0eaverage_rating\x18\b \x01(\x01R\raverageRating\x12!\n" +
+	"\freview_count\x18\t \x01(\x05R\vreviewCount\x12!\n" +
+	"\ftotal_copies\x18\n" +
+	" \x01(\x05R\vtotalCopies\x12)\n" +
+	"\x10available_copies\x18\v \x01(\x05R\x0favailableCopies\x12'\n" +
+	"\x0fallow_duplicate\x18\f \x01(\bR\x0eallowDuplicate\x12+\n" +
+	"\x06series\x18\r \x01(\v2\x13.library.BookSeriesR\x06series\x12 \n" +
+	"\vdescription\x18\x0e \x01(\tR\vdescription\x12\x1a\n" +
+	"\blanguage\x18\x0f \x01(\tR\blanguage\x12-\n" +
+	"\blocation\x18\x10 \x01(\v2\x11.library.LocationR\blocation\x12!\n" +
+	"\fpublisher_id\x18\x11 \x01(\x05R\vpublisherId\x129\n" +
+	"\n" +
+	"created_at\x18\x12 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x129\n" +
+	"\n" +
+	"updated_at\x18\x13 \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\x12\x19\n" +
+	"\bowner_id\x18\x14 \x01(\x05R\aownerId\"R\n" +
+	"\bLocation\x12\x16\n" +
+	"\x06branch\x18\x01 \x01(\tR\x06branch\x12\x18\n" +
+	"\asection\x18\x02 \x01(\tR\asection\x12\x14\n" +
+	"\x05shelf\x18\x03 \x01(\tR\x05shelf\"8\n" +
+	"\n" +
+	"BookSeries\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x16\n" +
+	"\x06volume\x18\x02 \x01(\x05R\x06volume\"\xbe\x02\n" +
 	"\x0fListBookRequest\x12\x12\n" +
 	"\x04page\x18\x01 \x01(\x05R\x04page\x12\x1b\n" +
-	"\tpage_size\x18\x02 \x01(\x05R\bpageSize\"X\n" +
+	"\tpage_size\x18\x02 \x01(\x05R\bpageSize\x12\x12\n" +
+	"\x04tags\x18\x03 \x03(\tR\x04tags\x12\x16\n" +
+	"\x06locale\x18\x04 \x01(\tR\x06locale\x12\x16\n" +
+	"\x06branch\x18\x05 \x01(\tR\x06branch\x12\x18\n" +
+	"\asection\x18\x06 \x01(\tR\asection\x12!\n" +
+	"\fpublisher_id\x18\a \x01(\x05R\vpublisherId\x12\x16\n" +
+	"\x06author\x18\b \x01(\tR\x06author\x12!\n" +
+	"\fnewest_first\x18\t \x01(\bR\vnewestFirst\x12\x1f\n" +
+	"\vexact_count\x18\n" +
+	" \x01(\bR\n" +
+	"exactCount\x12\x1d\n" +
+	"\n" +
+	"page_token\x18\v \x01(\tR\tpageToken\"s\n" +
+	"\x12SearchBooksRequest\x12\x14\n" +
+	"\x05query\x18\x01 \x01(\tR\x05query\x12\x12\n" +
+	"\x04page\x18\x02 \x01(\x05R\x04page\x12\x1b\n" +
+	"\tpage_size\x18\x03 \x01(\x05R\bpageSize\x12\x16\n" +
+	"\x06locale\x18\x04 \x01(\tR\x06locale\"\xb4\x01\n" +
 	"\x10ListBookResponse\x12#\n" +
 	"\x05books\x18\x01 \x03(\v2\r.library.BookR\x05books\x12\x1f\n" +
 	"\vtotal_count\x18\x02 \x01(\x05R\n" +
-	"totalCount\"D\n" +
+	"totalCount\x122\n" +
+	"\x15total_count_estimated\x18\x03 \x01(\bR\x13totalCountEstimated\x12&\n" +
+	"\x0fnext_page_token\x18\x04 \x01(\tR\rnextPageToken\"D\n" +
 	"\rBatchResponse\x123\n" +
-	"\tresponses\x18\x01 \x03(\v2\x15.library.BookResponseR\tresponses2\xba\x01\n" +
-	"\vUserService\x12R\n" +
-	"\bRegister\x12\r.library.User\x1a\x15.library.AuthResponse\" \x82\xd3\xe4\x93\x02\x1a:\x01*\"\x15/api/v1/auth/register\x12W\n" +
-	"\x05Login\x12\x18.library.UserCredentials\x1a\x15.library.AuthResponse\"\x1d\x82\xd3\xe4\x93\x02\x17:\x01*\"\x12/api/v1/auth/login2\x98\x03\n" +
+	"\tresponses\x18\x01 \x03(\v2\x15.library.BookResponseR\tresponses\"8\n" +
+	"\x11BulkUpdateRequest\x12#\n" +
+	"\x05books\x18\x01 \x03(\v2\r.library.BookR\x05books\"\x82\x01\n" +
+	"\x10PatchBookRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12!\n" +
+	"\x04book\x18\x02 \x01(\v2\r.library.BookR\x04book\x12;\n" +
+	"\vupdate_mask\x18\x03 \x01(\v2\x1a.google.protobuf.FieldMaskR\n" +
+	"updateMask\"\xac\x02\n" +
+	"\fBookRevision\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x17\n" +
+	"\abook_id\x18\x02 \x01(\tR\x06bookId\x12/\n" +
+	"\x06action\x18\x03 \x01(\x0e2\x17.library.RevisionActionR\x06action\x12\x17\n" +
+	"\auser_id\x18\x04 \x01(\x05R\x06userId\x12\x1a\n" +
+	"\busername\x18\x05 \x01(\tR\busername\x129\n" +
+	"\n" +
+	"changed_at\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\tchangedAt\x12(\n" +
+	"\bold_book\x18\a \x01(\v2\r.library.BookR\aoldBook\x12(\n" +
+	"\bnew_book\x18\b \x01(\v2\r.library.BookR\anewBook\"]\n" +
+	"\rExportRequest\x12-\n" +
+	"\x06format\x18\x01 \x01(\x0e2\x15.library.ExportFormatR\x06format\x12\x1d\n" +
+	"\n" +
+	"chunk_size\x18\x02 \x01(\x05R\tchunkSize\">\n" +
+	"\vExportChunk\x12\x12\n" +
+	"\x04data\x18\x01 \x01(\fR\x04data\x12\x1b\n" +
+	"\trow_count\x18\x02 \x01(\x05R\browCount\"R\n" +
+	"\rImportRequest\x12-\n" +
+	"\x06format\x18\x01 \x01(\x0e2\x15.library.ExportFormatR\x06format\x12\x12\n" +
+	"\x04line\x18\x02 \x01(\tR\x04line\"X\n" +
+	"\rImportFailure\x12\x1f\n" +
+	"\vline_number\x18\x01 \x01(\x05R\n" +
+	"lineNumber\x12\x0e\n" +
+	"\x02id\x18\x02 \x01(\tR\x02id\x12\x16\n" +
+	"\x06reason\x18\x03 \x01(\tR\x06reason\"\x92\x01\n" +
+	"\x0eImportResponse\x12\x1a\n" +
+	"\binserted\x18\x01 \x01(\x05R\binserted\x12\x18\n" +
+	"\askipped\x18\x02 \x01(\x05R\askipped\x12\x16\n" +
+	"\x06failed\x18\x03 \x01(\x05R\x06failed\x122\n" +
+	"\bfailures\x18\x04 \x03(\v2\x16.library.ImportFailureR\bfailures\"\\\n" +
+	"\n" +
+	"CoverChunk\x12\x17\n" +
+	"\abook_id\x18\x01 \x01(\tR\x06bookId\x12!\n" +
+	"\fcontent_type\x18\x02 \x01(\tR\vcontentType\x12\x12\n" +
+	"\x04data\x18\x03 \x01(\fR\x04data\"h\n" +
+	"\rCoverResponse\x12\x17\n" +
+	"\abook_id\x18\x01 \x01(\tR\x06bookId\x12\x1b\n" +
+	"\tcover_url\x18\x02 \x01(\tR\bcoverUrl\x12\x12\n" +
+	"\x04size\x18\x03 \x01(\x03R\x04sizeJ\x04\b\x04\x10\x05R\amessage\"!\n" +
+	"\vIsbnRequest\x12\x12\n" +
+	"\x04isbn\x18\x01 \x01(\tR\x04isbn\"H\n" +
+	"\x03Tag\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x05R\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x1d\n" +
+	"\n" +
+	"book_count\x18\x03 \x01(\x05R\tbookCount\"<\n" +
+	"\vTagResponse\x12\x1e\n" +
+	"\x03tag\x18\x01 \x01(\v2\f.library.TagR\x03tagJ\x04\b\x02\x10\x03R\amessage\"\x11\n" +
+	"\x0fListTagsRequest\"4\n" +
+	"\x10ListTagsResponse\x12 \n" +
+	"\x04tags\x18\x01 \x03(\v2\f.library.TagR\x04tags\";\n" +
+	"\x0eBookTagRequest\x12\x17\n" +
+	"\abook_id\x18\x01 \x01(\tR\x06bookId\x12\x10\n" +
+	"\x03tag\x18\x02 \x01(\tR\x03tag\"\xd3\x01\n" +
+	"\x06Review\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x17\n" +
+	"\abook_id\x18\x02 \x01(\tR\x06bookId\x12\x17\n" +
+	"\auser_id\x18\x03 \x01(\x05R\x06userId\x12\x1a\n" +
+	"\busername\x18\x04 \x01(\tR\busername\x12\x16\n" +
+	"\x06rating\x18\x05 \x01(\x05R\x06rating\x12\x18\n" +
+	"\acomment\x18\x06 \x01(\tR\acomment\x129\n" +
+	"\n" +
+	"created_at\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\"\x1f\n" +
+	"\rReviewRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\"/\n" +
+	"\x0eReviewResponse\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02idJ\x04\b\x02\x10\x03R\amessage\"}\n" +
+	"\x12ListReviewsRequest\x12\x17\n" +
+	"\abook_id\x18\x01 \x01(\tR\x06bookId\x12\x12\n" +
+	"\x04page\x18\x02 \x01(\x05R\x04page\x12\x1b\n" +
+	"\tpage_size\x18\x03 \x01(\x05R\bpageSize\x12\x1d\n" +
+	"\n" +
+	"page_token\x18\x04 \x01(\tR\tpageToken\"\xb0\x01\n" +
+	"\x13ListReviewsResponse\x12)\n" +
+	"\areviews\x18\x01 \x03(\v2\x0f.library.ReviewR\areviews\x12\x1f\n" +
+	"\vtotal_count\x18\x02 \x01(\x05R\n" +
+	"totalCount\x12%\n" +
+	"\x0eaverage_rating\x18\x03 \x01(\x01R\raverageRating\x12&\n" +
+	"\x0fnext_page_token\x18\x04 \x01(\tR\rnextPageToken\"*\n" +
+	"\x0fFavoriteRequest\x12\x17\n" +
+	"\abook_id\x18\x01 \x01(\tR\x06bookId\"f\n" +
+	"\x14ListFavoritesRequest\x12\x12\n" +
+	"\x04page\x18\x01 \x01(\x05R\x04page\x12\x1b\n" +
+	"\tpage_size\x18\x02 \x01(\x05R\bpageSize\x12\x1d\n" +
+	"\n" +
+	"page_token\x18\x03 \x01(\tR\tpageToken\"\xcc\x01\n" +
+	"\x05Shelf\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12 \n" +
+	"\vdescription\x18\x03 \x01(\tR\vdescription\x12\x1d\n" +
+	"\n" +
+	"book_count\x18\x04 \x01(\x05R\tbookCount\x12#\n" +
+	"\x05books\x18\x05 \x03(\v2\r.library.BookR\x05books\x129\n" +
+	"\n" +
+	"created_at\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\"\x1e\n" +
+	"\fShelfRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\".\n" +
+	"\rShelfResponse\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02idJ\x04\b\x02\x10\x03R\amessage\"\x14\n" +
+	"\x12ListShelvesRequest\"?\n" +
+	"\x13ListShelvesResponse\x12(\n" +
+	"\ashelves\x18\x01 \x03(\v2\x0e.library.ShelfR\ashelves\"F\n" +
+	"\x10ShelfBookRequest\x12\x19\n" +
+	"\bshelf_id\x18\x01 \x01(\x03R\ashelfId\x12\x17\n" +
+	"\abook_id\x18\x02 \x01(\tR\x06bookId\"-\n" +
+	"\x15RecommendationRequest\x12\x14\n" +
+	"\x05limit\x18\x01 \x01(\x05R\x05limit\"\xae\x02\n" +
+	"\x04Loan\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x17\n" +
+	"\abook_id\x18\x02 \x01(\tR\x06bookId\x12\x1d\n" +
+	"\n" +
+	"book_title\x18\x03 \x01(\tR\tbookTitle\x12\x17\n" +
+	"\auser_id\x18\x04 \x01(\x05R\x06userId\x12;\n" +
+	"\vborrowed_at\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"borrowedAt\x121\n" +
+	"\x06due_at\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\x05dueAt\x12;\n" +
+	"\vreturned_at\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"returnedAt\x12\x18\n" +
+	"\aoverdue\x18\b \x01(\bR\aoverdue\"E\n" +
+	"\rBorrowRequest\x12\x17\n" +
+	"\abook_id\x18\x01 \x01(\tR\x06bookId\x12\x1b\n" +
+	"\tloan_days\x18\x02 \x01(\x05R\bloanDays\"\x1d\n" +
+	"\vLoanRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\"\x8d\x01\n" +
+	"\x10ListLoansRequest\x12)\n" +
+	"\x10include_returned\x18\x01 \x01(\bR\x0fincludeReturned\x12\x12\n" +
+	"\x04page\x18\x02 \x01(\x05R\x04page\x12\x1b\n" +
+	"\tpage_size\x18\x03 \x01(\x05R\bpageSize\x12\x1d\n" +
+	"\n" +
+	"page_token\x18\x04 \x01(\tR\tpageToken\"\x81\x01\n" +
+	"\x11ListLoansResponse\x12#\n" +
+	"\x05loans\x18\x01 \x03(\v2\r.library.LoanR\x05loans\x12\x1f\n" +
+	"\vtotal_count\x18\x02 \x01(\x05R\n" +
+	"totalCount\x12&\n" +
+	"\x0fnext_page_token\x18\x03 \x01(\tR\rnextPageToken\"\xa6\x02\n" +
+	"\vReservation\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x17\n" +
+	"\abook_id\x18\x02 \x01(\tR\x06bookId\x12\x1d\n" +
+	"\n" +
+	"book_title\x18\x03 \x01(\tR\tbookTitle\x122\n" +
+	"\x06status\x18\x04 \x01(\x0e2\x1a.library.ReservationStatusR\x06status\x12%\n" +
+	"\x0equeue_position\x18\x05 \x01(\x05R\rqueuePosition\x129\n" +
+	"\n" +
+	"created_at\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x129\n" +
+	"\n" +
+	"expires_at\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\texpiresAt\")\n" +
+	"\x0eReserveRequest\x12\x17\n" +
+	"\abook_id\x18\x01 \x01(\tR\x06bookId\"$\n" +
+	"\x12ReservationRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\"@\n" +
+	"\x17ListReservationsRequest\x12%\n" +
+	"\x0einclude_closed\x18\x01 \x01(\bR\rincludeClosed\"T\n" +
+	"\x18ListReservationsResponse\x128\n" +
+	"\freservations\x18\x01 \x03(\v2\x14.library.ReservationR\freservations\"\xbc\x02\n" +
+	"\x04Fine\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x17\n" +
+	"\aloan_id\x18\x02 \x01(\x03R\x06loanId\x12\x17\n" +
+	"\abook_id\x18\x03 \x01(\tR\x06bookId\x12\x1d\n" +
+	"\n" +
+	"book_title\x18\x04 \x01(\tR\tbookTitle\x12!\n" +
+	"\fdays_overdue\x18\x05 \x01(\x05R\vdaysOverdue\x12!\n" +
+	"\famount_cents\x18\x06 \x01(\x03R\vamountCents\x12\x1d\n" +
+	"\n" +
+	"paid_cents\x18\a \x01(\x03R\tpaidCents\x129\n" +
+	"\n" +
+	"created_at\x18\b \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x123\n" +
+	"\apaid_at\x18\t \x01(\v2\x1a.google.protobuf.TimestampR\x06paidAt\"5\n" +
+	"\x10ListFinesRequest\x12!\n" +
+	"\finclude_paid\x18\x01 \x01(\bR\vincludePaid\"e\n" +
+	"\x11ListFinesResponse\x12#\n" +
+	"\x05fines\x18\x01 \x03(\v2\r.library.FineR\x05fines\x12+\n" +
+	"\x11outstanding_cents\x18\x02 \x01(\x03R\x10outstandingCents\"C\n" +
+	"\x0ePayFineRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12!\n" +
+	"\famount_cents\x18\x02 \x01(\x03R\vamountCents\"i\n" +
+	"\x13NotificationRequest\x12/\n" +
+	"\x05types\x18\x01 \x03(\x0e2\x19.library.NotificationTypeR\x05types\x12!\n" +
+	"\fresume_token\x18\x02 \x01(\tR\vresumeToken\"\xf1\x01\n" +
+	"\fNotification\x12-\n" +
+	"\x04type\x18\x01 \x01(\x0e2\x19.library.NotificationTypeR\x04type\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\x12\x17\n" +
+	"\abook_id\x18\x03 \x01(\tR\x06bookId\x12!\n" +
+	"\freference_id\x18\x04 \x01(\x03R\vreferenceId\x129\n" +
+	"\n" +
+	"created_at\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x12!\n" +
+	"\fresume_token\x18\x06 \x01(\tR\vresumeToken\"#\n" +
+	"\rSeriesRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\"~\n" +
+	"\x0fBookTranslation\x12\x17\n" +
+	"\abook_id\x18\x01 \x01(\tR\x06bookId\x12\x1a\n" +
+	"\blanguage\x18\x02 \x01(\tR\blanguage\x12\x14\n" +
+	"\x05title\x18\x03 \x01(\tR\x05title\x12 \n" +
+	"\vdescription\x18\x04 \x01(\tR\vdescription\"M\n" +
+	"\x16BookTranslationRequest\x12\x17\n" +
+	"\abook_id\x18\x01 \x01(\tR\x06bookId\x12\x1a\n" +
+	"\blanguage\x18\x02 \x01(\tR\blanguage\"\\\n" +
+	"\x1cListBookTranslationsResponse\x12<\n" +
+	"\ftranslations\x18\x01 \x03(\v2\x18.library.BookTranslationR\ftranslations\"\xba\x01\n" +
+	"\fBookLocation\x12\x17\n" +
+	"\abook_id\x18\x01 \x01(\tR\x06bookId\x12\x14\n" +
+	"\x05title\x18\x02 \x01(\tR\x05title\x12-\n" +
+	"\blocation\x18\x03 \x01(\v2\x11.library.LocationR\blocation\x12)\n" +
+	"\x10available_copies\x18\x04 \x01(\x05R\x0favailableCopies\x12!\n" +
+	"\ftotal_copies\x18\x05 \x01(\x05R\vtotalCopies\"\xb2\x02\n" +
+	"\bBookCopy\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x17\n" +
+	"\abook_id\x18\x02 \x01(\tR\x06bookId\x12\x18\n" +
+	"\abarcode\x18\x03 \x01(\tR\abarcode\x129\n" +
+	"\n" +
+	"created_at\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x124\n" +
+	"\tcondition\x18\x05 \x01(\x0e2\x16.library.CopyConditionR\tcondition\x12\x1f\n" +
+	"\vprice_cents\x18\x06 \x01(\x03R\n" +
+	"priceCents\x12;\n" +
+	"\vacquired_at\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"acquiredAt\x12\x14\n" +
+	"\x05donor\x18\b \x01(\tR\x05donor\"\xea\x01\n" +
+	"\vCopyRequest\x12\x17\n" +
+	"\abook_id\x18\x01 \x01(\tR\x06bookId\x12\x18\n" +
+	"\abarcode\x18\x02 \x01(\tR\abarcode\x124\n" +
+	"\tcondition\x18\x03 \x01(\x0e2\x16.library.CopyConditionR\tcondition\x12\x1f\n" +
+	"\vprice_cents\x18\x04 \x01(\x03R\n" +
+	"priceCents\x12;\n" +
+	"\vacquired_at\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"acquiredAt\x12\x14\n" +
+	"\x05donor\x18\x06 \x01(\tR\x05donor\"?\n" +
+	"\x12ListCopiesResponse\x12)\n" +
+	"\x06copies\x18\x01 \x03(\v2\x11.library.BookCopyR\x06copies\"c\n" +
+	"\x0eBarcodeRequest\x12\x18\n" +
+	"\abarcode\x18\x01 \x01(\tR\abarcode\x127\n" +
+	"\tsymbology\x18\x02 \x01(\x0e2\x19.library.BarcodeSymbologyR\tsymbology\"_\n" +
+	"\fBarcodeImage\x12\x18\n" +
+	"\abarcode\x18\x01 \x01(\tR\abarcode\x12!\n" +
+	"\fcontent_type\x18\x02 \x01(\tR\vcontentType\x12\x12\n" +
+	"\x04data\x18\x03 \x01(\fR\x04data\"h\n" +
+	"\tPublisher\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x05R\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x18\n" +
+	"\awebsite\x18\x03 \x01(\tR\awebsite\x12\x1d\n" +
+	"\n" +
+	"book_count\x18\x04 \x01(\x05R\tbookCount\"g\n" +
+	"\x15ListPublishersRequest\x12\x12\n" +
+	"\x04page\x18\x01 \x01(\x05R\x04page\x12\x1b\n" +
+	"\tpage_size\x18\x02 \x01(\x05R\bpageSize\x12\x1d\n" +
+	"\n" +
+	"page_token\x18\x03 \x01(\tR\tpageToken\"\x95\x01\n" +
+	"\x16ListPublishersResponse\x122\n" +
+	"\n" +
+	"publishers\x18\x01 \x03(\v2\x12.library.PublisherR\n" +
+	"publishers\x12\x1f\n" +
+	"\vtotal_count\x18\x02 \x01(\x05R\n" +
+	"totalCount\x12&\n" +
+	"\x0fnext_page_token\x18\x03 \x01(\tR\rnextPageToken\"w\n" +
+	"\x15PublisherBooksRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x05R\x02id\x12\x12\n" +
+	"\x04page\x18\x02 \x01(\x05R\x04page\x12\x1b\n" +
+	"\tpage_size\x18\x03 \x01(\x05R\bpageSize\x12\x1d\n" +
+	"\n" +
+	"page_token\x18\x04 \x01(\tR\tpageToken\"\xc0\x02\n" +
+	"\x17ListAcquisitionsRequest\x12?\n" +
+	"\racquired_from\x18\x01 \x01(\v2\x1a.google.protobuf.TimestampR\facquiredFrom\x12;\n" +
+	"\vacquired_to\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"acquiredTo\x124\n" +
+	"\tcondition\x18\x03 \x01(\x0e2\x16.library.CopyConditionR\tcondition\x12!\n" +
+	"\fdonated_only\x18\x04 \x01(\bR\vdonatedOnly\x12\x12\n" +
+	"\x04page\x18\x05 \x01(\x05R\x04page\x12\x1b\n" +
+	"\tpage_size\x18\x06 \x01(\x05R\bpageSize\x12\x1d\n" +
+	"\n" +
+	"page_token\x18\a \x01(\tR\tpageToken\"b\n" +
+	"\vAcquisition\x12%\n" +
+	"\x04copy\x18\x01 \x01(\v2\x11.library.BookCopyR\x04copy\x12\x14\n" +
+	"\x05title\x18\x02 \x01(\tR\x05title\x12\x16\n" +
+	"\x06author\x18\x03 \x01(\tR\x06author\"\xee\x01\n" +
+	"\x18ListAcquisitionsResponse\x128\n" +
+	"\facquisitions\x18\x01 \x03(\v2\x14.library.AcquisitionR\facquisitions\x12\x1f\n" +
+	"\vtotal_count\x18\x02 \x01(\x05R\n" +
+	"totalCount\x12*\n" +
+	"\x11total_price_cents\x18\x03 \x01(\x03R\x0ftotalPriceCents\x12#\n" +
+	"\rdonated_count\x18\x04 \x01(\x05R\fdonatedCount\x12&\n" +
+	"\x0fnext_page_token\x18\x05 \x01(\tR\rnextPageToken\":\n" +
+	"\x13RefreshTokenRequest\x12#\n" +
+	"\rrefresh_token\x18\x01 \x01(\tR\frefreshToken\"4\n" +
+	"\rLogoutRequest\x12#\n" +
+	"\rrefresh_token\x18\x01 \x01(\tR\frefreshToken\"\x1a\n" +
+	"\x18RevokeAllSessionsRequest\"`\n" +
+	"\x0eLogoutResponse\x12\x18\n" +
+	"\amessage\x18\x01 \x01(\tR\amessage\x124\n" +
+	"\x16revoked_refresh_tokens\x18\x02 \x01(\x05R\x14revokedRefreshTokens\"2\n" +
+	"\x14PasswordResetRequest\x12\x1a\n" +
+	"\busername\x18\x01 \x01(\tR\busername\"V\n" +
+	"\x1bConfirmPasswordResetRequest\x12\x14\n" +
+	"\x05token\x18\x01 \x01(\tR\x05token\x12!\n" +
+	"\fnew_password\x18\x02 \x01(\tR\vnewPassword\"1\n" +
+	"\x15PasswordResetResponse\x12\x18\n" +
+	"\amessage\x18\x01 \x01(\tR\amessage\"*\n" +
+	"\x12VerifyEmailRequest\x12\x14\n" +
+	"\x05token\x18\x01 \x01(\tR\x05token\"E\n" +
+	"\x13VerifyEmailResponse\x12\x18\n" +
+	"\amessage\x18\x01 \x01(\tR\amessage\x12\x14\n" +
+	"\x05email\x18\x02 \x01(\tR\x05email\"\xbf\x03\n" +
+	"\aProfile\x12\x1a\n" +
+	"\busername\x18\x01 \x01(\tR\busername\x12!\n" +
+	"\fdisplay_name\x18\x02 \x01(\tR\vdisplayName\x12\x14\n" +
+	"\x05email\x18\x03 \x01(\tR\x05email\x12%\n" +
+	"\x0eemail_verified\x18\x04 \x01(\bR\remailVerified\x12=\n" +
+	"\vpreferences\x18\x05 \x01(\v2\x1b.library.ProfilePreferencesR\vpreferences\x12>\n" +
+	"\rlast_login_at\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\vlastLoginAt\x12\"\n" +
+	"\rlast_login_ip\x18\a \x01(\tR\vlastLoginIp\x12\x1f\n" +
+	"\vlogin_count\x18\b \x01(\x03R\n" +
+	"loginCount\x129\n" +
+	"\n" +
+	"created_at\x18\t \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x129\n" +
+	"\n" +
+	"updated_at\x18\n" +
+	" \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\"~\n" +
+	"\x12ProfilePreferences\x12\x1a\n" +
+	"\blanguage\x18\x01 \x01(\tR\blanguage\x12\x1b\n" +
+	"\tpage_size\x18\x02 \x01(\x05R\bpageSize\x12/\n" +
+	"\x13email_notifications\x18\x03 \x01(\bR\x12emailNotifications\"\x13\n" +
+	"\x11GetProfileRequest\"\x7f\n" +
+	"\x14UpdateProfileRequest\x12*\n" +
+	"\aprofile\x18\x01 \x01(\v2\x10.library.ProfileR\aprofile\x12;\n" +
+	"\vupdate_mask\x18\x02 \x01(\v2\x1a.google.protobuf.FieldMaskR\n" +
+	"updateMask\"\xe8\x01\n" +
+	"\aSession\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x1d\n" +
+	"\n" +
+	"user_agent\x18\x02 \x01(\tR\tuserAgent\x12\x1d\n" +
+	"\n" +
+	"ip_address\x18\x03 \x01(\tR\tipAddress\x127\n" +
+	"\tissued_at\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\bissuedAt\x12<\n" +
+	"\flast_seen_at\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"lastSeenAt\x12\x18\n" +
+	"\acurrent\x18\x06 \x01(\bR\acurrent\"\x15\n" +
+	"\x13ListSessionsRequest\"D\n" +
+	"\x14ListSessionsResponse\x12,\n" +
+	"\bsessions\x18\x01 \x03(\v2\x10.library.SessionR\bsessions\"&\n" +
+	"\x14RevokeSessionRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\"0\n" +
+	"\x13IdTokenLoginRequest\x12\x19\n" +
+	"\bid_token\x18\x01 \x01(\tR\aidToken\"\xf4\x01\n" +
+	"\tAuthEvent\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12*\n" +
+	"\x04type\x18\x02 \x01(\x0e2\x16.library.AuthEventTypeR\x04type\x12\x1a\n" +
+	"\busername\x18\x03 \x01(\tR\busername\x12\x16\n" +
+	"\x06detail\x18\x04 \x01(\tR\x06detail\x12\x1d\n" +
+	"\n" +
+	"ip_address\x18\x05 \x01(\tR\tipAddress\x12\x1d\n" +
+	"\n" +
+	"user_agent\x18\x06 \x01(\tR\tuserAgent\x129\n" +
+	"\n" +
+	"created_at\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\"\xaf\x01\n" +
+	"\x15ListAuthEventsRequest\x12\x1a\n" +
+	"\busername\x18\x01 \x01(\tR\busername\x12*\n" +
+	"\x04type\x18\x02 \x01(\x0e2\x16.library.AuthEventTypeR\x04type\x12\x12\n" +
+	"\x04page\x18\x03 \x01(\x05R\x04page\x12\x1b\n" +
+	"\tpage_size\x18\x04 \x01(\x05R\bpageSize\x12\x1d\n" +
+	"\n" +
+	"page_token\x18\x05 \x01(\tR\tpageToken\"\x8d\x01\n" +
+	"\x16ListAuthEventsResponse\x12*\n" +
+	"\x06events\x18\x01 \x03(\v2\x12.library.AuthEventR\x06events\x12\x1f\n" +
+	"\vtotal_count\x18\x02 \x01(\x05R\n" +
+	"totalCount\x12&\n" +
+	"\x0fnext_page_token\x18\x03 \x01(\tR\rnextPageToken\"\xf1\x01\n" +
+	"\rAuditLogEntry\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x16\n" +
+	"\x06method\x18\x02 \x01(\tR\x06method\x12\x17\n" +
+	"\auser_id\x18\x03 \x01(\x05R\x06userId\x12\x1a\n" +
+	"\busername\x18\x04 \x01(\tR\busername\x12\x1f\n" +
+	"\vresource_id\x18\x05 \x01(\tR\n" +
+	"resourceId\x12'\n" +
+	"\x0frequest_summary\x18\x06 \x01(\tR\x0erequestSummary\x129\n" +
+	"\n" +
+	"created_at\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\"\xb7\x01\n" +
+	"\x13ListAuditLogRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\x05R\x06userId\x12\x16\n" +
+	"\x06method\x18\x02 \x01(\tR\x06method\x12\x1f\n" +
+	"\vresource_id\x18\x03 \x01(\tR\n" +
+	"resourceId\x12\x12\n" +
+	"\x04page\x18\x04 \x01(\x05R\x04page\x12\x1b\n" +
+	"\tpage_size\x18\x05 \x01(\x05R\bpageSize\x12\x1d\n" +
+	"\n" +
+	"page_token\x18\x06 \x01(\tR\tpageToken\"\x91\x01\n" +
+	"\x14ListAuditLogResponse\x120\n" +
+	"\aentries\x18\x01 \x03(\v2\x16.library.AuditLogEntryR\aentries\x12\x1f\n" +
+	"\vtotal_count\x18\x02 \x01(\x05R\n" +
+	"totalCount\x12&\n" +
+	"\x0fnext_page_token\x18\x03 \x01(\tR\rnextPageToken\"s\n" +
+	"\x18CreateScopedTokenRequest\x12\x16\n" +
+	"\x06scopes\x18\x01 \x03(\tR\x06scopes\x12+\n" +
+	"\x03ttl\x18\x02 \x01(\v2\x19.google.protobuf.DurationR\x03ttl\x12\x12\n" +
+	"\x04name\x18\x03 \x01(\tR\x04name\"\x95\x01\n" +
+	"\vScopedToken\x12\x14\n" +
+	"\x05token\x18\x01 \x01(\tR\x05token\x12\x16\n" +
+	"\x06scopes\x18\x02 \x03(\tR\x06scopes\x129\n" +
+	"\n" +
+	"expires_at\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\texpiresAt\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x04 \x01(\x03R\tsessionId\"e\n" +
+	"\x15ChangePasswordRequest\x12)\n" +
+	"\x10current_password\x18\x01 \x01(\tR\x0fcurrentPassword\x12!\n" +
+	"\fnew_password\x18\x02 \x01(\tR\vnewPassword\"]\n" +
+	"\x16ChangePasswordResponse\x12\x18\n" +
+	"\amessage\x18\x01 \x01(\tR\amessage\x12)\n" +
+	"\x10revoked_sessions\x18\x02 \x01(\x05R\x0frevokedSessions\"\x13\n" +
+	"\x11ServerInfoRequest\"\xca\x01\n" +
+	"\n" +
+	"ServerInfo\x12\x18\n" +
+	"\aversion\x18\x01 \x01(\tR\aversion\x12\x1d\n" +
+	"\n" +
+	"git_commit\x18\x02 \x01(\tR\tgitCommit\x129\n" +
+	"\n" +
+	"build_time\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\tbuildTime\x12\x1d\n" +
+	"\n" +
+	"go_version\x18\x04 \x01(\tR\tgoVersion\x12)\n" +
+	"\x10enabled_features\x18\x05 \x03(\tR\x0fenabledFeatures\"g\n" +
+	"\x06Tenant\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x129\n" +
+	"\n" +
+	"created_at\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\"\x87\x01\n" +
+	"\x13CreateTenantRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12%\n" +
+	"\x0eadmin_username\x18\x03 \x01(\tR\radminUsername\x12%\n" +
+	"\x0eadmin_password\x18\x04 \x01(\tR\radminPassword\"\x14\n" +
+	"\x12ListTenantsRequest\"@\n" +
+	"\x13ListTenantsResponse\x12)\n" +
+	"\atenants\x18\x01 \x03(\v2\x0f.library.TenantR\atenants*\x85\x01\n" +
+	"\x0eRevisionAction\x12\x1f\n" +
+	"\x1bREVISION_ACTION_UNSPECIFIED\x10\x00\x12\x1a\n" +
+	"\x16REVISION_ACTION_CREATE\x10\x01\x12\x1a\n" +
+	"\x16REVISION_ACTION_UPDATE\x10\x02\x12\x1a\n" +
+	"\x16REVISION_ACTION_DELETE\x10\x03*\\\n" +
+	"\fExportFormat\x12\x1d\n" +
+	"\x19EXPORT_FORMAT_UNSPECIFIED\x10\x00\x12\x15\n" +
+	"\x11EXPORT_FORMAT_CSV\x10\x01\x12\x16\n" +
+	"\x12EXPORT_FORMAT_JSON\x10\x02*\xd9\x01\n" +
+	"\x11ReservationStatus\x12\"\n" +
+	"\x1eRESERVATION_STATUS_UNSPECIFIED\x10\x00\x12\x1e\n" +
+	"\x1aRESERVATION_STATUS_WAITING\x10\x01\x12\x1c\n" +
+	"\x18RESERVATION_STATUS_READY\x10\x02\x12 \n" +
+	"\x1cRESERVATION_STATUS_FULFILLED\x10\x03\x12 \n" +
+	"\x1cRESERVATION_STATUS_CANCELLED\x10\x04\x12\x1e\n" +
+	"\x1aRESERVATION_STATUS_EXPIRED\x10\x05*\x8a\x02\n" +
+	"\x10NotificationType\x12!\n" +
+	"\x1dNOTIFICATION_TYPE_UNSPECIFIED\x10\x00\x12 \n" +
+	"\x1cNOTIFICATION_TYPE_HOLD_READY\x10\x01\x12\"\n" +
+	"\x1eNOTIFICATION_TYPE_HOLD_EXPIRED\x10\x02\x12#\n" +
+	"\x1fNOTIFICATION_TYPE_LOAN_DUE_SOON\x10\x03\x12\"\n" +
+	"\x1eNOTIFICATION_TYPE_LOAN_OVERDUE\x10\x04\x12\x1f\n" +
+	"\x1bNOTIFICATION_TYPE_KEEPALIVE\x10\x05\x12#\n" +
+	"\x1fNOTIFICATION_TYPE_EVENTS_MISSED\x10\x06*q\n" +
+	"\x10BarcodeSymbology\x12!\n" +
+	"\x1dBARCODE_SYMBOLOGY_UNSPECIFIED\x10\x00\x12\x1d\n" +
+	"\x19BARCODE_SYMBOLOGY_CODE128\x10\x01\x12\x1b\n" +
+	"\x17BARCODE_SYMBOLOGY_EAN13\x10\x02*\xae\x01\n" +
+	"\rCopyCondition\x12\x1e\n" +
+	"\x1aCOPY_CONDITION_UNSPECIFIED\x10\x00\x12\x16\n" +
+	"\x12COPY_CONDITION_NEW\x10\x01\x12\x17\n" +
+	"\x13COPY_CONDITION_GOOD\x10\x02\x12\x17\n" +
+	"\x13COPY_CONDITION_FAIR\x10\x03\x12\x17\n" +
+	"\x13COPY_CONDITION_POOR\x10\x04\x12\x1a\n" +
+	"\x16COPY_CONDITION_DAMAGED\x10\x05*\xd8\x01\n" +
+	"\rAuthEventType\x12\x1f\n" +
+	"\x1bAUTH_EVENT_TYPE_UNSPECIFIED\x10\x00\x12\x1c\n" +
+	"\x18AUTH_EVENT_TYPE_REGISTER\x10\x01\x12\x19\n" +
+	"\x15AUTH_EVENT_TYPE_LOGIN\x10\x02\x12 \n" +
+	"\x1cAUTH_EVENT_TYPE_LOGIN_FAILED\x10\x03\x12!\n" +
+	"\x1dAUTH_EVENT_TYPE_TOKEN_REFRESH\x10\x04\x12(\n" +
+	"$AUTH_EVENT_TYPE_TOKEN_REFRESH_FAILED\x10\x052\xeb\x0e\n" +
+	"\vUserService\x12k\n" +
+	"\bRegister\x12\r.library.User\x1a\x15.library.AuthResponse\"9\x82\xd3\xe4\x93\x023:\x01*Z\x1a:\x01*\"\x15/api/v1/auth/register\"\x12/v1/users:register\x12W\n" +
+	"\x05Login\x12\x18.library.UserCredentials\x1a\x15.library.AuthResponse\"\x1d\x82\xd3\xe4\x93\x02\x17:\x01*\"\x12/api/v1/auth/login\x12e\n" +
+	"\x10LoginWithIdToken\x12\x1c.library.IdTokenLoginRequest\x1a\x15.library.AuthResponse\"\x1c\x82\xd3\xe4\x93\x02\x16:\x01*\"\x11/api/v1/auth/oidc\x12d\n" +
+	"\fRefreshToken\x12\x1c.library.RefreshTokenRequest\x1a\x15.library.AuthResponse\"\x1f\x82\xd3\xe4\x93\x02\x19:\x01*\"\x14/api/v1/auth/refresh\x12Y\n" +
+	"\x06Logout\x12\x16.library.LogoutRequest\x1a\x17.library.LogoutResponse\"\x1e\x82\xd3\xe4\x93\x02\x18:\x01*\"\x13/api/v1/auth/logout\x12y\n" +
+	"\x11RevokeAllSessions\x12!.library.RevokeAllSessionsRequest\x1a\x17.library.LogoutResponse\"(\x82\xd3\xe4\x93\x02\":\x01*\"\x1d/api/v1/me/sessions:revokeAll\x12h\n" +
+	"\fListSessions\x12\x1c.library.ListSessionsRequest\x1a\x1d.library.ListSessionsResponse\"\x1b\x82\xd3\xe4\x93\x02\x15\x12\x13/api/v1/me/sessions\x12i\n" +
+	"\rRevokeSession\x12\x1d.library.RevokeSessionRequest\x1a\x17.library.LogoutResponse\" \x82\xd3\xe4\x93\x02\x1a*\x18/api/v1/me/sessions/{id}\x12\x99\x01\n" +
+	"\x0eListAuthEvents\x12\x1e.library.ListAuthEventsRequest\x1a\x1f.library.ListAuthEventsResponse\"F\x82\xd3\xe4\x93\x02@Z&\x12$/api/v1/users/{username}/auth-events\x12\x16/api/v1/me/auth-events\x12f\n" +
+	"\fListAuditLog\x12\x1c.library.ListAuditLogRequest\x1a\x1d.library.ListAuditLogResponse\"\x19\x82\xd3\xe4\x93\x02\x13\x12\x11/api/v1/audit-log\x12j\n" +
+	"\x11CreateScopedToken\x12!.library.CreateScopedTokenRequest\x1a\x14.library.ScopedToken\"\x1c\x82\xd3\xe4\x93\x02\x16:\x01*\"\x11/api/v1/me/tokens\x12}\n" +
+	"\x14RequestPasswordReset\x12\x1d.library.PasswordResetRequest\x1a\x1e.library.PasswordResetResponse\"&\x82\xd3\xe4\x93\x02 :\x01*\"\x1b/api/v1/auth/password-reset\x12\x8c\x01\n" +
+	"\x14ConfirmPasswordReset\x12$.library.ConfirmPasswordResetRequest\x1a\x1e.library.PasswordResetResponse\".\x82\xd3\xe4\x93\x02(:\x01*\"#/api/v1/auth/password-reset:confirm\x12q\n" +
+	"\x0eChangePassword\x12\x1e.library.ChangePasswordRequest\x1a\x1f.library.ChangePasswordResponse\"\x1e\x82\xd3\xe4\x93\x02\x18:\x01*\"\x13/api/v1/me/password\x12n\n" +
+	"\vVerifyEmail\x12\x1b.library.VerifyEmailRequest\x1a\x1c.library.VerifyEmailResponse\"$\x82\xd3\xe4\x93\x02\x1e:\x01*\"\x19/api/v1/auth/verify-email\x12V\n" +
+	"\n" +
+	"GetProfile\x12\x1a.library.GetProfileRequest\x1a\x10.library.Profile\"\x1a\x82\xd3\xe4\x93\x02\x14\x12\x12/api/v1/me/profile\x12e\n" +
+	"\rUpdateProfile\x12\x1d.library.UpdateProfileRequest\x1a\x10.library.Profile\"#\x82\xd3\xe4\x93\x02\x1d:\aprofile2\x12/api/v1/me/profile2\xe6\x14\n" +
 	"\x0eLibraryService\x12I\n" +
 	"\aAddBook\x12\r.library.Book\x1a\x15.library.BookResponse\"\x18\x82\xd3\xe4\x93\x02\x12:\x01*\"\r/api/v1/books\x12Q\n" +
 	"\n" +
-	"UpdateBook\x12\r.library.Book\x1a\x15.library.BookResponse\"\x1d\x82\xd3\xe4\x93\x02\x17:\x01*\x1a\x12/api/v1/books/{id}\x12U\n" +
+	"UpdateBook\x12\r.library.Book\x1a\x15.library.BookResponse\"\x1d\x82\xd3\xe4\x93\x02\x17:\x01*\x1a\x12/api/v1/books/{id}\x12w\n" +
+	"\tPatchBook\x12\x19.library.PatchBookRequest\x1a\x15.library.BookResponse\"8\x82\xd3\xe4\x93\x022:\x04bookZ\x1a:\x04book2\x12/api/v1/books/{id}2\x0e/v1/books/{id}\x12U\n" +
+	"\n" +
+	"DeleteBook\x12\x14.library.BookRequest\x1a\x15.library.BookResponse\"\x1a\x82\xd3\xe4\x93\x02\x14*\x12/api/v1/books/{id}\x12\\\n" +
+	"\aGetBook\x12\x14.library.BookRequest\x1a\r.library.Book\",\x82\xd3\xe4\x93\x02&Z\x14\x12\x12/api/v1/books/{id}\x12\x0e/v1/books/{id}\x12d\n" +
+	"\tListBooks\x12\x18.library.ListBookRequest\x1a\x19.library.ListBookResponse\"\"\x82\xd3\xe4\x93\x02\x1cZ\x0f\x12\r/api/v1/books\x12\t/v1/books\x12c\n" +
+	"\vSearchBooks\x12\x1b.library.SearchBooksRequest\x1a\x19.library.ListBookResponse\"\x1c\x82\xd3\xe4\x93\x02\x16\x12\x14/api/v1/books:search\x128\n" +
+	"\rBatchAddBooks\x12\r.library.Book\x1a\x16.library.BatchResponse(\x01\x12j\n" +
+	"\x0fBulkUpdateBooks\x12\x1a.library.BulkUpdateRequest\x1a\x16.library.BatchResponse\"#\x82\xd3\xe4\x93\x02\x1d:\x01*\"\x18/api/v1/books:bulkUpdate\x12c\n" +
+	"\x0eGetBookHistory\x12\x14.library.BookRequest\x1a\x15.library.BookRevision\"\"\x82\xd3\xe4\x93\x02\x1c\x12\x1a/api/v1/books/{id}/history0\x01\x12[\n" +
+	"\vExportBooks\x12\x16.library.ExportRequest\x1a\x14.library.ExportChunk\"\x1c\x82\xd3\xe4\x93\x02\x16\x12\x14/api/v1/books:export0\x01\x12@\n" +
+	"\vImportBooks\x12\x16.library.ImportRequest\x1a\x17.library.ImportResponse(\x01\x12<\n" +
+	"\vUploadCover\x12\x13.library.CoverChunk\x1a\x16.library.CoverResponse(\x01\x12<\n" +
+	"\rDownloadCover\x12\x14.library.BookRequest\x1a\x13.library.CoverChunk0\x01\x12P\n" +
+	"\fLookupByISBN\x12\x14.library.IsbnRequest\x1a\r.library.Book\"\x1b\x82\xd3\xe4\x93\x02\x15\x12\x13/api/v1/isbn/{isbn}\x12\x84\x01\n" +
+	"\x12SetBookTranslation\x12\x18.library.BookTranslation\x1a\x18.library.BookTranslation\":\x82\xd3\xe4\x93\x024:\x01*\x1a//api/v1/books/{book_id}/translations/{language}\x12\x88\x01\n" +
+	"\x15DeleteBookTranslation\x12\x1f.library.BookTranslationRequest\x1a\x15.library.BookResponse\"7\x82\xd3\xe4\x93\x021*//api/v1/books/{book_id}/translations/{language}\x12|\n" +
+	"\x14ListBookTranslations\x12\x14.library.BookRequest\x1a%.library.ListBookTranslationsResponse\"'\x82\xd3\xe4\x93\x02!\x12\x1f/api/v1/books/{id}/translations\x12d\n" +
+	"\x10FindBookLocation\x12\x14.library.BookRequest\x1a\x15.library.BookLocation\"#\x82\xd3\xe4\x93\x02\x1d\x12\x1b/api/v1/books/{id}/location\x12]\n" +
+	"\aAddCopy\x12\x14.library.CopyRequest\x1a\x11.library.BookCopy\")\x82\xd3\xe4\x93\x02#:\x01*\"\x1e/api/v1/books/{book_id}/copies\x12b\n" +
+	"\n" +
+	"ListCopies\x12\x14.library.BookRequest\x1a\x1b.library.ListCopiesResponse\"!\x82\xd3\xe4\x93\x02\x1b\x12\x19/api/v1/books/{id}/copies\x12u\n" +
+	"\x10ListAcquisitions\x12 .library.ListAcquisitionsRequest\x1a!.library.ListAcquisitionsResponse\"\x1c\x82\xd3\xe4\x93\x02\x16\x12\x14/api/v1/acquisitions\x12^\n" +
+	"\x10GetBookByBarcode\x12\x17.library.BarcodeRequest\x1a\r.library.Book\"\"\x82\xd3\xe4\x93\x02\x1c\x12\x1a/api/v1/barcodes/{barcode}\x12k\n" +
+	"\x0fGetBarcodeImage\x12\x17.library.BarcodeRequest\x1a\x15.library.BarcodeImage\"(\x82\xd3\xe4\x93\x02\"\x12 /api/v1/barcodes/{barcode}/image\x12f\n" +
+	"\x0fGetRelatedBooks\x12\x14.library.BookRequest\x1a\x19.library.ListBookResponse\"\"\x82\xd3\xe4\x93\x02\x1c\x12\x1a/api/v1/books/{id}/related\x12k\n" +
+	"\x11ListBooksInSeries\x12\x16.library.SeriesRequest\x1a\x19.library.ListBookResponse\"#\x82\xd3\xe4\x93\x02\x1d\x12\x1b/api/v1/series/{name}/books\x12s\n" +
+	"\x12GetRecommendations\x12\x1e.library.RecommendationRequest\x1a\x19.library.ListBookResponse\"\"\x82\xd3\xe4\x93\x02\x1c\x12\x1a/api/v1/me/recommendations2\xfa\x02\n" +
+	"\n" +
+	"TagService\x12H\n" +
+	"\tCreateTag\x12\f.library.Tag\x1a\x14.library.TagResponse\"\x17\x82\xd3\xe4\x93\x02\x11:\x01*\"\f/api/v1/tags\x12U\n" +
+	"\bListTags\x12\x18.library.ListTagsRequest\x1a\x19.library.ListTagsResponse\"\x14\x82\xd3\xe4\x93\x02\x0e\x12\f/api/v1/tags\x12b\n" +
+	"\aTagBook\x12\x17.library.BookTagRequest\x1a\x15.library.BookResponse\"'\x82\xd3\xe4\x93\x02!:\x01*\"\x1c/api/v1/books/{book_id}/tags\x12g\n" +
+	"\tUntagBook\x12\x17.library.BookTagRequest\x1a\x15.library.BookResponse\"*\x82\xd3\xe4\x93\x02$*\"/api/v1/books/{book_id}/tags/{tag}2\xc4\x02\n" +
+	"\rReviewService\x12a\n" +
+	"\tAddReview\x12\x0f.library.Review\x1a\x17.library.ReviewResponse\"*\x82\xd3\xe4\x93\x02$:\x01*\"\x1f/api/v1/books/{book_id}/reviews\x12q\n" +
+	"\vListReviews\x12\x1b.library.ListReviewsRequest\x1a\x1c.library.ListReviewsResponse\"'\x82\xd3\xe4\x93\x02!\x12\x1f/api/v1/books/{book_id}/reviews\x12]\n" +
+	"\fDeleteReview\x12\x16.library.ReviewRequest\x1a\x17.library.ReviewResponse\"\x1c\x82\xd3\xe4\x93\x02\x16*\x14/api/v1/reviews/{id}2\xc6\x02\n" +
+	"\x0fFavoriteService\x12_\n" +
+	"\vAddFavorite\x12\x18.library.FavoriteRequest\x1a\x15.library.BookResponse\"\x1f\x82\xd3\xe4\x93\x02\x19:\x01*\"\x14/api/v1/me/favorites\x12i\n" +
+	"\x0eRemoveFavorite\x12\x18.library.FavoriteRequest\x1a\x15.library.BookResponse\"&\x82\xd3\xe4\x93\x02 *\x1e/api/v1/me/favorites/{book_id}\x12g\n" +
+	"\rListFavorites\x12\x1d.library.ListFavoritesRequest\x1a\x19.library.ListBookResponse\"\x1c\x82\xd3\xe4\x93\x02\x16\x12\x14/api/v1/me/favorites2\x94\x04\n" +
+	"\fShelfService\x12T\n" +
+	"\vCreateShelf\x12\x0e.library.Shelf\x1a\x16.library.ShelfResponse\"\x1d\x82\xd3\xe4\x93\x02\x17:\x01*\"\x12/api/v1/me/shelves\x12d\n" +
+	"\vListShelves\x12\x1b.library.ListShelvesRequest\x1a\x1c.library.ListShelvesResponse\"\x1a\x82\xd3\xe4\x93\x02\x14\x12\x12/api/v1/me/shelves\x12R\n" +
+	"\bGetShelf\x12\x15.library.ShelfRequest\x1a\x0e.library.Shelf\"\x1f\x82\xd3\xe4\x93\x02\x19\x12\x17/api/v1/me/shelves/{id}\x12s\n" +
+	"\x0eAddBookToShelf\x12\x19.library.ShelfBookRequest\x1a\x16.library.ShelfResponse\".\x82\xd3\xe4\x93\x02(:\x01*\"#/api/v1/me/shelves/{shelf_id}/books\x12\x7f\n" +
+	"\x13RemoveBookFromShelf\x12\x19.library.ShelfBookRequest\x1a\x16.library.ShelfResponse\"5\x82\xd3\xe4\x93\x02/*-/api/v1/me/shelves/{shelf_id}/books/{book_id}2\x9e\x06\n" +
+	"\vLoanService\x12P\n" +
+	"\n" +
+	"BorrowBook\x12\x16.library.BorrowRequest\x1a\r.library.Loan\"\x1b\x82\xd3\xe4\x93\x02\x15:\x01*\"\x10/api/v1/me/loans\x12Z\n" +
 	"\n" +
-	"DeleteBook\x12\x14.library.BookRequest\x1a\x15.library.BookResponse\"\x1a\x82\xd3\xe4\x93\x02\x14*\x12/api/v1/books/{id}\x12W\n" +
-	"\tListBooks\x12\x18.library.ListBookRequest\x1a\x19.library.ListBookResponse\"\x15\x82\xd3\xe4\x93\x02\x0f\x12\r/api/v1/books\x128\n" +
-	"\rBatchAddBooks\x12\r.library.Book\x1a\x16.library.BatchResponse(\x01B\x1bZ\x19example/grpc_demo/libraryb\x06proto3"
+	"ReturnBook\x12\x14.library.LoanRequest\x1a\r.library.Loan\"'\x82\xd3\xe4\x93\x02!:\x01*\"\x1c/api/v1/me/loans/{id}:return\x12^\n" +
+	"\vListMyLoans\x12\x19.library.ListLoansRequest\x1a\x1a.library.ListLoansResponse\"\x18\x82\xd3\xe4\x93\x02\x12\x12\x10/api/v1/me/loans\x12`\n" +
+	"\vReserveBook\x12\x17.library.ReserveRequest\x1a\x14.library.Reservation\"\"\x82\xd3\xe4\x93\x02\x1c:\x01*\"\x17/api/v1/me/reservations\x12l\n" +
+	"\x11CancelReservation\x12\x1b.library.ReservationRequest\x1a\x14.library.Reservation\"$\x82\xd3\xe4\x93\x02\x1e*\x1c/api/v1/me/reservations/{id}\x12x\n" +
+	"\x10ListReservations\x12 .library.ListReservationsRequest\x1a!.library.ListReservationsResponse\"\x1f\x82\xd3\xe4\x93\x02\x19\x12\x17/api/v1/me/reservations\x12^\n" +
+	"\vListMyFines\x12\x19.library.ListFinesRequest\x1a\x1a.library.ListFinesResponse\"\x18\x82\xd3\xe4\x93\x02\x12\x12\x10/api/v1/me/fines\x12W\n" +
+	"\aPayFine\x12\x17.library.PayFineRequest\x1a\r.library.Fine\"$\x82\xd3\xe4\x93\x02\x1e:\x01*\"\x19/api/v1/me/fines/{id}:pay2{\n" +
+	"\x13NotificationService\x12d\n" +
+	"\tSubscribe\x12\x1c.library.NotificationRequest\x1a\x15.library.Notification\" \x82\xd3\xe4\x93\x02\x1a\x12\x18/api/v1/me/notifications0\x012\xb4\x03\n" +
+	"\x10PublisherService\x12X\n" +
+	"\x0fCreatePublisher\x12\x12.library.Publisher\x1a\x12.library.Publisher\"\x1d\x82\xd3\xe4\x93\x02\x17:\x01*\"\x12/api/v1/publishers\x12]\n" +
+	"\x0fUpdatePublisher\x12\x12.library.Publisher\x1a\x12.library.Publisher\"\"\x82\xd3\xe4\x93\x02\x1c:\x01*\x1a\x17/api/v1/publishers/{id}\x12m\n" +
+	"\x0eListPublishers\x12\x1e.library.ListPublishersRequest\x1a\x1f.library.ListPublishersResponse\"\x1a\x82\xd3\xe4\x93\x02\x14\x12\x12/api/v1/publishers\x12x\n" +
+	"\x14ListBooksByPublisher\x12\x1e.library.PublisherBooksRequest\x1a\x19.library.ListBookResponse\"%\x82\xd3\xe4\x93\x02\x1f\x12\x1d/api/v1/publishers/{id}/books2g\n" +
+	"\x11ServerInfoService\x12R\n" +
+	"\rGetServerInfo\x12\x1a.library.ServerInfoRequest\x1a\x13.library.ServerInfo\"\x10\x82\xd3\xe4\x93\x02\n" +
+	"\x12\b/version2\xcd\x01\n" +
+	"\rTenantService\x12Y\n" +
+	"\fCreateTenant\x12\x1c.library.CreateTenantRequest\x1a\x0f.library.Tenant\"\x1a\x82\xd3\xe4\x93\x02\x14:\x01*\"\x0f/api/v1/tenants\x12a\n" +
+	"\vListTenants\x12\x1b.library.ListTenantsRequest\x1a\x1c.library.ListTenantsResponse\"\x17\x82\xd3\xe4\x93\x02\x11\x12\x0f/api/v1/tenantsB\x1bZ\x19example/grpc_demo/libraryb\x06proto3"
 
 var (
 	file_library_proto_rawDescOnce sync.Once
@@ -538,40 +7844,361 @@ func file_library_proto_rawDescGZIP() []byte {
 	return file_library_proto_rawDescData
 }
 
-var file_library_proto_msgTypes = make([]protoimpl.MessageInfo, 9)
+var file_library_proto_enumTypes = make([]protoimpl.EnumInfo, 7)
+var file_library_proto_msgTypes = make([]protoimpl.MessageInfo, 109)
 var file_library_proto_goTypes = []any{
-	(*User)(nil),             // 0: library.User
-	(*UserCredentials)(nil),  // 1: library.UserCredentials
-	(*AuthResponse)(nil),     // 2: library.AuthResponse
-	(*BookRequest)(nil),      // 3: library.BookRequest
-	(*BookResponse)(nil),     // 4: library.BookResponse
-	(*Book)(nil),             // 5: library.Book
-	(*ListBookRequest)(nil),  // 6: library.ListBookRequest
-	(*ListBookResponse)(nil), // 7: library.ListBookResponse
-	(*BatchResponse)(nil),    // 8: library.BatchResponse
+	(RevisionAction)(0),                  // 0: library.RevisionAction
+	(ExportFormat)(0),                    // 1: library.ExportFormat
+	(ReservationStatus)(0),               // 2: library.ReservationStatus
+	(NotificationType)(0),                // 3: library.NotificationType
+	(BarcodeSymbology)(0),                // 4: library.BarcodeSymbology
+	(CopyCondition)(0),                   // 5: library.CopyCondition
+	(AuthEventType)(0),                   // 6: library.AuthEventType
+	(*User)(nil),                         // 7: library.User
+	(*UserCredentials)(nil),              // 8: library.UserCredentials
+	(*AuthResponse)(nil),                 // 9: library.AuthResponse
+	(*BookRequest)(nil),                  // 10: library.BookRequest
+	(*BookResponse)(nil),                 // 11: library.BookResponse
+	(*Book)(nil),                         // 12: library.Book
+	(*Location)(nil),                     // 13: library.Location
+	(*BookSeries)(nil),                   // 14: library.BookSeries
+	(*ListBookRequest)(nil),              // 15: library.ListBookRequest
+	(*SearchBooksRequest)(nil),           // 16: library.SearchBooksRequest
+	(*ListBookResponse)(nil),             // 17: library.ListBookResponse
+	(*BatchResponse)(nil),                // 18: library.BatchResponse
+	(*BulkUpdateRequest)(nil),            // 19: library.BulkUpdateRequest
+	(*PatchBookRequest)(nil),             // 20: library.PatchBookRequest
+	(*BookRevision)(nil),                 // 21: library.BookRevision
+	(*ExportRequest)(nil),                // 22: library.ExportRequest
+	(*ExportChunk)(nil),                  // 23: library.ExportChunk
+	(*ImportRequest)(nil),                // 24: library.ImportRequest
+	(*ImportFailure)(nil),                // 25: library.ImportFailure
+	(*ImportResponse)(nil),               // 26: library.ImportResponse
+	(*CoverChunk)(nil),                   // 27: library.CoverChunk
+	(*CoverResponse)(nil),                // 28: library.CoverResponse
+	(*IsbnRequest)(nil),                  // 29: library.IsbnRequest
+	(*Tag)(nil),                          // 30: library.Tag
+	(*TagResponse)(nil),                  // 31: library.TagResponse
+	(*ListTagsRequest)(nil),              // 32: library.ListTagsRequest
+	(*ListTagsResponse)(nil),             // 33: library.ListTagsResponse
+	(*BookTagRequest)(nil),               // 34: library.BookTagRequest
+	(*Review)(nil),                       // 35: library.Review
+	(*ReviewRequest)(nil),                // 36: library.ReviewRequest
+	(*ReviewResponse)(nil),               // 37: library.ReviewResponse
+	(*ListReviewsRequest)(nil),           // 38: library.ListReviewsRequest
+	(*ListReviewsResponse)(nil),          // 39: library.ListReviewsResponse
+	(*FavoriteRequest)(nil),              // 40: library.FavoriteRequest
+	(*ListFavoritesRequest)(nil),         // 41: library.ListFavoritesRequest
+	(*Shelf)(nil),                        // 42: library.Shelf
+	(*ShelfRequest)(nil),                 // 43: library.ShelfRequest
+	(*ShelfResponse)(nil),                // 44: library.ShelfResponse
+	(*ListShelvesRequest)(nil),           // 45: library.ListShelvesRequest
+	(*ListShelvesResponse)(nil),          // 46: library.ListShelvesResponse
+	(*ShelfBookRequest)(nil),             // 47: library.ShelfBookRequest
+	(*RecommendationRequest)(nil),        // 48: library.RecommendationRequest
+	(*Loan)(nil),                         // 49: library.Loan
+	(*BorrowRequest)(nil),                // 50: library.BorrowRequest
+	(*LoanRequest)(nil),                  // 51: library.LoanRequest
+	(*ListLoansRequest)(nil),             // 52: library.ListLoansRequest
+	(*ListLoansResponse)(nil),            // 53: library.ListLoansResponse
+	(*Reservation)(nil),                  // 54: library.Reservation
+	(*ReserveRequest)(nil),               // 55: library.ReserveRequest
+	(*ReservationRequest)(nil),           // 56: library.ReservationRequest
+	(*ListReservationsRequest)(nil),      // 57: library.ListReservationsRequest
+	(*ListReservationsResponse)(nil),     // 58: library.ListReservationsResponse
+	(*Fine)(nil),                         // 59: library.Fine
+	(*ListFinesRequest)(nil),             // 60: library.ListFinesRequest
+	(*ListFinesResponse)(nil),            // 61: library.ListFinesResponse
+	(*PayFineRequest)(nil),               // 62: library.PayFineRequest
+	(*NotificationRequest)(nil),          // 63: library.NotificationRequest
+	(*Notification)(nil),                 // 64: library.Notification
+	(*SeriesRequest)(nil),                // 65: library.SeriesRequest
+	(*BookTranslation)(nil),              // 66: library.BookTranslation
+	(*BookTranslationRequest)(nil),       // 67: library.BookTranslationRequest
+	(*ListBookTranslationsResponse)(nil), // 68: library.ListBookTranslationsResponse
+	(*BookLocation)(nil),                 // 69: library.BookLocation
+	(*BookCopy)(nil),                     // 70: library.BookCopy
+	(*CopyRequest)(nil),                  // 71: library.CopyRequest
+	(*ListCopiesResponse)(nil),           // 72: library.ListCopiesResponse
+	(*BarcodeRequest)(nil),               // 73: library.BarcodeRequest
+	(*BarcodeImage)(nil),                 // 74: library.BarcodeImage
+	(*Publisher)(nil),                    // 75: library.Publisher
+	(*ListPublishersRequest)(nil),        // 76: library.ListPublishersRequest
+	(*ListPublishersResponse)(nil),       // 77: library.ListPublishersResponse
+	(*PublisherBooksRequest)(nil),        // 78: library.PublisherBooksRequest
+	(*ListAcquisitionsRequest)(nil),      // 79: library.ListAcquisitionsRequest
+	(*Acquisition)(nil),                  // 80: library.Acquisition
+	(*ListAcquisitionsResponse)(nil),     // 81: library.ListAcquisitionsResponse
+	(*RefreshTokenRequest)(nil),          // 82: library.RefreshTokenRequest
+	(*LogoutRequest)(nil),                // 83: library.LogoutRequest
+	(*RevokeAllSessionsRequest)(nil),     // 84: library.RevokeAllSessionsRequest
+	(*LogoutResponse)(nil),               // 85: library.LogoutResponse
+	(*PasswordResetRequest)(nil),         // 86: library.PasswordResetRequest
+	(*ConfirmPasswordResetRequest)(nil),  // 87: library.ConfirmPasswordResetRequest
+	(*PasswordResetResponse)(nil),        // 88: library.PasswordResetResponse
+	(*VerifyEmailRequest)(nil),           // 89: library.VerifyEmailRequest
+	(*VerifyEmailResponse)(nil),          // 90: library.VerifyEmailResponse
+	(*Profile)(nil),                      // 91: library.Profile
+	(*ProfilePreferences)(nil),           // 92: library.ProfilePreferences
+	(*GetProfileRequest)(nil),            // 93: library.GetProfileRequest
+	(*UpdateProfileRequest)(nil),         // 94: library.UpdateProfileRequest
+	(*Session)(nil),                      // 95: library.Session
+	(*ListSessionsRequest)(nil),          // 96: library.ListSessionsRequest
+	(*ListSessionsResponse)(nil),         // 97: library.ListSessionsResponse
+	(*RevokeSessionRequest)(nil),         // 98: library.RevokeSessionRequest
+	(*IdTokenLoginRequest)(nil),          // 99: library.IdTokenLoginRequest
+	(*AuthEvent)(nil),                    // 100: library.AuthEvent
+	(*ListAuthEventsRequest)(nil),        // 101: library.ListAuthEventsRequest
+	(*ListAuthEventsResponse)(nil),       // 102: library.ListAuthEventsResponse
+	(*AuditLogEntry)(nil),                // 103: library.AuditLogEntry
+	(*ListAuditLogRequest)(nil),          // 104: library.ListAuditLogRequest
+	(*ListAuditLogResponse)(nil),         // 105: library.ListAuditLogResponse
+	(*CreateScopedTokenRequest)(nil),     // 106: library.CreateScopedTokenRequest
+	(*ScopedToken)(nil),                  // 107: library.ScopedToken
+	(*ChangePasswordRequest)(nil),        // 108: library.ChangePasswordRequest
+	(*ChangePasswordResponse)(nil),       // 109: library.ChangePasswordResponse
+	(*ServerInfoRequest)(nil),            // 110: library.ServerInfoRequest
+	(*ServerInfo)(nil),                   // 111: library.ServerInfo
+	(*Tenant)(nil),                       // 112: library.Tenant
+	(*CreateTenantRequest)(nil),          // 113: library.CreateTenantRequest
+	(*ListTenantsRequest)(nil),           // 114: library.ListTenantsRequest
+	(*ListTenantsResponse)(nil),          // 115: library.ListTenantsResponse
+	(*timestamppb.Timestamp)(nil),        // 116: google.protobuf.Timestamp
+	(*status.Status)(nil),                // 117: google.rpc.Status
+	(*fieldmaskpb.FieldMask)(nil),        // 118: google.protobuf.FieldMask
+	(*durationpb.Duration)(nil),          // 119: google.protobuf.Duration
 }
 var file_library_proto_depIdxs = []int32{
-	5, // 0: library.ListBookResponse.books:type_name -> library.Book
-	4, // 1: library.BatchResponse.responses:type_name -> library.BookResponse
-	0, // 2: library.UserService.Register:input_type -> library.User
-	1, // 3: library.UserService.Login:input_type -> library.UserCredentials
-	5, // 4: library.LibraryService.AddBook:input_type -> library.Book
-	5, // 5: library.LibraryService.UpdateBook:input_type -> library.Book
-	3, // 6: library.LibraryService.DeleteBook:input_type -> library.BookRequest
-	6, // 7: library.LibraryService.ListBooks:input_type -> library.ListBookRequest
-	5, // 8: library.LibraryService.BatchAddBooks:input_type -> library.Book
-	2, // 9: library.UserService.Register:output_type -> library.AuthResponse
-	2, // 10: library.UserService.Login:output_type -> library.AuthResponse
-	4, // 11: library.LibraryService.AddBook:output_type -> library.BookResponse
-	4, // 12: library.LibraryService.UpdateBook:output_type -> library.BookResponse
-	4, // 13: library.LibraryService.DeleteBook:output_type -> library.BookResponse
-	7, // 14: library.LibraryService.ListBooks:output_type -> library.ListBookResponse
-	8, // 15: library.LibraryService.BatchAddBooks:output_type -> library.BatchResponse
-	9, // [9:16] is the sub-list for method output_type
-	2, // [2:9] is the sub-list for method input_type
-	2, // [2:2] is the sub-list for extension type_name
-	2, // [2:2] is the sub-list for extension extendee
-	0, // [0:2] is the sub-list for field type_name
+	116, // 0: library.AuthResponse.expires_at:type_name -> google.protobuf.Timestamp
+	116, // 1: library.AuthResponse.refresh_expires_at:type_name -> google.protobuf.Timestamp
+	117, // 2: library.BookResponse.error:type_name -> google.rpc.Status
+	14,  // 3: library.Book.series:type_name -> library.BookSeries
+	13,  // 4: library.Book.location:type_name -> library.Location
+	116, // 5: library.Book.created_at:type_name -> google.protobuf.Timestamp
+	116, // 6: library.Book.updated_at:type_name -> google.protobuf.Timestamp
+	12,  // 7: library.ListBookResponse.books:type_name -> library.Book
+	11,  // 8: library.BatchResponse.responses:type_name -> library.BookResponse
+	12,  // 9: library.BulkUpdateRequest.books:type_name -> library.Book
+	12,  // 10: library.PatchBookRequest.book:type_name -> library.Book
+	118, // 11: library.PatchBookRequest.update_mask:type_name -> google.protobuf.FieldMask
+	0,   // 12: library.BookRevision.action:type_name -> library.RevisionAction
+	116, // 13: library.BookRevision.changed_at:type_name -> google.protobuf.Timestamp
+	12,  // 14: library.BookRevision.old_book:type_name -> library.Book
+	12,  // 15: library.BookRevision.new_book:type_name -> library.Book
+	1,   // 16: library.ExportRequest.format:type_name -> library.ExportFormat
+	1,   // 17: library.ImportRequest.format:type_name -> library.ExportFormat
+	25,  // 18: library.ImportResponse.failures:type_name -> library.ImportFailure
+	30,  // 19: library.TagResponse.tag:type_name -> library.Tag
+	30,  // 20: library.ListTagsResponse.tags:type_name -> library.Tag
+	116, // 21: library.Review.created_at:type_name -> google.protobuf.Timestamp
+	35,  // 22: library.ListReviewsResponse.reviews:type_name -> library.Review
+	12,  // 23: library.Shelf.books:type_name -> library.Book
+	116, // 24: library.Shelf.created_at:type_name -> google.protobuf.Timestamp
+	42,  // 25: library.ListShelvesResponse.shelves:type_name -> library.Shelf
+	116, // 26: library.Loan.borrowed_at:type_name -> google.protobuf.Timestamp
+	116, // 27: library.Loan.due_at:type_name -> google.protobuf.Timestamp
+	116, // 28: library.Loan.returned_at:type_name -> google.protobuf.Timestamp
+	49,  // 29: library.ListLoansResponse.loans:type_name -> library.Loan
+	2,   // 30: library.Reservation.status:type_name -> library.ReservationStatus
+	116, // 31: library.Reservation.created_at:type_name -> google.protobuf.Timestamp
+	116, // 32: library.Reservation.expires_at:type_name -> google.protobuf.Timestamp
+	54,  // 33: library.ListReservationsResponse.reservations:type_name -> library.Reservation
+	116, // 34: library.Fine.created_at:type_name -> google.protobuf.Timestamp
+	116, // 35: library.Fine.paid_at:type_name -> google.protobuf.Timestamp
+	59,  // 36: library.ListFinesResponse.fines:type_name -> library.Fine
+	3,   // 37: library.NotificationRequest.types:type_name -> library.NotificationType
+	3,   // 38: library.Notification.type:type_name -> library.NotificationType
+	116, // 39: library.Notification.created_at:type_name -> google.protobuf.Timestamp
+	66,  // 40: library.ListBookTranslationsResponse.translations:type_name -> library.BookTranslation
+	13,  // 41: library.BookLocation.location:type_name -> library.Location
+	116, // 42: library.BookCopy.created_at:type_name -> google.protobuf.Timestamp
+	5,   // 43: library.BookCopy.condition:type_name -> library.CopyCondition
+	116, // 44: library.BookCopy.acquired_at:type_name -> google.protobuf.Timestamp
+	5,   // 45: library.CopyRequest.condition:type_name -> library.CopyCondition
+	116, // 46: library.CopyRequest.acquired_at:type_name -> google.protobuf.Timestamp
+	70,  // 47: library.ListCopiesResponse.copies:type_name -> library.BookCopy
+	4,   // 48: library.BarcodeRequest.symbology:type_name -> library.BarcodeSymbology
+	75,  // 49: library.ListPublishersResponse.publishers:type_name -> library.Publisher
+	116, // 50: library.ListAcquisitionsRequest.acquired_from:type_name -> google.protobuf.Timestamp
+	116, // 51: library.ListAcquisitionsRequest.acquired_to:type_name -> google.protobuf.Timestamp
+	5,   // 52: library.ListAcquisitionsRequest.condition:type_name -> library.CopyCondition
+	70,  // 53: library.Acquisition.copy:type_name -> library.BookCopy
+	80,  // 54: library.ListAcquisitionsResponse.acquisitions:type_name -> library.Acquisition
+	92,  // 55: library.Profile.preferences:type_name -> library.ProfilePreferences
+	116, // 56: library.Profile.last_login_at:type_name -> google.protobuf.Timestamp
+	116, // 57: library.Profile.created_at:type_name -> google.protobuf.Timestamp
+	116, // 58: library.Profile.updated_at:type_name -> google.protobuf.Timestamp
+	91,  // 59: library.UpdateProfileRequest.profile:type_name -> library.Profile
+	118, // 60: library.UpdateProfileRequest.update_mask:type_name -> google.protobuf.FieldMask
+	116, // 61: library.Session.issued_at:type_name -> google.protobuf.Timestamp
+	116, // 62: library.Session.last_seen_at:type_name -> google.protobuf.Timestamp
+	95,  // 63: library.ListSessionsResponse.sessions:type_name -> library.Session
+	6,   // 64: library.AuthEvent.type:type_name -> library.AuthEventType
+	116, // 65: library.AuthEvent.created_at:type_name -> google.protobuf.Timestamp
+	6,   // 66: library.ListAuthEventsRequest.type:type_name -> library.AuthEventType
+	100, // 67: library.ListAuthEventsResponse.events:type_name -> library.AuthEvent
+	116, // 68: library.AuditLogEntry.created_at:type_name -> google.protobuf.Timestamp
+	103, // 69: library.ListAuditLogResponse.entries:type_name -> library.AuditLogEntry
+	119, // 70: library.CreateScopedTokenRequest.ttl:type_name -> google.protobuf.Duration
+	116, // 71: library.ScopedToken.expires_at:type_name -> google.protobuf.Timestamp
+	116, // 72: library.ServerInfo.build_time:type_name -> google.protobuf.Timestamp
+	116, // 73: library.Tenant.created_at:type_name -> google.protobuf.Timestamp
+	112, // 74: library.ListTenantsResponse.tenants:type_name -> library.Tenant
+	7,   // 75: library.UserService.Register:input_type -> library.User
+	8,   // 76: library.UserService.Login:input_type -> library.UserCredentials
+	99,  // 77: library.UserService.LoginWithIdToken:input_type -> library.IdTokenLoginRequest
+	82,  // 78: library.UserService.RefreshToken:input_type -> library.RefreshTokenRequest
+	83,  // 79: library.UserService.Logout:input_type -> library.LogoutRequest
+	84,  // 80: library.UserService.RevokeAllSessions:input_type -> library.RevokeAllSessionsRequest
+	96,  // 81: library.UserService.ListSessions:input_type -> library.ListSessionsRequest
+	98,  // 82: library.UserService.RevokeSession:input_type -> library.RevokeSessionRequest
+	101, // 83: library.UserService.ListAuthEvents:input_type -> library.ListAuthEventsRequest
+	104, // 84: library.UserService.ListAuditLog:input_type -> library.ListAuditLogRequest
+	106, // 85: library.UserService.CreateScopedToken:input_type -> library.CreateScopedTokenRequest
+	86,  // 86: library.UserService.RequestPasswordReset:input_type -> library.PasswordResetRequest
+	87,  // 87: library.UserService.ConfirmPasswordReset:input_type -> library.ConfirmPasswordResetRequest
+	108, // 88: library.UserService.ChangePassword:input_type -> library.ChangePasswordRequest
+	89,  // 89: library.UserService.VerifyEmail:input_type -> library.VerifyEmailRequest
+	93,  // 90: library.UserService.GetProfile:input_type -> library.GetProfileRequest
+	94,  // 91: library.UserService.UpdateProfile:input_type -> library.UpdateProfileRequest
+	12,  // 92: library.LibraryService.AddBook:input_type -> library.Book
+	12,  // 93: library.LibraryService.UpdateBook:input_type -> library.Book
+	20,  // 94: library.LibraryService.PatchBook:input_type -> library.PatchBookRequest
+	10,  // 95: library.LibraryService.DeleteBook:input_type -> library.BookRequest
+	10,  // 96: library.LibraryService.GetBook:input_type -> library.BookRequest
+	15,  // 97: library.LibraryService.ListBooks:input_type -> library.ListBookRequest
+	16,  // 98: library.LibraryService.SearchBooks:input_type -> library.SearchBooksRequest
+	12,  // 99: library.LibraryService.BatchAddBooks:input_type -> library.Book
+	19,  // 100: library.LibraryService.BulkUpdateBooks:input_type -> library.BulkUpdateRequest
+	10,  // 101: library.LibraryService.GetBookHistory:input_type -> library.BookRequest
+	22,  // 102: library.LibraryService.ExportBooks:input_type -> library.ExportRequest
+	24,  // 103: library.LibraryService.ImportBooks:input_type -> library.ImportRequest
+	27,  // 104: library.LibraryService.UploadCover:input_type -> library.CoverChunk
+	10,  // 105: library.LibraryService.DownloadCover:input_type -> library.BookRequest
+	29,  // 106: library.LibraryService.LookupByISBN:input_type -> library.IsbnRequest
+	66,  // 107: library.LibraryService.SetBookTranslation:input_type -> library.BookTranslation
+	67,  // 108: library.LibraryService.DeleteBookTranslation:input_type -> library.BookTranslationRequest
+	10,  // 109: library.LibraryService.ListBookTranslations:input_type -> library.BookRequest
+	10,  // 110: library.LibraryService.FindBookLocation:input_type -> library.BookRequest
+	71,  // 111: library.LibraryService.AddCopy:input_type -> library.CopyRequest
+	10,  // 112: library.LibraryService.ListCopies:input_type -> library.BookRequest
+	79,  // 113: library.LibraryService.ListAcquisitions:input_type -> library.ListAcquisitionsRequest
+	73,  // 114: library.LibraryService.GetBookByBarcode:input_type -> library.BarcodeRequest
+	73,  // 115: library.LibraryService.GetBarcodeImage:input_type -> library.BarcodeRequest
+	10,  // 116: library.LibraryService.GetRelatedBooks:input_type -> library.BookRequest
+	65,  // 117: library.LibraryService.ListBooksInSeries:input_type -> library.SeriesRequest
+	48,  // 118: library.LibraryService.GetRecommendations:input_type -> library.RecommendationRequest
+	30,  // 119: library.TagService.CreateTag:input_type -> library.Tag
+	32,  // 120: library.TagService.ListTags:input_type -> library.ListTagsRequest
+	34,  // 121: library.TagService.TagBook:input_type -> library.BookTagRequest
+	34,  // 122: library.TagService.UntagBook:input_type -> library.BookTagRequest
+	35,  // 123: library.ReviewService.AddReview:input_type -> library.Review
+	38,  // 124: library.ReviewService.ListReviews:input_type -> library.ListReviewsRequest
+	36,  // 125: library.ReviewService.DeleteReview:input_type -> library.ReviewRequest
+	40,  // 126: library.FavoriteService.AddFavorite:input_type -> library.FavoriteRequest
+	40,  // 127: library.FavoriteService.RemoveFavorite:input_type -> library.FavoriteRequest
+	41,  // 128: library.FavoriteService.ListFavorites:input_type -> library.ListFavoritesRequest
+	42,  // 129: library.ShelfService.CreateShelf:input_type -> library.Shelf
+	45,  // 130: library.ShelfService.ListShelves:input_type -> library.ListShelvesRequest
+	43,  // 131: library.ShelfService.GetShelf:input_type -> library.ShelfRequest
+	47,  // 132: library.ShelfService.AddBookToShelf:input_type -> library.ShelfBookRequest
+	47,  // 133: library.ShelfService.RemoveBookFromShelf:input_type -> library.ShelfBookRequest
+	50,  // 134: library.LoanService.BorrowBook:input_type -> library.BorrowRequest
+	51,  // 135: library.LoanService.ReturnBook:input_type -> library.LoanRequest
+	52,  // 136: library.LoanService.ListMyLoans:input_type -> library.ListLoansRequest
+	55,  // 137: library.LoanService.ReserveBook:input_type -> library.ReserveRequest
+	56,  // 138: library.LoanService.CancelReservation:input_type -> library.ReservationRequest
+	57,  // 139: library.LoanService.ListReservations:input_type -> library.ListReservationsRequest
+	60,  // 140: library.LoanService.ListMyFines:input_type -> library.ListFinesRequest
+	62,  // 141: library.LoanService.PayFine:input_type -> library.PayFineRequest
+	63,  // 142: library.NotificationService.Subscribe:input_type -> library.NotificationRequest
+	75,  // 143: library.PublisherService.CreatePublisher:input_type -> library.Publisher
+	75,  // 144: library.PublisherService.UpdatePublisher:input_type -> library.Publisher
+	76,  // 145: library.PublisherService.ListPublishers:input_type -> library.ListPublishersRequest
+	78,  // 146: library.PublisherService.ListBooksByPublisher:input_type -> library.PublisherBooksRequest
+	110, // 147: library.ServerInfoService.GetServerInfo:input_type -> library.ServerInfoRequest
+	113, // 148: library.TenantService.CreateTenant:input_type -> library.CreateTenantRequest
+	114, // 149: library.TenantService.ListTenants:input_type -> library.ListTenantsRequest
+	9,   // 150: library.UserService.Register:output_type -> library.AuthResponse
+	9,   // 151: library.UserService.Login:output_type -> library.AuthResponse
+	9,   // 152: library.UserService.LoginWithIdToken:output_type -> library.AuthResponse
+	9,   // 153: library.UserService.RefreshToken:output_type -> library.AuthResponse
+	85,  // 154: library.UserService.Logout:output_type -> library.LogoutResponse
+	85,  // 155: library.UserService.RevokeAllSessions:output_type -> library.LogoutResponse
+	97,  // 156: library.UserService.ListSessions:output_type -> library.ListSessionsResponse
+	85,  // 157: library.UserService.RevokeSession:output_type -> library.LogoutResponse
+	102, // 158: library.UserService.ListAuthEvents:output_type -> library.ListAuthEventsResponse
+	105, // 159: library.UserService.ListAuditLog:output_type -> library.ListAuditLogResponse
+	107, // 160: library.UserService.CreateScopedToken:output_type -> library.ScopedToken
+	88,  // 161: library.UserService.RequestPasswordReset:output_type -> library.PasswordResetResponse
+	88,  // 162: library.UserService.ConfirmPasswordReset:output_type -> library.PasswordResetResponse
+	109, // 163: library.UserService.ChangePassword:output_type -> library.ChangePasswordResponse
+	90,  // 164: library.UserService.VerifyEmail:output_type -> library.VerifyEmailResponse
+	91,  // 165: library.UserService.GetProfile:output_type -> library.Profile
+	91,  // 166: library.UserService.UpdateProfile:output_type -> library.Profile
+	11,  // 167: library.LibraryService.AddBook:output_type -> library.BookResponse
+	11,  // 168: library.LibraryService.UpdateBook:output_type -> library.BookResponse
+	11,  // 169: library.LibraryService.PatchBook:output_type -> library.BookResponse
+	11,  // 170: library.LibraryService.DeleteBook:output_type -> library.BookResponse
+	12,  // 171: library.LibraryService.GetBook:output_type -> library.Book
+	17,  // 172: library.LibraryService.ListBooks:output_type -> library.ListBookResponse
+	17,  // 173: library.LibraryService.SearchBooks:output_type -> library.ListBookResponse
+	18,  // 174: library.LibraryService.BatchAddBooks:output_type -> library.BatchResponse
+	18,  // 175: library.LibraryService.BulkUpdateBooks:output_type -> library.BatchResponse
+	21,  // 176: library.LibraryService.GetBookHistory:output_type -> library.BookRevision
+	23,  // 177: library.LibraryService.ExportBooks:output_type -> library.ExportChunk
+	26,  // 178: library.LibraryService.ImportBooks:output_type -> library.ImportResponse
+	28,  // 179: library.LibraryService.UploadCover:output_type -> library.CoverResponse
+	27,  // 180: library.LibraryService.DownloadCover:output_type -> library.CoverChunk
+	12,  // 181: library.LibraryService.LookupByISBN:output_type -> library.Book
+	66,  // 182: library.LibraryService.SetBookTranslation:output_type -> library.BookTranslation
+	11,  // 183: library.LibraryService.DeleteBookTranslation:output_type -> library.BookResponse
+	68,  // 184: library.LibraryService.ListBookTranslations:output_type -> library.ListBookTranslationsResponse
+	69,  // 185: library.LibraryService.FindBookLocation:output_type -> library.BookLocation
+	70,  // 186: library.LibraryService.AddCopy:output_type -> library.BookCopy
+	72,  // 187: library.LibraryService.ListCopies:output_type -> library.ListCopiesResponse
+	81,  // 188: library.LibraryService.ListAcquisitions:output_type -> library.ListAcquisitionsResponse
+	12,  // 189: library.LibraryService.GetBookByBarcode:output_type -> library.Book
+	74,  // 190: library.LibraryService.GetBarcodeImage:output_type -> library.BarcodeImage
+	17,  // 191: library.LibraryService.GetRelatedBooks:output_type -> library.ListBookResponse
+	17,  // 192: library.LibraryService.ListBooksInSeries:output_type -> library.ListBookResponse
+	17,  // 193: library.LibraryService.GetRecommendations:output_type -> library.ListBookResponse
+	31,  // 194: library.TagService.CreateTag:output_type -> library.TagResponse
+	33,  // 195: library.TagService.ListTags:output_type -> library.ListTagsResponse
+	11,  // 196: library.TagService.TagBook:output_type -> library.BookResponse
+	11,  // 197: library.TagService.UntagBook:output_type -> library.BookResponse
+	37,  // 198: library.ReviewService.AddReview:output_type -> library.ReviewResponse
+	39,  // 199: library.ReviewService.ListReviews:output_type -> library.ListReviewsResponse
+	37,  // 200: library.ReviewService.DeleteReview:output_type -> library.ReviewResponse
+	11,  // 201: library.FavoriteService.AddFavorite:output_type -> library.BookResponse
+	11,  // 202: library.FavoriteService.RemoveFavorite:output_type -> library.BookResponse
+	17,  // 203: library.FavoriteService.ListFavorites:output_type -> library.ListBookResponse
+	44,  // 204: library.ShelfService.CreateShelf:output_type -> library.ShelfResponse
+	46,  // 205: library.ShelfService.ListShelves:output_type -> library.ListShelvesResponse
+	42,  // 206: library.ShelfService.GetShelf:output_type -> library.Shelf
+	44,  // 207: library.ShelfService.AddBookToShelf:output_type -> library.ShelfResponse
+	44,  // 208: library.ShelfService.RemoveBookFromShelf:output_type -> library.ShelfResponse
+	49,  // 209: library.LoanService.BorrowBook:output_type -> library.Loan
+	49,  // 210: library.LoanService.ReturnBook:output_type -> library.Loan
+	53,  // 211: library.LoanService.ListMyLoans:output_type -> library.ListLoansResponse
+	54,  // 212: library.LoanService.ReserveBook:output_type -> library.Reservation
+	54,  // 213: library.LoanService.CancelReservation:output_type -> library.Reservation
+	58,  // 214: library.LoanService.ListReservations:output_type -> library.ListReservationsResponse
+	61,  // 215: library.LoanService.ListMyFines:output_type -> library.ListFinesResponse
+	59,  // 216: library.LoanService.PayFine:output_type -> library.Fine
+	64,  // 217: library.NotificationService.Subscribe:output_type -> library.Notification
+	75,  // 218: library.PublisherService.CreatePublisher:output_type -> library.Publisher
+	75,  // 219: library.PublisherService.UpdatePublisher:output_type -> library.Publisher
+	77,  // 220: library.PublisherService.ListPublishers:output_type -> library.ListPublishersResponse
+	17,  // 221: library.PublisherService.ListBooksByPublisher:output_type -> library.ListBookResponse
+	111, // 222: library.ServerInfoService.GetServerInfo:output_type -> library.ServerInfo
+	112, // 223: library.TenantService.CreateTenant:output_type -> library.Tenant
+	115, // 224: library.TenantService.ListTenants:output_type -> library.ListTenantsResponse
+	150, // [150:225] is the sub-list for method output_type
+	75,  // [75:150] is the sub-list for method input_type
+	75,  // [75:75] is the sub-list for extension type_name
+	75,  // [75:75] is the sub-list for extension extendee
+	0,   // [0:75] is the sub-list for field type_name
 }
 
 func init() { file_library_proto_init() }
@@ -584,13 +8211,14 @@ func file_library_proto_init() {
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_library_proto_rawDesc), len(file_library_proto_rawDesc)),
-			NumEnums:      0,
-			NumMessages:   9,
+			NumEnums:      7,
+			NumMessages:   109,
 			NumExtensions: 0,
-			NumServices:   2,
+			NumServices:   11,
 		},
 		GoTypes:           file_library_proto_goTypes,
 		DependencyIndexes: file_library_proto_depIdxs,
+		EnumInfos:         file_library_proto_enumTypes,
 		MessageInfos:      file_library_proto_msgTypes,
 	}.Build()
 	File_library_proto = out.File
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Values of users.state
+const (
+	accountActive = "active"
+	// accountPending accounts were registered while REQUIRE_VERIFIED_EMAIL was set and have not verified
+	// their email address yet; they cannot log in and no token of theirs is accepted
+	accountPending = "pending"
+)
+
+// errAccountPending is returned when a pending account tries to log in or its token is presented
+var errAccountPending = errors.New("account is pending email verification")
+
+// registrationState is the state Register creates accounts in
+func (s *server) registrationState() string {
+	if s.runtime().requireEmail {
+		return accountPending
+	}
+	return accountActive
+}
+
+// checkAccountState returns an error unless the account may use the service
+func checkAccountState(state string) error {
+	switch state {
+	case accountActive:
+		return nil
+	case accountPending:
+		return errAccountPending
+	default:
+		return fmt.Errorf("unknown account state %q", state)
+	}
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestRegistrationState(t *testing.T) {
+	if got := (&server{}).registrationState(); got != accountActive {
+		t.Errorf("without REQUIRE_VERIFIED_EMAIL: state = %q, want %q", got, accountActive)
+	}
+	if got := (&server{settings: newSettings(&runtimeSettings{requireEmail: true})}).registrationState(); got != accountPending {
+		t.Errorf("with REQUIRE_VERIFIED_EMAIL: state = %q, want %q", got, accountPending)
+	}
+}
+
+func TestCheckAccountState(t *testing.T) {
+	if err := checkAccountState(accountActive); err != nil {
+		t.Errorf("active account: error = %v", err)
+	}
+	if err := checkAccountState(accountPending); !errors.Is(err, errAccountPending) {
+		t.Errorf("pending account: error = %v, want %v", err, errAccountPending)
+	}
+	// A state written by a newer version must not be mistaken for an active account
+	if err := checkAccountState("suspended"); err == nil {
+		t.Error("unknown state: expected an error")
+	}
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	pb "example/grpc_demo/library"
+	"example/grpc_demo/server/storage"
+
+	"github.com/jackc/pgx/v5"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// maxDonorLength bounds donor names recorded on copies
+const maxDonorLength = 200
+
+// copyConditionNames maps conditions to the values stored in book_copies.condition;
+// an unrecorded condition is stored as an empty string
+var copyConditionNames = map[pb.CopyCondition]string{
+	pb.CopyCondition_COPY_CONDITION_NEW:     "new",
+	pb.CopyCondition_COPY_CONDITION_GOOD:    "good",
+	pb.CopyCondition_COPY_CONDITION_FAIR:    "fair",
+	pb.CopyCondition_COPY_CONDITION_POOR:    "poor",
+	pb.CopyCondition_COPY_CONDITION_DAMAGED: "damaged",
+}
+
+// parseCopyCondition converts a stored condition back into its enum value
+func parseCopyCondition(name string) pb.CopyCondition {
+	for c, n := range copyConditionNames {
+		if n == name {
+			return c
+		}
+	}
+	return pb.CopyCondition_COPY_CONDITION_UNSPECIFIED
+}
+
+// copyColumns is the column list read by scanCopy; copies are aliased c
+const copyColumns = "c.id, c.book_id, c.barcode, c.created_at, c.condition, c.price_cents, c.acquired_at, c.donor"
+
+// scanCopy reads a copy selected with copyColumns, followed by any extra destinations
+func scanCopy(row pgx.Row, extra ...any) (*pb.BookCopy, error) {
+	c := &pb.BookCopy{}
+	var condition string
+	var createdAt, acquiredAt time.Time
+	dest := append([]any{&c.Id, &c.BookId, &c.Barcode, &createdAt, &condition, &c.PriceCents, &acquiredAt, &c.Donor}, extra...)
+	if err := row.Scan(dest...); err != nil {
+		return nil, err
+	}
+	c.CreatedAt = timestamppb.New(createdAt)
+	c.Condition = parseCopyCondition(condition)
+	c.AcquiredAt = timestamppb.New(acquiredAt)
+	return c, nil
+}
+
+// validateAcquisition checks the acquisition details of a new copy and returns when it was acquired
+func validateAcquisition(req *pb.CopyRequest, now time.Time) (time.Time, error) {
+	if _, ok := copyConditionNames[req.GetCondition()]; !ok && req.GetCondition() != pb.CopyCondition_COPY_CONDITION_UNSPECIFIED {
+		return time.Time{}, status.Errorf(codes.InvalidArgument, "unknown condition %v", req.GetCondition())
+	}
+	if req.GetPriceCents() < 0 {
+		return time.Time{}, status.Error(codes.InvalidArgument, "price cannot be negative")
+	}
+	if len(strings.TrimSpace(req.GetDonor())) > maxDonorLength {
+		return time.Time{}, status.Error(codes.InvalidArgument, "donor name is too long")
+	}
+	if req.GetAcquiredAt() == nil {
+		return now, nil
+	}
+	if err := req.GetAcquiredAt().CheckValid(); err != nil {
+		return time.Time{}, status.Errorf(codes.InvalidArgument, "invalid acquired_at: %v", err)
+	}
+	acquiredAt := req.GetAcquiredAt().AsTime()
+	if acquiredAt.After(now) {
+		return time.Time{}, status.Error(codes.InvalidArgument, "acquired_at cannot be in the future")
+	}
+	return acquiredAt, nil
+}
+
+func (s *server) ListAcquisitions(ctx context.Context, req *pb.ListAcquisitionsRequest) (*pb.ListAcquisitionsResponse, error) {
+	from, to := req.GetAcquiredFrom(), req.GetAcquiredTo()
+	if from != nil && to != nil && !from.AsTime().Before(to.AsTime()) {
+		return nil, status.Error(codes.InvalidArgument, "acquired_from must be before acquired_to")
+	}
+
+	page, err := pageOf(req, fmt.Sprintf("acquisitions %d %d %d %t",
+		from.AsTime().UnixNano(), to.AsTime().UnixNano(), req.GetCondition(), req.GetDonatedOnly()), time.Time{}, int64(0))
+	if err != nil {
+		return nil, err
+	}
+
+	var filter sqlFilter
+	if from != nil {
+		filter.where("c.acquired_at >= " + filter.arg(from.AsTime()))
+	}
+	if to != nil {
+		filter.where("c.acquired_at < " + filter.arg(to.AsTime()))
+	}
+	if cond := req.GetCondition(); cond != pb.CopyCondition_COPY_CONDITION_UNSPECIFIED {
+		name, ok := copyConditionNames[cond]
+		if !ok {
+			return nil, status.Errorf(codes.InvalidArgument, "unknown condition %v", cond)
+		}
+		filter.where("c.condition = " + filter.arg(name))
+	}
+	if req.GetDonatedOnly() {
+		filter.where("c.donor <> ''")
+	}
+	where := filter.clause()
+
+	resp := &pb.ListAcquisitionsResponse{}
+	err = s.db.QueryRow(ctx,
+		"SELECT COUNT(*), COALESCE(SUM(c.price_cents), 0), COUNT(*) FILTER (WHERE c.donor <> '') FROM book_copies c"+where,
+		filter.args...).Scan(&resp.TotalCount, &resp.TotalPriceCents, &resp.DonatedCount)
+	if err != nil {
+		return nil, err
+	}
+
+	order := storage.Keyset{Columns: []string{"c.acquired_at", "c.id"}, Desc: true}
+	page.where(&filter, order)
+	query := "SELECT " + copyColumns + ", b.title, b.author FROM book_copies c JOIN books b ON b.id = c.book_id" + filter.clause() +
+		order.OrderBy() + page.limitOffset(&filter)
+	rows, err := s.db.Query(ctx, query, filter.args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		a := &pb.Acquisition{}
+		if a.Copy, err = scanCopy(rows, &a.Title, &a.Author); err != nil {
+			return nil, err
+		}
+		resp.Acquisitions = append(resp.Acquisitions, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	resp.Acquisitions, resp.NextPageToken = nextPage(page, resp.Acquisitions, func(a *pb.Acquisition) []any {
+		return []any{a.GetCopy().GetAcquiredAt().AsTime(), a.GetCopy().GetId()}
+	})
+	return resp, nil
+}
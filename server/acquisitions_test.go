@@ -0,0 +1,55 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	pb "example/grpc_demo/library"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestCopyConditionRoundTrip(t *testing.T) {
+	for cond, name := range copyConditionNames {
+		if got := parseCopyCondition(name); got != cond {
+			t.Errorf("parseCopyCondition(%q) = %v, want %v", name, got, cond)
+		}
+	}
+	if got := parseCopyCondition(""); got != pb.CopyCondition_COPY_CONDITION_UNSPECIFIED {
+		t.Errorf("parseCopyCondition(\"\") = %v, want unspecified", got)
+	}
+}
+
+func TestValidateAcquisition(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	yesterday := now.Add(-24 * time.Hour)
+
+	tests := []struct {
+		name     string
+		req      *pb.CopyRequest
+		want     time.Time
+		wantCode codes.Code
+	}{
+		{"defaults to now", &pb.CopyRequest{}, now, codes.OK},
+		{"purchase", &pb.CopyRequest{Condition: pb.CopyCondition_COPY_CONDITION_NEW, PriceCents: 1999, AcquiredAt: timestamppb.New(yesterday)}, yesterday, codes.OK},
+		{"donation", &pb.CopyRequest{Condition: pb.CopyCondition_COPY_CONDITION_FAIR, Donor: "Friends of the Library"}, now, codes.OK},
+		{"negative price", &pb.CopyRequest{PriceCents: -1}, time.Time{}, codes.InvalidArgument},
+		{"future date", &pb.CopyRequest{AcquiredAt: timestamppb.New(now.Add(time.Hour))}, time.Time{}, codes.InvalidArgument},
+		{"unknown condition", &pb.CopyRequest{Condition: pb.CopyCondition(99)}, time.Time{}, codes.InvalidArgument},
+		{"long donor", &pb.CopyRequest{Donor: strings.Repeat("x", maxDonorLength+1)}, time.Time{}, codes.InvalidArgument},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := validateAcquisition(tt.req, now)
+			if code := status.Code(err); code != tt.wantCode {
+				t.Fatalf("validateAcquisition() code = %v, want %v (err %v)", code, tt.wantCode, err)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("validateAcquisition() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+)
+
+// Resource types named in the ResourceInfo details of NotFound and AlreadyExists errors
+const (
+	resourceBook        = "library.Book"
+	resourceReview      = "library.Review"
+	resourceShelf       = "library.Shelf"
+	resourceTag         = "library.Tag"
+	resourcePublisher   = "library.Publisher"
+	resourceTranslation = "library.BookTranslation"
+	resourceFavorite    = "library.Favorite"
+	resourceShelfBook   = "library.ShelfBook"
+	resourceBookTag     = "library.BookTag"
+	resourceTenant      = "library.Tenant"
+	resourceUser        = "library.User"
+	resourceSession     = "library.Session"
+	resourceLoan        = "library.Loan"
+	resourceReservation = "library.Reservation"
+	resourceFine        = "library.Fine"
+	resourceSeries      = "library.Series"
+	resourceCover       = "library.BookCover"
+)
+
+// badRequest is the InvalidArgument status for an invalid request field, with a BadRequest detail naming
+// the field (e.g. "isbn" or "series.volume") so clients can show the message next to it
+func badRequest(field, description string) error {
+	return withDetails(status.New(codes.InvalidArgument, description), &errdetails.BadRequest{
+		FieldViolations: []*errdetails.BadRequest_FieldViolation{{Field: field, Description: description}},
+	})
+}
+
+// notFound is the NotFound status for a resource that doesn't exist, with a ResourceInfo detail naming it
+func notFound(resourceType, name, message string) error {
+	return withDetails(status.New(codes.NotFound, message), &errdetails.ResourceInfo{ResourceType: resourceType, ResourceName: name})
+}
+
+// alreadyExists is the AlreadyExists status for a resource created twice, with a ResourceInfo detail naming it
+func alreadyExists(resourceType, name, message string) error {
+	return withDetails(status.New(codes.AlreadyExists, message), &errdetails.ResourceInfo{ResourceType: resourceType, ResourceName: name})
+}
+
+// withDetails attaches details to st, falling back to st alone if they can't be encoded
+func withDetails(st *status.Status, details ...protoadapt.MessageV1) error {
+	detailed, err := st.WithDetails(details...)
+	if err != nil {
+		return st.Err()
+	}
+	return detailed.Err()
+}
+
+// canonicalError gives errors returned without a status, such as a query error passed straight up, a
+// canonical code instead of Unknown: the context's code when the call timed out or was cancelled,
+// Internal otherwise
+func canonicalError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, err.Error())
+	}
+	return status.Errorf(codes.Internal, "internal error: %v", err)
+}
+
+// CreateErrorInterceptor applies canonicalError to the errors of unary calls
+func CreateErrorInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		resp, err := handler(ctx, req)
+		return resp, canonicalError(err)
+	}
+}
+
+// CreateStreamErrorInterceptor applies canonicalError to the errors of streaming calls
+func CreateStreamErrorInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return canonicalError(handler(srv, ss))
+	}
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestBadRequest(t *testing.T) {
+	st := status.Convert(badRequest("isbn", "Invalid ISBN"))
+	if st.Code() != codes.InvalidArgument || st.Message() != "Invalid ISBN" {
+		t.Fatalf("status = %v", st)
+	}
+	details := st.Details()
+	if len(details) != 1 {
+		t.Fatalf("details = %v, want one BadRequest", details)
+	}
+	br, ok := details[0].(*errdetails.BadRequest)
+	if !ok || len(br.GetFieldViolations()) != 1 || br.GetFieldViolations()[0].GetField() != "isbn" {
+		t.Errorf("detail = %v, want a violation of isbn", details[0])
+	}
+}
+
+// checkResourceError fails the test unless err has code and a ResourceInfo detail naming the resource
+func checkResourceError(t *testing.T, err error, code codes.Code, resourceType, name string) {
+	t.Helper()
+	st := status.Convert(err)
+	if st.Code() != code {
+		t.Errorf("code = %v, want %v", st.Code(), code)
+		return
+	}
+	for _, detail := range st.Details() {
+		if info, ok := detail.(*errdetails.ResourceInfo); ok && info.GetResourceType() == resourceType && info.GetResourceName() == name {
+			return
+		}
+	}
+	t.Errorf("%v: details = %v, want ResourceInfo for %s %s", code, st.Details(), resourceType, name)
+}
+
+func TestResourceErrors(t *testing.T) {
+	checkResourceError(t, notFound(resourceBook, "book1", "Book not found"), codes.NotFound, resourceBook, "book1")
+	checkResourceError(t, alreadyExists(resourceBook, "book1", "Book already exists"), codes.AlreadyExists, resourceBook, "book1")
+}
+
+func TestCanonicalError(t *testing.T) {
+	if canonicalError(nil) != nil {
+		t.Error("canonicalError(nil) != nil")
+	}
+	notFoundErr := notFound(resourceBook, "book1", "Book not found")
+	if got := canonicalError(notFoundErr); got != notFoundErr {
+		t.Errorf("canonicalError() changed a status error: %v", got)
+	}
+	for err, want := range map[error]codes.Code{
+		errors.New("connection reset"):                    codes.Internal,
+		fmt.Errorf("query: %w", context.DeadlineExceeded): codes.DeadlineExceeded,
+		fmt.Errorf("acquire: %w", context.Canceled):       codes.Canceled,
+	} {
+		if got := status.Code(canonicalError(err)); got != want {
+			t.Errorf("canonicalError(%v) code = %v, want %v", err, got, want)
+		}
+	}
+}
@@ -0,0 +1,344 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	pb "example/grpc_demo/library"
+	"example/grpc_demo/server/storage"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	// auditBufferSize is how many entries wait for the writer before new ones are dropped
+	auditBufferSize = 1024
+	// auditBatchSize bounds the entries written in one COPY
+	auditBatchSize = 100
+	// maxAuditSummaryLength bounds the request summary stored, in bytes
+	maxAuditSummaryLength = 500
+)
+
+// auditedMethods are the methods that change data. Logins, registrations and token refreshes are
+// recorded in auth_events instead.
+var auditedMethods = map[string]bool{
+	pb.UserService_Logout_FullMethodName:                   true,
+	pb.UserService_RevokeAllSessions_FullMethodName:        true,
+	pb.UserService_RevokeSession_FullMethodName:            true,
+	pb.UserService_CreateScopedToken_FullMethodName:        true,
+	pb.UserService_ChangePassword_FullMethodName:           true,
+	pb.UserService_UpdateProfile_FullMethodName:            true,
+	pb.LibraryService_AddBook_FullMethodName:               true,
+	pb.LibraryService_UpdateBook_FullMethodName:            true,
+	pb.LibraryService_PatchBook_FullMethodName:             true,
+	pb.LibraryService_DeleteBook_FullMethodName:            true,
+	pb.LibraryService_BatchAddBooks_FullMethodName:         true,
+	pb.LibraryService_BulkUpdateBooks_FullMethodName:       true,
+	pb.LibraryService_ImportBooks_FullMethodName:           true,
+	pb.LibraryService_UploadCover_FullMethodName:           true,
+	pb.LibraryService_SetBookTranslation_FullMethodName:    true,
+	pb.LibraryService_DeleteBookTranslation_FullMethodName: true,
+	pb.LibraryService_AddCopy_FullMethodName:               true,
+	pb.TagService_CreateTag_FullMethodName:                 true,
+	pb.TagService_TagBook_FullMethodName:                   true,
+	pb.TagService_UntagBook_FullMethodName:                 true,
+	pb.ReviewService_AddReview_FullMethodName:              true,
+	pb.ReviewService_DeleteReview_FullMethodName:           true,
+	pb.FavoriteService_AddFavorite_FullMethodName:          true,
+	pb.FavoriteService_RemoveFavorite_FullMethodName:       true,
+	pb.ShelfService_CreateShelf_FullMethodName:             true,
+	pb.ShelfService_AddBookToShelf_FullMethodName:          true,
+	pb.ShelfService_RemoveBookFromShelf_FullMethodName:     true,
+	pb.LoanService_BorrowBook_FullMethodName:               true,
+	pb.LoanService_ReturnBook_FullMethodName:               true,
+	pb.LoanService_ReserveBook_FullMethodName:              true,
+	pb.LoanService_CancelReservation_FullMethodName:        true,
+	pb.LoanService_PayFine_FullMethodName:                  true,
+	pb.PublisherService_CreatePublisher_FullMethodName:     true,
+	pb.PublisherService_UpdatePublisher_FullMethodName:     true,
+	pb.TenantService_CreateTenant_FullMethodName:           true,
+}
+
+// auditEntry is a row of audit_log
+type auditEntry struct {
+	// tenant is the library whose audit_log the entry goes to
+	tenant     string
+	method     string
+	userID     int
+	username   string
+	resourceID string
+	summary    string
+	at         time.Time
+}
+
+// auditLog writes entries to the audit_log table in the background, so that calls do not wait for it
+type auditLog struct {
+	db      *pgxpool.Pool
+	entries chan auditEntry
+}
+
+func newAuditLog(db *pgxpool.Pool) *auditLog {
+	return &auditLog{db: db, entries: make(chan auditEntry, auditBufferSize)}
+}
+
+// record queues the entry of a successful call by the caller of ctx; it is dropped with a warning when
+// the writer is too far behind
+func (a *auditLog) record(ctx context.Context, method, resourceID, summary string) {
+	userID, username, _ := userFromContext(ctx)
+	entry := auditEntry{tenant: tenantFromContext(ctx), method: method, userID: userID, username: username, resourceID: resourceID, summary: summary, at: time.Now()}
+	select {
+	case a.entries <- entry:
+	default:
+		slog.WarnContext(ctx, "audit log buffer full; entry dropped", "method", method, "user_id", userID)
+	}
+}
+
+// run writes queued entries in batches until ctx is cancelled
+func (a *auditLog) run(ctx context.Context) {
+	for {
+		var batch []auditEntry
+		select {
+		case <-ctx.Done():
+			return
+		case entry := <-a.entries:
+			batch = append(batch, entry)
+		}
+		for len(batch) < auditBatchSize && len(a.entries) > 0 {
+			batch = append(batch, <-a.entries)
+		}
+		if err := a.write(ctx, batch); err != nil {
+			slog.ErrorContext(ctx, "failed to write audit log", "entries", len(batch), "error", err)
+		}
+	}
+}
+
+// write copies batch into the audit_log of the library of each entry
+func (a *auditLog) write(ctx context.Context, batch []auditEntry) error {
+	rows := make(map[string][][]any)
+	for _, e := range batch {
+		var userID any
+		if e.userID != 0 {
+			userID = e.userID
+		}
+		rows[e.tenant] = append(rows[e.tenant], []any{userID, e.username, e.method, e.resourceID, e.summary, e.at})
+	}
+	for tenant, tenantRows := range rows {
+		_, err := a.db.CopyFrom(withTenant(ctx, tenant), pgx.Identifier{"audit_log"},
+			[]string{"user_id", "username", "method", "resource_id", "request_summary", "created_at"},
+			pgx.CopyFromRows(tenantRows))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// auditResourceID is the first ID set in msg: its id field, or else the first field named *_id
+func auditResourceID(msg proto.Message) string {
+	if msg == nil {
+		return ""
+	}
+	m := msg.ProtoReflect()
+	fields := m.Descriptor().Fields()
+	if id := auditIDValue(m, fields.ByName("id")); id != "" {
+		return id
+	}
+	for i := 0; i < fields.Len(); i++ {
+		if fd := fields.Get(i); strings.HasSuffix(string(fd.Name()), "_id") {
+			if id := auditIDValue(m, fd); id != "" {
+				return id
+			}
+		}
+	}
+	return ""
+}
+
+// auditIDValue formats the string or integer field fd of m, empty when unset
+func auditIDValue(m protoreflect.Message, fd protoreflect.FieldDescriptor) string {
+	if fd == nil || fd.IsList() || fd.IsMap() || !m.Has(fd) {
+		return ""
+	}
+	switch fd.Kind() {
+	case protoreflect.StringKind:
+		return m.Get(fd).String()
+	case protoreflect.Int32Kind, protoreflect.Int64Kind, protoreflect.Sint32Kind, protoreflect.Sint64Kind:
+		return strconv.FormatInt(m.Get(fd).Int(), 10)
+	case protoreflect.Uint32Kind, protoreflect.Uint64Kind:
+		return strconv.FormatUint(m.Get(fd).Uint(), 10)
+	}
+	return ""
+}
+
+// auditSummary is req as JSON without secrets or binary data, truncated to maxAuditSummaryLength
+func auditSummary(req proto.Message) string {
+	if req == nil {
+		return ""
+	}
+	clean := proto.Clone(req)
+	redactAudited(clean.ProtoReflect())
+	data, err := protojson.Marshal(clean)
+	if err != nil {
+		return ""
+	}
+	return truncateUTF8(string(data), maxAuditSummaryLength)
+}
+
+// redactAudited clears the bytes fields of m and those whose name mentions a password, token or secret,
+// in nested messages too
+func redactAudited(m protoreflect.Message) {
+	m.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		name := string(fd.Name())
+		switch {
+		case fd.Kind() == protoreflect.BytesKind, strings.Contains(name, "password"),
+			strings.Contains(name, "token"), strings.Contains(name, "secret"):
+			m.Clear(fd)
+		case fd.Kind() == protoreflect.MessageKind && fd.IsList():
+			list := v.List()
+			for i := 0; i < list.Len(); i++ {
+				redactAudited(list.Get(i).Message())
+			}
+		case fd.Kind() == protoreflect.MessageKind && !fd.IsMap():
+			redactAudited(v.Message())
+		}
+		return true
+	})
+}
+
+// truncateUTF8 cuts s to at most n bytes without splitting a character
+func truncateUTF8(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
+// CreateAuditInterceptor records successful calls of the auditedMethods. It comes after the idempotency
+// interceptor, so replayed retries are not recorded twice.
+func CreateAuditInterceptor(audit *auditLog) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		resp, err := handler(ctx, req)
+		if err != nil || !auditedMethods[info.FullMethod] {
+			return resp, err
+		}
+		reqMsg, _ := req.(proto.Message)
+		resourceID := auditResourceID(reqMsg)
+		if resourceID == "" {
+			respMsg, _ := resp.(proto.Message)
+			resourceID = auditResourceID(respMsg)
+		}
+		audit.record(ctx, info.FullMethod, resourceID, auditSummary(reqMsg))
+		return resp, err
+	}
+}
+
+// CreateStreamAuditInterceptor records successful calls of the client-streaming auditedMethods. Their
+// summary is the number of messages received; the resource is taken from the response, as a stream
+// may touch many.
+func CreateStreamAuditInterceptor(audit *auditLog) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if !auditedMethods[info.FullMethod] || !info.IsClientStream {
+			return handler(srv, ss)
+		}
+		stream := &auditedServerStream{ServerStream: ss}
+		if err := handler(srv, stream); err != nil {
+			return err
+		}
+		audit.record(ss.Context(), info.FullMethod, auditResourceID(stream.response), fmt.Sprintf("%d messages", stream.received))
+		return nil
+	}
+}
+
+// auditedServerStream counts the requests received and keeps the response sent
+type auditedServerStream struct {
+	grpc.ServerStream
+	received int
+	response proto.Message
+}
+
+func (s *auditedServerStream) RecvMsg(m interface{}) error {
+	err := s.ServerStream.RecvMsg(m)
+	if err == nil {
+		s.received++
+	}
+	return err
+}
+
+func (s *auditedServerStream) SendMsg(m interface{}) error {
+	if msg, ok := m.(proto.Message); ok {
+		s.response = msg
+	}
+	return s.ServerStream.SendMsg(m)
+}
+
+func (s *server) ListAuditLog(ctx context.Context, req *pb.ListAuditLogRequest) (*pb.ListAuditLogResponse, error) {
+	if _, _, ok := userFromContext(ctx); !ok {
+		return nil, status.Error(codes.Unauthenticated, "authentication required")
+	}
+	if role, _ := roleFromContext(ctx); role != adminRole {
+		return nil, status.Error(codes.PermissionDenied, "only admins can list the audit log")
+	}
+
+	page, err := pageOf(req, fmt.Sprintf("audit log %d %q %q", req.GetUserId(), req.GetMethod(), req.GetResourceId()), time.Time{}, int64(0))
+	if err != nil {
+		return nil, err
+	}
+
+	var filter sqlFilter
+	if req.GetUserId() != 0 {
+		filter.where("user_id = " + filter.arg(req.GetUserId()))
+	}
+	if req.GetMethod() != "" {
+		filter.where("method = " + filter.arg(req.GetMethod()))
+	}
+	if req.GetResourceId() != "" {
+		filter.where("resource_id = " + filter.arg(req.GetResourceId()))
+	}
+
+	var total int32
+	if err := s.db.QueryRow(ctx, "SELECT COUNT(*) FROM audit_log"+filter.clause(), filter.args...).Scan(&total); err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to count audit log entries: %v", err)
+	}
+
+	order := storage.Keyset{Columns: []string{"created_at", "id"}, Desc: true}
+	page.where(&filter, order)
+	rows, err := s.db.Query(ctx,
+		"SELECT id, method, COALESCE(user_id, 0), username, resource_id, request_summary, created_at FROM audit_log"+
+			filter.clause()+order.OrderBy()+page.limitOffset(&filter),
+		filter.args...)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to list audit log: %v", err)
+	}
+	defer rows.Close()
+
+	resp := &pb.ListAuditLogResponse{TotalCount: total}
+	for rows.Next() {
+		var e pb.AuditLogEntry
+		var createdAt time.Time
+		if err := rows.Scan(&e.Id, &e.Method, &e.UserId, &e.Username, &e.ResourceId, &e.RequestSummary, &createdAt); err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to read audit log entry: %v", err)
+		}
+		e.CreatedAt = timestamppb.New(createdAt)
+		resp.Entries = append(resp.Entries, &e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to list audit log: %v", err)
+	}
+	resp.Entries, resp.NextPageToken = nextPage(page, resp.Entries, func(e *pb.AuditLogEntry) []any { return []any{e.GetCreatedAt().AsTime(), e.GetId()} })
+	return resp, nil
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	pb "example/grpc_demo/library"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestAuditInterceptor(t *testing.T) {
+	audit := &auditLog{entries: make(chan auditEntry, 10)}
+	interceptor := CreateAuditInterceptor(audit)
+	ctx := context.WithValue(context.WithValue(context.Background(), userIDKey, 7), usernameKey, "alice")
+	ok := func(ctx context.Context, req interface{}) (interface{}, error) { return &pb.ShelfResponse{Id: 42}, nil }
+
+	if _, err := interceptor(ctx, &pb.Book{Id: "b1", Title: "Go"}, &grpc.UnaryServerInfo{FullMethod: pb.LibraryService_AddBook_FullMethodName}, ok); err != nil {
+		t.Fatal(err)
+	}
+	// Reads and failures are not recorded
+	interceptor(ctx, &pb.BookRequest{Id: "b1"}, &grpc.UnaryServerInfo{FullMethod: pb.LibraryService_GetBook_FullMethodName}, ok)
+	interceptor(ctx, &pb.BookRequest{Id: "b1"}, &grpc.UnaryServerInfo{FullMethod: pb.LibraryService_DeleteBook_FullMethodName},
+		func(context.Context, interface{}) (interface{}, error) {
+			return nil, status.Error(codes.NotFound, "Book not found")
+		})
+	// Without an ID in the request, the response's is used
+	interceptor(ctx, &pb.Shelf{Name: "To read"}, &grpc.UnaryServerInfo{FullMethod: pb.ShelfService_CreateShelf_FullMethodName}, ok)
+
+	if len(audit.entries) != 2 {
+		t.Fatalf("recorded %d entries, want 2", len(audit.entries))
+	}
+	added := <-audit.entries
+	if added.method != pb.LibraryService_AddBook_FullMethodName || added.userID != 7 || added.username != "alice" ||
+		added.resourceID != "b1" || !strings.Contains(added.summary, `"title":"Go"`) || added.at.IsZero() {
+		t.Errorf("AddBook entry = %+v", added)
+	}
+	if shelf := <-audit.entries; shelf.resourceID != "42" {
+		t.Errorf("CreateShelf resource ID = %q, want the new shelf's 42", shelf.resourceID)
+	}
+}
+
+func TestAuditBufferFull(t *testing.T) {
+	audit := &auditLog{entries: make(chan auditEntry, 1)}
+	audit.record(context.Background(), pb.LibraryService_AddBook_FullMethodName, "b1", "")
+	// A full buffer drops the entry instead of blocking the call
+	audit.record(context.Background(), pb.LibraryService_AddBook_FullMethodName, "b2", "")
+	if e := <-audit.entries; e.resourceID != "b1" || len(audit.entries) != 0 {
+		t.Errorf("entry = %+v with %d queued, want only b1", e, len(audit.entries))
+	}
+}
+
+func TestAuditSummary(t *testing.T) {
+	summary := auditSummary(&pb.ChangePasswordRequest{CurrentPassword: "hunter2", NewPassword: "correct horse"})
+	if strings.Contains(summary, "hunter2") || strings.Contains(summary, "horse") {
+		t.Errorf("summary = %s, want the passwords left out", summary)
+	}
+	if summary := auditSummary(&pb.CoverChunk{BookId: "b1", Data: []byte("image")}); summary != `{"bookId":"b1"}` {
+		t.Errorf("summary = %s, want the data left out", summary)
+	}
+	long := auditSummary(&pb.Book{Id: "b1", Description: strings.Repeat("é", maxAuditSummaryLength)})
+	if len(long) > maxAuditSummaryLength || !strings.HasPrefix(long, `{"id":"b1"`) || strings.ContainsRune(long, '�') {
+		t.Errorf("summary of %d bytes = %q", len(long), long)
+	}
+}
+
+func TestAuditResourceID(t *testing.T) {
+	if id := auditResourceID(&pb.ShelfBookRequest{ShelfId: 3, BookId: "b1"}); id != "3" {
+		t.Errorf("ShelfBookRequest resource ID = %q, want 3", id)
+	}
+	if id := auditResourceID(&pb.BookTagRequest{BookId: "b1", Tag: "go"}); id != "b1" {
+		t.Errorf("BookTagRequest resource ID = %q, want b1", id)
+	}
+	if id := auditResourceID(&pb.ChangePasswordRequest{}); id != "" {
+		t.Errorf("ChangePasswordRequest resource ID = %q, want none", id)
+	}
+}
+
+func TestStreamAuditInterceptor(t *testing.T) {
+	audit := &auditLog{entries: make(chan auditEntry, 10)}
+	interceptor := CreateStreamAuditInterceptor(audit)
+	info := &grpc.StreamServerInfo{FullMethod: pb.LibraryService_BatchAddBooks_FullMethodName, IsClientStream: true}
+	handler := func(srv interface{}, ss grpc.ServerStream) error {
+		for {
+			if err := ss.RecvMsg(&pb.Book{}); err != nil {
+				break
+			}
+		}
+		return ss.SendMsg(&pb.BatchResponse{})
+	}
+	stream := &batchStream{ctx: idempotentContext(""), books: []*pb.Book{{Id: "b1"}, {Id: "b2"}}}
+	if err := interceptor(nil, stream, info, handler); err != nil {
+		t.Fatal(err)
+	}
+	if e := <-audit.entries; e.summary != "2 messages" || e.userID != 1 {
+		t.Errorf("entry = %+v, want 2 messages by user 1", e)
+	}
+
+	failing := func(srv interface{}, ss grpc.ServerStream) error { return errors.New("failed") }
+	interceptor(nil, &batchStream{ctx: idempotentContext("")}, info, failing)
+	if len(audit.entries) != 0 {
+		t.Error("a failed stream was recorded")
+	}
+}
@@ -2,12 +2,16 @@ package main
 
 import (
 	"context"
+	"crypto/rand"
 	"errors"
 	"fmt"
-	"os"
+	"log/slog"
 	"strconv"
+	"sync/atomic"
 	"time"
 
+	"example/grpc_demo/server/storage"
+
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"google.golang.org/grpc"
@@ -16,8 +20,41 @@ import (
 	"google.golang.org/grpc/status"
 )
 
-// JWT secret key - in production, this should be loaded from environment variables
-var jwtSecret = []byte(getJWTSecret())
+// jwtSecret signs HS256 tokens; main sets it from the configuration with useAuthConfig
+var jwtSecret = []byte(defaultJWTSecret)
+
+// defaultJWTIssuer is the iss claim of access tokens unless JWT_ISSUER overrides it
+const defaultJWTIssuer = "library-service"
+
+// jwtIssuer and jwtAudience are written into access tokens and required of every token presented,
+// so a token from another environment signed with the same key is rejected. An empty audience is neither set nor checked.
+var jwtIssuer, jwtAudience = defaultJWTIssuer, ""
+
+// useAuthConfig makes tokens signed and checked with the configured secret, issuer and audience, and
+// issued with the configured lifetimes
+func useAuthConfig(cfg AuthConfig) {
+	jwtSecret = []byte(cfg.JWTSecret)
+	jwtIssuer, jwtAudience = cfg.JWTIssuer, cfg.JWTAudience
+	tokenTTLs = tokenLifetimes{access: cfg.AccessTokenTTL, refresh: cfg.RefreshTokenTTL, clockSkew: cfg.JWTClockSkew}
+}
+
+// publicMethods can be called without a token unless AUTH_POLICY_FILE says otherwise
+var publicMethods = map[string]bool{
+	"/library.UserService/Register":         true,
+	"/library.UserService/Login":            true,
+	"/library.UserService/RefreshToken":     true,
+	"/library.UserService/LoginWithIdToken": true,
+
+	"/library.UserService/RequestPasswordReset": true,
+	"/library.UserService/ConfirmPasswordReset": true,
+	"/library.UserService/VerifyEmail":          true,
+
+	// What is deployed, for clients and operators checking a rollout
+	"/library.ServerInfoService/GetServerInfo": true,
+
+	// Probes of load balancers and orchestrators
+	"/grpc.health.v1.Health/*": true,
+}
 
 // Context key types to avoid collisions
 type contextKey string
@@ -25,55 +62,88 @@ type contextKey string
 const (
 	userIDKey   contextKey = "user_id"
 	usernameKey contextKey = "username"
+	claimsKey   contextKey = "claims"
+	serviceKey  contextKey = "service"
+	roleKey     contextKey = "role"
 )
 
 // Claims represents the JWT claims
 type Claims struct {
 	UserID   int    `json:"user_id"`
 	Username string `json:"username"`
+	// SessionID ties the token to the login it came from; tokens issued before sessions existed have none
+	SessionID int64 `json:"sid,omitempty"`
+	// DeviceFingerprint binds the token to the device it was issued to (see deviceFingerprint); empty means unbound
+	DeviceFingerprint string `json:"dfp,omitempty"`
+	// Scopes restricts the token to methods whose policy asks for these scopes; nil means unrestricted
+	Scopes []string `json:"scopes,omitempty"`
+	// TenantID is the library the user belongs to; empty for the default library
+	TenantID string `json:"tid,omitempty"`
 	jwt.RegisteredClaims
 }
 
-// getJWTSecret returns the JWT secret from environment or default
-func getJWTSecret() string {
-	secret := os.Getenv("JWT_SECRET")
-	if secret == "" {
-		secret = "your-super-secure-secret-key-change-this-in-production"
-	}
-	return secret
+// GenerateJWT generates a JWT token for a user's session
+func GenerateJWT(userID int, username string, sessionID int64) (string, error) {
+	return signAccessToken(newAccessClaims(userID, username, sessionID))
 }
 
-// GenerateJWT generates a JWT token for a user
-func GenerateJWT(userID int, username string) (string, error) {
-	expirationTime := time.Now().Add(24 * time.Hour) // Token expires in 24 hours
+// newAccessClaims returns the claims of an access token valid for the configured lifetime from now
+func newAccessClaims(userID int, username string, sessionID int64) *Claims {
+	expirationTime := time.Now().Add(tokenTTLs.access)
 
 	claims := &Claims{
-		UserID:   userID,
-		Username: username,
+		UserID:    userID,
+		Username:  username,
+		SessionID: sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(expirationTime),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			NotBefore: jwt.NewNumericDate(time.Now()),
-			Issuer:    "library-service",
+			Issuer:    jwtIssuer,
 			Subject:   strconv.Itoa(userID),
+			// The token ID lets Logout revoke this token before it expires
+			ID: rand.Text(),
 		},
 	}
+	if jwtAudience != "" {
+		claims.Audience = jwt.ClaimStrings{jwtAudience}
+	}
+	return claims
+}
 
+// signAccessToken signs claims with the configured keys, or the HS256 secret when there are none
+func signAccessToken(claims *Claims) (string, error) {
+	if jwtKeys != nil {
+		return jwtKeys.sign(claims)
+	}
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	return token.SignedString(jwtSecret)
 }
 
-// ValidateJWT validates a JWT token and returns the claims
-func ValidateJWT(tokenString string) (*Claims, error) {
-	claims := &Claims{}
-
-	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+// jwtKeyFunc returns the key verifying the signature of token: one of the configured keys, or the HS256
+// secret when there are none
+func jwtKeyFunc() jwt.Keyfunc {
+	if jwtKeys != nil {
+		return jwtKeys.keyFunc
+	}
+	return func(token *jwt.Token) (interface{}, error) {
 		// Validate the signing method
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 		}
 		return jwtSecret, nil
-	})
+	}
+}
+
+// ValidateJWT validates a JWT token and returns the claims
+func ValidateJWT(tokenString string) (*Claims, error) {
+	claims := &Claims{}
+
+	opts := []jwt.ParserOption{jwt.WithIssuer(jwtIssuer), jwt.WithLeeway(tokenTTLs.clockSkew)}
+	if jwtAudience != "" {
+		opts = append(opts, jwt.WithAudience(jwtAudience))
+	}
+	token, err := jwt.ParseWithClaims(tokenString, claims, jwtKeyFunc(), opts...)
 
 	if err != nil {
 		return nil, err
@@ -106,86 +176,189 @@ func extractTokenFromMetadata(ctx context.Context) (string, error) {
 	return authHeader[0][7:], nil
 }
 
-// validateUserExistsInDB validates that the user from JWT claims still exists in the database
-func validateUserExistsInDB(ctx context.Context, db *pgxpool.Pool, userID int, username string) error {
-	var dbUserID int
-	var dbUsername string
+// userFromContext returns the authenticated user injected by the auth interceptors
+func userFromContext(ctx context.Context) (int, string, bool) {
+	userID, ok := ctx.Value(userIDKey).(int)
+	if !ok {
+		return 0, "", false
+	}
+	username, _ := ctx.Value(usernameKey).(string)
+	return userID, username, true
+}
 
-	err := db.QueryRow(ctx, "SELECT id, username FROM users WHERE id=$1 AND username=$2", userID, username).Scan(&dbUserID, &dbUsername)
+// withActor records the authenticated caller of ctx as who makes the book changes of the request
+func withActor(ctx context.Context) context.Context {
+	if userID, username, ok := userFromContext(ctx); ok {
+		role, _ := roleFromContext(ctx)
+		return storage.WithActor(ctx, storage.Actor{UserID: userID, Name: username, Admin: role == adminRole})
+	}
+	if service, ok := serviceFromContext(ctx); ok {
+		return storage.WithActor(ctx, storage.Actor{Name: "service:" + service})
+	}
+	return ctx
+}
+
+// claimsFromContext returns the claims of the access token the request was authenticated with
+func claimsFromContext(ctx context.Context) (*Claims, bool) {
+	claims, ok := ctx.Value(claimsKey).(*Claims)
+	return claims, ok
+}
+
+// validateTokenNotRevoked rejects tokens revoked by Logout, tokens of a revoked session,
+// or tokens issued before the user's last RevokeAllSessions.
+// Token issue times have second precision, so a token issued in the same second as the revocation is rejected too.
+func validateTokenNotRevoked(ctx context.Context, users storage.UserRepository, denylist tokenDenylist, claims *Claims) error {
+	// Revoked token IDs are looked up in the denylist; if it cannot answer, the durable revoked_tokens table does
+	checkTable := false
+	if claims.ID != "" {
+		revoked, err := denylist.IsRevoked(ctx, claims.ID)
+		if err != nil {
+			slog.WarnContext(ctx, "token denylist unavailable, checking the database", "error", err)
+			checkTable = true
+		} else if revoked {
+			return errors.New("token has been revoked")
+		}
+	}
+
+	rev, err := users.Revocation(ctx, claims.UserID, claims.ID, claims.SessionID, checkTable)
+	if errors.Is(err, storage.ErrNotFound) {
+		return errors.New("user not found in database")
+	}
 	if err != nil {
-		return fmt.Errorf("user not found in database")
+		return fmt.Errorf("revocation lookup failed: %w", err)
+	}
+	if rev.TokenRevoked {
+		return errors.New("token has been revoked")
+	}
+	if rev.SessionRevoked {
+		return errors.New("session has been revoked")
+	}
+	if rev.SessionsRevokedAt != nil && (claims.IssuedAt == nil || !claims.IssuedAt.After(rev.SessionsRevokedAt.Truncate(time.Second))) {
+		return errors.New("session has been revoked")
+	}
+	return nil
+}
+
+// validateUserExistsInDB validates that the user from JWT claims still exists in the database, and may use the
+// service, and returns their role
+func validateUserExistsInDB(ctx context.Context, users storage.UserRepository, userID int, username string) (string, error) {
+	u, err := users.GetByID(ctx, userID)
+	if errors.Is(err, storage.ErrNotFound) {
+		return "", errors.New("user not found in database")
+	}
+	if err != nil {
+		return "", fmt.Errorf("user lookup failed: %w", err)
+	}
+	if err := checkAccountState(u.State); err != nil {
+		return "", err
 	}
 
 	// Double-check that the data matches exactly
-	if dbUserID != userID || dbUsername != username {
-		return fmt.Errorf("user data mismatch")
+	if u.Username != username {
+		return "", fmt.Errorf("user data mismatch")
 	}
 
-	return nil
+	return u.Role, nil
+}
+
+// authOptions configures how the auth interceptors authenticate and authorize calls
+type authOptions struct {
+	// users looks up the accounts and revocations tokens are checked against
+	users storage.UserRepository
+	// denylist holds revoked access token IDs
+	denylist tokenDenylist
+	// identities lists the client certificates that may call without a token
+	identities certIdentities
+	// settings hold the policy deciding which methods need credentials, roles or scopes
+	settings *atomic.Pointer[runtimeSettings]
+}
+
+// authenticate verifies the caller's client certificate or bearer token and returns a context carrying their identity
+func authenticate(ctx context.Context, db *pgxpool.Pool, opts authOptions) (context.Context, error) {
+	// Internal services may authenticate with a client certificate instead of a token
+	if certCtx, ok, err := authenticateClientCert(ctx, db, opts.identities); err != nil {
+		return nil, err
+	} else if ok {
+		return withActor(certCtx), nil
+	}
+
+	token, err := extractTokenFromMetadata(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.Unauthenticated, "authentication failed: %v", err)
+	}
+
+	claims, err := ValidateJWT(token)
+	if err != nil {
+		return nil, status.Errorf(codes.Unauthenticated, "invalid token: %v", err)
+	}
+	if err := checkDeviceBinding(ctx, claims); err != nil {
+		return nil, err
+	}
+	// User IDs are only unique within a library
+	if claims.TenantID != tenantFromContext(ctx) {
+		return nil, status.Error(codes.Unauthenticated, "token was issued by another library")
+	}
+
+	// CRITICAL: Validate that the user still exists in the database
+	role, err := validateUserExistsInDB(ctx, opts.users, claims.UserID, claims.Username)
+	if err != nil {
+		return nil, status.Errorf(codes.Unauthenticated, "user validation failed: %v", err)
+	}
+
+	if err := validateTokenNotRevoked(ctx, opts.users, opts.denylist, claims); err != nil {
+		return nil, status.Errorf(codes.Unauthenticated, "invalid token: %v", err)
+	}
+	touchSession(ctx, db, claims.SessionID)
+
+	// Add user info to context for use in handlers
+	ctx = context.WithValue(ctx, userIDKey, claims.UserID)
+	ctx = context.WithValue(ctx, usernameKey, claims.Username)
+	ctx = context.WithValue(ctx, claimsKey, claims)
+	ctx = context.WithValue(ctx, roleKey, role)
+	return withActor(ctx), nil
 }
 
-// CreateAuthInterceptor creates a gRPC unary interceptor for authentication with database access
-func CreateAuthInterceptor(db *pgxpool.Pool) grpc.UnaryServerInterceptor {
+// CreateAuthInterceptor creates a gRPC unary interceptor for authentication with database access.
+// Which methods need credentials, and which roles or scopes, is decided by the policy in opts.settings.
+func CreateAuthInterceptor(db *pgxpool.Pool, opts authOptions) grpc.UnaryServerInterceptor {
 	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
-		// Skip authentication for Register and Login methods
-		if info.FullMethod == "/library.UserService/Register" || info.FullMethod == "/library.UserService/Login" {
+		// Skip authentication for login, registration and other anonymous methods
+		policy := opts.settings.Load().policy
+		if policy.rule(info.FullMethod).Access == accessAnonymous {
 			return handler(ctx, req)
 		}
 
-		token, err := extractTokenFromMetadata(ctx)
+		ctx, err := authenticate(ctx, db, opts)
 		if err != nil {
-			return nil, status.Errorf(codes.Unauthenticated, "authentication failed: %v", err)
+			return nil, err
 		}
-
-		claims, err := ValidateJWT(token)
-		if err != nil {
-			return nil, status.Errorf(codes.Unauthenticated, "invalid token: %v", err)
+		noteRPCCaller(ctx)
+		if err := policy.authorize(ctx, info.FullMethod); err != nil {
+			return nil, err
 		}
-
-		// CRITICAL: Validate that the user still exists in the database
-		err = validateUserExistsInDB(ctx, db, claims.UserID, claims.Username)
-		if err != nil {
-			return nil, status.Errorf(codes.Unauthenticated, "user validation failed: %v", err)
-		}
-
-		// Add user info to context for use in handlers
-		ctx = context.WithValue(ctx, userIDKey, claims.UserID)
-		ctx = context.WithValue(ctx, usernameKey, claims.Username)
-
 		return handler(ctx, req)
 	}
 }
 
 // CreateStreamAuthInterceptor creates a gRPC stream interceptor for authentication with database access
-func CreateStreamAuthInterceptor(db *pgxpool.Pool) grpc.StreamServerInterceptor {
+func CreateStreamAuthInterceptor(db *pgxpool.Pool, opts authOptions) grpc.StreamServerInterceptor {
 	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
-		// Skip authentication for methods that don't require it
-		// For now, all streaming methods require authentication
-
-		token, err := extractTokenFromMetadata(ss.Context())
-		if err != nil {
-			return status.Errorf(codes.Unauthenticated, "authentication failed: %v", err)
+		policy := opts.settings.Load().policy
+		if policy.rule(info.FullMethod).Access == accessAnonymous {
+			return handler(srv, ss)
 		}
 
-		claims, err := ValidateJWT(token)
+		ctx, err := authenticate(ss.Context(), db, opts)
 		if err != nil {
-			return status.Errorf(codes.Unauthenticated, "invalid token: %v", err)
+			return err
 		}
-
-		// CRITICAL: Validate that the user still exists in the database
-		err = validateUserExistsInDB(ss.Context(), db, claims.UserID, claims.Username)
-		if err != nil {
-			return status.Errorf(codes.Unauthenticated, "user validation failed: %v", err)
+		noteRPCCaller(ctx)
+		if err := policy.authorize(ctx, info.FullMethod); err != nil {
+			return err
 		}
 
-		// Create a new context with user info
-		ctx := context.WithValue(ss.Context(), userIDKey, claims.UserID)
-		ctx = context.WithValue(ctx, usernameKey, claims.Username)
-
 		// Wrap the stream with the new context
-		wrappedStream := &contextServerStream{ss, ctx}
-
-		return handler(srv, wrappedStream)
+		return handler(srv, &contextServerStream{ss, ctx})
 	}
 }
 
@@ -2,11 +2,14 @@ package main
 
 import (
 	"context"
+	"errors"
 	"os"
 	"strconv"
 	"testing"
 	"time"
 
+	"example/grpc_demo/server/storage"
+
 	"github.com/golang-jwt/jwt/v5"
 	"google.golang.org/grpc/metadata"
 )
@@ -41,7 +44,7 @@ func TestGenerateJWT(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			token, err := GenerateJWT(tt.userID, tt.username)
+			token, err := GenerateJWT(tt.userID, tt.username, 0)
 
 			if (err != nil) != tt.wantErr {
 				t.Errorf("GenerateJWT() error = %v, wantErr %v", err, tt.wantErr)
@@ -73,7 +76,7 @@ func TestGenerateJWT(t *testing.T) {
 
 func TestValidateJWT(t *testing.T) {
 	// Generate a valid token for testing
-	validToken, err := GenerateJWT(1, "testuser")
+	validToken, err := GenerateJWT(1, "testuser", 0)
 	if err != nil {
 		t.Fatalf("Failed to generate valid token for testing: %v", err)
 	}
@@ -210,29 +213,6 @@ func TestExtractTokenFromMetadata(t *testing.T) {
 	}
 }
 
-func TestGetJWTSecret(t *testing.T) {
-	// Test with environment variable set
-	originalSecret := os.Getenv("JWT_SECRET")
-	os.Setenv("JWT_SECRET", "test-env-secret")
-
-	secret := getJWTSecret()
-	if secret != "test-env-secret" {
-		t.Errorf("getJWTSecret() with env var = %v, want %v", secret, "test-env-secret")
-	}
-
-	// Test with no environment variable
-	os.Unsetenv("JWT_SECRET")
-	secret = getJWTSecret()
-	if secret == "" {
-		t.Error("getJWTSecret() should return default secret when env var is not set")
-	}
-
-	// Restore original
-	if originalSecret != "" {
-		os.Setenv("JWT_SECRET", originalSecret)
-	}
-}
-
 // Helper function to generate a token with wrong secret for testing
 func generateTokenWithWrongSecret(userID int, username string) string {
 	claims := &Claims{
@@ -255,7 +235,7 @@ func generateTokenWithWrongSecret(userID int, username string) string {
 // Benchmark tests
 func BenchmarkGenerateJWT(b *testing.B) {
 	for i := 0; i < b.N; i++ {
-		_, err := GenerateJWT(1, "testuser")
+		_, err := GenerateJWT(1, "testuser", 0)
 		if err != nil {
 			b.Fatal(err)
 		}
@@ -263,7 +243,7 @@ func BenchmarkGenerateJWT(b *testing.B) {
 }
 
 func BenchmarkValidateJWT(b *testing.B) {
-	token, err := GenerateJWT(1, "testuser")
+	token, err := GenerateJWT(1, "testuser", 0)
 	if err != nil {
 		b.Fatal(err)
 	}
@@ -276,3 +256,133 @@ func BenchmarkValidateJWT(b *testing.B) {
 		}
 	}
 }
+
+func TestGenerateJWTTokenID(t *testing.T) {
+	first, err := GenerateJWT(1, "testuser", 0)
+	if err != nil {
+		t.Fatalf("GenerateJWT() error = %v", err)
+	}
+	second, err := GenerateJWT(1, "testuser", 0)
+	if err != nil {
+		t.Fatalf("GenerateJWT() error = %v", err)
+	}
+
+	a, err := ValidateJWT(first)
+	if err != nil {
+		t.Fatalf("ValidateJWT() error = %v", err)
+	}
+	b, err := ValidateJWT(second)
+	if err != nil {
+		t.Fatalf("ValidateJWT() error = %v", err)
+	}
+	if a.ID == "" || a.ID == b.ID {
+		t.Errorf("token IDs should be set and unique, got %q and %q", a.ID, b.ID)
+	}
+}
+
+func TestClaimsFromContext(t *testing.T) {
+	if _, ok := claimsFromContext(context.Background()); ok {
+		t.Error("claimsFromContext() should fail without claims")
+	}
+	claims := &Claims{UserID: 7, Username: "testuser"}
+	got, ok := claimsFromContext(context.WithValue(context.Background(), claimsKey, claims))
+	if !ok || got != claims {
+		t.Errorf("claimsFromContext() = %v, %v, want the stored claims", got, ok)
+	}
+}
+
+func TestValidateJWTIssuerAudience(t *testing.T) {
+	useIssuerAudience := func(issuer, audience string) {
+		jwtIssuer, jwtAudience = issuer, audience
+	}
+	t.Cleanup(func() { useIssuerAudience(defaultJWTIssuer, "") })
+
+	useIssuerAudience("library-staging", "library-api")
+	staging, err := GenerateJWT(1, "testuser", 0)
+	if err != nil {
+		t.Fatalf("GenerateJWT() error = %v", err)
+	}
+	claims, err := ValidateJWT(staging)
+	if err != nil {
+		t.Fatalf("ValidateJWT() error = %v", err)
+	}
+	if claims.Issuer != "library-staging" || len(claims.Audience) != 1 || claims.Audience[0] != "library-api" {
+		t.Errorf("claims iss = %q, aud = %v, want library-staging, [library-api]", claims.Issuer, claims.Audience)
+	}
+
+	useIssuerAudience("library-production", "library-api")
+	if _, err := ValidateJWT(staging); !errors.Is(err, jwt.ErrTokenInvalidIssuer) {
+		t.Errorf("ValidateJWT() with another issuer error = %v, want ErrTokenInvalidIssuer", err)
+	}
+	useIssuerAudience("library-staging", "reporting-api")
+	if _, err := ValidateJWT(staging); !errors.Is(err, jwt.ErrTokenInvalidAudience) {
+		t.Errorf("ValidateJWT() with another audience error = %v, want ErrTokenInvalidAudience", err)
+	}
+
+	// Once an audience is configured, tokens issued without one are rejected too
+	useIssuerAudience("library-staging", "")
+	noAudience, err := GenerateJWT(1, "testuser", 0)
+	if err != nil {
+		t.Fatalf("GenerateJWT() error = %v", err)
+	}
+	useIssuerAudience("library-staging", "library-api")
+	if _, err := ValidateJWT(noAudience); err == nil {
+		t.Error("ValidateJWT() accepted a token without the configured audience")
+	}
+}
+
+func TestValidateUserExistsInDB(t *testing.T) {
+	ctx := context.Background()
+	users := &fakeUsers{user: &storage.User{ID: 7, Username: "alice", Role: adminRole, State: accountActive}}
+	if role, err := validateUserExistsInDB(ctx, users, 7, "alice"); err != nil || role != adminRole {
+		t.Errorf("validateUserExistsInDB() = %q, %v, want %q", role, err, adminRole)
+	}
+	if _, err := validateUserExistsInDB(ctx, users, 7, "mallory"); err == nil {
+		t.Error("validateUserExistsInDB() of another username should fail")
+	}
+	if _, err := validateUserExistsInDB(ctx, users, 8, "alice"); err == nil {
+		t.Error("validateUserExistsInDB() of an unknown user should fail")
+	}
+	users.user.State = accountPending
+	if _, err := validateUserExistsInDB(ctx, users, 7, "alice"); err == nil {
+		t.Error("validateUserExistsInDB() of a pending account should fail")
+	}
+}
+
+func TestValidateTokenNotRevoked(t *testing.T) {
+	ctx := context.Background()
+	denylist := newMemoryDenylist()
+	issued := time.Now().Add(-time.Hour)
+	claims := &Claims{UserID: 7, SessionID: 3, RegisteredClaims: jwt.RegisteredClaims{ID: "jti-1", IssuedAt: jwt.NewNumericDate(issued)}}
+	later, earlier := issued.Add(time.Minute), issued.Add(-time.Minute)
+
+	tests := []struct {
+		name       string
+		revocation storage.Revocation
+		wantErr    bool
+	}{
+		{"nothing revoked", storage.Revocation{}, false},
+		{"token revoked", storage.Revocation{TokenRevoked: true}, true},
+		{"session revoked", storage.Revocation{SessionRevoked: true}, true},
+		{"all sessions revoked after issue", storage.Revocation{SessionsRevokedAt: &later}, true},
+		{"all sessions revoked before issue", storage.Revocation{SessionsRevokedAt: &earlier}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			users := &fakeUsers{user: &storage.User{ID: 7}, revocation: tt.revocation}
+			if err := validateTokenNotRevoked(ctx, users, denylist, claims); (err != nil) != tt.wantErr {
+				t.Errorf("validateTokenNotRevoked() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	if err := validateTokenNotRevoked(ctx, &fakeUsers{}, denylist, claims); err == nil {
+		t.Error("validateTokenNotRevoked() of an unknown user should fail")
+	}
+	if err := denylist.Revoke(ctx, "jti-1", time.Now().Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if err := validateTokenNotRevoked(ctx, &fakeUsers{user: &storage.User{ID: 7}}, denylist, claims); err == nil {
+		t.Error("validateTokenNotRevoked() of a denylisted token should fail")
+	}
+}
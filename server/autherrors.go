@@ -0,0 +1,38 @@
+package main
+
+import (
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// authErrorDomain identifies the ErrorInfo reasons below
+const authErrorDomain = "library.UserService"
+
+// Machine-readable reasons for failed registrations and logins, sent in ErrorInfo details
+const (
+	reasonMissingCredentials = "MISSING_CREDENTIALS"
+	reasonInvalidEmail       = "INVALID_EMAIL"
+	reasonEmailRequired      = "EMAIL_REQUIRED"
+	reasonUsernameTaken      = "USERNAME_TAKEN"
+	reasonEmailTaken         = "EMAIL_TAKEN"
+	reasonInvalidCredentials = "INVALID_CREDENTIALS"
+	reasonEmailNotVerified   = "EMAIL_NOT_VERIFIED"
+	reasonChallengeRequired  = "CHALLENGE_REQUIRED"
+	reasonChallengeFailed    = "CHALLENGE_FAILED"
+	reasonPasswordReused     = "PASSWORD_REUSED"
+)
+
+// authError builds the status returned by Register and Login. Clients branch on the ErrorInfo reason;
+// the LocalizedMessage carries the text to show the user.
+func authError(code codes.Code, reason, message string) error {
+	st := status.New(code, message)
+	withDetails, err := st.WithDetails(
+		&errdetails.ErrorInfo{Reason: reason, Domain: authErrorDomain},
+		&errdetails.LocalizedMessage{Locale: "en-US", Message: message},
+	)
+	if err != nil {
+		return st.Err()
+	}
+	return withDetails.Err()
+}
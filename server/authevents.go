@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	pb "example/grpc_demo/library"
+	"example/grpc_demo/server/storage"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// authEventTypeNames maps event types to the values stored in auth_events.event
+var authEventTypeNames = map[pb.AuthEventType]string{
+	pb.AuthEventType_AUTH_EVENT_TYPE_REGISTER:             "register",
+	pb.AuthEventType_AUTH_EVENT_TYPE_LOGIN:                "login",
+	pb.AuthEventType_AUTH_EVENT_TYPE_LOGIN_FAILED:         "login_failed",
+	pb.AuthEventType_AUTH_EVENT_TYPE_TOKEN_REFRESH:        "token_refresh",
+	pb.AuthEventType_AUTH_EVENT_TYPE_TOKEN_REFRESH_FAILED: "token_refresh_failed",
+}
+
+// parseAuthEventType converts a stored event back into its enum value
+func parseAuthEventType(name string) pb.AuthEventType {
+	for t, n := range authEventTypeNames {
+		if n == name {
+			return t
+		}
+	}
+	return pb.AuthEventType_AUTH_EVENT_TYPE_UNSPECIFIED
+}
+
+// recordAuthEvent appends to the audit trail with the caller's IP address and user agent.
+// userID is 0 when the attempt matched no user. Recording must not block logins, so failures are only logged.
+func (s *server) recordAuthEvent(ctx context.Context, eventType pb.AuthEventType, userID int, username, detail string) {
+	userAgent, ip := sessionDevice(ctx)
+	_, err := s.db.Exec(ctx,
+		`INSERT INTO auth_events (user_id, username, event, detail, ip_address, user_agent)
+		 VALUES (NULLIF($1, 0), $2, $3, $4, $5, $6)`,
+		userID, username, authEventTypeNames[eventType], detail, ip, userAgent)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to record auth event", "event", authEventTypeNames[eventType], "username", username, "error", err)
+	}
+}
+
+func (s *server) ListAuthEvents(ctx context.Context, req *pb.ListAuthEventsRequest) (*pb.ListAuthEventsResponse, error) {
+	userID, username, ok := userFromContext(ctx)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "authentication required")
+	}
+	if req.GetType() != pb.AuthEventType_AUTH_EVENT_TYPE_UNSPECIFIED {
+		if _, ok := authEventTypeNames[req.GetType()]; !ok {
+			return nil, status.Errorf(codes.InvalidArgument, "unknown event type %v", req.GetType())
+		}
+	}
+
+	// Users see the events of their own account; admins may look up any username,
+	// including failed attempts against names that match no user
+	var filter sqlFilter
+	scope := fmt.Sprintf("auth events user %d", userID)
+	if req.GetUsername() != "" && req.GetUsername() != username {
+		if role, _ := roleFromContext(ctx); role != adminRole {
+			return nil, status.Error(codes.PermissionDenied, "only admins can list other users' events")
+		}
+		filter.where("username = " + filter.arg(req.GetUsername()))
+		scope = fmt.Sprintf("auth events username %q", req.GetUsername())
+	} else {
+		filter.where("user_id = " + filter.arg(userID))
+	}
+	if req.GetType() != pb.AuthEventType_AUTH_EVENT_TYPE_UNSPECIFIED {
+		filter.where("event = " + filter.arg(authEventTypeNames[req.GetType()]))
+	}
+	page, err := pageOf(req, fmt.Sprintf("%s %d", scope, req.GetType()), time.Time{}, int64(0))
+	if err != nil {
+		return nil, err
+	}
+
+	var total int32
+	if err := s.db.QueryRow(ctx, "SELECT COUNT(*) FROM auth_events"+filter.clause(), filter.args...).Scan(&total); err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to count events: %v", err)
+	}
+
+	order := storage.Keyset{Columns: []string{"created_at", "id"}, Desc: true}
+	page.where(&filter, order)
+	rows, err := s.db.Query(ctx,
+		"SELECT id, event, username, detail, ip_address, user_agent, created_at FROM auth_events"+
+			filter.clause()+order.OrderBy()+page.limitOffset(&filter),
+		filter.args...)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to list events: %v", err)
+	}
+	defer rows.Close()
+
+	resp := &pb.ListAuthEventsResponse{TotalCount: total}
+	for rows.Next() {
+		var e pb.AuthEvent
+		var event string
+		var createdAt time.Time
+		if err := rows.Scan(&e.Id, &event, &e.Username, &e.Detail, &e.IpAddress, &e.UserAgent, &createdAt); err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to read event: %v", err)
+		}
+		e.Type = parseAuthEventType(event)
+		e.CreatedAt = timestamppb.New(createdAt)
+		resp.Events = append(resp.Events, &e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to list events: %v", err)
+	}
+	resp.Events, resp.NextPageToken = nextPage(page, resp.Events, func(e *pb.AuthEvent) []any { return []any{e.GetCreatedAt().AsTime(), e.GetId()} })
+	return resp, nil
+}
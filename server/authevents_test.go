@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	pb "example/grpc_demo/library"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestAuthEventTypeNames(t *testing.T) {
+	for eventType, name := range authEventTypeNames {
+		if got := parseAuthEventType(name); got != eventType {
+			t.Errorf("parseAuthEventType(%q) = %v, want %v", name, got, eventType)
+		}
+	}
+	if got := parseAuthEventType("unknown"); got != pb.AuthEventType_AUTH_EVENT_TYPE_UNSPECIFIED {
+		t.Errorf("parseAuthEventType(unknown) = %v, want UNSPECIFIED", got)
+	}
+}
+
+func TestListAuthEventsAccess(t *testing.T) {
+	s := &server{}
+	asUser := func(role string) context.Context {
+		ctx := context.WithValue(context.Background(), userIDKey, 1)
+		ctx = context.WithValue(ctx, usernameKey, "alice")
+		return context.WithValue(ctx, roleKey, role)
+	}
+	tests := []struct {
+		name string
+		ctx  context.Context
+		req  *pb.ListAuthEventsRequest
+		want codes.Code
+	}{
+		{"anonymous", context.Background(), &pb.ListAuthEventsRequest{}, codes.Unauthenticated},
+		{"other user", asUser(userRole), &pb.ListAuthEventsRequest{Username: "bob"}, codes.PermissionDenied},
+		{"invalid type", asUser(adminRole), &pb.ListAuthEventsRequest{Type: pb.AuthEventType(99)}, codes.InvalidArgument},
+	}
+	for _, tt := range tests {
+		_, err := s.ListAuthEvents(tt.ctx, tt.req)
+		if status.Code(err) != tt.want {
+			t.Errorf("%s: ListAuthEvents() error = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+}
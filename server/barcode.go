@@ -0,0 +1,204 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image/png"
+	"strings"
+	"time"
+
+	pb "example/grpc_demo/library"
+	"example/grpc_demo/server/storage"
+
+	"github.com/boombuler/barcode"
+	"github.com/boombuler/barcode/code128"
+	"github.com/boombuler/barcode/ean"
+	"github.com/jackc/pgx/v5"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// maxBarcodeLength bounds copy barcodes so they stay scannable as Code 128
+	maxBarcodeLength = 48
+	// barcodeImageWidth and barcodeImageHeight size rendered labels in pixels
+	barcodeImageWidth  = 300
+	barcodeImageHeight = 80
+)
+
+// generatedBarcode returns the barcode assigned to a copy registered without one
+func generatedBarcode(copyID int64) string {
+	return fmt.Sprintf("LIB%09d", copyID)
+}
+
+// validBarcode reports whether a barcode is non-empty printable ASCII within maxBarcodeLength
+func validBarcode(code string) bool {
+	if code == "" || len(code) > maxBarcodeLength {
+		return false
+	}
+	for i := 0; i < len(code); i++ {
+		if code[i] < 0x20 || code[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// renderBarcode encodes code in the given symbology and returns it as a PNG
+func renderBarcode(code string, symbology pb.BarcodeSymbology) ([]byte, error) {
+	var (
+		bc  barcode.Barcode
+		err error
+	)
+	switch symbology {
+	case pb.BarcodeSymbology_BARCODE_SYMBOLOGY_UNSPECIFIED, pb.BarcodeSymbology_BARCODE_SYMBOLOGY_CODE128:
+		bc, err = code128.Encode(code)
+	case pb.BarcodeSymbology_BARCODE_SYMBOLOGY_EAN13:
+		if len(code) != 12 && len(code) != 13 {
+			return nil, errors.New("EAN-13 barcodes need 12 or 13 digits")
+		}
+		bc, err = ean.Encode(code)
+	default:
+		return nil, fmt.Errorf("unsupported barcode symbology: %v", symbology)
+	}
+	if err != nil {
+		return nil, err
+	}
+	scaled, err := barcode.Scale(bc, barcodeImageWidth, barcodeImageHeight)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, scaled); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (s *server) AddCopy(ctx context.Context, req *pb.CopyRequest) (*pb.BookCopy, error) {
+	if req.GetBookId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "Book ID is required")
+	}
+	code := strings.TrimSpace(req.GetBarcode())
+	if code != "" && !validBarcode(code) {
+		return nil, status.Errorf(codes.InvalidArgument, "barcode must be 1-%d printable ASCII characters", maxBarcodeLength)
+	}
+	acquiredAt, err := validateAcquisition(req, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "database error: %v", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// The new copy starts on the shelf, or goes straight to the first reservation in line
+	res, err := tx.Exec(ctx, "UPDATE books SET total_copies = total_copies + 1 WHERE id=$1", req.GetBookId())
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to add copy: %v", err)
+	}
+	if res.RowsAffected() == 0 {
+		return nil, notFound(resourceBook, req.GetBookId(), "Book not found")
+	}
+	promoted, err := releaseCopy(ctx, tx, req.GetBookId())
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to add copy: %v", err)
+	}
+
+	var id int64
+	if err := tx.QueryRow(ctx, "SELECT nextval(pg_get_serial_sequence('book_copies', 'id'))").Scan(&id); err != nil {
+		return nil, status.Errorf(codes.Internal, "database error: %v", err)
+	}
+	if code == "" {
+		code = generatedBarcode(id)
+	}
+	added, err := scanCopy(tx.QueryRow(ctx,
+		`INSERT INTO book_copies AS c (id, book_id, barcode, condition, price_cents, acquired_at, donor)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING `+copyColumns,
+		id, req.GetBookId(), code, copyConditionNames[req.GetCondition()], req.GetPriceCents(), acquiredAt, strings.TrimSpace(req.GetDonor())))
+	if isUniqueViolation(err) {
+		return nil, status.Errorf(codes.AlreadyExists, "barcode %s is already assigned", code)
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to add copy: %v", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to add copy: %v", err)
+	}
+	s.cache.invalidate()
+	s.notifyHoldReady(ctx, promoted)
+
+	return added, nil
+}
+
+func (s *server) ListCopies(ctx context.Context, req *pb.BookRequest) (*pb.ListCopiesResponse, error) {
+	if req.GetId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "Book ID is required")
+	}
+	var exists bool
+	if err := s.db.QueryRow(ctx, "SELECT EXISTS(SELECT 1 FROM books WHERE id=$1)", req.GetId()).Scan(&exists); err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, notFound(resourceBook, req.GetId(), "Book not found")
+	}
+
+	rows, err := s.db.Query(ctx, "SELECT "+copyColumns+" FROM book_copies c WHERE c.book_id=$1 ORDER BY c.id", req.GetId())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	resp := &pb.ListCopiesResponse{}
+	for rows.Next() {
+		c, err := scanCopy(rows)
+		if err != nil {
+			return nil, err
+		}
+		resp.Copies = append(resp.Copies, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (s *server) GetBookByBarcode(ctx context.Context, req *pb.BarcodeRequest) (*pb.Book, error) {
+	code := strings.TrimSpace(req.GetBarcode())
+	if code == "" {
+		return nil, status.Error(codes.InvalidArgument, "Barcode is required")
+	}
+
+	book, err := storage.ScanBook(s.db.QueryRow(ctx,
+		"SELECT "+storage.QualifiedBookColumns("b")+" FROM book_copies c JOIN books b ON b.id = c.book_id WHERE c.barcode=$1", code))
+	// Fall back to the ISBN printed as an EAN-13 on the jacket
+	if isbn := normalizeISBN(code); errors.Is(err, pgx.ErrNoRows) && len(isbn) == 13 && validISBN(isbn) {
+		book, err = storage.ScanBook(s.db.QueryRow(ctx, "SELECT "+storage.BookColumns+" FROM books WHERE isbn=$1 ORDER BY id LIMIT 1", isbn))
+	}
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, notFound(resourceBook, code, "No book has this barcode")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := s.enrichBooks(ctx, []*pb.Book{book}); err != nil {
+		return nil, err
+	}
+	return book, nil
+}
+
+func (s *server) GetBarcodeImage(ctx context.Context, req *pb.BarcodeRequest) (*pb.BarcodeImage, error) {
+	code := strings.TrimSpace(req.GetBarcode())
+	if !validBarcode(code) {
+		return nil, status.Errorf(codes.InvalidArgument, "barcode must be 1-%d printable ASCII characters", maxBarcodeLength)
+	}
+	data, err := renderBarcode(code, req.GetSymbology())
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "cannot render barcode: %v", err)
+	}
+	return &pb.BarcodeImage{Barcode: code, ContentType: "image/png", Data: data}, nil
+}
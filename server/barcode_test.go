@@ -0,0 +1,73 @@
+package main
+
+import (
+	"bytes"
+	"image/png"
+	"strings"
+	"testing"
+
+	pb "example/grpc_demo/library"
+)
+
+func TestGeneratedBarcode(t *testing.T) {
+	if got := generatedBarcode(42); got != "LIB000000042" {
+		t.Errorf("generatedBarcode(42) = %q, want LIB000000042", got)
+	}
+	if !validBarcode(generatedBarcode(1)) {
+		t.Error("generated barcodes should be valid")
+	}
+}
+
+func TestValidBarcode(t *testing.T) {
+	tests := []struct {
+		code string
+		want bool
+	}{
+		{"LIB000000001", true},
+		{"9780306406157", true},
+		{"copy 7/B", true},
+		{"", false},
+		{"tab\there", false},
+		{"café", false},
+		{strings.Repeat("1", maxBarcodeLength+1), false},
+	}
+	for _, tt := range tests {
+		if got := validBarcode(tt.code); got != tt.want {
+			t.Errorf("validBarcode(%q) = %v, want %v", tt.code, got, tt.want)
+		}
+	}
+}
+
+func TestRenderBarcode(t *testing.T) {
+	tests := []struct {
+		name      string
+		code      string
+		symbology pb.BarcodeSymbology
+		wantErr   bool
+	}{
+		{"default is code128", "LIB000000001", pb.BarcodeSymbology_BARCODE_SYMBOLOGY_UNSPECIFIED, false},
+		{"code128", "copy 7/B", pb.BarcodeSymbology_BARCODE_SYMBOLOGY_CODE128, false},
+		{"ean13 from isbn", "9780306406157", pb.BarcodeSymbology_BARCODE_SYMBOLOGY_EAN13, false},
+		{"ean13 without check digit", "978030640615", pb.BarcodeSymbology_BARCODE_SYMBOLOGY_EAN13, false},
+		{"ean13 wrong length", "12345", pb.BarcodeSymbology_BARCODE_SYMBOLOGY_EAN13, true},
+		{"ean13 letters", "LIB000000001X", pb.BarcodeSymbology_BARCODE_SYMBOLOGY_EAN13, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := renderBarcode(tt.code, tt.symbology)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("renderBarcode() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			img, err := png.Decode(bytes.NewReader(data))
+			if err != nil {
+				t.Fatalf("renderBarcode() did not return a PNG: %v", err)
+			}
+			if b := img.Bounds(); b.Dx() != barcodeImageWidth || b.Dy() != barcodeImageHeight {
+				t.Errorf("image size = %dx%d, want %dx%d", b.Dx(), b.Dy(), barcodeImageWidth, barcodeImageHeight)
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+
+	pb "example/grpc_demo/library"
+	"example/grpc_demo/server/storage"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// patchableBookFields are the fields of a book PatchBook changes, along with their sub-fields, such as
+// location.branch. The others are read-only or, like tags, changed by their own methods.
+var patchableBookFields = []string{"title", "author", "isbn", "publisher", "publisher_id", "description", "total_copies", "location", "series"}
+
+// PatchBook changes the fields of req.update_mask and then updates the book as UpdateBook does
+func (s *server) PatchBook(ctx context.Context, req *pb.PatchBookRequest) (*pb.BookResponse, error) {
+	if req.GetId() == "" {
+		return nil, badRequest("id", "Book ID is required")
+	}
+	err := s.tx.InTx(ctx, func(ctx context.Context) error {
+		book, err := s.books.GetForUpdate(ctx, req.GetId())
+		if errors.Is(err, storage.ErrNotFound) {
+			return notFound(resourceBook, req.GetId(), "Book not found")
+		}
+		if err != nil {
+			return status.Errorf(codes.Internal, "database error: %v", err)
+		}
+		// The book as locked is what the patched one replaces, so it isn't read again
+		old := proto.Clone(book).(*pb.Book)
+		if err := applyBookPatch(book, req.GetBook(), req.GetUpdateMask().GetPaths()); err != nil {
+			return err
+		}
+		return s.updateBook(ctx, old, book)
+	})
+	if err != nil {
+		return nil, txStatus(err, "failed to update book")
+	}
+	return &pb.BookResponse{Id: req.GetId()}, nil
+}
+
+// applyBookPatch sets the fields of book named in paths to their value in patch, unset fields clearing
+// them. Empty paths change the patchable fields set in patch, as AIP-134 has it; the others, such as the
+// read-only ones of a book read earlier, are ignored.
+func applyBookPatch(book, patch *pb.Book, paths []string) error {
+	if len(paths) == 0 {
+		paths = setBookFields(patch)
+	}
+	for _, path := range paths {
+		field, _, _ := strings.Cut(path, ".")
+		if !slices.Contains(patchableBookFields, field) || !copyBookField(book.ProtoReflect(), patch.ProtoReflect(), strings.Split(path, ".")) {
+			return badRequest(path, "Field cannot be changed")
+		}
+	}
+	// A publisher given by name is looked up by it, rather than keeping the book's publisher ID
+	if slices.Contains(paths, "publisher") && !slices.Contains(paths, "publisher_id") {
+		book.PublisherId = 0
+	}
+	return nil
+}
+
+// setBookFields returns the patchable fields set in patch
+func setBookFields(patch *pb.Book) []string {
+	var paths []string
+	patch.ProtoReflect().Range(func(fd protoreflect.FieldDescriptor, _ protoreflect.Value) bool {
+		if name := string(fd.Name()); slices.Contains(patchableBookFields, name) {
+			paths = append(paths, name)
+		}
+		return true
+	})
+	return paths
+}
+
+// copyBookField copies the field at path from src to dst, reporting false when there is no such field
+func copyBookField(dst, src protoreflect.Message, path []string) bool {
+	fd := dst.Descriptor().Fields().ByName(protoreflect.Name(path[0]))
+	if fd == nil {
+		return false
+	}
+	if len(path) == 1 {
+		if src.Has(fd) {
+			dst.Set(fd, src.Get(fd))
+		} else {
+			dst.Clear(fd)
+		}
+		return true
+	}
+	if fd.Message() == nil || fd.IsList() || fd.IsMap() {
+		return false
+	}
+	return copyBookField(dst.Mutable(fd).Message(), src.Get(fd).Message(), path[1:])
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	pb "example/grpc_demo/library"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
+func TestApplyBookPatch(t *testing.T) {
+	book := &pb.Book{Id: "b1", Title: "Dune", Author: "Frank Herbert", Publisher: "Chilton", PublisherId: 3,
+		Location: &pb.Location{Branch: "Central", Section: "Fiction"}, Series: &pb.BookSeries{Name: "Dune", Volume: 1}}
+	patch := &pb.Book{Id: "b9", Title: "Dune Messiah", Publisher: "Ace", Location: &pb.Location{Branch: "North"}}
+	if err := applyBookPatch(book, patch, []string{"title", "publisher", "location.branch", "series"}); err != nil {
+		t.Fatalf("applyBookPatch() error = %v", err)
+	}
+	if book.GetId() != "b1" || book.GetTitle() != "Dune Messiah" || book.GetAuthor() != "Frank Herbert" {
+		t.Errorf("book = %v, want the new title only", book)
+	}
+	// The publisher is looked up by its new name, and a cleared series is removed
+	if book.GetPublisher() != "Ace" || book.GetPublisherId() != 0 || book.GetSeries() != nil {
+		t.Errorf("publisher %q (%d), series %v", book.GetPublisher(), book.GetPublisherId(), book.GetSeries())
+	}
+	if loc := book.GetLocation(); loc.GetBranch() != "North" || loc.GetSection() != "Fiction" {
+		t.Errorf("location = %v, want the new branch in the same section", loc)
+	}
+
+	for _, path := range []string{"id", "owner_id", "tags", "location.floor", "title.text"} {
+		err := applyBookPatch(&pb.Book{Id: "b1"}, patch, []string{path})
+		if status.Code(err) != codes.InvalidArgument {
+			t.Errorf("applyBookPatch(%s) error = %v, want InvalidArgument", path, err)
+		}
+	}
+}
+
+func TestApplyBookPatchEmptyMask(t *testing.T) {
+	book := &pb.Book{Id: "b1", Title: "Dune", Author: "Frank Herbert", Description: "Desert planet", TotalCopies: 3, PublisherId: 3}
+	// A book read earlier and sent back with a new title: its read-only fields are ignored, and unset fields
+	// are kept rather than cleared
+	patch := &pb.Book{Id: "b1", Title: "Dune Messiah", AvailableCopies: 1, OwnerId: 7, Tags: []string{"sf"}, TotalCopies: 4}
+	if err := applyBookPatch(book, patch, nil); err != nil {
+		t.Fatalf("applyBookPatch() error = %v", err)
+	}
+	want := &pb.Book{Id: "b1", Title: "Dune Messiah", Author: "Frank Herbert", Description: "Desert planet", TotalCopies: 4, PublisherId: 3}
+	if !proto.Equal(book, want) {
+		t.Errorf("book = %v, want %v", book, want)
+	}
+
+	// Without any field set, nothing changes
+	if err := applyBookPatch(book, &pb.Book{}, nil); err != nil || !proto.Equal(book, want) {
+		t.Errorf("applyBookPatch() of an empty book = %v, book %v", err, book)
+	}
+}
+
+func TestPatchBook(t *testing.T) {
+	books := newFakeBooks(&pb.Book{Id: "b1", Title: "Dune", Author: "Frank Herbert", TotalCopies: 3, AvailableCopies: 1})
+	s := newBookServer(books)
+	ctx := context.Background()
+
+	req := &pb.PatchBookRequest{Id: "b1", Book: &pb.Book{TotalCopies: 4}, UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"total_copies"}}}
+	if _, err := s.PatchBook(ctx, req); err != nil {
+		t.Fatalf("PatchBook() error = %v", err)
+	}
+	if b := books.books["b1"]; b.GetTitle() != "Dune" || b.GetTotalCopies() != 4 || b.GetAvailableCopies() != 2 {
+		t.Errorf("patched book = %v, want 2 of 4 copies available and the title kept", b)
+	}
+	if _, err := s.PatchBook(ctx, &pb.PatchBookRequest{Id: "b9", Book: &pb.Book{Title: "Emma"}}); status.Code(err) != codes.NotFound {
+		t.Errorf("PatchBook() of an unknown book error = %v, want NotFound", err)
+	}
+	// The book is validated as a whole once patched
+	if _, err := s.PatchBook(ctx, &pb.PatchBookRequest{Id: "b1", Book: &pb.Book{Isbn: "123"}, UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"isbn"}}}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("PatchBook() with an invalid ISBN error = %v, want InvalidArgument", err)
+	}
+
+	// Through the gateway, the fields of the body make the mask
+	mux := runtime.NewServeMux()
+	if err := pb.RegisterLibraryServiceHandlerServer(ctx, mux, s); err != nil {
+		t.Fatal(err)
+	}
+	r := httptest.NewRequest(http.MethodPatch, "/api/v1/books/b1", strings.NewReader(`{"author": "F. Herbert"}`))
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("PATCH /api/v1/books/b1: %d %s", w.Code, w.Body)
+	}
+	if b := books.books["b1"]; b.GetAuthor() != "F. Herbert" || b.GetTitle() != "Dune" || b.GetTotalCopies() != 4 {
+		t.Errorf("book patched through the gateway = %v, want only the author changed", b)
+	}
+
+	// The /v1 routes, which the /api/v1 ones remain bindings of
+	r = httptest.NewRequest(http.MethodPatch, "/v1/books/b1", strings.NewReader(`{"title": "Dune Messiah"}`))
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, r)
+	if b := books.books["b1"]; w.Code != http.StatusOK || b.GetTitle() != "Dune Messiah" || b.GetAuthor() != "F. Herbert" {
+		t.Errorf("PATCH /v1/books/b1: %d %s, book %v", w.Code, w.Body, b)
+	}
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/books/b1", nil))
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"title":"Dune Messiah"`) {
+		t.Errorf("GET /v1/books/b1: %d %s", w.Code, w.Body)
+	}
+}
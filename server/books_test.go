@@ -0,0 +1,620 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	pb "example/grpc_demo/library"
+	"example/grpc_demo/server/storage"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// fakeTx runs units of work without a transaction; the fakes apply changes immediately
+type fakeTx struct{}
+
+func (fakeTx) InTx(ctx context.Context, fn func(ctx context.Context) error) error { return fn(ctx) }
+
+// fakeBooks is a BookRepository over a map, failing Create with createErr when set
+type fakeBooks struct {
+	books     map[string]*pb.Book
+	createErr error
+	// listed is the filter of the last List call
+	listed storage.BookFilter
+	// estimate, when set, is returned by EstimateCount in place of counting the books
+	estimate int32
+}
+
+func newFakeBooks(books ...*pb.Book) *fakeBooks {
+	f := &fakeBooks{books: make(map[string]*pb.Book)}
+	for _, b := range books {
+		f.books[b.GetId()] = b
+	}
+	return f
+}
+
+func (f *fakeBooks) Exists(ctx context.Context, id string) (bool, error) {
+	_, ok := f.books[id]
+	return ok, nil
+}
+
+func (f *fakeBooks) FindByISBN(ctx context.Context, isbn string) (string, error) {
+	for id, b := range f.books {
+		if b.GetIsbn() == isbn {
+			return id, nil
+		}
+	}
+	return "", nil
+}
+
+func (f *fakeBooks) FindByTitleAuthorKey(ctx context.Context, titleKey, authorKey string) (string, error) {
+	for id, b := range f.books {
+		if storage.MatchKey(b.GetTitle()) == titleKey && storage.MatchKey(b.GetAuthor()) == authorKey {
+			return id, nil
+		}
+	}
+	return "", nil
+}
+
+func (f *fakeBooks) Create(ctx context.Context, book *pb.Book) error {
+	if f.createErr != nil {
+		return f.createErr
+	}
+	if _, ok := f.books[book.GetId()]; ok {
+		return storage.ErrAlreadyExists
+	}
+	f.books[book.GetId()] = proto.Clone(book).(*pb.Book)
+	return nil
+}
+
+func (f *fakeBooks) Match(ctx context.Context, keys []storage.BookKeys) ([]storage.BookMatches, error) {
+	matches := make([]storage.BookMatches, len(keys))
+	for i, k := range keys {
+		matches[i].Exists, _ = f.Exists(ctx, k.ID)
+		if k.ISBN != "" {
+			matches[i].ISBN, _ = f.FindByISBN(ctx, k.ISBN)
+		}
+		if k.TitleKey != "" {
+			matches[i].TitleAuthor, _ = f.FindByTitleAuthorKey(ctx, k.TitleKey, k.AuthorKey)
+		}
+	}
+	return matches, nil
+}
+
+func (f *fakeBooks) CreateMany(ctx context.Context, books []*pb.Book) ([]error, error) {
+	if f.createErr != nil {
+		return nil, f.createErr
+	}
+	results := make([]error, len(books))
+	for i, b := range books {
+		if _, ok := f.books[b.GetId()]; ok {
+			results[i] = storage.ErrAlreadyExists
+			continue
+		}
+		f.books[b.GetId()] = proto.Clone(b).(*pb.Book)
+	}
+	return results, nil
+}
+
+func (f *fakeBooks) Get(ctx context.Context, id string, languages []string) (*pb.Book, error) {
+	b, ok := f.books[id]
+	if !ok {
+		return nil, storage.ErrNotFound
+	}
+	return proto.Clone(b).(*pb.Book), nil
+}
+
+func (f *fakeBooks) GetForUpdate(ctx context.Context, id string) (*pb.Book, error) {
+	return f.Get(ctx, id, nil)
+}
+
+func (f *fakeBooks) List(ctx context.Context, filter storage.BookFilter, languages []string) ([]*pb.Book, error) {
+	f.listed = filter
+	var books []*pb.Book
+	for _, b := range f.books {
+		books = append(books, proto.Clone(b).(*pb.Book))
+	}
+	sort.Slice(books, func(i, j int) bool { return books[i].GetId() < books[j].GetId() })
+	return books, nil
+}
+
+func (f *fakeBooks) Each(ctx context.Context, fn func(*pb.Book) error) error {
+	books, _ := f.List(ctx, storage.BookFilter{}, nil)
+	for _, b := range books {
+		if err := fn(b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *fakeBooks) Count(ctx context.Context, filter storage.BookFilter) (int32, error) {
+	return int32(len(f.books)), nil
+}
+
+func (f *fakeBooks) EstimateCount(ctx context.Context) (int32, bool, error) {
+	if f.estimate != 0 {
+		return f.estimate, false, nil
+	}
+	return int32(len(f.books)), true, nil
+}
+
+func (f *fakeBooks) Update(ctx context.Context, old, book *pb.Book) error {
+	if book.GetPublisherId() != 0 {
+		return storage.ErrPublisherNotFound
+	}
+	f.books[book.GetId()] = proto.Clone(book).(*pb.Book)
+	return nil
+}
+
+func (f *fakeBooks) Delete(ctx context.Context, id string) (*pb.Book, error) {
+	b, ok := f.books[id]
+	if !ok {
+		return nil, storage.ErrNotFound
+	}
+	delete(f.books, id)
+	return b, nil
+}
+
+func (f *fakeBooks) History(ctx context.Context, id string) ([]*pb.BookRevision, error) {
+	return nil, nil
+}
+
+// newBookServer returns a server over books, checking new books for duplicates
+func newBookServer(books *fakeBooks) *server {
+	return &server{books: books, tx: fakeTx{}, settings: newSettings(&runtimeSettings{duplicates: defaultDuplicatePolicy})}
+}
+
+func TestAddBook(t *testing.T) {
+	books := newFakeBooks(&pb.Book{Id: "b1", Title: "Dune", Author: "Frank Herbert", Isbn: "9780441172719"})
+	s := newBookServer(books)
+	ctx := context.Background()
+
+	book := &pb.Book{Id: "b2", Title: "Emma", Author: "Jane Austen", Isbn: "978-0-14-143958-7", Location: &pb.Location{Branch: " Main "}}
+	if _, err := s.AddBook(ctx, book); err != nil {
+		t.Fatalf("AddBook() error = %v", err)
+	}
+	stored := books.books["b2"]
+	if stored.GetIsbn() != "9780141439587" || stored.GetLocation().GetBranch() != "Main" {
+		t.Errorf("stored book = %v, want its ISBN and location normalized", stored)
+	}
+	if stored.GetTotalCopies() != 1 || stored.GetAvailableCopies() != 1 {
+		t.Errorf("stored copies = %d/%d, want 1/1", stored.GetAvailableCopies(), stored.GetTotalCopies())
+	}
+
+	// Books without an ID are given a UUIDv7
+	resp, err := s.AddBook(ctx, &pb.Book{Title: "Untitled"})
+	if err != nil {
+		t.Fatalf("AddBook() without an ID error = %v", err)
+	}
+	if id, err := uuid.Parse(resp.GetId()); err != nil || id.Version() != 7 {
+		t.Errorf("generated ID = %q, want a UUIDv7", resp.GetId())
+	}
+	if books.books[resp.GetId()].GetTitle() != "Untitled" {
+		t.Errorf("book %s was not stored under its generated ID", resp.GetId())
+	}
+
+	tests := []struct {
+		name string
+		book *pb.Book
+		want codes.Code
+	}{
+		{"invalid isbn", &pb.Book{Id: "b3", Isbn: "12345"}, codes.InvalidArgument},
+		{"same id", &pb.Book{Id: "b1", Title: "Other"}, codes.AlreadyExists},
+		{"same isbn", &pb.Book{Id: "b3", Isbn: "978-0441172719"}, codes.AlreadyExists},
+		{"same title and author", &pb.Book{Id: "b3", Title: "DUNE", Author: "frank herbert"}, codes.AlreadyExists},
+	}
+	for _, tt := range tests {
+		if _, err := s.AddBook(ctx, tt.book); status.Code(err) != tt.want {
+			t.Errorf("%s: AddBook() error = %v, want %s", tt.name, err, tt.want)
+		}
+	}
+	if _, err := s.AddBook(ctx, &pb.Book{Id: "b3", Title: "DUNE", Author: "frank herbert", AllowDuplicate: true}); err != nil {
+		t.Errorf("allowed duplicate: AddBook() error = %v", err)
+	}
+	// A book sent again is reported as existing rather than as its own duplicate
+	_, err = s.AddBook(ctx, &pb.Book{Id: "b1", Title: "Dune", Author: "Frank Herbert", Isbn: "9780441172719"})
+	if st := status.Convert(err); st.Code() != codes.AlreadyExists || st.Message() != "Book already exists" {
+		t.Errorf("same book: AddBook() error = %v, want Book already exists", err)
+	}
+
+	// Failures of the repository map to the status of the book
+	books.createErr = storage.ErrAlreadyExists
+	if _, err := s.AddBook(ctx, &pb.Book{Id: "b4", Title: "Persuasion"}); status.Code(err) != codes.AlreadyExists {
+		t.Errorf("added meanwhile: AddBook() error = %v, want AlreadyExists", err)
+	}
+	books.createErr = storage.ErrPublisherNotFound
+	if _, err := s.AddBook(ctx, &pb.Book{Id: "b4", Title: "Persuasion", PublisherId: 9}); status.Code(err) != codes.NotFound {
+		t.Errorf("unknown publisher: AddBook() error = %v, want NotFound", err)
+	}
+}
+
+func TestAddBookConcurrently(t *testing.T) {
+	store := storage.NewMemory()
+	s := &server{books: store.Books(), tx: store, settings: newSettings(&runtimeSettings{duplicates: duplicatePolicy{}})}
+	var added atomic.Int32
+	var wg sync.WaitGroup
+	for range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := s.AddBook(context.Background(), &pb.Book{Id: "b1", Title: "Dune"})
+			switch status.Code(err) {
+			case codes.OK:
+				added.Add(1)
+			case codes.AlreadyExists:
+			default:
+				t.Errorf("AddBook() error = %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if added.Load() != 1 {
+		t.Errorf("%d calls added the book, want 1", added.Load())
+	}
+}
+
+func TestUpdateBook(t *testing.T) {
+	books := newFakeBooks(&pb.Book{Id: "b1", Title: "Dune", TotalCopies: 3, AvailableCopies: 1})
+	s := newBookServer(books)
+	ctx := context.Background()
+
+	if _, err := s.UpdateBook(ctx, &pb.Book{Id: "b1", Title: "Dune Messiah", TotalCopies: 4}); err != nil {
+		t.Fatalf("UpdateBook() error = %v", err)
+	}
+	if b := books.books["b1"]; b.GetTitle() != "Dune Messiah" || b.GetTotalCopies() != 4 || b.GetAvailableCopies() != 2 {
+		t.Errorf("updated book = %v, want 2 of 4 copies available", b)
+	}
+
+	tests := []struct {
+		name string
+		book *pb.Book
+		want codes.Code
+	}{
+		{"unknown book", &pb.Book{Id: "b9", Title: "Emma"}, codes.NotFound},
+		{"fewer copies than on loan", &pb.Book{Id: "b1", Title: "Dune", TotalCopies: 1}, codes.FailedPrecondition},
+		{"unknown publisher", &pb.Book{Id: "b1", Title: "Dune", PublisherId: 9}, codes.NotFound},
+	}
+	for _, tt := range tests {
+		if _, err := s.UpdateBook(ctx, tt.book); status.Code(err) != tt.want {
+			t.Errorf("%s: UpdateBook() error = %v, want %s", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestDeleteBook(t *testing.T) {
+	books := newFakeBooks(&pb.Book{Id: "b1", Title: "Dune"})
+	s := newBookServer(books)
+	ctx := context.Background()
+
+	if _, err := s.DeleteBook(ctx, &pb.BookRequest{Id: "b1"}); err != nil {
+		t.Fatalf("DeleteBook() error = %v", err)
+	}
+	if len(books.books) != 0 {
+		t.Errorf("books after delete = %v", books.books)
+	}
+	if _, err := s.DeleteBook(ctx, &pb.BookRequest{Id: "b1"}); status.Code(err) != codes.NotFound {
+		t.Errorf("DeleteBook() of a deleted book error = %v, want NotFound", err)
+	}
+}
+
+func TestBookOwners(t *testing.T) {
+	store := storage.NewMemory()
+	s := &server{books: store.Books(), tx: store, settings: newSettings(&runtimeSettings{duplicates: duplicatePolicy{}})}
+	as := func(userID int, role string) context.Context {
+		ctx := context.WithValue(context.Background(), userIDKey, userID)
+		ctx = context.WithValue(ctx, usernameKey, fmt.Sprint("user", userID))
+		return withActor(context.WithValue(ctx, roleKey, role))
+	}
+	alice, bob, admin := as(1, userRole), as(2, userRole), as(3, adminRole)
+
+	for _, id := range []string{"b1", "b2"} {
+		if _, err := s.AddBook(alice, &pb.Book{Id: id, Title: "Dune " + id}); err != nil {
+			t.Fatalf("AddBook() error = %v", err)
+		}
+	}
+	if _, err := s.UpdateBook(bob, &pb.Book{Id: "b1", Title: "Emma"}); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("UpdateBook() of another user's book error = %v, want PermissionDenied", err)
+	}
+	if _, err := s.DeleteBook(bob, &pb.BookRequest{Id: "b1"}); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("DeleteBook() of another user's book error = %v, want PermissionDenied", err)
+	}
+	if _, err := s.UpdateBook(alice, &pb.Book{Id: "b1", Title: "Dune Messiah"}); err != nil {
+		t.Errorf("UpdateBook() by the owner error = %v", err)
+	}
+	if _, err := s.DeleteBook(admin, &pb.BookRequest{Id: "b2"}); err != nil {
+		t.Errorf("DeleteBook() by an admin error = %v", err)
+	}
+}
+
+// historyStream records what a GetBookHistory call sends
+type historyStream struct {
+	grpc.ServerStream
+	ctx  context.Context
+	sent []*pb.BookRevision
+}
+
+func (s *historyStream) Context() context.Context { return s.ctx }
+
+func (s *historyStream) Send(rev *pb.BookRevision) error {
+	s.sent = append(s.sent, rev)
+	return nil
+}
+
+func TestBookHistory(t *testing.T) {
+	store := storage.NewMemory()
+	s := &server{books: store.Books(), tx: store, settings: newSettings(&runtimeSettings{duplicates: duplicatePolicy{}})}
+	ctx := context.WithValue(context.Background(), userIDKey, 1)
+	ctx = context.WithValue(ctx, usernameKey, "alice")
+	ctx = withActor(context.WithValue(ctx, roleKey, adminRole))
+
+	if _, err := s.AddBook(ctx, &pb.Book{Id: "b1", Title: "Dune"}); err != nil {
+		t.Fatalf("AddBook() error = %v", err)
+	}
+	if _, err := s.UpdateBook(ctx, &pb.Book{Id: "b1", Title: "Dune Messiah"}); err != nil {
+		t.Fatalf("UpdateBook() error = %v", err)
+	}
+	// A rejected update leaves no revision behind
+	if _, err := s.UpdateBook(ctx, &pb.Book{Id: "b1", Title: "Dune", Isbn: "12345"}); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("UpdateBook() with an invalid ISBN error = %v, want InvalidArgument", err)
+	}
+	if _, err := s.DeleteBook(ctx, &pb.BookRequest{Id: "b1"}); err != nil {
+		t.Fatalf("DeleteBook() error = %v", err)
+	}
+
+	stream := &historyStream{ctx: ctx}
+	if err := s.GetBookHistory(&pb.BookRequest{Id: "b1"}, stream); err != nil {
+		t.Fatalf("GetBookHistory() error = %v", err)
+	}
+	want := []struct {
+		action   pb.RevisionAction
+		old, new string
+	}{
+		{pb.RevisionAction_REVISION_ACTION_CREATE, "", "Dune"},
+		{pb.RevisionAction_REVISION_ACTION_UPDATE, "Dune", "Dune Messiah"},
+		{pb.RevisionAction_REVISION_ACTION_DELETE, "Dune Messiah", ""},
+	}
+	if len(stream.sent) != len(want) {
+		t.Fatalf("GetBookHistory() sent %d revisions, want %d: %v", len(stream.sent), len(want), stream.sent)
+	}
+	for i, w := range want {
+		rev := stream.sent[i]
+		if rev.GetAction() != w.action || rev.GetOldBook().GetTitle() != w.old || rev.GetNewBook().GetTitle() != w.new {
+			t.Errorf("revision %d = %v, want %s of %q to %q", i, rev, w.action, w.old, w.new)
+		}
+		if rev.GetBookId() != "b1" || rev.GetUsername() != "alice" {
+			t.Errorf("revision %d = %v, want book b1 changed by alice", i, rev)
+		}
+	}
+	if err := s.GetBookHistory(&pb.BookRequest{}, stream); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("GetBookHistory() without an ID error = %v, want InvalidArgument", err)
+	}
+}
+
+func TestGetAndListBooks(t *testing.T) {
+	books := newFakeBooks(&pb.Book{Id: "b1", Title: "Dune"}, &pb.Book{Id: "b2", Title: "Emma"})
+	s := newBookServer(books)
+	ctx := context.Background()
+
+	if book, err := s.GetBook(ctx, &pb.BookRequest{Id: "b2"}); err != nil || book.GetTitle() != "Emma" {
+		t.Errorf("GetBook() = %v, %v", book, err)
+	}
+	_, err := s.GetBook(ctx, &pb.BookRequest{Id: "b9"})
+	checkResourceError(t, err, codes.NotFound, resourceBook, "b9")
+
+	resp, err := s.ListBooks(ctx, &pb.ListBookRequest{Tags: []string{" SF", "sf"}, Branch: " Main ", PageSize: 10, Page: 2})
+	if err != nil || resp.GetTotalCount() != 2 || len(resp.GetBooks()) != 2 {
+		t.Fatalf("ListBooks() = %v, %v", resp, err)
+	}
+	// One book more than the page is fetched, to tell whether another page follows
+	want := storage.BookFilter{Tags: []string{"sf"}, Branch: "Main", Limit: 11, Offset: 10}
+	if got := books.listed; len(got.Tags) != 1 || got.Tags[0] != want.Tags[0] || got.Branch != want.Branch || got.Limit != want.Limit || got.Offset != want.Offset {
+		t.Errorf("ListBooks() filter = %+v, want %+v", got, want)
+	}
+	if resp.GetTotalCountEstimated() {
+		t.Error("ListBooks() with filters estimated its total")
+	}
+
+	// Only unfiltered listings are estimated, unless the exact count is asked for
+	books.estimate = 1000
+	if resp, err := s.ListBooks(ctx, &pb.ListBookRequest{}); err != nil || resp.GetTotalCount() != 1000 || !resp.GetTotalCountEstimated() {
+		t.Errorf("ListBooks() of every book = %v, %v, want an estimate of 1000", resp, err)
+	}
+	if resp, err := s.ListBooks(ctx, &pb.ListBookRequest{ExactCount: true}); err != nil || resp.GetTotalCount() != 2 || resp.GetTotalCountEstimated() {
+		t.Errorf("ListBooks() with exact_count = %v, %v, want 2 counted", resp, err)
+	}
+}
+
+// batchAdd sends books to BatchAddBooks of s, with ctx as the context of the stream
+func batchAdd(t *testing.T, s *server, ctx context.Context, books ...*pb.Book) ([]*pb.BookResponse, error) {
+	t.Helper()
+	stream := &batchStream{ctx: ctx, books: books}
+	if err := s.BatchAddBooks(&grpc.GenericServerStream[pb.Book, pb.BatchResponse]{ServerStream: stream}); err != nil {
+		return nil, err
+	}
+	if len(stream.sent) != 1 {
+		t.Fatalf("BatchAddBooks() sent %d responses, want 1", len(stream.sent))
+	}
+	return stream.sent[0].(*pb.BatchResponse).GetResponses(), nil
+}
+
+func TestBatchAddBooks(t *testing.T) {
+	books := newFakeBooks(&pb.Book{Id: "b1", Title: "Dune", Author: "Frank Herbert", Isbn: "9780441172719"})
+	s := newBookServer(books)
+
+	sent := []*pb.Book{
+		{Id: "b2", Title: "Emma", Author: "Jane Austen", Isbn: "9780141439587"},
+		{Id: "b3", Title: "Emma (annotated)", Isbn: "978-0-14-143958-7"},
+		{Id: "b2", Title: "Persuasion"},
+		{Id: "b4", Title: "DUNE", Author: "Frank Herbert", AllowDuplicate: true},
+		{Id: "b7", Title: "Untitled", TotalCopies: -1},
+		{Title: "Untitled"},
+	}
+	// Enough books for a second round, whose last duplicates a book of the first
+	for i := len(sent); i < batchSize; i++ {
+		sent = append(sent, &pb.Book{Id: fmt.Sprintf("f%03d", i), Title: fmt.Sprintf("Filler %d", i)})
+	}
+	sent = append(sent, &pb.Book{Id: "b5", Title: "emma", Author: "JANE AUSTEN"}, &pb.Book{Id: "b6", Title: "Sanditon"})
+
+	resps, err := batchAdd(t, s, context.Background(), sent...)
+	if err != nil {
+		t.Fatalf("BatchAddBooks() error = %v", err)
+	}
+	if len(resps) != len(sent) {
+		t.Fatalf("BatchAddBooks() returned %d responses, want %d", len(resps), len(sent))
+	}
+	want := map[int]codes.Code{1: codes.AlreadyExists, 2: codes.AlreadyExists, 4: codes.InvalidArgument, len(sent) - 2: codes.AlreadyExists}
+	for i, r := range resps {
+		if got := codes.Code(r.GetError().GetCode()); got != want[i] {
+			t.Errorf("response %d (%s) = %v, want %v", i, r.GetId(), got, want[i])
+		}
+	}
+	if _, ok := books.books[resps[5].GetId()]; !ok || resps[5].GetId() == "" {
+		t.Errorf("book without an ID reported as %q, want it stored under a generated ID", resps[5].GetId())
+	}
+	if got := status.FromProto(resps[1].GetError()).Message(); !strings.Contains(got, "b2 (same ISBN)") {
+		t.Errorf("response of a duplicate within the batch = %q, want it to name b2", got)
+	}
+	if got := len(books.books); got != len(sent)-3 {
+		t.Errorf("%d books stored, want %d", got, len(sent)-3)
+	}
+	if b := books.books["b2"]; b.GetAvailableCopies() != 1 || b.GetIsbn() != "9780141439587" {
+		t.Errorf("stored book = %v, want it prepared as by AddBook", b)
+	}
+}
+
+func TestBatchAddBooksAtomically(t *testing.T) {
+	store := storage.NewMemory()
+	s := &server{books: store.Books(), tx: store, settings: newSettings(&runtimeSettings{duplicates: defaultDuplicatePolicy})}
+	atomic := metadata.NewIncomingContext(context.Background(), metadata.Pairs(batchAtomicHeader, "true"))
+
+	// The second book duplicates the first, which was only added within the transaction
+	resps, err := batchAdd(t, s, atomic,
+		&pb.Book{Id: "b1", Title: "Dune", Author: "Frank Herbert"},
+		&pb.Book{Id: "b2", Title: "DUNE", Author: "Frank Herbert"},
+		&pb.Book{Id: "b3", Title: "Emma", Author: "Jane Austen"})
+	if err != nil {
+		t.Fatalf("BatchAddBooks() error = %v", err)
+	}
+	want := []codes.Code{codes.Aborted, codes.AlreadyExists, codes.Aborted}
+	if len(resps) != len(want) {
+		t.Fatalf("BatchAddBooks() responses = %v, want %d", resps, len(want))
+	}
+	for i, r := range resps {
+		if got := codes.Code(r.GetError().GetCode()); got != want[i] {
+			t.Errorf("response %d (%s) = %v, want %v", i, r.GetId(), got, want[i])
+		}
+	}
+	if n, _ := store.Books().Count(context.Background(), storage.BookFilter{}); n != 0 {
+		t.Errorf("%d books kept after a rolled back batch, want 0", n)
+	}
+
+	resps, err = batchAdd(t, s, atomic, &pb.Book{Id: "b1", Title: "Dune"}, &pb.Book{Id: "b3", Title: "Emma"})
+	if err != nil || len(resps) != 2 || resps[0].GetError() != nil || resps[1].GetError() != nil {
+		t.Fatalf("BatchAddBooks() = %v, %v", resps, err)
+	}
+	if n, _ := store.Books().Count(context.Background(), storage.BookFilter{}); n != 2 {
+		t.Errorf("%d books kept after a batch, want 2", n)
+	}
+
+	// Without the header each book is added on its own
+	resps, err = batchAdd(t, s, context.Background(), &pb.Book{Id: "b1", Title: "Dune"}, &pb.Book{Id: "b4", Title: "Persuasion"})
+	if err != nil || len(resps) != 2 || codes.Code(resps[0].GetError().GetCode()) != codes.AlreadyExists || resps[1].GetError() != nil {
+		t.Errorf("BatchAddBooks() = %v, %v", resps, err)
+	}
+
+	bad := metadata.NewIncomingContext(context.Background(), metadata.Pairs(batchAtomicHeader, "always"))
+	if _, err := batchAdd(t, s, bad); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("BatchAddBooks() with an invalid header error = %v, want InvalidArgument", err)
+	}
+}
+
+func TestBulkUpdateBooks(t *testing.T) {
+	store := storage.NewMemory()
+	s := &server{books: store.Books(), tx: store, settings: newSettings(&runtimeSettings{duplicates: duplicatePolicy{}})}
+	ctx := context.WithValue(context.Background(), userIDKey, 1)
+	ctx = context.WithValue(ctx, usernameKey, "alice")
+	ctx = withActor(context.WithValue(ctx, roleKey, adminRole))
+	for _, b := range []*pb.Book{{Id: "b1", Title: "Dune", TotalCopies: 2}, {Id: "b2", Title: "Emma"}} {
+		if _, err := s.AddBook(ctx, b); err != nil {
+			t.Fatalf("AddBook() error = %v", err)
+		}
+	}
+	bulkUpdate := func(books ...*pb.Book) []*pb.BookResponse {
+		t.Helper()
+		resp, err := s.BulkUpdateBooks(ctx, &pb.BulkUpdateRequest{Books: books})
+		if err != nil {
+			t.Fatalf("BulkUpdateBooks() error = %v", err)
+		}
+		return resp.GetResponses()
+	}
+	titles := func() string {
+		b1, _ := store.Books().Get(context.Background(), "b1", nil)
+		b2, _ := store.Books().Get(context.Background(), "b2", nil)
+		return b1.GetTitle() + ", " + b2.GetTitle()
+	}
+
+	// The failure of one entry undoes the updates made before and after it
+	for _, bad := range []*pb.Book{{Id: "b9", Title: "Persuasion"}, {Id: "b2", Title: "Emma", Isbn: "12345"}} {
+		resps := bulkUpdate(&pb.Book{Id: "b1", Title: "Dune Messiah"}, bad, &pb.Book{Id: "b2", Title: "Emma (annotated)"})
+		want := []codes.Code{codes.Aborted, codes.NotFound, codes.Aborted}
+		if bad.GetId() == "b2" {
+			want[1] = codes.InvalidArgument
+		}
+		for i, r := range resps {
+			if got := codes.Code(r.GetError().GetCode()); got != want[i] {
+				t.Errorf("response %d (%s) = %v, want %v", i, r.GetId(), got, want[i])
+			}
+		}
+		if got := titles(); got != "Dune, Emma" {
+			t.Errorf("books after a rolled back update of %s = %s, want Dune, Emma", bad.GetId(), got)
+		}
+	}
+
+	resps := bulkUpdate(&pb.Book{Id: "b1", Title: "Dune Messiah"}, &pb.Book{Id: "b2", Title: "Emma (annotated)", TotalCopies: 3})
+	if len(resps) != 2 || resps[0].GetError() != nil || resps[1].GetError() != nil {
+		t.Fatalf("BulkUpdateBooks() = %v", resps)
+	}
+	if got := titles(); got != "Dune Messiah, Emma (annotated)" {
+		t.Errorf("books after a bulk update = %s", got)
+	}
+	if b2, _ := store.Books().Get(context.Background(), "b2", nil); b2.GetTotalCopies() != 3 || b2.GetAvailableCopies() != 3 {
+		t.Errorf("b2 after a bulk update = %v, want 3 of 3 copies available", b2)
+	}
+
+	if _, err := s.BulkUpdateBooks(ctx, &pb.BulkUpdateRequest{}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("BulkUpdateBooks() without books error = %v, want InvalidArgument", err)
+	}
+}
+
+func TestSearchBooks(t *testing.T) {
+	store, err := newDemoStore(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &server{books: store.Books(), tx: store}
+	ctx := context.Background()
+
+	resp, err := s.SearchBooks(ctx, &pb.SearchBooksRequest{Query: "austen", PageSize: 1})
+	if err != nil || resp.GetTotalCount() != 2 || len(resp.GetBooks()) != 1 {
+		t.Fatalf("SearchBooks() = %v, %v", resp, err)
+	}
+	if resp, _ := s.SearchBooks(ctx, &pb.SearchBooksRequest{Query: "Pride jane"}); len(resp.GetBooks()) != 1 || resp.GetBooks()[0].GetId() != "pride-and-prejudice" {
+		t.Errorf("SearchBooks() = %v, want Pride and Prejudice", resp)
+	}
+	for _, query := range []string{"", " ?! ", strings.Repeat("word ", 50)} {
+		if _, err := s.SearchBooks(ctx, &pb.SearchBooksRequest{Query: query}); status.Code(err) != codes.InvalidArgument {
+			t.Errorf("SearchBooks(%q) error = %v, want InvalidArgument", query, err)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	pb "example/grpc_demo/library"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestSiteverifyChallenge(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.PostFormValue("secret") != "shh" {
+			w.Write([]byte(`{"success":false,"error-codes":["invalid-input-secret"]}`))
+			return
+		}
+		switch r.PostFormValue("response") {
+		case "good":
+			if r.PostFormValue("remoteip") != "203.0.113.7" {
+				t.Errorf("remoteip = %q, want 203.0.113.7", r.PostFormValue("remoteip"))
+			}
+			w.Write([]byte(`{"success":true}`))
+		case "broken":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			w.Write([]byte(`{"success":false,"error-codes":["invalid-input-response"]}`))
+		}
+	}))
+	defer ts.Close()
+
+	c := &siteverifyChallenge{verifyURL: ts.URL, secret: "shh", client: ts.Client()}
+	if err := c.Verify(context.Background(), "good", "203.0.113.7"); err != nil {
+		t.Errorf("Verify(good) error = %v", err)
+	}
+	if err := c.Verify(context.Background(), "bot", ""); !errors.Is(err, errChallengeFailed) {
+		t.Errorf("Verify(bot) error = %v, want errChallengeFailed", err)
+	}
+	if err := c.Verify(context.Background(), "broken", ""); err == nil || errors.Is(err, errChallengeFailed) {
+		t.Errorf("Verify(broken) error = %v, want a provider error", err)
+	}
+}
+
+// stubChallenge accepts only the token "human"
+type stubChallenge struct{ err error }
+
+func (c stubChallenge) Verify(ctx context.Context, token, remoteIP string) error {
+	if c.err != nil {
+		return c.err
+	}
+	if token != "human" {
+		return errChallengeFailed
+	}
+	return nil
+}
+
+func TestVerifyChallenge(t *testing.T) {
+	if err := (&server{}).verifyChallenge(context.Background(), ""); err != nil {
+		t.Errorf("verifyChallenge() without a provider error = %v", err)
+	}
+	tests := []struct {
+		name      string
+		challenge challengeVerifier
+		token     string
+		want      codes.Code
+	}{
+		{"missing token", stubChallenge{}, "", codes.InvalidArgument},
+		{"rejected token", stubChallenge{}, "bot", codes.PermissionDenied},
+		{"provider down", stubChallenge{err: errors.New("connection refused")}, "human", codes.Unavailable},
+		{"accepted token", stubChallenge{}, "human", codes.OK},
+	}
+	for _, tt := range tests {
+		err := (&server{challenge: tt.challenge}).verifyChallenge(context.Background(), tt.token)
+		if status.Code(err) != tt.want {
+			t.Errorf("%s: verifyChallenge() error = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+
+	// Register checks the challenge before touching the database
+	_, err := (&server{challenge: stubChallenge{}}).Register(context.Background(), &pb.User{Username: "bot", Password: "password123"})
+	assertAuthError(t, err, codes.InvalidArgument, reasonChallengeRequired)
+}
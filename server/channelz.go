@@ -0,0 +1,25 @@
+package main
+
+import (
+	"google.golang.org/grpc"
+	channelzsvc "google.golang.org/grpc/channelz/service"
+)
+
+// channelzMethods are the methods of the gRPC channelz service, which reports the server's live connections,
+// streams and socket statistics
+const channelzMethods = "/grpc.channelz.v1.Channelz/*"
+
+// enableChannelz registers the channelz service on s. Its statistics reveal who is connected, so unless the
+// authorization policy says otherwise only administrators and services authenticated by certificate may
+// read them.
+func enableChannelz(s grpc.ServiceRegistrar, policy *authPolicy) {
+	channelzsvc.RegisterChannelzServiceToServer(s)
+	restrictChannelz(policy)
+}
+
+// restrictChannelz limits the channelz service to administrators and services, unless policy has a rule for it
+func restrictChannelz(policy *authPolicy) {
+	if _, ok := policy.Methods[channelzMethods]; !ok {
+		policy.Methods[channelzMethods] = authRule{Access: accessAuthenticated, Roles: []string{adminRole, serviceRole}}
+	}
+}
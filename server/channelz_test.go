@@ -0,0 +1,33 @@
+package main
+
+import (
+	"slices"
+	"testing"
+
+	"google.golang.org/grpc"
+)
+
+func TestEnableChannelz(t *testing.T) {
+	s := grpc.NewServer()
+	policy := &authPolicy{Default: authRule{Access: accessAuthenticated}, Methods: make(map[string]authRule)}
+	enableChannelz(s, policy)
+
+	if _, ok := s.GetServiceInfo()["grpc.channelz.v1.Channelz"]; !ok {
+		t.Fatal("channelz service not registered")
+	}
+	if err := policy.checkMethods(s.GetServiceInfo()); err != nil {
+		t.Errorf("checkMethods() error = %v", err)
+	}
+	rule := policy.rule("/grpc.channelz.v1.Channelz/GetServers")
+	if !slices.Contains(rule.Roles, adminRole) || slices.Contains(rule.Roles, userRole) {
+		t.Errorf("channelz rule = %+v, want admins only", rule)
+	}
+
+	// A rule from AUTH_POLICY_FILE is kept
+	policy = defaultAuthPolicy()
+	policy.Methods[channelzMethods] = authRule{Access: accessAuthenticated}
+	enableChannelz(grpc.NewServer(), policy)
+	if rule := policy.rule("/grpc.channelz.v1.Channelz/GetServers"); len(rule.Roles) != 0 {
+		t.Errorf("channelz rule = %+v, want the configured one", rule)
+	}
+}
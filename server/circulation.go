@@ -0,0 +1,194 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	pb "example/grpc_demo/library"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// runCirculation sweeps loans and reservations every interval until ctx is cancelled
+func (s *server) runCirculation(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := forEachTenant(ctx, s.db, s.sweepCirculation); err != nil {
+			slog.ErrorContext(ctx, "circulation sweep failed", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// sweepCirculation reminds borrowers of loans due soon, marks late loans overdue,
+// accrues their fines and expires uncollected holds
+func (s *server) sweepCirculation(ctx context.Context) error {
+	if err := s.remindDueLoans(ctx); err != nil {
+		return fmt.Errorf("due reminders: %w", err)
+	}
+	if err := s.accrueOverdueFines(ctx); err != nil {
+		return fmt.Errorf("overdue loans: %w", err)
+	}
+	if err := s.expireHolds(ctx); err != nil {
+		return fmt.Errorf("expired holds: %w", err)
+	}
+	return nil
+}
+
+// circulationEvent is a loan or reservation change the sweep notifies its owner about
+type circulationEvent struct {
+	id     int64
+	userID int
+	bookID string
+	title  string
+}
+
+// collectCirculationEvents reads rows of (id, user_id, book_id, title)
+func collectCirculationEvents(rows pgx.Rows) ([]circulationEvent, error) {
+	defer rows.Close()
+	var events []circulationEvent
+	for rows.Next() {
+		var e circulationEvent
+		if err := rows.Scan(&e.id, &e.userID, &e.bookID, &e.title); err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
+
+// remindDueLoans notifies borrowers once when a loan is due within a day
+func (s *server) remindDueLoans(ctx context.Context) error {
+	rows, err := s.db.Query(ctx,
+		`UPDATE loans l SET due_reminder_sent=TRUE FROM books b
+		 WHERE b.id = l.book_id AND l.returned_at IS NULL AND NOT l.due_reminder_sent
+			AND l.due_at > NOW() AND l.due_at <= NOW() + INTERVAL '1 day'
+		 RETURNING l.id, l.user_id, l.book_id, b.title`)
+	if err != nil {
+		return err
+	}
+	due, err := collectCirculationEvents(rows)
+	if err != nil {
+		return err
+	}
+	for _, e := range due {
+		s.notifications.publish(recipientOf(ctx, e.userID), &pb.Notification{
+			Type:        pb.NotificationType_NOTIFICATION_TYPE_LOAN_DUE_SOON,
+			Message:     fmt.Sprintf("%q is due back tomorrow", e.title),
+			BookId:      e.bookID,
+			ReferenceId: e.id,
+		})
+	}
+	return nil
+}
+
+// accrueOverdueFines flags unreturned loans past their due date and brings their fines up to date
+func (s *server) accrueOverdueFines(ctx context.Context) error {
+	rows, err := s.db.Query(ctx,
+		`UPDATE loans l SET overdue=TRUE FROM books b
+		 WHERE b.id = l.book_id AND l.returned_at IS NULL AND l.due_at < NOW() AND NOT l.overdue
+		 RETURNING l.id, l.user_id, l.book_id, b.title`)
+	if err != nil {
+		return err
+	}
+	overdue, err := collectCirculationEvents(rows)
+	if err != nil {
+		return err
+	}
+	for _, e := range overdue {
+		s.notifications.publish(recipientOf(ctx, e.userID), &pb.Notification{
+			Type:        pb.NotificationType_NOTIFICATION_TYPE_LOAN_OVERDUE,
+			Message:     fmt.Sprintf("%q is overdue; fines are accruing", e.title),
+			BookId:      e.bookID,
+			ReferenceId: e.id,
+		})
+	}
+
+	type lateLoan struct {
+		id     int64
+		userID int
+		dueAt  time.Time
+	}
+	rows, err = s.db.Query(ctx, "SELECT id, user_id, due_at FROM loans WHERE returned_at IS NULL AND overdue")
+	if err != nil {
+		return err
+	}
+	var late []lateLoan
+	for rows.Next() {
+		var l lateLoan
+		if err := rows.Scan(&l.id, &l.userID, &l.dueAt); err != nil {
+			rows.Close()
+			return err
+		}
+		late = append(late, l)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, l := range late {
+		if err := s.fines.assessFine(ctx, s.db, l.id, l.userID, l.dueAt, now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// expireHolds lapses ready reservations nobody collected and passes their copies down the queue
+func (s *server) expireHolds(ctx context.Context) error {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx,
+		`UPDATE reservations r SET status='expired' FROM books b
+		 WHERE b.id = r.book_id AND r.status='ready' AND r.expires_at <= NOW()
+		 RETURNING r.id, r.user_id, r.book_id, b.title`)
+	if err != nil {
+		return err
+	}
+	expired, err := collectCirculationEvents(rows)
+	if err != nil {
+		return err
+	}
+
+	var promoted []int64
+	for _, e := range expired {
+		id, err := releaseCopy(ctx, tx, e.bookID)
+		if err != nil {
+			return err
+		}
+		promoted = append(promoted, id)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return err
+	}
+	// The sweep runs outside any call, so the cache interceptor doesn't see the copies it put back
+	if len(expired) > 0 {
+		s.cache.invalidate()
+	}
+
+	for _, e := range expired {
+		s.notifications.publish(recipientOf(ctx, e.userID), &pb.Notification{
+			Type:        pb.NotificationType_NOTIFICATION_TYPE_HOLD_EXPIRED,
+			Message:     fmt.Sprintf("Your hold on %q expired before it was collected", e.title),
+			BookId:      e.bookID,
+			ReferenceId: e.id,
+		})
+	}
+	for _, id := range promoted {
+		s.notifyHoldReady(ctx, id)
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// concurrencyRetryDelay is the RetryInfo sent with calls turned away by the concurrency limits
+const concurrencyRetryDelay = time.Second
+
+// unlimitedServices are never turned away, so that a saturated server isn't taken for a dead one
+var unlimitedServices = []string{"/grpc.health.v1.Health/"}
+
+// checkMethods reports a limit set for a method the server doesn't have, most likely a typo
+func (c ConcurrencyConfig) checkMethods(services map[string]grpc.ServiceInfo) error {
+	for method := range c.Methods {
+		if !hasMethod(services, method) {
+			return fmt.Errorf("concurrency limit set for unknown method %q", method)
+		}
+	}
+	return nil
+}
+
+// concurrencyLimiter holds a semaphore, a channel with a slot per call allowed at once, for the global
+// limit and for each method with a limit of its own
+type concurrencyLimiter struct {
+	global  chan struct{}
+	methods map[string]chan struct{}
+}
+
+func newConcurrencyLimiter(cfg ConcurrencyConfig) *concurrencyLimiter {
+	l := &concurrencyLimiter{methods: make(map[string]chan struct{})}
+	if cfg.Max > 0 {
+		l.global = make(chan struct{}, cfg.Max)
+	}
+	for method, n := range cfg.Methods {
+		l.methods[method] = make(chan struct{}, n)
+	}
+	return l
+}
+
+// acquire takes a slot for a call to method, without waiting, and returns the func that gives it back. A
+// saturated limit fails the call with ResourceExhausted.
+func (l *concurrencyLimiter) acquire(method string, streaming bool) (release func(), err error) {
+	for _, prefix := range unlimitedServices {
+		if strings.HasPrefix(method, prefix) {
+			return func() {}, nil
+		}
+	}
+	var held []chan struct{}
+	release = func() {
+		for _, sem := range held {
+			<-sem
+		}
+	}
+	for _, sem := range []chan struct{}{l.methods[method], l.global} {
+		if sem == nil || (streaming && sem == l.global) {
+			continue
+		}
+		select {
+		case sem <- struct{}{}:
+			held = append(held, sem)
+		default:
+			release()
+			subject := "server"
+			if sem != l.global {
+				subject = method
+			}
+			concurrencyRejections.WithLabelValues(method).Inc()
+			return nil, withDetails(status.New(codes.ResourceExhausted, "the server is handling too many requests; retry later"),
+				&errdetails.QuotaFailure{Violations: []*errdetails.QuotaFailure_Violation{{Subject: subject, Description: "concurrent requests"}}},
+				&errdetails.RetryInfo{RetryDelay: durationpb.New(concurrencyRetryDelay)})
+		}
+	}
+	return release, nil
+}
+
+// CreateConcurrencyInterceptor turns away unary calls over the concurrency limits
+func CreateConcurrencyInterceptor(limiter *concurrencyLimiter) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		release, err := limiter.acquire(info.FullMethod, false)
+		if err != nil {
+			return nil, err
+		}
+		defer release()
+		return handler(ctx, req)
+	}
+}
+
+// CreateStreamConcurrencyInterceptor turns away streaming calls over the limit of their method
+func CreateStreamConcurrencyInterceptor(limiter *concurrencyLimiter) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		release, err := limiter.acquire(info.FullMethod, true)
+		if err != nil {
+			return err
+		}
+		defer release()
+		return handler(srv, ss)
+	}
+}
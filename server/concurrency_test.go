@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	pb "example/grpc_demo/library"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestConcurrencyLimiter(t *testing.T) {
+	limiter := newConcurrencyLimiter(ConcurrencyConfig{
+		Max:     2,
+		Methods: map[string]int{pb.LibraryService_ListBooks_FullMethodName: 1, pb.NotificationService_Subscribe_FullMethodName: 1},
+	})
+
+	releaseList, err := limiter.acquire(pb.LibraryService_ListBooks_FullMethodName, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// ListBooks is at its own limit while the server still has room
+	if _, err := limiter.acquire(pb.LibraryService_ListBooks_FullMethodName, false); status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("second ListBooks: error = %v, want ResourceExhausted", err)
+	}
+	releaseGet, err := limiter.acquire(pb.LibraryService_GetBook_FullMethodName, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = limiter.acquire(pb.LibraryService_GetBook_FullMethodName, false)
+	st := status.Convert(err)
+	if st.Code() != codes.ResourceExhausted {
+		t.Fatalf("third call: error = %v, want ResourceExhausted", err)
+	}
+	var retry *errdetails.RetryInfo
+	for _, d := range st.Details() {
+		if d, ok := d.(*errdetails.RetryInfo); ok {
+			retry = d
+		}
+	}
+	if retry.GetRetryDelay().AsDuration() != concurrencyRetryDelay {
+		t.Errorf("RetryInfo = %v", retry)
+	}
+
+	// Streams and health checks don't count against the global limit
+	releaseStream, err := limiter.acquire(pb.NotificationService_Subscribe_FullMethodName, true)
+	if err != nil {
+		t.Errorf("Subscribe: error = %v", err)
+	}
+	if _, err := limiter.acquire(pb.NotificationService_Subscribe_FullMethodName, true); status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("second Subscribe: error = %v, want ResourceExhausted", err)
+	}
+	if _, err := limiter.acquire("/grpc.health.v1.Health/Check", false); err != nil {
+		t.Errorf("health check: error = %v", err)
+	}
+
+	releaseList()
+	releaseGet()
+	releaseStream()
+	if _, err := limiter.acquire(pb.LibraryService_ListBooks_FullMethodName, false); err != nil {
+		t.Errorf("after release: error = %v", err)
+	}
+	if len(limiter.global) != 1 || len(limiter.methods[pb.LibraryService_ListBooks_FullMethodName]) != 1 {
+		t.Errorf("slots held: %d global, %d ListBooks; want 1 each", len(limiter.global), len(limiter.methods[pb.LibraryService_ListBooks_FullMethodName]))
+	}
+}
+
+func TestConcurrencyLimiterRejectionReleasesMethodSlot(t *testing.T) {
+	limiter := newConcurrencyLimiter(ConcurrencyConfig{Max: 1, Methods: map[string]int{pb.LibraryService_ListBooks_FullMethodName: 1}})
+	if _, err := limiter.acquire(pb.LibraryService_GetBook_FullMethodName, false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := limiter.acquire(pb.LibraryService_ListBooks_FullMethodName, false); status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("error = %v, want ResourceExhausted", err)
+	}
+	if n := len(limiter.methods[pb.LibraryService_ListBooks_FullMethodName]); n != 0 {
+		t.Errorf("the rejected call kept %d ListBooks slots", n)
+	}
+}
+
+func TestConcurrencyInterceptor(t *testing.T) {
+	limiter := newConcurrencyLimiter(ConcurrencyConfig{Max: 1})
+	interceptor := CreateConcurrencyInterceptor(limiter)
+	info := &grpc.UnaryServerInfo{FullMethod: pb.LibraryService_GetBook_FullMethodName}
+	_, err := interceptor(context.Background(), nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		// The call holds the only slot while it runs
+		_, err := interceptor(ctx, nil, info, func(context.Context, interface{}) (interface{}, error) { return nil, nil })
+		return nil, err
+	})
+	if status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("nested call: error = %v, want ResourceExhausted", err)
+	}
+	if len(limiter.global) != 0 {
+		t.Error("the slot was not given back")
+	}
+}
+
+func TestConcurrencyCheckMethods(t *testing.T) {
+	services := map[string]grpc.ServiceInfo{"library.LibraryService": {Methods: []grpc.MethodInfo{{Name: "ListBooks"}}}}
+	if err := (ConcurrencyConfig{Methods: map[string]int{pb.LibraryService_ListBooks_FullMethodName: 5}}).checkMethods(services); err != nil {
+		t.Errorf("checkMethods() error = %v", err)
+	}
+	if err := (ConcurrencyConfig{Methods: map[string]int{"/library.LibraryService/ListBook": 5}}).checkMethods(services); err == nil {
+		t.Error("a misspelled method was accepted")
+	}
+}
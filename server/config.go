@@ -0,0 +1,1033 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"net/url"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/keepalive"
+	"gopkg.in/yaml.v3"
+)
+
+// defaultJWTSecret signs tokens when no secret is configured; it is only fit for development
+const defaultJWTSecret = "your-super-secure-secret-key-change-this-in-production"
+
+// Config holds the settings the server needs to start: where it listens, the database, and how tokens and
+// passwords are protected. Every setting has a default, overridden in turn by the YAML file named by -config
+// (or CONFIG_FILE), by its environment variable and by its command-line flag.
+type Config struct {
+	GRPC           GRPCConfig         `yaml:"grpc"`
+	Gateway        GatewayConfig      `yaml:"gateway"`
+	Database       DatabaseConfig     `yaml:"database"`
+	Auth           AuthConfig         `yaml:"auth"`
+	Timeouts       TimeoutConfig      `yaml:"timeouts"`
+	Concurrency    ConcurrencyConfig  `yaml:"concurrency"`
+	Cache          CacheConfig        `yaml:"cache"`
+	Shadow         ShadowConfig       `yaml:"shadow"`
+	Idempotency    IdempotencyConfig  `yaml:"idempotency"`
+	Quotas         QuotaConfig        `yaml:"quotas"`
+	Notifications  NotificationConfig `yaml:"notifications"`
+	Purge          PurgeConfig        `yaml:"purge"`
+	Retention      RetentionConfig    `yaml:"retention"`
+	Outbox         OutboxConfig       `yaml:"outbox"`
+	Tenants        TenantConfig       `yaml:"tenants"`
+	Storage        StorageConfig      `yaml:"storage"`
+	Logging        LoggingConfig      `yaml:"logging"`
+	TLS            TLSConfig          `yaml:"tls"`
+	Passwords      PasswordConfig     `yaml:"passwords"`
+	RateLimits     RateLimitConfig    `yaml:"rate_limits"`
+	Challenge      ChallengeConfig    `yaml:"challenge"`
+	OIDC           OIDCConfig         `yaml:"oidc"`
+	Mail           MailConfig         `yaml:"mail"`
+	Circulation    CirculationConfig  `yaml:"circulation"`
+	Catalog        CatalogConfig      `yaml:"catalog"`
+	ErrorReporting ErrorReportConfig  `yaml:"error_reporting"`
+}
+
+// GRPCConfig is where the gRPC server listens, the largest messages it accepts and sends, and how it keeps
+// connections alive
+type GRPCConfig struct {
+	Addr           string          `yaml:"addr"`
+	MaxRecvMsgSize int             `yaml:"max_recv_msg_size"`
+	MaxSendMsgSize int             `yaml:"max_send_msg_size"`
+	Keepalive      KeepaliveConfig `yaml:"keepalive"`
+	// Channelz serves the channelz service for inspecting connections when debugging
+	Channelz bool `yaml:"channelz"`
+	// RequestTracing samples recent calls for the gateway's /debug/requests page
+	RequestTracing bool `yaml:"request_tracing"`
+}
+
+// KeepaliveConfig is passed to grpc.KeepaliveParams and grpc.KeepaliveEnforcementPolicy. A zero
+// MaxConnectionIdle, MaxConnectionAge or MaxConnectionAgeGrace means no limit.
+type KeepaliveConfig struct {
+	// Time is how long a connection may be idle before the server pings the client, which must answer within Timeout
+	Time    time.Duration `yaml:"time"`
+	Timeout time.Duration `yaml:"timeout"`
+	// MaxConnectionIdle closes connections without RPCs for this long; MaxConnectionAge closes every
+	// connection after this long, so clients reconnect and spread over new replicas, giving RPCs in flight
+	// MaxConnectionAgeGrace to finish
+	MaxConnectionIdle     time.Duration `yaml:"max_connection_idle"`
+	MaxConnectionAge      time.Duration `yaml:"max_connection_age"`
+	MaxConnectionAgeGrace time.Duration `yaml:"max_connection_age_grace"`
+	// MinTime is the shortest interval allowed between a client's pings, which are only allowed without
+	// active streams if PermitWithoutStream is set. Clients pinging more often are disconnected.
+	MinTime             time.Duration `yaml:"min_time"`
+	PermitWithoutStream bool          `yaml:"permit_without_stream"`
+}
+
+// TimeoutConfig is the deadline given to calls whose client set none
+type TimeoutConfig struct {
+	// Default applies to unary calls; 0 means no timeout
+	Default time.Duration `yaml:"default"`
+	// Methods overrides Default by full method name, e.g. /library.LibraryService/ExportBooks. Streaming
+	// calls only get a timeout listed here.
+	Methods map[string]time.Duration `yaml:"methods"`
+}
+
+// ConcurrencyConfig bounds the calls handled at once, so that bursts are turned away with ResourceExhausted
+// instead of queueing for database connections
+type ConcurrencyConfig struct {
+	// Max bounds unary calls across all methods; 0 means no limit
+	Max int `yaml:"max"`
+	// Methods bounds the calls of a method, by full method name, on top of Max. Streaming calls only
+	// count against a limit listed here.
+	Methods map[string]int `yaml:"methods"`
+}
+
+// CacheConfig sizes the in-memory cache of GetBook and ListBooks responses
+type CacheConfig struct {
+	// Size is the most responses kept; 0 turns caching off
+	Size int `yaml:"size"`
+	// TTL is how long a response is kept, and so how stale it may be after a change made by another replica
+	TTL time.Duration `yaml:"ttl"`
+}
+
+// ShadowConfig mirrors a share of the calls to read methods to a second deployment, such as one running a
+// new storage implementation, to compare its responses with this server's before switching over
+type ShadowConfig struct {
+	// Upstream is the gRPC address of the shadow; empty turns shadowing off
+	Upstream string `yaml:"upstream"`
+	// Percent of the calls to Methods that are mirrored
+	Percent int `yaml:"percent"`
+	// Methods are the mirrored methods, by full method name; only methods without side effects belong here
+	Methods []string `yaml:"methods"`
+}
+
+// IdempotencyConfig is how long the result of a call made with an idempotency key is kept for retries
+type IdempotencyConfig struct {
+	KeyTTL time.Duration `yaml:"key_ttl"`
+}
+
+// QuotaConfig is how many catalog changes each user may make per day (UTC), so a runaway script can't
+// flood or empty the catalog; 0 means no limit
+type QuotaConfig struct {
+	// BooksAddedPerDay counts AddBook, BatchAddBooks and ImportBooks
+	BooksAddedPerDay   int `yaml:"books_added_per_day"`
+	BooksDeletedPerDay int `yaml:"books_deleted_per_day"`
+}
+
+// PurgeConfig is how often the purge job runs and how long expired, used or revoked tokens are kept before
+// it deletes them
+type PurgeConfig struct {
+	Interval time.Duration `yaml:"interval"`
+	// Retention keeps dead rows around for investigating incidents (0: deleted at the next run)
+	Retention time.Duration `yaml:"retention"`
+}
+
+// RetentionConfig is how long the purge job keeps the personal data of records of activity, and the
+// records themselves. Ages are counted from the records' creation; 0 keeps them forever.
+type RetentionConfig struct {
+	AuthEvents RetentionPolicy `yaml:"auth_events"`
+	AuditLog   RetentionPolicy `yaml:"audit_log"`
+	// DryRun logs how many rows the policies would change instead of changing them
+	DryRun bool `yaml:"dry_run"`
+}
+
+// RetentionPolicy is when the rows of a table are anonymized and when they are deleted
+type RetentionPolicy struct {
+	AnonymizeAfter time.Duration `yaml:"anonymize_after"`
+	DeleteAfter    time.Duration `yaml:"delete_after"`
+}
+
+// OutboxConfig is where and how often the outbox relay publishes the events of book changes
+type OutboxConfig struct {
+	// WebhookURL receives each event as a JSON POST; empty logs the events instead
+	WebhookURL string        `yaml:"webhook_url"`
+	Interval   time.Duration `yaml:"interval"`
+	// BatchSize is how many events are published per transaction
+	BatchSize int `yaml:"batch_size"`
+}
+
+// TenantConfig is whether the server hosts several libraries in one database, their rows told apart by a
+// tenant_id column, and chosen by the x-tenant-id header of calls or the library of their token
+type TenantConfig struct {
+	Enabled bool `yaml:"enabled"`
+}
+
+// StorageConfig is where the book catalog is kept. Accounts, sessions and everything else stay in
+// PostgreSQL whatever the backend.
+type StorageConfig struct {
+	// Backend is "postgres", or "mongodb" for a deployment serving the catalog and accounts only: the
+	// other services keep rows referring to books in PostgreSQL, so mongoServes refuses their calls
+	Backend string        `yaml:"backend"`
+	MongoDB MongoDBConfig `yaml:"mongodb"`
+}
+
+// MongoDBConfig is the MongoDB deployment of the mongodb backend, a replica set for its transactions
+type MongoDBConfig struct {
+	URI      string `yaml:"uri"`
+	Database string `yaml:"database"`
+}
+
+// NotificationConfig is how Subscribe streams survive idle-timeout proxies and reconnects
+type NotificationConfig struct {
+	// KeepaliveInterval is how long a stream may stay idle before a keepalive notification is sent (0: never)
+	KeepaliveInterval time.Duration `yaml:"keepalive_interval"`
+	// History is how many recent notifications of each user are kept for streams resuming after a reconnect
+	History int `yaml:"history"`
+}
+
+// GatewayConfig is where and how the REST gateway listens, and the gRPC address it proxies to
+type GatewayConfig struct {
+	Addr string `yaml:"addr"`
+	// Upstream defaults to the gRPC server's own port on localhost
+	Upstream string `yaml:"upstream"`
+	// TLSCertFile and TLSKeyFile serve HTTPS rather than plain HTTP when both are set
+	TLSCertFile string `yaml:"tls_cert_file"`
+	TLSKeyFile  string `yaml:"tls_key_file"`
+	// ReadTimeout bounds reading a request, headers and body, and IdleTimeout how long a keep-alive
+	// connection waits for the next one. WriteTimeout bounds writing a response, including the streams of
+	// ExportBooks and notifications, and is 0 (none) by default so that those aren't cut off.
+	ReadTimeout  time.Duration `yaml:"read_timeout"`
+	WriteTimeout time.Duration `yaml:"write_timeout"`
+	IdleTimeout  time.Duration `yaml:"idle_timeout"`
+	// CORSAllowedOrigins may make credentialed (cookie) requests; a wildcard is refused, as it would let
+	// any site use the user's cookies
+	CORSAllowedOrigins []string     `yaml:"cors_allowed_origins"`
+	Cookies            CookieConfig `yaml:"cookies"`
+}
+
+// CookieConfig is how the gateway sets the token cookies of browser clients
+type CookieConfig struct {
+	// Secure is off by default so that plain-HTTP development works
+	Secure bool `yaml:"secure"`
+	// SameSite is strict, lax or none, which requires Secure
+	SameSite string `yaml:"same_site"`
+}
+
+// DatabaseConfig is the PostgreSQL server to connect to
+type DatabaseConfig struct {
+	Host     string `yaml:"host"`
+	Port     int    `yaml:"port"`
+	User     string `yaml:"user"`
+	Password string `yaml:"password"`
+	Name     string `yaml:"name"`
+	SSLMode  string `yaml:"sslmode"`
+	// SlowQueryThreshold is the duration above which statements are logged and counted (0: never)
+	SlowQueryThreshold time.Duration `yaml:"slow_query_threshold"`
+	// StatementCacheCapacity is how many prepared statements each connection keeps (0: statements are
+	// not prepared, for poolers in transaction mode)
+	StatementCacheCapacity int             `yaml:"statement_cache_capacity"`
+	Pool                   PoolConfig      `yaml:"pool"`
+	Reconnect              ReconnectConfig `yaml:"reconnect"`
+}
+
+// ReconnectConfig is how the server notices that the database went away and waits for it to come back
+type ReconnectConfig struct {
+	// PingInterval is how often the database is pinged while it answers
+	PingInterval time.Duration `yaml:"ping_interval"`
+	// FailureThreshold consecutive failed pings take the server out of service
+	FailureThreshold int `yaml:"failure_threshold"`
+	// MaxBackoff bounds the wait between pings while the database is down, which doubles from PingInterval
+	MaxBackoff time.Duration `yaml:"max_backoff"`
+}
+
+// PoolConfig sizes and recycles the connections of pgxpool. The defaults are pgxpool's own.
+type PoolConfig struct {
+	// MaxConns bounds the open connections; RPCs beyond it wait for one to be released. MinConns are
+	// kept open even when idle, so bursts do not pay for the connection setup.
+	MaxConns int `yaml:"max_conns"`
+	MinConns int `yaml:"min_conns"`
+	// MaxConnLifetime closes connections this old once released, MaxConnIdleTime those unused for this long
+	MaxConnLifetime time.Duration `yaml:"max_conn_lifetime"`
+	MaxConnIdleTime time.Duration `yaml:"max_conn_idle_time"`
+	// HealthCheckPeriod is how often idle connections are checked, and the pool refilled to MinConns
+	HealthCheckPeriod time.Duration `yaml:"health_check_period"`
+}
+
+// AuthConfig is how tokens are signed, checked and expire, the bcrypt cost factor and who may do what
+type AuthConfig struct {
+	JWTSecret   string `yaml:"jwt_secret"`
+	JWTIssuer   string `yaml:"jwt_issuer"`
+	JWTAudience string `yaml:"jwt_audience"`
+	BcryptCost  int    `yaml:"bcrypt_cost"`
+	// JWTPrivateKeyFile signs tokens with an RSA or Ed25519 key (PEM) instead of JWTSecret. Tokens signed with
+	// the keys of JWTVerificationKeyFiles stay valid, and so do those signed with JWTSecret unless it is the
+	// development default.
+	JWTPrivateKeyFile       string   `yaml:"jwt_private_key_file"`
+	JWTVerificationKeyFiles []string `yaml:"jwt_verification_key_files"`
+	// AllowInsecureJWTSecret lets a development setup start with the default JWTSecret
+	AllowInsecureJWTSecret bool `yaml:"allow_insecure_jwt_secret"`
+	// AccessTokenTTL and RefreshTokenTTL are how long issued tokens stay valid, and JWTClockSkew how far the
+	// exp, nbf and iat claims of access tokens may be off
+	AccessTokenTTL  time.Duration `yaml:"access_token_ttl"`
+	RefreshTokenTTL time.Duration `yaml:"refresh_token_ttl"`
+	JWTClockSkew    time.Duration `yaml:"jwt_clock_skew"`
+	// RedisURL shares the denylist of revoked tokens between replicas, e.g. redis://:password@localhost:6379/0;
+	// empty keeps one per process
+	RedisURL string `yaml:"redis_url"`
+	// AdminUsernames are made administrators at startup
+	AdminUsernames []string `yaml:"admin_usernames"`
+	// PolicyFile is a JSON authorization policy whose rules are layered over the defaults
+	PolicyFile string `yaml:"policy_file"`
+	// RequireVerifiedEmail makes Register require an email address, and keeps accounts whose address is not
+	// verified from logging in
+	RequireVerifiedEmail bool `yaml:"require_verified_email"`
+}
+
+// TLSConfig is the certificate the gRPC server serves TLS with, and the client certificates it accepts
+type TLSConfig struct {
+	// CertFile and KeyFile serve TLS rather than plaintext when both are set
+	CertFile string `yaml:"cert_file"`
+	KeyFile  string `yaml:"key_file"`
+	// ClientCAFile lets clients present a certificate signed by this CA (mutual TLS)
+	ClientCAFile string `yaml:"client_ca_file"`
+	// Identities are "CN=username" entries for certificates acting as a user, and bare "CN" entries for
+	// services
+	Identities []string `yaml:"identities"`
+}
+
+// PasswordConfig is how passwords are hashed, and how many old ones cannot be chosen again
+type PasswordConfig struct {
+	// HashAlgorithm is argon2id or bcrypt. Hashes of the other algorithm still verify, and are replaced
+	// as their users log in.
+	HashAlgorithm     string `yaml:"hash_algorithm"`
+	Argon2MemoryKiB   int    `yaml:"argon2_memory_kib"`
+	Argon2Iterations  int    `yaml:"argon2_iterations"`
+	Argon2Parallelism int    `yaml:"argon2_parallelism"`
+	// HistoryDepth is how many of a user's passwords, the current one included, cannot be chosen again
+	// (0: any)
+	HistoryDepth int `yaml:"history_depth"`
+}
+
+// RateLimitConfig is how many Register, Login and ChangePassword attempts each client IP and each username
+// get per minute, and in a burst; 0 turns a limit off
+type RateLimitConfig struct {
+	PerIP   int `yaml:"per_ip"`
+	PerUser int `yaml:"per_user"`
+}
+
+// ChallengeConfig is the bot challenge Register requires, if any
+type ChallengeConfig struct {
+	// Provider is turnstile or hcaptcha; empty turns the challenge off
+	Provider string `yaml:"provider"`
+	Secret   string `yaml:"secret"`
+	// VerifyURL overrides the provider's verification endpoint
+	VerifyURL string `yaml:"verify_url"`
+}
+
+// OIDCConfig is the OpenID Connect provider whose ID tokens LoginWithIdToken accepts
+type OIDCConfig struct {
+	// Issuer is empty when ID token login is off
+	Issuer   string `yaml:"issuer"`
+	ClientID string `yaml:"client_id"`
+	// JWKSURL serves the signing keys; empty reads it from the issuer's discovery document
+	JWKSURL string `yaml:"jwks_url"`
+}
+
+// MailConfig is the SMTP relay that password resets and email verifications are sent through. Without a
+// Host the tokens are logged instead.
+type MailConfig struct {
+	Host     string `yaml:"host"`
+	Port     int    `yaml:"port"`
+	From     string `yaml:"from"`
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+}
+
+// CirculationConfig is how often loans and reservations are swept, and what late loans are charged
+type CirculationConfig struct {
+	Interval        time.Duration `yaml:"interval"`
+	FinePerDayCents int           `yaml:"fine_per_day_cents"`
+	// FineMaxCents caps a single fine; 0 means uncapped
+	FineMaxCents int `yaml:"fine_max_cents"`
+}
+
+// CatalogConfig is what makes a new book a duplicate, and where book metadata is looked up by ISBN
+type CatalogConfig struct {
+	// DuplicateChecks are isbn and title_author, or off
+	DuplicateChecks []string `yaml:"duplicate_checks"`
+	// ISBNLookupURL is the base URL of the OpenLibrary books API
+	ISBNLookupURL string `yaml:"isbn_lookup_url"`
+}
+
+// LoggingConfig is the format of the logs and the levels they are written at
+type LoggingConfig struct {
+	// Format is text, or json for production
+	Format string `yaml:"format"`
+	// Level is the minimum level logged and RPCLevel the level of successful RPCs: debug, info, warn or error
+	Level    string `yaml:"level"`
+	RPCLevel string `yaml:"rpc_level"`
+}
+
+// ErrorReportConfig is the Sentry-compatible project that unexpected errors are reported to
+type ErrorReportConfig struct {
+	// SentryDSN is empty when errors are not reported
+	SentryDSN   string `yaml:"sentry_dsn"`
+	Environment string `yaml:"environment"`
+	Release     string `yaml:"release"`
+}
+
+// defaultConfig is the configuration of a development setup on one machine
+func defaultConfig() Config {
+	return Config{
+		// The limits and keepalive settings are gRPC's own defaults
+		GRPC: GRPCConfig{
+			Addr:           ":50051",
+			MaxRecvMsgSize: 4 << 20,
+			MaxSendMsgSize: math.MaxInt32,
+			Keepalive: KeepaliveConfig{
+				Time:    2 * time.Hour,
+				Timeout: 20 * time.Second,
+				MinTime: 5 * time.Minute,
+			},
+		},
+		Gateway: GatewayConfig{
+			Addr:               ":8080",
+			ReadTimeout:        30 * time.Second,
+			IdleTimeout:        2 * time.Minute,
+			CORSAllowedOrigins: []string{"http://localhost:3000"},
+			Cookies:            CookieConfig{SameSite: "strict"},
+		},
+		Database: DatabaseConfig{
+			Host:                   "localhost",
+			Port:                   5432,
+			User:                   "postgres",
+			Password:               "postgres",
+			Name:                   "library_db",
+			SSLMode:                "disable",
+			SlowQueryThreshold:     500 * time.Millisecond,
+			StatementCacheCapacity: 512,
+			Pool: PoolConfig{
+				MaxConns:          max(4, runtime.NumCPU()),
+				MaxConnLifetime:   time.Hour,
+				MaxConnIdleTime:   30 * time.Minute,
+				HealthCheckPeriod: time.Minute,
+			},
+			Reconnect: ReconnectConfig{PingInterval: 5 * time.Second, FailureThreshold: 3, MaxBackoff: 30 * time.Second},
+		},
+		Auth: AuthConfig{
+			JWTSecret:       defaultJWTSecret,
+			JWTIssuer:       defaultJWTIssuer,
+			BcryptCost:      bcrypt.DefaultCost,
+			AccessTokenTTL:  defaultAccessTokenTTL,
+			RefreshTokenTTL: defaultRefreshTokenTTL,
+		},
+		Timeouts: TimeoutConfig{Default: 30 * time.Second},
+		Cache:    CacheConfig{TTL: 30 * time.Second},
+		Shadow: ShadowConfig{
+			Percent: 10,
+			Methods: []string{"/library.LibraryService/GetBook", "/library.LibraryService/ListBooks"},
+		},
+		Idempotency:   IdempotencyConfig{KeyTTL: 24 * time.Hour},
+		Quotas:        QuotaConfig{BooksAddedPerDay: 1000, BooksDeletedPerDay: 100},
+		Notifications: NotificationConfig{KeepaliveInterval: 30 * time.Second, History: 100},
+		Purge:         PurgeConfig{Interval: time.Hour, Retention: 7 * 24 * time.Hour},
+		Retention: RetentionConfig{
+			AuthEvents: RetentionPolicy{AnonymizeAfter: 30 * 24 * time.Hour, DeleteAfter: 90 * 24 * time.Hour},
+			AuditLog:   RetentionPolicy{DeleteAfter: 365 * 24 * time.Hour},
+		},
+		Outbox: OutboxConfig{Interval: 5 * time.Second, BatchSize: 100},
+		Storage: StorageConfig{
+			Backend: storagePostgres,
+			MongoDB: MongoDBConfig{URI: "mongodb://localhost:27017/?replicaSet=rs0", Database: "library"},
+		},
+		Logging: LoggingConfig{Format: "text", Level: "info", RPCLevel: "info"},
+		// The Argon2id defaults are the OWASP minimum recommendation
+		Passwords: PasswordConfig{
+			HashAlgorithm:     "argon2id",
+			Argon2MemoryKiB:   defaultArgon2MemoryKiB,
+			Argon2Iterations:  defaultArgon2Iterations,
+			Argon2Parallelism: defaultArgon2Parallelism,
+			HistoryDepth:      defaultPasswordHistoryDepth,
+		},
+		RateLimits:  RateLimitConfig{PerIP: defaultAuthRateLimitPerIP, PerUser: defaultAuthRateLimitPerUser},
+		Mail:        MailConfig{Port: 587},
+		Circulation: CirculationConfig{Interval: time.Hour, FinePerDayCents: 25},
+		Catalog:     CatalogConfig{DuplicateChecks: []string{"isbn", "title_author"}, ISBNLookupURL: defaultOpenLibraryURL},
+	}
+}
+
+// configSetting is one setting that can be overridden from the environment and, unless flag is empty,
+// the command line. Secrets have no flag, as command lines are visible to other users of the machine.
+type configSetting struct {
+	env, flag, usage string
+	dst              any // *string, *int, *bool, *time.Duration or *[]string, given comma-separated
+}
+
+func (c *Config) settings() []configSetting {
+	return []configSetting{
+		{"GRPC_ADDR", "grpc-addr", "address the gRPC server listens on", &c.GRPC.Addr},
+		{"GRPC_MAX_RECV_MSG_SIZE", "grpc-max-recv-msg-size", "largest message the gRPC server accepts, in bytes", &c.GRPC.MaxRecvMsgSize},
+		{"GRPC_MAX_SEND_MSG_SIZE", "grpc-max-send-msg-size", "largest message the gRPC server sends, in bytes", &c.GRPC.MaxSendMsgSize},
+		{"GRPC_KEEPALIVE_TIME", "grpc-keepalive-time", "idle time before the server pings a client", &c.GRPC.Keepalive.Time},
+		{"GRPC_KEEPALIVE_TIMEOUT", "grpc-keepalive-timeout", "how long the server waits for a ping to be answered", &c.GRPC.Keepalive.Timeout},
+		{"GRPC_MAX_CONNECTION_IDLE", "grpc-max-connection-idle", "close connections idle for this long (0: never)", &c.GRPC.Keepalive.MaxConnectionIdle},
+		{"GRPC_MAX_CONNECTION_AGE", "grpc-max-connection-age", "close connections this old (0: never)", &c.GRPC.Keepalive.MaxConnectionAge},
+		{"GRPC_MAX_CONNECTION_AGE_GRACE", "grpc-max-connection-age-grace", "time RPCs get to finish on a connection closed for its age (0: unlimited)", &c.GRPC.Keepalive.MaxConnectionAgeGrace},
+		{"GRPC_KEEPALIVE_MIN_TIME", "grpc-keepalive-min-time", "shortest interval allowed between client pings", &c.GRPC.Keepalive.MinTime},
+		{"GRPC_KEEPALIVE_PERMIT_WITHOUT_STREAM", "grpc-keepalive-permit-without-stream", "allow client pings on connections without streams", &c.GRPC.Keepalive.PermitWithoutStream},
+		{"GRPC_CHANNELZ", "grpc-channelz", "serve the channelz service (admins only by default)", &c.GRPC.Channelz},
+		{"GRPC_REQUEST_TRACING", "grpc-request-tracing", "sample recent calls for /debug/requests (loopback clients only)", &c.GRPC.RequestTracing},
+		{"GATEWAY_ADDR", "gateway-addr", "address the REST gateway listens on", &c.Gateway.Addr},
+		{"GATEWAY_UPSTREAM", "gateway-upstream", "gRPC address the REST gateway proxies to", &c.Gateway.Upstream},
+		{"GATEWAY_TLS_CERT_FILE", "gateway-tls-cert-file", "certificate the REST gateway serves HTTPS with", &c.Gateway.TLSCertFile},
+		{"GATEWAY_TLS_KEY_FILE", "gateway-tls-key-file", "private key of GATEWAY_TLS_CERT_FILE", &c.Gateway.TLSKeyFile},
+		{"GATEWAY_READ_TIMEOUT", "gateway-read-timeout", "how long the REST gateway waits for a request (0: no limit)", &c.Gateway.ReadTimeout},
+		{"GATEWAY_WRITE_TIMEOUT", "gateway-write-timeout", "how long the REST gateway may take to write a response, streams included (0: no limit)", &c.Gateway.WriteTimeout},
+		{"GATEWAY_IDLE_TIMEOUT", "gateway-idle-timeout", "how long the REST gateway keeps an idle connection open (0: the read timeout)", &c.Gateway.IdleTimeout},
+		{"DB_HOST", "db-host", "PostgreSQL host", &c.Database.Host},
+		{"DB_PORT", "db-port", "PostgreSQL port", &c.Database.Port},
+		{"DB_USER", "db-user", "PostgreSQL user", &c.Database.User},
+		{"DB_PASSWORD", "", "", &c.Database.Password},
+		{"DB_NAME", "db-name", "PostgreSQL database", &c.Database.Name},
+		{"DB_SSLMODE", "db-sslmode", "PostgreSQL sslmode", &c.Database.SSLMode},
+		{"DB_SLOW_QUERY_THRESHOLD", "db-slow-query-threshold", "log statements slower than this (0: never)", &c.Database.SlowQueryThreshold},
+		{"DB_STATEMENT_CACHE_CAPACITY", "db-statement-cache-capacity", "prepared statements kept per database connection (0: none)", &c.Database.StatementCacheCapacity},
+		{"DB_POOL_MAX_CONNS", "db-pool-max-conns", "most connections open to PostgreSQL", &c.Database.Pool.MaxConns},
+		{"DB_POOL_MIN_CONNS", "db-pool-min-conns", "connections kept open to PostgreSQL even when idle", &c.Database.Pool.MinConns},
+		{"DB_POOL_MAX_CONN_LIFETIME", "db-pool-max-conn-lifetime", "close database connections this old", &c.Database.Pool.MaxConnLifetime},
+		{"DB_POOL_MAX_CONN_IDLE_TIME", "db-pool-max-conn-idle-time", "close database connections idle for this long", &c.Database.Pool.MaxConnIdleTime},
+		{"DB_POOL_HEALTH_CHECK_PERIOD", "db-pool-health-check-period", "interval between checks of idle database connections", &c.Database.Pool.HealthCheckPeriod},
+		{"DB_PING_INTERVAL", "db-ping-interval", "how often the database is pinged to notice it went away", &c.Database.Reconnect.PingInterval},
+		{"DB_PING_FAILURE_THRESHOLD", "db-ping-failure-threshold", "consecutive failed pings that take the server out of service", &c.Database.Reconnect.FailureThreshold},
+		{"DB_RECONNECT_MAX_BACKOFF", "db-reconnect-max-backoff", "longest wait between pings while the database is down", &c.Database.Reconnect.MaxBackoff},
+		{"JWT_SECRET", "", "", &c.Auth.JWTSecret},
+		{"JWT_ISSUER", "jwt-issuer", "iss claim of access tokens", &c.Auth.JWTIssuer},
+		{"JWT_AUDIENCE", "jwt-audience", "aud claim of access tokens", &c.Auth.JWTAudience},
+		{"BCRYPT_COST", "bcrypt-cost", "bcrypt cost factor", &c.Auth.BcryptCost},
+		{"RPC_TIMEOUT", "rpc-timeout", "deadline of unary calls whose client set none (0: none)", &c.Timeouts.Default},
+		{"RPC_MAX_CONCURRENT", "rpc-max-concurrent", "unary calls handled at once before others are turned away (0: no limit)", &c.Concurrency.Max},
+		{"RESPONSE_CACHE_SIZE", "response-cache-size", "GetBook and ListBooks responses cached in memory (0: no cache)", &c.Cache.Size},
+		{"RESPONSE_CACHE_TTL", "response-cache-ttl", "how long a cached response is served", &c.Cache.TTL},
+		{"SHADOW_UPSTREAM", "shadow-upstream", "gRPC address read calls are mirrored to for comparison (empty: no shadowing)", &c.Shadow.Upstream},
+		{"SHADOW_PERCENT", "shadow-percent", "percentage of the calls to shadowed methods that are mirrored", &c.Shadow.Percent},
+		{"IDEMPOTENCY_KEY_TTL", "idempotency-key-ttl", "how long results of calls with an idempotency key are kept", &c.Idempotency.KeyTTL},
+		{"QUOTA_BOOKS_ADDED_PER_DAY", "quota-books-added-per-day", "books each user may add per day (0: no limit)", &c.Quotas.BooksAddedPerDay},
+		{"QUOTA_BOOKS_DELETED_PER_DAY", "quota-books-deleted-per-day", "books each user may delete per day (0: no limit)", &c.Quotas.BooksDeletedPerDay},
+		{"NOTIFICATION_KEEPALIVE_INTERVAL", "notification-keepalive-interval", "idle time before a notification stream gets a keepalive (0: never)", &c.Notifications.KeepaliveInterval},
+		{"NOTIFICATION_HISTORY", "notification-history", "recent notifications kept per user for resuming streams", &c.Notifications.History},
+		{"PURGE_INTERVAL", "purge-interval", "how often expired, used and revoked tokens are purged", &c.Purge.Interval},
+		{"PURGE_RETENTION", "purge-retention", "how long expired, used and revoked tokens are kept", &c.Purge.Retention},
+		{"RETENTION_AUTH_EVENTS_ANONYMIZE_AFTER", "retention-auth-events-anonymize-after", "clear the IP address and user agent of sign-in events this old (0: never)", &c.Retention.AuthEvents.AnonymizeAfter},
+		{"RETENTION_AUTH_EVENTS_DELETE_AFTER", "retention-auth-events-delete-after", "delete sign-in events this old (0: never)", &c.Retention.AuthEvents.DeleteAfter},
+		{"RETENTION_AUDIT_LOG_ANONYMIZE_AFTER", "retention-audit-log-anonymize-after", "remove who made audit log entries this old (0: never)", &c.Retention.AuditLog.AnonymizeAfter},
+		{"RETENTION_AUDIT_LOG_DELETE_AFTER", "retention-audit-log-delete-after", "delete audit log entries this old (0: never)", &c.Retention.AuditLog.DeleteAfter},
+		{"RETENTION_DRY_RUN", "retention-dry-run", "log the rows the retention policies would change without changing them", &c.Retention.DryRun},
+		{"OUTBOX_WEBHOOK_URL", "", "", &c.Outbox.WebhookURL},
+		{"OUTBOX_INTERVAL", "outbox-interval", "how often the outbox relay publishes the events of book changes", &c.Outbox.Interval},
+		{"OUTBOX_BATCH_SIZE", "outbox-batch-size", "events the outbox relay publishes per transaction", &c.Outbox.BatchSize},
+		{"TENANTS_ENABLED", "tenants-enabled", "host several libraries, chosen by the x-tenant-id header, in one database", &c.Tenants.Enabled},
+		{"STORAGE_BACKEND", "storage-backend", "where the book catalog is kept: postgres, or mongodb to serve the catalog and accounts only", &c.Storage.Backend},
+		{"MONGODB_URI", "", "", &c.Storage.MongoDB.URI},
+		{"MONGODB_DATABASE", "mongodb-database", "MongoDB database of the mongodb storage backend", &c.Storage.MongoDB.Database},
+		{"LOG_FORMAT", "log-format", "format of the logs: text or json", &c.Logging.Format},
+		{"LOG_LEVEL", "log-level", "minimum level logged: debug, info, warn or error", &c.Logging.Level},
+		{"LOG_RPC_LEVEL", "log-rpc-level", "level successful RPCs are logged at", &c.Logging.RPCLevel},
+		{"TLS_CERT_FILE", "tls-cert-file", "certificate the gRPC server serves TLS with", &c.TLS.CertFile},
+		{"TLS_KEY_FILE", "tls-key-file", "private key of TLS_CERT_FILE", &c.TLS.KeyFile},
+		{"TLS_CLIENT_CA_FILE", "tls-client-ca-file", "CA of the client certificates the gRPC server accepts", &c.TLS.ClientCAFile},
+		{"MTLS_IDENTITIES", "mtls-identities", "client certificates by common name: CN=username for users, CN for services", &c.TLS.Identities},
+		{"JWT_PRIVATE_KEY_FILE", "jwt-private-key-file", "RSA or Ed25519 key (PEM) tokens are signed with instead of JWT_SECRET", &c.Auth.JWTPrivateKeyFile},
+		{"JWT_VERIFICATION_KEY_FILES", "jwt-verification-key-files", "public keys (PEM) tokens may also be signed with", &c.Auth.JWTVerificationKeyFiles},
+		{"ALLOW_INSECURE_JWT_SECRET", "allow-insecure-jwt-secret", "start with the development JWT secret", &c.Auth.AllowInsecureJWTSecret},
+		{"ACCESS_TOKEN_TTL", "access-token-ttl", "how long access tokens are valid", &c.Auth.AccessTokenTTL},
+		{"REFRESH_TOKEN_TTL", "refresh-token-ttl", "how long refresh tokens are valid", &c.Auth.RefreshTokenTTL},
+		{"JWT_CLOCK_SKEW", "jwt-clock-skew", "clock skew tolerated on the time claims of access tokens", &c.Auth.JWTClockSkew},
+		{"REDIS_URL", "", "", &c.Auth.RedisURL},
+		{"ADMIN_USERNAMES", "admin-usernames", "users made administrators at startup", &c.Auth.AdminUsernames},
+		{"AUTH_POLICY_FILE", "auth-policy-file", "JSON authorization policy layered over the defaults", &c.Auth.PolicyFile},
+		{"REQUIRE_VERIFIED_EMAIL", "require-verified-email", "require a verified email address to log in", &c.Auth.RequireVerifiedEmail},
+		{"PASSWORD_HASH_ALGORITHM", "password-hash-algorithm", "algorithm new passwords are hashed with: argon2id or bcrypt", &c.Passwords.HashAlgorithm},
+		{"ARGON2_MEMORY_KIB", "argon2-memory-kib", "memory an Argon2id hash takes, in KiB", &c.Passwords.Argon2MemoryKiB},
+		{"ARGON2_ITERATIONS", "argon2-iterations", "passes of an Argon2id hash", &c.Passwords.Argon2Iterations},
+		{"ARGON2_PARALLELISM", "argon2-parallelism", "lanes of an Argon2id hash", &c.Passwords.Argon2Parallelism},
+		{"PASSWORD_HISTORY_DEPTH", "password-history-depth", "recent passwords of a user that cannot be chosen again (0: any)", &c.Passwords.HistoryDepth},
+		{"AUTH_RATE_LIMIT_PER_IP", "auth-rate-limit-per-ip", "sign-in attempts per minute for each client IP (0: no limit)", &c.RateLimits.PerIP},
+		{"AUTH_RATE_LIMIT_PER_USER", "auth-rate-limit-per-user", "sign-in attempts per minute for each username (0: no limit)", &c.RateLimits.PerUser},
+		{"CORS_ALLOWED_ORIGINS", "cors-allowed-origins", "origins allowed to make requests with cookies", &c.Gateway.CORSAllowedOrigins},
+		{"AUTH_COOKIE_SECURE", "auth-cookie-secure", "send the token cookies over HTTPS only", &c.Gateway.Cookies.Secure},
+		{"AUTH_COOKIE_SAMESITE", "auth-cookie-samesite", "SameSite attribute of the token cookies: strict, lax or none", &c.Gateway.Cookies.SameSite},
+		{"CHALLENGE_PROVIDER", "challenge-provider", "bot challenge of Register: turnstile or hcaptcha (empty: none)", &c.Challenge.Provider},
+		{"CHALLENGE_SECRET", "", "", &c.Challenge.Secret},
+		{"CHALLENGE_VERIFY_URL", "challenge-verify-url", "verification endpoint of the challenge provider", &c.Challenge.VerifyURL},
+		{"OIDC_ISSUER", "oidc-issuer", "OpenID Connect provider whose ID tokens log users in (empty: none)", &c.OIDC.Issuer},
+		{"OIDC_CLIENT_ID", "oidc-client-id", "audience of accepted ID tokens", &c.OIDC.ClientID},
+		{"OIDC_JWKS_URL", "oidc-jwks-url", "signing keys of the OIDC provider (empty: from its discovery document)", &c.OIDC.JWKSURL},
+		{"SMTP_HOST", "smtp-host", "SMTP relay of account emails (empty: tokens are logged)", &c.Mail.Host},
+		{"SMTP_PORT", "smtp-port", "port of the SMTP relay", &c.Mail.Port},
+		{"SMTP_FROM", "smtp-from", "sender address of account emails", &c.Mail.From},
+		{"SMTP_USERNAME", "smtp-username", "user the SMTP relay is logged in as", &c.Mail.Username},
+		{"SMTP_PASSWORD", "", "", &c.Mail.Password},
+		{"CIRCULATION_INTERVAL", "circulation-interval", "how often overdue loans and expired holds are swept", &c.Circulation.Interval},
+		{"FINE_PER_DAY_CENTS", "fine-per-day-cents", "fine for each day a loan is late, in cents", &c.Circulation.FinePerDayCents},
+		{"FINE_MAX_CENTS", "fine-max-cents", "largest fine of a loan, in cents (0: uncapped)", &c.Circulation.FineMaxCents},
+		{"DUPLICATE_CHECK", "duplicate-check", "what makes a new book a duplicate: isbn, title_author or off", &c.Catalog.DuplicateChecks},
+		{"ISBN_LOOKUP_URL", "isbn-lookup-url", "OpenLibrary API books are looked up in by ISBN", &c.Catalog.ISBNLookupURL},
+		{"SENTRY_DSN", "", "", &c.ErrorReporting.SentryDSN},
+		{"SENTRY_ENVIRONMENT", "sentry-environment", "environment unexpected errors are reported with", &c.ErrorReporting.Environment},
+		{"SENTRY_RELEASE", "sentry-release", "release unexpected errors are reported with", &c.ErrorReporting.Release},
+	}
+}
+
+func (s configSetting) set(raw string) error {
+	switch dst := s.dst.(type) {
+	case *string:
+		*dst = raw
+	case *int:
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return fmt.Errorf("%s must be a number, got %q", s.env, raw)
+		}
+		*dst = n
+	case *bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("%s must be true or false, got %q", s.env, raw)
+		}
+		*dst = b
+	case *time.Duration:
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return fmt.Errorf("%s must be a duration such as 30s, got %q", s.env, raw)
+		}
+		*dst = d
+	case *[]string:
+		*dst = nil
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				*dst = append(*dst, item)
+			}
+		}
+	}
+	return nil
+}
+
+// bindConfigFlags registers -config and a flag per setting on fs. Flags left unset don't override the file
+// or the environment, so they have no default of their own.
+func bindConfigFlags(fs *flag.FlagSet) {
+	fs.String("config", "", "YAML configuration file (also CONFIG_FILE)")
+	for _, s := range (&Config{}).settings() {
+		usage := fmt.Sprintf("%s (also %s)", s.usage, s.env)
+		switch {
+		case s.flag == "":
+		case isBool(s.dst):
+			// So that -grpc-channelz works without a value
+			fs.Bool(s.flag, false, usage)
+		default:
+			fs.String(s.flag, "", usage)
+		}
+	}
+}
+
+func isBool(dst any) bool {
+	_, ok := dst.(*bool)
+	return ok
+}
+
+// LoadConfig builds the configuration from its defaults, the configuration file, the environment and the
+// flags set on fs, which must have been registered with bindConfigFlags and parsed, and validates it
+func LoadConfig(fs *flag.FlagSet) (*Config, error) {
+	cfg := defaultConfig()
+	settings := cfg.settings()
+
+	path := os.Getenv("CONFIG_FILE")
+	if f := fs.Lookup("config"); f != nil && f.Value.String() != "" {
+		path = f.Value.String()
+	}
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		// A misspelt key would otherwise be silently ignored
+		dec.KnownFields(true)
+		if err := dec.Decode(&cfg); err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+
+	for _, s := range settings {
+		if raw := os.Getenv(s.env); raw != "" {
+			if err := s.set(raw); err != nil {
+				return nil, err
+			}
+		}
+	}
+	var flagErr error
+	fs.Visit(func(f *flag.Flag) {
+		for _, s := range settings {
+			if s.flag == f.Name && flagErr == nil {
+				if err := s.set(f.Value.String()); err != nil {
+					flagErr = fmt.Errorf("-%s: %w", f.Name, err)
+				}
+			}
+		}
+	})
+	if flagErr != nil {
+		return nil, flagErr
+	}
+
+	if cfg.Gateway.Upstream == "" {
+		cfg.Gateway.Upstream = localAddr(cfg.GRPC.Addr)
+	}
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// localAddr is how another process on this machine reaches a server listening on addr
+func localAddr(addr string) string {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+	if ip := net.ParseIP(host); host == "" || (ip != nil && ip.IsUnspecified()) {
+		host = "localhost"
+	}
+	return net.JoinHostPort(host, port)
+}
+
+// validate reports every invalid setting at once, so a broken deployment is fixed in one go
+func (c *Config) validate() error {
+	var errs []error
+	for _, addr := range []struct{ name, value string }{
+		{"GRPC_ADDR", c.GRPC.Addr},
+		{"GATEWAY_ADDR", c.Gateway.Addr},
+		{"GATEWAY_UPSTREAM", c.Gateway.Upstream},
+	} {
+		if _, port, err := net.SplitHostPort(addr.value); err != nil || port == "" {
+			errs = append(errs, fmt.Errorf("%s must be a host:port address, got %q", addr.name, addr.value))
+		}
+	}
+
+	gw := c.Gateway
+	if (gw.TLSCertFile == "") != (gw.TLSKeyFile == "") {
+		errs = append(errs, errors.New("GATEWAY_TLS_CERT_FILE and GATEWAY_TLS_KEY_FILE must be set together"))
+	}
+	if gw.ReadTimeout < 0 || gw.WriteTimeout < 0 || gw.IdleTimeout < 0 {
+		errs = append(errs, errors.New("GATEWAY_READ_TIMEOUT, GATEWAY_WRITE_TIMEOUT and GATEWAY_IDLE_TIMEOUT must not be negative"))
+	}
+
+	if c.GRPC.MaxRecvMsgSize <= 0 || c.GRPC.MaxSendMsgSize <= 0 {
+		errs = append(errs, errors.New("GRPC_MAX_RECV_MSG_SIZE and GRPC_MAX_SEND_MSG_SIZE must be positive"))
+	}
+	ka := c.GRPC.Keepalive
+	if ka.Time < time.Second || ka.Timeout <= 0 {
+		errs = append(errs, errors.New("GRPC_KEEPALIVE_TIME must be at least 1s and GRPC_KEEPALIVE_TIMEOUT positive"))
+	}
+	if ka.MaxConnectionIdle < 0 || ka.MaxConnectionAge < 0 || ka.MaxConnectionAgeGrace < 0 || ka.MinTime < 0 {
+		errs = append(errs, errors.New("keepalive durations must not be negative"))
+	}
+
+	db := c.Database
+	if db.Host == "" || db.User == "" || db.Name == "" {
+		errs = append(errs, errors.New("DB_HOST, DB_USER and DB_NAME must not be empty"))
+	}
+	if db.Port < 1 || db.Port > 65535 {
+		errs = append(errs, fmt.Errorf("DB_PORT must be between 1 and 65535, got %d", db.Port))
+	}
+	switch db.SSLMode {
+	case "disable", "allow", "prefer", "require", "verify-ca", "verify-full":
+	default:
+		errs = append(errs, fmt.Errorf("DB_SSLMODE must be a PostgreSQL sslmode such as disable or verify-full, got %q", db.SSLMode))
+	}
+	if db.SlowQueryThreshold < 0 {
+		errs = append(errs, fmt.Errorf("DB_SLOW_QUERY_THRESHOLD must not be negative, got %s", db.SlowQueryThreshold))
+	}
+	if db.StatementCacheCapacity < 0 {
+		errs = append(errs, fmt.Errorf("DB_STATEMENT_CACHE_CAPACITY must not be negative, got %d", db.StatementCacheCapacity))
+	}
+	if db.Pool.MaxConns < 1 || db.Pool.MaxConns > math.MaxInt32 {
+		errs = append(errs, fmt.Errorf("DB_POOL_MAX_CONNS must be positive, got %d", db.Pool.MaxConns))
+	}
+	if db.Pool.MinConns < 0 || db.Pool.MinConns > db.Pool.MaxConns {
+		errs = append(errs, fmt.Errorf("DB_POOL_MIN_CONNS must be between 0 and DB_POOL_MAX_CONNS (%d), got %d", db.Pool.MaxConns, db.Pool.MinConns))
+	}
+	if db.Pool.MaxConnLifetime <= 0 || db.Pool.MaxConnIdleTime <= 0 || db.Pool.HealthCheckPeriod <= 0 {
+		errs = append(errs, errors.New("DB_POOL_MAX_CONN_LIFETIME, DB_POOL_MAX_CONN_IDLE_TIME and DB_POOL_HEALTH_CHECK_PERIOD must be positive"))
+	}
+	if db.Reconnect.PingInterval <= 0 {
+		errs = append(errs, fmt.Errorf("DB_PING_INTERVAL must be positive, got %s", db.Reconnect.PingInterval))
+	}
+	if db.Reconnect.FailureThreshold < 1 {
+		errs = append(errs, fmt.Errorf("DB_PING_FAILURE_THRESHOLD must be at least 1, got %d", db.Reconnect.FailureThreshold))
+	}
+	if db.Reconnect.MaxBackoff < db.Reconnect.PingInterval {
+		errs = append(errs, fmt.Errorf("DB_RECONNECT_MAX_BACKOFF must be at least DB_PING_INTERVAL (%s), got %s", db.Reconnect.PingInterval, db.Reconnect.MaxBackoff))
+	}
+
+	if c.Auth.JWTSecret == "" {
+		errs = append(errs, errors.New("JWT_SECRET must not be empty"))
+	}
+	if c.Auth.JWTIssuer == "" {
+		errs = append(errs, errors.New("JWT_ISSUER must not be empty"))
+	}
+	if c.Auth.BcryptCost < bcrypt.MinCost || c.Auth.BcryptCost > bcrypt.MaxCost {
+		errs = append(errs, fmt.Errorf("BCRYPT_COST must be between %d and %d, got %d", bcrypt.MinCost, bcrypt.MaxCost, c.Auth.BcryptCost))
+	}
+	if c.Timeouts.Default < 0 {
+		errs = append(errs, fmt.Errorf("RPC_TIMEOUT must not be negative, got %s", c.Timeouts.Default))
+	}
+	for method, d := range c.Timeouts.Methods {
+		if d < 0 {
+			errs = append(errs, fmt.Errorf("timeout of %s must not be negative, got %s", method, d))
+		}
+	}
+	if c.Concurrency.Max < 0 {
+		errs = append(errs, fmt.Errorf("RPC_MAX_CONCURRENT must not be negative, got %d", c.Concurrency.Max))
+	}
+	for method, n := range c.Concurrency.Methods {
+		if n <= 0 {
+			errs = append(errs, fmt.Errorf("concurrency limit of %s must be positive, got %d", method, n))
+		}
+	}
+	if c.Cache.Size < 0 {
+		errs = append(errs, fmt.Errorf("RESPONSE_CACHE_SIZE must not be negative, got %d", c.Cache.Size))
+	}
+	if c.Cache.Size > 0 && c.Cache.TTL <= 0 {
+		errs = append(errs, fmt.Errorf("RESPONSE_CACHE_TTL must be positive, got %s", c.Cache.TTL))
+	}
+	if c.Shadow.Percent < 0 || c.Shadow.Percent > 100 {
+		errs = append(errs, fmt.Errorf("SHADOW_PERCENT must be between 0 and 100, got %d", c.Shadow.Percent))
+	}
+	if c.Idempotency.KeyTTL <= 0 {
+		errs = append(errs, fmt.Errorf("IDEMPOTENCY_KEY_TTL must be positive, got %s", c.Idempotency.KeyTTL))
+	}
+	if c.Quotas.BooksAddedPerDay < 0 || c.Quotas.BooksDeletedPerDay < 0 {
+		errs = append(errs, errors.New("QUOTA_BOOKS_ADDED_PER_DAY and QUOTA_BOOKS_DELETED_PER_DAY must not be negative"))
+	}
+	if c.Notifications.KeepaliveInterval < 0 || c.Notifications.History < 0 {
+		errs = append(errs, errors.New("NOTIFICATION_KEEPALIVE_INTERVAL and NOTIFICATION_HISTORY must not be negative"))
+	}
+	if c.Purge.Interval <= 0 {
+		errs = append(errs, fmt.Errorf("PURGE_INTERVAL must be positive, got %s", c.Purge.Interval))
+	}
+	if c.Purge.Retention < 0 {
+		errs = append(errs, fmt.Errorf("PURGE_RETENTION must not be negative, got %s", c.Purge.Retention))
+	}
+	for _, p := range []struct {
+		name   string
+		policy RetentionPolicy
+	}{
+		{"RETENTION_AUTH_EVENTS", c.Retention.AuthEvents},
+		{"RETENTION_AUDIT_LOG", c.Retention.AuditLog},
+	} {
+		if p.policy.AnonymizeAfter < 0 || p.policy.DeleteAfter < 0 {
+			errs = append(errs, fmt.Errorf("%s_ANONYMIZE_AFTER and %s_DELETE_AFTER must not be negative", p.name, p.name))
+		}
+	}
+	if u, err := url.Parse(c.Outbox.WebhookURL); c.Outbox.WebhookURL != "" && (err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "") {
+		errs = append(errs, errors.New("OUTBOX_WEBHOOK_URL must be an http or https URL"))
+	}
+	if c.Outbox.Interval <= 0 {
+		errs = append(errs, fmt.Errorf("OUTBOX_INTERVAL must be positive, got %s", c.Outbox.Interval))
+	}
+	if c.Outbox.BatchSize < 1 {
+		errs = append(errs, fmt.Errorf("OUTBOX_BATCH_SIZE must be at least 1, got %d", c.Outbox.BatchSize))
+	}
+	switch c.Storage.Backend {
+	case storagePostgres:
+	case storageMongoDB:
+		if c.Storage.MongoDB.URI == "" || c.Storage.MongoDB.Database == "" {
+			errs = append(errs, errors.New("MONGODB_URI and MONGODB_DATABASE must not be empty"))
+		}
+		// Libraries are kept apart by the tenant_id columns and row level security of the PostgreSQL tables
+		if c.Tenants.Enabled {
+			errs = append(errs, errors.New("TENANTS_ENABLED is not supported with STORAGE_BACKEND=mongodb"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("STORAGE_BACKEND must be postgres or mongodb, got %q", c.Storage.Backend))
+	}
+	errs = append(errs, c.validateAccess()...)
+	errs = append(errs, c.validateServices()...)
+	return errors.Join(errs...)
+}
+
+// validateAccess checks the settings of tokens, passwords, certificates and cookies
+func (c *Config) validateAccess() []error {
+	var errs []error
+	auth := c.Auth
+	if auth.AccessTokenTTL <= 0 || auth.RefreshTokenTTL <= 0 {
+		errs = append(errs, errors.New("ACCESS_TOKEN_TTL and REFRESH_TOKEN_TTL must be positive"))
+	}
+	// A refresh token that expires first could never be used to renew the access token
+	if auth.RefreshTokenTTL < auth.AccessTokenTTL {
+		errs = append(errs, fmt.Errorf("REFRESH_TOKEN_TTL (%s) must not be shorter than ACCESS_TOKEN_TTL (%s)", auth.RefreshTokenTTL, auth.AccessTokenTTL))
+	}
+	if auth.JWTClockSkew < 0 || auth.JWTClockSkew >= auth.AccessTokenTTL {
+		errs = append(errs, fmt.Errorf("JWT_CLOCK_SKEW must be between 0 and ACCESS_TOKEN_TTL (%s), got %s", auth.AccessTokenTTL, auth.JWTClockSkew))
+	}
+	if auth.JWTPrivateKeyFile == "" && len(auth.JWTVerificationKeyFiles) > 0 {
+		errs = append(errs, errors.New("JWT_VERIFICATION_KEY_FILES requires JWT_PRIVATE_KEY_FILE"))
+	}
+
+	tls := c.TLS
+	if (tls.CertFile == "") != (tls.KeyFile == "") {
+		errs = append(errs, errors.New("TLS_CERT_FILE and TLS_KEY_FILE must be set together"))
+	}
+	if tls.ClientCAFile != "" && tls.CertFile == "" {
+		errs = append(errs, errors.New("TLS_CLIENT_CA_FILE requires TLS_CERT_FILE and TLS_KEY_FILE"))
+	}
+	if _, err := parseCertIdentities(tls.Identities); err != nil {
+		errs = append(errs, err)
+	}
+
+	pw := c.Passwords
+	switch strings.ToLower(pw.HashAlgorithm) {
+	case "argon2id", "bcrypt":
+	default:
+		errs = append(errs, fmt.Errorf("PASSWORD_HASH_ALGORITHM must be argon2id or bcrypt, got %q", pw.HashAlgorithm))
+	}
+	for _, p := range []struct {
+		name     string
+		value    int
+		min, max int
+	}{
+		{"ARGON2_MEMORY_KIB", pw.Argon2MemoryKiB, 1, 4 * 1024 * 1024},
+		{"ARGON2_ITERATIONS", pw.Argon2Iterations, 1, 100},
+		{"ARGON2_PARALLELISM", pw.Argon2Parallelism, 1, 255},
+		{"PASSWORD_HISTORY_DEPTH", pw.HistoryDepth, 0, 50},
+	} {
+		if p.value < p.min || p.value > p.max {
+			errs = append(errs, fmt.Errorf("%s must be a number between %d and %d, got %d", p.name, p.min, p.max, p.value))
+		}
+	}
+	if pw.Argon2MemoryKiB < 8*pw.Argon2Parallelism {
+		errs = append(errs, errors.New("ARGON2_MEMORY_KIB must be at least 8 KiB per ARGON2_PARALLELISM lane"))
+	}
+	if c.RateLimits.PerIP < 0 || c.RateLimits.PerUser < 0 {
+		errs = append(errs, errors.New("AUTH_RATE_LIMIT_PER_IP and AUTH_RATE_LIMIT_PER_USER must not be negative"))
+	}
+
+	for _, origin := range c.Gateway.CORSAllowedOrigins {
+		if origin == "*" {
+			errs = append(errs, errors.New("CORS_ALLOWED_ORIGINS cannot contain *; list the origins explicitly"))
+		}
+	}
+	switch sameSite := strings.ToLower(c.Gateway.Cookies.SameSite); sameSite {
+	case "strict", "lax":
+	case "none":
+		// Browsers drop SameSite=None cookies that are not also Secure
+		if !c.Gateway.Cookies.Secure {
+			errs = append(errs, errors.New("AUTH_COOKIE_SAMESITE=none requires AUTH_COOKIE_SECURE=true"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("AUTH_COOKIE_SAMESITE must be strict, lax or none, got %q", c.Gateway.Cookies.SameSite))
+	}
+
+	if provider := strings.ToLower(c.Challenge.Provider); provider != "" {
+		if _, ok := challengeVerifyURLs[provider]; !ok {
+			errs = append(errs, fmt.Errorf("CHALLENGE_PROVIDER must be turnstile or hcaptcha, got %q", c.Challenge.Provider))
+		}
+		if c.Challenge.Secret == "" {
+			errs = append(errs, errors.New("CHALLENGE_SECRET is required with CHALLENGE_PROVIDER"))
+		}
+	}
+	if c.OIDC.Issuer != "" && c.OIDC.ClientID == "" {
+		errs = append(errs, errors.New("OIDC_CLIENT_ID is required with OIDC_ISSUER"))
+	}
+	return errs
+}
+
+// validateServices checks the settings of logging, mail, circulation, the catalog and error reporting
+func (c *Config) validateServices() []error {
+	var errs []error
+	switch format := strings.ToLower(c.Logging.Format); format {
+	case "text", "json":
+	default:
+		errs = append(errs, fmt.Errorf("LOG_FORMAT must be text or json, got %q", c.Logging.Format))
+	}
+	if _, _, err := c.Logging.levels(); err != nil {
+		errs = append(errs, err)
+	}
+
+	if c.Mail.Host != "" {
+		if c.Mail.Port < 1 || c.Mail.Port > 65535 {
+			errs = append(errs, fmt.Errorf("SMTP_PORT must be between 1 and 65535, got %d", c.Mail.Port))
+		}
+		if _, err := normalizeEmail(c.Mail.From); err != nil {
+			errs = append(errs, fmt.Errorf("SMTP_FROM: %w", err))
+		}
+	}
+
+	if c.Circulation.Interval <= 0 {
+		errs = append(errs, fmt.Errorf("CIRCULATION_INTERVAL must be positive, got %s", c.Circulation.Interval))
+	}
+	if c.Circulation.FinePerDayCents < 0 || c.Circulation.FineMaxCents < 0 {
+		errs = append(errs, errors.New("FINE_PER_DAY_CENTS and FINE_MAX_CENTS must not be negative"))
+	}
+	if _, err := parseDuplicatePolicy(c.Catalog.DuplicateChecks); err != nil {
+		errs = append(errs, err)
+	}
+	if u, err := url.Parse(c.Catalog.ISBNLookupURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		errs = append(errs, errors.New("ISBN_LOOKUP_URL must be an http or https URL"))
+	}
+	if c.ErrorReporting.SentryDSN != "" {
+		if _, _, err := parseSentryDSN(c.ErrorReporting.SentryDSN); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// DSN is the connection string of the database
+func (db DatabaseConfig) DSN() string {
+	u := url.URL{
+		Scheme:   "postgresql",
+		User:     url.UserPassword(db.User, db.Password),
+		Host:     net.JoinHostPort(db.Host, strconv.Itoa(db.Port)),
+		Path:     "/" + db.Name,
+		RawQuery: url.Values{"sslmode": {db.SSLMode}}.Encode(),
+	}
+	return u.String()
+}
+
+// serverOptions applies the message size limits and keepalive settings to the gRPC server
+func (c GRPCConfig) serverOptions() []grpc.ServerOption {
+	return []grpc.ServerOption{
+		grpc.MaxRecvMsgSize(c.MaxRecvMsgSize),
+		grpc.MaxSendMsgSize(c.MaxSendMsgSize),
+		grpc.KeepaliveParams(keepalive.ServerParameters{
+			Time:                  c.Keepalive.Time,
+			Timeout:               c.Keepalive.Timeout,
+			MaxConnectionIdle:     c.Keepalive.MaxConnectionIdle,
+			MaxConnectionAge:      c.Keepalive.MaxConnectionAge,
+			MaxConnectionAgeGrace: c.Keepalive.MaxConnectionAgeGrace,
+		}),
+		grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{
+			MinTime:             c.Keepalive.MinTime,
+			PermitWithoutStream: c.Keepalive.PermitWithoutStream,
+		}),
+	}
+}
+
+// callOptions lets a client of the server, such as the gateway, send and receive messages as large as the
+// server allows
+func (c GRPCConfig) callOptions() []grpc.CallOption {
+	return []grpc.CallOption{grpc.MaxCallSendMsgSize(c.MaxRecvMsgSize), grpc.MaxCallRecvMsgSize(c.MaxSendMsgSize)}
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// loadTestConfig loads the configuration from a clean environment plus env, with args on the command line
+func loadTestConfig(t *testing.T, env map[string]string, args ...string) (*Config, error) {
+	t.Helper()
+	t.Setenv("CONFIG_FILE", "")
+	for _, s := range (&Config{}).settings() {
+		t.Setenv(s.env, env[s.env])
+	}
+	for name, value := range env {
+		t.Setenv(name, value)
+	}
+	fs := flag.NewFlagSet("server", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	bindConfigFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		t.Fatalf("parsing %v: %v", args, err)
+	}
+	return LoadConfig(fs)
+}
+
+func TestLoadConfigDefaults(t *testing.T) {
+	cfg, err := loadTestConfig(t, nil)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	want := defaultConfig()
+	want.Gateway.Upstream = "localhost:50051"
+	if !reflect.DeepEqual(*cfg, want) {
+		t.Errorf("LoadConfig() = %+v, want %+v", *cfg, want)
+	}
+}
+
+func TestLoadConfigPrecedence(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "server.yaml")
+	yaml := `
+grpc:
+  addr: ":6000"
+  max_recv_msg_size: 33554432
+  keepalive:
+    time: 1m
+    max_connection_age: 30m
+gateway:
+  addr: ":9000"
+database:
+  host: db.internal
+  port: 6432
+  name: library
+auth:
+  jwt_secret: from-file
+  bcrypt_cost: 12
+timeouts:
+  methods:
+    /library.LibraryService/ExportBooks: 10m
+`
+	if err := os.WriteFile(file, []byte(yaml), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := loadTestConfig(t, map[string]string{"CONFIG_FILE": file, "DB_HOST": "db.env", "GATEWAY_ADDR": ":9100", "GRPC_MAX_CONNECTION_AGE": "45m"},
+		"-gateway-addr", ":9200", "-db-port", "7432", "-grpc-keepalive-permit-without-stream")
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	// File over defaults, the environment over the file, flags over both
+	if cfg.GRPC.Addr != ":6000" || cfg.Gateway.Upstream != "localhost:6000" {
+		t.Errorf("gRPC address = %q, upstream %q; want the file's port", cfg.GRPC.Addr, cfg.Gateway.Upstream)
+	}
+	if cfg.GRPC.MaxRecvMsgSize != 32<<20 || cfg.GRPC.Keepalive.Time != time.Minute || cfg.GRPC.Keepalive.MaxConnectionAge != 45*time.Minute {
+		t.Errorf("gRPC settings = %+v", cfg.GRPC)
+	}
+	if !cfg.GRPC.Keepalive.PermitWithoutStream || cfg.GRPC.Keepalive.Timeout != 20*time.Second {
+		t.Errorf("keepalive = %+v, want the permission from the flag and the default timeout", cfg.GRPC.Keepalive)
+	}
+	if cfg.Database.Host != "db.env" || cfg.Database.Name != "library" || cfg.Database.User != "postgres" {
+		t.Errorf("database = %+v", cfg.Database)
+	}
+	if cfg.Gateway.Addr != ":9200" || cfg.Database.Port != 7432 {
+		t.Errorf("gateway address = %q, database port %d; want the flags' values", cfg.Gateway.Addr, cfg.Database.Port)
+	}
+	if gw := cfg.Gateway; gw.ReadTimeout != 30*time.Second || gw.WriteTimeout != 0 || gw.IdleTimeout != 2*time.Minute || gw.TLSCertFile != "" {
+		t.Errorf("gateway = %+v, want the default timeouts over plain HTTP", gw)
+	}
+	if cfg.Timeouts.Default != 30*time.Second || cfg.Timeouts.Methods["/library.LibraryService/ExportBooks"] != 10*time.Minute {
+		t.Errorf("timeouts = %+v", cfg.Timeouts)
+	}
+	if cfg.Auth.JWTSecret != "from-file" || cfg.Auth.BcryptCost != 12 {
+		t.Errorf("auth = %+v", cfg.Auth)
+	}
+}
+
+func TestLoadConfigErrors(t *testing.T) {
+	for name, env := range map[string]map[string]string{
+		"bad port":        {"DB_PORT": "postgres"},
+		"port range":      {"DB_PORT": "70000"},
+		"bcrypt cost":     {"BCRYPT_COST": "99"},
+		"sslmode":         {"DB_SSLMODE": "sometimes"},
+		"listen address":  {"GRPC_ADDR": "50051"},
+		"gateway tls":     {"GATEWAY_TLS_CERT_FILE": "gateway.pem"},
+		"gateway timeout": {"GATEWAY_WRITE_TIMEOUT": "-1s"},
+		"message size":    {"GRPC_MAX_RECV_MSG_SIZE": "0"},
+		"duration":        {"GRPC_KEEPALIVE_TIME": "often"},
+		"keepalive time":  {"GRPC_KEEPALIVE_TIME": "100ms"},
+		"boolean":         {"GRPC_KEEPALIVE_PERMIT_WITHOUT_STREAM": "sometimes"},
+		"rpc timeout":     {"RPC_TIMEOUT": "-1s"},
+		"concurrency":     {"RPC_MAX_CONCURRENT": "-1"},
+		"cache ttl":       {"RESPONSE_CACHE_SIZE": "100", "RESPONSE_CACHE_TTL": "0s"},
+		"shadow percent":  {"SHADOW_PERCENT": "101"},
+		"quota":           {"QUOTA_BOOKS_ADDED_PER_DAY": "-1"},
+		"pool size":       {"DB_POOL_MAX_CONNS": "0"},
+		"statement cache": {"DB_STATEMENT_CACHE_CAPACITY": "-1"},
+		"min conns":       {"DB_POOL_MAX_CONNS": "4", "DB_POOL_MIN_CONNS": "5"},
+		"health check":    {"DB_POOL_HEALTH_CHECK_PERIOD": "0s"},
+		"purge interval":  {"PURGE_INTERVAL": "0s"},
+		"purge retention": {"PURGE_RETENTION": "-1h"},
+		"retention":       {"RETENTION_AUDIT_LOG_DELETE_AFTER": "-1h"},
+		"outbox webhook":  {"OUTBOX_WEBHOOK_URL": "ftp://example.com/events"},
+		"outbox batch":    {"OUTBOX_BATCH_SIZE": "0"},
+		"storage backend": {"STORAGE_BACKEND": "cassandra"},
+		"mongodb tenants": {"STORAGE_BACKEND": "mongodb", "TENANTS_ENABLED": "true"},
+		"missing file":    {"CONFIG_FILE": filepath.Join(t.TempDir(), "missing.yaml")},
+		"access ttl":      {"ACCESS_TOKEN_TTL": "0s"},
+		"refresh ttl":     {"ACCESS_TOKEN_TTL": "48h", "REFRESH_TOKEN_TTL": "24h"},
+		"clock skew":      {"ACCESS_TOKEN_TTL": "1m", "JWT_CLOCK_SKEW": "1m"},
+		"jwt keys":        {"JWT_VERIFICATION_KEY_FILES": "old.pub"},
+		"tls pair":        {"TLS_CERT_FILE": "server.pem"},
+		"tls client ca":   {"TLS_CLIENT_CA_FILE": "ca.pem"},
+		"mtls identities": {"MTLS_IDENTITIES": "reporting,reporting"},
+		"hash algorithm":  {"PASSWORD_HASH_ALGORITHM": "md5"},
+		"argon2 memory":   {"ARGON2_MEMORY_KIB": "0"},
+		"argon2 lanes":    {"ARGON2_MEMORY_KIB": "16", "ARGON2_PARALLELISM": "4"},
+		"history depth":   {"PASSWORD_HISTORY_DEPTH": "51"},
+		"rate limit":      {"AUTH_RATE_LIMIT_PER_IP": "-1"},
+		"cors wildcard":   {"CORS_ALLOWED_ORIGINS": "*"},
+		"samesite":        {"AUTH_COOKIE_SAMESITE": "loose"},
+		"samesite none":   {"AUTH_COOKIE_SAMESITE": "none"},
+		"challenge":       {"CHALLENGE_PROVIDER": "recaptcha", "CHALLENGE_SECRET": "secret"},
+		"challenge key":   {"CHALLENGE_PROVIDER": "turnstile"},
+		"oidc client":     {"OIDC_ISSUER": "https://accounts.example.com"},
+		"log format":      {"LOG_FORMAT": "xml"},
+		"log level":       {"LOG_LEVEL": "loud"},
+		"smtp port":       {"SMTP_HOST": "mail.example.com", "SMTP_PORT": "0"},
+		"smtp from":       {"SMTP_HOST": "mail.example.com", "SMTP_FROM": "library"},
+		"circulation":     {"CIRCULATION_INTERVAL": "0s"},
+		"fines":           {"FINE_PER_DAY_CENTS": "-1"},
+		"duplicate check": {"DUPLICATE_CHECK": "isbn,author"},
+		"isbn lookup":     {"ISBN_LOOKUP_URL": "openlibrary.org"},
+		"sentry dsn":      {"SENTRY_DSN": "not a dsn"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := loadTestConfig(t, env); err == nil {
+				t.Errorf("%v: expected an error", env)
+			}
+		})
+	}
+
+	file := filepath.Join(t.TempDir(), "server.yaml")
+	if err := os.WriteFile(file, []byte("database:\n  hostname: db\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadTestConfig(t, nil, "-config", file); err == nil || !strings.Contains(err.Error(), "hostname") {
+		t.Errorf("unknown key: error = %v", err)
+	}
+}
+
+func TestDatabaseDSN(t *testing.T) {
+	db := DatabaseConfig{Host: "db", Port: 5432, User: "app", Password: "p@ss/word", Name: "library", SSLMode: "verify-full"}
+	if got, want := db.DSN(), "postgresql://app:p%40ss%2Fword@db:5432/library?sslmode=verify-full"; got != want {
+		t.Errorf("DSN() = %q, want %q", got, want)
+	}
+}
+
+func TestLocalAddr(t *testing.T) {
+	for addr, want := range map[string]string{
+		":50051":         "localhost:50051",
+		"0.0.0.0:50051":  "localhost:50051",
+		"10.0.0.5:50051": "10.0.0.5:50051",
+	} {
+		if got := localAddr(addr); got != want {
+			t.Errorf("localAddr(%q) = %q, want %q", addr, got, want)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	pb "example/grpc_demo/library"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	// Cookies the gateway keeps the tokens of browser clients in, out of reach of scripts
+	accessTokenCookie  = "library_access"
+	refreshTokenCookie = "library_refresh"
+	// The access token is needed by every API call, the refresh token only by RefreshToken
+	accessCookiePath  = "/api"
+	refreshCookiePath = "/api/v1/auth"
+	// refreshTokenHeader carries the refresh cookie to RefreshToken when the request body has no token
+	refreshTokenHeader = "x-refresh-token"
+)
+
+// authCookies sets the token cookies on REST responses and reads them back on requests
+type authCookies struct {
+	secure   bool
+	sameSite http.SameSite
+}
+
+// newAuthCookies sets cookies with the configured Secure flag (off by default, so plain-HTTP development
+// works) and SameSite mode (strict, lax or none)
+func newAuthCookies(cfg CookieConfig) *authCookies {
+	c := &authCookies{secure: cfg.Secure, sameSite: http.SameSiteStrictMode}
+	switch strings.ToLower(cfg.SameSite) {
+	case "lax":
+		c.sameSite = http.SameSiteLaxMode
+	case "none":
+		c.sameSite = http.SameSiteNoneMode
+	}
+	return c
+}
+
+// forwardResponse is a gateway response hook: token responses set the cookies, logging out clears them
+func (c *authCookies) forwardResponse(ctx context.Context, w http.ResponseWriter, m proto.Message) error {
+	switch resp := m.(type) {
+	case *pb.AuthResponse:
+		if resp.GetToken() != "" {
+			http.SetCookie(w, c.cookie(accessTokenCookie, resp.GetToken(), accessCookiePath, resp.GetExpiresAt()))
+		}
+		if resp.GetRefreshToken() != "" {
+			http.SetCookie(w, c.cookie(refreshTokenCookie, resp.GetRefreshToken(), refreshCookiePath, resp.GetRefreshExpiresAt()))
+		}
+	case *pb.LogoutResponse:
+		// RevokeSession also answers with a LogoutResponse but ends some other session
+		method, _ := runtime.RPCMethod(ctx)
+		if method == "/library.UserService/Logout" || method == "/library.UserService/RevokeAllSessions" {
+			http.SetCookie(w, c.expired(accessTokenCookie, accessCookiePath))
+			http.SetCookie(w, c.expired(refreshTokenCookie, refreshCookiePath))
+		}
+	}
+	return nil
+}
+
+// metadata is a gateway metadata annotator passing the cookies on to the gRPC call. An Authorization
+// header takes precedence over the access cookie.
+func (c *authCookies) metadata(ctx context.Context, r *http.Request) metadata.MD {
+	md := metadata.MD{}
+	if r.Header.Get("Authorization") == "" {
+		if auth := cookieAuthorization(r); auth != "" {
+			md.Set("authorization", auth)
+		}
+	}
+	if cookie, err := r.Cookie(refreshTokenCookie); err == nil && cookie.Value != "" {
+		md.Set(refreshTokenHeader, cookie.Value)
+	}
+	return md
+}
+
+// cookieAuthorization turns the access cookie into an Authorization header value, or "" without one
+func cookieAuthorization(r *http.Request) string {
+	cookie, err := r.Cookie(accessTokenCookie)
+	if err != nil || cookie.Value == "" {
+		return ""
+	}
+	return "Bearer " + cookie.Value
+}
+
+func (c *authCookies) cookie(name, value, path string, expiresAt *timestamppb.Timestamp) *http.Cookie {
+	cookie := &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     path,
+		HttpOnly: true,
+		Secure:   c.secure,
+		SameSite: c.sameSite,
+	}
+	if expiresAt != nil {
+		cookie.MaxAge = int(time.Until(expiresAt.AsTime()).Seconds())
+		if cookie.MaxAge <= 0 {
+			cookie.MaxAge = -1
+		}
+	}
+	return cookie
+}
+
+func (c *authCookies) expired(name, path string) *http.Cookie {
+	return &http.Cookie{Name: name, Path: path, MaxAge: -1, HttpOnly: true, Secure: c.secure, SameSite: c.sameSite}
+}
+
+// refreshTokenFromRequest returns the refresh token from the request body, falling back to the refresh
+// cookie forwarded by the gateway
+func refreshTokenFromRequest(ctx context.Context, token string) string {
+	if token != "" {
+		return token
+	}
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(refreshTokenHeader); len(values) > 0 {
+			return values[0]
+		}
+	}
+	return ""
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	pb "example/grpc_demo/library"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestNewAuthCookies(t *testing.T) {
+	cfg, err := loadTestConfig(t, nil)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if c := newAuthCookies(cfg.Gateway.Cookies); c.secure || c.sameSite != http.SameSiteStrictMode {
+		t.Errorf("defaults: got %+v; want insecure strict cookies", c)
+	}
+
+	cfg, err = loadTestConfig(t, map[string]string{"AUTH_COOKIE_SECURE": "true", "AUTH_COOKIE_SAMESITE": "None"})
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if c := newAuthCookies(cfg.Gateway.Cookies); !c.secure || c.sameSite != http.SameSiteNoneMode {
+		t.Errorf("secure none: got %+v", c)
+	}
+}
+
+func TestAuthCookiesForwardResponse(t *testing.T) {
+	c := &authCookies{sameSite: http.SameSiteStrictMode}
+	w := httptest.NewRecorder()
+	resp := &pb.AuthResponse{
+		Token:            "access",
+		RefreshToken:     "refresh",
+		ExpiresAt:        timestamppb.New(time.Now().Add(time.Hour)),
+		RefreshExpiresAt: timestamppb.New(time.Now().Add(24 * time.Hour)),
+	}
+	if err := c.forwardResponse(context.Background(), w, resp); err != nil {
+		t.Fatalf("forwardResponse() error = %v", err)
+	}
+	cookies := map[string]*http.Cookie{}
+	for _, cookie := range w.Result().Cookies() {
+		cookies[cookie.Name] = cookie
+	}
+	access, refresh := cookies[accessTokenCookie], cookies[refreshTokenCookie]
+	if access == nil || access.Value != "access" || !access.HttpOnly || access.Path != accessCookiePath || access.SameSite != http.SameSiteStrictMode {
+		t.Errorf("access cookie = %+v", access)
+	}
+	if access != nil && (access.MaxAge <= 0 || access.MaxAge > 3600) {
+		t.Errorf("access cookie MaxAge = %d, want about an hour", access.MaxAge)
+	}
+	if refresh == nil || refresh.Value != "refresh" || !refresh.HttpOnly || refresh.Path != refreshCookiePath {
+		t.Errorf("refresh cookie = %+v", refresh)
+	}
+
+	// Logging out clears both cookies, revoking another session leaves them alone
+	for method, cleared := range map[string]bool{
+		"/library.UserService/Logout":            true,
+		"/library.UserService/RevokeAllSessions": true,
+		"/library.UserService/RevokeSession":     false,
+	} {
+		ctx, err := runtime.AnnotateContext(context.Background(), runtime.NewServeMux(), httptest.NewRequest("POST", "/", nil), method)
+		if err != nil {
+			t.Fatalf("AnnotateContext() error = %v", err)
+		}
+		w := httptest.NewRecorder()
+		if err := c.forwardResponse(ctx, w, &pb.LogoutResponse{}); err != nil {
+			t.Fatalf("forwardResponse() error = %v", err)
+		}
+		cookies := w.Result().Cookies()
+		if cleared && (len(cookies) != 2 || cookies[0].MaxAge >= 0 || cookies[1].MaxAge >= 0) {
+			t.Errorf("%s: cookies = %v, want both cleared", method, cookies)
+		}
+		if !cleared && len(cookies) != 0 {
+			t.Errorf("%s: cookies = %v, want none", method, cookies)
+		}
+	}
+}
+
+func TestAuthCookiesMetadata(t *testing.T) {
+	c := &authCookies{}
+	r := httptest.NewRequest("GET", "/api/v1/books", nil)
+	r.AddCookie(&http.Cookie{Name: accessTokenCookie, Value: "access"})
+	r.AddCookie(&http.Cookie{Name: refreshTokenCookie, Value: "refresh"})
+	md := c.metadata(context.Background(), r)
+	if got := md.Get("authorization"); len(got) != 1 || got[0] != "Bearer access" {
+		t.Errorf("authorization = %v, want [Bearer access]", got)
+	}
+	if got := md.Get(refreshTokenHeader); len(got) != 1 || got[0] != "refresh" {
+		t.Errorf("%s = %v, want [refresh]", refreshTokenHeader, got)
+	}
+
+	// An explicit Authorization header wins over the cookie
+	r.Header.Set("Authorization", "Bearer header")
+	if got := c.metadata(context.Background(), r).Get("authorization"); len(got) != 0 {
+		t.Errorf("with Authorization header: authorization = %v, want none from the cookie", got)
+	}
+	if md := c.metadata(context.Background(), httptest.NewRequest("GET", "/", nil)); md.Len() != 0 {
+		t.Errorf("without cookies: metadata = %v, want empty", md)
+	}
+}
+
+func TestRefreshTokenFromRequest(t *testing.T) {
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(refreshTokenHeader, "cookie"))
+	if got := refreshTokenFromRequest(ctx, "body"); got != "body" {
+		t.Errorf("with body token: got %q, want body", got)
+	}
+	if got := refreshTokenFromRequest(ctx, ""); got != "cookie" {
+		t.Errorf("without body token: got %q, want cookie", got)
+	}
+	if got := refreshTokenFromRequest(context.Background(), ""); got != "" {
+		t.Errorf("without either: got %q, want empty", got)
+	}
+}
+
+func TestCORSMiddleware(t *testing.T) {
+	cfg, err := loadTestConfig(t, map[string]string{"CORS_ALLOWED_ORIGINS": "http://localhost:3000/"})
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	origins := corsOrigins(cfg.Gateway)
+	if len(origins) != 1 || origins[0] != "http://localhost:3000" {
+		t.Fatalf("origins = %v", origins)
+	}
+
+	h := corsMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}), origins)
+	for origin, credentials := range map[string]bool{"http://localhost:3000": true, "https://evil.example": false} {
+		r := httptest.NewRequest("OPTIONS", "/api/v1/books", nil)
+		r.Header.Set("Origin", origin)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		allowOrigin, allowCredentials := w.Header().Get("Access-Control-Allow-Origin"), w.Header().Get("Access-Control-Allow-Credentials")
+		if credentials && (allowOrigin != origin || allowCredentials != "true") {
+			t.Errorf("%s: Allow-Origin %q, Allow-Credentials %q; want the origin with credentials", origin, allowOrigin, allowCredentials)
+		}
+		if !credentials && (allowOrigin != "*" || allowCredentials != "") {
+			t.Errorf("%s: Allow-Origin %q, Allow-Credentials %q; want * without credentials", origin, allowOrigin, allowCredentials)
+		}
+		if expose := w.Header().Get("Access-Control-Expose-Headers"); expose != "X-Request-Id" {
+			t.Errorf("%s: Expose-Headers %q, want X-Request-Id", origin, expose)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	pb "example/grpc_demo/library"
+
+	"github.com/jackc/pgx/v5"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// maxCoverSize is the largest cover image accepted by UploadCover
+	maxCoverSize = 5 << 20
+	// coverChunkSize is the number of image bytes sent per DownloadCover message
+	coverChunkSize = 64 << 10
+)
+
+// allowedCoverTypes lists the image content types accepted for covers
+var allowedCoverTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+	"image/webp": true,
+}
+
+// coverURL returns the gateway route serving a book's cover
+func coverURL(bookID string) string {
+	return "/api/v1/books/" + url.PathEscape(bookID) + "/cover"
+}
+
+// detectCoverType validates the declared content type against the image bytes
+func detectCoverType(declared string, data []byte) (string, error) {
+	sniffed := http.DetectContentType(data)
+	if !allowedCoverTypes[sniffed] {
+		return "", status.Errorf(codes.InvalidArgument, "unsupported cover content type: %s", sniffed)
+	}
+	declared = strings.ToLower(strings.TrimSpace(declared))
+	if declared != "" && declared != sniffed {
+		return "", status.Errorf(codes.InvalidArgument, "declared content type %s does not match image data (%s)", declared, sniffed)
+	}
+	return sniffed, nil
+}
+
+func (s *server) UploadCover(stream pb.LibraryService_UploadCoverServer) error {
+	ctx := stream.Context()
+	var (
+		bookID      string
+		contentType string
+		buf         bytes.Buffer
+	)
+
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return status.Errorf(codes.Internal, "failed to receive cover chunk: %v", err)
+		}
+		if bookID == "" {
+			bookID = chunk.GetBookId()
+			contentType = chunk.GetContentType()
+		}
+		if buf.Len()+len(chunk.GetData()) > maxCoverSize {
+			return status.Errorf(codes.InvalidArgument, "cover exceeds maximum size of %d bytes", maxCoverSize)
+		}
+		buf.Write(chunk.GetData())
+	}
+
+	if bookID == "" {
+		return status.Error(codes.InvalidArgument, "Book ID is required")
+	}
+	if buf.Len() == 0 {
+		return status.Error(codes.InvalidArgument, "cover image is empty")
+	}
+	contentType, err := detectCoverType(contentType, buf.Bytes())
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return status.Errorf(codes.Internal, "database error: %v", err)
+	}
+	defer tx.Rollback(ctx)
+
+	coverLink := coverURL(bookID)
+	res, err := tx.Exec(ctx, "UPDATE books SET cover_url=$1 WHERE id=$2", coverLink, bookID)
+	if err != nil {
+		return status.Errorf(codes.Internal, "failed to update book: %v", err)
+	}
+	if res.RowsAffected() == 0 {
+		return notFound(resourceBook, bookID, "Book not found")
+	}
+	_, err = tx.Exec(ctx,
+		`INSERT INTO book_covers (book_id, content_type, data) VALUES ($1, $2, $3)
+		 ON CONFLICT (tenant_id, book_id) DO UPDATE SET content_type=EXCLUDED.content_type, data=EXCLUDED.data, updated_at=NOW()`,
+		bookID, contentType, buf.Bytes())
+	if err != nil {
+		return status.Errorf(codes.Internal, "failed to store cover: %v", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return status.Errorf(codes.Internal, "failed to store cover: %v", err)
+	}
+
+	return stream.SendAndClose(&pb.CoverResponse{
+		BookId:   bookID,
+		CoverUrl: coverLink,
+		Size:     int64(buf.Len()),
+	})
+}
+
+func (s *server) DownloadCover(req *pb.BookRequest, stream pb.LibraryService_DownloadCoverServer) error {
+	if req.GetId() == "" {
+		return status.Error(codes.InvalidArgument, "Book ID is required")
+	}
+
+	var contentType string
+	var data []byte
+	err := s.db.QueryRow(stream.Context(), "SELECT content_type, data FROM book_covers WHERE book_id=$1", req.GetId()).Scan(&contentType, &data)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return notFound(resourceCover, req.GetId(), "Cover not found")
+	}
+	if err != nil {
+		return status.Errorf(codes.Internal, "failed to load cover: %v", err)
+	}
+
+	// The first chunk carries the book ID and content type, like uploads
+	for offset := 0; offset < len(data); offset += coverChunkSize {
+		end := min(offset+coverChunkSize, len(data))
+		chunk := &pb.CoverChunk{Data: data[offset:end]}
+		if offset == 0 {
+			chunk.BookId = req.GetId()
+			chunk.ContentType = contentType
+		}
+		if err := stream.Send(chunk); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	pb "example/grpc_demo/library"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+func TestDetectCoverType(t *testing.T) {
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+	jpeg := []byte("\xff\xd8\xff\xe0\x00\x10JFIF\x00")
+
+	tests := []struct {
+		name     string
+		declared string
+		data     []byte
+		want     string
+		wantErr  bool
+	}{
+		{"PNG without declared type", "", png, "image/png", false},
+		{"PNG with matching type", "image/png", png, "image/png", false},
+		{"JPEG with mismatched type", "image/png", jpeg, "", true},
+		{"Plain text", "", []byte("hello world"), "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := detectCoverType(tt.declared, tt.data)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("detectCoverType() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("detectCoverType() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCoverURL(t *testing.T) {
+	if got := coverURL("book 1"); got != "/api/v1/books/book%201/cover" {
+		t.Errorf("coverURL() = %q", got)
+	}
+}
+
+// coverStream keeps the chunks sent by DownloadCover
+type coverStream struct {
+	grpc.ServerStream
+	chunks []*pb.CoverChunk
+}
+
+func (s *coverStream) Context() context.Context { return context.Background() }
+
+func (s *coverStream) Send(chunk *pb.CoverChunk) error {
+	s.chunks = append(s.chunks, chunk)
+	return nil
+}
+
+// TestDownloadCover checks a stored cover is streamed in chunks, the first with its book and type, against
+// a fake database
+func TestDownloadCover(t *testing.T) {
+	pool, db := newFakeDatabase(t)
+	s := &server{db: pool}
+	cover := bytes.Repeat([]byte("\x89PNG cover "), coverChunkSize*5/2/12)
+	db.answer("WHERE book_id=('b1')", "SELECT 1", []any{"image/png", cover})
+	db.answer("FROM book_covers", "SELECT 0")
+
+	if err := s.DownloadCover(&pb.BookRequest{}, &coverStream{}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("DownloadCover() without a book error = %v, want InvalidArgument", err)
+	}
+	if err := s.DownloadCover(&pb.BookRequest{Id: "b2"}, &coverStream{}); status.Code(err) != codes.NotFound {
+		t.Errorf("DownloadCover() of a book without a cover error = %v, want NotFound", err)
+	}
+
+	stream := &coverStream{}
+	if err := s.DownloadCover(&pb.BookRequest{Id: "b1"}, stream); err != nil {
+		t.Fatalf("DownloadCover() error = %v", err)
+	}
+	if len(stream.chunks) != 3 {
+		t.Fatalf("DownloadCover() sent %d chunks of %d bytes, want 3", len(stream.chunks), len(cover))
+	}
+	var got []byte
+	for i, chunk := range stream.chunks {
+		if len(chunk.GetData()) > coverChunkSize {
+			t.Errorf("chunk %d has %d bytes, more than %d", i, len(chunk.GetData()), coverChunkSize)
+		}
+		if first := i == 0; (chunk.GetBookId() == "b1") != first || (chunk.GetContentType() == "image/png") != first {
+			t.Errorf("chunk %d has book %q and type %q, want them on the first chunk only", i, chunk.GetBookId(), chunk.GetContentType())
+		}
+		got = append(got, chunk.GetData()...)
+	}
+	if !bytes.Equal(got, cover) {
+		t.Errorf("DownloadCover() sent %d bytes that aren't the cover's %d", len(got), len(cover))
+	}
+
+	// Through the gateway, the cover is the body with its stored type, and a missing one is a 404
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	gs := grpc.NewServer()
+	pb.RegisterLibraryServiceServer(gs, s)
+	go gs.Serve(lis)
+	defer gs.Stop()
+	conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	mux := runtime.NewServeMux()
+	if err := mux.HandlePath("GET", "/api/v1/books/{id}/cover", coverHandler(pb.NewLibraryServiceClient(conn))); err != nil {
+		t.Fatal(err)
+	}
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, coverURL("b1"), nil))
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "image/png" || !bytes.Equal(w.Body.Bytes(), cover) {
+		t.Errorf("GET %s: %d %s with %d bytes, want the cover", coverURL("b1"), w.Code, w.Header().Get("Content-Type"), w.Body.Len())
+	}
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, coverURL("b2"), nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("GET %s: %d %s, want 404", coverURL("b2"), w.Code, w.Body)
+	}
+}
@@ -2,26 +2,43 @@ package main
 
 import (
 	"context"
-	"fmt"
 	"os"
+	"strings"
 	"time"
 
+	"example/grpc_demo/server/storage"
+
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
-	"github.com/joho/godotenv"
 )
 
-func NewDBPool() (*pgxpool.Pool, error) {
-	_ = godotenv.Load("../.env")
-	host := os.Getenv("DB_HOST")
-	port := os.Getenv("DB_PORT")
-	user := os.Getenv("DB_USER")
-	password := os.Getenv("DB_PASSWORD")
-	dbname := os.Getenv("DB_NAME")
+// querier is the subset of pgx methods shared by *pgxpool.Pool and pgx.Tx,
+// so helpers can run either standalone or inside a transaction
+type querier = storage.Querier
+
+// conn returns the transaction of the unit of work of ctx, or the pool outside of one
+func (s *server) conn(ctx context.Context) querier {
+	return storage.Conn(ctx, s.db)
+}
 
-	dsn := fmt.Sprintf("postgresql://%s:%s@%s:%s/%s?sslmode=disable", user, password, host, port, dbname)
+// NewDBPool connects to the configured database and checks that it answers
+func NewDBPool(cfg DatabaseConfig) (*pgxpool.Pool, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
-	pool, err := pgxpool.New(ctx, dsn)
+	config, err := pgxpool.ParseConfig(cfg.DSN())
+	if err != nil {
+		return nil, err
+	}
+	// Every statement becomes a span of the RPC it runs for, and slow ones are logged
+	config.ConnConfig.Tracer = dbTracer{slowQuery: cfg.SlowQueryThreshold}
+	config.MaxConns = int32(cfg.Pool.MaxConns)
+	config.MinConns = int32(cfg.Pool.MinConns)
+	config.MaxConnLifetime = cfg.Pool.MaxConnLifetime
+	config.MaxConnIdleTime = cfg.Pool.MaxConnIdleTime
+	config.HealthCheckPeriod = cfg.Pool.HealthCheckPeriod
+	useStatementCache(config, cfg.StatementCacheCapacity)
+	useTenantSetting(config)
+	pool, err := pgxpool.NewWithConfig(ctx, config)
 	if err != nil {
 		return nil, err
 	}
@@ -31,22 +48,79 @@ func NewDBPool() (*pgxpool.Pool, error) {
 	return pool, nil
 }
 
+// useStatementCache makes the connections of config prepare the statements they run and keep up to capacity
+// of them, ListBooks' among others, so a statement is only parsed and planned once per connection. A capacity
+// of 0 runs every statement unprepared, as poolers in transaction mode such as PgBouncer need.
+func useStatementCache(config *pgxpool.Config, capacity int) {
+	config.ConnConfig.StatementCacheCapacity = capacity
+	if capacity == 0 {
+		config.ConnConfig.DefaultQueryExecMode = pgx.QueryExecModeDescribeExec
+		return
+	}
+	config.ConnConfig.DefaultQueryExecMode = pgx.QueryExecModeCacheStatement
+	config.AfterConnect = func(ctx context.Context, conn *pgx.Conn) error {
+		for _, sql := range storage.PreparedQueries {
+			if _, err := conn.Prepare(ctx, sql, sql); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// migrationsFile is the idempotent schema applied at every start, relative to the server's working directory
+const migrationsFile = "migrations.sql"
+
+// RunMigrations applies migrationsFile, whose tables hold the rows of every library
 func RunMigrations(pool *pgxpool.Pool) error {
-	data, err := os.ReadFile("migrations.sql")
+	data, err := os.ReadFile(migrationsFile)
 	if err != nil {
 		return err
 	}
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
-	_, err = pool.Exec(ctx, string(data))
+	_, err = pool.Exec(withTenant(ctx, ""), string(data))
 	return err
 }
 
+// appTables lists every table created by migrations.sql, dependents first
+var appTables = []string{
+	"audit_log",
+	"quota_usage",
+	"idempotency_keys",
+	"password_history",
+	"auth_events",
+	"email_verifications",
+	"password_resets",
+	"revoked_tokens",
+	"refresh_tokens",
+	"sessions",
+	"user_identities",
+	"fine_payments",
+	"fines",
+	"reservations",
+	"loans",
+	"shelf_books",
+	"shelves",
+	"favorites",
+	"reviews",
+	"book_tags",
+	"tags",
+	"book_copies",
+	"book_translations",
+	"book_covers",
+	"book_revisions",
+	"books",
+	"series",
+	"publishers",
+	"users",
+}
+
 func ClearDatabase(pool *pgxpool.Pool) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
 	// Drop tables completely
-	_, err := pool.Exec(ctx, "DROP TABLE IF EXISTS books; DROP TABLE IF EXISTS users;")
+	_, err := pool.Exec(ctx, "DROP TABLE IF EXISTS "+strings.Join(appTables, ", ")+", tenants CASCADE")
 	return err
 }
@@ -0,0 +1,335 @@
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	pb "example/grpc_demo/library"
+	"example/grpc_demo/server/storage"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgproto3"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// BenchmarkPostgresStatements authenticates a call and lists a page of books from parallel goroutines, as
+// every ListBooks call does, with statements run unprepared and with the statement cache. It runs against
+// the database of STORAGE_TEST_DATABASE_URL, whose books it replaces, e.g.
+//
+//	STORAGE_TEST_DATABASE_URL=postgres://localhost/library_test go test -run '^$' -bench PostgresStatements ./server
+func BenchmarkPostgresStatements(b *testing.B) {
+	dsn := os.Getenv("STORAGE_TEST_DATABASE_URL")
+	if dsn == "" {
+		b.Skip("STORAGE_TEST_DATABASE_URL is not set")
+	}
+	ctx := context.Background()
+
+	for _, mode := range []struct {
+		name     string
+		capacity int
+	}{
+		{"unprepared", 0},
+		{"prepared", defaultConfig().Database.StatementCacheCapacity},
+	} {
+		config, err := pgxpool.ParseConfig(dsn)
+		if err != nil {
+			b.Fatal(err)
+		}
+		useStatementCache(config, mode.capacity)
+		pool, err := pgxpool.NewWithConfig(ctx, config)
+		if err != nil {
+			b.Fatal(err)
+		}
+		defer pool.Close()
+		if err := RunMigrations(pool); err != nil {
+			b.Fatalf("migrations: %v", err)
+		}
+		if mode.capacity == 0 {
+			seedStatementBenchmark(b, pool)
+		}
+
+		var claims Claims
+		err = pool.QueryRow(ctx, "SELECT id, username FROM users WHERE username='benchmark'").Scan(&claims.UserID, &claims.Username)
+		if err != nil {
+			b.Fatal(err)
+		}
+		store := storage.NewPostgres(pool)
+		books, users := store.Books(), store.Users()
+		filter := storage.BookFilter{Branch: "Main", Limit: 20}
+
+		b.Run(mode.name, func(b *testing.B) {
+			b.RunParallel(func(p *testing.PB) {
+				for p.Next() {
+					if _, err := validateUserExistsInDB(ctx, users, claims.UserID, claims.Username); err != nil {
+						b.Error(err)
+						return
+					}
+					if err := validateTokenNotRevoked(ctx, users, nil, &claims); err != nil {
+						b.Error(err)
+						return
+					}
+					if _, err := books.List(ctx, filter, []string{"pt-BR"}); err != nil {
+						b.Error(err)
+						return
+					}
+				}
+			})
+		})
+	}
+}
+
+// seedStatementBenchmark replaces the books of pool with ten thousand, spread over two branches, and adds
+// the user the benchmark authenticates as
+func seedStatementBenchmark(b *testing.B, pool *pgxpool.Pool) {
+	ctx := context.Background()
+	if _, err := pool.Exec(ctx, "TRUNCATE books, publishers, series, book_revisions CASCADE"); err != nil {
+		b.Fatal(err)
+	}
+	_, err := pool.Exec(ctx,
+		`INSERT INTO books (id, title, author, location_branch)
+		 SELECT 'b' || lpad(i::text, 5, '0'), 'Title ' || i, 'Author ' || (i % 500), CASE WHEN i % 2 = 0 THEN 'Main' ELSE 'Riverside' END
+		 FROM generate_series(1, 10000) AS i`)
+	if err != nil {
+		b.Fatalf("seeding: %v", err)
+	}
+	_, err = pool.Exec(ctx, "INSERT INTO users (username, password_hash) VALUES ('benchmark', '') ON CONFLICT (tenant_id, username) DO NOTHING")
+	if err != nil {
+		b.Fatal(err)
+	}
+	if _, err := pool.Exec(ctx, "ANALYZE books"); err != nil {
+		b.Fatal(err)
+	}
+}
+
+// testDatabase connects to the database of STORAGE_TEST_DATABASE_URL and migrates it, or skips t. The
+// books and users with IDs and names starting with prefix are removed before and after the test, and
+// with them everything that refers to them.
+func testDatabase(t *testing.T, prefix string) *pgxpool.Pool {
+	t.Helper()
+	dsn := os.Getenv("STORAGE_TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("STORAGE_TEST_DATABASE_URL is not set")
+	}
+	ctx := withTenant(context.Background(), "")
+	pool, err := pgxpool.New(ctx, dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(pool.Close)
+	if err := RunMigrations(pool); err != nil {
+		t.Fatalf("migrations: %v", err)
+	}
+	cleanup := func() {
+		for _, query := range []string{
+			"DELETE FROM books WHERE id LIKE $1",
+			"DELETE FROM users WHERE username LIKE $1",
+			"DELETE FROM tags WHERE name LIKE $1",
+		} {
+			if _, err := pool.Exec(ctx, query, prefix+"%"); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	cleanup()
+	t.Cleanup(cleanup)
+	return pool
+}
+
+// testUser adds the user name to pool and returns a context authenticated as them
+func testUser(t *testing.T, pool *pgxpool.Pool, name string) context.Context {
+	t.Helper()
+	ctx := withTenant(context.Background(), "")
+	var id int
+	if err := pool.QueryRow(ctx, "INSERT INTO users (username, password_hash) VALUES ($1, '') RETURNING id", name).Scan(&id); err != nil {
+		t.Fatal(err)
+	}
+	ctx = context.WithValue(ctx, userIDKey, id)
+	return context.WithValue(ctx, usernameKey, name)
+}
+
+// testBook adds a book with copies to pool
+func testBook(t *testing.T, pool *pgxpool.Pool, id string, copies int32) {
+	t.Helper()
+	books := storage.NewPostgres(pool).Books()
+	if err := books.Create(withTenant(context.Background(), ""), &pb.Book{Id: id, Title: id, TotalCopies: copies, AvailableCopies: copies}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// fakeDatabase is a PostgreSQL server answering the statements of a test from a script, so that the
+// checks handlers leave to SQL, such as who a row belongs to, are tested without a database. Statements
+// arrive with their arguments inlined as quoted literals in parentheses, as the simple protocol sends
+// them, e.g. "WHERE id=('7') AND user_id=('1')".
+type fakeDatabase struct {
+	t       *testing.T
+	mu      sync.Mutex
+	answers []fakeAnswer
+	// statements are those received, but for BEGIN, COMMIT and ROLLBACK
+	statements []string
+}
+
+// fakeAnswer answers the statements containing match
+type fakeAnswer struct {
+	match string
+	tag   string
+	rows  [][]any
+}
+
+// newFakeDatabase returns a pool of connections to a fake database, and the fake
+func newFakeDatabase(t *testing.T) (*pgxpool.Pool, *fakeDatabase) {
+	t.Helper()
+	fake := &fakeDatabase{t: t}
+	config, err := pgxpool.ParseConfig("postgres://test@127.0.0.1/test?sslmode=disable")
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.ConnConfig.DefaultQueryExecMode = pgx.QueryExecModeSimpleProtocol
+	config.ConnConfig.DialFunc = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		client, server := net.Pipe()
+		go fake.serve(server)
+		return client, nil
+	}
+	pool, err := pgxpool.NewWithConfig(context.Background(), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(pool.Close)
+	return pool, fake
+}
+
+// answer has statements containing match answered with rows of Go values (bool, int, int64, float64,
+// string, time.Time or nil) and the command tag, e.g. "SELECT 1" or "DELETE 0". Answers are tried in the
+// order they were added.
+func (d *fakeDatabase) answer(match, tag string, rows ...[]any) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.answers = append(d.answers, fakeAnswer{match: match, tag: tag, rows: rows})
+}
+
+// received reports whether a statement containing match was run
+func (d *fakeDatabase) received(match string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return slices.ContainsFunc(d.statements, func(s string) bool { return strings.Contains(s, match) })
+}
+
+func (d *fakeDatabase) serve(conn net.Conn) {
+	defer conn.Close()
+	backend := pgproto3.NewBackend(conn, conn)
+	for {
+		msg, err := backend.ReceiveStartupMessage()
+		if err != nil {
+			return
+		}
+		if _, ok := msg.(*pgproto3.StartupMessage); ok {
+			break
+		}
+		conn.Write([]byte("N"))
+	}
+	backend.Send(&pgproto3.AuthenticationOk{})
+	backend.Send(&pgproto3.ParameterStatus{Name: "client_encoding", Value: "UTF8"})
+	backend.Send(&pgproto3.ParameterStatus{Name: "standard_conforming_strings", Value: "on"})
+	backend.Send(&pgproto3.ReadyForQuery{TxStatus: 'I'})
+	txStatus := byte('I')
+	for {
+		if err := backend.Flush(); err != nil {
+			return
+		}
+		msg, err := backend.Receive()
+		if err != nil {
+			return
+		}
+		query, ok := msg.(*pgproto3.Query)
+		if !ok {
+			return
+		}
+		switch sql := strings.TrimSpace(query.String); strings.ToLower(sql) {
+		case "begin":
+			txStatus = 'T'
+			backend.Send(&pgproto3.CommandComplete{CommandTag: []byte("BEGIN")})
+		case "commit", "rollback":
+			txStatus = 'I'
+			backend.Send(&pgproto3.CommandComplete{CommandTag: []byte(strings.ToUpper(sql))})
+		default:
+			d.respond(backend, sql)
+		}
+		backend.Send(&pgproto3.ReadyForQuery{TxStatus: txStatus})
+	}
+}
+
+// respond sends the answer to sql, or an error for a statement the test didn't expect
+func (d *fakeDatabase) respond(backend *pgproto3.Backend, sql string) {
+	d.mu.Lock()
+	d.statements = append(d.statements, sql)
+	i := slices.IndexFunc(d.answers, func(a fakeAnswer) bool { return strings.Contains(sql, a.match) })
+	var a fakeAnswer
+	if i >= 0 {
+		a = d.answers[i]
+	}
+	d.mu.Unlock()
+	if i < 0 {
+		d.t.Errorf("unexpected statement %s", sql)
+		backend.Send(&pgproto3.ErrorResponse{Severity: "ERROR", Code: "XX000", Message: "unexpected statement"})
+		return
+	}
+	if len(a.rows) > 0 {
+		var fields []pgproto3.FieldDescription
+		for i, v := range a.rows[0] {
+			fields = append(fields, pgproto3.FieldDescription{Name: []byte("c" + strconv.Itoa(i)), DataTypeOID: fakeOID(v), DataTypeSize: -1, TypeModifier: -1})
+		}
+		backend.Send(&pgproto3.RowDescription{Fields: fields})
+		for _, row := range a.rows {
+			values := make([][]byte, len(row))
+			for i, v := range row {
+				values[i] = fakeText(v)
+			}
+			backend.Send(&pgproto3.DataRow{Values: values})
+		}
+	}
+	backend.Send(&pgproto3.CommandComplete{CommandTag: []byte(a.tag)})
+}
+
+// fakeOID is the type of the column of v
+func fakeOID(v any) uint32 {
+	switch v.(type) {
+	case bool:
+		return pgtype.BoolOID
+	case int, int64:
+		return pgtype.Int8OID
+	case float64:
+		return pgtype.Float8OID
+	case time.Time:
+		return pgtype.TimestamptzOID
+	case []byte:
+		return pgtype.ByteaOID
+	}
+	return pgtype.TextOID
+}
+
+// fakeText is v in PostgreSQL's text format, nil for NULL
+func fakeText(v any) []byte {
+	switch v := v.(type) {
+	case nil:
+		return nil
+	case bool:
+		if v {
+			return []byte("t")
+		}
+		return []byte("f")
+	case time.Time:
+		return []byte(v.UTC().Format("2006-01-02 15:04:05.999999-07"))
+	case []byte:
+		return []byte(`\x` + hex.EncodeToString(v))
+	}
+	return []byte(fmt.Sprint(v))
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	poolAcquiredConns = prometheus.NewDesc("db_pool_acquired_conns",
+		"Connections of the database pool in use by a call or job.", nil, nil)
+	poolIdleConns = prometheus.NewDesc("db_pool_idle_conns",
+		"Connections of the database pool open and waiting to be acquired.", nil, nil)
+	poolConstructingConns = prometheus.NewDesc("db_pool_constructing_conns",
+		"Connections of the database pool being opened.", nil, nil)
+	poolMaxConns = prometheus.NewDesc("db_pool_max_conns",
+		"Most connections the database pool opens (DB_POOL_MAX_CONNS).", nil, nil)
+	poolAcquires = prometheus.NewDesc("db_pool_acquires_total",
+		"Connections acquired from the database pool.", nil, nil)
+	poolEmptyAcquires = prometheus.NewDesc("db_pool_empty_acquires_total",
+		"Acquires that found no idle connection, and waited for one to be released or opened.", nil, nil)
+	poolCanceledAcquires = prometheus.NewDesc("db_pool_canceled_acquires_total",
+		"Acquires given up because the caller's context ended first, usually its deadline.", nil, nil)
+	poolAcquireSeconds = prometheus.NewDesc("db_pool_acquire_duration_seconds_total",
+		"Time spent acquiring connections from the database pool; divided by db_pool_acquires_total, the average wait.", nil, nil)
+	poolNewConns = prometheus.NewDesc("db_pool_new_conns_total",
+		"Connections opened by the database pool.", nil, nil)
+	poolClosedConns = prometheus.NewDesc("db_pool_closed_conns_total",
+		"Connections closed by the database pool for their age (max_lifetime) or idleness (max_idle_time).", []string{"reason"}, nil)
+)
+
+// poolCollector reads the statistics of a pgxpool on each scrape, so that a pool running out of
+// connections shows before calls start timing out waiting for one
+type poolCollector struct {
+	stat func() *pgxpool.Stat
+}
+
+func newPoolCollector(db *pgxpool.Pool) poolCollector {
+	return poolCollector{stat: db.Stat}
+}
+
+func (c poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, d := range []*prometheus.Desc{poolAcquiredConns, poolIdleConns, poolConstructingConns, poolMaxConns, poolAcquires,
+		poolEmptyAcquires, poolCanceledAcquires, poolAcquireSeconds, poolNewConns, poolClosedConns} {
+		ch <- d
+	}
+}
+
+func (c poolCollector) Collect(ch chan<- prometheus.Metric) {
+	s := c.stat()
+	ch <- prometheus.MustNewConstMetric(poolAcquiredConns, prometheus.GaugeValue, float64(s.AcquiredConns()))
+	ch <- prometheus.MustNewConstMetric(poolIdleConns, prometheus.GaugeValue, float64(s.IdleConns()))
+	ch <- prometheus.MustNewConstMetric(poolConstructingConns, prometheus.GaugeValue, float64(s.ConstructingConns()))
+	ch <- prometheus.MustNewConstMetric(poolMaxConns, prometheus.GaugeValue, float64(s.MaxConns()))
+	ch <- prometheus.MustNewConstMetric(poolAcquires, prometheus.CounterValue, float64(s.AcquireCount()))
+	ch <- prometheus.MustNewConstMetric(poolEmptyAcquires, prometheus.CounterValue, float64(s.EmptyAcquireCount()))
+	ch <- prometheus.MustNewConstMetric(poolCanceledAcquires, prometheus.CounterValue, float64(s.CanceledAcquireCount()))
+	ch <- prometheus.MustNewConstMetric(poolAcquireSeconds, prometheus.CounterValue, s.AcquireDuration().Seconds())
+	ch <- prometheus.MustNewConstMetric(poolNewConns, prometheus.CounterValue, float64(s.NewConnsCount()))
+	ch <- prometheus.MustNewConstMetric(poolClosedConns, prometheus.CounterValue, float64(s.MaxLifetimeDestroyCount()), "max_lifetime")
+	ch <- prometheus.MustNewConstMetric(poolClosedConns, prometheus.CounterValue, float64(s.MaxIdleDestroyCount()), "max_idle_time")
+}
+
+// queryFamily groups statements by operation and the table they work on, e.g. "SELECT books", few enough
+// to label metrics with. The table is the first after FROM, INTO or UPDATE; statements with a WITH clause
+// or none, such as SELECT NOW(), are named by their operation alone.
+func queryFamily(sql string) string {
+	operation := sqlOperation(sql)
+	fields := strings.Fields(sql)
+	after := ""
+	switch operation {
+	case "SELECT", "DELETE":
+		after = "FROM"
+	case "INSERT":
+		after = "INTO"
+	case "UPDATE":
+		if len(fields) > 1 && tableName(fields[1]) != "" {
+			return operation + " " + tableName(fields[1])
+		}
+		return operation
+	default:
+		return operation
+	}
+	for i, f := range fields[:len(fields)-1] {
+		if strings.EqualFold(f, after) {
+			// A subquery in FROM has no table of its own
+			if table := tableName(fields[i+1]); table != "" {
+				return operation + " " + table
+			}
+			break
+		}
+	}
+	return operation
+}
+
+// tableName returns the table of a FROM, INTO or UPDATE clause's next word, without its schema, or "" when
+// it names no table
+func tableName(word string) string {
+	if i := strings.IndexByte(word, '('); i >= 0 {
+		word = word[:i]
+	}
+	word = strings.TrimRight(word, ",;)")
+	if i := strings.LastIndexByte(word, '.'); i >= 0 {
+		word = word[i+1:]
+	}
+	word = strings.Trim(word, `"`)
+	for _, r := range word {
+		if !(r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9') {
+			return ""
+		}
+	}
+	return strings.ToLower(word)
+}
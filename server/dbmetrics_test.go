@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestQueryFamily(t *testing.T) {
+	for sql, want := range map[string]string{
+		"SELECT id FROM books WHERE id=$1":                                      "SELECT books",
+		"select EXISTS(SELECT 1 FROM public.books WHERE id=$1)":                 "SELECT books",
+		"SELECT COUNT(*) FROM (SELECT 1 FROM loans) AS l":                       "SELECT",
+		"INSERT INTO auth_events(user_id, event) VALUES ($1, $2)":               "INSERT auth_events",
+		"\n\tUPDATE \"users\" SET state=$1 WHERE id=$2":                         "UPDATE users",
+		"DELETE FROM refresh_tokens WHERE id IN (SELECT id FROM x LIMIT 1)":     "DELETE refresh_tokens",
+		"WITH gone AS (DELETE FROM loans RETURNING book_id) SELECT * FROM gone": "WITH",
+		"SELECT pg_try_advisory_lock(hashtext($1))":                             "SELECT",
+	} {
+		if got := queryFamily(sql); got != want {
+			t.Errorf("queryFamily(%q) = %q, want %q", sql, got, want)
+		}
+	}
+}
+
+// querySamples returns how many statements of family db_query_duration_seconds has timed
+func querySamples(t *testing.T, family string) uint64 {
+	families, err := metricsRegistry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range families {
+		if f.GetName() != "db_query_duration_seconds" {
+			continue
+		}
+		for _, m := range f.GetMetric() {
+			if len(m.GetLabel()) == 1 && m.GetLabel()[0].GetValue() == family {
+				return m.GetHistogram().GetSampleCount()
+			}
+		}
+	}
+	return 0
+}
+
+func TestDBTracerTimesQueries(t *testing.T) {
+	before := querySamples(t, "SELECT publishers")
+	var tracer dbTracer
+	ctx := tracer.TraceQueryStart(context.Background(), nil, pgx.TraceQueryStartData{SQL: "SELECT name FROM publishers"})
+	tracer.TraceQueryEnd(ctx, nil, pgx.TraceQueryEndData{})
+	if got := querySamples(t, "SELECT publishers") - before; got != 1 {
+		t.Errorf("db_query_duration_seconds timed %d statements, want 1", got)
+	}
+}
+
+func TestPoolCollector(t *testing.T) {
+	// The pool connects on its first acquire, so it needs no database to report its statistics
+	pool, err := pgxpool.New(context.Background(), "postgres://localhost:1/none?pool_max_conns=7")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Close()
+	collector := newPoolCollector(pool)
+	// Every metric once, and the connections closed for each reason
+	if n := testutil.CollectAndCount(collector); n != 11 {
+		t.Errorf("collected %d metrics, want 11", n)
+	}
+	want := `# HELP db_pool_max_conns Most connections the database pool opens (DB_POOL_MAX_CONNS).
+# TYPE db_pool_max_conns gauge
+db_pool_max_conns 7
+`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(want), "db_pool_max_conns"); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	pb "example/grpc_demo/library"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// dbPingTimeout bounds each ping of the supervisor, so that a database that stopped answering counts as
+// a failure instead of holding the loop
+const dbPingTimeout = 2 * time.Second
+
+// dbSupervisor pings the database and takes the server out of service while it doesn't answer: the health
+// service reports NOT_SERVING and calls fail with Unavailable, which clients and load balancers know to
+// retry elsewhere, instead of Internal errors until a restart
+type dbSupervisor struct {
+	ping   func(ctx context.Context) error
+	reset  func()
+	health *health.Server
+	cfg    ReconnectConfig
+
+	// failures counts the consecutive failed pings; it's only touched by run
+	failures int
+	// backoff is the wait before the next ping while degraded
+	backoff  time.Duration
+	degraded atomic.Bool
+}
+
+func newDBSupervisor(db *pgxpool.Pool, healthServer *health.Server, cfg ReconnectConfig) *dbSupervisor {
+	return &dbSupervisor{ping: db.Ping, reset: db.Reset, health: healthServer, cfg: cfg}
+}
+
+// observe records the outcome of a ping, taking the server out of service after cfg.FailureThreshold
+// failures in a row and back in on the first success, and returns how long to wait before the next one
+func (s *dbSupervisor) observe(ctx context.Context, err error) time.Duration {
+	if err == nil {
+		s.failures = 0
+		if s.degraded.CompareAndSwap(true, false) {
+			slog.InfoContext(ctx, "database reachable again; serving")
+			s.health.SetServingStatus("", healthpb.HealthCheckResponse_SERVING)
+			dbUp.Set(1)
+		}
+		return s.cfg.PingInterval
+	}
+	s.failures++
+	if !s.degraded.Load() {
+		if s.failures < s.cfg.FailureThreshold {
+			slog.WarnContext(ctx, "database ping failed", "error", err, "failures", s.failures)
+			return s.cfg.PingInterval
+		}
+		slog.ErrorContext(ctx, "database unreachable; not serving until it answers again", "error", err, "failures", s.failures)
+		s.degraded.Store(true)
+		s.health.SetServingStatus("", healthpb.HealthCheckResponse_NOT_SERVING)
+		dbUp.Set(0)
+		s.backoff = s.cfg.PingInterval
+		// The pool's connections are most likely dead; new ones are dialed on the next acquire
+		s.reset()
+		return s.backoff
+	}
+	s.backoff = min(2*s.backoff, s.cfg.MaxBackoff)
+	slog.WarnContext(ctx, "database still unreachable", "error", err, "failures", s.failures, "retry_in", s.backoff)
+	return s.backoff
+}
+
+// run pings the database until ctx is cancelled
+func (s *dbSupervisor) run(ctx context.Context) {
+	dbUp.Set(1)
+	timer := time.NewTimer(s.cfg.PingInterval)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+		pingCtx, cancel := context.WithTimeout(ctx, dbPingTimeout)
+		err := s.ping(pingCtx)
+		cancel()
+		timer.Reset(s.observe(ctx, err))
+	}
+}
+
+// check fails calls with Unavailable while the database is down. The health service and GetServerInfo,
+// which don't need it, still answer.
+func (s *dbSupervisor) check(method string) error {
+	if s == nil || !s.degraded.Load() {
+		return nil
+	}
+	if strings.HasPrefix(method, "/grpc.health.v1.Health/") || method == pb.ServerInfoService_GetServerInfo_FullMethodName {
+		return nil
+	}
+	return withDetails(status.New(codes.Unavailable, "the database is unavailable; retry later"),
+		&errdetails.RetryInfo{RetryDelay: durationpb.New(s.cfg.PingInterval)})
+}
+
+// CreateDegradedInterceptor fails unary calls with Unavailable while the database is down
+func CreateDegradedInterceptor(supervisor *dbSupervisor) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if err := supervisor.check(info.FullMethod); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// CreateStreamDegradedInterceptor fails streaming calls with Unavailable while the database is down
+func CreateStreamDegradedInterceptor(supervisor *dbSupervisor) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := supervisor.check(info.FullMethod); err != nil {
+			return err
+		}
+		return handler(srv, ss)
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	pb "example/grpc_demo/library"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+func TestDBSupervisor(t *testing.T) {
+	ctx := context.Background()
+	healthServer := health.NewServer()
+	resets := 0
+	s := &dbSupervisor{
+		reset:  func() { resets++ },
+		health: healthServer,
+		cfg:    ReconnectConfig{PingInterval: time.Second, FailureThreshold: 2, MaxBackoff: 3 * time.Second},
+	}
+	serving := func() healthpb.HealthCheckResponse_ServingStatus {
+		resp, err := healthServer.Check(ctx, &healthpb.HealthCheckRequest{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp.GetStatus()
+	}
+	down := errors.New("connection refused")
+
+	// A single failed ping is not enough to leave service
+	if wait := s.observe(ctx, down); wait != time.Second || serving() != healthpb.HealthCheckResponse_SERVING {
+		t.Fatalf("after one failure: wait %s, status %s", wait, serving())
+	}
+	if err := s.check(pb.LibraryService_ListBooks_FullMethodName); err != nil {
+		t.Fatalf("check() after one failure = %v", err)
+	}
+
+	// The threshold takes the server out of service, and the wait doubles up to the maximum
+	for i, want := range []time.Duration{time.Second, 2 * time.Second, 3 * time.Second, 3 * time.Second} {
+		if wait := s.observe(ctx, down); wait != want {
+			t.Errorf("failure %d: wait %s, want %s", i+2, wait, want)
+		}
+	}
+	if serving() != healthpb.HealthCheckResponse_NOT_SERVING || resets != 1 {
+		t.Errorf("degraded: status %s, %d pool resets; want NOT_SERVING and 1", serving(), resets)
+	}
+	if err := s.check(pb.LibraryService_ListBooks_FullMethodName); status.Code(err) != codes.Unavailable {
+		t.Errorf("check(ListBooks) while degraded = %v, want Unavailable", err)
+	}
+	for _, method := range []string{"/grpc.health.v1.Health/Check", pb.ServerInfoService_GetServerInfo_FullMethodName} {
+		if err := s.check(method); err != nil {
+			t.Errorf("check(%s) while degraded = %v, want nil", method, err)
+		}
+	}
+
+	// The first ping answered puts it back in service
+	if wait := s.observe(ctx, nil); wait != time.Second || serving() != healthpb.HealthCheckResponse_SERVING {
+		t.Errorf("after recovery: wait %s, status %s", wait, serving())
+	}
+	if err := s.check(pb.LibraryService_ListBooks_FullMethodName); err != nil {
+		t.Errorf("check() after recovery = %v", err)
+	}
+
+	// Without a database there is nothing to supervise
+	var none *dbSupervisor
+	if err := none.check(pb.LibraryService_ListBooks_FullMethodName); err != nil {
+		t.Errorf("nil supervisor check() = %v", err)
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+)
+
+// timeout returns the deadline of calls to method whose client set none, 0 for none
+func (c TimeoutConfig) timeout(method string, streaming bool) time.Duration {
+	if d, ok := c.Methods[method]; ok {
+		return d
+	}
+	if streaming {
+		return 0
+	}
+	return c.Default
+}
+
+// checkMethods reports a timeout set for a method the server doesn't have, most likely a typo
+func (c TimeoutConfig) checkMethods(services map[string]grpc.ServiceInfo) error {
+	for method := range c.Methods {
+		if !hasMethod(services, method) {
+			return fmt.Errorf("timeout set for unknown method %q", method)
+		}
+	}
+	return nil
+}
+
+// hasMethod tells whether services include the method of a full method name
+func hasMethod(services map[string]grpc.ServiceInfo, method string) bool {
+	service, name, _ := strings.Cut(strings.TrimPrefix(method, "/"), "/")
+	info, ok := services[service]
+	return ok && slices.ContainsFunc(info.Methods, func(m grpc.MethodInfo) bool { return m.Name == name })
+}
+
+// CreateTimeoutInterceptor gives calls without a deadline the configured timeout. Handlers run every query
+// with the call's context, so a hung database fails the call with DeadlineExceeded instead of holding
+// its goroutine and pool connection forever.
+func CreateTimeoutInterceptor(cfg TimeoutConfig) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, cancel := withDefaultTimeout(ctx, cfg.timeout(info.FullMethod, false))
+		defer cancel()
+		return handler(ctx, req)
+	}
+}
+
+// CreateStreamTimeoutInterceptor is CreateTimeoutInterceptor for streaming calls, which only get a timeout
+// configured for their method: Subscribe and large exports legitimately run for long
+func CreateStreamTimeoutInterceptor(cfg TimeoutConfig) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, cancel := withDefaultTimeout(ss.Context(), cfg.timeout(info.FullMethod, true))
+		defer cancel()
+		return handler(srv, &contextServerStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// withDefaultTimeout applies timeout to ctx unless it is 0 or ctx already has a deadline, set by the client
+func withDefaultTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok || timeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, timeout)
+}
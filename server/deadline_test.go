@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	pb "example/grpc_demo/library"
+
+	"google.golang.org/grpc"
+)
+
+// deadlineOf runs a unary call to method through the timeout interceptor and returns the handler's deadline
+func deadlineOf(t *testing.T, ctx context.Context, cfg TimeoutConfig, method string) (time.Time, bool) {
+	t.Helper()
+	var deadline time.Time
+	var ok bool
+	_, err := CreateTimeoutInterceptor(cfg)(ctx, &pb.BookRequest{}, &grpc.UnaryServerInfo{FullMethod: method},
+		func(ctx context.Context, req interface{}) (interface{}, error) {
+			deadline, ok = ctx.Deadline()
+			return nil, nil
+		})
+	if err != nil {
+		t.Fatalf("interceptor error = %v", err)
+	}
+	return deadline, ok
+}
+
+func TestTimeoutInterceptor(t *testing.T) {
+	cfg := TimeoutConfig{
+		Default: time.Minute,
+		Methods: map[string]time.Duration{"/library.LibraryService/SearchBooks": 5 * time.Second, "/library.LibraryService/GetStats": 0},
+	}
+
+	if deadline, ok := deadlineOf(t, context.Background(), cfg, "/library.LibraryService/GetBook"); !ok || time.Until(deadline) > time.Minute || time.Until(deadline) < 50*time.Second {
+		t.Errorf("default deadline = %v, %v; want a minute from now", deadline, ok)
+	}
+	if deadline, ok := deadlineOf(t, context.Background(), cfg, "/library.LibraryService/SearchBooks"); !ok || time.Until(deadline) > 5*time.Second {
+		t.Errorf("SearchBooks deadline = %v, %v; want 5s from now", deadline, ok)
+	}
+	if _, ok := deadlineOf(t, context.Background(), cfg, "/library.LibraryService/GetStats"); ok {
+		t.Error("a method with a 0 timeout got a deadline")
+	}
+
+	// The client's own deadline is kept, even when longer than the default
+	ctx, cancel := context.WithTimeout(context.Background(), time.Hour)
+	defer cancel()
+	want, _ := ctx.Deadline()
+	if deadline, ok := deadlineOf(t, ctx, cfg, "/library.LibraryService/GetBook"); !ok || !deadline.Equal(want) {
+		t.Errorf("deadline = %v, want the client's %v", deadline, want)
+	}
+}
+
+func TestStreamTimeoutInterceptor(t *testing.T) {
+	cfg := TimeoutConfig{Default: time.Minute, Methods: map[string]time.Duration{"/library.LibraryService/ExportBooks": time.Hour}}
+	deadlineOf := func(method string) bool {
+		var ok bool
+		stream := &contextServerStream{ctx: context.Background()}
+		CreateStreamTimeoutInterceptor(cfg)(nil, stream, &grpc.StreamServerInfo{FullMethod: method}, func(srv interface{}, ss grpc.ServerStream) error {
+			_, ok = ss.Context().Deadline()
+			return nil
+		})
+		return ok
+	}
+	if deadlineOf("/library.NotificationService/Subscribe") {
+		t.Error("Subscribe got the unary default timeout")
+	}
+	if !deadlineOf("/library.LibraryService/ExportBooks") {
+		t.Error("ExportBooks did not get its configured timeout")
+	}
+}
+
+func TestTimeoutCheckMethods(t *testing.T) {
+	services := map[string]grpc.ServiceInfo{"library.LibraryService": {Methods: []grpc.MethodInfo{{Name: "GetBook"}}}}
+	if err := (TimeoutConfig{Methods: map[string]time.Duration{"/library.LibraryService/GetBook": time.Second}}).checkMethods(services); err != nil {
+		t.Errorf("checkMethods() error = %v", err)
+	}
+	if err := (TimeoutConfig{Methods: map[string]time.Duration{"/library.LibraryService/GetBok": time.Second}}).checkMethods(services); err == nil {
+		t.Error("checkMethods() accepted a misspelt method")
+	}
+}
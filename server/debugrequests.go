@@ -0,0 +1,20 @@
+package main
+
+import (
+	"net/http"
+
+	"golang.org/x/net/trace"
+	"google.golang.org/grpc"
+)
+
+// enableRequestTracing has gRPC keep samples of recent calls of every method, with their events, bucketed
+// by duration, and of recent failures. It must run before the server is created.
+func enableRequestTracing() {
+	grpc.EnableTracing = true
+}
+
+// debugRequestsHandler serves the samples of enableRequestTracing as the /debug/requests page. As they hold
+// request messages and peer addresses, only clients connecting from a loopback address are answered.
+func debugRequestsHandler(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+	trace.Traces(w, r)
+}
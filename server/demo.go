@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+
+	pb "example/grpc_demo/library"
+	"example/grpc_demo/server/storage"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// demoMethods are served in demo mode: those of the book catalog, which the in-memory store keeps.
+// The others need the database.
+var demoMethods = map[string]bool{
+	pb.LibraryService_AddBook_FullMethodName:          true,
+	pb.LibraryService_BatchAddBooks_FullMethodName:    true,
+	pb.LibraryService_GetBook_FullMethodName:          true,
+	pb.LibraryService_ListBooks_FullMethodName:        true,
+	pb.LibraryService_SearchBooks_FullMethodName:      true,
+	pb.LibraryService_UpdateBook_FullMethodName:       true,
+	pb.LibraryService_PatchBook_FullMethodName:        true,
+	pb.LibraryService_BulkUpdateBooks_FullMethodName:  true,
+	pb.LibraryService_DeleteBook_FullMethodName:       true,
+	pb.ServerInfoService_GetServerInfo_FullMethodName: true,
+	healthpb.Health_Check_FullMethodName:              true,
+	healthpb.Health_Watch_FullMethodName:              true,
+}
+
+// demoActor is who the book history of demo mode records changes as made by
+var demoActor = storage.Actor{Name: "demo"}
+
+// demoBooks are the books the demo catalog starts with
+var demoBooks = []*pb.Book{
+	{Id: "dune", Title: "Dune", Author: "Frank Herbert", Isbn: "9780441172719", Publisher: "Ace", TotalCopies: 3,
+		Series: &pb.BookSeries{Name: "Dune", Volume: 1}, Location: &pb.Location{Branch: "Main", Section: "Science Fiction"}},
+	{Id: "neuromancer", Title: "Neuromancer", Author: "William Gibson", Isbn: "9780441569595", Publisher: "Ace", TotalCopies: 2,
+		Location: &pb.Location{Branch: "Main", Section: "Science Fiction"}},
+	{Id: "emma", Title: "Emma", Author: "Jane Austen", Isbn: "9780141439587", Publisher: "Penguin Classics", TotalCopies: 1,
+		Location: &pb.Location{Branch: "Main", Section: "Classics"}},
+	{Id: "pride-and-prejudice", Title: "Pride and Prejudice", Author: "Jane Austen", Isbn: "9780141439518", Publisher: "Penguin Classics",
+		TotalCopies: 2, Location: &pb.Location{Branch: "Riverside", Section: "Classics"}},
+	{Id: "the-hobbit", Title: "The Hobbit", Author: "J. R. R. Tolkien", Isbn: "9780547928227", Publisher: "Houghton Mifflin Harcourt",
+		TotalCopies: 4, Location: &pb.Location{Branch: "Riverside", Section: "Fantasy"}},
+}
+
+// newDemoStore returns an in-memory store holding the demo catalog
+func newDemoStore(ctx context.Context) (*storage.Memory, error) {
+	store := storage.NewMemory()
+	ctx = storage.WithActor(ctx, demoActor)
+	for _, b := range demoBooks {
+		book := proto.Clone(b).(*pb.Book)
+		book.AvailableCopies = book.GetTotalCopies()
+		if err := store.Books().Create(ctx, book); err != nil {
+			return nil, err
+		}
+	}
+	return store, nil
+}
+
+// demoAccess lets anyone call the methods of demo mode, without credentials, and refuses the others
+func demoAccess(ctx context.Context, method string) (context.Context, error) {
+	if !demoMethods[method] {
+		return nil, status.Error(codes.Unimplemented, "Not available in demo mode")
+	}
+	return storage.WithActor(ctx, demoActor), nil
+}
+
+// CreateDemoInterceptor replaces authentication in demo mode
+func CreateDemoInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, err := demoAccess(ctx, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// CreateStreamDemoInterceptor replaces authentication of streams in demo mode
+func CreateStreamDemoInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := demoAccess(ss.Context(), info.FullMethod)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &contextServerStream{ss, ctx})
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	pb "example/grpc_demo/library"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestDemoCatalog(t *testing.T) {
+	for _, b := range demoBooks {
+		if err := validateBook(b); err != nil {
+			t.Errorf("demo book %s: %v", b.GetId(), err)
+		}
+	}
+
+	store, err := newDemoStore(context.Background())
+	if err != nil {
+		t.Fatalf("newDemoStore() error = %v", err)
+	}
+	s := &server{books: store.Books(), users: store.Users(), tx: store, settings: newSettings(&runtimeSettings{duplicates: defaultDuplicatePolicy})}
+	ctx, err := demoAccess(context.Background(), pb.LibraryService_ListBooks_FullMethodName)
+	if err != nil {
+		t.Fatalf("demoAccess(ListBooks) error = %v", err)
+	}
+
+	resp, err := s.ListBooks(ctx, &pb.ListBookRequest{Branch: "Riverside"})
+	if err != nil || resp.GetTotalCount() != 2 {
+		t.Fatalf("ListBooks() in Riverside = %v, %v", resp, err)
+	}
+	if _, err := s.AddBook(ctx, &pb.Book{Id: "persuasion", Title: "Persuasion", Author: "Jane Austen", TotalCopies: 1}); err != nil {
+		t.Errorf("AddBook() error = %v", err)
+	}
+	// The duplicate check runs against the catalog in memory too
+	if _, err := s.AddBook(ctx, &pb.Book{Id: "dune-2", Title: "DUNE", Author: "Frank Herbert"}); status.Code(err) != codes.AlreadyExists {
+		t.Errorf("AddBook() of a duplicate error = %v, want AlreadyExists", err)
+	}
+	if _, err := s.DeleteBook(ctx, &pb.BookRequest{Id: "emma"}); err != nil {
+		t.Errorf("DeleteBook() error = %v", err)
+	}
+	if resp, _ := s.ListBooks(ctx, &pb.ListBookRequest{}); resp.GetTotalCount() != int32(len(demoBooks)) {
+		t.Errorf("ListBooks() total = %d, want %d", resp.GetTotalCount(), len(demoBooks))
+	}
+}
+
+func TestDemoAccess(t *testing.T) {
+	if _, err := demoAccess(context.Background(), pb.UserService_Login_FullMethodName); status.Code(err) != codes.Unimplemented {
+		t.Errorf("demoAccess(Login) error = %v, want Unimplemented", err)
+	}
+	for method := range demoMethods {
+		if _, err := demoAccess(context.Background(), method); err != nil {
+			t.Errorf("demoAccess(%s) error = %v", method, err)
+		}
+	}
+}
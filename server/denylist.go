@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// redisDenylistPrefix namespaces revoked token IDs in a shared Redis
+	redisDenylistPrefix = "library:revoked-jti:"
+	// denylistSweepInterval is how often the in-memory denylist drops tokens that have expired anyway
+	denylistSweepInterval = time.Minute
+)
+
+// tokenDenylist answers whether an access token ID was revoked before it expired.
+// revoked_tokens stays the durable record; the denylist is what the auth interceptors consult on every call.
+type tokenDenylist interface {
+	Revoke(ctx context.Context, jti string, expiresAt time.Time) error
+	IsRevoked(ctx context.Context, jti string) (bool, error)
+}
+
+// newDenylist returns a Redis denylist shared by every replica when redisURL is set
+// (e.g. redis://:password@localhost:6379/0), and a per-process in-memory one otherwise
+func newDenylist(url string) (tokenDenylist, error) {
+	if url == "" {
+		return newMemoryDenylist(), nil
+	}
+	opts, err := redis.ParseURL(url)
+	if err != nil {
+		return nil, err
+	}
+	return &redisDenylist{client: redis.NewClient(opts)}, nil
+}
+
+// loadRevokedTokens copies the unexpired revocations from the revoked_tokens of every library into the
+// denylist, so a restarted process or a fresh Redis still rejects them
+func loadRevokedTokens(ctx context.Context, db *pgxpool.Pool, denylist tokenDenylist) error {
+	return forEachTenant(ctx, db, func(ctx context.Context) error {
+		return loadTenantRevokedTokens(ctx, db, denylist)
+	})
+}
+
+// loadTenantRevokedTokens adds the revoked tokens of the library of ctx to denylist
+func loadTenantRevokedTokens(ctx context.Context, db *pgxpool.Pool, denylist tokenDenylist) error {
+	rows, err := db.Query(ctx, "SELECT jti, expires_at FROM revoked_tokens WHERE expires_at > NOW()")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var jti string
+		var expiresAt time.Time
+		if err := rows.Scan(&jti, &expiresAt); err != nil {
+			return err
+		}
+		if err := denylist.Revoke(ctx, jti, expiresAt); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// redisDenylist stores revoked token IDs in Redis with a TTL matching the token's remaining lifetime
+type redisDenylist struct {
+	client *redis.Client
+}
+
+func (d *redisDenylist) Revoke(ctx context.Context, jti string, expiresAt time.Time) error {
+	ttl := time.Until(expiresAt)
+	if ttl <= 0 {
+		return nil
+	}
+	return d.client.Set(ctx, redisDenylistPrefix+jti, 1, ttl).Err()
+}
+
+func (d *redisDenylist) IsRevoked(ctx context.Context, jti string) (bool, error) {
+	n, err := d.client.Exists(ctx, redisDenylistPrefix+jti).Result()
+	return n > 0, err
+}
+
+// memoryDenylist keeps revoked token IDs in process memory; revocations are not seen by other replicas
+type memoryDenylist struct {
+	mu        sync.Mutex
+	now       func() time.Time
+	revoked   map[string]time.Time
+	lastSweep time.Time
+}
+
+func newMemoryDenylist() *memoryDenylist {
+	return &memoryDenylist{now: time.Now, revoked: make(map[string]time.Time)}
+}
+
+func (d *memoryDenylist) Revoke(ctx context.Context, jti string, expiresAt time.Time) error {
+	if jti == "" {
+		return errors.New("token ID is required")
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	now := d.now()
+	if now.Sub(d.lastSweep) >= denylistSweepInterval {
+		for id, exp := range d.revoked {
+			if !exp.After(now) {
+				delete(d.revoked, id)
+			}
+		}
+		d.lastSweep = now
+	}
+	if expiresAt.After(now) {
+		d.revoked[jti] = expiresAt
+	}
+	return nil
+}
+
+func (d *memoryDenylist) IsRevoked(ctx context.Context, jti string) (bool, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	exp, ok := d.revoked[jti]
+	return ok && exp.After(d.now()), nil
+}
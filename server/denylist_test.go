@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryDenylist(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	d := newMemoryDenylist()
+	d.now = func() time.Time { return now }
+
+	if err := d.Revoke(ctx, "live", now.Add(time.Hour)); err != nil {
+		t.Fatalf("Revoke() error = %v", err)
+	}
+	if err := d.Revoke(ctx, "already-expired", now.Add(-time.Minute)); err != nil {
+		t.Fatalf("Revoke() error = %v", err)
+	}
+	if err := d.Revoke(ctx, "", now.Add(time.Hour)); err == nil {
+		t.Error("Revoke() should require a token ID")
+	}
+
+	for jti, want := range map[string]bool{"live": true, "already-expired": false, "unknown": false} {
+		if got, err := d.IsRevoked(ctx, jti); err != nil || got != want {
+			t.Errorf("IsRevoked(%q) = %v, %v, want %v", jti, got, err, want)
+		}
+	}
+
+	// Entries are forgotten once the token would have expired anyway
+	now = now.Add(2 * time.Hour)
+	if got, _ := d.IsRevoked(ctx, "live"); got {
+		t.Error("IsRevoked() should be false after the token expired")
+	}
+	d.Revoke(ctx, "next", now.Add(time.Hour))
+	if _, ok := d.revoked["live"]; ok {
+		t.Error("expired entries should be swept")
+	}
+}
+
+func TestNewDenylist(t *testing.T) {
+	d, err := newDenylist("")
+	if err != nil {
+		t.Fatalf("newDenylist() error = %v", err)
+	}
+	if _, ok := d.(*memoryDenylist); !ok {
+		t.Errorf("newDenylist() = %T, want the in-memory fallback", d)
+	}
+
+	d, err = newDenylist("redis://:secret@localhost:6379/2")
+	if err != nil {
+		t.Fatalf("newDenylist() error = %v", err)
+	}
+	r, ok := d.(*redisDenylist)
+	if !ok {
+		t.Fatalf("newDenylist() = %T, want Redis", d)
+	}
+	if opts := r.client.Options(); opts.Addr != "localhost:6379" || opts.DB != 2 || opts.Password != "secret" {
+		t.Errorf("Redis options = %s db %d", opts.Addr, opts.DB)
+	}
+
+	if _, err := newDenylist("http://localhost"); err == nil {
+		t.Error("newDenylist() should reject a non-Redis URL")
+	}
+}
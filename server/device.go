@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// deviceIDHeader carries a random identifier the client generates once per installation or browser profile
+const deviceIDHeader = "x-device-id"
+
+// deviceFingerprint hashes the caller's device ID, or returns "" when the client sent none.
+// Only the hash is kept in tokens and sessions so the ID itself cannot be read back from them.
+func deviceFingerprint(ctx context.Context) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get(deviceIDHeader)
+	if len(values) == 0 {
+		return ""
+	}
+	id := strings.TrimSpace(values[0])
+	if id == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte("library-device:" + id))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// sameDevice reports whether the caller presents the device a token or session was bound to.
+// Tokens and sessions started without a device ID are not bound.
+func sameDevice(ctx context.Context, bound string) bool {
+	if bound == "" {
+		return true
+	}
+	return subtle.ConstantTimeCompare([]byte(deviceFingerprint(ctx)), []byte(bound)) == 1
+}
+
+// checkDeviceBinding rejects an access token used from another device than the one it was issued to
+func checkDeviceBinding(ctx context.Context, claims *Claims) error {
+	if !sameDevice(ctx, claims.DeviceFingerprint) {
+		return status.Error(codes.Unauthenticated, "token was issued to another device")
+	}
+	return nil
+}
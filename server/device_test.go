@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func withDeviceID(id string) context.Context {
+	return metadata.NewIncomingContext(context.Background(), metadata.Pairs(deviceIDHeader, id))
+}
+
+func TestDeviceFingerprint(t *testing.T) {
+	laptop := deviceFingerprint(withDeviceID("laptop-1"))
+	if laptop == "" || laptop == "laptop-1" {
+		t.Fatalf("deviceFingerprint() = %q, want a hash of the device ID", laptop)
+	}
+	if got := deviceFingerprint(withDeviceID(" laptop-1 ")); got != laptop {
+		t.Errorf("deviceFingerprint() should ignore surrounding spaces, got %q want %q", got, laptop)
+	}
+	if got := deviceFingerprint(withDeviceID("phone-1")); got == laptop {
+		t.Error("different devices should have different fingerprints")
+	}
+	if got := deviceFingerprint(context.Background()); got != "" {
+		t.Errorf("deviceFingerprint() without a device ID = %q, want empty", got)
+	}
+}
+
+func TestCheckDeviceBinding(t *testing.T) {
+	claims := newAccessClaims(1, "testuser", 0)
+	claims.DeviceFingerprint = deviceFingerprint(withDeviceID("laptop-1"))
+	token, err := signAccessToken(claims)
+	if err != nil {
+		t.Fatalf("signAccessToken() error = %v", err)
+	}
+	validated, err := ValidateJWT(token)
+	if err != nil {
+		t.Fatalf("ValidateJWT() error = %v", err)
+	}
+
+	if err := checkDeviceBinding(withDeviceID("laptop-1"), validated); err != nil {
+		t.Errorf("checkDeviceBinding() from the same device error = %v", err)
+	}
+	for name, ctx := range map[string]context.Context{
+		"other device":   withDeviceID("attacker"),
+		"no device ID":   context.Background(),
+		"empty metadata": metadata.NewIncomingContext(context.Background(), metadata.MD{}),
+	} {
+		if err := checkDeviceBinding(ctx, validated); status.Code(err) != codes.Unauthenticated {
+			t.Errorf("%s: checkDeviceBinding() error = %v, want Unauthenticated", name, err)
+		}
+	}
+
+	// Tokens issued without a device ID are not bound
+	if err := checkDeviceBinding(withDeviceID("anything"), newAccessClaims(1, "testuser", 0)); err != nil {
+		t.Errorf("checkDeviceBinding() for an unbound token error = %v", err)
+	}
+}
+
+func TestGatewayHeaderMatcher(t *testing.T) {
+	if key, ok := gatewayHeaderMatcher("X-Device-Id"); !ok || key != deviceIDHeader {
+		t.Errorf("gatewayHeaderMatcher(X-Device-Id) = %q, %v, want %q, true", key, ok, deviceIDHeader)
+	}
+	if key, ok := gatewayHeaderMatcher("Idempotency-Key"); !ok || key != idempotencyKeyHeader {
+		t.Errorf("gatewayHeaderMatcher(Idempotency-Key) = %q, %v, want %q, true", key, ok, idempotencyKeyHeader)
+	}
+	for _, header := range []string{"User-Agent", "Grpc-Metadata-Trace"} {
+		key, ok := gatewayHeaderMatcher(header)
+		wantKey, wantOK := runtime.DefaultHeaderMatcher(header)
+		if key != wantKey || ok != wantOK {
+			t.Errorf("gatewayHeaderMatcher(%s) = %q, %v, want the default %q, %v", header, key, ok, wantKey, wantOK)
+		}
+	}
+	// The runtime forwards Authorization itself
+	if _, ok := gatewayHeaderMatcher("Authorization"); ok {
+		t.Error("gatewayHeaderMatcher() should leave Authorization to the runtime")
+	}
+	if _, ok := gatewayHeaderMatcher("X-Random"); ok {
+		t.Error("gatewayHeaderMatcher() should not forward arbitrary headers")
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	pb "example/grpc_demo/library"
+	"example/grpc_demo/server/storage"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// duplicatePolicy selects which matches against the catalog make a new book a duplicate
+type duplicatePolicy struct {
+	isbn        bool
+	titleAuthor bool
+}
+
+// defaultDuplicatePolicy checks both ISBN and title+author when no checks are configured
+var defaultDuplicatePolicy = duplicatePolicy{isbn: true, titleAuthor: true}
+
+// parseDuplicatePolicy parses the DUPLICATE_CHECK list of "isbn" and "title_author", or "off" to disable
+// duplicate detection
+func parseDuplicatePolicy(checks []string) (duplicatePolicy, error) {
+	if len(checks) == 0 {
+		return defaultDuplicatePolicy, nil
+	}
+	var policy duplicatePolicy
+	for _, check := range checks {
+		switch strings.ToLower(strings.TrimSpace(check)) {
+		case "isbn":
+			policy.isbn = true
+		case "title_author":
+			policy.titleAuthor = true
+		case "off", "none":
+		default:
+			return duplicatePolicy{}, fmt.Errorf("DUPLICATE_CHECK: unknown check %q (want isbn, title_author or off)", check)
+		}
+	}
+	return policy, nil
+}
+
+// findDuplicate returns the ID of an existing book that the new book duplicates and why, or "" if none
+func (p duplicatePolicy) findDuplicate(ctx context.Context, books storage.BookRepository, book *pb.Book) (string, string, error) {
+	if isbn := normalizeISBN(book.GetIsbn()); p.isbn && isbn != "" {
+		id, err := books.FindByISBN(ctx, isbn)
+		if err != nil {
+			return "", "", err
+		}
+		if id != "" {
+			return id, "same ISBN", nil
+		}
+	}
+	if title := storage.MatchKey(book.GetTitle()); p.titleAuthor && title != "" {
+		id, err := books.FindByTitleAuthorKey(ctx, title, storage.MatchKey(book.GetAuthor()))
+		if err != nil {
+			return "", "", err
+		}
+		if id != "" {
+			return id, "same title and author", nil
+		}
+	}
+	return "", "", nil
+}
+
+// matchKeys returns the keys BookRepository.Match looks book up by, leaving out the checks p doesn't make
+func (p duplicatePolicy) matchKeys(book *pb.Book) storage.BookKeys {
+	keys := storage.BookKeys{ID: book.GetId()}
+	if p.isbn {
+		keys.ISBN = normalizeISBN(book.GetIsbn())
+	}
+	if title := storage.MatchKey(book.GetTitle()); p.titleAuthor && title != "" {
+		keys.TitleKey, keys.AuthorKey = title, storage.MatchKey(book.GetAuthor())
+	}
+	return keys
+}
+
+// duplicateError reports a duplicate as AlreadyExists, naming the conflicting book in a ResourceInfo detail
+func duplicateError(existingID, reason string) error {
+	st := status.Newf(codes.AlreadyExists, "book duplicates %s (%s); set allow_duplicate to add it anyway", existingID, reason)
+	return withDetails(st, &errdetails.ResourceInfo{
+		ResourceType: resourceBook,
+		ResourceName: existingID,
+		Description:  reason,
+	})
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"testing"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestParseDuplicatePolicy(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    duplicatePolicy
+		wantErr bool
+	}{
+		{"", defaultDuplicatePolicy, false},
+		{"isbn", duplicatePolicy{isbn: true}, false},
+		{"title_author", duplicatePolicy{titleAuthor: true}, false},
+		{"ISBN, title_author", duplicatePolicy{isbn: true, titleAuthor: true}, false},
+		{"off", duplicatePolicy{}, false},
+		{"title", duplicatePolicy{}, true},
+	}
+	for _, tt := range tests {
+		cfg, err := loadTestConfig(t, map[string]string{"DUPLICATE_CHECK": tt.value})
+		if (err != nil) != tt.wantErr {
+			t.Errorf("DUPLICATE_CHECK=%q: error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			continue
+		}
+		if tt.wantErr {
+			continue
+		}
+		if got, err := parseDuplicatePolicy(cfg.Catalog.DuplicateChecks); err != nil || got != tt.want {
+			t.Errorf("DUPLICATE_CHECK=%q: parseDuplicatePolicy() = %+v, %v, want %+v", tt.value, got, err, tt.want)
+		}
+	}
+}
+
+func TestDuplicateError(t *testing.T) {
+	st := status.Convert(duplicateError("book1", "same ISBN"))
+	if st.Code() != codes.AlreadyExists {
+		t.Errorf("code = %v, want AlreadyExists", st.Code())
+	}
+	details := st.Details()
+	if len(details) != 1 {
+		t.Fatalf("got %d details, want 1", len(details))
+	}
+	info, ok := details[0].(*errdetails.ResourceInfo)
+	if !ok || info.GetResourceName() != "book1" || info.GetDescription() != "same ISBN" {
+		t.Errorf("detail = %v, want ResourceInfo for book1", details[0])
+	}
+}
@@ -0,0 +1,286 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// errorReportHTTPTimeout bounds a call to the error reporting endpoint
+	errorReportHTTPTimeout = 10 * time.Second
+	// errorReportBufferSize is how many reports may wait to be sent before new ones are dropped
+	errorReportBufferSize = 100
+	// maxStackDepth bounds the frames captured for a panic
+	maxStackDepth = 64
+)
+
+// errorReport describes an RPC that failed unexpectedly
+type errorReport struct {
+	method    string
+	requestID string
+	userID    int
+	code      codes.Code
+	message   string
+	// panicked is set when the handler panicked, with stack the program counters of the goroutine at the
+	// panic, innermost first
+	panicked bool
+	stack    []uintptr
+	traceID  string
+	at       time.Time
+}
+
+// errorReporter ships unexpected errors to an error tracking service
+type errorReporter interface {
+	Report(ctx context.Context, r errorReport)
+}
+
+// reportedCode tells whether code is that of an unexpected failure, as opposed to a client error
+func reportedCode(code codes.Code) bool {
+	return code == codes.Internal || code == codes.Unknown || code == codes.DataLoss
+}
+
+// newErrorReport describes the failure of the call of ctx to method with err
+func newErrorReport(ctx context.Context, method string, err error) errorReport {
+	r := errorReport{
+		method:    method,
+		requestID: requestIDFromContext(ctx),
+		code:      status.Code(err),
+		message:   status.Convert(err).Message(),
+		at:        time.Now(),
+	}
+	if caller, ok := ctx.Value(rpcLogKey{}).(*rpcCaller); ok {
+		r.userID = caller.userID
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		r.traceID = sc.TraceID().String()
+	}
+	return r
+}
+
+// reportError sends err to reporter unless it is a client error or the call's panic was already reported
+func reportError(ctx context.Context, reporter errorReporter, method string, err error) {
+	if reporter == nil || !reportedCode(status.Code(err)) {
+		return
+	}
+	if caller, ok := ctx.Value(rpcLogKey{}).(*rpcCaller); ok && caller.reported {
+		return
+	}
+	reporter.Report(ctx, newErrorReport(ctx, method, err))
+}
+
+// sentryReporter sends reports to a Sentry-compatible service (Sentry, GlitchTip, ...) through its envelope
+// API, from a goroutine so that calls never wait for it
+type sentryReporter struct {
+	dsn         string
+	endpoint    string
+	auth        string
+	environment string
+	release     string
+	serverName  string
+	client      *http.Client
+	reports     chan errorReport
+}
+
+// newErrorReporter configures reporting to the project of cfg.SentryDSN, tagging events with the
+// configured environment and release. It returns nil when no DSN is configured.
+func newErrorReporter(cfg ErrorReportConfig) (*sentryReporter, error) {
+	dsn := cfg.SentryDSN
+	if dsn == "" {
+		return nil, nil
+	}
+	endpoint, key, err := parseSentryDSN(dsn)
+	if err != nil {
+		return nil, err
+	}
+	hostname, _ := os.Hostname()
+	return &sentryReporter{
+		dsn:         dsn,
+		endpoint:    endpoint,
+		auth:        "Sentry sentry_version=7, sentry_client=grpc_demo/1.0, sentry_key=" + key,
+		environment: cfg.Environment,
+		release:     cfg.Release,
+		serverName:  hostname,
+		client:      &http.Client{Timeout: errorReportHTTPTimeout},
+		reports:     make(chan errorReport, errorReportBufferSize),
+	}, nil
+}
+
+// parseSentryDSN returns the envelope endpoint and public key of a DSN such as
+// https://<key>@o1.ingest.sentry.io/<project>
+func parseSentryDSN(dsn string) (endpoint, key string, err error) {
+	u, err := url.Parse(dsn)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || u.User == nil {
+		return "", "", fmt.Errorf("SENTRY_DSN must look like https://<key>@<host>/<project>, got %q", dsn)
+	}
+	path := strings.TrimSuffix(u.Path, "/")
+	i := strings.LastIndex(path, "/")
+	project := path[i+1:]
+	if _, err := strconv.Atoi(project); err != nil {
+		return "", "", fmt.Errorf("SENTRY_DSN must end with the numeric project ID, got %q", dsn)
+	}
+	key = u.User.Username()
+	endpoint = fmt.Sprintf("%s://%s%s/api/%s/envelope/", u.Scheme, u.Host, path[:i], project)
+	return endpoint, key, nil
+}
+
+// Report queues r, dropping it with a warning when the queue is full
+func (s *sentryReporter) Report(ctx context.Context, r errorReport) {
+	select {
+	case s.reports <- r:
+	default:
+		slog.WarnContext(ctx, "dropping error report: the queue is full", "method", r.method)
+	}
+}
+
+// run sends queued reports until ctx is done
+func (s *sentryReporter) run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case r := <-s.reports:
+			if err := s.send(ctx, r); err != nil {
+				slog.Warn("failed to send error report", "method", r.method, "error", err)
+			}
+		}
+	}
+}
+
+// sentryFrame is a stack frame of a Sentry event
+type sentryFrame struct {
+	Function string `json:"function"`
+	Module   string `json:"module,omitempty"`
+	AbsPath  string `json:"abs_path,omitempty"`
+	Lineno   int    `json:"lineno,omitempty"`
+	InApp    bool   `json:"in_app"`
+}
+
+// sentryEvent builds the event of r, as documented at https://develop.sentry.dev/sdk/data-model/event-payloads/
+func (s *sentryReporter) sentryEvent(eventID string, r errorReport) map[string]any {
+	exception := map[string]any{
+		"type":      r.code.String(),
+		"value":     r.message,
+		"mechanism": map[string]any{"type": "grpc", "handled": !r.panicked},
+	}
+	level := "error"
+	if r.panicked {
+		exception["type"] = "panic"
+		level = "fatal"
+	}
+	if frames := sentryFrames(r.stack); len(frames) > 0 {
+		exception["stacktrace"] = map[string]any{"frames": frames}
+	}
+	tags := map[string]string{"grpc.method": r.method, "grpc.code": r.code.String(), "request_id": r.requestID}
+	event := map[string]any{
+		"event_id":    eventID,
+		"timestamp":   r.at.UTC().Format(time.RFC3339Nano),
+		"platform":    "go",
+		"level":       level,
+		"logger":      "grpc",
+		"transaction": r.method,
+		"server_name": s.serverName,
+		"tags":        tags,
+		"exception":   map[string]any{"values": []any{exception}},
+	}
+	if s.environment != "" {
+		event["environment"] = s.environment
+	}
+	if s.release != "" {
+		event["release"] = s.release
+	}
+	if r.userID != 0 {
+		event["user"] = map[string]string{"id": strconv.Itoa(r.userID)}
+	}
+	if r.traceID != "" {
+		tags["trace_id"] = r.traceID
+	}
+	return event
+}
+
+// sentryFrames lists the frames of stack outermost first, as Sentry expects
+func sentryFrames(stack []uintptr) []sentryFrame {
+	if len(stack) == 0 {
+		return nil
+	}
+	var frames []sentryFrame
+	callers := runtime.CallersFrames(stack)
+	for {
+		frame, more := callers.Next()
+		module, function := splitFunctionName(frame.Function)
+		frames = append(frames, sentryFrame{
+			Function: function,
+			Module:   module,
+			AbsPath:  frame.File,
+			Lineno:   frame.Line,
+			InApp:    module == "main" || strings.HasPrefix(module, "example/grpc_demo"),
+		})
+		if !more {
+			break
+		}
+	}
+	for i, j := 0, len(frames)-1; i < j; i, j = i+1, j-1 {
+		frames[i], frames[j] = frames[j], frames[i]
+	}
+	return frames
+}
+
+// splitFunctionName splits a qualified function name such as github.com/jackc/pgx/v5.(*Conn).Query into
+// its package path and the function within it
+func splitFunctionName(name string) (module, function string) {
+	slash := strings.LastIndex(name, "/")
+	dot := strings.Index(name[slash+1:], ".")
+	if dot < 0 {
+		return "", name
+	}
+	return name[:slash+1+dot], name[slash+1+dot+1:]
+}
+
+// send posts r to the envelope endpoint
+func (s *sentryReporter) send(ctx context.Context, r errorReport) error {
+	eventID := strings.ReplaceAll(uuid.NewString(), "-", "")
+	event, err := json.Marshal(s.sentryEvent(eventID, r))
+	if err != nil {
+		return err
+	}
+	header, err := json.Marshal(map[string]string{"event_id": eventID, "dsn": s.dsn, "sent_at": time.Now().UTC().Format(time.RFC3339Nano)})
+	if err != nil {
+		return err
+	}
+	var body bytes.Buffer
+	body.Write(header)
+	fmt.Fprintf(&body, "\n{\"type\":\"event\",\"length\":%d}\n", len(event))
+	body.Write(event)
+	body.WriteByte('\n')
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.endpoint, &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-sentry-envelope")
+	req.Header.Set("X-Sentry-Auth", s.auth)
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return errors.New("error reporting endpoint answered " + resp.Status)
+	}
+	return nil
+}
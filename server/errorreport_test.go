@@ -0,0 +1,167 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	pb "example/grpc_demo/library"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// recordingReporter keeps the reports it is sent
+type recordingReporter struct {
+	reports []errorReport
+}
+
+func (r *recordingReporter) Report(_ context.Context, report errorReport) {
+	r.reports = append(r.reports, report)
+}
+
+func TestParseSentryDSN(t *testing.T) {
+	endpoint, key, err := parseSentryDSN("https://abc123@o1.ingest.sentry.io/42")
+	if err != nil || endpoint != "https://o1.ingest.sentry.io/api/42/envelope/" || key != "abc123" {
+		t.Errorf("parseSentryDSN() = %q, %q, %v", endpoint, key, err)
+	}
+	// Self-hosted services may live under a path
+	endpoint, _, err = parseSentryDSN("http://key@glitchtip.internal:8000/errors/7/")
+	if err != nil || endpoint != "http://glitchtip.internal:8000/errors/api/7/envelope/" {
+		t.Errorf("parseSentryDSN() with a path = %q, %v", endpoint, err)
+	}
+	for _, dsn := range []string{"o1.ingest.sentry.io/42", "https://o1.ingest.sentry.io/42", "https://key@o1.ingest.sentry.io/project", "ftp://key@host/1"} {
+		if _, _, err := parseSentryDSN(dsn); err == nil {
+			t.Errorf("parseSentryDSN(%q) succeeded", dsn)
+		}
+	}
+}
+
+func TestSentryReporterSend(t *testing.T) {
+	var auth, path string
+	var lines []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth, path = r.Header.Get("X-Sentry-Auth"), r.URL.Path
+		scanner := bufio.NewScanner(r.Body)
+		for scanner.Scan() {
+			lines = append(lines, scanner.Text())
+		}
+	}))
+	defer srv.Close()
+	reporter, err := newErrorReporter(ErrorReportConfig{SentryDSN: strings.Replace(srv.URL, "://", "://public@", 1) + "/3", Environment: "staging"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = reporter.send(context.Background(), errorReport{
+		method:    pb.LibraryService_GetBook_FullMethodName,
+		requestID: "req-1",
+		userID:    7,
+		code:      codes.Internal,
+		message:   "runtime error: index out of range",
+		panicked:  true,
+		stack:     []uintptr{callerPC()},
+		at:        time.Now(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path != "/api/3/envelope/" || !strings.Contains(auth, "sentry_key=public") {
+		t.Errorf("posted to %s with auth %q", path, auth)
+	}
+	if len(lines) != 3 || !strings.Contains(lines[1], `"type":"event"`) {
+		t.Fatalf("envelope = %q, want a header, an item header and an event", lines)
+	}
+	var event struct {
+		Level       string            `json:"level"`
+		Environment string            `json:"environment"`
+		User        map[string]string `json:"user"`
+		Tags        map[string]string `json:"tags"`
+		Exception   struct {
+			Values []struct {
+				Type       string `json:"type"`
+				Value      string `json:"value"`
+				Stacktrace struct {
+					Frames []sentryFrame `json:"frames"`
+				} `json:"stacktrace"`
+			} `json:"values"`
+		} `json:"exception"`
+	}
+	if err := json.Unmarshal([]byte(lines[2]), &event); err != nil {
+		t.Fatal(err)
+	}
+	if event.Level != "fatal" || event.Environment != "staging" || event.User["id"] != "7" || event.Tags["request_id"] != "req-1" ||
+		event.Tags["grpc.method"] != pb.LibraryService_GetBook_FullMethodName {
+		t.Errorf("event = %+v", event)
+	}
+	exception := event.Exception.Values[0]
+	frames := exception.Stacktrace.Frames
+	if exception.Type != "panic" || len(frames) != 1 || frames[0].Function != "callerPC" || frames[0].Module != "example/grpc_demo/server" && frames[0].Module != "main" || !frames[0].InApp {
+		t.Errorf("exception = %+v", exception)
+	}
+}
+
+// callerPC returns a program counter inside itself
+func callerPC() uintptr {
+	var pcs [1]uintptr
+	runtime.Callers(1, pcs[:])
+	return pcs[0]
+}
+
+func TestRecoveryInterceptor(t *testing.T) {
+	reporter := &recordingReporter{}
+	interceptor := CreateRecoveryInterceptor(reporter)
+	caller := &rpcCaller{userID: 7}
+	ctx := context.WithValue(context.WithValue(context.Background(), requestIDKey{}, "req-1"), rpcLogKey{}, caller)
+	info := &grpc.UnaryServerInfo{FullMethod: pb.LibraryService_GetBook_FullMethodName}
+
+	resp, err := interceptor(ctx, nil, info, func(context.Context, interface{}) (interface{}, error) {
+		var books []*pb.Book
+		return books[1], nil
+	})
+	if resp != nil || status.Code(err) != codes.Internal {
+		t.Fatalf("interceptor() = %v, %v; want an Internal error", resp, err)
+	}
+	if len(reporter.reports) != 1 {
+		t.Fatalf("%d reports, want 1", len(reporter.reports))
+	}
+	r := reporter.reports[0]
+	if !r.panicked || r.userID != 7 || r.requestID != "req-1" || !strings.Contains(r.message, "index out of range") {
+		t.Errorf("report = %+v", r)
+	}
+	// The innermost frame of the program, under the runtime's, is the handler that panicked
+	var innermost sentryFrame
+	for _, f := range sentryFrames(r.stack) {
+		if f.InApp {
+			innermost = f
+		}
+	}
+	if !strings.HasPrefix(innermost.Function, "TestRecoveryInterceptor.func") {
+		t.Errorf("innermost frame = %+v, want the handler", innermost)
+	}
+
+	// The logging interceptor doesn't report the same failure again
+	reportError(ctx, reporter, info.FullMethod, err)
+	if len(reporter.reports) != 1 {
+		t.Errorf("the panic was reported %d times", len(reporter.reports))
+	}
+}
+
+func TestReportError(t *testing.T) {
+	reporter := &recordingReporter{}
+	ctx := context.WithValue(context.Background(), rpcLogKey{}, &rpcCaller{})
+	reportError(ctx, reporter, pb.LibraryService_GetBook_FullMethodName, status.Error(codes.NotFound, "Book not found"))
+	reportError(ctx, reporter, pb.LibraryService_GetBook_FullMethodName, nil)
+	reportError(ctx, reporter, pb.LibraryService_GetBook_FullMethodName, status.Error(codes.Internal, "internal error: connection reset"))
+	if len(reporter.reports) != 1 || reporter.reports[0].code != codes.Internal {
+		t.Errorf("reports = %+v, want only the Internal error", reporter.reports)
+	}
+	reportError(ctx, nil, pb.LibraryService_GetBook_FullMethodName, status.Error(codes.Internal, "no reporter"))
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+
+	pb "example/grpc_demo/library"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+const (
+	defaultExportChunkSize = 100
+	maxExportChunkSize     = 1000
+)
+
+// bookCSVHeader is the column layout used by CSV exports and imports
+var bookCSVHeader = []string{"id", "title", "author", "isbn"}
+
+// exportWriter buffers encoded books until a chunk is ready to be sent
+type exportWriter struct {
+	format pb.ExportFormat
+	buf    bytes.Buffer
+	csv    *csv.Writer
+	rows   int32
+}
+
+func newExportWriter(format pb.ExportFormat) (*exportWriter, error) {
+	w := &exportWriter{format: format}
+	if format == pb.ExportFormat_EXPORT_FORMAT_CSV {
+		w.csv = csv.NewWriter(&w.buf)
+		if err := w.csv.Write(bookCSVHeader); err != nil {
+			return nil, err
+		}
+	}
+	return w, nil
+}
+
+// Write appends one book to the current chunk
+func (w *exportWriter) Write(book *pb.Book) error {
+	if w.csv != nil {
+		if err := w.csv.Write([]string{book.GetId(), book.GetTitle(), book.GetAuthor(), book.GetIsbn()}); err != nil {
+			return err
+		}
+	} else {
+		line, err := protojson.Marshal(book)
+		if err != nil {
+			return err
+		}
+		w.buf.Write(line)
+		w.buf.WriteByte('\n')
+	}
+	w.rows++
+	return nil
+}
+
+// Rows returns the number of books buffered in the current chunk
+func (w *exportWriter) Rows() int32 {
+	return w.rows
+}
+
+// Flush returns the buffered chunk, or nil if nothing is buffered, and resets the writer
+func (w *exportWriter) Flush() (*pb.ExportChunk, error) {
+	if w.csv != nil {
+		w.csv.Flush()
+		if err := w.csv.Error(); err != nil {
+			return nil, err
+		}
+	}
+	if w.buf.Len() == 0 {
+		return nil, nil
+	}
+	chunk := &pb.ExportChunk{Data: bytes.Clone(w.buf.Bytes()), RowCount: w.rows}
+	w.buf.Reset()
+	w.rows = 0
+	return chunk, nil
+}
+
+func (s *server) ExportBooks(req *pb.ExportRequest, stream pb.LibraryService_ExportBooksServer) error {
+	format := req.GetFormat()
+	if format == pb.ExportFormat_EXPORT_FORMAT_UNSPECIFIED {
+		format = pb.ExportFormat_EXPORT_FORMAT_CSV
+	}
+	chunkSize := req.GetChunkSize()
+	if chunkSize < 1 {
+		chunkSize = defaultExportChunkSize
+	}
+	if chunkSize > maxExportChunkSize {
+		chunkSize = maxExportChunkSize
+	}
+
+	w, err := newExportWriter(format)
+	if err != nil {
+		return status.Errorf(codes.Internal, "failed to start export: %v", err)
+	}
+
+	// Each returns the errors of the function as they are: the statuses of encoding failures, and sendErr
+	var sendErr error
+	err = s.books.Each(stream.Context(), func(b *pb.Book) error {
+		if err := w.Write(b); err != nil {
+			return status.Errorf(codes.Internal, "failed to encode book: %v", err)
+		}
+		if w.Rows() < chunkSize {
+			return nil
+		}
+		chunk, err := w.Flush()
+		if err != nil {
+			return status.Errorf(codes.Internal, "failed to encode chunk: %v", err)
+		}
+		sendErr = stream.Send(chunk)
+		return sendErr
+	})
+	if sendErr != nil {
+		return sendErr
+	}
+	if _, ok := status.FromError(err); !ok {
+		return status.Errorf(codes.Internal, "failed to read books: %v", err)
+	}
+	if err != nil {
+		return err
+	}
+
+	chunk, err := w.Flush()
+	if err != nil {
+		return status.Errorf(codes.Internal, "failed to encode chunk: %v", err)
+	}
+	if chunk != nil {
+		return stream.Send(chunk)
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+
+	pb "example/grpc_demo/library"
+	"example/grpc_demo/server/storage"
+
+	"google.golang.org/grpc"
+)
+
+func TestExportWriterCSV(t *testing.T) {
+	w, err := newExportWriter(pb.ExportFormat_EXPORT_FORMAT_CSV)
+	if err != nil {
+		t.Fatalf("newExportWriter() error = %v", err)
+	}
+	if err := w.Write(&pb.Book{Id: "book1", Title: "Go, Programming", Author: "John Doe"}); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	chunk, err := w.Flush()
+	if err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+	want := "id,title,author,isbn\nbook1,\"Go, Programming\",John Doe,\n"
+	if string(chunk.GetData()) != want {
+		t.Errorf("Flush() data = %q, want %q", chunk.GetData(), want)
+	}
+	if chunk.GetRowCount() != 1 {
+		t.Errorf("Flush() row count = %d, want 1", chunk.GetRowCount())
+	}
+
+	// Nothing buffered after a flush
+	chunk, err = w.Flush()
+	if err != nil || chunk != nil {
+		t.Errorf("second Flush() = %v, %v; want nil, nil", chunk, err)
+	}
+}
+
+func TestExportWriterJSON(t *testing.T) {
+	w, err := newExportWriter(pb.ExportFormat_EXPORT_FORMAT_JSON)
+	if err != nil {
+		t.Fatalf("newExportWriter() error = %v", err)
+	}
+	for _, id := range []string{"book1", "book2"} {
+		if err := w.Write(&pb.Book{Id: id, Title: "Title", Author: "Author"}); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+	}
+
+	chunk, err := w.Flush()
+	if err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+	lines := strings.Split(strings.TrimSuffix(string(chunk.GetData()), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Flush() produced %d lines, want 2", len(lines))
+	}
+	if !strings.Contains(lines[1], "book2") {
+		t.Errorf("second line = %q, want it to contain book2", lines[1])
+	}
+}
+
+// exportStream keeps the chunks sent by ExportBooks
+type exportStream struct {
+	grpc.ServerStream
+	chunks []*pb.ExportChunk
+}
+
+func (s *exportStream) Context() context.Context { return context.Background() }
+
+func (s *exportStream) Send(chunk *pb.ExportChunk) error {
+	s.chunks = append(s.chunks, chunk)
+	return nil
+}
+
+func TestExportBooks(t *testing.T) {
+	store := storage.NewMemory()
+	for _, id := range []string{"b3", "b1", "b2"} {
+		if err := store.Books().Create(context.Background(), &pb.Book{Id: id, Title: "Title " + id}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s := &server{books: store.Books()}
+
+	stream := &exportStream{}
+	if err := s.ExportBooks(&pb.ExportRequest{ChunkSize: 2}, stream); err != nil {
+		t.Fatalf("ExportBooks() error = %v", err)
+	}
+	var rows []int32
+	var data strings.Builder
+	for _, chunk := range stream.chunks {
+		rows = append(rows, chunk.GetRowCount())
+		data.Write(chunk.GetData())
+	}
+	want := "id,title,author,isbn\nb1,Title b1,,\nb2,Title b2,,\nb3,Title b3,,\n"
+	if !slices.Equal(rows, []int32{2, 1}) || data.String() != want {
+		t.Errorf("ExportBooks() sent chunks of %v rows holding %q, want 2 and 1 holding %q", rows, data.String(), want)
+	}
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	pb "example/grpc_demo/library"
+	"example/grpc_demo/server/storage"
+
+	"github.com/jackc/pgx/v5"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (s *server) AddFavorite(ctx context.Context, req *pb.FavoriteRequest) (*pb.BookResponse, error) {
+	userID, _, ok := userFromContext(ctx)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "authentication required")
+	}
+	if req.GetBookId() == "" {
+		return nil, badRequest("book_id", "Book ID is required")
+	}
+
+	var exists bool
+	err := s.db.QueryRow(ctx, "SELECT EXISTS(SELECT 1 FROM books WHERE id=$1)", req.GetBookId()).Scan(&exists)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "database error: %v", err)
+	}
+	if !exists {
+		return nil, notFound(resourceBook, req.GetBookId(), "Book not found")
+	}
+
+	_, err = s.db.Exec(ctx, "INSERT INTO favorites (user_id, book_id) VALUES ($1, $2) ON CONFLICT DO NOTHING", userID, req.GetBookId())
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to add favorite: %v", err)
+	}
+	return &pb.BookResponse{Id: req.GetBookId()}, nil
+}
+
+func (s *server) RemoveFavorite(ctx context.Context, req *pb.FavoriteRequest) (*pb.BookResponse, error) {
+	userID, _, ok := userFromContext(ctx)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "authentication required")
+	}
+	if req.GetBookId() == "" {
+		return nil, badRequest("book_id", "Book ID is required")
+	}
+
+	res, err := s.db.Exec(ctx, "DELETE FROM favorites WHERE user_id=$1 AND book_id=$2", userID, req.GetBookId())
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to remove favorite: %v", err)
+	}
+	if res.RowsAffected() == 0 {
+		return nil, notFound(resourceFavorite, req.GetBookId(), "Book is not in favorites")
+	}
+	return &pb.BookResponse{Id: req.GetBookId()}, nil
+}
+
+func (s *server) ListFavorites(ctx context.Context, req *pb.ListFavoritesRequest) (*pb.ListBookResponse, error) {
+	userID, _, ok := userFromContext(ctx)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "authentication required")
+	}
+	page, err := pageOf(req, fmt.Sprintf("favorites %d", userID), time.Time{}, "")
+	if err != nil {
+		return nil, err
+	}
+
+	var filter sqlFilter
+	filter.where("f.user_id = " + filter.arg(userID))
+	order := storage.Keyset{Columns: []string{"f.created_at", "b.id"}, Desc: true}
+	page.where(&filter, order)
+	rows, err := s.db.Query(ctx,
+		"SELECT "+storage.QualifiedBookColumns("b")+", f.created_at FROM favorites f JOIN books b ON b.id = f.book_id"+
+			filter.clause()+order.OrderBy()+page.limitOffset(&filter),
+		filter.args...)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "database error: %v", err)
+	}
+	// The key of a favorite is when it was added, which isn't part of the book
+	favoritedAt := make(map[string]time.Time)
+	books, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (*pb.Book, error) {
+		var at time.Time
+		book, err := storage.ScanBook(row, &at)
+		if err != nil {
+			return nil, err
+		}
+		favoritedAt[book.GetId()] = at
+		return book, nil
+	})
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "database error: %v", err)
+	}
+	books, next := nextPage(page, books, func(b *pb.Book) []any { return []any{favoritedAt[b.GetId()], b.GetId()} })
+	if err := s.enrichBooks(ctx, books); err != nil {
+		return nil, status.Errorf(codes.Internal, "database error: %v", err)
+	}
+
+	var totalCount int32
+	if err := s.db.QueryRow(ctx, "SELECT COUNT(*) FROM favorites WHERE user_id=$1", userID).Scan(&totalCount); err != nil {
+		return nil, status.Errorf(codes.Internal, "database error: %v", err)
+	}
+	return &pb.ListBookResponse{Books: books, TotalCount: totalCount, NextPageToken: next}, nil
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"testing"
+
+	pb "example/grpc_demo/library"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// TestFavorites runs against the database of STORAGE_TEST_DATABASE_URL
+func TestFavorites(t *testing.T) {
+	pool := testDatabase(t, "favorites-test-")
+	s := &server{db: pool}
+	alice := testUser(t, pool, "favorites-test-alice")
+	bob := testUser(t, pool, "favorites-test-bob")
+	for _, id := range []string{"favorites-test-dune", "favorites-test-emma"} {
+		testBook(t, pool, id, 1)
+	}
+
+	if _, err := s.AddFavorite(alice, &pb.FavoriteRequest{BookId: "favorites-test-missing"}); status.Code(err) != codes.NotFound {
+		t.Errorf("AddFavorite() of an unknown book error = %v, want NotFound", err)
+	}
+	for _, id := range []string{"favorites-test-dune", "favorites-test-emma", "favorites-test-dune"} {
+		if _, err := s.AddFavorite(alice, &pb.FavoriteRequest{BookId: id}); err != nil {
+			t.Fatalf("AddFavorite(%s) error = %v", id, err)
+		}
+	}
+
+	resp, err := s.ListFavorites(alice, &pb.ListFavoritesRequest{})
+	if err != nil || resp.GetTotalCount() != 2 || len(resp.GetBooks()) != 2 {
+		t.Errorf("ListFavorites() = %v, %v, want 2 books", resp, err)
+	}
+	if resp, err := s.ListFavorites(bob, &pb.ListFavoritesRequest{}); err != nil || resp.GetTotalCount() != 0 {
+		t.Errorf("ListFavorites() of another user = %v, %v, want none", resp, err)
+	}
+
+	if _, err := s.RemoveFavorite(alice, &pb.FavoriteRequest{BookId: "favorites-test-dune"}); err != nil {
+		t.Errorf("RemoveFavorite() error = %v", err)
+	}
+	if _, err := s.RemoveFavorite(alice, &pb.FavoriteRequest{BookId: "favorites-test-dune"}); status.Code(err) != codes.NotFound {
+		t.Errorf("RemoveFavorite() of a book not in favorites error = %v, want NotFound", err)
+	}
+}
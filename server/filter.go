@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"example/grpc_demo/server/storage"
+)
+
+// sqlFilter accumulates WHERE conditions and their positional arguments
+type sqlFilter struct {
+	conds []string
+	args  []any
+}
+
+// arg registers a query argument and returns its $N placeholder
+func (f *sqlFilter) arg(v any) string {
+	f.args = append(f.args, v)
+	return fmt.Sprintf("$%d", len(f.args))
+}
+
+// where adds a condition built with placeholders from arg
+func (f *sqlFilter) where(cond string) {
+	f.conds = append(f.conds, cond)
+}
+
+// clause renders the accumulated conditions as a WHERE clause, or "" when empty
+func (f *sqlFilter) clause() string {
+	if len(f.conds) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(f.conds, " AND ")
+}
+
+// pageBounds applies the default paging (page 1, 10 per page) and returns LIMIT and OFFSET
+func pageBounds(page, pageSize int32) (limit, offset int32) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = 10
+	}
+	return pageSize, (page - 1) * pageSize
+}
+
+// pagedRequest is a request to a list RPC, paged by number or by token
+type pagedRequest interface {
+	GetPage() int32
+	GetPageSize() int32
+	GetPageToken() string
+}
+
+// listPage is where a page of a listing starts: after the key of a page token, or at the offset of a
+// page number
+type listPage struct {
+	limit, offset int32
+	// after is the key of the page token, nil when paging by number
+	after []any
+	// scope identifies the listing and its filters, which page tokens are bound to
+	scope string
+}
+
+// pageOf returns the page req asks for in the listing identified by scope, whose keys have the shape of
+// a value of each of their elements' types; a token of another listing or shape is rejected
+func pageOf(req pagedRequest, scope string, shape ...any) (listPage, error) {
+	limit, offset := pageBounds(req.GetPage(), req.GetPageSize())
+	p := listPage{limit: limit, offset: offset, scope: scope}
+	if req.GetPageToken() == "" {
+		return p, nil
+	}
+	after, err := storage.DecodePageToken(req.GetPageToken(), scope, shape...)
+	if err != nil {
+		return p, badRequest("page_token", "Invalid page token")
+	}
+	p.after, p.offset = after, 0
+	return p, nil
+}
+
+// where adds to f the condition of a page starting after a token's key, in the order of k
+func (p listPage) where(f *sqlFilter, k storage.Keyset) {
+	if p.after != nil {
+		f.where(k.After(p.after, f.arg))
+	}
+}
+
+// limitOffset returns the LIMIT and OFFSET clauses of the page, fetching one row more to tell whether
+// another page follows
+func (p listPage) limitOffset(f *sqlFilter) string {
+	return " LIMIT " + f.arg(p.limit+1) + " OFFSET " + f.arg(p.offset)
+}
+
+// nextPage trims the extra row fetched for the page and returns the token of the page after it, or ""
+func nextPage[T any](p listPage, rows []T, key func(T) []any) ([]T, string) {
+	return storage.NextPage(rows, p.limit, p.scope, key)
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"testing"
+
+	pb "example/grpc_demo/library"
+	"example/grpc_demo/server/storage"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestSQLFilter(t *testing.T) {
+	var empty sqlFilter
+	if got := empty.clause(); got != "" {
+		t.Errorf("empty clause() = %q, want empty", got)
+	}
+
+	var f sqlFilter
+	f.where("author = " + f.arg("John Doe"))
+	f.where("title ILIKE " + f.arg("%go%"))
+	if got, want := f.clause(), " WHERE author = $1 AND title ILIKE $2"; got != want {
+		t.Errorf("clause() = %q, want %q", got, want)
+	}
+	if len(f.args) != 2 || f.args[0] != "John Doe" {
+		t.Errorf("args = %v", f.args)
+	}
+}
+
+func TestPageBounds(t *testing.T) {
+	tests := []struct {
+		page, pageSize        int32
+		wantLimit, wantOffset int32
+	}{
+		{0, 0, 10, 0},
+		{1, 5, 5, 0},
+		{3, 5, 5, 10},
+		{-1, 20, 20, 0},
+	}
+	for _, tt := range tests {
+		limit, offset := pageBounds(tt.page, tt.pageSize)
+		if limit != tt.wantLimit || offset != tt.wantOffset {
+			t.Errorf("pageBounds(%d, %d) = %d, %d; want %d, %d", tt.page, tt.pageSize, limit, offset, tt.wantLimit, tt.wantOffset)
+		}
+	}
+}
+
+func TestPageOf(t *testing.T) {
+	p, err := pageOf(&pb.ListReviewsRequest{Page: 3, PageSize: 5}, "reviews b1", int64(0))
+	if err != nil || p.limit != 5 || p.offset != 10 || p.after != nil {
+		t.Fatalf("pageOf() by number = %+v, %v", p, err)
+	}
+	var f sqlFilter
+	p.where(&f, storage.Keyset{Columns: []string{"created_at", "id"}, Desc: true})
+	if got := p.limitOffset(&f); f.clause() != "" || got != " LIMIT $1 OFFSET $2" || f.args[0] != int32(6) {
+		t.Errorf("page by number: clause %q, %q, args %v", f.clause(), got, f.args)
+	}
+
+	// The token of the last row takes the place of the page number
+	rows, next := nextPage(p, []int64{1, 2, 3, 4, 5, 6}, func(id int64) []any { return []any{id} })
+	if len(rows) != 5 || next == "" {
+		t.Fatalf("nextPage() = %v, %q", rows, next)
+	}
+	p, err = pageOf(&pb.ListReviewsRequest{Page: 3, PageSize: 5, PageToken: next}, "reviews b1", int64(0))
+	if err != nil || p.offset != 0 || len(p.after) != 1 || p.after[0] != int64(5) {
+		t.Fatalf("pageOf() by token = %+v, %v", p, err)
+	}
+	f = sqlFilter{}
+	p.where(&f, storage.Keyset{Columns: []string{"id"}})
+	if f.clause() != " WHERE (id) > ($1)" {
+		t.Errorf("page by token clause = %q", f.clause())
+	}
+	if _, next := nextPage(p, rows[:3], func(id int64) []any { return []any{id} }); next != "" {
+		t.Errorf("nextPage() of the last page = %q, want none", next)
+	}
+
+	// A token is only good for the listing it was issued for
+	token := storage.EncodePageToken("reviews b1", int64(5))
+	if _, err := pageOf(&pb.ListReviewsRequest{PageToken: token}, "reviews b2", int64(0)); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("pageOf() of another listing's token error = %v, want InvalidArgument", err)
+	}
+	if _, err := pageOf(&pb.ListReviewsRequest{PageToken: "garbage"}, "reviews b1", int64(0)); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("pageOf() of a malformed token error = %v, want InvalidArgument", err)
+	}
+	// and for keys of the listing's shape, which would otherwise make the query fail
+	for _, key := range [][]any{{"5"}, {int64(5), int64(6)}} {
+		token := storage.EncodePageToken("reviews b1", key...)
+		if _, err := pageOf(&pb.ListReviewsRequest{PageToken: token}, "reviews b1", int64(0)); status.Code(err) != codes.InvalidArgument {
+			t.Errorf("pageOf() of a token with key %v error = %v, want InvalidArgument", key, err)
+		}
+	}
+}
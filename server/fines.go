@@ -0,0 +1,187 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	pb "example/grpc_demo/library"
+
+	"github.com/jackc/pgx/v5"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// finePolicy sets how much late loans are charged
+type finePolicy struct {
+	perDayCents int64
+	// maxCents caps a single fine; 0 means uncapped
+	maxCents int64
+}
+
+// newFinePolicy returns the fines configured in cfg
+func newFinePolicy(cfg CirculationConfig) finePolicy {
+	return finePolicy{perDayCents: int64(cfg.FinePerDayCents), maxCents: int64(cfg.FineMaxCents)}
+}
+
+// overdueDays returns the number of started days between due and end, or 0 if end is not past due
+func overdueDays(due, end time.Time) int64 {
+	late := end.Sub(due)
+	if late <= 0 {
+		return 0
+	}
+	const day = 24 * time.Hour
+	return int64((late + day - 1) / day)
+}
+
+// amount returns the fine for a loan that is days late
+func (p finePolicy) amount(days int64) int64 {
+	cents := days * p.perDayCents
+	if p.maxCents > 0 && cents > p.maxCents {
+		cents = p.maxCents
+	}
+	return cents
+}
+
+// assessFine creates or updates the fine for a loan due at dueAt and returned (or checked) at end.
+// A fine that grows after being paid off is reopened for the difference.
+func (p finePolicy) assessFine(ctx context.Context, q querier, loanID int64, userID int, dueAt, end time.Time) error {
+	days := overdueDays(dueAt, end)
+	if days == 0 {
+		return nil
+	}
+	_, err := q.Exec(ctx,
+		`INSERT INTO fines (loan_id, user_id, days_overdue, amount_cents) VALUES ($1, $2, $3, $4)
+		 ON CONFLICT (loan_id) DO UPDATE SET days_overdue=EXCLUDED.days_overdue, amount_cents=EXCLUDED.amount_cents,
+			paid_at = CASE WHEN EXCLUDED.amount_cents > fines.amount_cents THEN NULL ELSE fines.paid_at END`,
+		loanID, userID, days, p.amount(days))
+	return err
+}
+
+// fineColumns is the column list read by scanFine; fines are aliased f, loans l and books b
+const fineColumns = `f.id, f.loan_id, l.book_id, b.title, f.days_overdue, f.amount_cents,
+	COALESCE((SELECT SUM(p.amount_cents) FROM fine_payments p WHERE p.fine_id = f.id), 0)::bigint,
+	f.created_at, f.paid_at`
+
+// fineTables joins the tables referenced by fineColumns
+const fineTables = " FROM fines f JOIN loans l ON l.id = f.loan_id JOIN books b ON b.id = l.book_id"
+
+// scanFine reads a single fine selected with fineColumns
+func scanFine(row pgx.Row) (*pb.Fine, error) {
+	var (
+		fine      pb.Fine
+		createdAt time.Time
+		paidAt    *time.Time
+	)
+	if err := row.Scan(&fine.Id, &fine.LoanId, &fine.BookId, &fine.BookTitle, &fine.DaysOverdue,
+		&fine.AmountCents, &fine.PaidCents, &createdAt, &paidAt); err != nil {
+		return nil, err
+	}
+	fine.CreatedAt = timestamppb.New(createdAt)
+	if paidAt != nil {
+		fine.PaidAt = timestamppb.New(*paidAt)
+	}
+	return &fine, nil
+}
+
+func (s *server) ListMyFines(ctx context.Context, req *pb.ListFinesRequest) (*pb.ListFinesResponse, error) {
+	userID, _, ok := userFromContext(ctx)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "authentication required")
+	}
+
+	var filter sqlFilter
+	filter.where("f.user_id = " + filter.arg(userID))
+	if !req.GetIncludePaid() {
+		filter.where("f.paid_at IS NULL")
+	}
+	query := "SELECT " + fineColumns + fineTables + filter.clause() + " ORDER BY f.created_at DESC, f.id DESC"
+
+	rows, err := s.db.Query(ctx, query, filter.args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	resp := &pb.ListFinesResponse{}
+	for rows.Next() {
+		fine, err := scanFine(rows)
+		if err != nil {
+			return nil, err
+		}
+		resp.Fines = append(resp.Fines, fine)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Paid fines may have been filtered out, so the balance is computed separately
+	err = s.db.QueryRow(ctx,
+		`SELECT COALESCE(SUM(f.amount_cents - COALESCE((SELECT SUM(p.amount_cents) FROM fine_payments p WHERE p.fine_id = f.id), 0)), 0)::bigint
+		 FROM fines f WHERE f.user_id=$1 AND f.paid_at IS NULL`, userID).Scan(&resp.OutstandingCents)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (s *server) PayFine(ctx context.Context, req *pb.PayFineRequest) (*pb.Fine, error) {
+	userID, _, ok := userFromContext(ctx)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "authentication required")
+	}
+	if req.GetAmountCents() < 0 {
+		return nil, status.Error(codes.InvalidArgument, "payment amount cannot be negative")
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "database error: %v", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var amount, paid int64
+	var paidAt *time.Time
+	err = tx.QueryRow(ctx,
+		`SELECT amount_cents, COALESCE((SELECT SUM(p.amount_cents) FROM fine_payments p WHERE p.fine_id = fines.id), 0)::bigint, paid_at
+		 FROM fines WHERE id=$1 AND user_id=$2 FOR UPDATE`,
+		req.GetId(), userID).Scan(&amount, &paid, &paidAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, notFound(resourceFine, strconv.FormatInt(req.GetId(), 10), "Fine not found")
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "database error: %v", err)
+	}
+	remaining := amount - paid
+	if paidAt != nil || remaining <= 0 {
+		return nil, status.Error(codes.FailedPrecondition, "fine is already paid")
+	}
+
+	payment := req.GetAmountCents()
+	if payment == 0 {
+		payment = remaining
+	}
+	if payment > remaining {
+		return nil, status.Errorf(codes.InvalidArgument, "payment exceeds the remaining balance of %d cents", remaining)
+	}
+
+	if _, err := tx.Exec(ctx, "INSERT INTO fine_payments (fine_id, amount_cents) VALUES ($1, $2)", req.GetId(), payment); err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to record payment: %v", err)
+	}
+	if payment == remaining {
+		if _, err := tx.Exec(ctx, "UPDATE fines SET paid_at=NOW() WHERE id=$1", req.GetId()); err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to record payment: %v", err)
+		}
+	}
+
+	fine, err := scanFine(tx.QueryRow(ctx, "SELECT "+fineColumns+fineTables+" WHERE f.id=$1", req.GetId()))
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to load fine: %v", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to record payment: %v", err)
+	}
+	return fine, nil
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestOverdueDays(t *testing.T) {
+	due := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		end  time.Time
+		want int64
+	}{
+		{"returned early", due.Add(-time.Hour), 0},
+		{"returned on time", due, 0},
+		{"one minute late", due.Add(time.Minute), 1},
+		{"exactly one day late", due.Add(24 * time.Hour), 1},
+		{"just over two days late", due.Add(48*time.Hour + time.Second), 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := overdueDays(due, tt.end); got != tt.want {
+				t.Errorf("overdueDays() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFinePolicyAmount(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy finePolicy
+		days   int64
+		want   int64
+	}{
+		{"uncapped", finePolicy{perDayCents: 25}, 10, 250},
+		{"below cap", finePolicy{perDayCents: 25, maxCents: 500}, 10, 250},
+		{"capped", finePolicy{perDayCents: 25, maxCents: 500}, 30, 500},
+		{"free", finePolicy{}, 30, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.amount(tt.days); got != tt.want {
+				t.Errorf("amount(%d) = %d, want %d", tt.days, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewFinePolicy(t *testing.T) {
+	cfg, err := loadTestConfig(t, nil)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if policy := newFinePolicy(cfg.Circulation); policy != (finePolicy{perDayCents: 25}) {
+		t.Errorf("default policy = %+v, want {25 0}", policy)
+	}
+
+	cfg, err = loadTestConfig(t, map[string]string{"FINE_PER_DAY_CENTS": "10", "FINE_MAX_CENTS": "300"})
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if policy := newFinePolicy(cfg.Circulation); policy != (finePolicy{perDayCents: 10, maxCents: 300}) {
+		t.Errorf("newFinePolicy() = %+v, want {10 300}", policy)
+	}
+}
@@ -2,56 +2,265 @@ package main
 
 import (
 	"context"
-	"fmt"
-	"log"
+	"io"
+	"log/slog"
+	"net"
 	"net/http"
+	"slices"
+	"strings"
 
 	pb "example/grpc_demo/library"
+	"example/grpc_demo/server/graph"
 
 	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
 )
 
-func StartGateway() {
+// StartGateway serves the REST API on cfg.Gateway.Addr, over HTTPS when it has a certificate, proxying to the gRPC server at cfg.Gateway.Upstream with creds. Browser clients
+// authenticate with cookies, allowed only from the configured CORS origins.
+func StartGateway(cfg *Config, creds credentials.TransportCredentials) {
 	ctx := context.Background()
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
-	// Create a more basic ServeMux without custom header matching
-	mux := runtime.NewServeMux()
+	mux := newGatewayMux(newAuthCookies(cfg.Gateway.Cookies))
 
-	// Use basic connection options
+	// The stats handler passes the HTTP request's trace on to the gRPC server, and requests and responses
+	// may be as large as the server allows
 	opts := []grpc.DialOption{
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithTransportCredentials(creds),
+		grpc.WithStatsHandler(otelgrpc.NewClientHandler()),
+		grpc.WithDefaultCallOptions(cfg.GRPC.callOptions()...),
 	}
 
-	err := pb.RegisterUserServiceHandlerFromEndpoint(ctx, mux, "localhost:50051", opts)
+	// Share one upstream connection between generated and custom handlers
+	conn, err := grpc.NewClient(cfg.Gateway.Upstream, opts...)
 	if err != nil {
-		log.Fatalf("Failed to register UserService gateway: %v", err)
+		fatal("Failed to connect gateway to gRPC server", "error", err)
 	}
+	defer conn.Close()
 
-	err = pb.RegisterLibraryServiceHandlerFromEndpoint(ctx, mux, "localhost:50051", opts)
+	err = pb.RegisterUserServiceHandler(ctx, mux, conn)
 	if err != nil {
-		log.Fatalf("Failed to register LibraryService gateway: %v", err)
+		fatal("Failed to register UserService gateway", "error", err)
 	}
 
-	// Add CORS middleware
-	handler := corsMiddleware(mux)
+	err = pb.RegisterLibraryServiceHandler(ctx, mux, conn)
+	if err != nil {
+		fatal("Failed to register LibraryService gateway", "error", err)
+	}
+
+	err = pb.RegisterTagServiceHandler(ctx, mux, conn)
+	if err != nil {
+		fatal("Failed to register TagService gateway", "error", err)
+	}
+
+	err = pb.RegisterReviewServiceHandler(ctx, mux, conn)
+	if err != nil {
+		fatal("Failed to register ReviewService gateway", "error", err)
+	}
+
+	err = pb.RegisterFavoriteServiceHandler(ctx, mux, conn)
+	if err != nil {
+		fatal("Failed to register FavoriteService gateway", "error", err)
+	}
+
+	err = pb.RegisterShelfServiceHandler(ctx, mux, conn)
+	if err != nil {
+		fatal("Failed to register ShelfService gateway", "error", err)
+	}
+
+	err = pb.RegisterLoanServiceHandler(ctx, mux, conn)
+	if err != nil {
+		fatal("Failed to register LoanService gateway", "error", err)
+	}
+
+	err = pb.RegisterNotificationServiceHandler(ctx, mux, conn)
+	if err != nil {
+		fatal("Failed to register NotificationService gateway", "error", err)
+	}
+
+	err = pb.RegisterPublisherServiceHandler(ctx, mux, conn)
+	if err != nil {
+		fatal("Failed to register PublisherService gateway", "error", err)
+	}
+
+	err = pb.RegisterServerInfoServiceHandler(ctx, mux, conn)
+	if err != nil {
+		fatal("Failed to register ServerInfoService gateway", "error", err)
+	}
+	err = pb.RegisterTenantServiceHandler(ctx, mux, conn)
+	if err != nil {
+		fatal("Failed to register TenantService gateway", "error", err)
+	}
+
+	// Covers are served as raw image bytes rather than JSON
+	err = mux.HandlePath("GET", "/api/v1/books/{id}/cover", coverHandler(pb.NewLibraryServiceClient(conn)))
+	if err != nil {
+		fatal("Failed to register cover route", "error", err)
+	}
+
+	// GraphQL over the catalog, for frontends that prefer it to the REST routes
+	gql := graph.NewHandler(pb.NewUserServiceClient(conn), pb.NewLibraryServiceClient(conn), gatewayCallMetadata)
+	for _, method := range []string{"GET", "POST"} {
+		err = mux.HandlePath(method, "/graphql", func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+			gql.ServeHTTP(w, r)
+		})
+		if err != nil {
+			fatal("Failed to register GraphQL route", "error", err)
+		}
+	}
+
+	// Public keys for validating access tokens without calling back into the service
+	err = mux.HandlePath("GET", "/.well-known/jwks.json", jwksHandler)
+	if err != nil {
+		fatal("Failed to register JWKS route", "error", err)
+	}
+
+	// Prometheus metrics of the server, such as slow database queries
+	err = mux.HandlePath("GET", "/metrics", metricsHandler)
+	if err != nil {
+		fatal("Failed to register metrics route", "error", err)
+	}
+
+	// Samples of recent calls, bucketed by duration, for looking into latency outliers
+	if cfg.GRPC.RequestTracing {
+		err = mux.HandlePath("GET", "/debug/requests", debugRequestsHandler)
+		if err != nil {
+			fatal("Failed to register request tracing route", "error", err)
+		}
+	}
+
+	// Add CORS middleware, and start (or continue, from a traceparent header) a trace per request
+	handler := otelhttp.NewHandler(corsMiddleware(mux, corsOrigins(cfg.Gateway)), "gateway")
+
+	srv := gatewayServer(cfg.Gateway, handler)
+	serveTLS := cfg.Gateway.TLSCertFile != ""
+	slog.Info("REST gateway starting", "addr", cfg.Gateway.Addr, "upstream", cfg.Gateway.Upstream, "tls", serveTLS)
+	if serveTLS {
+		err = srv.ListenAndServeTLS(cfg.Gateway.TLSCertFile, cfg.Gateway.TLSKeyFile)
+	} else {
+		err = srv.ListenAndServe()
+	}
+	if err != nil {
+		fatal("Failed to serve gateway", "error", err)
+	}
+}
+
+// gatewayServer is the HTTP server of the gateway, with the timeouts of cfg
+func gatewayServer(cfg GatewayConfig, handler http.Handler) *http.Server {
+	return &http.Server{
+		Addr:         cfg.Addr,
+		Handler:      handler,
+		ReadTimeout:  cfg.ReadTimeout,
+		WriteTimeout: cfg.WriteTimeout,
+		IdleTimeout:  cfg.IdleTimeout,
+	}
+}
+
+// newGatewayMux is the gateway's ServeMux, before the services are registered on it
+func newGatewayMux(cookies *authCookies) *runtime.ServeMux {
+	return runtime.NewServeMux(
+		runtime.WithIncomingHeaderMatcher(gatewayHeaderMatcher),
+		runtime.WithOutgoingHeaderMatcher(gatewayOutgoingHeaderMatcher),
+		runtime.WithMetadata(cookies.metadata),
+		runtime.WithForwardResponseOption(cookies.forwardResponse),
+		runtime.WithErrorHandler(gatewayErrorHandler),
+	)
+}
+
+// forwardedForHeader is the metadata of the addresses a call was forwarded for, the caller's last
+const forwardedForHeader = "x-forwarded-for"
+
+// gatewayForwardedHeaders are the headers the gateway passes on to the gRPC server under their own name,
+// which the interceptors read, rather than the grpcgateway- one of runtime.DefaultHeaderMatcher
+var gatewayForwardedHeaders = []string{acceptLanguageHeader, deviceIDHeader, idempotencyKeyHeader, requestIDHeader, tenantHeader}
+
+// gatewayHeaderMatcher forwards gatewayForwardedHeaders as is, in addition to the default headers.
+// Authorization is left out: the runtime always forwards it as authorization itself, so matching it too
+// would hand the auth interceptor a second copy, under authorization or grpcgateway-authorization.
+// So is a Grpc-Metadata-X-Forwarded-For header, which would pass a client's pick of addresses for the
+// x-forwarded-for the gateway sets from the caller's.
+func gatewayHeaderMatcher(key string) (string, bool) {
+	if strings.EqualFold(key, "Authorization") {
+		return "", false
+	}
+	for _, header := range gatewayForwardedHeaders {
+		if strings.EqualFold(key, header) {
+			return header, true
+		}
+	}
+	header, ok := runtime.DefaultHeaderMatcher(key)
+	if strings.EqualFold(header, forwardedForHeader) {
+		return "", false
+	}
+	return header, ok
+}
+
+// gatewayOutgoingHeaderMatcher returns the request ID of a call as X-Request-Id, as errors are, and the
+// rest of its header metadata with the default Grpc-Metadata- prefix
+func gatewayOutgoingHeaderMatcher(key string) (string, bool) {
+	if key == requestIDHeader {
+		return http.CanonicalHeaderKey(requestIDHeader), true
+	}
+	return runtime.MetadataHeaderPrefix + key, true
+}
 
-	fmt.Println("REST Gateway server starting on port 8080")
-	if err := http.ListenAndServe(":8080", handler); err != nil {
-		log.Fatalf("Failed to serve gateway: %v", err)
+// gatewayCallMetadata is the metadata of the calls the gateway makes itself for r, outside the generated
+// handlers: the headers gatewayHeaderMatcher forwards and the Authorization header, or else the access
+// cookie. Its x-forwarded-for is the caller's address alone, as that is the hop sessionDevice and the
+// login rate limit trust.
+func gatewayCallMetadata(r *http.Request) metadata.MD {
+	md := metadata.MD{}
+	for key, values := range r.Header {
+		if header, ok := gatewayHeaderMatcher(key); ok {
+			md.Append(header, values...)
+		}
+	}
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		md.Set(forwardedForHeader, host)
+	}
+	auth := r.Header.Get("Authorization")
+	if auth == "" {
+		auth = cookieAuthorization(r)
+	}
+	if auth != "" {
+		md.Set("authorization", auth)
 	}
+	return md
 }
 
-func corsMiddleware(h http.Handler) http.Handler {
+// corsOrigins returns the origins allowed to make credentialed (cookie) requests, without trailing slashes
+// so that they compare equal to Origin headers
+func corsOrigins(cfg GatewayConfig) []string {
+	origins := make([]string, 0, len(cfg.CORSAllowedOrigins))
+	for _, origin := range cfg.CORSAllowedOrigins {
+		origins = append(origins, strings.TrimRight(origin, "/"))
+	}
+	return origins
+}
+
+// corsMiddleware allows credentials for the listed origins only; other origins can still call the API
+// with an Authorization header, but browsers never send them the cookies
+func corsMiddleware(h http.Handler, origins []string) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		fmt.Printf("Gateway request: %s %s\n", r.Method, r.URL.Path)
+		slog.DebugContext(r.Context(), "gateway request", "method", r.Method, "path", r.URL.Path)
 
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.Header().Add("Vary", "Origin")
+		if origin := r.Header.Get("Origin"); origin != "" && slices.Contains(origins, origin) {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+			w.Header().Set("Access-Control-Allow-Credentials", "true")
+		} else {
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+		}
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Device-Id, X-Tenant-Id, Idempotency-Key, X-Request-Id, Traceparent, Tracestate")
+		// Let scripts read the request ID, to quote when reporting a failure
+		w.Header().Set("Access-Control-Expose-Headers", "X-Request-Id")
 
 		if r.Method == "OPTIONS" {
 			w.WriteHeader(http.StatusOK)
@@ -61,3 +270,42 @@ func corsMiddleware(h http.Handler) http.Handler {
 		h.ServeHTTP(w, r)
 	})
 }
+
+// coverHandler streams a book cover from DownloadCover with its stored Content-Type
+func coverHandler(client pb.LibraryServiceClient) runtime.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request, pathParams map[string]string) {
+		ctx := metadata.NewOutgoingContext(r.Context(), gatewayCallMetadata(r))
+
+		stream, err := client.DownloadCover(ctx, &pb.BookRequest{Id: pathParams["id"]})
+		if err != nil {
+			writeGatewayError(w, r, err, nil)
+			return
+		}
+
+		// Headers can only be written once the first chunk (and any error) is known
+		chunk, err := stream.Recv()
+		if err != nil {
+			header, _ := stream.Header()
+			writeGatewayError(w, r, err, header)
+			return
+		}
+		header, _ := stream.Header()
+		if ids := header.Get(requestIDHeader); len(ids) > 0 {
+			w.Header().Set(requestIDHeader, ids[0])
+		}
+		w.Header().Set("Content-Type", chunk.GetContentType())
+		for {
+			if _, err := w.Write(chunk.GetData()); err != nil {
+				return
+			}
+			chunk, err = stream.Recv()
+			if err == io.EOF {
+				return
+			}
+			if err != nil {
+				slog.ErrorContext(ctx, "cover stream failed", "book_id", pathParams["id"], "error", err)
+				return
+			}
+		}
+	}
+}
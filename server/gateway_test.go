@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+func TestGatewayIncomingMetadata(t *testing.T) {
+	mux := newGatewayMux(&authCookies{})
+	r := httptest.NewRequest(http.MethodGet, "/api/v1/books/b1", nil)
+	r.Header.Set("Authorization", "Bearer header-token")
+	r.Header.Set("X-Request-Id", "req-1")
+	r.Header.Set("Accept-Language", "pt-BR")
+	r.Header.Set("X-Tenant-Id", "riverside")
+	r.AddCookie(&http.Cookie{Name: accessTokenCookie, Value: "cookie-token"})
+
+	ctx, err := runtime.AnnotateContext(context.Background(), mux, r, "/library.LibraryService/GetBook")
+	if err != nil {
+		t.Fatal(err)
+	}
+	md, _ := metadata.FromOutgoingContext(ctx)
+	// The header wins over the cookie, and the interceptor gets exactly one token
+	if got := md.Get("authorization"); !slices.Equal(got, []string{"Bearer header-token"}) {
+		t.Errorf("authorization = %q, want the header's token once", got)
+	}
+	if got := md.Get("grpcgateway-authorization"); len(got) > 0 {
+		t.Errorf("grpcgateway-authorization = %q, want none", got)
+	}
+	for key, want := range map[string]string{requestIDHeader: "req-1", acceptLanguageHeader: "pt-BR", tenantHeader: "riverside"} {
+		if got := md.Get(key); !slices.Equal(got, []string{want}) {
+			t.Errorf("%s = %q, want %q", key, got, want)
+		}
+	}
+}
+
+func TestGatewayOutgoingHeaderMatcher(t *testing.T) {
+	if key, ok := gatewayOutgoingHeaderMatcher(requestIDHeader); !ok || key != "X-Request-Id" {
+		t.Errorf("gatewayOutgoingHeaderMatcher(%s) = %q, %v, want X-Request-Id", requestIDHeader, key, ok)
+	}
+	if key, ok := gatewayOutgoingHeaderMatcher("x-ratelimit-remaining"); !ok || key != "Grpc-Metadata-x-ratelimit-remaining" {
+		t.Errorf("gatewayOutgoingHeaderMatcher(x-ratelimit-remaining) = %q, %v, want the Grpc-Metadata- prefix", key, ok)
+	}
+}
+
+func TestGatewayCallMetadata(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/api/v1/books/b1/cover", nil)
+	r.Header.Set("X-Device-Id", "phone")
+	r.Header.Set("X-Request-Id", "req-2")
+	r.Header.Set("X-Random", "dropped")
+	r.AddCookie(&http.Cookie{Name: accessTokenCookie, Value: "cookie-token"})
+
+	md := gatewayCallMetadata(r)
+	want := map[string]string{"authorization": "Bearer cookie-token", deviceIDHeader: "phone", requestIDHeader: "req-2"}
+	for key, value := range want {
+		if got := md.Get(key); !slices.Equal(got, []string{value}) {
+			t.Errorf("%s = %q, want %q", key, got, value)
+		}
+	}
+	if got := md.Get("x-random"); len(got) > 0 {
+		t.Errorf("x-random = %q, want it left out", got)
+	}
+}
+
+// TestGatewayCallMetadataForwardedFor checks a client can't pick the address sessions and login rate
+// limits see for the gateway's own calls, such as those of GraphQL logins
+func TestGatewayCallMetadataForwardedFor(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/graphql", nil)
+	r.RemoteAddr = "198.51.100.7:52110"
+	r.Header.Set("X-Forwarded-For", "10.0.0.1")
+	r.Header.Set("Grpc-Metadata-X-Forwarded-For", "203.0.113.9")
+
+	md := gatewayCallMetadata(r)
+	if got := md.Get(forwardedForHeader); !slices.Equal(got, []string{"198.51.100.7"}) {
+		t.Errorf("x-forwarded-for = %q, want the caller's address only", got)
+	}
+	ctx := peer.NewContext(metadata.NewIncomingContext(context.Background(), md),
+		&peer.Peer{Addr: &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 9090}})
+	if _, ip := sessionDevice(ctx); ip != "198.51.100.7" {
+		t.Errorf("sessionDevice IP = %q, want the caller's", ip)
+	}
+
+	// The generated handlers append the caller's address to X-Forwarded-For themselves
+	if key, ok := gatewayHeaderMatcher("Grpc-Metadata-X-Forwarded-For"); ok {
+		t.Errorf("gatewayHeaderMatcher(Grpc-Metadata-X-Forwarded-For) = %q, want it dropped", key)
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/genproto/googleapis/rpc/code"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// gatewayError is the JSON body of the gateway's error responses, {"error": {...}}, whatever the call or
+// whether it reached the gRPC server
+type gatewayError struct {
+	// Code is the HTTP status and Status the name of the gRPC code, e.g. NOT_FOUND
+	Code    int    `json:"code"`
+	Status  string `json:"status"`
+	Message string `json:"message"`
+	// RequestID is the ID the call was logged with, for reporting a failure
+	RequestID string `json:"requestId,omitempty"`
+	// FieldViolations gathers the invalid fields of the BadRequest details
+	FieldViolations []gatewayFieldViolation `json:"fieldViolations,omitempty"`
+	// Details are the status details, such as ResourceInfo, RetryInfo and LocalizedMessage, with their @type
+	Details []json.RawMessage `json:"details,omitempty"`
+}
+
+type gatewayFieldViolation struct {
+	Field       string `json:"field"`
+	Description string `json:"description"`
+}
+
+// gatewayHTTPStatus is the HTTP status of a gRPC status: that of runtime.HTTPStatusFromCode, e.g. 404 for
+// NotFound, 409 for AlreadyExists and 401 for Unauthenticated, except for requests naming invalid fields,
+// which are well-formed but can't be processed (422) rather than malformed (400)
+func gatewayHTTPStatus(st *status.Status) int {
+	if st.Code() == codes.InvalidArgument {
+		for _, d := range st.Details() {
+			if br, ok := d.(*errdetails.BadRequest); ok && len(br.GetFieldViolations()) > 0 {
+				return http.StatusUnprocessableEntity
+			}
+		}
+	}
+	return runtime.HTTPStatusFromCode(st.Code())
+}
+
+// gatewayErrorHandler writes the errors of calls proxied by the gateway, and of requests it turns away
+// itself, as a gatewayError. It replaces runtime.DefaultHTTPErrorHandler, whose body is the bare status.
+func gatewayErrorHandler(ctx context.Context, mux *runtime.ServeMux, marshaler runtime.Marshaler, w http.ResponseWriter, r *http.Request, err error) {
+	md, _ := runtime.ServerMetadataFromContext(ctx)
+	writeGatewayError(w, r, err, md.HeaderMD)
+}
+
+// writeGatewayError writes err as a gatewayError, with the request ID of the call's header metadata, or
+// else of the request
+func writeGatewayError(w http.ResponseWriter, r *http.Request, err error, header metadata.MD) {
+	st := status.Convert(err)
+	httpStatus := gatewayHTTPStatus(st)
+	// The routing errors of the gateway, such as 405 Method Not Allowed, keep their own status
+	var httpErr *runtime.HTTPStatusError
+	if errors.As(err, &httpErr) {
+		st, httpStatus = status.Convert(httpErr.Err), httpErr.HTTPStatus
+	}
+
+	body := gatewayError{Code: httpStatus, Status: code.Code(st.Code()).String(), Message: st.Message(), RequestID: r.Header.Get(requestIDHeader)}
+	if ids := header.Get(requestIDHeader); len(ids) > 0 {
+		body.RequestID = ids[0]
+	}
+	for _, d := range st.Proto().GetDetails() {
+		detail, err := protojson.Marshal(d)
+		if err != nil {
+			// A detail of a type this binary doesn't know is left out
+			continue
+		}
+		body.Details = append(body.Details, detail)
+	}
+	for _, d := range st.Details() {
+		if br, ok := d.(*errdetails.BadRequest); ok {
+			for _, v := range br.GetFieldViolations() {
+				body.FieldViolations = append(body.FieldViolations, gatewayFieldViolation{Field: v.GetField(), Description: v.GetDescription()})
+			}
+		}
+	}
+
+	if body.RequestID != "" {
+		w.Header().Set(requestIDHeader, body.RequestID)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(httpStatus)
+	if err := json.NewEncoder(w).Encode(map[string]gatewayError{"error": body}); err != nil {
+		slog.WarnContext(r.Context(), "failed to write gateway error", "error", err)
+	}
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestGatewayHTTPStatus(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{notFound(resourceBook, "b1", "Book not found"), http.StatusNotFound},
+		{alreadyExists(resourceBook, "b1", "Book already exists"), http.StatusConflict},
+		{status.Error(codes.Unauthenticated, "missing token"), http.StatusUnauthorized},
+		{status.Error(codes.PermissionDenied, "Book was added by another user"), http.StatusForbidden},
+		{badRequest("isbn", "Invalid ISBN"), http.StatusUnprocessableEntity},
+		{status.Error(codes.InvalidArgument, "at least one book is required"), http.StatusBadRequest},
+		{status.Error(codes.ResourceExhausted, "quota exceeded"), http.StatusTooManyRequests},
+	}
+	for _, tt := range tests {
+		if got := gatewayHTTPStatus(status.Convert(tt.err)); got != tt.want {
+			t.Errorf("gatewayHTTPStatus(%v) = %d, want %d", tt.err, got, tt.want)
+		}
+	}
+}
+
+// decodeGatewayError returns the error of a response written by writeGatewayError
+func decodeGatewayError(t *testing.T, rec *httptest.ResponseRecorder) gatewayError {
+	t.Helper()
+	var body struct {
+		Error gatewayError `json:"error"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("error body %q: %v", rec.Body, err)
+	}
+	return body.Error
+}
+
+func TestGatewayErrorHandler(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/api/v1/books", nil)
+	r.Header.Set(requestIDHeader, "from-the-client")
+	ctx := runtime.NewServerMetadataContext(context.Background(), runtime.ServerMetadata{
+		HeaderMD: metadata.Pairs(requestIDHeader, "from-the-server"),
+	})
+	rec := httptest.NewRecorder()
+	gatewayErrorHandler(ctx, nil, nil, rec, r, badRequest("series.volume", "Volume must be positive"))
+
+	if rec.Code != http.StatusUnprocessableEntity || rec.Header().Get("Content-Type") != "application/json" {
+		t.Errorf("response status %d, content type %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	got := decodeGatewayError(t, rec)
+	if got.Code != http.StatusUnprocessableEntity || got.Status != "INVALID_ARGUMENT" || got.Message != "Volume must be positive" {
+		t.Errorf("error = %+v", got)
+	}
+	if got.RequestID != "from-the-server" || rec.Header().Get(requestIDHeader) != "from-the-server" {
+		t.Errorf("request ID = %q, header %q, want the server's", got.RequestID, rec.Header().Get(requestIDHeader))
+	}
+	if len(got.FieldViolations) != 1 || got.FieldViolations[0].Field != "series.volume" {
+		t.Errorf("field violations = %+v", got.FieldViolations)
+	}
+	if len(got.Details) != 1 {
+		t.Errorf("details = %s, want the BadRequest", got.Details)
+	}
+
+	// Requests the gateway turns away itself keep its status, and the caller's request ID
+	rec = httptest.NewRecorder()
+	routing := &runtime.HTTPStatusError{HTTPStatus: http.StatusMethodNotAllowed, Err: status.Error(codes.Unimplemented, "Method Not Allowed")}
+	gatewayErrorHandler(context.Background(), nil, nil, rec, r, routing)
+	if got := decodeGatewayError(t, rec); rec.Code != http.StatusMethodNotAllowed || got.Status != "UNIMPLEMENTED" || got.RequestID != "from-the-client" {
+		t.Errorf("routing error: status %d, body %+v", rec.Code, got)
+	}
+}
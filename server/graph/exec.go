@@ -0,0 +1,729 @@
+package graph
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"slices"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// resolveFunc returns the value of a field of src, the value of the parent field, given its coerced arguments
+type resolveFunc func(ctx context.Context, src any, args map[string]any) (any, error)
+
+// fieldDef is a field of an object type of the schema
+type fieldDef struct {
+	typ typeRef
+	// args are the types of the field's arguments, by name
+	args    map[string]typeRef
+	resolve resolveFunc
+}
+
+// objectDef is an object type of the schema
+type objectDef struct {
+	name   string
+	fields map[string]*fieldDef
+}
+
+// inputDef is an input object type of the schema
+type inputDef struct {
+	name   string
+	fields map[string]typeRef
+}
+
+// schema is the types of schema.graphqls, with the resolvers of their fields
+type schema struct {
+	query    *objectDef
+	mutation *objectDef
+	objects  map[string]*objectDef
+	inputs   map[string]*inputDef
+}
+
+// scalars are the scalar types of the schema: the built-in ones and Time, an RFC 3339 string
+var scalars = []string{"ID", "String", "Int", "Float", "Boolean", "Time"}
+
+// maxRootFields bounds the fields of an operation's selection, each of which is a call to the server
+const maxRootFields = 20
+
+// maxSelections bounds the selections validated in an operation, counting those of a fragment at each of
+// its spreads, so that fragments spreading each other many times can't make validation run for long
+const maxSelections = 2000
+
+// gqlError is an error of the GraphQL response
+type gqlError struct {
+	Message    string         `json:"message"`
+	Locations  []location     `json:"locations,omitempty"`
+	Path       []any          `json:"path,omitempty"`
+	Extensions map[string]any `json:"extensions,omitempty"`
+}
+
+func (e *gqlError) Error() string {
+	return e.Message
+}
+
+func newError(loc location, format string, args ...any) *gqlError {
+	return &gqlError{Message: fmt.Sprintf(format, args...), Locations: []location{loc}}
+}
+
+// response is the body of a GraphQL response. Data is left out when the request failed before execution,
+// and null when an error nulled the whole result.
+type response struct {
+	Data   any         `json:"data,omitempty"`
+	Errors []*gqlError `json:"errors,omitempty"`
+}
+
+// resultMap is the result of a selection set, keeping the order of its fields as the response must
+type resultMap struct {
+	keys   []string
+	values []any
+}
+
+func (m *resultMap) MarshalJSON() ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteByte('{')
+	for i, key := range m.keys {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		k, _ := json.Marshal(key)
+		b.Write(k)
+		b.WriteByte(':')
+		v, err := json.Marshal(m.values[i])
+		if err != nil {
+			return nil, err
+		}
+		b.Write(v)
+	}
+	b.WriteByte('}')
+	return b.Bytes(), nil
+}
+
+// nullData is the data of a response whose whole result is null, which unlike a nil Data is written
+type nullData struct{}
+
+func (nullData) MarshalJSON() ([]byte, error) {
+	return []byte("null"), nil
+}
+
+// request is an operation of a document ready to execute: validated, with its variables coerced
+type request struct {
+	schema    *schema
+	doc       *document
+	op        *operation
+	root      *objectDef
+	variables map[string]any
+}
+
+// prepare picks the operation of doc to run, validates it against s and coerces its variables
+func (s *schema) prepare(doc *document, operationName string, variables map[string]any) (*request, error) {
+	var op *operation
+	switch {
+	case operationName != "":
+		i := slices.IndexFunc(doc.operations, func(op *operation) bool { return op.name == operationName })
+		if i >= 0 {
+			op = doc.operations[i]
+		}
+	case len(doc.operations) > 1:
+		return nil, &gqlError{Message: "operationName is required when the document has several operations"}
+	default:
+		op = doc.operations[0]
+	}
+	if op == nil {
+		return nil, &gqlError{Message: fmt.Sprintf("Unknown operation %q", operationName)}
+	}
+	req := &request{schema: s, doc: doc, op: op}
+	switch op.kind {
+	case "query":
+		req.root = s.query
+	case "mutation":
+		req.root = s.mutation
+	default:
+		return nil, newError(op.loc, "Subscriptions are not supported")
+	}
+
+	v := &validator{schema: s, doc: doc, vars: make(map[string]*varDef)}
+	for _, def := range op.vars {
+		if _, ok := v.vars[def.name]; ok {
+			return nil, newError(def.loc, "There can be only one variable named \"$%s\"", def.name)
+		}
+		if !s.isInputType(def.typ) {
+			return nil, newError(def.loc, "Variable \"$%s\" cannot be of non-input type \"%s\"", def.name, def.typ)
+		}
+		v.vars[def.name] = def
+	}
+	if err := v.selections(req.root, op.selections, nil); err != nil {
+		return nil, err
+	}
+	if n := len(v.collectRoot(op.selections)); n > maxRootFields {
+		return nil, newError(op.loc, "An operation selects at most %d fields, got %d", maxRootFields, n)
+	}
+
+	req.variables = make(map[string]any, len(op.vars))
+	for _, def := range op.vars {
+		raw, present := variables[def.name]
+		if !present && def.def != nil {
+			raw, present = def.def.resolve(nil)
+		}
+		if !present {
+			if def.typ.nonNull {
+				return nil, newError(def.loc, "Variable \"$%s\" of required type \"%s\" was not provided", def.name, def.typ)
+			}
+			continue
+		}
+		coerced, err := s.coerce(def.typ, raw)
+		if err != nil {
+			return nil, newError(def.loc, "Variable \"$%s\" got invalid value: %v", def.name, err)
+		}
+		req.variables[def.name] = coerced
+	}
+	return req, nil
+}
+
+// validator checks the selections of an operation against the schema, before anything runs
+type validator struct {
+	schema *schema
+	doc    *document
+	vars   map[string]*varDef
+	// visited counts the selections validated, up to maxSelections
+	visited int
+}
+
+// selections validates the selections of a value of obj; spreading lists the fragments being validated,
+// to refuse fragments that spread themselves
+func (v *validator) selections(obj *objectDef, selections []selection, spreading []string) error {
+	for _, sel := range selections {
+		if v.visited++; v.visited > maxSelections {
+			return &gqlError{Message: fmt.Sprintf("An operation has at most %d selections, fragments included", maxSelections)}
+		}
+		switch sel := sel.(type) {
+		case *field:
+			if err := v.directives(sel.directives); err != nil {
+				return err
+			}
+			if sel.name == "__typename" {
+				if len(sel.args) > 0 || sel.selections != nil {
+					return newError(sel.loc, "Field \"__typename\" takes no arguments or selections")
+				}
+				continue
+			}
+			def, ok := obj.fields[sel.name]
+			if !ok {
+				return newError(sel.loc, "Cannot query field \"%s\" on type \"%s\"", sel.name, obj.name)
+			}
+			if err := v.arguments(sel, def); err != nil {
+				return err
+			}
+			named := def.typ
+			for named.elem != nil {
+				named = *named.elem
+			}
+			child, isObject := v.schema.objects[named.name]
+			switch {
+			case isObject && sel.selections == nil:
+				return newError(sel.loc, "Field \"%s\" of type \"%s\" must have a selection of subfields", sel.name, def.typ)
+			case !isObject && sel.selections != nil:
+				return newError(sel.loc, "Field \"%s\" must not have a selection since type \"%s\" has no subfields", sel.name, def.typ)
+			case isObject:
+				if err := v.selections(child, sel.selections, spreading); err != nil {
+					return err
+				}
+			}
+		case *fragmentSpread:
+			if err := v.directives(sel.directives); err != nil {
+				return err
+			}
+			frag, ok := v.doc.fragments[sel.name]
+			if !ok {
+				return newError(sel.loc, "Unknown fragment \"%s\"", sel.name)
+			}
+			if slices.Contains(spreading, sel.name) {
+				return newError(sel.loc, "Cannot spread fragment \"%s\" within itself", sel.name)
+			}
+			if err := v.typeCondition(obj, frag.typeCond, frag.loc); err != nil {
+				return err
+			}
+			if err := v.selections(obj, frag.selections, append(spreading, sel.name)); err != nil {
+				return err
+			}
+		case *inlineFragment:
+			if err := v.directives(sel.directives); err != nil {
+				return err
+			}
+			if sel.typeCond != "" {
+				if err := v.typeCondition(obj, sel.typeCond, sel.loc); err != nil {
+					return err
+				}
+			}
+			if err := v.selections(obj, sel.selections, spreading); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// typeCondition checks a fragment on typeCond can be spread in a value of obj. The schema has no interfaces
+// or unions, so the condition must be obj itself.
+func (v *validator) typeCondition(obj *objectDef, typeCond string, loc location) error {
+	if _, ok := v.schema.objects[typeCond]; !ok {
+		return newError(loc, "Unknown type \"%s\"", typeCond)
+	}
+	if typeCond != obj.name {
+		return newError(loc, "Fragment on \"%s\" cannot be spread here as objects of type \"%s\" can never be of type \"%s\"", typeCond, obj.name, typeCond)
+	}
+	return nil
+}
+
+// directives validates @skip and @include, the only directives of the schema
+func (v *validator) directives(directives []*directive) error {
+	for _, d := range directives {
+		if d.name != "skip" && d.name != "include" {
+			return newError(d.loc, "Unknown directive \"@%s\"", d.name)
+		}
+		if len(d.args) != 1 || d.args[0].name != "if" {
+			return newError(d.loc, "Directive \"@%s\" takes one argument, \"if\"", d.name)
+		}
+		if err := v.value(d.args[0].val, typeRef{name: "Boolean", nonNull: true}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (v *validator) arguments(f *field, def *fieldDef) error {
+	seen := make(map[string]bool)
+	for _, arg := range f.args {
+		typ, ok := def.args[arg.name]
+		if !ok {
+			return newError(arg.loc, "Unknown argument \"%s\" on field \"%s\"", arg.name, f.name)
+		}
+		if seen[arg.name] {
+			return newError(arg.loc, "There can be only one argument named \"%s\"", arg.name)
+		}
+		seen[arg.name] = true
+		if err := v.value(arg.val, typ); err != nil {
+			return err
+		}
+	}
+	for name, typ := range def.args {
+		if typ.nonNull && !seen[name] {
+			return newError(f.loc, "Field \"%s\" argument \"%s\" of type \"%s\" is required, but it was not provided", f.name, name, typ)
+		}
+	}
+	return nil
+}
+
+// value checks that val may be given where typ is expected. Literals are coerced, and variables must be
+// declared, of a type that fits.
+func (v *validator) value(val value, typ typeRef) error {
+	switch val.kind {
+	case variableValue:
+		def, ok := v.vars[val.raw]
+		if !ok {
+			return newError(val.loc, "Variable \"$%s\" is not defined", val.raw)
+		}
+		if !fits(def.typ, typ, def.def != nil) {
+			return newError(val.loc, "Variable \"$%s\" of type \"%s\" used in position expecting type \"%s\"", val.raw, def.typ, typ)
+		}
+		return nil
+	case listValue:
+		if typ.elem != nil {
+			for _, item := range val.list {
+				if err := v.value(item, *typ.elem); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	case objectValue:
+		if input, ok := v.schema.inputs[typ.name]; ok && typ.elem == nil {
+			for _, f := range val.fields {
+				fieldType, ok := input.fields[f.name]
+				if !ok {
+					return newError(f.loc, "Field \"%s\" is not defined by type \"%s\"", f.name, input.name)
+				}
+				if err := v.value(f.val, fieldType); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	if !containsVariable(val) {
+		resolved, _ := val.resolve(nil)
+		if _, err := v.schema.coerce(typ, resolved); err != nil {
+			return newError(val.loc, "Expected value of type \"%s\": %v", typ, err)
+		}
+	}
+	return nil
+}
+
+func containsVariable(val value) bool {
+	switch val.kind {
+	case variableValue:
+		return true
+	case listValue:
+		return slices.ContainsFunc(val.list, containsVariable)
+	case objectValue:
+		return slices.ContainsFunc(val.fields, func(f *argument) bool { return containsVariable(f.val) })
+	}
+	return false
+}
+
+// fits reports whether a variable of type varType may be used where typ is expected: a nullable variable
+// fits a non-null position only when it has a default
+func fits(varType, typ typeRef, hasDefault bool) bool {
+	if typ.nonNull && !varType.nonNull && !hasDefault {
+		return false
+	}
+	if (varType.elem == nil) != (typ.elem == nil) {
+		return false
+	}
+	if varType.elem != nil {
+		return fits(*varType.elem, *typ.elem, false)
+	}
+	return varType.name == typ.name || varType.name == "Int" && typ.name == "Float"
+}
+
+// collectRoot lists the fields an operation selects at its root, ignoring directives, for maxRootFields
+func (v *validator) collectRoot(selections []selection) []*field {
+	var fields []*field
+	for _, sel := range selections {
+		switch sel := sel.(type) {
+		case *field:
+			fields = append(fields, sel)
+		case *fragmentSpread:
+			fields = append(fields, v.collectRoot(v.doc.fragments[sel.name].selections)...)
+		case *inlineFragment:
+			fields = append(fields, v.collectRoot(sel.selections)...)
+		}
+	}
+	return fields
+}
+
+func (s *schema) isInputType(t typeRef) bool {
+	for t.elem != nil {
+		t = *t.elem
+	}
+	_, isInput := s.inputs[t.name]
+	return isInput || slices.Contains(scalars, t.name)
+}
+
+// coerce converts v, a JSON-like value of a variable or literal, to typ: Int to int32, Float to float64,
+// ID and String to string, lists to []any and input objects to map[string]any. Coerced values coerce to
+// themselves, as variables are coerced once declared and again where they are used.
+func (s *schema) coerce(typ typeRef, v any) (any, error) {
+	if v == nil {
+		if typ.nonNull {
+			return nil, fmt.Errorf("expected non-nullable type \"%s\" not to be null", typ)
+		}
+		return nil, nil
+	}
+	if typ.elem != nil {
+		items, ok := v.([]any)
+		if !ok {
+			// A single value is a list of one
+			items = []any{v}
+		}
+		list := make([]any, 0, len(items))
+		for _, item := range items {
+			coerced, err := s.coerce(*typ.elem, item)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, coerced)
+		}
+		return list, nil
+	}
+	if input, ok := s.inputs[typ.name]; ok {
+		object, ok := v.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("expected type \"%s\" to be an object", input.name)
+		}
+		coerced := make(map[string]any, len(object))
+		for name, value := range object {
+			fieldType, ok := input.fields[name]
+			if !ok {
+				return nil, fmt.Errorf("field \"%s\" is not defined by type \"%s\"", name, input.name)
+			}
+			c, err := s.coerce(fieldType, value)
+			if err != nil {
+				return nil, fmt.Errorf("in field \"%s\": %w", name, err)
+			}
+			coerced[name] = c
+		}
+		for name, fieldType := range input.fields {
+			if _, ok := object[name]; !ok && fieldType.nonNull {
+				return nil, fmt.Errorf("field \"%s\" of required type \"%s\" was not provided", name, fieldType)
+			}
+		}
+		return coerced, nil
+	}
+	switch typ.name {
+	case "Int":
+		if i, ok := v.(int32); ok {
+			return i, nil
+		}
+		if n, ok := v.(json.Number); ok {
+			if i, err := n.Int64(); err == nil && i >= math.MinInt32 && i <= math.MaxInt32 {
+				return int32(i), nil
+			}
+			if f, err := n.Float64(); err == nil && f == math.Trunc(f) && f >= math.MinInt32 && f <= math.MaxInt32 {
+				return int32(f), nil
+			}
+		}
+		return nil, fmt.Errorf("Int cannot represent %v", v)
+	case "Float":
+		switch f := v.(type) {
+		case float64:
+			return f, nil
+		case int32:
+			return float64(f), nil
+		}
+		if n, ok := v.(json.Number); ok {
+			if f, err := n.Float64(); err == nil {
+				return f, nil
+			}
+		}
+		return nil, fmt.Errorf("Float cannot represent %v", v)
+	case "String", "Time":
+		if str, ok := v.(string); ok {
+			return str, nil
+		}
+		return nil, fmt.Errorf("%s cannot represent a non-string value: %v", typ.name, v)
+	case "ID":
+		switch id := v.(type) {
+		case string:
+			return id, nil
+		case json.Number:
+			if _, err := id.Int64(); err == nil {
+				return id.String(), nil
+			}
+		}
+		return nil, fmt.Errorf("ID cannot represent %v", v)
+	case "Boolean":
+		if b, ok := v.(bool); ok {
+			return b, nil
+		}
+		return nil, fmt.Errorf("Boolean cannot represent a non-boolean value: %v", v)
+	}
+	return nil, fmt.Errorf("unknown type \"%s\"", typ.name)
+}
+
+// executor runs a request, collecting the errors of its fields
+type executor struct {
+	*request
+	errors []*gqlError
+}
+
+// execute runs req: the fields of a mutation one after the other, as each may depend on the previous
+// one's changes, and those of a query in order too, as they are few
+func (req *request) execute(ctx context.Context) *response {
+	e := &executor{request: req}
+	data, ok := e.selectionSet(ctx, req.root, nil, req.op.selections, nil)
+	resp := &response{Errors: e.errors}
+	if !ok {
+		resp.Data = nullData{}
+	} else {
+		resp.Data = data
+	}
+	return resp
+}
+
+// collectedField is the fields of a selection set sharing a response key, whose selections are merged
+type collectedField struct {
+	key    string
+	fields []*field
+}
+
+// collect lists the fields of selections to execute by response key, in order, expanding fragments and
+// applying @skip and @include
+func (e *executor) collect(selections []selection, into []*collectedField) []*collectedField {
+	for _, sel := range selections {
+		switch sel := sel.(type) {
+		case *field:
+			if !e.included(sel.directives) {
+				continue
+			}
+			key := sel.responseKey()
+			i := slices.IndexFunc(into, func(c *collectedField) bool { return c.key == key })
+			if i < 0 {
+				into = append(into, &collectedField{key: key})
+				i = len(into) - 1
+			}
+			into[i].fields = append(into[i].fields, sel)
+		case *fragmentSpread:
+			if e.included(sel.directives) {
+				into = e.collect(e.doc.fragments[sel.name].selections, into)
+			}
+		case *inlineFragment:
+			if e.included(sel.directives) {
+				into = e.collect(sel.selections, into)
+			}
+		}
+	}
+	return into
+}
+
+func (e *executor) included(directives []*directive) bool {
+	for _, d := range directives {
+		cond, _ := d.args[0].val.resolve(e.variables)
+		if (d.name == "skip") == (cond == true) {
+			return false
+		}
+	}
+	return true
+}
+
+// selectionSet executes selections on src, a value of obj. It returns ok false when a non-null field
+// failed, which nulls the value of obj itself.
+func (e *executor) selectionSet(ctx context.Context, obj *objectDef, src any, selections []selection, path []any) (*resultMap, bool) {
+	result := &resultMap{}
+	for _, c := range e.collect(selections, nil) {
+		fieldPath := append(slices.Clip(path), c.key)
+		f := c.fields[0]
+		if f.name == "__typename" {
+			result.keys = append(result.keys, c.key)
+			result.values = append(result.values, obj.name)
+			continue
+		}
+		def := obj.fields[f.name]
+		value, ok := e.field(ctx, def, src, c.fields, fieldPath)
+		if !ok {
+			return nil, false
+		}
+		result.keys = append(result.keys, c.key)
+		result.values = append(result.values, value)
+	}
+	return result, true
+}
+
+func (e *executor) field(ctx context.Context, def *fieldDef, src any, fields []*field, path []any) (any, bool) {
+	f := fields[0]
+	args := make(map[string]any, len(def.args))
+	for _, arg := range f.args {
+		raw, present := arg.val.resolve(e.variables)
+		if !present {
+			continue
+		}
+		coerced, err := e.schema.coerce(def.args[arg.name], raw)
+		if err != nil {
+			e.fail(newError(arg.loc, "Argument \"%s\" got invalid value: %v", arg.name, err), path)
+			return nil, !def.typ.nonNull
+		}
+		args[arg.name] = coerced
+	}
+	for name, typ := range def.args {
+		if _, ok := args[name]; !ok && typ.nonNull {
+			e.fail(newError(f.loc, "Argument \"%s\" of required type \"%s\" was not provided", name, typ), path)
+			return nil, !def.typ.nonNull
+		}
+	}
+
+	value, err := def.resolve(ctx, src, args)
+	if err != nil {
+		e.fail(fieldError(err, f.loc), path)
+		return nil, !def.typ.nonNull
+	}
+	var selections []selection
+	for _, f := range fields {
+		selections = append(selections, f.selections...)
+	}
+	return e.complete(ctx, def.typ, value, f.loc, selections, path)
+}
+
+// complete converts the value a resolver returned to the response value of typ. It returns ok false when
+// the value is null for a non-null type, which nulls the closest nullable value above, and only that one.
+func (e *executor) complete(ctx context.Context, typ typeRef, value any, loc location, selections []selection, path []any) (any, bool) {
+	if isNil(value) {
+		if typ.nonNull {
+			e.fail(newError(loc, "Cannot return null for non-nullable field"), path)
+			return nil, false
+		}
+		return nil, true
+	}
+	completed, ok := e.completeValue(ctx, typ, value, loc, selections, path)
+	if !ok && !typ.nonNull {
+		return nil, true
+	}
+	return completed, ok
+}
+
+// completeValue completes a non-null value: the items of a list, or the selections of an object
+func (e *executor) completeValue(ctx context.Context, typ typeRef, value any, loc location, selections []selection, path []any) (any, bool) {
+	if typ.elem != nil {
+		items := reflect.ValueOf(value)
+		list := make([]any, 0, items.Len())
+		for i := range items.Len() {
+			item, ok := e.complete(ctx, *typ.elem, items.Index(i).Interface(), loc, selections, append(slices.Clip(path), i))
+			if !ok {
+				return nil, false
+			}
+			list = append(list, item)
+		}
+		return list, true
+	}
+	if obj, ok := e.schema.objects[typ.name]; ok {
+		result, ok := e.selectionSet(ctx, obj, value, selections, path)
+		if !ok {
+			return nil, false
+		}
+		return result, true
+	}
+	return value, true
+}
+
+func isNil(v any) bool {
+	if v == nil {
+		return true
+	}
+	switch rv := reflect.ValueOf(v); rv.Kind() {
+	case reflect.Pointer, reflect.Map, reflect.Interface:
+		return rv.IsNil()
+	}
+	return false
+}
+
+func (e *executor) fail(err *gqlError, path []any) {
+	err.Path = path
+	e.errors = append(e.errors, err)
+}
+
+// fieldError is the GraphQL error of a resolver's error. The errors of the server keep their message, and
+// their gRPC code goes in the code extension; others are internal.
+func fieldError(err error, loc location) *gqlError {
+	var gqlErr *gqlError
+	if errors.As(err, &gqlErr) {
+		return gqlErr
+	}
+	st, ok := status.FromError(err)
+	if !ok {
+		st = status.New(codes.Internal, err.Error())
+	}
+	return &gqlError{
+		Message:    st.Message(),
+		Locations:  []location{loc},
+		Extensions: map[string]any{"code": codeName(st.Code())},
+	}
+}
+
+// codeName is the name of code in upper snake case, as in google.rpc.Code: NOT_FOUND, INVALID_ARGUMENT
+func codeName(code codes.Code) string {
+	if code == codes.OK {
+		return "OK"
+	}
+	var b strings.Builder
+	for i, r := range code.String() {
+		if i > 0 && r >= 'A' && r <= 'Z' {
+			b.WriteByte('_')
+		}
+		b.WriteRune(r)
+	}
+	return strings.ToUpper(b.String())
+}
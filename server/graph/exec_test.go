@@ -0,0 +1,205 @@
+package graph
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	pb "example/grpc_demo/library"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestVariables(t *testing.T) {
+	tests := []struct {
+		name      string
+		query     string
+		variables map[string]any
+		want      *pb.ListBookRequest
+	}{
+		{"default", `query($size: Int = 7) { books(pageSize: $size) { totalCount } }`, nil, &pb.ListBookRequest{PageSize: 7}},
+		{"given over the default", `query($size: Int = 7) { books(pageSize: $size) { totalCount } }`, map[string]any{"size": 3}, &pb.ListBookRequest{PageSize: 3}},
+		{"nullable and not given", `query($size: Int, $author: String) { books(pageSize: $size, author: $author) { totalCount } }`, nil, &pb.ListBookRequest{}},
+		{"null", `query($size: Int = 7) { books(pageSize: $size) { totalCount } }`, map[string]any{"size": nil}, &pb.ListBookRequest{}},
+		{"integral float for an Int", `query($page: Int) { books(page: $page) { totalCount } }`, map[string]any{"page": 2.0}, &pb.ListBookRequest{Page: 2}},
+		{"single value for a list", `query($tags: [String!]) { books(tags: $tags) { totalCount } }`, map[string]any{"tags": "sf"}, &pb.ListBookRequest{Tags: []string{"sf"}}},
+		{"in a list literal", `query($tag: String!) { books(tags: ["classic", $tag]) { totalCount } }`, map[string]any{"tag": "sf"}, &pb.ListBookRequest{Tags: []string{"classic", "sf"}}},
+		{"in a directive", `query($all: Boolean!) { books(page: 1) { totalCount books @include(if: $all) { id } } }`, map[string]any{"all": false}, &pb.ListBookRequest{Page: 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, library := newTestHandler()
+			if _, body := post(h, tt.query, tt.variables); body != `{"data":{"books":{"totalCount":2}}}` {
+				t.Errorf("response = %s", body)
+			}
+			if !proto.Equal(library.list, tt.want) {
+				t.Errorf("ListBooks request = %v, want %v", library.list, tt.want)
+			}
+		})
+	}
+
+	// Variables fill the fields of input object literals, and missing nullable ones are left out
+	h, library := newTestHandler()
+	_, body := post(h, `mutation($title: String!, $copies: Int, $isbn: String) { addBook(input: {title: $title, author: "Jane Austen", totalCopies: $copies, isbn: $isbn}) }`,
+		map[string]any{"title": "Emma", "copies": 2})
+	if body != `{"data":{"addBook":"b9"}}` {
+		t.Errorf("addBook = %s", body)
+	}
+	if want := (&pb.Book{Title: "Emma", Author: "Jane Austen", TotalCopies: 2}); !proto.Equal(library.added, want) {
+		t.Errorf("AddBook request = %v, want %v", library.added, want)
+	}
+}
+
+func TestFragments(t *testing.T) {
+	h, _ := newTestHandler()
+	query := `query($brief: Boolean!) {
+		books { ...page }
+		book(id: "b1") { id ... on Book { title } ... @skip(if: $brief) { author } ...copies @include(if: $brief) }
+		again: book(id: "b1") { id }
+		again: book(id: "b1") { title ...copies }
+	}
+	fragment page on BookConnection { totalCount books { ...titled } }
+	fragment titled on Book { title }
+	fragment copies on Book { totalCopies availableCopies }`
+	tests := []struct {
+		brief bool
+		want  string
+	}{
+		{false, `{"data":{"books":{"totalCount":2,"books":[{"title":"Dune"}]},` +
+			`"book":{"id":"b1","title":"Dune","author":"Frank Herbert"},` +
+			`"again":{"id":"b1","title":"Dune","totalCopies":2,"availableCopies":1}}}`},
+		{true, `{"data":{"books":{"totalCount":2,"books":[{"title":"Dune"}]},` +
+			`"book":{"id":"b1","title":"Dune","totalCopies":2,"availableCopies":1},` +
+			`"again":{"id":"b1","title":"Dune","totalCopies":2,"availableCopies":1}}}`},
+	}
+	for _, tt := range tests {
+		if _, body := post(h, query, map[string]any{"brief": tt.brief}); body != tt.want {
+			t.Errorf("response with $brief %v = %s, want %s", tt.brief, body, tt.want)
+		}
+	}
+}
+
+func TestFieldErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		err   error
+		books map[string]*pb.Book
+		want  string
+	}{
+		{
+			name:  "error of a nullable field",
+			query: `{ book(id: "b1") { id } }`,
+			err:   status.Error(codes.PermissionDenied, "Not allowed"),
+			want:  `{"data":{"book":null},"errors":[{"message":"Not allowed","locations":[{"line":1,"column":3}],"path":["book"],"extensions":{"code":"PERMISSION_DENIED"}}]}`,
+		},
+		{
+			name:  "error without a status",
+			query: `{ book(id: "b1") { id } }`,
+			err:   errors.New("connection reset"),
+			want:  `{"data":{"book":null},"errors":[{"message":"connection reset","locations":[{"line":1,"column":3}],"path":["book"],"extensions":{"code":"INTERNAL"}}]}`,
+		},
+		{
+			// A null book nulls the non-null list holding it and then the non-null books, reported once
+			name:  "null item of a non-null list",
+			query: `{ books { books { id } } }`,
+			books: map[string]*pb.Book{},
+			want:  `{"data":null,"errors":[{"message":"Cannot return null for non-nullable field","locations":[{"line":1,"column":11}],"path":["books","books",0]}]}`,
+		},
+		{
+			name:  "failed mutation",
+			query: `mutation { login(username: "ana", password: "wrong") { token } }`,
+			want:  `{"data":null,"errors":[{"message":"Invalid username or password","locations":[{"line":1,"column":12}],"path":["login"],"extensions":{"code":"UNAUTHENTICATED"}}]}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, library := newTestHandler()
+			library.err = tt.err
+			if tt.books != nil {
+				library.books = tt.books
+			}
+			if _, body := post(h, tt.query, nil); body != tt.want {
+				t.Errorf("response = %s, want %s", body, tt.want)
+			}
+		})
+	}
+}
+
+// TestMutationsRunInOrder checks a failed mutation stops the ones after it, which run one after the other
+func TestMutationsRunInOrder(t *testing.T) {
+	h, library := newTestHandler()
+	_, body := post(h, `mutation {
+		first: updateBook(id: "b1", input: {title: "Dune", author: "Frank Herbert"})
+		second: updateBook(id: "b2", input: {title: "Emma", author: "Jane Austen"})
+		deleteBook(id: "b1")
+	}`, nil)
+	want := `{"data":null,"errors":[{"message":"Book not found","locations":[{"line":3,"column":3}],"path":["second"],"extensions":{"code":"NOT_FOUND"}}]}`
+	if body != want {
+		t.Errorf("response = %s, want %s", body, want)
+	}
+	if library.updated.GetId() != "b2" || library.deleted != "" {
+		t.Errorf("last update of %q, delete of %q, want the delete not run after the failed update", library.updated.GetId(), library.deleted)
+	}
+}
+
+func TestCoerce(t *testing.T) {
+	s := newSchema(fakeUsers{}, &fakeLibrary{})
+	tests := []struct {
+		typ     typeRef
+		value   any
+		want    any
+		wantErr string
+	}{
+		{named("Int"), json.Number("42"), int32(42), ""},
+		{named("Int"), json.Number("4e1"), int32(40), ""},
+		{named("Int"), int32(7), int32(7), ""},
+		{named("Int"), json.Number("1.5"), nil, "Int cannot represent 1.5"},
+		{named("Int"), "42", nil, "Int cannot represent 42"},
+		{named("Float"), json.Number("1.5"), 1.5, ""},
+		{named("Float"), int32(2), 2.0, ""},
+		{named("ID"), json.Number("12"), "12", ""},
+		{named("ID"), json.Number("1.5"), nil, "ID cannot represent 1.5"},
+		{named("String"), json.Number("12"), nil, "String cannot represent a non-string value: 12"},
+		{named("Boolean"), "true", nil, "Boolean cannot represent a non-boolean value: true"},
+		{nonNull(named("String")), nil, nil, `expected non-nullable type "String!" not to be null`},
+		{listOf(nonNull(named("String"))), "sf", []any{"sf"}, ""},
+		{listOf(nonNull(named("String"))), []any{"sf", nil}, nil, `expected non-nullable type "String!" not to be null`},
+		{named("BookInput"), map[string]any{"title": "Emma", "author": "Jane Austen", "totalCopies": json.Number("3")},
+			map[string]any{"title": "Emma", "author": "Jane Austen", "totalCopies": int32(3)}, ""},
+		{named("BookInput"), map[string]any{"title": "Emma", "author": "Jane Austen", "pages": json.Number("3")}, nil, `field "pages" is not defined by type "BookInput"`},
+		{named("BookInput"), map[string]any{"title": "Emma", "author": true}, nil, `in field "author": String cannot represent`},
+		{named("BookInput"), "Emma", nil, `expected type "BookInput" to be an object`},
+	}
+	for _, tt := range tests {
+		got, err := s.coerce(tt.typ, tt.value)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("coerce(%s, %#v) error = %v, want %q", tt.typ, tt.value, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("coerce(%s, %#v) = %#v, %v, want %#v", tt.typ, tt.value, got, err, tt.want)
+		}
+	}
+}
+
+func TestCodeName(t *testing.T) {
+	tests := map[codes.Code]string{
+		codes.OK:                "OK",
+		codes.NotFound:          "NOT_FOUND",
+		codes.InvalidArgument:   "INVALID_ARGUMENT",
+		codes.DeadlineExceeded:  "DEADLINE_EXCEEDED",
+		codes.ResourceExhausted: "RESOURCE_EXHAUSTED",
+	}
+	for code, want := range tests {
+		if got := codeName(code); got != want {
+			t.Errorf("codeName(%v) = %q, want %q", code, got, want)
+		}
+	}
+}
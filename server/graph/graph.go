@@ -0,0 +1,342 @@
+// Package graph serves the GraphQL endpoint of the gateway: the queries and mutations of schema.graphqls,
+// resolved by calling the gRPC services as the REST routes do. Requests are parsed, validated and executed
+// here, without introspection, which clients don't need with the schema file at hand.
+//
+// The server isn't generated with gqlgen: each of the seven root fields is one call to a gRPC client, and
+// the objects are the messages the calls return, so generated models and resolvers, and the runtime they
+// need, would wrap the same calls in far more code than the small engine here. TestSchemaMatchesFile keeps
+// the engine's schema and schema.graphqls in step.
+package graph
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	pb "example/grpc_demo/library"
+
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// maxRequestBytes bounds the body of a request, its query and variables
+const maxRequestBytes = 1 << 20
+
+// Handler serves GraphQL requests, POSTed as JSON or sent with GET for queries
+type Handler struct {
+	schema *schema
+	// metadata is the metadata of the calls made for a request: its token, from the Authorization header
+	// or the access cookie, and the headers the gateway forwards
+	metadata func(*http.Request) metadata.MD
+}
+
+// NewHandler returns the handler of the GraphQL endpoint, which calls users and library with the metadata
+// md returns for each request
+func NewHandler(users pb.UserServiceClient, library pb.LibraryServiceClient, md func(*http.Request) metadata.MD) *Handler {
+	return &Handler{schema: newSchema(users, library), metadata: md}
+}
+
+// graphQLRequest is the body of a POST, or the parameters of a GET
+type graphQLRequest struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName"`
+	Variables     map[string]any `json:"variables"`
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var body graphQLRequest
+	switch r.Method {
+	case http.MethodGet:
+		q := r.URL.Query()
+		body.Query, body.OperationName = q.Get("query"), q.Get("operationName")
+		if vars := q.Get("variables"); vars != "" {
+			if err := decodeJSON(strings.NewReader(vars), &body.Variables); err != nil {
+				writeError(w, http.StatusBadRequest, "variables must be a JSON object")
+				return
+			}
+		}
+	case http.MethodPost:
+		if err := decodeJSON(http.MaxBytesReader(w, r.Body, maxRequestBytes), &body); err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				writeError(w, http.StatusRequestEntityTooLarge, "The request is too large")
+				return
+			}
+			writeError(w, http.StatusBadRequest, "The body must be a JSON object with a query")
+			return
+		}
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		writeError(w, http.StatusMethodNotAllowed, "GraphQL requests are POSTed, or sent with GET for queries")
+		return
+	}
+	if strings.TrimSpace(body.Query) == "" {
+		writeError(w, http.StatusBadRequest, "query is required")
+		return
+	}
+
+	doc, err := parse(body.Query)
+	if err != nil {
+		var syntaxErr *syntaxError
+		if !errors.As(err, &syntaxErr) {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		writeResponse(w, http.StatusOK, &response{Errors: []*gqlError{newError(syntaxErr.loc, "Syntax Error: %s", syntaxErr.msg)}})
+		return
+	}
+	req, err := h.schema.prepare(doc, body.OperationName, body.Variables)
+	if err != nil {
+		var gqlErr *gqlError
+		if !errors.As(err, &gqlErr) {
+			gqlErr = &gqlError{Message: err.Error()}
+		}
+		writeResponse(w, http.StatusOK, &response{Errors: []*gqlError{gqlErr}})
+		return
+	}
+	// GET is safe, so it can't change anything
+	if r.Method == http.MethodGet && req.op.kind == "mutation" {
+		w.Header().Set("Allow", "POST")
+		writeError(w, http.StatusMethodNotAllowed, "Mutations must be POSTed")
+		return
+	}
+
+	ctx := metadata.NewOutgoingContext(r.Context(), h.metadata(r))
+	writeResponse(w, http.StatusOK, req.execute(ctx))
+}
+
+// decodeJSON decodes a JSON object, keeping numbers as json.Number for coercion to Int or Float
+func decodeJSON(r io.Reader, v any) error {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	return dec.Decode(v)
+}
+
+func writeResponse(w http.ResponseWriter, code int, resp *response) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(resp)
+}
+
+// writeError answers a request that isn't a GraphQL request at all
+func writeError(w http.ResponseWriter, code int, msg string) {
+	writeResponse(w, code, &response{Errors: []*gqlError{{Message: msg}}})
+}
+
+// named, nonNull and listOf build the types of the schema's fields
+func named(name string) typeRef { return typeRef{name: name} }
+
+func nonNull(t typeRef) typeRef {
+	t.nonNull = true
+	return t
+}
+
+func listOf(t typeRef) typeRef { return typeRef{elem: &t} }
+
+// newSchema builds the types of schema.graphqls, their resolvers calling users and library
+func newSchema(users pb.UserServiceClient, library pb.LibraryServiceClient) *schema {
+	id, str, integer, boolean := named("ID"), named("String"), named("Int"), named("Boolean")
+	tags := listOf(nonNull(str))
+
+	bookField := func(typ typeRef, get func(*pb.Book) any) *fieldDef {
+		return &fieldDef{typ: typ, resolve: func(ctx context.Context, src any, args map[string]any) (any, error) {
+			return get(src.(*pb.Book)), nil
+		}}
+	}
+	book := &objectDef{name: "Book", fields: map[string]*fieldDef{
+		"id":              bookField(nonNull(id), func(b *pb.Book) any { return b.GetId() }),
+		"title":           bookField(nonNull(str), func(b *pb.Book) any { return b.GetTitle() }),
+		"author":          bookField(nonNull(str), func(b *pb.Book) any { return b.GetAuthor() }),
+		"isbn":            bookField(nonNull(str), func(b *pb.Book) any { return b.GetIsbn() }),
+		"coverUrl":        bookField(nonNull(str), func(b *pb.Book) any { return b.GetCoverUrl() }),
+		"publisher":       bookField(nonNull(str), func(b *pb.Book) any { return b.GetPublisher() }),
+		"publisherId":     bookField(nonNull(integer), func(b *pb.Book) any { return b.GetPublisherId() }),
+		"tags":            bookField(nonNull(tags), func(b *pb.Book) any { return append([]string{}, b.GetTags()...) }),
+		"description":     bookField(nonNull(str), func(b *pb.Book) any { return b.GetDescription() }),
+		"language":        bookField(nonNull(str), func(b *pb.Book) any { return b.GetLanguage() }),
+		"averageRating":   bookField(nonNull(named("Float")), func(b *pb.Book) any { return b.GetAverageRating() }),
+		"reviewCount":     bookField(nonNull(integer), func(b *pb.Book) any { return b.GetReviewCount() }),
+		"totalCopies":     bookField(nonNull(integer), func(b *pb.Book) any { return b.GetTotalCopies() }),
+		"availableCopies": bookField(nonNull(integer), func(b *pb.Book) any { return b.GetAvailableCopies() }),
+		"ownerId":         bookField(nonNull(integer), func(b *pb.Book) any { return b.GetOwnerId() }),
+		"createdAt":       bookField(named("Time"), func(b *pb.Book) any { return formatTime(b.GetCreatedAt()) }),
+		"updatedAt":       bookField(named("Time"), func(b *pb.Book) any { return formatTime(b.GetUpdatedAt()) }),
+	}}
+
+	pageField := func(typ typeRef, get func(*pb.ListBookResponse) any) *fieldDef {
+		return &fieldDef{typ: typ, resolve: func(ctx context.Context, src any, args map[string]any) (any, error) {
+			return get(src.(*pb.ListBookResponse)), nil
+		}}
+	}
+	connection := &objectDef{name: "BookConnection", fields: map[string]*fieldDef{
+		"books":               pageField(nonNull(listOf(nonNull(named("Book")))), func(r *pb.ListBookResponse) any { return append([]*pb.Book{}, r.GetBooks()...) }),
+		"totalCount":          pageField(nonNull(integer), func(r *pb.ListBookResponse) any { return r.GetTotalCount() }),
+		"totalCountEstimated": pageField(nonNull(boolean), func(r *pb.ListBookResponse) any { return r.GetTotalCountEstimated() }),
+		"nextPageToken": pageField(str, func(r *pb.ListBookResponse) any {
+			if r.GetNextPageToken() == "" {
+				return nil
+			}
+			return r.GetNextPageToken()
+		}),
+	}}
+
+	authField := func(typ typeRef, get func(*pb.AuthResponse) any) *fieldDef {
+		return &fieldDef{typ: typ, resolve: func(ctx context.Context, src any, args map[string]any) (any, error) {
+			return get(src.(*pb.AuthResponse)), nil
+		}}
+	}
+	auth := &objectDef{name: "AuthPayload", fields: map[string]*fieldDef{
+		"token":        authField(nonNull(str), func(r *pb.AuthResponse) any { return r.GetToken() }),
+		"refreshToken": authField(nonNull(str), func(r *pb.AuthResponse) any { return r.GetRefreshToken() }),
+		"expiresAt":    authField(named("Time"), func(r *pb.AuthResponse) any { return formatTime(r.GetExpiresAt()) }),
+	}}
+
+	query := &objectDef{name: "Query", fields: map[string]*fieldDef{
+		"book": {
+			typ:  named("Book"),
+			args: map[string]typeRef{"id": nonNull(id), "locale": str},
+			resolve: func(ctx context.Context, src any, args map[string]any) (any, error) {
+				return library.GetBook(ctx, &pb.BookRequest{Id: stringArg(args, "id"), Locale: stringArg(args, "locale")})
+			},
+		},
+		"books": {
+			typ: nonNull(named("BookConnection")),
+			args: map[string]typeRef{"page": integer, "pageSize": integer, "pageToken": str, "tags": tags, "author": str,
+				"publisherId": integer, "newestFirst": boolean, "locale": str},
+			resolve: func(ctx context.Context, src any, args map[string]any) (any, error) {
+				return library.ListBooks(ctx, &pb.ListBookRequest{
+					Page:        intArg(args, "page"),
+					PageSize:    intArg(args, "pageSize"),
+					PageToken:   stringArg(args, "pageToken"),
+					Tags:        stringsArg(args, "tags"),
+					Author:      stringArg(args, "author"),
+					PublisherId: intArg(args, "publisherId"),
+					NewestFirst: boolArg(args, "newestFirst"),
+					Locale:      stringArg(args, "locale"),
+				})
+			},
+		},
+		"searchBooks": {
+			typ:  nonNull(named("BookConnection")),
+			args: map[string]typeRef{"query": nonNull(str), "page": integer, "pageSize": integer, "locale": str},
+			resolve: func(ctx context.Context, src any, args map[string]any) (any, error) {
+				return library.SearchBooks(ctx, &pb.SearchBooksRequest{
+					Query:    stringArg(args, "query"),
+					Page:     intArg(args, "page"),
+					PageSize: intArg(args, "pageSize"),
+					Locale:   stringArg(args, "locale"),
+				})
+			},
+		},
+	}}
+
+	bookInput := nonNull(named("BookInput"))
+	mutation := &objectDef{name: "Mutation", fields: map[string]*fieldDef{
+		"login": {
+			typ:  nonNull(named("AuthPayload")),
+			args: map[string]typeRef{"username": nonNull(str), "password": nonNull(str)},
+			resolve: func(ctx context.Context, src any, args map[string]any) (any, error) {
+				return users.Login(ctx, &pb.UserCredentials{Username: stringArg(args, "username"), Password: stringArg(args, "password")})
+			},
+		},
+		"addBook": {
+			typ:  nonNull(id),
+			args: map[string]typeRef{"input": bookInput},
+			resolve: func(ctx context.Context, src any, args map[string]any) (any, error) {
+				resp, err := library.AddBook(ctx, bookFromInput(args["input"]))
+				return resp.GetId(), err
+			},
+		},
+		"updateBook": {
+			typ:  nonNull(id),
+			args: map[string]typeRef{"id": nonNull(id), "input": bookInput},
+			resolve: func(ctx context.Context, src any, args map[string]any) (any, error) {
+				book := bookFromInput(args["input"])
+				book.Id = stringArg(args, "id")
+				if _, err := library.UpdateBook(ctx, book); err != nil {
+					return nil, err
+				}
+				return book.Id, nil
+			},
+		},
+		"deleteBook": {
+			typ:  nonNull(id),
+			args: map[string]typeRef{"id": nonNull(id)},
+			resolve: func(ctx context.Context, src any, args map[string]any) (any, error) {
+				if _, err := library.DeleteBook(ctx, &pb.BookRequest{Id: stringArg(args, "id")}); err != nil {
+					return nil, err
+				}
+				return stringArg(args, "id"), nil
+			},
+		},
+	}}
+
+	return &schema{
+		query:    query,
+		mutation: mutation,
+		objects: map[string]*objectDef{
+			"Query": query, "Mutation": mutation, "Book": book, "BookConnection": connection, "AuthPayload": auth,
+		},
+		inputs: map[string]*inputDef{
+			"BookInput": {name: "BookInput", fields: map[string]typeRef{
+				"title": nonNull(str), "author": nonNull(str), "isbn": str, "publisher": str, "publisherId": integer,
+				"tags": tags, "description": str, "totalCopies": integer, "allowDuplicate": boolean,
+			}},
+		},
+	}
+}
+
+// bookFromInput is the Book of a coerced BookInput
+func bookFromInput(input any) *pb.Book {
+	fields, _ := input.(map[string]any)
+	return &pb.Book{
+		Title:          stringArg(fields, "title"),
+		Author:         stringArg(fields, "author"),
+		Isbn:           stringArg(fields, "isbn"),
+		Publisher:      stringArg(fields, "publisher"),
+		PublisherId:    intArg(fields, "publisherId"),
+		Tags:           stringsArg(fields, "tags"),
+		Description:    stringArg(fields, "description"),
+		TotalCopies:    intArg(fields, "totalCopies"),
+		AllowDuplicate: boolArg(fields, "allowDuplicate"),
+	}
+}
+
+// formatTime is the Time of ts, an RFC 3339 string, or nil when ts is unset
+func formatTime(ts *timestamppb.Timestamp) any {
+	if ts == nil {
+		return nil
+	}
+	return ts.AsTime().Format(time.RFC3339Nano)
+}
+
+// stringArg, intArg, boolArg and stringsArg read coerced arguments, the zero value when null or not given
+func stringArg(args map[string]any, name string) string {
+	s, _ := args[name].(string)
+	return s
+}
+
+func intArg(args map[string]any, name string) int32 {
+	i, _ := args[name].(int32)
+	return i
+}
+
+func boolArg(args map[string]any, name string) bool {
+	b, _ := args[name].(bool)
+	return b
+}
+
+func stringsArg(args map[string]any, name string) []string {
+	list, _ := args[name].([]any)
+	var s []string
+	for _, item := range list {
+		if item, ok := item.(string); ok {
+			s = append(s, item)
+		}
+	}
+	return s
+}
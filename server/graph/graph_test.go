@@ -0,0 +1,387 @@
+package graph
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	pb "example/grpc_demo/library"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// fakeLibrary serves the books of its map, and records the last request of each call and its metadata.
+// Calls fail with err when it is set.
+type fakeLibrary struct {
+	pb.LibraryServiceClient
+	books   map[string]*pb.Book
+	err     error
+	md      metadata.MD
+	list    *pb.ListBookRequest
+	search  *pb.SearchBooksRequest
+	added   *pb.Book
+	updated *pb.Book
+	deleted string
+}
+
+func (f *fakeLibrary) record(ctx context.Context) error {
+	f.md, _ = metadata.FromOutgoingContext(ctx)
+	return f.err
+}
+
+func (f *fakeLibrary) GetBook(ctx context.Context, in *pb.BookRequest, opts ...grpc.CallOption) (*pb.Book, error) {
+	if err := f.record(ctx); err != nil {
+		return nil, err
+	}
+	book, ok := f.books[in.GetId()]
+	if !ok {
+		return nil, status.Error(codes.NotFound, "Book not found")
+	}
+	return book, nil
+}
+
+func (f *fakeLibrary) ListBooks(ctx context.Context, in *pb.ListBookRequest, opts ...grpc.CallOption) (*pb.ListBookResponse, error) {
+	if err := f.record(ctx); err != nil {
+		return nil, err
+	}
+	f.list = in
+	if in.GetPageSize() < 0 {
+		return nil, status.Error(codes.InvalidArgument, "page_size must not be negative")
+	}
+	return &pb.ListBookResponse{Books: []*pb.Book{f.books["b1"]}, TotalCount: 2, NextPageToken: "page-2"}, nil
+}
+
+func (f *fakeLibrary) SearchBooks(ctx context.Context, in *pb.SearchBooksRequest, opts ...grpc.CallOption) (*pb.ListBookResponse, error) {
+	if err := f.record(ctx); err != nil {
+		return nil, err
+	}
+	f.search = in
+	return &pb.ListBookResponse{Books: []*pb.Book{f.books["b1"]}, TotalCount: 1}, nil
+}
+
+func (f *fakeLibrary) AddBook(ctx context.Context, in *pb.Book, opts ...grpc.CallOption) (*pb.BookResponse, error) {
+	if err := f.record(ctx); err != nil {
+		return nil, err
+	}
+	f.added = in
+	return &pb.BookResponse{Id: "b9"}, nil
+}
+
+func (f *fakeLibrary) UpdateBook(ctx context.Context, in *pb.Book, opts ...grpc.CallOption) (*pb.BookResponse, error) {
+	if err := f.record(ctx); err != nil {
+		return nil, err
+	}
+	f.updated = in
+	if _, ok := f.books[in.GetId()]; !ok {
+		return nil, status.Error(codes.NotFound, "Book not found")
+	}
+	return &pb.BookResponse{Id: in.GetId()}, nil
+}
+
+func (f *fakeLibrary) DeleteBook(ctx context.Context, in *pb.BookRequest, opts ...grpc.CallOption) (*pb.BookResponse, error) {
+	if err := f.record(ctx); err != nil {
+		return nil, err
+	}
+	f.deleted = in.GetId()
+	return &pb.BookResponse{Id: in.GetId()}, nil
+}
+
+type fakeUsers struct {
+	pb.UserServiceClient
+}
+
+func (fakeUsers) Login(ctx context.Context, in *pb.UserCredentials, opts ...grpc.CallOption) (*pb.AuthResponse, error) {
+	if in.GetPassword() != "secret123" {
+		return nil, status.Error(codes.Unauthenticated, "Invalid username or password")
+	}
+	return &pb.AuthResponse{Token: "access-" + in.GetUsername(), RefreshToken: "refresh", ExpiresAt: timestamppb.New(time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC))}, nil
+}
+
+func newTestHandler() (*Handler, *fakeLibrary) {
+	library := &fakeLibrary{books: map[string]*pb.Book{
+		"b1": {Id: "b1", Title: "Dune", Author: "Frank Herbert", Tags: []string{"sf"}, TotalCopies: 2, AvailableCopies: 1,
+			CreatedAt: timestamppb.New(time.Date(2025, 5, 6, 7, 8, 9, 0, time.UTC))},
+	}}
+	// As the gateway's gatewayCallMetadata, reduced to the token
+	md := func(r *http.Request) metadata.MD {
+		return metadata.Pairs("authorization", r.Header.Get("Authorization"))
+	}
+	return NewHandler(fakeUsers{}, library, md), library
+}
+
+// post sends a GraphQL request to h, returning the status code and body of the response
+func post(h http.Handler, query string, variables map[string]any) (int, string) {
+	body, _ := json.Marshal(map[string]any{"query": query, "variables": variables})
+	r := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(string(body)))
+	r.Header.Set("Authorization", "Bearer reader-token")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w.Code, strings.TrimSpace(w.Body.String())
+}
+
+func TestQueryBook(t *testing.T) {
+	h, library := newTestHandler()
+	query := `query Book($id: ID!, $withTags: Boolean = false) {
+		dune: book(id: $id) { __typename ...details tags @include(if: $withTags) }
+		missing: book(id: "nope") { id }
+	}
+	fragment details on Book { id title copies: availableCopies createdAt updatedAt }`
+	code, body := post(h, query, map[string]any{"id": "b1"})
+	want := `{"data":{"dune":{"__typename":"Book","id":"b1","title":"Dune","copies":1,"createdAt":"2025-05-06T07:08:09Z","updatedAt":null},"missing":null},` +
+		`"errors":[{"message":"Book not found","locations":[{"line":3,"column":3}],"path":["missing"],"extensions":{"code":"NOT_FOUND"}}]}`
+	if code != http.StatusOK || body != want {
+		t.Errorf("response = %d %s, want %s", code, body, want)
+	}
+	// The resolvers call the server with the caller's token
+	if got := library.md.Get("authorization"); !slices.Equal(got, []string{"Bearer reader-token"}) {
+		t.Errorf("authorization = %q, want the caller's token", got)
+	}
+
+	if _, body := post(h, query, map[string]any{"id": "b1", "withTags": true}); !strings.Contains(body, `"tags":["sf"]`) {
+		t.Errorf("response with @include(if: true) = %s, want the tags", body)
+	}
+}
+
+func TestQueryBooks(t *testing.T) {
+	h, library := newTestHandler()
+	_, body := post(h, `{ books(page: 2, pageSize: 10, pageToken: "page-2", tags: "sf", author: "Frank Herbert", publisherId: 3, newestFirst: true, locale: "pt-BR") {
+		totalCount totalCountEstimated nextPageToken books { id }
+	} }`, nil)
+	if want := `{"data":{"books":{"totalCount":2,"totalCountEstimated":false,"nextPageToken":"page-2","books":[{"id":"b1"}]}}}`; body != want {
+		t.Errorf("books = %s, want %s", body, want)
+	}
+	// A single tag is a list of one
+	want := &pb.ListBookRequest{Page: 2, PageSize: 10, PageToken: "page-2", Tags: []string{"sf"}, Author: "Frank Herbert", PublisherId: 3, NewestFirst: true, Locale: "pt-BR"}
+	if !proto.Equal(library.list, want) {
+		t.Errorf("ListBooks request = %v, want %v", library.list, want)
+	}
+
+	_, body = post(h, `query Search($q: String!) { searchBooks(query: $q, pageSize: 5) { totalCount nextPageToken books { title } } }`, map[string]any{"q": "dune"})
+	if want := `{"data":{"searchBooks":{"totalCount":1,"nextPageToken":null,"books":[{"title":"Dune"}]}}}`; body != want {
+		t.Errorf("searchBooks = %s, want %s", body, want)
+	}
+	if library.search.GetQuery() != "dune" || library.search.GetPageSize() != 5 {
+		t.Errorf("SearchBooks request = %v", library.search)
+	}
+
+	// books is non-null, so its failure nulls the whole result
+	_, body = post(h, `{ books(pageSize: -1) { totalCount } }`, nil)
+	if want := `{"data":null,"errors":[{"message":"page_size must not be negative","locations":[{"line":1,"column":3}],"path":["books"],"extensions":{"code":"INVALID_ARGUMENT"}}]}`; body != want {
+		t.Errorf("failed books = %s, want %s", body, want)
+	}
+}
+
+func TestMutations(t *testing.T) {
+	h, library := newTestHandler()
+	_, body := post(h, `mutation { login(username: "ana", password: "secret123") { token refreshToken expiresAt } }`, nil)
+	if want := `{"data":{"login":{"token":"access-ana","refreshToken":"refresh","expiresAt":"2026-01-02T03:04:05Z"}}}`; body != want {
+		t.Errorf("login = %s, want %s", body, want)
+	}
+
+	_, body = post(h, `mutation Add($book: BookInput!) { addBook(input: $book) }`,
+		map[string]any{"book": map[string]any{"title": "Emma", "author": "Jane Austen", "tags": []string{"classic"}, "totalCopies": 3}})
+	if want := `{"data":{"addBook":"b9"}}`; body != want {
+		t.Errorf("addBook = %s, want %s", body, want)
+	}
+	if want := (&pb.Book{Title: "Emma", Author: "Jane Austen", Tags: []string{"classic"}, TotalCopies: 3}); !proto.Equal(library.added, want) {
+		t.Errorf("AddBook request = %v, want %v", library.added, want)
+	}
+
+	_, body = post(h, `mutation { updated: updateBook(id: "b1", input: {title: "Dune", author: "F. Herbert"}) deleteBook(id: "b1") }`, nil)
+	if want := `{"data":{"updated":"b1","deleteBook":"b1"}}`; body != want {
+		t.Errorf("updateBook and deleteBook = %s, want %s", body, want)
+	}
+	if library.updated.GetId() != "b1" || library.updated.GetAuthor() != "F. Herbert" || library.deleted != "b1" {
+		t.Errorf("UpdateBook request = %v, DeleteBook of %q", library.updated, library.deleted)
+	}
+
+	// Mutations change data, so GET can't run them
+	r := httptest.NewRequest(http.MethodGet, "/graphql?query="+url.QueryEscape(`mutation { deleteBook(id: "b1") }`), nil)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("mutation with GET = %d, want %d", w.Code, http.StatusMethodNotAllowed)
+	}
+}
+
+func TestGetQuery(t *testing.T) {
+	h, _ := newTestHandler()
+	q := url.Values{"query": {`query($id: ID!) { book(id: $id) { title } }`}, "variables": {`{"id": "b1"}`}}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/graphql?"+q.Encode(), nil))
+	if body := strings.TrimSpace(w.Body.String()); w.Code != http.StatusOK || body != `{"data":{"book":{"title":"Dune"}}}` {
+		t.Errorf("GET = %d %s", w.Code, body)
+	}
+}
+
+func TestInvalidRequests(t *testing.T) {
+	h, library := newTestHandler()
+	tests := []struct {
+		name      string
+		query     string
+		variables map[string]any
+		wantError string
+	}{
+		{"syntax", `{ book(id: "b1") { id }`, nil, "Syntax Error: unexpected end of document"},
+		{"unknown field", `{ book(id: "b1") { isbn pages } }`, nil, `Cannot query field "pages" on type "Book"`},
+		{"leaf with selections", `{ book(id: "b1") { title { id } } }`, nil, `Field "title" must not have a selection`},
+		{"object without selections", `{ book(id: "b1") }`, nil, `Field "book" of type "Book" must have a selection of subfields`},
+		{"missing argument", `{ book { id } }`, nil, `Field "book" argument "id" of type "ID!" is required`},
+		{"unknown argument", `{ book(id: "b1", lang: "pt") { id } }`, nil, `Unknown argument "lang" on field "book"`},
+		{"argument of the wrong type", `{ books(pageSize: "ten") { totalCount } }`, nil, `Expected value of type "Int"`},
+		{"Int out of range", `{ books(pageSize: 3000000000) { totalCount } }`, nil, "Int cannot represent 3000000000"},
+		{"undefined variable", `{ book(id: $id) { id } }`, nil, `Variable "$id" is not defined`},
+		{"variable of the wrong type", `query($id: Int!) { book(id: $id) { id } }`, map[string]any{"id": 1}, `Variable "$id" of type "Int!" used in position expecting type "ID!"`},
+		{"missing variable", `query($id: ID!) { book(id: $id) { id } }`, nil, `Variable "$id" of required type "ID!" was not provided`},
+		{"invalid variable", `query($p: Int) { books(page: $p) { totalCount } }`, map[string]any{"p": "two"}, `Variable "$p" got invalid value`},
+		{"input without a required field", `mutation { addBook(input: {title: "Emma"}) }`, nil, `field "author" of required type "String!" was not provided`},
+		{"unknown fragment", `{ book(id: "b1") { ...details } }`, nil, `Unknown fragment "details"`},
+		{"fragment cycle", `{ book(id: "b1") { ...a } } fragment a on Book { ...b } fragment b on Book { ...a }`, nil, `Cannot spread fragment "a" within itself`},
+		{"fragment on another type", `{ book(id: "b1") { ...page } } fragment page on BookConnection { totalCount }`, nil, `Fragment on "BookConnection" cannot be spread here`},
+		{"query field in a mutation", `mutation { book(id: "b1") { id } }`, nil, `Cannot query field "book" on type "Mutation"`},
+		{"subscription", `subscription { book(id: "b1") { id } }`, nil, "Subscriptions are not supported"},
+		{"several operations", `query A { book(id: "b1") { id } } query B { book(id: "b1") { id } }`, nil, "operationName is required"},
+		{"too many fields", "{" + strings.Repeat(` b: book(id: "b1") { id }`, maxRootFields+1) + " }", nil, "An operation selects at most 20 fields"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			library.md = nil
+			code, body := post(h, tt.query, tt.variables)
+			var resp struct {
+				Data   json.RawMessage
+				Errors []gqlError
+			}
+			if err := json.Unmarshal([]byte(body), &resp); err != nil {
+				t.Fatalf("response %s: %v", body, err)
+			}
+			if code != http.StatusOK || resp.Data != nil || len(resp.Errors) != 1 || !strings.Contains(resp.Errors[0].Message, tt.wantError) {
+				t.Errorf("response = %d %s, want only an error containing %q", code, body, tt.wantError)
+			}
+			// Invalid requests don't call the server
+			if library.md != nil {
+				t.Error("the server was called")
+			}
+		})
+	}
+}
+
+func TestNotGraphQLRequests(t *testing.T) {
+	h, _ := newTestHandler()
+	tests := []struct {
+		name     string
+		method   string
+		body     string
+		wantCode int
+	}{
+		{"not JSON", http.MethodPost, "{ book(id: 1) { id } }", http.StatusBadRequest},
+		{"no query", http.MethodPost, `{"variables": {}}`, http.StatusBadRequest},
+		{"too large", http.MethodPost, `{"query": "` + strings.Repeat(" ", maxRequestBytes) + `"}`, http.StatusRequestEntityTooLarge},
+		{"other method", http.MethodPut, `{"query": "{ book(id: 1) { id } }"}`, http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest(tt.method, "/graphql", strings.NewReader(tt.body)))
+			if w.Code != tt.wantCode {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantCode)
+			}
+		})
+	}
+}
+
+// TestSchemaMatchesFile checks the types, fields and arguments of newSchema against schema.graphqls
+func TestSchemaMatchesFile(t *testing.T) {
+	s := newSchema(fakeUsers{}, &fakeLibrary{})
+	f, err := os.Open("schema.graphqls")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	fieldPattern := regexp.MustCompile(`^(\w+)(?:\((.*)\))?:\s*(\S+)$`)
+	argPattern := regexp.MustCompile(`(\w+):\s*([\w\[\]!]+)`)
+	var object *objectDef
+	var input *inputDef
+	seen := make(map[string]bool)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "scalar "):
+		case strings.HasPrefix(line, "type "):
+			name := strings.Fields(line)[1]
+			if object = s.objects[name]; object == nil {
+				t.Errorf("type %s is missing from the schema", name)
+			}
+		case strings.HasPrefix(line, "input "):
+			name := strings.Fields(line)[1]
+			if input = s.inputs[name]; input == nil {
+				t.Errorf("input %s is missing from the schema", name)
+			}
+		case line == "}":
+			object, input = nil, nil
+		default:
+			m := fieldPattern.FindStringSubmatch(line)
+			if m == nil {
+				t.Fatalf("unexpected line %q", line)
+			}
+			switch {
+			case input != nil:
+				seen[input.name+"."+m[1]] = true
+				if got := input.fields[m[1]]; got.String() != m[3] {
+					t.Errorf("%s.%s is %q, want %q", input.name, m[1], got, m[3])
+				}
+			case object != nil:
+				seen[object.name+"."+m[1]] = true
+				def := object.fields[m[1]]
+				if def == nil {
+					t.Errorf("%s.%s is missing from the schema", object.name, m[1])
+					continue
+				}
+				if def.typ.String() != m[3] {
+					t.Errorf("%s.%s is %q, want %q", object.name, m[1], def.typ, m[3])
+				}
+				args := argPattern.FindAllStringSubmatch(m[2], -1)
+				if len(args) != len(def.args) {
+					t.Errorf("%s.%s has %d arguments, want %d", object.name, m[1], len(def.args), len(args))
+				}
+				for _, arg := range args {
+					if got := def.args[arg[1]]; got.String() != arg[2] {
+						t.Errorf("%s.%s argument %s is %q, want %q", object.name, m[1], arg[1], got, arg[2])
+					}
+				}
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatal(err)
+	}
+	for _, object := range s.objects {
+		for name := range object.fields {
+			if !seen[object.name+"."+name] {
+				t.Errorf("%s.%s is not in schema.graphqls", object.name, name)
+			}
+		}
+	}
+	for _, input := range s.inputs {
+		for name := range input.fields {
+			if !seen[input.name+"."+name] {
+				t.Errorf("%s.%s is not in schema.graphqls", input.name, name)
+			}
+		}
+	}
+}
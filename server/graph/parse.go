@@ -0,0 +1,897 @@
+package graph
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// document is a parsed GraphQL request document: its operations, and the fragments they spread
+type document struct {
+	operations []*operation
+	fragments  map[string]*fragment
+}
+
+// operation is a query or mutation of a document
+type operation struct {
+	kind       string
+	name       string
+	vars       []*varDef
+	selections []selection
+	loc        location
+}
+
+// varDef declares a variable of an operation, with its default value when it has one
+type varDef struct {
+	name string
+	typ  typeRef
+	def  *value
+	loc  location
+}
+
+type fragment struct {
+	name       string
+	typeCond   string
+	selections []selection
+	loc        location
+}
+
+// selection is a field, a fragment spread or an inline fragment
+type selection interface {
+	isSelection()
+}
+
+type field struct {
+	alias      string
+	name       string
+	args       []*argument
+	directives []*directive
+	selections []selection
+	loc        location
+}
+
+type fragmentSpread struct {
+	name       string
+	directives []*directive
+	loc        location
+}
+
+type inlineFragment struct {
+	typeCond   string
+	directives []*directive
+	selections []selection
+	loc        location
+}
+
+func (*field) isSelection()          {}
+func (*fragmentSpread) isSelection() {}
+func (*inlineFragment) isSelection() {}
+
+// responseKey is the key of the field in the response: its alias, or else its name
+func (f *field) responseKey() string {
+	if f.alias != "" {
+		return f.alias
+	}
+	return f.name
+}
+
+type argument struct {
+	name string
+	val  value
+	loc  location
+}
+
+type directive struct {
+	name string
+	args []*argument
+	loc  location
+}
+
+// location is where a token starts in the document, 1-based as in GraphQL errors
+type location struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// typeRef is a type of the schema as written in variable and field definitions: a named type, or a list of
+// its elem, either of them possibly non-null
+type typeRef struct {
+	name    string
+	elem    *typeRef
+	nonNull bool
+}
+
+func (t typeRef) String() string {
+	s := t.name
+	if t.elem != nil {
+		s = "[" + t.elem.String() + "]"
+	}
+	if t.nonNull {
+		s += "!"
+	}
+	return s
+}
+
+// nullable is t without its non-null modifier
+func (t typeRef) nullable() typeRef {
+	t.nonNull = false
+	return t
+}
+
+type valueKind int
+
+const (
+	variableValue valueKind = iota
+	intValue
+	floatValue
+	stringValue
+	booleanValue
+	nullValue
+	enumValue
+	listValue
+	objectValue
+)
+
+// value is a literal or variable of a document
+type value struct {
+	kind   valueKind
+	raw    string
+	list   []value
+	fields []*argument
+	loc    location
+}
+
+// enumLiteral is an enum value of a document, which no input type of the schema accepts
+type enumLiteral string
+
+// resolve returns v as the JSON-like values variables decode to, with the variables of vars substituted.
+// A variable missing from vars resolves to nil, and present false.
+func (v value) resolve(vars map[string]any) (resolved any, present bool) {
+	switch v.kind {
+	case variableValue:
+		resolved, present = vars[v.raw]
+		return resolved, present
+	case intValue, floatValue:
+		return json.Number(v.raw), true
+	case stringValue:
+		return v.raw, true
+	case booleanValue:
+		return v.raw == "true", true
+	case nullValue:
+		return nil, true
+	case enumValue:
+		return enumLiteral(v.raw), true
+	case listValue:
+		list := make([]any, 0, len(v.list))
+		for _, item := range v.list {
+			// A missing variable in a list is null
+			resolved, _ := item.resolve(vars)
+			list = append(list, resolved)
+		}
+		return list, true
+	default:
+		object := make(map[string]any, len(v.fields))
+		for _, f := range v.fields {
+			if resolved, present := f.val.resolve(vars); present {
+				object[f.name] = resolved
+			}
+		}
+		return object, true
+	}
+}
+
+// syntaxError is a document that doesn't parse, at loc
+type syntaxError struct {
+	msg string
+	loc location
+}
+
+func (e *syntaxError) Error() string {
+	return fmt.Sprintf("Syntax Error: %s (line %d, column %d)", e.msg, e.loc.Line, e.loc.Column)
+}
+
+type tokenKind int
+
+const (
+	eofToken tokenKind = iota
+	punctToken
+	nameToken
+	intToken
+	floatToken
+	stringToken
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	loc  location
+}
+
+// maxTokens bounds the tokens of a document, and maxDepth the nesting of its selection sets, values and
+// types, so that a large request can't keep the parser busy or exhaust its stack before authentication
+const (
+	maxTokens = 15000
+	maxDepth  = 64
+)
+
+// lexer splits a document into tokens, skipping whitespace, commas and comments
+type lexer struct {
+	src  string
+	pos  int
+	line int
+	// column is the column of counted, the offset up to which the runes of the line have been counted,
+	// so that each rune is counted once however long the line
+	column  int
+	counted int
+}
+
+func (l *lexer) location() location {
+	l.column += utf8.RuneCountInString(l.src[l.counted:l.pos])
+	l.counted = l.pos
+	return location{Line: l.line, Column: l.column + 1}
+}
+
+func (l *lexer) newline() {
+	l.line++
+	l.column, l.counted = 0, l.pos
+}
+
+func (l *lexer) skipIgnored() {
+	for l.pos < len(l.src) {
+		switch c := l.src[l.pos]; c {
+		case ' ', '\t', ',':
+			l.pos++
+		case '\n':
+			l.pos++
+			l.newline()
+		case '\r':
+			l.pos++
+			if l.pos < len(l.src) && l.src[l.pos] == '\n' {
+				l.pos++
+			}
+			l.newline()
+		case '#':
+			for l.pos < len(l.src) && l.src[l.pos] != '\n' && l.src[l.pos] != '\r' {
+				l.pos++
+			}
+		default:
+			// The byte order mark is ignored like whitespace
+			if strings.HasPrefix(l.src[l.pos:], "\ufeff") {
+				l.pos += len("\ufeff")
+				continue
+			}
+			return
+		}
+	}
+}
+
+func isNameStart(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func (l *lexer) next() (token, error) {
+	l.skipIgnored()
+	loc := l.location()
+	if l.pos >= len(l.src) {
+		return token{kind: eofToken, loc: loc}, nil
+	}
+	c := l.src[l.pos]
+	switch {
+	case strings.IndexByte("!$&()[]{}:=@|", c) >= 0:
+		l.pos++
+		return token{kind: punctToken, text: string(c), loc: loc}, nil
+	case c == '.':
+		if !strings.HasPrefix(l.src[l.pos:], "...") {
+			return token{}, &syntaxError{"unexpected \".\"", loc}
+		}
+		l.pos += 3
+		return token{kind: punctToken, text: "...", loc: loc}, nil
+	case isNameStart(c):
+		start := l.pos
+		for l.pos < len(l.src) && (isNameStart(l.src[l.pos]) || isDigit(l.src[l.pos])) {
+			l.pos++
+		}
+		return token{kind: nameToken, text: l.src[start:l.pos], loc: loc}, nil
+	case c == '-' || isDigit(c):
+		return l.number(loc)
+	case c == '"':
+		if strings.HasPrefix(l.src[l.pos:], `"""`) {
+			return l.blockString(loc)
+		}
+		return l.string(loc)
+	}
+	r, _ := utf8.DecodeRuneInString(l.src[l.pos:])
+	return token{}, &syntaxError{fmt.Sprintf("unexpected character %q", r), loc}
+}
+
+func (l *lexer) number(loc location) (token, error) {
+	start := l.pos
+	digits := func() int {
+		n := 0
+		for l.pos < len(l.src) && isDigit(l.src[l.pos]) {
+			l.pos++
+			n++
+		}
+		return n
+	}
+	if l.src[l.pos] == '-' {
+		l.pos++
+	}
+	intStart := l.pos
+	if digits() == 0 || l.pos-intStart > 1 && l.src[intStart] == '0' {
+		return token{}, &syntaxError{"invalid number", loc}
+	}
+	kind := intToken
+	if l.pos < len(l.src) && l.src[l.pos] == '.' {
+		l.pos++
+		if digits() == 0 {
+			return token{}, &syntaxError{"invalid number", loc}
+		}
+		kind = floatToken
+	}
+	if l.pos < len(l.src) && (l.src[l.pos] == 'e' || l.src[l.pos] == 'E') {
+		l.pos++
+		if l.pos < len(l.src) && (l.src[l.pos] == '+' || l.src[l.pos] == '-') {
+			l.pos++
+		}
+		if digits() == 0 {
+			return token{}, &syntaxError{"invalid number", loc}
+		}
+		kind = floatToken
+	}
+	if l.pos < len(l.src) && (isNameStart(l.src[l.pos]) || l.src[l.pos] == '.') {
+		return token{}, &syntaxError{"invalid number", loc}
+	}
+	return token{kind: kind, text: l.src[start:l.pos], loc: loc}, nil
+}
+
+func (l *lexer) string(loc location) (token, error) {
+	l.pos++
+	var b strings.Builder
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		switch {
+		case c == '"':
+			l.pos++
+			return token{kind: stringToken, text: b.String(), loc: loc}, nil
+		case c == '\n' || c == '\r':
+			return token{}, &syntaxError{"unterminated string", loc}
+		case c == '\\':
+			if l.pos+1 >= len(l.src) {
+				return token{}, &syntaxError{"unterminated string", loc}
+			}
+			escape := l.src[l.pos+1]
+			l.pos += 2
+			switch escape {
+			case '"', '\\', '/':
+				b.WriteByte(escape)
+			case 'b':
+				b.WriteByte('\b')
+			case 'f':
+				b.WriteByte('\f')
+			case 'n':
+				b.WriteByte('\n')
+			case 'r':
+				b.WriteByte('\r')
+			case 't':
+				b.WriteByte('\t')
+			case 'u':
+				if l.pos+4 > len(l.src) {
+					return token{}, &syntaxError{"invalid unicode escape", loc}
+				}
+				code, err := strconv.ParseUint(l.src[l.pos:l.pos+4], 16, 32)
+				if err != nil {
+					return token{}, &syntaxError{"invalid unicode escape", loc}
+				}
+				b.WriteRune(rune(code))
+				l.pos += 4
+			default:
+				return token{}, &syntaxError{fmt.Sprintf("invalid escape \\%c", escape), loc}
+			}
+		default:
+			b.WriteByte(c)
+			l.pos++
+		}
+	}
+	return token{}, &syntaxError{"unterminated string", loc}
+}
+
+// blockString reads a """ string, whose common indentation and leading and trailing blank lines are removed
+func (l *lexer) blockString(loc location) (token, error) {
+	l.pos += 3
+	start := l.pos
+	for l.pos < len(l.src) {
+		switch {
+		case strings.HasPrefix(l.src[l.pos:], `\"""`):
+			l.pos += 4
+		case strings.HasPrefix(l.src[l.pos:], `"""`):
+			raw := strings.ReplaceAll(l.src[start:l.pos], `\"""`, `"""`)
+			l.pos += 3
+			return token{kind: stringToken, text: blockStringValue(raw), loc: loc}, nil
+		case l.src[l.pos] == '\n':
+			l.pos++
+			l.newline()
+		default:
+			l.pos++
+		}
+	}
+	return token{}, &syntaxError{"unterminated string", loc}
+}
+
+func blockStringValue(raw string) string {
+	lines := strings.Split(strings.ReplaceAll(raw, "\r\n", "\n"), "\n")
+	indent := -1
+	for _, line := range lines[1:] {
+		trimmed := strings.TrimLeft(line, " \t")
+		if trimmed == "" {
+			continue
+		}
+		if n := len(line) - len(trimmed); indent < 0 || n < indent {
+			indent = n
+		}
+	}
+	if indent > 0 {
+		for i := 1; i < len(lines); i++ {
+			if len(lines[i]) >= indent {
+				lines[i] = lines[i][indent:]
+			} else {
+				lines[i] = ""
+			}
+		}
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[0]) == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// parser builds a document from the tokens of a lexer, one token ahead
+type parser struct {
+	lex *lexer
+	tok token
+	// tokens counts the tokens read, up to maxTokens, and depth the selection sets, lists, objects and list
+	// types being parsed, up to maxDepth
+	tokens int
+	depth  int
+}
+
+// parse parses the executable document src: operations and fragments only, as type system definitions
+// are for schemas
+func parse(src string) (*document, error) {
+	p := &parser{lex: &lexer{src: src, line: 1}}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	doc := &document{fragments: make(map[string]*fragment)}
+	for p.tok.kind != eofToken {
+		switch {
+		case p.peek("{"):
+			op := &operation{kind: "query", loc: p.tok.loc}
+			var err error
+			if op.selections, err = p.selectionSet(); err != nil {
+				return nil, err
+			}
+			doc.operations = append(doc.operations, op)
+		case p.tok.kind == nameToken && (p.tok.text == "query" || p.tok.text == "mutation" || p.tok.text == "subscription"):
+			op, err := p.operation()
+			if err != nil {
+				return nil, err
+			}
+			doc.operations = append(doc.operations, op)
+		case p.tok.kind == nameToken && p.tok.text == "fragment":
+			frag, err := p.fragment()
+			if err != nil {
+				return nil, err
+			}
+			if _, ok := doc.fragments[frag.name]; ok {
+				return nil, &syntaxError{fmt.Sprintf("there can be only one fragment named %q", frag.name), frag.loc}
+			}
+			doc.fragments[frag.name] = frag
+		default:
+			return nil, p.unexpected()
+		}
+	}
+	if len(doc.operations) == 0 {
+		return nil, &syntaxError{"the document has no operation", p.tok.loc}
+	}
+	return doc, nil
+}
+
+func (p *parser) advance() error {
+	tok, err := p.lex.next()
+	if err != nil {
+		return err
+	}
+	if p.tokens++; p.tokens > maxTokens {
+		return &syntaxError{fmt.Sprintf("a document has at most %d tokens", maxTokens), tok.loc}
+	}
+	p.tok = tok
+	return nil
+}
+
+// nest enters a selection set, list, object or list type, which leave returns from
+func (p *parser) nest() error {
+	if p.depth++; p.depth > maxDepth {
+		return &syntaxError{fmt.Sprintf("a document nests at most %d levels", maxDepth), p.tok.loc}
+	}
+	return nil
+}
+
+func (p *parser) leave() {
+	p.depth--
+}
+
+// peek reports whether the current token is the punctuator punct
+func (p *parser) peek(punct string) bool {
+	return p.tok.kind == punctToken && p.tok.text == punct
+}
+
+func (p *parser) unexpected() error {
+	if p.tok.kind == eofToken {
+		return &syntaxError{"unexpected end of document", p.tok.loc}
+	}
+	return &syntaxError{fmt.Sprintf("unexpected %q", p.tok.text), p.tok.loc}
+}
+
+// expect consumes the punctuator punct
+func (p *parser) expect(punct string) error {
+	if !p.peek(punct) {
+		return p.unexpected()
+	}
+	return p.advance()
+}
+
+// skip consumes the punctuator punct if it is the current token, and reports whether it was
+func (p *parser) skip(punct string) (bool, error) {
+	if !p.peek(punct) {
+		return false, nil
+	}
+	return true, p.advance()
+}
+
+func (p *parser) name() (string, error) {
+	if p.tok.kind != nameToken {
+		return "", p.unexpected()
+	}
+	name := p.tok.text
+	return name, p.advance()
+}
+
+func (p *parser) operation() (*operation, error) {
+	op := &operation{kind: p.tok.text, loc: p.tok.loc}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	var err error
+	if p.tok.kind == nameToken {
+		if op.name, err = p.name(); err != nil {
+			return nil, err
+		}
+	}
+	if ok, err := p.skip("("); err != nil {
+		return nil, err
+	} else if ok {
+		for !p.peek(")") {
+			def, err := p.varDef()
+			if err != nil {
+				return nil, err
+			}
+			op.vars = append(op.vars, def)
+		}
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := p.directives(); err != nil {
+		return nil, err
+	}
+	if op.selections, err = p.selectionSet(); err != nil {
+		return nil, err
+	}
+	return op, nil
+}
+
+func (p *parser) varDef() (*varDef, error) {
+	def := &varDef{loc: p.tok.loc}
+	if err := p.expect("$"); err != nil {
+		return nil, err
+	}
+	var err error
+	if def.name, err = p.name(); err != nil {
+		return nil, err
+	}
+	if err := p.expect(":"); err != nil {
+		return nil, err
+	}
+	if def.typ, err = p.typeRef(); err != nil {
+		return nil, err
+	}
+	if ok, err := p.skip("="); err != nil {
+		return nil, err
+	} else if ok {
+		v, err := p.value(true)
+		if err != nil {
+			return nil, err
+		}
+		def.def = &v
+	}
+	if _, err := p.directives(); err != nil {
+		return nil, err
+	}
+	return def, nil
+}
+
+func (p *parser) typeRef() (typeRef, error) {
+	var t typeRef
+	if ok, err := p.skip("["); err != nil {
+		return t, err
+	} else if ok {
+		if err := p.nest(); err != nil {
+			return t, err
+		}
+		defer p.leave()
+		elem, err := p.typeRef()
+		if err != nil {
+			return t, err
+		}
+		t.elem = &elem
+		if err := p.expect("]"); err != nil {
+			return t, err
+		}
+	} else {
+		name, err := p.name()
+		if err != nil {
+			return t, err
+		}
+		t.name = name
+	}
+	nonNull, err := p.skip("!")
+	t.nonNull = nonNull
+	return t, err
+}
+
+func (p *parser) fragment() (*fragment, error) {
+	frag := &fragment{loc: p.tok.loc}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	var err error
+	if frag.name, err = p.name(); err != nil {
+		return nil, err
+	}
+	if frag.name == "on" {
+		return nil, &syntaxError{"a fragment can't be named \"on\"", frag.loc}
+	}
+	if p.tok.kind != nameToken || p.tok.text != "on" {
+		return nil, p.unexpected()
+	}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	if frag.typeCond, err = p.name(); err != nil {
+		return nil, err
+	}
+	if _, err := p.directives(); err != nil {
+		return nil, err
+	}
+	if frag.selections, err = p.selectionSet(); err != nil {
+		return nil, err
+	}
+	return frag, nil
+}
+
+func (p *parser) selectionSet() ([]selection, error) {
+	if err := p.nest(); err != nil {
+		return nil, err
+	}
+	defer p.leave()
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	var selections []selection
+	for !p.peek("}") {
+		sel, err := p.selection()
+		if err != nil {
+			return nil, err
+		}
+		selections = append(selections, sel)
+	}
+	if len(selections) == 0 {
+		return nil, &syntaxError{"a selection set can't be empty", p.tok.loc}
+	}
+	return selections, p.advance()
+}
+
+func (p *parser) selection() (selection, error) {
+	loc := p.tok.loc
+	if ok, err := p.skip("..."); err != nil {
+		return nil, err
+	} else if ok {
+		if p.tok.kind == nameToken && p.tok.text != "on" {
+			spread := &fragmentSpread{loc: loc}
+			var err error
+			if spread.name, err = p.name(); err != nil {
+				return nil, err
+			}
+			spread.directives, err = p.directives()
+			return spread, err
+		}
+		inline := &inlineFragment{loc: loc}
+		if p.tok.kind == nameToken {
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+			var err error
+			if inline.typeCond, err = p.name(); err != nil {
+				return nil, err
+			}
+		}
+		var err error
+		if inline.directives, err = p.directives(); err != nil {
+			return nil, err
+		}
+		inline.selections, err = p.selectionSet()
+		return inline, err
+	}
+
+	f := &field{loc: loc}
+	var err error
+	if f.name, err = p.name(); err != nil {
+		return nil, err
+	}
+	if ok, err := p.skip(":"); err != nil {
+		return nil, err
+	} else if ok {
+		f.alias = f.name
+		if f.name, err = p.name(); err != nil {
+			return nil, err
+		}
+	}
+	if f.args, err = p.arguments(); err != nil {
+		return nil, err
+	}
+	if f.directives, err = p.directives(); err != nil {
+		return nil, err
+	}
+	if p.peek("{") {
+		if f.selections, err = p.selectionSet(); err != nil {
+			return nil, err
+		}
+	}
+	return f, nil
+}
+
+func (p *parser) arguments() ([]*argument, error) {
+	if ok, err := p.skip("("); err != nil || !ok {
+		return nil, err
+	}
+	var args []*argument
+	for !p.peek(")") {
+		arg := &argument{loc: p.tok.loc}
+		var err error
+		if arg.name, err = p.name(); err != nil {
+			return nil, err
+		}
+		if err := p.expect(":"); err != nil {
+			return nil, err
+		}
+		if arg.val, err = p.value(false); err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+	}
+	if len(args) == 0 {
+		return nil, &syntaxError{"an argument list can't be empty", p.tok.loc}
+	}
+	return args, p.advance()
+}
+
+func (p *parser) directives() ([]*directive, error) {
+	var directives []*directive
+	for p.peek("@") {
+		d := &directive{loc: p.tok.loc}
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		var err error
+		if d.name, err = p.name(); err != nil {
+			return nil, err
+		}
+		if d.args, err = p.arguments(); err != nil {
+			return nil, err
+		}
+		directives = append(directives, d)
+	}
+	return directives, nil
+}
+
+// value parses a value; constant ones, such as the defaults of variables, can't hold variables
+func (p *parser) value(constant bool) (value, error) {
+	v := value{loc: p.tok.loc, raw: p.tok.text}
+	switch p.tok.kind {
+	case intToken:
+		v.kind = intValue
+	case floatToken:
+		v.kind = floatValue
+	case stringToken:
+		v.kind = stringValue
+	case nameToken:
+		switch p.tok.text {
+		case "true", "false":
+			v.kind = booleanValue
+		case "null":
+			v.kind = nullValue
+		default:
+			v.kind = enumValue
+		}
+	case punctToken:
+		switch p.tok.text {
+		case "$":
+			if constant {
+				return v, &syntaxError{"unexpected variable in a constant value", v.loc}
+			}
+			if err := p.advance(); err != nil {
+				return v, err
+			}
+			v.kind = variableValue
+			var err error
+			v.raw, err = p.name()
+			return v, err
+		case "[":
+			v.kind = listValue
+			if err := p.nest(); err != nil {
+				return v, err
+			}
+			defer p.leave()
+			if err := p.advance(); err != nil {
+				return v, err
+			}
+			for !p.peek("]") {
+				item, err := p.value(constant)
+				if err != nil {
+					return v, err
+				}
+				v.list = append(v.list, item)
+			}
+			return v, p.advance()
+		case "{":
+			v.kind = objectValue
+			if err := p.nest(); err != nil {
+				return v, err
+			}
+			defer p.leave()
+			if err := p.advance(); err != nil {
+				return v, err
+			}
+			for !p.peek("}") {
+				f := &argument{loc: p.tok.loc}
+				var err error
+				if f.name, err = p.name(); err != nil {
+					return v, err
+				}
+				if err := p.expect(":"); err != nil {
+					return v, err
+				}
+				if f.val, err = p.value(constant); err != nil {
+					return v, err
+				}
+				v.fields = append(v.fields, f)
+			}
+			return v, p.advance()
+		default:
+			return v, p.unexpected()
+		}
+	default:
+		return v, p.unexpected()
+	}
+	return v, p.advance()
+}
@@ -0,0 +1,88 @@
+package graph
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestParseValues(t *testing.T) {
+	tests := []struct {
+		literal string
+		want    any
+	}{
+		{`"Le Guin\n\"Earthsea\" é"`, "Le Guin\n\"Earthsea\" é"},
+		{"\"\"\"\n    First line\n      indented\n    \\\"\"\" quoted\n  \"\"\"", "First line\n  indented\n\"\"\" quoted"},
+		{"-12", json.Number("-12")},
+		{"1.5e3", json.Number("1.5e3")},
+		{"true", true},
+		{"null", nil},
+		{`[1, "two" # a comment
+			null]`, []any{json.Number("1"), "two", nil}},
+		{`{title: "Emma", tags: ["classic"]}`, map[string]any{"title": "Emma", "tags": []any{"classic"}}},
+	}
+	for _, tt := range tests {
+		doc, err := parse(`{ books(author: ` + tt.literal + `) { totalCount } }`)
+		if err != nil {
+			t.Errorf("parse(%s): %v", tt.literal, err)
+			continue
+		}
+		got, _ := doc.operations[0].selections[0].(*field).args[0].val.resolve(nil)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("value of %s = %#v, want %#v", tt.literal, got, tt.want)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{`{ books(page: 01) { totalCount } }`, "invalid number (line 1, column 15)"},
+		{`{ books(page: 1.) { totalCount } }`, "invalid number"},
+		{"{ book(id: \"b1\n\") { id } }", "unterminated string"},
+		{`{ book(id: "\q") { id } }`, `invalid escape \q`},
+		{`{ book(id: "b1") { id } `, "unexpected end of document"},
+		{`{ }`, "a selection set can't be empty"},
+		{`{ book() { id } }`, "an argument list can't be empty"},
+		{`query($id: ID = $other) { book(id: $id) { id } }`, "unexpected variable in a constant value"},
+		{`fragment on on Book { id }`, `a fragment can't be named "on"`},
+		{"{ book(id: \"b1\") { id } }\nfragment f on Book { id }\nfragment f on Book { title }", `there can be only one fragment named "f" (line 3, column 1)`},
+		{`fragment f on Book { id }`, "the document has no operation"},
+		{`{ book(id: "b1") { id } } %`, `unexpected character '%'`},
+		{`{ books(author: ` + strings.Repeat("[", 70) + `) { totalCount } }`, "a document nests at most 64 levels (line 1, column 80)"},
+		{`query($tags: ` + strings.Repeat("[", 70) + `String` + strings.Repeat("]", 70) + `) { books { totalCount } }`, "a document nests at most 64 levels"},
+		{strings.Repeat("{ book ", 70), "a document nests at most 64 levels"},
+		{`{ books { ` + strings.Repeat("totalCount ", maxTokens) + `} }`, "a document has at most 15000 tokens"},
+	}
+	for _, tt := range tests {
+		_, err := parse(tt.query)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("parse(%q) error = %v, want %q", tt.query, err, tt.want)
+		}
+	}
+}
+
+// TestParseLongLine parses a document of a megabyte on one line: each token's column must be counted from
+// where the last one's was, or the document takes minutes to parse
+func TestParseLongLine(t *testing.T) {
+	id := strings.Repeat("é", 1<<19)
+	query := `{ book(id: "` + id + `") { ` + strings.Repeat("id ", maxTokens-20) + `} }`
+	doc, err := parse(query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := len(doc.operations[0].selections[0].(*field).selections); got != maxTokens-20 {
+		t.Errorf("parsed %d selections, want %d", got, maxTokens-20)
+	}
+
+	_, err = parse(query + " %")
+	want := fmt.Sprintf("(line 1, column %d)", utf8.RuneCountInString(query)+2)
+	if err == nil || !strings.HasSuffix(err.Error(), want) {
+		t.Errorf("error at the end of the line = %v, want it %s", err, want)
+	}
+}
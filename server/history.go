@@ -0,0 +1,24 @@
+package main
+
+import (
+	pb "example/grpc_demo/library"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (s *server) GetBookHistory(req *pb.BookRequest, stream pb.LibraryService_GetBookHistoryServer) error {
+	if req.GetId() == "" {
+		return status.Error(codes.InvalidArgument, "Book ID is required")
+	}
+	revisions, err := s.books.History(stream.Context(), req.GetId())
+	if err != nil {
+		return status.Errorf(codes.Internal, "failed to query book history: %v", err)
+	}
+	for _, rev := range revisions {
+		if err := stream.Send(rev); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+
+	"golang.org/x/text/language"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+// acceptLanguageHeader is the metadata header clients list their preferred languages in, as in HTTP
+const acceptLanguageHeader = "accept-language"
+
+// supportedLocales are the languages messages are available in; the first is the default
+var supportedLocales = []language.Tag{language.AmericanEnglish, language.BrazilianPortuguese}
+
+var localeMatcher = language.NewMatcher(supportedLocales)
+
+// messageCatalog translates messages, keyed by their English text, into each supported locale but the
+// default. Messages missing from a locale's catalog are sent in English.
+var messageCatalog = map[language.Tag]map[string]string{
+	language.BrazilianPortuguese: messagesPtBR,
+}
+
+// localeFromContext negotiates the locale of a call from its accept-language header, falling back to
+// English when the header is missing or names no supported language
+func localeFromContext(ctx context.Context) language.Tag {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get(acceptLanguageHeader)
+	if len(values) == 0 {
+		return supportedLocales[0]
+	}
+	preferred, _, err := language.ParseAcceptLanguage(values[0])
+	if err != nil || len(preferred) == 0 {
+		return supportedLocales[0]
+	}
+	_, i, confidence := localeMatcher.Match(preferred...)
+	if confidence == language.No {
+		return supportedLocales[0]
+	}
+	return supportedLocales[i]
+}
+
+// translate returns message in locale, or message itself when the catalog doesn't have it
+func translate(locale language.Tag, message string) string {
+	if translated, ok := messageCatalog[locale][message]; ok {
+		return translated
+	}
+	return message
+}
+
+// localize translates message into the locale of the call
+func localize(ctx context.Context, message string) string {
+	return translate(localeFromContext(ctx), message)
+}
+
+// localizeError translates the message of a status error, and the BadRequest and LocalizedMessage details
+// it carries, into the locale of the call. The LocalizedMessage detail says which locale that is; one is
+// added when a translation was made.
+func localizeError(ctx context.Context, err error) error {
+	st, ok := status.FromError(err)
+	if !ok || err == nil {
+		return err
+	}
+	locale := localeFromContext(ctx)
+	if _, ok := messageCatalog[locale]; !ok {
+		return err
+	}
+	p := st.Proto()
+	translated := translate(locale, p.GetMessage())
+	if translated == p.GetMessage() {
+		return err
+	}
+	p.Message = translated
+	hasLocalizedMessage := false
+	for i, detail := range p.GetDetails() {
+		m, err := detail.UnmarshalNew()
+		if err != nil {
+			continue
+		}
+		switch d := m.(type) {
+		case *errdetails.BadRequest:
+			for _, v := range d.GetFieldViolations() {
+				v.Description = translate(locale, v.GetDescription())
+			}
+		case *errdetails.LocalizedMessage:
+			hasLocalizedMessage = true
+			d.Locale, d.Message = locale.String(), translated
+		default:
+			continue
+		}
+		if updated, err := anypb.New(m); err == nil {
+			p.Details[i] = updated
+		}
+	}
+	if !hasLocalizedMessage {
+		if detail, err := anypb.New(&errdetails.LocalizedMessage{Locale: locale.String(), Message: translated}); err == nil {
+			p.Details = append(p.Details, detail)
+		}
+	}
+	return status.FromProto(p).Err()
+}
+
+// CreateLocaleInterceptor applies localizeError to the errors of unary calls
+func CreateLocaleInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		resp, err := handler(ctx, req)
+		return resp, localizeError(ctx, err)
+	}
+}
+
+// CreateStreamLocaleInterceptor applies localizeError to the errors of streaming calls
+func CreateStreamLocaleInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return localizeError(ss.Context(), handler(srv, ss))
+	}
+}
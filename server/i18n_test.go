@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"strconv"
+	"strings"
+	"testing"
+
+	"golang.org/x/text/language"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func acceptLanguageContext(header string) context.Context {
+	return metadata.NewIncomingContext(context.Background(), metadata.Pairs(acceptLanguageHeader, header))
+}
+
+func TestLocaleFromContext(t *testing.T) {
+	tests := []struct {
+		header string
+		want   language.Tag
+	}{
+		{"", language.AmericanEnglish},
+		{"pt-BR", language.BrazilianPortuguese},
+		{"pt", language.BrazilianPortuguese},
+		{"fr-FR, pt-BR;q=0.8, en;q=0.5", language.BrazilianPortuguese},
+		{"en-GB, pt-BR;q=0.5", language.AmericanEnglish},
+		{"ja", language.AmericanEnglish},
+		{"not a language;;", language.AmericanEnglish},
+	}
+	for _, tt := range tests {
+		if got := localeFromContext(acceptLanguageContext(tt.header)); got != tt.want {
+			t.Errorf("accept-language %q: locale = %s, want %s", tt.header, got, tt.want)
+		}
+	}
+	if got := localeFromContext(context.Background()); got != language.AmericanEnglish {
+		t.Errorf("without metadata: locale = %s, want en-US", got)
+	}
+}
+
+func TestLocalize(t *testing.T) {
+	ctx := acceptLanguageContext("pt-BR")
+	if got := localize(ctx, "Logged out"); got != "Sessão encerrada" {
+		t.Errorf("localize() = %q", got)
+	}
+	// Messages missing from the catalog stay in English
+	if got := localize(ctx, "not in the catalog"); got != "not in the catalog" {
+		t.Errorf("localize() of an unknown message = %q", got)
+	}
+	if got := localize(context.Background(), "Logged out"); got != "Logged out" {
+		t.Errorf("localize() in English = %q", got)
+	}
+}
+
+func TestLocalizeError(t *testing.T) {
+	ctx := acceptLanguageContext("pt-BR")
+	st := status.Convert(localizeError(ctx, badRequest("id", "Book ID is required")))
+	if st.Code() != codes.InvalidArgument || st.Message() != "O ID do livro é obrigatório" {
+		t.Fatalf("status = %v", st)
+	}
+	var violation string
+	var localized *errdetails.LocalizedMessage
+	for _, d := range st.Details() {
+		switch d := d.(type) {
+		case *errdetails.BadRequest:
+			violation = d.GetFieldViolations()[0].GetDescription()
+		case *errdetails.LocalizedMessage:
+			localized = d
+		}
+	}
+	if violation != st.Message() {
+		t.Errorf("field violation = %q, want it translated", violation)
+	}
+	if localized.GetLocale() != "pt-BR" || localized.GetMessage() != st.Message() {
+		t.Errorf("localized message = %v", localized)
+	}
+
+	// An existing LocalizedMessage is translated rather than added to
+	st = status.Convert(localizeError(ctx, authError(codes.Unauthenticated, reasonInvalidCredentials, "Invalid username or password")))
+	var count int
+	for _, d := range st.Details() {
+		if d, ok := d.(*errdetails.LocalizedMessage); ok {
+			count++
+			if d.GetLocale() != "pt-BR" || d.GetMessage() != "Usuário ou senha inválidos" {
+				t.Errorf("localized message = %v", d)
+			}
+		}
+	}
+	if count != 1 {
+		t.Errorf("%d LocalizedMessage details, want 1", count)
+	}
+
+	unknown := status.Error(codes.Internal, "internal error: boom")
+	if err := localizeError(ctx, unknown); err != unknown {
+		t.Errorf("untranslated error became %v", err)
+	}
+	english := status.Error(codes.NotFound, "Book not found")
+	if err := localizeError(context.Background(), english); err != english {
+		t.Errorf("English error became %v", err)
+	}
+	if err := localizeError(ctx, nil); err != nil {
+		t.Errorf("localizeError(nil) = %v", err)
+	}
+}
+
+// TestMessageCatalogKeys checks that every message in the catalogs is still used by the server, so
+// reworded messages don't silently fall back to English
+func TestMessageCatalogKeys(t *testing.T) {
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, ".", func(fi fs.FileInfo) bool {
+		return !strings.HasSuffix(fi.Name(), "_test.go") && !strings.HasPrefix(fi.Name(), "messages_")
+	}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	literals := make(map[string]bool)
+	for _, pkg := range pkgs {
+		ast.Inspect(pkg, func(n ast.Node) bool {
+			if lit, ok := n.(*ast.BasicLit); ok && lit.Kind == token.STRING {
+				if s, err := strconv.Unquote(lit.Value); err == nil {
+					literals[s] = true
+				}
+			}
+			return true
+		})
+	}
+	for locale, catalog := range messageCatalog {
+		for message := range catalog {
+			if !literals[message] {
+				t.Errorf("%s catalog translates %q, which the server no longer uses", locale, message)
+			}
+		}
+	}
+}
@@ -0,0 +1,384 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"io"
+	"log/slog"
+	"strings"
+	"time"
+
+	pb "example/grpc_demo/library"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	spb "google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+const (
+	// idempotencyKeyHeader carries a client-chosen key, such as a UUID, identifying one logical request
+	// across the retries of it
+	idempotencyKeyHeader = "idempotency-key"
+	// maxIdempotencyKeyLength bounds the keys stored
+	maxIdempotencyKeyLength = 255
+	// idempotencyPurgeInterval is how often keys older than their TTL are deleted
+	idempotencyPurgeInterval = time.Hour
+	// idempotencyClaimTimeout is how long a call may stay in progress. A claim older than this was left by a
+	// replica that stopped during the call, and the next retry takes it over.
+	idempotencyClaimTimeout = 10 * time.Minute
+	// Streams sent with an idempotency key are read into memory before the handler runs, so their requests
+	// are limited in number and size
+	maxIdempotentStreamMessages = 10000
+	maxIdempotentStreamBytes    = 32 << 20
+)
+
+// idempotentMethods are the methods whose results are recorded under an idempotency key, so that a retry
+// after a network error returns the original result instead of AlreadyExists, NotFound or a second insert.
+// Client-streaming methods map to their request message, as the interceptor reads the requests itself.
+var idempotentMethods = map[string]func() proto.Message{
+	pb.LibraryService_AddBook_FullMethodName:       nil,
+	pb.LibraryService_DeleteBook_FullMethodName:    nil,
+	pb.LibraryService_BatchAddBooks_FullMethodName: func() proto.Message { return new(pb.Book) },
+}
+
+// idempotencyHeaders are the metadata headers that change what an idempotent call does; they are hashed
+// with its requests, so a retry that changes one is refused like a changed request
+var idempotencyHeaders = []string{batchAtomicHeader}
+
+// idempotencyScope identifies a key: keys are per user and method, so clients need not coordinate them
+type idempotencyScope struct {
+	userID int
+	method string
+	key    string
+}
+
+// idempotencyRecord is the stored call made earlier with a key
+type idempotencyRecord struct {
+	requestHash []byte
+	done        bool
+	// response is the google.protobuf.Any of the response and status the google.rpc.Status of the
+	// failure; one of them is set once the call is done
+	response []byte
+	status   []byte
+}
+
+// idempotencyStore records the calls made with an idempotency key
+type idempotencyStore interface {
+	// claim records a call as in progress and returns nil, or returns the earlier call made with the key
+	claim(ctx context.Context, scope idempotencyScope, requestHash []byte) (*idempotencyRecord, error)
+	// complete stores the result of a claimed call
+	complete(ctx context.Context, scope idempotencyScope, response, status []byte) error
+	// release forgets a claimed call, so that a retry runs it again
+	release(ctx context.Context, scope idempotencyScope) error
+}
+
+// idempotencyScopeOf returns the scope of a call to an idempotent method made with a key; ok is false for
+// other calls, which run as usual
+func idempotencyScopeOf(ctx context.Context, method string) (scope idempotencyScope, ok bool, err error) {
+	if _, idempotent := idempotentMethods[method]; !idempotent {
+		return scope, false, nil
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get(idempotencyKeyHeader)
+	if len(values) == 0 || strings.TrimSpace(values[0]) == "" {
+		return scope, false, nil
+	}
+	key := strings.TrimSpace(values[0])
+	if len(key) > maxIdempotencyKeyLength {
+		return scope, false, status.Errorf(codes.InvalidArgument, "Idempotency key must be at most %d characters", maxIdempotencyKeyLength)
+	}
+	userID, _, authenticated := userFromContext(ctx)
+	if !authenticated {
+		return scope, false, nil
+	}
+	return idempotencyScope{userID: userID, method: method, key: key}, true, nil
+}
+
+// requestHash identifies the requests of a call and its idempotencyHeaders, so a key reused for a different
+// request is refused
+func requestHash(ctx context.Context, requests ...proto.Message) ([]byte, error) {
+	h := sha256.New()
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, name := range idempotencyHeaders {
+		for _, value := range md.Get(name) {
+			h.Write([]byte(name + "=" + strings.ToLower(strings.TrimSpace(value)) + "\n"))
+		}
+	}
+	for _, req := range requests {
+		data, err := proto.MarshalOptions{Deterministic: true}.Marshal(req)
+		if err != nil {
+			return nil, err
+		}
+		h.Write(binary.AppendUvarint(nil, uint64(len(data))))
+		h.Write(data)
+	}
+	return h.Sum(nil), nil
+}
+
+// recordedFailure reports whether a failure is part of the call's result, replayed to retries. Failures
+// that may pass on their own, such as database errors and timeouts, are not recorded, so a retry runs
+// the call again.
+func recordedFailure(err error) bool {
+	switch status.Code(canonicalError(err)) {
+	case codes.InvalidArgument, codes.NotFound, codes.AlreadyExists, codes.FailedPrecondition, codes.OutOfRange,
+		codes.PermissionDenied, codes.Unimplemented:
+		return true
+	}
+	return false
+}
+
+// runIdempotent runs call once per key: a retry with the same key and requests gets the stored result
+func runIdempotent(ctx context.Context, store idempotencyStore, scope idempotencyScope, hash []byte, call func() (proto.Message, error)) (proto.Message, error) {
+	rec, err := store.claim(ctx, scope, hash)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "database error: %v", err)
+	}
+	if rec != nil {
+		return rec.replay(hash)
+	}
+
+	resp, callErr := call()
+	// The result is stored even when the client has gone, as that is when it retries
+	ctx = context.WithoutCancel(ctx)
+	if callErr == nil || recordedFailure(callErr) {
+		err := completeIdempotent(ctx, store, scope, resp, callErr)
+		if err == nil {
+			return resp, callErr
+		}
+		slog.ErrorContext(ctx, "failed to record idempotent result", "method", scope.method, "error", err)
+	}
+	// Without a stored result, a retry runs the call again rather than waiting for one
+	if err := store.release(ctx, scope); err != nil {
+		slog.ErrorContext(ctx, "failed to release idempotency key", "method", scope.method, "error", err)
+	}
+	return resp, callErr
+}
+
+// completeIdempotent stores the response of a call, or the status it failed with
+func completeIdempotent(ctx context.Context, store idempotencyStore, scope idempotencyScope, resp proto.Message, callErr error) error {
+	if callErr != nil {
+		data, err := proto.Marshal(status.Convert(callErr).Proto())
+		if err != nil {
+			return err
+		}
+		return store.complete(ctx, scope, nil, data)
+	}
+	if resp == nil {
+		return errors.New("call returned no response")
+	}
+	response, err := anypb.New(resp)
+	if err != nil {
+		return err
+	}
+	data, err := proto.Marshal(response)
+	if err != nil {
+		return err
+	}
+	return store.complete(ctx, scope, data, nil)
+}
+
+// replay returns the result of the earlier call, or why it can't
+func (rec *idempotencyRecord) replay(hash []byte) (proto.Message, error) {
+	if !bytes.Equal(rec.requestHash, hash) {
+		return nil, status.Error(codes.InvalidArgument, "Idempotency key was already used for a different request")
+	}
+	if !rec.done {
+		return nil, status.Error(codes.Aborted, "A request with this idempotency key is still in progress")
+	}
+	if rec.status != nil {
+		st := &spb.Status{}
+		if err := proto.Unmarshal(rec.status, st); err != nil {
+			return nil, status.Errorf(codes.Internal, "invalid stored result: %v", err)
+		}
+		return nil, status.ErrorProto(st)
+	}
+	response := &anypb.Any{}
+	if err := proto.Unmarshal(rec.response, response); err != nil {
+		return nil, status.Errorf(codes.Internal, "invalid stored result: %v", err)
+	}
+	resp, err := response.UnmarshalNew()
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "invalid stored result: %v", err)
+	}
+	return resp, nil
+}
+
+// CreateIdempotencyInterceptor replays the result of unary calls retried with the same idempotency key.
+// It must run after authentication, as keys are per user.
+func CreateIdempotencyInterceptor(store idempotencyStore) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		scope, ok, err := idempotencyScopeOf(ctx, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+		msg, isMsg := req.(proto.Message)
+		if !ok || !isMsg {
+			return handler(ctx, req)
+		}
+		hash, err := requestHash(ctx, msg)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to hash request: %v", err)
+		}
+		return runIdempotent(ctx, store, scope, hash, func() (proto.Message, error) {
+			resp, err := handler(ctx, req)
+			msg, _ := resp.(proto.Message)
+			return msg, err
+		})
+	}
+}
+
+// CreateStreamIdempotencyInterceptor is CreateIdempotencyInterceptor for client-streaming calls. Their
+// requests are read before the handler runs, since the call is only known once the client has sent them all.
+func CreateStreamIdempotencyInterceptor(store idempotencyStore) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		scope, ok, err := idempotencyScopeOf(ss.Context(), info.FullMethod)
+		if err != nil {
+			return err
+		}
+		newRequest := idempotentMethods[info.FullMethod]
+		if !ok || newRequest == nil || info.IsServerStream {
+			return handler(srv, ss)
+		}
+		var requests []proto.Message
+		size := 0
+		for {
+			req := newRequest()
+			err := ss.RecvMsg(req)
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return err
+			}
+			requests = append(requests, req)
+			size += proto.Size(req)
+			if len(requests) > maxIdempotentStreamMessages || size > maxIdempotentStreamBytes {
+				return status.Errorf(codes.ResourceExhausted, "Calls with an idempotency key may send at most %d messages and %d MiB; split the stream into several calls",
+					maxIdempotentStreamMessages, maxIdempotentStreamBytes>>20)
+			}
+		}
+		hash, err := requestHash(ss.Context(), requests...)
+		if err != nil {
+			return status.Errorf(codes.Internal, "failed to hash request: %v", err)
+		}
+		stream := &replayedServerStream{ServerStream: ss, requests: requests}
+		ran := false
+		resp, err := runIdempotent(ss.Context(), store, scope, hash, func() (proto.Message, error) {
+			ran = true
+			err := handler(srv, stream)
+			return stream.response, err
+		})
+		if err != nil || ran {
+			return err
+		}
+		// The response stored by the earlier call
+		return ss.SendMsg(resp)
+	}
+}
+
+// replayedServerStream hands the handler requests read beforehand, and keeps the response it sends
+type replayedServerStream struct {
+	grpc.ServerStream
+	requests []proto.Message
+	response proto.Message
+}
+
+func (s *replayedServerStream) RecvMsg(m interface{}) error {
+	if len(s.requests) == 0 {
+		return io.EOF
+	}
+	msg, ok := m.(proto.Message)
+	if !ok {
+		return status.Errorf(codes.Internal, "unexpected request type %T", m)
+	}
+	proto.Reset(msg)
+	proto.Merge(msg, s.requests[0])
+	s.requests = s.requests[1:]
+	return nil
+}
+
+func (s *replayedServerStream) SendMsg(m interface{}) error {
+	if msg, ok := m.(proto.Message); ok {
+		s.response = msg
+	}
+	return s.ServerStream.SendMsg(m)
+}
+
+// pgIdempotencyStore keeps idempotency keys in the idempotency_keys table for ttl
+type pgIdempotencyStore struct {
+	db  *pgxpool.Pool
+	ttl time.Duration
+}
+
+func (s *pgIdempotencyStore) claim(ctx context.Context, scope idempotencyScope, requestHash []byte) (*idempotencyRecord, error) {
+	// A key older than the TTL is free to reuse, and a call in progress for longer than idempotencyClaimTimeout
+	// is run again, as the replica running it stopped. A retry changing the request is still refused.
+	var claimed bool
+	err := s.db.QueryRow(ctx,
+		`INSERT INTO idempotency_keys (user_id, method, key, request_hash) VALUES ($1, $2, $3, $4)
+		 ON CONFLICT (user_id, method, key) DO UPDATE
+		 SET request_hash = EXCLUDED.request_hash, response = NULL, status = NULL, completed_at = NULL, created_at = NOW()
+		 WHERE idempotency_keys.created_at < NOW() - make_interval(secs => $5)
+		    OR (idempotency_keys.completed_at IS NULL AND idempotency_keys.request_hash = EXCLUDED.request_hash
+		        AND idempotency_keys.created_at < NOW() - make_interval(secs => $6))
+		 RETURNING true`,
+		scope.userID, scope.method, scope.key, requestHash, s.ttl.Seconds(), idempotencyClaimTimeout.Seconds()).Scan(&claimed)
+	if err == nil {
+		return nil, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return nil, err
+	}
+	rec := &idempotencyRecord{}
+	err = s.db.QueryRow(ctx,
+		`SELECT request_hash, completed_at IS NOT NULL, response, status FROM idempotency_keys
+		 WHERE user_id = $1 AND method = $2 AND key = $3`,
+		scope.userID, scope.method, scope.key).Scan(&rec.requestHash, &rec.done, &rec.response, &rec.status)
+	if err != nil {
+		return nil, err
+	}
+	return rec, nil
+}
+
+func (s *pgIdempotencyStore) complete(ctx context.Context, scope idempotencyScope, response, status []byte) error {
+	_, err := s.db.Exec(ctx,
+		`UPDATE idempotency_keys SET response = $4, status = $5, completed_at = NOW()
+		 WHERE user_id = $1 AND method = $2 AND key = $3`,
+		scope.userID, scope.method, scope.key, response, status)
+	return err
+}
+
+func (s *pgIdempotencyStore) release(ctx context.Context, scope idempotencyScope) error {
+	_, err := s.db.Exec(ctx, "DELETE FROM idempotency_keys WHERE user_id = $1 AND method = $2 AND key = $3",
+		scope.userID, scope.method, scope.key)
+	return err
+}
+
+// runPurge deletes keys older than the TTL every interval until ctx is cancelled
+func (s *pgIdempotencyStore) runPurge(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		err := forEachTenant(ctx, s.db, func(ctx context.Context) error {
+			_, err := s.db.Exec(ctx, "DELETE FROM idempotency_keys WHERE created_at < NOW() - make_interval(secs => $1)", s.ttl.Seconds())
+			return err
+		})
+		if err != nil {
+			slog.ErrorContext(ctx, "idempotency key purge failed", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
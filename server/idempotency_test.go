@@ -0,0 +1,271 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	pb "example/grpc_demo/library"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// memoryIdempotencyStore is an idempotencyStore without expiry
+type memoryIdempotencyStore struct {
+	mu      sync.Mutex
+	records map[idempotencyScope]*idempotencyRecord
+}
+
+func newMemoryIdempotencyStore() *memoryIdempotencyStore {
+	return &memoryIdempotencyStore{records: map[idempotencyScope]*idempotencyRecord{}}
+}
+
+func (m *memoryIdempotencyStore) claim(ctx context.Context, scope idempotencyScope, requestHash []byte) (*idempotencyRecord, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if rec, ok := m.records[scope]; ok {
+		return rec, nil
+	}
+	m.records[scope] = &idempotencyRecord{requestHash: requestHash}
+	return nil, nil
+}
+
+func (m *memoryIdempotencyStore) complete(ctx context.Context, scope idempotencyScope, response, status []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	rec := m.records[scope]
+	rec.done, rec.response, rec.status = true, response, status
+	return nil
+}
+
+func (m *memoryIdempotencyStore) release(ctx context.Context, scope idempotencyScope) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.records, scope)
+	return nil
+}
+
+// idempotentContext is the context of a call by user 1 with the idempotency key key, none if empty
+func idempotentContext(key string) context.Context {
+	ctx := context.WithValue(context.Background(), userIDKey, 1)
+	if key == "" {
+		return ctx
+	}
+	return metadata.NewIncomingContext(ctx, metadata.Pairs(idempotencyKeyHeader, key))
+}
+
+func TestIdempotencyInterceptor(t *testing.T) {
+	store := newMemoryIdempotencyStore()
+	interceptor := CreateIdempotencyInterceptor(store)
+	addBook := &grpc.UnaryServerInfo{FullMethod: pb.LibraryService_AddBook_FullMethodName}
+	calls := 0
+	added := map[string]bool{}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		calls++
+		id := req.(*pb.Book).GetId()
+		if added[id] {
+			return nil, alreadyExists(resourceBook, id, "Book already exists")
+		}
+		added[id] = true
+		return &pb.BookResponse{Id: id}, nil
+	}
+	call := func(key string, book *pb.Book) (interface{}, error) {
+		return interceptor(idempotentContext(key), book, addBook, handler)
+	}
+
+	book := &pb.Book{Id: "b1", Title: "Go"}
+	if _, err := call("k1", book); err != nil {
+		t.Fatalf("first call: %v", err)
+	}
+	// The retry gets the original response instead of AlreadyExists
+	resp, err := call("k1", book)
+	if err != nil || !proto.Equal(resp.(proto.Message), &pb.BookResponse{Id: "b1"}) {
+		t.Errorf("retry = %v, %v; want the original response", resp, err)
+	}
+	if calls != 1 {
+		t.Errorf("handler ran %d times, want 1", calls)
+	}
+
+	if _, err := call("k1", &pb.Book{Id: "b2"}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("key reused for another book: error = %v, want InvalidArgument", err)
+	}
+	// Without a key every call runs
+	if _, err := call("", book); status.Code(err) != codes.AlreadyExists {
+		t.Errorf("call without a key: error = %v, want AlreadyExists", err)
+	}
+
+	// Failures that are part of the result are replayed with their details
+	if _, err := call("k2", book); status.Code(err) != codes.AlreadyExists {
+		t.Fatalf("error = %v, want AlreadyExists", err)
+	}
+	calls = 0
+	_, err = call("k2", book)
+	if status.Code(err) != codes.AlreadyExists || len(status.Convert(err).Details()) != 1 || calls != 0 {
+		t.Errorf("replayed failure = %v (%d handler calls), want the original AlreadyExists", err, calls)
+	}
+}
+
+func TestIdempotencyTransientFailure(t *testing.T) {
+	store := newMemoryIdempotencyStore()
+	deleteBook := &grpc.UnaryServerInfo{FullMethod: pb.LibraryService_DeleteBook_FullMethodName}
+	failures := 1
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		if failures > 0 {
+			failures--
+			return nil, status.Error(codes.Internal, "database error: connection reset")
+		}
+		return &pb.BookResponse{Id: "b1"}, nil
+	}
+	ctx := idempotentContext("k1")
+	if _, err := CreateIdempotencyInterceptor(store)(ctx, &pb.BookRequest{Id: "b1"}, deleteBook, handler); status.Code(err) != codes.Internal {
+		t.Fatalf("error = %v, want Internal", err)
+	}
+	// A database error is not the call's result: the retry runs it again
+	if _, err := CreateIdempotencyInterceptor(store)(ctx, &pb.BookRequest{Id: "b1"}, deleteBook, handler); err != nil {
+		t.Errorf("retry error = %v", err)
+	}
+}
+
+func TestIdempotencyInProgress(t *testing.T) {
+	store := newMemoryIdempotencyStore()
+	deleteBook := &grpc.UnaryServerInfo{FullMethod: pb.LibraryService_DeleteBook_FullMethodName}
+	req := &pb.BookRequest{Id: "b1"}
+	var retryErr error
+	CreateIdempotencyInterceptor(store)(idempotentContext("k1"), req, deleteBook, func(ctx context.Context, _ interface{}) (interface{}, error) {
+		_, retryErr = CreateIdempotencyInterceptor(store)(idempotentContext("k1"), req, deleteBook, func(context.Context, interface{}) (interface{}, error) {
+			t.Error("the retry ran while the first call was in progress")
+			return nil, nil
+		})
+		return &pb.BookResponse{Id: "b1"}, nil
+	})
+	if status.Code(retryErr) != codes.Aborted {
+		t.Errorf("retry during the call: error = %v, want Aborted", retryErr)
+	}
+}
+
+func TestIdempotencyKeyTooLong(t *testing.T) {
+	long := make([]byte, maxIdempotencyKeyLength+1)
+	for i := range long {
+		long[i] = 'k'
+	}
+	_, _, err := idempotencyScopeOf(idempotentContext(string(long)), pb.LibraryService_AddBook_FullMethodName)
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("error = %v, want InvalidArgument", err)
+	}
+}
+
+// batchStream is a BatchAddBooks stream receiving books and keeping the response sent
+type batchStream struct {
+	grpc.ServerStream
+	ctx   context.Context
+	books []*pb.Book
+	sent  []proto.Message
+}
+
+func (s *batchStream) Context() context.Context { return s.ctx }
+
+func (s *batchStream) RecvMsg(m interface{}) error {
+	if len(s.books) == 0 {
+		return io.EOF
+	}
+	proto.Merge(m.(proto.Message), s.books[0])
+	s.books = s.books[1:]
+	return nil
+}
+
+func (s *batchStream) SendMsg(m interface{}) error {
+	s.sent = append(s.sent, m.(proto.Message))
+	return nil
+}
+
+func TestStreamIdempotencyInterceptor(t *testing.T) {
+	store := newMemoryIdempotencyStore()
+	interceptor := CreateStreamIdempotencyInterceptor(store)
+	info := &grpc.StreamServerInfo{FullMethod: pb.LibraryService_BatchAddBooks_FullMethodName, IsClientStream: true}
+	calls := 0
+	handler := func(srv interface{}, ss grpc.ServerStream) error {
+		calls++
+		resp := &pb.BatchResponse{}
+		for {
+			book := &pb.Book{}
+			if err := ss.RecvMsg(book); err == io.EOF {
+				break
+			}
+			resp.Responses = append(resp.Responses, &pb.BookResponse{Id: book.GetId()})
+		}
+		return ss.SendMsg(resp)
+	}
+	batch := func(ids ...string) *batchStream {
+		stream := &batchStream{ctx: idempotentContext("k1")}
+		for _, id := range ids {
+			stream.books = append(stream.books, &pb.Book{Id: id})
+		}
+		return stream
+	}
+
+	first := batch("b1", "b2")
+	if err := interceptor(nil, first, info, handler); err != nil {
+		t.Fatalf("first batch: %v", err)
+	}
+	retry := batch("b1", "b2")
+	if err := interceptor(nil, retry, info, handler); err != nil {
+		t.Fatalf("retried batch: %v", err)
+	}
+	if calls != 1 || len(retry.sent) != 1 || !proto.Equal(retry.sent[0], first.sent[0]) {
+		t.Errorf("retry sent %v after %d handler calls, want the original %v", retry.sent, calls, first.sent)
+	}
+	if err := interceptor(nil, batch("b1"), info, handler); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("key reused for another batch: error = %v, want InvalidArgument", err)
+	}
+	atomic := batch("b1", "b2")
+	atomic.ctx = metadata.NewIncomingContext(atomic.ctx, metadata.Pairs(idempotencyKeyHeader, "k1", batchAtomicHeader, "true"))
+	if err := interceptor(nil, atomic, info, handler); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("key reused with %s: error = %v, want InvalidArgument", batchAtomicHeader, err)
+	}
+
+	ids := make([]string, maxIdempotentStreamMessages+1)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("b%d", i)
+	}
+	large := batch(ids...)
+	large.ctx = idempotentContext("k2")
+	if err := interceptor(nil, large, info, handler); status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("batch of %d books: error = %v, want ResourceExhausted", len(ids), err)
+	}
+	if calls != 1 {
+		t.Errorf("handler called %d times, want 1", calls)
+	}
+}
+
+// TestPgIdempotencyStoreStaleClaim runs against the database of STORAGE_TEST_DATABASE_URL
+func TestPgIdempotencyStoreStaleClaim(t *testing.T) {
+	pool := testDatabase(t, "idempotency-test-")
+	ctx := testUser(t, pool, "idempotency-test-alice")
+	store := &pgIdempotencyStore{db: pool, ttl: 24 * time.Hour}
+	scope := idempotencyScope{userID: ctx.Value(userIDKey).(int), method: pb.LibraryService_AddBook_FullMethodName, key: "k1"}
+
+	if rec, err := store.claim(ctx, scope, []byte("a")); err != nil || rec != nil {
+		t.Fatalf("first claim = %v, %v, want a new claim", rec, err)
+	}
+	if rec, err := store.claim(ctx, scope, []byte("a")); err != nil || rec == nil || rec.done {
+		t.Fatalf("claim during the call = %v, %v, want the call in progress", rec, err)
+	}
+	_, err := pool.Exec(ctx, "UPDATE idempotency_keys SET created_at = NOW() - make_interval(secs => $2) WHERE user_id = $1",
+		scope.userID, (idempotencyClaimTimeout + time.Minute).Seconds())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec, err := store.claim(ctx, scope, []byte("b")); err != nil || rec == nil || rec.done {
+		t.Errorf("stale claim with another request = %v, %v, want the call in progress", rec, err)
+	}
+	if rec, err := store.claim(ctx, scope, []byte("a")); err != nil || rec != nil {
+		t.Errorf("stale claim = %v, %v, want it taken over", rec, err)
+	}
+}
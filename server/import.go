@@ -0,0 +1,165 @@
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	pb "example/grpc_demo/library"
+	"example/grpc_demo/server/storage"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// isCSVHeader reports whether a CSV line is the header row written by ExportBooks
+func isCSVHeader(line string) bool {
+	fields, err := csv.NewReader(strings.NewReader(line)).Read()
+	return err == nil && len(fields) > 0 && strings.EqualFold(strings.TrimSpace(fields[0]), bookCSVHeader[0])
+}
+
+// parseImportLine decodes a single CSV row or JSON object into a book
+func parseImportLine(format pb.ExportFormat, line string) (*pb.Book, error) {
+	switch format {
+	case pb.ExportFormat_EXPORT_FORMAT_CSV:
+		fields, err := csv.NewReader(strings.NewReader(line)).Read()
+		if err != nil {
+			return nil, fmt.Errorf("invalid CSV row: %v", err)
+		}
+		if len(fields) < 3 || len(fields) > len(bookCSVHeader) {
+			return nil, fmt.Errorf("expected %d columns (%s), got %d", len(bookCSVHeader), strings.Join(bookCSVHeader, ","), len(fields))
+		}
+		book := &pb.Book{
+			Id:     strings.TrimSpace(fields[0]),
+			Title:  strings.TrimSpace(fields[1]),
+			Author: strings.TrimSpace(fields[2]),
+		}
+		if len(fields) > 3 {
+			book.Isbn = strings.TrimSpace(fields[3])
+		}
+		return book, nil
+	case pb.ExportFormat_EXPORT_FORMAT_JSON:
+		book := &pb.Book{}
+		if err := (protojson.UnmarshalOptions{DiscardUnknown: true}).Unmarshal([]byte(line), book); err != nil {
+			return nil, fmt.Errorf("invalid JSON object: %v", err)
+		}
+		return book, nil
+	default:
+		return nil, fmt.Errorf("unsupported import format: %v", format)
+	}
+}
+
+// validateImportedBook checks the fields an imported row must provide
+func validateImportedBook(book *pb.Book) error {
+	if book.GetId() == "" {
+		return errors.New("Book ID is required")
+	}
+	if book.GetTitle() == "" {
+		return errors.New("Book title is required")
+	}
+	if !validISBN(book.GetIsbn()) {
+		return errors.New("Invalid ISBN")
+	}
+	if msg := validateSeries(book.GetSeries()); msg != "" {
+		return errors.New(msg)
+	}
+	if msg := validateLocation(book.GetLocation()); msg != "" {
+		return errors.New(msg)
+	}
+	if msg := validatePublisher(book); msg != "" {
+		return errors.New(msg)
+	}
+	return nil
+}
+
+func (s *server) ImportBooks(stream pb.LibraryService_ImportBooksServer) error {
+	resp := &pb.ImportResponse{}
+	ctx := stream.Context()
+	format := pb.ExportFormat_EXPORT_FORMAT_UNSPECIFIED
+	seenISBNs := make(map[string]bool)
+	sawRecord := false
+	var lineNumber int32
+
+	fail := func(id, reason string) {
+		resp.Failed++
+		resp.Failures = append(resp.Failures, &pb.ImportFailure{LineNumber: lineNumber, Id: id, Reason: reason})
+	}
+
+	for {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			return stream.SendAndClose(resp)
+		}
+		if err != nil {
+			return status.Errorf(codes.Internal, "failed to receive import line: %v", err)
+		}
+		lineNumber++
+
+		if format == pb.ExportFormat_EXPORT_FORMAT_UNSPECIFIED {
+			format = req.GetFormat()
+			if format == pb.ExportFormat_EXPORT_FORMAT_UNSPECIFIED {
+				format = pb.ExportFormat_EXPORT_FORMAT_CSV
+			}
+		}
+
+		line := strings.TrimSpace(req.GetLine())
+		if line == "" {
+			continue
+		}
+		firstRecord := !sawRecord
+		sawRecord = true
+		if firstRecord && format == pb.ExportFormat_EXPORT_FORMAT_CSV && isCSVHeader(line) {
+			continue
+		}
+
+		book, err := parseImportLine(format, line)
+		if err != nil {
+			fail("", err.Error())
+			continue
+		}
+		if err := validateImportedBook(book); err != nil {
+			fail(book.GetId(), err.Error())
+			continue
+		}
+
+		// Deduplicate by ISBN, both within this import and against the catalog
+		if isbn := normalizeISBN(book.GetIsbn()); isbn != "" {
+			if seenISBNs[isbn] {
+				resp.Skipped++
+				continue
+			}
+			seenISBNs[isbn] = true
+			existing, err := s.books.FindByISBN(ctx, isbn)
+			if err != nil {
+				fail(book.GetId(), "Database error")
+				continue
+			}
+			if existing != "" {
+				resp.Skipped++
+				continue
+			}
+		}
+
+		err = s.insertBook(ctx, book)
+		if errors.Is(err, storage.ErrAlreadyExists) {
+			fail(book.GetId(), "Book already exists")
+			continue
+		}
+		if errors.Is(err, storage.ErrPublisherNotFound) {
+			fail(book.GetId(), "Publisher not found")
+			continue
+		}
+		if st, ok := quotaStatus(err); ok {
+			fail(book.GetId(), status.Convert(st).Message())
+			continue
+		}
+		if err != nil {
+			fail(book.GetId(), "Failed to add book")
+			continue
+		}
+		resp.Inserted++
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"testing"
+
+	pb "example/grpc_demo/library"
+)
+
+func TestParseImportLine(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  pb.ExportFormat
+		line    string
+		want    *pb.Book
+		wantErr bool
+	}{
+		{
+			name:   "CSV without ISBN",
+			format: pb.ExportFormat_EXPORT_FORMAT_CSV,
+			line:   "book1,Go Programming,John Doe",
+			want:   &pb.Book{Id: "book1", Title: "Go Programming", Author: "John Doe"},
+		},
+		{
+			name:   "CSV with quoted title and ISBN",
+			format: pb.ExportFormat_EXPORT_FORMAT_CSV,
+			line:   `book2,"Go, Advanced",Jane Doe,9780306406157`,
+			want:   &pb.Book{Id: "book2", Title: "Go, Advanced", Author: "Jane Doe", Isbn: "9780306406157"},
+		},
+		{
+			name:    "CSV with too few columns",
+			format:  pb.ExportFormat_EXPORT_FORMAT_CSV,
+			line:    "book3,Only Title",
+			wantErr: true,
+		},
+		{
+			name:   "JSON object",
+			format: pb.ExportFormat_EXPORT_FORMAT_JSON,
+			line:   `{"id":"book4","title":"JSON Book","author":"Ann","extra":true}`,
+			want:   &pb.Book{Id: "book4", Title: "JSON Book", Author: "Ann"},
+		},
+		{
+			name:    "Malformed JSON",
+			format:  pb.ExportFormat_EXPORT_FORMAT_JSON,
+			line:    `{"id":`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseImportLine(tt.format, tt.line)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseImportLine() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got.GetId() != tt.want.GetId() || got.GetTitle() != tt.want.GetTitle() ||
+				got.GetAuthor() != tt.want.GetAuthor() || got.GetIsbn() != tt.want.GetIsbn() {
+				t.Errorf("parseImportLine() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsCSVHeader(t *testing.T) {
+	if !isCSVHeader("id,title,author,isbn") {
+		t.Error("isCSVHeader() should accept the export header")
+	}
+	if isCSVHeader("book1,Go Programming,John Doe") {
+		t.Error("isCSVHeader() should reject a data row")
+	}
+}
+
+func TestValidateImportedBook(t *testing.T) {
+	if err := validateImportedBook(&pb.Book{Id: "book1", Title: "Title"}); err != nil {
+		t.Errorf("validateImportedBook() unexpected error: %v", err)
+	}
+	if err := validateImportedBook(&pb.Book{Title: "Title"}); err == nil {
+		t.Error("validateImportedBook() should require an ID")
+	}
+	if err := validateImportedBook(&pb.Book{Id: "book1", Title: "Title", Isbn: "123"}); err == nil {
+		t.Error("validateImportedBook() should reject an invalid ISBN")
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+import "strings"
+
+// normalizeISBN strips the hyphens and spaces commonly used when printing ISBNs
+func normalizeISBN(isbn string) string {
+	return strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(isbn))
+}
+
+// validISBN reports whether isbn is empty or a well-formed ISBN-10/ISBN-13 with a valid check digit
+func validISBN(isbn string) bool {
+	isbn = normalizeISBN(isbn)
+	switch len(isbn) {
+	case 0:
+		return true
+	case 10:
+		sum := 0
+		for i, c := range isbn {
+			var digit int
+			switch {
+			case c >= '0' && c <= '9':
+				digit = int(c - '0')
+			case c == 'X' && i == 9:
+				digit = 10
+			default:
+				return false
+			}
+			sum += digit * (10 - i)
+		}
+		return sum%11 == 0
+	case 13:
+		sum := 0
+		for i, c := range isbn {
+			if c < '0' || c > '9' {
+				return false
+			}
+			digit := int(c - '0')
+			if i%2 == 1 {
+				digit *= 3
+			}
+			sum += digit
+		}
+		return sum%10 == 0
+	default:
+		return false
+	}
+}
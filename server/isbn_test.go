@@ -0,0 +1,39 @@
+package main
+
+import "testing"
+
+func TestValidISBN(t *testing.T) {
+	tests := []struct {
+		isbn string
+		want bool
+	}{
+		{"", true},
+		{"0-306-40615-2", true},
+		{"0306406152", true},
+		{"080442957X", true},
+		{"978-0-306-40615-7", true},
+		{"9780306406157", true},
+		{"0306406153", false},
+		{"9780306406158", false},
+		{"978030640615", false},
+		{"97803064061X7", false},
+		{"not-an-isbn", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.isbn, func(t *testing.T) {
+			if got := validISBN(tt.isbn); got != tt.want {
+				t.Errorf("validISBN(%q) = %v, want %v", tt.isbn, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeISBN(t *testing.T) {
+	if got := normalizeISBN("978-0 306-40615-7"); got != "9780306406157" {
+		t.Errorf("normalizeISBN() = %q, want %q", got, "9780306406157")
+	}
+	if got := normalizeISBN("080442957x"); got != "080442957X" {
+		t.Errorf("normalizeISBN() = %q, want %q", got, "080442957X")
+	}
+}
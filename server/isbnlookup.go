@@ -0,0 +1,207 @@
+package main
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	pb "example/grpc_demo/library"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	isbnLookupTimeout     = 5 * time.Second
+	isbnCacheTTL          = 24 * time.Hour
+	isbnCacheSize         = 10000
+	defaultOpenLibraryURL = "https://openlibrary.org"
+)
+
+// errISBNNotFound is returned by providers that have no record for an ISBN
+var errISBNNotFound = errors.New("no metadata found for ISBN")
+
+// BookMetadataProvider looks up catalog metadata (title, author, publisher) by ISBN
+type BookMetadataProvider interface {
+	LookupISBN(ctx context.Context, isbn string) (*pb.Book, error)
+}
+
+// openLibraryProvider queries the OpenLibrary books API
+type openLibraryProvider struct {
+	client  *http.Client
+	baseURL string
+}
+
+// newOpenLibraryProvider creates a provider querying the OpenLibrary API at baseURL
+func newOpenLibraryProvider(baseURL string) *openLibraryProvider {
+	return &openLibraryProvider{
+		client:  &http.Client{Timeout: isbnLookupTimeout},
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+	}
+}
+
+// openLibraryBook is the subset of the OpenLibrary "jscmd=data" response we use
+type openLibraryBook struct {
+	Title   string `json:"title"`
+	Authors []struct {
+		Name string `json:"name"`
+	} `json:"authors"`
+	Publishers []struct {
+		Name string `json:"name"`
+	} `json:"publishers"`
+}
+
+func (p *openLibraryProvider) LookupISBN(ctx context.Context, isbn string) (*pb.Book, error) {
+	key := "ISBN:" + isbn
+	query := url.Values{"bibkeys": {key}, "format": {"json"}, "jscmd": {"data"}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.baseURL+"/api/books?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("metadata lookup returned HTTP %d", resp.StatusCode)
+	}
+
+	var result map[string]openLibraryBook
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode metadata response: %w", err)
+	}
+	data, ok := result[key]
+	if !ok {
+		return nil, errISBNNotFound
+	}
+
+	book := &pb.Book{Isbn: isbn, Title: data.Title}
+	var authors []string
+	for _, a := range data.Authors {
+		authors = append(authors, a.Name)
+	}
+	book.Author = strings.Join(authors, ", ")
+	if len(data.Publishers) > 0 {
+		book.Publisher = data.Publishers[0].Name
+	}
+	return book, nil
+}
+
+// cachingMetadataProvider memoizes lookups (including misses) for a fixed TTL, keeping at most size of
+// them: the least recently used is evicted to make room for a new one
+type cachingMetadataProvider struct {
+	next    BookMetadataProvider
+	ttl     time.Duration
+	size    int
+	now     func() time.Time
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	// recent holds the *metadataCacheEntry values, most recently used first
+	recent *list.List
+}
+
+type metadataCacheEntry struct {
+	isbn    string
+	book    *pb.Book
+	err     error
+	expires time.Time
+}
+
+func newCachingMetadataProvider(next BookMetadataProvider, ttl time.Duration, size int) *cachingMetadataProvider {
+	return &cachingMetadataProvider{
+		next:    next,
+		ttl:     ttl,
+		size:    size,
+		now:     time.Now,
+		entries: make(map[string]*list.Element),
+		recent:  list.New(),
+	}
+}
+
+func (c *cachingMetadataProvider) LookupISBN(ctx context.Context, isbn string) (*pb.Book, error) {
+	if entry, ok := c.get(isbn); ok {
+		if entry.err != nil {
+			return nil, entry.err
+		}
+		return proto.Clone(entry.book).(*pb.Book), nil
+	}
+
+	book, err := c.next.LookupISBN(ctx, isbn)
+	// Only definitive answers are cached; transient failures are retried next time
+	if err != nil && !errors.Is(err, errISBNNotFound) {
+		return nil, err
+	}
+
+	c.put(&metadataCacheEntry{isbn: isbn, book: book, err: err, expires: c.now().Add(c.ttl)})
+	if err != nil {
+		return nil, err
+	}
+	return proto.Clone(book).(*pb.Book), nil
+}
+
+// get returns the unexpired entry of isbn, dropping an expired one
+func (c *cachingMetadataProvider) get(isbn string) (*metadataCacheEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[isbn]
+	if !ok {
+		return nil, false
+	}
+	entry := e.Value.(*metadataCacheEntry)
+	if !c.now().Before(entry.expires) {
+		c.recent.Remove(e)
+		delete(c.entries, isbn)
+		return nil, false
+	}
+	c.recent.MoveToFront(e)
+	return entry, true
+}
+
+// put caches entry, evicting the least recently used one when the cache is full
+func (c *cachingMetadataProvider) put(entry *metadataCacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.entries[entry.isbn]; ok {
+		e.Value = entry
+		c.recent.MoveToFront(e)
+		return
+	}
+	c.entries[entry.isbn] = c.recent.PushFront(entry)
+	if c.recent.Len() > c.size {
+		oldest := c.recent.Back()
+		c.recent.Remove(oldest)
+		delete(c.entries, oldest.Value.(*metadataCacheEntry).isbn)
+	}
+}
+
+func (s *server) LookupByISBN(ctx context.Context, req *pb.IsbnRequest) (*pb.Book, error) {
+	isbn := normalizeISBN(req.GetIsbn())
+	if isbn == "" {
+		return nil, status.Error(codes.InvalidArgument, "ISBN is required")
+	}
+	if !validISBN(isbn) {
+		return nil, status.Error(codes.InvalidArgument, "Invalid ISBN")
+	}
+	if s.bookMetadata == nil {
+		return nil, status.Error(codes.Unimplemented, "ISBN lookup is not configured")
+	}
+
+	book, err := s.bookMetadata.LookupISBN(ctx, isbn)
+	if errors.Is(err, errISBNNotFound) {
+		return nil, notFound(resourceBook, isbn, fmt.Sprintf("no metadata found for ISBN %s", isbn))
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.Unavailable, "metadata lookup failed: %v", err)
+	}
+	return book, nil
+}
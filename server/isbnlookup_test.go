@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	pb "example/grpc_demo/library"
+)
+
+func TestOpenLibraryProvider(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("bibkeys") == "ISBN:9780306406157" {
+			w.Write([]byte(`{"ISBN:9780306406157":{"title":"Go Programming","authors":[{"name":"John Doe"},{"name":"Jane Roe"}],"publishers":[{"name":"Example Press"}]}}`))
+			return
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer ts.Close()
+
+	p := &openLibraryProvider{client: ts.Client(), baseURL: ts.URL}
+
+	book, err := p.LookupISBN(context.Background(), "9780306406157")
+	if err != nil {
+		t.Fatalf("LookupISBN() error = %v", err)
+	}
+	if book.GetTitle() != "Go Programming" || book.GetAuthor() != "John Doe, Jane Roe" || book.GetPublisher() != "Example Press" {
+		t.Errorf("LookupISBN() = %v", book)
+	}
+
+	if _, err := p.LookupISBN(context.Background(), "0306406152"); !errors.Is(err, errISBNNotFound) {
+		t.Errorf("LookupISBN() for unknown ISBN error = %v, want errISBNNotFound", err)
+	}
+}
+
+// countingProvider counts lookups and fails with err when set
+type countingProvider struct {
+	calls int
+	err   error
+}
+
+func (p *countingProvider) LookupISBN(ctx context.Context, isbn string) (*pb.Book, error) {
+	p.calls++
+	if p.err != nil {
+		return nil, p.err
+	}
+	return &pb.Book{Isbn: isbn, Title: "Cached"}, nil
+}
+
+func TestCachingMetadataProvider(t *testing.T) {
+	now := time.Now()
+	next := &countingProvider{}
+	c := newCachingMetadataProvider(next, time.Hour, 10)
+	c.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		if _, err := c.LookupISBN(context.Background(), "9780306406157"); err != nil {
+			t.Fatalf("LookupISBN() error = %v", err)
+		}
+	}
+	if next.calls != 1 {
+		t.Errorf("provider called %d times, want 1", next.calls)
+	}
+
+	// Entries expire after the TTL
+	now = now.Add(2 * time.Hour)
+	if _, err := c.LookupISBN(context.Background(), "9780306406157"); err != nil {
+		t.Fatalf("LookupISBN() error = %v", err)
+	}
+	if next.calls != 2 {
+		t.Errorf("provider called %d times after expiry, want 2", next.calls)
+	}
+
+	// Transient errors are not cached
+	failing := &countingProvider{err: errors.New("timeout")}
+	c = newCachingMetadataProvider(failing, time.Hour, 10)
+	c.LookupISBN(context.Background(), "0306406152")
+	c.LookupISBN(context.Background(), "0306406152")
+	if failing.calls != 2 {
+		t.Errorf("failing provider called %d times, want 2", failing.calls)
+	}
+}
+
+func TestCachingMetadataProviderSize(t *testing.T) {
+	next := &countingProvider{}
+	c := newCachingMetadataProvider(next, time.Hour, 2)
+	ctx := context.Background()
+	c.LookupISBN(ctx, "9780306406157")
+	c.LookupISBN(ctx, "0306406152")
+	// Looking the first up again makes the second the least recently used, evicted by the third
+	c.LookupISBN(ctx, "9780306406157")
+	c.LookupISBN(ctx, "9780441172719")
+	if len(c.entries) != 2 || c.recent.Len() != 2 {
+		t.Errorf("cache holds %d entries (%d in recency order), want 2", len(c.entries), c.recent.Len())
+	}
+
+	next.calls = 0
+	c.LookupISBN(ctx, "9780306406157")
+	c.LookupISBN(ctx, "9780441172719")
+	if next.calls != 0 {
+		t.Errorf("provider called %d times for the entries kept, want 0", next.calls)
+	}
+	c.LookupISBN(ctx, "0306406152")
+	if next.calls != 1 {
+		t.Errorf("provider called %d times for the evicted entry, want 1", next.calls)
+	}
+
+	for i := range 100 {
+		c.LookupISBN(ctx, fmt.Sprintf("isbn-%d", i))
+	}
+	if len(c.entries) != 2 || c.recent.Len() != 2 {
+		t.Errorf("cache holds %d entries after 100 lookups, want 2", len(c.entries))
+	}
+}
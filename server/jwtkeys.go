@@ -0,0 +1,258 @@
+package main
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// minRSAKeyBits is the smallest RSA key accepted for signing or verifying access tokens
+const minRSAKeyBits = 2048
+
+// jwtKeys holds the asymmetric access token keys; nil keeps the HS256 shared secret
+var jwtKeys *jwtKeySet
+
+// jwtKeySet signs access tokens with one private key and verifies them with every active public key,
+// so the signing key can be rotated while tokens signed with the previous key are still live
+type jwtKeySet struct {
+	signingKID    string
+	signingKey    crypto.Signer
+	signingMethod jwt.SigningMethod
+	verification  map[string]jwtVerificationKey
+	// legacySecret, when set, keeps HS256 tokens issued before the switch to asymmetric keys valid
+	legacySecret []byte
+}
+
+// jwtVerificationKey is a public key and the algorithm tokens signed with it must use
+type jwtVerificationKey struct {
+	method jwt.SigningMethod
+	key    crypto.PublicKey
+}
+
+// loadJWTKeys loads the signing key of cfg (RSA or Ed25519, PEM) and its additional verification keys.
+// It returns nil when cfg has no private key, leaving tokens signed with the JWT secret. Otherwise HS256
+// tokens signed with the secret are still accepted, unless it is the development default.
+func loadJWTKeys(cfg AuthConfig) (*jwtKeySet, error) {
+	privateFile := cfg.JWTPrivateKeyFile
+	if privateFile == "" {
+		return nil, nil
+	}
+
+	pemData, err := os.ReadFile(privateFile)
+	if err != nil {
+		return nil, err
+	}
+	signer, err := parseJWTPrivateKey(pemData)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", privateFile, err)
+	}
+	keys := &jwtKeySet{verification: make(map[string]jwtVerificationKey)}
+	if keys.signingKID, err = keys.add(signer.Public()); err != nil {
+		return nil, fmt.Errorf("%s: %w", privateFile, err)
+	}
+	keys.signingKey = signer
+	keys.signingMethod = keys.verification[keys.signingKID].method
+
+	for _, file := range cfg.JWTVerificationKeyFiles {
+		pemData, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		if err := keys.addPEM(pemData); err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+	}
+	if cfg.JWTSecret != defaultJWTSecret {
+		keys.legacySecret = []byte(cfg.JWTSecret)
+	}
+	return keys, nil
+}
+
+// parseJWTPrivateKey reads a PKCS#8 or PKCS#1 private key
+func parseJWTPrivateKey(pemData []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(pemData)
+	if block == nil {
+		return nil, errors.New("no PEM private key found")
+	}
+	var (
+		key any
+		err error
+	)
+	switch block.Type {
+	case "PRIVATE KEY":
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		return k, nil
+	case ed25519.PrivateKey:
+		return k, nil
+	}
+	return nil, fmt.Errorf("unsupported private key type %T", key)
+}
+
+// addPEM adds every public key (or certificate) in pemData as a verification key
+func (s *jwtKeySet) addPEM(pemData []byte) error {
+	found := false
+	for {
+		var block *pem.Block
+		block, pemData = pem.Decode(pemData)
+		if block == nil {
+			break
+		}
+		var (
+			key any
+			err error
+		)
+		switch block.Type {
+		case "PUBLIC KEY":
+			key, err = x509.ParsePKIXPublicKey(block.Bytes)
+		case "RSA PUBLIC KEY":
+			key, err = x509.ParsePKCS1PublicKey(block.Bytes)
+		case "CERTIFICATE":
+			var cert *x509.Certificate
+			if cert, err = x509.ParseCertificate(block.Bytes); err == nil {
+				key = cert.PublicKey
+			}
+		default:
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if _, err := s.add(key); err != nil {
+			return err
+		}
+		found = true
+	}
+	if !found {
+		return errors.New("no PEM public keys found")
+	}
+	return nil
+}
+
+// add registers a verification key and returns its key ID
+func (s *jwtKeySet) add(key crypto.PublicKey) (string, error) {
+	var method jwt.SigningMethod
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		if k.N.BitLen() < minRSAKeyBits {
+			return "", fmt.Errorf("RSA key has %d bits, need at least %d", k.N.BitLen(), minRSAKeyBits)
+		}
+		method = jwt.SigningMethodRS256
+	case ed25519.PublicKey:
+		method = jwt.SigningMethodEdDSA
+	default:
+		return "", fmt.Errorf("unsupported public key type %T", key)
+	}
+	kid, err := jwtKeyID(key)
+	if err != nil {
+		return "", err
+	}
+	s.verification[kid] = jwtVerificationKey{method: method, key: key}
+	return kid, nil
+}
+
+// jwtKeyID derives a stable key ID from the public key, so the signing server and verifiers agree without configuration
+func jwtKeyID(key crypto.PublicKey) (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(key)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(der)
+	return base64.RawURLEncoding.EncodeToString(sum[:12]), nil
+}
+
+// sign signs the claims with the current signing key, naming it in the kid header
+func (s *jwtKeySet) sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(s.signingMethod, claims)
+	token.Header["kid"] = s.signingKID
+	return token.SignedString(s.signingKey)
+}
+
+// keyFunc selects the verification key named by the token's kid header
+func (s *jwtKeySet) keyFunc(token *jwt.Token) (interface{}, error) {
+	if _, ok := token.Method.(*jwt.SigningMethodHMAC); ok {
+		if s.legacySecret == nil {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return s.legacySecret, nil
+	}
+	kid, _ := token.Header["kid"].(string)
+	key, ok := s.verification[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	if token.Method.Alg() != key.method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method %v for key %q", token.Header["alg"], kid)
+	}
+	return key.key, nil
+}
+
+// publicJWKS returns the verification keys as a JWKS document, so other services can validate access tokens locally.
+// A nil set (HS256 shared secret) publishes no keys.
+func (s *jwtKeySet) publicJWKS() jsonWebKeySet {
+	set := jsonWebKeySet{Keys: []jsonWebKey{}}
+	if s == nil {
+		return set
+	}
+	for kid, v := range s.verification {
+		jwk := jsonWebKey{Kid: kid, Use: "sig", Alg: v.method.Alg()}
+		switch k := v.key.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(k.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(k.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(k)
+		default:
+			continue
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	// The signing key first, then a stable order
+	slices.SortFunc(set.Keys, func(a, b jsonWebKey) int {
+		if (a.Kid == s.signingKID) != (b.Kid == s.signingKID) {
+			if a.Kid == s.signingKID {
+				return -1
+			}
+			return 1
+		}
+		return strings.Compare(a.Kid, b.Kid)
+	})
+	return set
+}
+
+// jwksHandler serves the access token verification keys at /.well-known/jwks.json
+func jwksHandler(w http.ResponseWriter, r *http.Request, pathParams map[string]string) {
+	w.Header().Set("Content-Type", "application/json")
+	// Verifiers refetch on an unknown kid, so a short cache is enough for rotations
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	if err := json.NewEncoder(w).Encode(jwtKeys.publicJWKS()); err != nil {
+		slog.Error("failed to write JWKS", "error", err)
+	}
+}
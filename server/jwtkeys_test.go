@@ -0,0 +1,193 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// writePEM writes a PEM block to a file in dir and returns its path
+func writePEM(t *testing.T, dir, name, blockType string, der []byte) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// useJWTKeys loads the key set of cfg and installs it for the rest of the test
+func useJWTKeys(t *testing.T, cfg AuthConfig) *jwtKeySet {
+	t.Helper()
+	keys, err := loadJWTKeys(cfg)
+	if err != nil {
+		t.Fatalf("loadJWTKeys() error = %v", err)
+	}
+	previous := jwtKeys
+	jwtKeys = keys
+	t.Cleanup(func() { jwtKeys = previous })
+	return keys
+}
+
+func TestJWTKeyRotation(t *testing.T) {
+	dir := t.TempDir()
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldPrivate := writePEM(t, dir, "old.pem", "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(rsaKey))
+	oldPublicDER, _ := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	oldPublic := writePEM(t, dir, "old.pub", "PUBLIC KEY", oldPublicDER)
+
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edDER, _ := x509.MarshalPKCS8PrivateKey(edKey)
+	newPrivate := writePEM(t, dir, "new.pem", "PRIVATE KEY", edDER)
+
+	useJWTKeys(t, AuthConfig{JWTSecret: defaultJWTSecret, JWTPrivateKeyFile: oldPrivate})
+	oldToken, err := GenerateJWT(1, "testuser", 0)
+	if err != nil {
+		t.Fatalf("GenerateJWT() error = %v", err)
+	}
+	parsed, _, err := jwt.NewParser().ParseUnverified(oldToken, &Claims{})
+	if err != nil || parsed.Method.Alg() != "RS256" || parsed.Header["kid"] == "" {
+		t.Fatalf("RS256 token header = %v, %v", parsed.Header, err)
+	}
+
+	// Rotate to the Ed25519 key while the old public key stays valid
+	keys := useJWTKeys(t, AuthConfig{JWTSecret: defaultJWTSecret, JWTPrivateKeyFile: newPrivate, JWTVerificationKeyFiles: []string{oldPublic}})
+	if len(keys.verification) != 2 {
+		t.Errorf("verification keys = %d, want 2", len(keys.verification))
+	}
+	newToken, err := GenerateJWT(2, "other", 0)
+	if err != nil {
+		t.Fatalf("GenerateJWT() error = %v", err)
+	}
+	for _, token := range []string{oldToken, newToken} {
+		if _, err := ValidateJWT(token); err != nil {
+			t.Errorf("ValidateJWT() after rotation error = %v", err)
+		}
+	}
+
+	// Once the old key is retired its tokens stop validating
+	useJWTKeys(t, AuthConfig{JWTSecret: defaultJWTSecret, JWTPrivateKeyFile: newPrivate})
+	if _, err := ValidateJWT(oldToken); err == nil {
+		t.Error("ValidateJWT() accepted a token signed with a retired key")
+	}
+	if _, err := ValidateJWT(newToken); err != nil {
+		t.Errorf("ValidateJWT() error = %v", err)
+	}
+}
+
+func TestJWTKeysLegacySecret(t *testing.T) {
+	dir := t.TempDir()
+	_, edKey, _ := ed25519.GenerateKey(rand.Reader)
+	der, _ := x509.MarshalPKCS8PrivateKey(edKey)
+	privateFile := writePEM(t, dir, "key.pem", "PRIVATE KEY", der)
+
+	hsToken := func(secret string) string {
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, &Claims{UserID: 1, Username: "testuser", RegisteredClaims: jwt.RegisteredClaims{Issuer: jwtIssuer}}).SignedString([]byte(secret))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+
+	useJWTKeys(t, AuthConfig{JWTSecret: defaultJWTSecret, JWTPrivateKeyFile: privateFile})
+	if _, err := ValidateJWT(hsToken(defaultJWTSecret)); err == nil {
+		t.Error("ValidateJWT() accepted an HS256 token signed with the default secret")
+	}
+
+	useJWTKeys(t, AuthConfig{JWTSecret: "configured-secret", JWTPrivateKeyFile: privateFile})
+	if _, err := ValidateJWT(hsToken("configured-secret")); err != nil {
+		t.Errorf("ValidateJWT() rejected a legacy HS256 token: %v", err)
+	}
+}
+
+func TestLoadJWTKeysErrors(t *testing.T) {
+	dir := t.TempDir()
+	if keys, err := loadJWTKeys(AuthConfig{JWTSecret: defaultJWTSecret}); keys != nil || err != nil {
+		t.Errorf("loadJWTKeys() = %v, %v, want HS256 fallback", keys, err)
+	}
+
+	weak, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	weakFile := writePEM(t, dir, "weak.pem", "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(weak))
+	if _, err := loadJWTKeys(AuthConfig{JWTPrivateKeyFile: weakFile}); err == nil || !strings.Contains(err.Error(), "bits") {
+		t.Errorf("loadJWTKeys() with a 1024-bit key error = %v", err)
+	}
+}
+
+func TestJWKSHandler(t *testing.T) {
+	dir := t.TempDir()
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edPublic, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edDER, _ := x509.MarshalPKIXPublicKey(edPublic)
+	keys := useJWTKeys(t, AuthConfig{
+		JWTPrivateKeyFile:       writePEM(t, dir, "key.pem", "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(rsaKey)),
+		JWTVerificationKeyFiles: []string{writePEM(t, dir, "old.pub", "PUBLIC KEY", edDER)},
+	})
+
+	rec := httptest.NewRecorder()
+	jwksHandler(rec, httptest.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil), nil)
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	var set jsonWebKeySet
+	if err := json.Unmarshal(rec.Body.Bytes(), &set); err != nil {
+		t.Fatalf("invalid JWKS: %v", err)
+	}
+	if len(set.Keys) != 2 || set.Keys[0].Kid != keys.signingKID || set.Keys[0].Alg != "RS256" {
+		t.Fatalf("JWKS keys = %+v, want the RS256 signing key first", set.Keys)
+	}
+	if k := set.Keys[1]; k.Kty != "OKP" || k.Crv != "Ed25519" || k.Alg != "EdDSA" || k.X == "" {
+		t.Errorf("Ed25519 JWK = %+v", k)
+	}
+	if strings.Contains(rec.Body.String(), `"d"`) {
+		t.Error("JWKS must not contain private key material")
+	}
+
+	// A verifier that only has the JWKS can validate the service's tokens
+	published, err := set.publicKeys()
+	if err != nil {
+		t.Fatalf("publicKeys() error = %v", err)
+	}
+	token, err := GenerateJWT(1, "testuser", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = jwt.ParseWithClaims(token, &Claims{}, func(tok *jwt.Token) (interface{}, error) {
+		return published[tok.Header["kid"].(string)], nil
+	})
+	if err != nil {
+		t.Errorf("token did not validate against the published key: %v", err)
+	}
+
+	jwtKeys = nil
+	rec = httptest.NewRecorder()
+	jwksHandler(rec, httptest.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil), nil)
+	if body := strings.TrimSpace(rec.Body.String()); body != `{"keys":[]}` {
+		t.Errorf("JWKS with a shared secret = %s, want no keys", body)
+	}
+}
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	pb "example/grpc_demo/library"
+	"example/grpc_demo/server/storage"
+
+	"github.com/jackc/pgx/v5"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	defaultLoanDays = 14
+	maxLoanDays     = 60
+)
+
+// loanColumns is the column list read by scanLoan; the loan table is aliased l and books b
+const loanColumns = "l.id, l.book_id, b.title, l.user_id, l.borrowed_at, l.due_at, l.returned_at, l.overdue"
+
+// scanLoan reads a single loan selected with loanColumns
+func scanLoan(row pgx.Row) (*pb.Loan, error) {
+	var (
+		loan              pb.Loan
+		borrowedAt, dueAt time.Time
+		returnedAt        *time.Time
+	)
+	if err := row.Scan(&loan.Id, &loan.BookId, &loan.BookTitle, &loan.UserId, &borrowedAt, &dueAt, &returnedAt, &loan.Overdue); err != nil {
+		return nil, err
+	}
+	loan.BorrowedAt = timestamppb.New(borrowedAt)
+	loan.DueAt = timestamppb.New(dueAt)
+	if returnedAt != nil {
+		loan.ReturnedAt = timestamppb.New(*returnedAt)
+	}
+	return &loan, nil
+}
+
+// takeShelfCopy removes one available copy of a book for a new loan.
+// Decrementing only when a copy is left makes concurrent borrows safe.
+func takeShelfCopy(ctx context.Context, tx pgx.Tx, bookID string) error {
+	res, err := tx.Exec(ctx, "UPDATE books SET available_copies = available_copies - 1 WHERE id=$1 AND available_copies > 0", bookID)
+	if err != nil {
+		return status.Errorf(codes.Internal, "failed to reserve copy: %v", err)
+	}
+	if res.RowsAffected() > 0 {
+		return nil
+	}
+	var exists bool
+	if err := tx.QueryRow(ctx, "SELECT EXISTS(SELECT 1 FROM books WHERE id=$1)", bookID).Scan(&exists); err != nil {
+		return status.Errorf(codes.Internal, "database error: %v", err)
+	}
+	if !exists {
+		return notFound(resourceBook, bookID, "Book not found")
+	}
+	return status.Error(codes.FailedPrecondition, "no copies of this book are available; place a reservation instead")
+}
+
+func (s *server) BorrowBook(ctx context.Context, req *pb.BorrowRequest) (*pb.Loan, error) {
+	userID, _, ok := userFromContext(ctx)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "authentication required")
+	}
+	if req.GetBookId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "Book ID is required")
+	}
+	days := req.GetLoanDays()
+	if days == 0 {
+		days = defaultLoanDays
+	}
+	if days < 0 || days > maxLoanDays {
+		return nil, status.Errorf(codes.InvalidArgument, "loan period must be between 1 and %d days", maxLoanDays)
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "database error: %v", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// A copy held for the user's reservation is already off the shelf
+	held, err := claimHold(ctx, tx, req.GetBookId(), userID)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to claim reservation: %v", err)
+	}
+	if !held {
+		if err := takeShelfCopy(ctx, tx, req.GetBookId()); err != nil {
+			return nil, err
+		}
+	}
+
+	var loanID int64
+	err = tx.QueryRow(ctx,
+		"INSERT INTO loans (book_id, user_id, due_at) VALUES ($1, $2, NOW() + make_interval(days => $3)) RETURNING id",
+		req.GetBookId(), userID, days).Scan(&loanID)
+	if isUniqueViolation(err) {
+		return nil, status.Error(codes.FailedPrecondition, "you already have this book on loan")
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to create loan: %v", err)
+	}
+
+	loan, err := scanLoan(tx.QueryRow(ctx, "SELECT "+loanColumns+" FROM loans l JOIN books b ON b.id = l.book_id WHERE l.id=$1", loanID))
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to load loan: %v", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to create loan: %v", err)
+	}
+	s.cache.invalidate()
+	return loan, nil
+}
+
+func (s *server) ReturnBook(ctx context.Context, req *pb.LoanRequest) (*pb.Loan, error) {
+	userID, _, ok := userFromContext(ctx)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "authentication required")
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "database error: %v", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var bookID string
+	var dueAt time.Time
+	var returnedAt *time.Time
+	err = tx.QueryRow(ctx, "SELECT book_id, due_at, returned_at FROM loans WHERE id=$1 AND user_id=$2 FOR UPDATE", req.GetId(), userID).Scan(&bookID, &dueAt, &returnedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, notFound(resourceLoan, strconv.FormatInt(req.GetId(), 10), "Loan not found")
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "database error: %v", err)
+	}
+	if returnedAt != nil {
+		return nil, status.Error(codes.FailedPrecondition, "book has already been returned")
+	}
+
+	var returned time.Time
+	if err := tx.QueryRow(ctx, "UPDATE loans SET returned_at=NOW() WHERE id=$1 RETURNING returned_at", req.GetId()).Scan(&returned); err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to return book: %v", err)
+	}
+	// Late returns settle the fine at its final amount
+	if err := s.fines.assessFine(ctx, tx, req.GetId(), userID, dueAt, returned); err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to assess fine: %v", err)
+	}
+	// The copy goes to the next reservation in line, if any
+	promoted, err := releaseCopy(ctx, tx, bookID)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to release copy: %v", err)
+	}
+
+	loan, err := scanLoan(tx.QueryRow(ctx, "SELECT "+loanColumns+" FROM loans l JOIN books b ON b.id = l.book_id WHERE l.id=$1", req.GetId()))
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to load loan: %v", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to return book: %v", err)
+	}
+	s.cache.invalidate()
+	s.notifyHoldReady(ctx, promoted)
+	return loan, nil
+}
+
+func (s *server) ListMyLoans(ctx context.Context, req *pb.ListLoansRequest) (*pb.ListLoansResponse, error) {
+	userID, _, ok := userFromContext(ctx)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "authentication required")
+	}
+	page, err := pageOf(req, fmt.Sprintf("loans %d %t", userID, req.GetIncludeReturned()), time.Time{}, int64(0))
+	if err != nil {
+		return nil, err
+	}
+
+	var filter sqlFilter
+	filter.where("l.user_id = " + filter.arg(userID))
+	if !req.GetIncludeReturned() {
+		filter.where("l.returned_at IS NULL")
+	}
+	where := filter.clause()
+
+	var totalCount int32
+	if err := s.db.QueryRow(ctx, "SELECT COUNT(*) FROM loans l"+where, filter.args...).Scan(&totalCount); err != nil {
+		return nil, status.Errorf(codes.Internal, "database error: %v", err)
+	}
+
+	order := storage.Keyset{Columns: []string{"l.borrowed_at", "l.id"}, Desc: true}
+	page.where(&filter, order)
+	query := "SELECT " + loanColumns + " FROM loans l JOIN books b ON b.id = l.book_id" + filter.clause() +
+		order.OrderBy() + page.limitOffset(&filter)
+	rows, err := s.db.Query(ctx, query, filter.args...)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "database error: %v", err)
+	}
+	defer rows.Close()
+
+	var loans []*pb.Loan
+	for rows.Next() {
+		loan, err := scanLoan(rows)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "database error: %v", err)
+		}
+		loans = append(loans, loan)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, status.Errorf(codes.Internal, "database error: %v", err)
+	}
+	loans, next := nextPage(page, loans, func(l *pb.Loan) []any { return []any{l.GetBorrowedAt().AsTime(), l.GetId()} })
+	return &pb.ListLoansResponse{Loans: loans, TotalCount: totalCount, NextPageToken: next}, nil
+}
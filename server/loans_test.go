@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"testing"
+	"time"
+
+	pb "example/grpc_demo/library"
+	"example/grpc_demo/server/storage"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestBorrowBookValidation(t *testing.T) {
+	s := &server{}
+	if _, err := s.BorrowBook(context.Background(), &pb.BorrowRequest{BookId: "b1"}); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("BorrowBook() without a user error = %v, want Unauthenticated", err)
+	}
+	ctx := context.WithValue(context.Background(), userIDKey, 1)
+	for _, req := range []*pb.BorrowRequest{{}, {BookId: "b1", LoanDays: -1}, {BookId: "b1", LoanDays: maxLoanDays + 1}} {
+		if _, err := s.BorrowBook(ctx, req); status.Code(err) != codes.InvalidArgument {
+			t.Errorf("BorrowBook(%v) error = %v, want InvalidArgument", req, err)
+		}
+	}
+}
+
+func TestLoanValidation(t *testing.T) {
+	s := &server{}
+	if _, err := s.ReturnBook(context.Background(), &pb.LoanRequest{Id: 1}); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("ReturnBook() without a user error = %v, want Unauthenticated", err)
+	}
+	if _, err := s.ListMyLoans(context.Background(), &pb.ListLoansRequest{}); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("ListMyLoans() without a user error = %v, want Unauthenticated", err)
+	}
+	ctx := context.WithValue(context.Background(), userIDKey, 1)
+	// A token of another user's loans isn't good for the caller's
+	token := storage.EncodePageToken("loans 2 false", time.Now(), int64(5))
+	if _, err := s.ListMyLoans(ctx, &pb.ListLoansRequest{PageToken: token}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("ListMyLoans() with another user's page token error = %v, want InvalidArgument", err)
+	}
+}
+
+// TestLoanPermissions checks copies are only lent when one is left and loans only returned by their
+// borrower, against a fake database
+func TestLoanPermissions(t *testing.T) {
+	pool, db := newFakeDatabase(t)
+	s := &server{db: pool}
+	alice := context.WithValue(context.Background(), userIDKey, 1)
+	bob := context.WithValue(context.Background(), userIDKey, 2)
+	borrowed := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	db.answer("UPDATE reservations SET status='fulfilled'", "UPDATE 0")
+	db.answer("UPDATE books SET available_copies = available_copies - 1", "UPDATE 0")
+	db.answer("SELECT EXISTS(SELECT 1 FROM books WHERE id=('dune'))", "SELECT 1", []any{true})
+	// Loan 5 is alice's, and returned
+	db.answer("FROM loans WHERE id=('5') AND user_id=('1') FOR UPDATE", "SELECT 1", []any{"dune", borrowed.AddDate(0, 0, 14), borrowed.AddDate(0, 0, 7)})
+	db.answer("FROM loans WHERE id=", "SELECT 0")
+
+	if _, err := s.BorrowBook(bob, &pb.BorrowRequest{BookId: "dune"}); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("BorrowBook() with no copies left error = %v, want FailedPrecondition", err)
+	}
+	if !db.received("AND user_id=('2') AND status='ready'") {
+		t.Error("BorrowBook() didn't look for a copy held for bob")
+	}
+	if db.received("INSERT INTO loans") {
+		t.Error("a loan was made with no copies left")
+	}
+
+	if _, err := s.ReturnBook(bob, &pb.LoanRequest{Id: 5}); status.Code(err) != codes.NotFound {
+		t.Errorf("ReturnBook() of another user's loan error = %v, want NotFound", err)
+	}
+	if _, err := s.ReturnBook(alice, &pb.LoanRequest{Id: 5}); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("ReturnBook() of a returned loan error = %v, want FailedPrecondition", err)
+	}
+	if db.received("UPDATE loans") {
+		t.Error("a loan was returned by someone else or twice")
+	}
+}
+
+// TestLoans runs against the database of STORAGE_TEST_DATABASE_URL
+func TestLoans(t *testing.T) {
+	pool := testDatabase(t, "loans-test-")
+	s := &server{db: pool, cache: newResponseCache(CacheConfig{Size: 10, TTL: time.Minute})}
+	alice := testUser(t, pool, "loans-test-alice")
+	bob := testUser(t, pool, "loans-test-bob")
+	testBook(t, pool, "loans-test-dune", 1)
+
+	available := func() int32 {
+		t.Helper()
+		var n int32
+		if err := pool.QueryRow(alice, "SELECT available_copies FROM books WHERE id='loans-test-dune'").Scan(&n); err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+
+	_, err := s.BorrowBook(alice, &pb.BorrowRequest{BookId: "loans-test-missing"})
+	checkResourceError(t, err, codes.NotFound, resourceBook, "loans-test-missing")
+	loan, err := s.BorrowBook(alice, &pb.BorrowRequest{BookId: "loans-test-dune", LoanDays: 7})
+	if err != nil {
+		t.Fatalf("BorrowBook() error = %v", err)
+	}
+	if loan.GetBookTitle() != "loans-test-dune" || loan.GetReturnedAt() != nil || math.Round(loan.GetDueAt().AsTime().Sub(loan.GetBorrowedAt().AsTime()).Hours()/24) != 7 {
+		t.Errorf("BorrowBook() = %v, want a 7 day loan of loans-test-dune", loan)
+	}
+	if n := available(); n != 0 {
+		t.Errorf("available copies after borrowing = %d, want 0", n)
+	}
+	// A read cached while the copy is out must not outlive its return
+	_, generation, _ := s.cache.get("dune")
+	s.cache.put("dune", &pb.Book{Id: "loans-test-dune"}, generation)
+
+	// The only copy is out, so no one can borrow the book
+	if _, err := s.BorrowBook(bob, &pb.BorrowRequest{BookId: "loans-test-dune"}); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("BorrowBook() with no copies left error = %v, want FailedPrecondition", err)
+	}
+
+	// Loans of other users are reported as missing, and stay open
+	_, err = s.ReturnBook(bob, &pb.LoanRequest{Id: loan.GetId()})
+	checkResourceError(t, err, codes.NotFound, resourceLoan, strconv.FormatInt(loan.GetId(), 10))
+	if resp, err := s.ListMyLoans(alice, &pb.ListLoansRequest{}); err != nil || resp.GetTotalCount() != 1 || resp.GetLoans()[0].GetId() != loan.GetId() {
+		t.Errorf("ListMyLoans() = %v, %v, want the open loan %d", resp, err, loan.GetId())
+	}
+
+	returned, err := s.ReturnBook(alice, &pb.LoanRequest{Id: loan.GetId()})
+	if err != nil || returned.GetReturnedAt() == nil {
+		t.Fatalf("ReturnBook() = %v, %v, want the loan returned", returned, err)
+	}
+	if n := available(); n != 1 {
+		t.Errorf("available copies after returning = %d, want 1", n)
+	}
+	if _, _, ok := s.cache.get("dune"); ok {
+		t.Error("the cached book survived its return")
+	}
+	if _, err := s.ReturnBook(alice, &pb.LoanRequest{Id: loan.GetId()}); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("ReturnBook() again error = %v, want FailedPrecondition", err)
+	}
+	if n := available(); n != 1 {
+		t.Errorf("available copies after returning twice = %d, want 1", n)
+	}
+
+	if resp, err := s.ListMyLoans(alice, &pb.ListLoansRequest{}); err != nil || resp.GetTotalCount() != 0 {
+		t.Errorf("ListMyLoans() = %v, %v, want no open loans", resp, err)
+	}
+	if resp, err := s.ListMyLoans(alice, &pb.ListLoansRequest{IncludeReturned: true}); err != nil || resp.GetTotalCount() != 1 {
+		t.Errorf("ListMyLoans() with returned loans = %v, %v, want 1", resp, err)
+	}
+}
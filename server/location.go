@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	pb "example/grpc_demo/library"
+	"example/grpc_demo/server/storage"
+
+	"github.com/jackc/pgx/v5"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// maxLocationFieldLength bounds each of branch, section and shelf
+const maxLocationFieldLength = 100
+
+// normalizeLocation trims each field; a location with no fields set means "not recorded"
+func normalizeLocation(loc *pb.Location) *pb.Location {
+	n := &pb.Location{
+		Branch:  strings.TrimSpace(loc.GetBranch()),
+		Section: strings.TrimSpace(loc.GetSection()),
+		Shelf:   strings.TrimSpace(loc.GetShelf()),
+	}
+	if n.Branch == "" && n.Section == "" && n.Shelf == "" {
+		return nil
+	}
+	return n
+}
+
+// validateLocation returns the message for an invalid location, or "" if it is acceptable
+func validateLocation(loc *pb.Location) string {
+	loc = normalizeLocation(loc)
+	if len(loc.GetBranch()) > maxLocationFieldLength || len(loc.GetSection()) > maxLocationFieldLength || len(loc.GetShelf()) > maxLocationFieldLength {
+		return "Location fields are too long"
+	}
+	return ""
+}
+
+func (s *server) FindBookLocation(ctx context.Context, req *pb.BookRequest) (*pb.BookLocation, error) {
+	if req.GetId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "Book ID is required")
+	}
+	book, err := storage.ScanBook(s.db.QueryRow(ctx, "SELECT "+storage.BookColumns+" FROM books WHERE id=$1", req.GetId()))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, notFound(resourceBook, req.GetId(), "Book not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	// Location is left unset when it has not been recorded
+	return &pb.BookLocation{
+		BookId:          book.GetId(),
+		Title:           book.GetTitle(),
+		Location:        book.GetLocation(),
+		AvailableCopies: book.GetAvailableCopies(),
+		TotalCopies:     book.GetTotalCopies(),
+	}, nil
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	pb "example/grpc_demo/library"
+
+	"google.golang.org/protobuf/proto"
+)
+
+func TestNormalizeLocation(t *testing.T) {
+	tests := []struct {
+		name string
+		in   *pb.Location
+		want *pb.Location
+	}{
+		{"nil", nil, nil},
+		{"blank", &pb.Location{Branch: " ", Shelf: "\t"}, nil},
+		{"trimmed", &pb.Location{Branch: " Main ", Section: "Computing", Shelf: " C3"}, &pb.Location{Branch: "Main", Section: "Computing", Shelf: "C3"}},
+		{"branch only", &pb.Location{Branch: "East"}, &pb.Location{Branch: "East"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := normalizeLocation(tt.in); !proto.Equal(got, tt.want) {
+				t.Errorf("normalizeLocation() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateLocation(t *testing.T) {
+	if msg := validateLocation(&pb.Location{Branch: "Main", Shelf: "C3"}); msg != "" {
+		t.Errorf("validateLocation(valid) = %q, want empty", msg)
+	}
+	if msg := validateLocation(&pb.Location{Section: strings.Repeat("x", maxLocationFieldLength+1)}); msg == "" {
+		t.Error("validateLocation() accepted an over-long section")
+	}
+}
@@ -0,0 +1,236 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// loggingConfig is how the server logs: the handler for all logs, and the level of successful RPCs in the request log
+type loggingConfig struct {
+	logger  *slog.Logger
+	rpcOKAt slog.Leveler
+	// level is the minimum level of the handler, and rpcLevel backs rpcOKAt; they change when the
+	// configuration is reloaded
+	level, rpcLevel *slog.LevelVar
+	// reporter, if set, is sent the calls that fail unexpectedly
+	reporter errorReporter
+}
+
+// newLogging returns the logging of cfg, writing to w. cfg must have been validated.
+func newLogging(w io.Writer, cfg LoggingConfig) loggingConfig {
+	level, rpcLevel := new(slog.LevelVar), new(slog.LevelVar)
+	logging := loggingConfig{rpcOKAt: rpcLevel, level: level, rpcLevel: rpcLevel}
+	logging.setLevels(cfg)
+
+	opts := &slog.HandlerOptions{Level: level}
+	var handler slog.Handler = slog.NewTextHandler(w, opts)
+	if strings.EqualFold(cfg.Format, "json") {
+		handler = slog.NewJSONHandler(w, opts)
+	}
+	logging.logger = slog.New(traceHandler{handler})
+	return logging
+}
+
+// levels returns the minimum level logged and the level of successful RPCs
+func (c LoggingConfig) levels() (level, rpcLevel slog.Level, err error) {
+	if err := level.UnmarshalText([]byte(c.Level)); err != nil {
+		return 0, 0, fmt.Errorf("LOG_LEVEL must be debug, info, warn or error, got %q", c.Level)
+	}
+	if err := rpcLevel.UnmarshalText([]byte(c.RPCLevel)); err != nil {
+		return 0, 0, fmt.Errorf("LOG_RPC_LEVEL must be debug, info, warn or error, got %q", c.RPCLevel)
+	}
+	return level, rpcLevel, nil
+}
+
+// setLevels changes the minimum level logged and the level of successful RPCs to those of cfg, which must
+// have been validated
+func (c loggingConfig) setLevels(cfg LoggingConfig) {
+	level, rpcLevel, _ := cfg.levels()
+	c.level.Set(level)
+	c.rpcLevel.Set(rpcLevel)
+}
+
+// fatal logs msg at error level and exits, for startup failures
+func fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
+
+// traceHandler adds the IDs of the current trace and span to records logged with a context, so logs can be
+// matched with traces
+type traceHandler struct {
+	slog.Handler
+}
+
+func (h traceHandler) Handle(ctx context.Context, r slog.Record) error {
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		r.AddAttrs(slog.String("trace_id", sc.TraceID().String()), slog.String("span_id", sc.SpanID().String()))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h traceHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return traceHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h traceHandler) WithGroup(name string) slog.Handler {
+	return traceHandler{h.Handler.WithGroup(name)}
+}
+
+type rpcLogKey struct{}
+
+type requestIDKey struct{}
+
+const (
+	// requestIDHeader carries the ID of a request: the caller's, such as a load balancer's X-Request-Id
+	// forwarded by the gateway, or else a generated one. It is returned in the response headers.
+	requestIDHeader = "x-request-id"
+	// maxRequestIDLength bounds the request IDs taken from callers
+	maxRequestIDLength = 128
+)
+
+// withRequestID returns ctx carrying the request ID of the incoming call
+func withRequestID(ctx context.Context) (context.Context, string) {
+	var id string
+	if values := metadata.ValueFromIncomingContext(ctx, requestIDHeader); len(values) > 0 && len(values[0]) <= maxRequestIDLength {
+		id = values[0]
+	}
+	if id == "" {
+		id = uuid.NewString()
+	}
+	return context.WithValue(ctx, requestIDKey{}, id), id
+}
+
+// requestIDFromContext returns the ID of the request being served, empty outside of one
+func requestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// rpcCaller is filled in by the auth interceptors, which run inside the logging interceptors
+type rpcCaller struct {
+	userID  int
+	service string
+	// reported is set by the recovery interceptors once they have reported a panic of the call
+	reported bool
+}
+
+// noteRPCCaller records the authenticated caller of ctx for the request log
+func noteRPCCaller(ctx context.Context) {
+	caller, ok := ctx.Value(rpcLogKey{}).(*rpcCaller)
+	if !ok {
+		return
+	}
+	if userID, _, ok := userFromContext(ctx); ok {
+		caller.userID = userID
+	}
+	if service, ok := serviceFromContext(ctx); ok {
+		caller.service = service
+	}
+}
+
+// CreateLoggingInterceptor logs every unary call with its caller, duration, status code and request size
+func CreateLoggingInterceptor(cfg loggingConfig) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		caller := &rpcCaller{}
+		ctx, requestID := withRequestID(ctx)
+		ctx = context.WithValue(ctx, rpcLogKey{}, caller)
+		grpc.SetHeader(ctx, metadata.Pairs(requestIDHeader, requestID))
+		start := time.Now()
+		resp, err := handler(ctx, req)
+
+		size := 0
+		if m, ok := req.(proto.Message); ok {
+			size = proto.Size(m)
+		}
+		logRPC(ctx, cfg, info.FullMethod, caller, time.Since(start), err, slog.Int("request_bytes", size))
+		return resp, err
+	}
+}
+
+// CreateStreamLoggingInterceptor logs every streaming call once it ends, with the messages and bytes received
+func CreateStreamLoggingInterceptor(cfg loggingConfig) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		caller := &rpcCaller{}
+		ctx, requestID := withRequestID(ss.Context())
+		stream := &loggedServerStream{ServerStream: ss, ctx: context.WithValue(ctx, rpcLogKey{}, caller)}
+		ss.SetHeader(metadata.Pairs(requestIDHeader, requestID))
+		start := time.Now()
+		err := handler(srv, stream)
+
+		logRPC(stream.ctx, cfg, info.FullMethod, caller, time.Since(start), err,
+			slog.Int("messages_received", stream.received), slog.Int("request_bytes", stream.receivedBytes))
+		return err
+	}
+}
+
+// logRPC writes one request log entry. Errors the server is responsible for are logged at error level,
+// those caused by the request at warn level.
+func logRPC(ctx context.Context, cfg loggingConfig, method string, caller *rpcCaller, elapsed time.Duration, err error, attrs ...slog.Attr) {
+	reportError(ctx, cfg.reporter, method, err)
+	code := status.Code(err)
+	level := cfg.rpcOKAt.Level()
+	switch code {
+	case codes.OK:
+	case codes.Internal, codes.Unknown, codes.DataLoss, codes.Unavailable, codes.Unimplemented, codes.DeadlineExceeded:
+		level = slog.LevelError
+	default:
+		level = slog.LevelWarn
+	}
+	if !cfg.logger.Enabled(ctx, level) {
+		return
+	}
+
+	attrs = append([]slog.Attr{
+		slog.String("method", method),
+		slog.String("code", code.String()),
+		slog.Duration("duration", elapsed),
+		slog.String("request_id", requestIDFromContext(ctx)),
+	}, attrs...)
+	if caller.userID != 0 {
+		attrs = append(attrs, slog.Int("user_id", caller.userID))
+	}
+	if caller.service != "" {
+		attrs = append(attrs, slog.String("service", caller.service))
+	}
+	if err != nil {
+		attrs = append(attrs, slog.String("error", status.Convert(err).Message()))
+	}
+	cfg.logger.LogAttrs(ctx, level, "rpc", attrs...)
+}
+
+// loggedServerStream counts the messages received on a stream and carries the logging context
+type loggedServerStream struct {
+	grpc.ServerStream
+	ctx           context.Context
+	received      int
+	receivedBytes int
+}
+
+func (s *loggedServerStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *loggedServerStream) RecvMsg(m interface{}) error {
+	err := s.ServerStream.RecvMsg(m)
+	if err == nil {
+		s.received++
+		if msg, ok := m.(proto.Message); ok {
+			s.receivedBytes += proto.Size(msg)
+		}
+	}
+	return err
+}
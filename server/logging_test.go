@@ -0,0 +1,122 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+
+	pb "example/grpc_demo/library"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestNewLogging(t *testing.T) {
+	var buf bytes.Buffer
+	cfg := newLogging(&buf, LoggingConfig{Format: "JSON", Level: "warn", RPCLevel: "debug"})
+	if cfg.rpcOKAt.Level() != slog.LevelDebug {
+		t.Errorf("rpcOKAt = %v, want debug", cfg.rpcOKAt)
+	}
+	cfg.logger.Info("hidden")
+	cfg.logger.Warn("shown", "key", "value")
+	var entry map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("log output %q is not a single JSON entry: %v", buf.String(), err)
+	}
+	if entry["msg"] != "shown" || entry["key"] != "value" {
+		t.Errorf("entry = %v", entry)
+	}
+}
+
+// logEntries runs a unary call through the logging interceptor and returns what it logged
+func logEntries(t *testing.T, handler grpc.UnaryHandler) []map[string]any {
+	t.Helper()
+	var buf bytes.Buffer
+	cfg := loggingConfig{logger: slog.New(traceHandler{slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})}), rpcOKAt: slog.LevelInfo}
+	info := &grpc.UnaryServerInfo{FullMethod: "/library.LibraryService/GetBook"}
+	CreateLoggingInterceptor(cfg)(context.Background(), &pb.BookRequest{Id: "book1"}, info, handler)
+
+	var entries []map[string]any
+	dec := json.NewDecoder(&buf)
+	for dec.More() {
+		var entry map[string]any
+		if err := dec.Decode(&entry); err != nil {
+			t.Fatalf("decoding log output: %v", err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func TestLoggingInterceptor(t *testing.T) {
+	entries := logEntries(t, func(ctx context.Context, req interface{}) (interface{}, error) {
+		// As the auth interceptor does once it has authenticated the caller
+		noteRPCCaller(context.WithValue(ctx, userIDKey, 42))
+		return &pb.Book{}, nil
+	})
+	if len(entries) != 1 {
+		t.Fatalf("logged %d entries, want 1", len(entries))
+	}
+	e := entries[0]
+	if e["level"] != "INFO" || e["method"] != "/library.LibraryService/GetBook" || e["code"] != "OK" || e["user_id"] != float64(42) {
+		t.Errorf("entry = %v", e)
+	}
+	if _, ok := e["duration"]; !ok {
+		t.Errorf("entry has no duration: %v", e)
+	}
+	if e["request_bytes"] != float64(7) {
+		t.Errorf("request_bytes = %v, want 7", e["request_bytes"])
+	}
+
+	for code, level := range map[codes.Code]string{codes.NotFound: "WARN", codes.Internal: "ERROR"} {
+		entries := logEntries(t, func(ctx context.Context, req interface{}) (interface{}, error) {
+			return nil, status.Error(code, "failed")
+		})
+		if len(entries) != 1 || entries[0]["level"] != level || entries[0]["code"] != code.String() || entries[0]["error"] != "failed" {
+			t.Errorf("%v: entries = %v, want one %s entry", code, entries, level)
+		}
+		if _, ok := entries[0]["user_id"]; ok {
+			t.Errorf("%v: anonymous call logged with a user ID: %v", code, entries[0])
+		}
+	}
+}
+
+func TestWithRequestID(t *testing.T) {
+	incoming := func(id string) context.Context {
+		return metadata.NewIncomingContext(context.Background(), metadata.Pairs(requestIDHeader, id))
+	}
+	if ctx, id := withRequestID(incoming("req-1")); id != "req-1" || requestIDFromContext(ctx) != "req-1" {
+		t.Errorf("request ID = %q, want the caller's req-1", id)
+	}
+	// Callers without an ID, or with an overlong one, get a generated ID
+	for _, ctx := range []context.Context{context.Background(), incoming(strings.Repeat("x", maxRequestIDLength+1))} {
+		if _, id := withRequestID(ctx); len(id) != 36 {
+			t.Errorf("request ID = %q, want a generated UUID", id)
+		}
+	}
+	if id := requestIDFromContext(context.Background()); id != "" {
+		t.Errorf("request ID outside of a request = %q", id)
+	}
+}
+
+func TestTraceHandler(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(traceHandler{slog.NewJSONHandler(&buf, nil)})
+	ctx, span := sdktrace.NewTracerProvider().Tracer("test").Start(context.Background(), "rpc")
+	defer span.End()
+
+	logger.InfoContext(ctx, "traced")
+	var entry map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("decoding log output: %v", err)
+	}
+	if entry["trace_id"] != span.SpanContext().TraceID().String() || entry["span_id"] != span.SpanContext().SpanID().String() {
+		t.Errorf("entry = %v, want the span's trace and span IDs", entry)
+	}
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	pb "example/grpc_demo/library"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// revokeAllSessions invalidates every access token issued to the user so far and revokes their refresh tokens.
+// It returns the number of refresh tokens revoked.
+func revokeAllSessions(ctx context.Context, q querier, userID int) (int64, error) {
+	if _, err := q.Exec(ctx, "UPDATE users SET sessions_revoked_at=NOW() WHERE id=$1", userID); err != nil {
+		return 0, err
+	}
+	if _, err := q.Exec(ctx, "UPDATE sessions SET revoked_at=NOW() WHERE user_id=$1 AND revoked_at IS NULL", userID); err != nil {
+		return 0, err
+	}
+	res, err := q.Exec(ctx, "UPDATE refresh_tokens SET revoked_at=NOW() WHERE user_id=$1 AND revoked_at IS NULL", userID)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected(), nil
+}
+
+// revokeOtherSessions ends every session of the user except keep and revokes their refresh tokens.
+// It returns the number of sessions ended.
+func revokeOtherSessions(ctx context.Context, q querier, userID int, keep int64) (int64, error) {
+	res, err := q.Exec(ctx, "UPDATE sessions SET revoked_at=NOW() WHERE user_id=$1 AND id<>$2 AND revoked_at IS NULL", userID, keep)
+	if err != nil {
+		return 0, err
+	}
+	_, err = q.Exec(ctx,
+		"UPDATE refresh_tokens SET revoked_at=NOW() WHERE user_id=$1 AND session_id IS DISTINCT FROM $2 AND revoked_at IS NULL",
+		userID, keep)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected(), nil
+}
+
+func (s *server) Logout(ctx context.Context, req *pb.LogoutRequest) (*pb.LogoutResponse, error) {
+	userID, _, ok := userFromContext(ctx)
+	claims, hasClaims := claimsFromContext(ctx)
+	if !ok || !hasClaims {
+		return nil, status.Error(codes.Unauthenticated, "authentication required")
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "database error: %v", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Tokens issued before token IDs were introduced cannot be revoked one by one and simply run out
+	if claims.ID != "" {
+		expiresAt := time.Now().Add(tokenTTLs.access)
+		if claims.ExpiresAt != nil {
+			expiresAt = claims.ExpiresAt.Time
+		}
+		_, err := tx.Exec(ctx,
+			"INSERT INTO revoked_tokens (jti, user_id, expires_at) VALUES ($1, $2, $3) ON CONFLICT (jti) DO NOTHING",
+			claims.ID, userID, expiresAt)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to revoke token: %v", err)
+		}
+		// The auth interceptors consult the denylist, so the logout only counts once it is recorded there
+		if err := s.denylist.Revoke(ctx, claims.ID, expiresAt); err != nil {
+			return nil, status.Errorf(codes.Unavailable, "failed to revoke token: %v", err)
+		}
+	}
+
+	resp := &pb.LogoutResponse{Message: localize(ctx, "Logged out")}
+	// Logging out ends the token's session, including its refresh tokens
+	if claims.SessionID != 0 {
+		_, revoked, err := revokeSession(ctx, tx, userID, claims.SessionID)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to revoke session: %v", err)
+		}
+		resp.RevokedRefreshTokens = int32(revoked)
+	}
+	if req.GetRefreshToken() != "" {
+		// Only the caller's own refresh tokens can be revoked
+		res, err := tx.Exec(ctx,
+			"UPDATE refresh_tokens SET revoked_at=NOW() WHERE token_hash=$1 AND user_id=$2 AND revoked_at IS NULL",
+			hashOpaqueToken(req.GetRefreshToken()), userID)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to revoke refresh token: %v", err)
+		}
+		resp.RevokedRefreshTokens += int32(res.RowsAffected())
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, status.Errorf(codes.Internal, "database error: %v", err)
+	}
+	return resp, nil
+}
+
+func (s *server) RevokeAllSessions(ctx context.Context, req *pb.RevokeAllSessionsRequest) (*pb.LogoutResponse, error) {
+	userID, _, ok := userFromContext(ctx)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "authentication required")
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "database error: %v", err)
+	}
+	defer tx.Rollback(ctx)
+
+	revoked, err := revokeAllSessions(ctx, tx, userID)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to revoke sessions: %v", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, status.Errorf(codes.Internal, "database error: %v", err)
+	}
+	return &pb.LogoutResponse{Message: localize(ctx, "Logged out everywhere"), RevokedRefreshTokens: int32(revoked)}, nil
+}
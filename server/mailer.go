@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// maxEmailLength is the longest address SMTP allows
+	maxEmailLength = 254
+	// mailQueueSize is how many messages wait for the sender before new ones are dropped
+	mailQueueSize = 256
+)
+
+// normalizeEmail trims an address and checks it is a bare address such as "reader@example.com"
+func normalizeEmail(email string) (string, error) {
+	email = strings.TrimSpace(email)
+	if len(email) > maxEmailLength {
+		return "", errors.New("email address is too long")
+	}
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Address != email {
+		return "", errors.New("invalid email address")
+	}
+	return email, nil
+}
+
+// mailRecipient identifies the account a message is for
+type mailRecipient struct {
+	username string
+	email    string
+}
+
+// accountMailer delivers account tokens to users
+type accountMailer interface {
+	SendPasswordReset(ctx context.Context, to mailRecipient, token string, expiresAt time.Time) error
+	SendEmailVerification(ctx context.Context, to mailRecipient, token string, expiresAt time.Time) error
+}
+
+// logMailer writes tokens to the server log, for development and demos
+type logMailer struct{}
+
+func (logMailer) SendPasswordReset(ctx context.Context, to mailRecipient, token string, expiresAt time.Time) error {
+	slog.InfoContext(ctx, "password reset token", "username", to.username, "expires_at", expiresAt.Format(time.RFC3339), "token", token)
+	return nil
+}
+
+func (logMailer) SendEmailVerification(ctx context.Context, to mailRecipient, token string, expiresAt time.Time) error {
+	slog.InfoContext(ctx, "email verification token", "username", to.username, "email", to.email, "expires_at", expiresAt.Format(time.RFC3339), "token", token)
+	return nil
+}
+
+// mailQueue sends the messages of another mailer in the background, so that calls neither wait for the
+// mail server nor take longer when they send a message, which would tell apart the accounts that exist.
+// Its methods never fail: delivery errors are logged by the sender.
+type mailQueue struct {
+	next     accountMailer
+	messages chan func()
+}
+
+func newMailQueue(next accountMailer) *mailQueue {
+	return &mailQueue{next: next, messages: make(chan func(), mailQueueSize)}
+}
+
+func (q *mailQueue) SendPasswordReset(ctx context.Context, to mailRecipient, token string, expiresAt time.Time) error {
+	ctx = context.WithoutCancel(ctx)
+	q.enqueue(ctx, "password reset", to, func() error { return q.next.SendPasswordReset(ctx, to, token, expiresAt) })
+	return nil
+}
+
+func (q *mailQueue) SendEmailVerification(ctx context.Context, to mailRecipient, token string, expiresAt time.Time) error {
+	ctx = context.WithoutCancel(ctx)
+	q.enqueue(ctx, "email verification", to, func() error { return q.next.SendEmailVerification(ctx, to, token, expiresAt) })
+	return nil
+}
+
+// enqueue queues the sending of a message of kind to a recipient; it is dropped with an error when the
+// sender is too far behind
+func (q *mailQueue) enqueue(ctx context.Context, kind string, to mailRecipient, send func() error) {
+	select {
+	case q.messages <- func() {
+		if err := send(); err != nil {
+			slog.ErrorContext(ctx, "failed to send "+kind, "username", to.username, "error", err)
+		}
+	}:
+	default:
+		slog.ErrorContext(ctx, "mail queue full; "+kind+" dropped", "username", to.username)
+	}
+}
+
+// run sends queued messages until ctx is cancelled
+func (q *mailQueue) run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case send := <-q.messages:
+			send()
+		}
+	}
+}
+
+// smtpMailer sends tokens by email through an SMTP relay
+type smtpMailer struct {
+	addr string
+	from string
+	auth smtp.Auth
+}
+
+// newMailer returns an SMTP mailer when cfg names a host, authenticating when it has a username, and a
+// mailer that logs tokens otherwise
+func newMailer(cfg MailConfig) (accountMailer, error) {
+	if cfg.Host == "" {
+		return logMailer{}, nil
+	}
+	from, err := normalizeEmail(cfg.From)
+	if err != nil {
+		return nil, fmt.Errorf("SMTP_FROM: %w", err)
+	}
+	m := &smtpMailer{addr: net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port)), from: from}
+	if cfg.Username != "" {
+		m.auth = smtp.PlainAuth("", cfg.Username, cfg.Password, cfg.Host)
+	}
+	return m, nil
+}
+
+func (m *smtpMailer) SendPasswordReset(ctx context.Context, to mailRecipient, token string, expiresAt time.Time) error {
+	return m.send(to, "Reset your library password", fmt.Sprintf(
+		"Hello %s,\r\n\r\nUse this token to set a new password before %s:\r\n\r\n%s\r\n\r\nIf you did not ask for a reset, ignore this message.\r\n",
+		to.username, expiresAt.Format(time.RFC1123), token))
+}
+
+func (m *smtpMailer) SendEmailVerification(ctx context.Context, to mailRecipient, token string, expiresAt time.Time) error {
+	return m.send(to, "Verify your library email address", fmt.Sprintf(
+		"Hello %s,\r\n\r\nUse this token to verify your email address before %s:\r\n\r\n%s\r\n",
+		to.username, expiresAt.Format(time.RFC1123), token))
+}
+
+// send delivers a plain-text message to the recipient's email address
+func (m *smtpMailer) send(to mailRecipient, subject, body string) error {
+	if to.email == "" {
+		return fmt.Errorf("user %s has no email address", to.username)
+	}
+	msg := "From: " + m.from + "\r\n" +
+		"To: " + to.email + "\r\n" +
+		"Subject: " + subject + "\r\n" +
+		"Content-Type: text/plain; charset=utf-8\r\n" +
+		"\r\n" + body
+	return smtp.SendMail(m.addr, m.auth, m.from, []string{to.email}, []byte(msg))
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNormalizeEmail(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{"reader@example.com", "reader@example.com", false},
+		{"  Reader@Example.com ", "Reader@Example.com", false},
+		{"", "", true},
+		{"not-an-address", "", true},
+		{"Reader <reader@example.com>", "", true},
+		{strings.Repeat("x", maxEmailLength) + "@example.com", "", true},
+	}
+	for _, tt := range tests {
+		got, err := normalizeEmail(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("normalizeEmail(%q) = %q, %v, want %q (error %v)", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestNewMailer(t *testing.T) {
+	cfg, err := loadTestConfig(t, nil)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if m, err := newMailer(cfg.Mail); err != nil {
+		t.Fatalf("newMailer() error = %v", err)
+	} else if _, ok := m.(logMailer); !ok {
+		t.Errorf("newMailer() = %T, want logMailer without SMTP_HOST", m)
+	}
+
+	cfg, err = loadTestConfig(t, map[string]string{"SMTP_HOST": "smtp.example.com", "SMTP_FROM": "library@example.com"})
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	m, err := newMailer(cfg.Mail)
+	if err != nil {
+		t.Fatalf("newMailer() error = %v", err)
+	}
+	sm, ok := m.(*smtpMailer)
+	if !ok || sm.addr != "smtp.example.com:587" || sm.auth != nil {
+		t.Errorf("newMailer() = %+v, want unauthenticated SMTP mailer on port 587", m)
+	}
+}
+
+func TestSMTPMailerNeedsAddress(t *testing.T) {
+	m := &smtpMailer{addr: "127.0.0.1:0", from: "library@example.com"}
+	if err := m.send(mailRecipient{username: "reader"}, "subject", "body"); err == nil {
+		t.Error("send() should fail for a user without an email address")
+	}
+}
+
+func TestRequireVerifiedEmailConfig(t *testing.T) {
+	for raw, want := range map[string]bool{"": false, "true": true, "0": false} {
+		cfg, err := loadTestConfig(t, map[string]string{"REQUIRE_VERIFIED_EMAIL": raw})
+		if err != nil {
+			t.Errorf("REQUIRE_VERIFIED_EMAIL=%q: error = %v", raw, err)
+		} else if cfg.Auth.RequireVerifiedEmail != want {
+			t.Errorf("REQUIRE_VERIFIED_EMAIL=%q: got %v, want %v", raw, cfg.Auth.RequireVerifiedEmail, want)
+		}
+	}
+	if _, err := loadTestConfig(t, map[string]string{"REQUIRE_VERIFIED_EMAIL": "sometimes"}); err == nil {
+		t.Error("REQUIRE_VERIFIED_EMAIL should reject non-boolean values")
+	}
+}
+
+// failingMailer fails every message, reporting each on sent
+type failingMailer struct {
+	sent chan mailRecipient
+}
+
+func (m failingMailer) SendPasswordReset(ctx context.Context, to mailRecipient, token string, expiresAt time.Time) error {
+	m.sent <- to
+	return errors.New("connection refused")
+}
+
+func (m failingMailer) SendEmailVerification(ctx context.Context, to mailRecipient, token string, expiresAt time.Time) error {
+	m.sent <- to
+	return errors.New("connection refused")
+}
+
+func TestMailQueue(t *testing.T) {
+	mailer := failingMailer{sent: make(chan mailRecipient, 1)}
+	q := newMailQueue(mailer)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Nothing is sent until the sender runs, and the mailer's error never reaches the caller
+	callCtx, done := context.WithCancel(context.Background())
+	if err := q.SendPasswordReset(callCtx, mailRecipient{username: "ana"}, "token", time.Now()); err != nil {
+		t.Fatalf("SendPasswordReset() error = %v", err)
+	}
+	done()
+	select {
+	case <-mailer.sent:
+		t.Fatal("the message was sent before the sender ran")
+	default:
+	}
+	go q.run(ctx)
+	select {
+	case to := <-mailer.sent:
+		if to.username != "ana" {
+			t.Errorf("sent to %q, want ana", to.username)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the queued message was never sent")
+	}
+
+	// Messages beyond the queue's size are dropped rather than waited for
+	full := newMailQueue(mailer)
+	for range mailQueueSize + 1 {
+		if err := full.SendEmailVerification(ctx, mailRecipient{username: "ana"}, "token", time.Now()); err != nil {
+			t.Fatalf("SendEmailVerification() error = %v", err)
+		}
+	}
+	if len(full.messages) != mailQueueSize {
+		t.Errorf("%d messages queued, want %d", len(full.messages), mailQueueSize)
+	}
+}
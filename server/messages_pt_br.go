@@ -0,0 +1,130 @@
+package main
+
+// messagesPtBR translates the server's messages into Brazilian Portuguese, keyed by their English text
+var messagesPtBR = map[string]string{
+	// Responses
+	"Login successful":                                         "Login realizado com sucesso",
+	"Token refreshed":                                          "Token renovado",
+	"User registered successfully":                             "Usuário cadastrado com sucesso",
+	"User registered; verify your email address to log in":     "Usuário cadastrado; confirme seu endereço de e-mail para entrar",
+	"Logged out":                                               "Sessão encerrada",
+	"Logged out everywhere":                                    "Sessões encerradas em todos os dispositivos",
+	"Session revoked":                                          "Sessão revogada",
+	"Password changed":                                         "Senha alterada",
+	"Password updated; log in with the new password":           "Senha atualizada; entre com a nova senha",
+	passwordResetSentMessage:                                   "Se a conta existir, um token de redefinição de senha foi enviado",
+	"Email address verified":                                   "Endereço de e-mail confirmado",
+	"Some notifications could not be delivered":                "Algumas notificações não puderam ser entregues",
+	"A request with this idempotency key is still in progress": "Uma requisição com esta chave de idempotência ainda está em andamento",
+
+	// Errors
+	"Barcode is required":                                      "O código de barras é obrigatório",
+	"Book ID is required":                                      "O ID do livro é obrigatório",
+	"Book already exists":                                      "O livro já existe",
+	"Book is not in favorites":                                 "O livro não está nos favoritos",
+	"Book is not on this shelf":                                "O livro não está nesta estante",
+	"Book not found":                                           "Livro não encontrado",
+	"Book was added by another user":                           "O livro foi adicionado por outro usuário",
+	"Cannot reduce copies below the number on loan":            "Não é possível reduzir os exemplares abaixo do número emprestado",
+	"Challenge token is required":                              "O token do desafio é obrigatório",
+	"Challenge verification failed; please try again":          "A verificação do desafio falhou; tente novamente",
+	"Cover not found":                                          "Capa não encontrada",
+	"Email address already registered":                         "Endereço de e-mail já cadastrado",
+	"Email address is required":                                "O endereço de e-mail é obrigatório",
+	"Email address not verified":                               "Endereço de e-mail não confirmado",
+	"Field cannot be changed":                                  "O campo não pode ser alterado",
+	"Fine not found":                                           "Multa não encontrada",
+	"ID token is required":                                     "O ID token é obrigatório",
+	"ISBN is required":                                         "O ISBN é obrigatório",
+	"ISBN lookup is not configured":                            "A consulta de ISBN não está configurada",
+	"Idempotency key was already used for a different request": "A chave de idempotência já foi usada em outra requisição",
+	"Invalid ISBN":                                             "ISBN inválido",
+	"Invalid email address":                                    "Endereço de e-mail inválido",
+	"Invalid library ID":                                       "ID de biblioteca inválido",
+	"Invalid page token":                                       "Token de página inválido",
+	"Invalid language":                                         "Idioma inválido",
+	"Invalid username or password":                             "Usuário ou senha inválidos",
+	"Library ID is required":                                   "O ID da biblioteca é obrigatório",
+	"Library already exists":                                   "A biblioteca já existe",
+	"Library name is required":                                 "O nome da biblioteca é obrigatório",
+	"Library name is too long":                                 "O nome da biblioteca é longo demais",
+	"Library not found":                                        "Biblioteca não encontrada",
+	"Loan not found":                                           "Empréstimo não encontrado",
+	"Multiple libraries are not enabled":                       "Múltiplas bibliotecas não estão ativadas",
+	"No book has this barcode":                                 "Nenhum livro tem este código de barras",
+	"Not available in demo mode":                               "Indisponível no modo de demonstração",
+	"Not available with MongoDB":                               "Indisponível com o MongoDB",
+	"OIDC login is not configured":                             "O login via OIDC não está configurado",
+	"Only admins of the default library can manage libraries":  "Apenas administradores da biblioteca padrão podem gerenciar bibliotecas",
+	"Publisher ID is required":                                 "O ID da editora é obrigatório",
+	"Publisher name is required":                               "O nome da editora é obrigatório",
+	"Publisher name is too long":                               "O nome da editora é longo demais",
+	"Publisher not found":                                      "Editora não encontrada",
+	"Publisher website is too long":                            "O site da editora é longo demais",
+	"Rating must be between 1 and 5":                           "A nota deve estar entre 1 e 5",
+	"Reservation not found":                                    "Reserva não encontrada",
+	"Review ID is required":                                    "O ID da resenha é obrigatório",
+	"Search query is required":                                 "A busca é obrigatória",
+	"Search query is too long":                                 "A busca é longa demais",
+	"Series name is required":                                  "O nome da série é obrigatório",
+	"Series not found":                                         "Série não encontrada",
+	"Shelf already exists":                                     "A estante já existe",
+	"Shelf name is required":                                   "O nome da estante é obrigatório",
+	"Shelf not found":                                          "Estante não encontrada",
+	"Tag already exists":                                       "A tag já existe",
+	"Tag name is required":                                     "O nome da tag é obrigatório",
+	"Tag name is too long":                                     "O nome da tag é longo demais",
+	"Tag not found":                                            "Tag não encontrada",
+	"The library's admin username and password are required":   "O usuário e a senha do administrador da biblioteca são obrigatórios",
+	"Total copies cannot be negative":                          "O total de exemplares não pode ser negativo",
+	"Translated title is required":                             "O título traduzido é obrigatório",
+	"Translation not found":                                    "Tradução não encontrada",
+	"Username already exists":                                  "O nome de usuário já existe",
+	"Username and password are required":                       "Usuário e senha são obrigatórios",
+	"Username or email address already exists":                 "O nome de usuário ou endereço de e-mail já existe",
+	"acquired_at cannot be in the future":                      "acquired_at não pode estar no futuro",
+	"acquired_from must be before acquired_to":                 "acquired_from deve ser anterior a acquired_to",
+	"at least one book is required":                            "é necessário pelo menos um livro",
+	"at least one scope is required":                           "é necessário pelo menos um escopo",
+	"authentication required":                                  "autenticação necessária",
+	"book has already been returned":                           "o livro já foi devolvido",
+	"copies are available; borrow the book instead":            "há exemplares disponíveis; pegue o livro emprestado",
+	"cover image is empty":                                     "a imagem da capa está vazia",
+	"current password is incorrect":                            "a senha atual está incorreta",
+	"current password is required":                             "a senha atual é obrigatória",
+	"display name is too long":                                 "o nome de exibição é longo demais",
+	"donor name is too long":                                   "o nome do doador é longo demais",
+	"email address already registered":                         "endereço de e-mail já cadastrado",
+	"email address is required":                                "o endereço de e-mail é obrigatório",
+	"fine is already paid":                                     "a multa já foi paga",
+	"invalid or expired reset token":                           "token de redefinição inválido ou expirado",
+	"invalid or expired verification token":                    "token de confirmação inválido ou expirado",
+	"invalid refresh token":                                    "refresh token inválido",
+	"no copies of this book are available; place a reservation instead":      "não há exemplares disponíveis deste livro; faça uma reserva",
+	"not a token of a notification":                                          "não é um token de notificação",
+	"notifications are not enabled":                                          "as notificações não estão ativadas",
+	"only admins can list other users' events":                               "apenas administradores podem listar eventos de outros usuários",
+	"only admins can list the audit log":                                     "apenas administradores podem listar o log de auditoria",
+	"payment amount cannot be negative":                                      "o valor do pagamento não pode ser negativo",
+	"price cannot be negative":                                               "o preço não pode ser negativo",
+	"refresh token expired; log in again":                                    "o refresh token expirou; entre novamente",
+	"refresh token is required":                                              "o refresh token é obrigatório",
+	"refresh token was already used; log in again":                           "o refresh token já foi usado; entre novamente",
+	"refresh token was issued to another device":                             "o refresh token foi emitido para outro dispositivo",
+	"reset token is required":                                                "o token de redefinição é obrigatório",
+	"session ID is required":                                                 "o ID da sessão é obrigatório",
+	"session has been revoked; log in again":                                 "a sessão foi revogada; entre novamente",
+	"session not found":                                                      "sessão não encontrada",
+	"the account no longer uses this email address":                          "a conta não usa mais este endereço de e-mail",
+	"the database is unavailable; retry later":                               "o banco de dados está indisponível; tente novamente mais tarde",
+	"the server is handling too many requests; retry later":                  "o servidor está atendendo requisições demais; tente novamente mais tarde",
+	"the identity provider did not supply an unused, verified email address": "o provedor de identidade não forneceu um endereço de e-mail confirmado e ainda não usado",
+	"token was issued by another library":                                    "o token foi emitido por outra biblioteca",
+	"token was issued to another device":                                     "o token foi emitido para outro dispositivo",
+	"user not found":                                                         "usuário não encontrado",
+	"username is required":                                                   "o nome de usuário é obrigatório",
+	"verification token is required":                                         "o token de confirmação é obrigatório",
+	"x-batch-atomic must be true or false":                                   "x-batch-atomic deve ser true ou false",
+	"you already have a reservation for this book":                           "você já tem uma reserva deste livro",
+	"you already have this book on loan":                                     "você já está com este livro emprestado",
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	pb "example/grpc_demo/library"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestMetricsInterceptor(t *testing.T) {
+	interceptor := CreateMetricsInterceptor()
+	info := &grpc.UnaryServerInfo{FullMethod: pb.LibraryService_GetBook_FullMethodName}
+	ok := testutil.ToFloat64(rpcHandled.WithLabelValues("unary", "library.LibraryService", "GetBook", "OK"))
+	notFound := testutil.ToFloat64(rpcHandled.WithLabelValues("unary", "library.LibraryService", "GetBook", "NotFound"))
+
+	interceptor(context.Background(), nil, info, func(context.Context, interface{}) (interface{}, error) { return nil, nil })
+	interceptor(context.Background(), nil, info, func(context.Context, interface{}) (interface{}, error) {
+		return nil, status.Error(codes.NotFound, "Book not found")
+	})
+
+	if got := testutil.ToFloat64(rpcHandled.WithLabelValues("unary", "library.LibraryService", "GetBook", "OK")) - ok; got != 1 {
+		t.Errorf("OK calls counted %v times, want 1", got)
+	}
+	if got := testutil.ToFloat64(rpcHandled.WithLabelValues("unary", "library.LibraryService", "GetBook", "NotFound")) - notFound; got != 1 {
+		t.Errorf("NotFound calls counted %v times, want 1", got)
+	}
+}
+
+func TestObserveRPCExemplar(t *testing.T) {
+	traceID := trace.TraceID{1, 2, 3}
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     trace.SpanID{4},
+		TraceFlags: trace.FlagsSampled,
+	}))
+	observeRPC(ctx, rpcType(false, true), pb.NotificationService_Subscribe_FullMethodName, 3*time.Millisecond, nil)
+
+	families, err := metricsRegistry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, family := range families {
+		if family.GetName() != "grpc_server_handling_seconds" {
+			continue
+		}
+		for _, m := range family.GetMetric() {
+			labels := make(map[string]string)
+			for _, l := range m.GetLabel() {
+				labels[l.GetName()] = l.GetValue()
+			}
+			if labels["grpc_method"] != "Subscribe" || labels["grpc_type"] != "server_stream" {
+				continue
+			}
+			for _, b := range m.GetHistogram().GetBucket() {
+				if e := b.GetExemplar(); e != nil {
+					if e.GetLabel()[0].GetValue() != traceID.String() || e.GetValue() != 0.003 {
+						t.Errorf("exemplar = %v, want trace %s", e, traceID)
+					}
+					return
+				}
+			}
+		}
+	}
+	t.Error("no exemplar recorded for the traced Subscribe call")
+}
+
+func TestRPCType(t *testing.T) {
+	for _, tt := range []struct {
+		client, server bool
+		want           string
+	}{
+		{false, false, "unary"},
+		{true, false, "client_stream"},
+		{false, true, "server_stream"},
+		{true, true, "bidi_stream"},
+	} {
+		if got := rpcType(tt.client, tt.server); got != tt.want {
+			t.Errorf("rpcType(%v, %v) = %s, want %s", tt.client, tt.server, got, tt.want)
+		}
+	}
+}
@@ -10,4 +10,531 @@ CREATE TABLE IF NOT EXISTS books (
     id TEXT PRIMARY KEY,
     title TEXT NOT NULL,
     author TEXT NOT NULL
-); 
\ No newline at end of file
+); 
+
+-- Book revisions table (audit trail of catalog edits)
+CREATE TABLE IF NOT EXISTS book_revisions (
+    id BIGSERIAL PRIMARY KEY,
+    book_id TEXT NOT NULL,
+    action TEXT NOT NULL,
+    user_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    username TEXT NOT NULL DEFAULT '',
+    old_value JSONB,
+    new_value JSONB,
+    changed_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_book_revisions_book_id ON book_revisions (book_id, id);
+
+-- ISBN column used for duplicate detection and metadata lookups
+ALTER TABLE books ADD COLUMN IF NOT EXISTS isbn TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_books_isbn ON books (isbn) WHERE isbn <> '';
+
+-- Book covers (image bytes stored alongside the catalog)
+ALTER TABLE books ADD COLUMN IF NOT EXISTS cover_url TEXT NOT NULL DEFAULT '';
+
+CREATE TABLE IF NOT EXISTS book_covers (
+    book_id TEXT PRIMARY KEY REFERENCES books(id) ON DELETE CASCADE,
+    content_type TEXT NOT NULL,
+    data BYTEA NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Publisher name (prefilled from ISBN metadata lookups)
+ALTER TABLE books ADD COLUMN IF NOT EXISTS publisher TEXT NOT NULL DEFAULT '';
+
+-- Tags and the books they are attached to
+CREATE TABLE IF NOT EXISTS tags (
+    id SERIAL PRIMARY KEY,
+    name TEXT UNIQUE NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS book_tags (
+    book_id TEXT NOT NULL REFERENCES books(id) ON DELETE CASCADE,
+    tag_id INTEGER NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
+    PRIMARY KEY (book_id, tag_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_book_tags_tag_id ON book_tags (tag_id);
+
+-- Reviews (one per user per book)
+CREATE TABLE IF NOT EXISTS reviews (
+    id BIGSERIAL PRIMARY KEY,
+    book_id TEXT NOT NULL REFERENCES books(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    rating SMALLINT NOT NULL CHECK (rating BETWEEN 1 AND 5),
+    comment TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (book_id, user_id)
+);
+
+-- Per-user favorite books
+CREATE TABLE IF NOT EXISTS favorites (
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    book_id TEXT NOT NULL REFERENCES books(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, book_id)
+);
+
+-- Reading lists (shelves) owned by users
+CREATE TABLE IF NOT EXISTS shelves (
+    id BIGSERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (user_id, name)
+);
+
+CREATE TABLE IF NOT EXISTS shelf_books (
+    shelf_id BIGINT NOT NULL REFERENCES shelves(id) ON DELETE CASCADE,
+    book_id TEXT NOT NULL REFERENCES books(id) ON DELETE CASCADE,
+    added_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (shelf_id, book_id)
+);
+
+-- Physical copies and lending
+ALTER TABLE books ADD COLUMN IF NOT EXISTS total_copies INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE books ADD COLUMN IF NOT EXISTS available_copies INTEGER NOT NULL DEFAULT 1;
+
+DO $$ BEGIN
+    ALTER TABLE books ADD CONSTRAINT books_available_copies_check CHECK (available_copies >= 0 AND available_copies <= total_copies);
+EXCEPTION WHEN duplicate_object THEN NULL;
+END $$;
+
+CREATE TABLE IF NOT EXISTS loans (
+    id BIGSERIAL PRIMARY KEY,
+    book_id TEXT NOT NULL REFERENCES books(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    borrowed_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    due_at TIMESTAMPTZ NOT NULL,
+    returned_at TIMESTAMPTZ
+);
+
+-- A user can hold at most one active loan per book
+CREATE UNIQUE INDEX IF NOT EXISTS idx_loans_active ON loans (book_id, user_id) WHERE returned_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_loans_user_id ON loans (user_id, borrowed_at DESC);
+
+-- Hold queue for books with no available copies
+CREATE TABLE IF NOT EXISTS reservations (
+    id BIGSERIAL PRIMARY KEY,
+    book_id TEXT NOT NULL REFERENCES books(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    status TEXT NOT NULL DEFAULT 'waiting',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    ready_at TIMESTAMPTZ,
+    expires_at TIMESTAMPTZ
+);
+
+-- A user can hold at most one open reservation per book
+CREATE UNIQUE INDEX IF NOT EXISTS idx_reservations_open ON reservations (book_id, user_id) WHERE status IN ('waiting', 'ready');
+CREATE INDEX IF NOT EXISTS idx_reservations_queue ON reservations (book_id, created_at, id) WHERE status = 'waiting';
+
+-- Set by the circulation job when a loan passes its due date unreturned
+ALTER TABLE loans ADD COLUMN IF NOT EXISTS overdue BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- One fine per late loan; amounts are in cents and keep accruing until the book is returned
+CREATE TABLE IF NOT EXISTS fines (
+    id BIGSERIAL PRIMARY KEY,
+    loan_id BIGINT NOT NULL UNIQUE REFERENCES loans(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    days_overdue INTEGER NOT NULL,
+    amount_cents BIGINT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    paid_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_fines_user_id ON fines (user_id, created_at DESC);
+
+CREATE TABLE IF NOT EXISTS fine_payments (
+    id BIGSERIAL PRIMARY KEY,
+    fine_id BIGINT NOT NULL REFERENCES fines(id) ON DELETE CASCADE,
+    amount_cents BIGINT NOT NULL CHECK (amount_cents > 0),
+    paid_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Set once the "due soon" notification has been sent for a loan
+ALTER TABLE loans ADD COLUMN IF NOT EXISTS due_reminder_sent BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Supports duplicate detection on normalized title and author
+CREATE INDEX IF NOT EXISTS idx_books_title_author_key ON books (
+    (regexp_replace(lower(title), '[^[:alnum:]]', '', 'g')),
+    (regexp_replace(lower(author), '[^[:alnum:]]', '', 'g'))
+);
+
+-- Named series a book can belong to, with its volume number
+CREATE TABLE IF NOT EXISTS series (
+    id SERIAL PRIMARY KEY,
+    name TEXT NOT NULL UNIQUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE books ADD COLUMN IF NOT EXISTS series_id INTEGER REFERENCES series(id) ON DELETE SET NULL;
+ALTER TABLE books ADD COLUMN IF NOT EXISTS series_volume INTEGER;
+CREATE INDEX IF NOT EXISTS idx_books_series ON books (series_id, series_volume);
+
+ALTER TABLE books ADD COLUMN IF NOT EXISTS description TEXT NOT NULL DEFAULT '';
+
+-- Localized titles and descriptions, keyed by lowercase BCP 47 tag
+CREATE TABLE IF NOT EXISTS book_translations (
+    book_id TEXT NOT NULL REFERENCES books(id) ON DELETE CASCADE,
+    language TEXT NOT NULL,
+    title TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (book_id, language)
+);
+
+-- Physical location of a book's copies
+ALTER TABLE books ADD COLUMN IF NOT EXISTS location_branch TEXT NOT NULL DEFAULT '';
+ALTER TABLE books ADD COLUMN IF NOT EXISTS location_section TEXT NOT NULL DEFAULT '';
+ALTER TABLE books ADD COLUMN IF NOT EXISTS location_shelf TEXT NOT NULL DEFAULT '';
+CREATE INDEX IF NOT EXISTS idx_books_location ON books (location_branch, location_section);
+
+-- Physical copies registered with a barcode label
+CREATE TABLE IF NOT EXISTS book_copies (
+    id BIGSERIAL PRIMARY KEY,
+    book_id TEXT NOT NULL REFERENCES books(id) ON DELETE CASCADE,
+    barcode TEXT NOT NULL UNIQUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_book_copies_book_id ON book_copies (book_id);
+
+-- Canonical publishers referenced by books; names are unique regardless of case
+CREATE TABLE IF NOT EXISTS publishers (
+    id SERIAL PRIMARY KEY,
+    name TEXT NOT NULL,
+    website TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_publishers_name ON publishers ((lower(name)));
+
+ALTER TABLE books ADD COLUMN IF NOT EXISTS publisher_id INTEGER REFERENCES publishers(id) ON DELETE SET NULL;
+CREATE INDEX IF NOT EXISTS idx_books_publisher_id ON books (publisher_id);
+
+-- Link books added before publishers existed to a publisher record with the same name
+INSERT INTO publishers (name)
+SELECT DISTINCT ON (lower(btrim(publisher))) btrim(publisher) FROM books
+WHERE publisher_id IS NULL AND btrim(publisher) <> ''
+ORDER BY lower(btrim(publisher)), btrim(publisher)
+ON CONFLICT DO NOTHING;
+UPDATE books b SET publisher_id = p.id, publisher = p.name FROM publishers p
+WHERE b.publisher_id IS NULL AND btrim(b.publisher) <> '' AND lower(btrim(b.publisher)) = lower(p.name);
+
+-- Acquisition details of each copy for inventory reports
+ALTER TABLE book_copies ADD COLUMN IF NOT EXISTS condition TEXT NOT NULL DEFAULT '';
+ALTER TABLE book_copies ADD COLUMN IF NOT EXISTS price_cents BIGINT NOT NULL DEFAULT 0 CHECK (price_cents >= 0);
+ALTER TABLE book_copies ADD COLUMN IF NOT EXISTS acquired_at TIMESTAMPTZ;
+UPDATE book_copies SET acquired_at = created_at WHERE acquired_at IS NULL;
+ALTER TABLE book_copies ALTER COLUMN acquired_at SET DEFAULT NOW();
+ALTER TABLE book_copies ALTER COLUMN acquired_at SET NOT NULL;
+ALTER TABLE book_copies ADD COLUMN IF NOT EXISTS donor TEXT NOT NULL DEFAULT '';
+CREATE INDEX IF NOT EXISTS idx_book_copies_acquired_at ON book_copies (acquired_at DESC, id DESC);
+
+-- Refresh tokens, stored as SHA-256 hashes; revoked_at is set when a token is rotated or revoked
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+    id BIGSERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    token_hash TEXT NOT NULL UNIQUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMPTZ NOT NULL,
+    revoked_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_user_id ON refresh_tokens (user_id);
+
+-- Access tokens revoked by Logout, kept until they would have expired anyway
+CREATE TABLE IF NOT EXISTS revoked_tokens (
+    jti TEXT PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    expires_at TIMESTAMPTZ NOT NULL,
+    revoked_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_revoked_tokens_expires_at ON revoked_tokens (expires_at);
+
+-- Tokens issued at or before this time were revoked by RevokeAllSessions
+ALTER TABLE users ADD COLUMN IF NOT EXISTS sessions_revoked_at TIMESTAMPTZ;
+
+-- Single-use password reset tokens, stored as SHA-256 hashes
+CREATE TABLE IF NOT EXISTS password_resets (
+    id BIGSERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    token_hash TEXT NOT NULL UNIQUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMPTZ NOT NULL,
+    used_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_password_resets_user_id ON password_resets (user_id);
+
+-- Optional email address per user, unique regardless of case
+ALTER TABLE users ADD COLUMN IF NOT EXISTS email TEXT;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS email_verified_at TIMESTAMPTZ;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email ON users ((lower(email))) WHERE email IS NOT NULL;
+
+-- Email verification tokens, stored as SHA-256 hashes and bound to the address they were sent to
+CREATE TABLE IF NOT EXISTS email_verifications (
+    id BIGSERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    email TEXT NOT NULL,
+    token_hash TEXT NOT NULL UNIQUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMPTZ NOT NULL,
+    used_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_email_verifications_user_id ON email_verifications (user_id);
+
+-- Profile details editable by each user
+ALTER TABLE users ADD COLUMN IF NOT EXISTS display_name TEXT NOT NULL DEFAULT '';
+ALTER TABLE users ADD COLUMN IF NOT EXISTS preferences JSONB NOT NULL DEFAULT '{}';
+
+-- One row per login; refreshed tokens stay in the session they were issued for
+CREATE TABLE IF NOT EXISTS sessions (
+    id BIGSERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    user_agent TEXT NOT NULL DEFAULT '',
+    ip_address TEXT NOT NULL DEFAULT '',
+    issued_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_seen_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    revoked_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_sessions_user_id ON sessions (user_id);
+
+ALTER TABLE refresh_tokens ADD COLUMN IF NOT EXISTS session_id BIGINT REFERENCES sessions(id) ON DELETE CASCADE;
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_session_id ON refresh_tokens (session_id);
+
+-- Accounts at an external OpenID Connect provider, linked to the local user created on first login
+CREATE TABLE IF NOT EXISTS user_identities (
+    issuer TEXT NOT NULL,
+    subject TEXT NOT NULL,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (issuer, subject)
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_identities_user_id ON user_identities (user_id);
+
+-- Role checked by the authorization policy ('user' or 'admin')
+ALTER TABLE users ADD COLUMN IF NOT EXISTS role TEXT NOT NULL DEFAULT 'user';
+
+-- Audit trail of registrations, logins and token refreshes; failed logins for unknown users keep only the username
+CREATE TABLE IF NOT EXISTS auth_events (
+    id BIGSERIAL PRIMARY KEY,
+    user_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    username TEXT NOT NULL DEFAULT '',
+    event TEXT NOT NULL,
+    detail TEXT NOT NULL DEFAULT '',
+    ip_address TEXT NOT NULL DEFAULT '',
+    user_agent TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_auth_events_user_id ON auth_events (user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_auth_events_username ON auth_events (username, created_at DESC);
+
+-- Hash of the client's device ID; refresh tokens of a bound session only work from that device
+ALTER TABLE sessions ADD COLUMN IF NOT EXISTS device_fingerprint TEXT NOT NULL DEFAULT '';
+
+-- Most recent successful login, shown in the user's profile
+ALTER TABLE users ADD COLUMN IF NOT EXISTS last_login_at TIMESTAMPTZ;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS last_login_ip TEXT NOT NULL DEFAULT '';
+ALTER TABLE users ADD COLUMN IF NOT EXISTS login_count BIGINT NOT NULL DEFAULT 0;
+
+-- Previous password hashes of each user, which ChangePassword and ConfirmPasswordReset refuse to reuse
+CREATE TABLE IF NOT EXISTS password_history (
+    id BIGSERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    password_hash TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_password_history_user_id ON password_history (user_id, created_at DESC);
+
+-- Accounts registered while verified emails are required stay 'pending' until VerifyEmail activates them
+ALTER TABLE users ADD COLUMN IF NOT EXISTS state TEXT NOT NULL DEFAULT 'active';
+
+-- Outcome of AddBook, BatchAddBooks and DeleteBook calls made with an idempotency key, replayed to retries.
+-- response holds the google.protobuf.Any of a successful call's response, status the google.rpc.Status of
+-- a failed one; both are NULL while the call is in progress.
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    method TEXT NOT NULL,
+    key TEXT NOT NULL,
+    request_hash BYTEA NOT NULL,
+    response BYTEA,
+    status BYTEA,
+    completed_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, method, key)
+);
+
+CREATE INDEX IF NOT EXISTS idx_idempotency_keys_created_at ON idempotency_keys (created_at);
+
+-- Catalog changes made by each user per day (UTC), checked against the daily quotas
+CREATE TABLE IF NOT EXISTS quota_usage (
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    quota TEXT NOT NULL,
+    day DATE NOT NULL,
+    used INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (user_id, quota, day)
+);
+
+-- Successful calls of methods that change data, written in the background by the audit interceptor
+CREATE TABLE IF NOT EXISTS audit_log (
+    id BIGSERIAL PRIMARY KEY,
+    user_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    username TEXT NOT NULL DEFAULT '',
+    method TEXT NOT NULL,
+    resource_id TEXT NOT NULL DEFAULT '',
+    request_summary TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_created_at ON audit_log (created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_audit_log_user_id ON audit_log (user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_audit_log_resource_id ON audit_log (resource_id, created_at DESC);
+
+-- Full-text search of the catalog: titles weigh most, then authors, then descriptions. The simple
+-- configuration doesn't stem, as books are in many languages, and searches match word prefixes instead.
+ALTER TABLE books ADD COLUMN IF NOT EXISTS search_vector tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('simple', title), 'A') ||
+    setweight(to_tsvector('simple', author), 'B') ||
+    setweight(to_tsvector('simple', description), 'C')
+) STORED;
+CREATE INDEX IF NOT EXISTS idx_books_search_vector ON books USING GIN (search_vector);
+
+-- When each book was added, for listing the newest first
+ALTER TABLE books ADD COLUMN IF NOT EXISTS created_at TIMESTAMPTZ NOT NULL DEFAULT NOW();
+
+-- Orders and filters of ListBooks. Both indexes end with id, the tiebreak of every page, so a page is read
+-- off the index in order rather than sorted from all the matching rows. Logins need no index of their own:
+-- users is UNIQUE on its library and username.
+CREATE INDEX IF NOT EXISTS idx_books_author ON books (author, id);
+CREATE INDEX IF NOT EXISTS idx_books_created_at ON books (created_at, id);
+
+-- When books and accounts were created and last changed. Rows that predate the columns get the time of
+-- the migration. updated_at is kept by triggers, as many statements change these tables, and only follows
+-- the columns a user edits: loans, returns and logins don't change it.
+ALTER TABLE books ADD COLUMN IF NOT EXISTS updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW();
+ALTER TABLE users ADD COLUMN IF NOT EXISTS created_at TIMESTAMPTZ NOT NULL DEFAULT NOW();
+ALTER TABLE users ADD COLUMN IF NOT EXISTS updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW();
+
+CREATE OR REPLACE FUNCTION touch_updated_at() RETURNS trigger AS $$
+BEGIN
+    NEW.updated_at := NOW();
+    RETURN NEW;
+END
+$$ LANGUAGE plpgsql;
+
+DO $$ BEGIN
+    CREATE TRIGGER books_touch_updated_at BEFORE UPDATE OF title, author, isbn, cover_url, publisher, publisher_id,
+        total_copies, description, location_branch, location_section, location_shelf, series_id, series_volume
+        ON books FOR EACH ROW EXECUTE FUNCTION touch_updated_at();
+EXCEPTION WHEN duplicate_object THEN NULL;
+END $$;
+
+DO $$ BEGIN
+    CREATE TRIGGER users_touch_updated_at BEFORE UPDATE OF username, password_hash, email, email_verified_at,
+        display_name, preferences, role, state
+        ON users FOR EACH ROW EXECUTE FUNCTION touch_updated_at();
+EXCEPTION WHEN duplicate_object THEN NULL;
+END $$;
+
+-- The purge job deletes refresh tokens some time after they expire
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_expires_at ON refresh_tokens (expires_at);
+
+-- The retention policies anonymize and delete sign-in events by age
+CREATE INDEX IF NOT EXISTS idx_auth_events_created_at ON auth_events (created_at);
+
+-- Books remember the user who added them, who may change them besides admins
+ALTER TABLE books ADD COLUMN IF NOT EXISTS created_by INTEGER REFERENCES users(id) ON DELETE SET NULL;
+
+-- Events of book changes, written in the transaction of the change and published by the outbox relay
+CREATE TABLE IF NOT EXISTS outbox (
+    id BIGSERIAL PRIMARY KEY,
+    event_type TEXT NOT NULL,
+    aggregate_id TEXT NOT NULL,
+    payload JSONB NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    sent_at TIMESTAMPTZ,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS idx_outbox_unsent ON outbox (id) WHERE sent_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_outbox_sent_at ON outbox (sent_at) WHERE sent_at IS NOT NULL;
+
+-- Libraries hosted besides the default one, when TENANTS_ENABLED is set
+CREATE TABLE IF NOT EXISTS tenants (
+    id VARCHAR(30) PRIMARY KEY,
+    name VARCHAR(200) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Every row belongs to one library, named by its tenant_id: '' is the default library. The server sets
+-- app.tenant_id on each connection to the library of the call using it, which rows get by default and
+-- which row level security limits the statements to, so a query missing its tenant_id condition still
+-- can't read or change another library's rows. FORCE applies the policies to the owner of the tables
+-- too; superusers and roles with BYPASSRLS skip them, and the server refuses to host libraries as one.
+CREATE OR REPLACE FUNCTION current_tenant_id() RETURNS TEXT LANGUAGE sql STABLE AS $$
+    SELECT coalesce(current_setting('app.tenant_id', true), '')
+$$;
+
+DO $$
+DECLARE
+    t TEXT;
+BEGIN
+    FOREACH t IN ARRAY ARRAY['users', 'books', 'book_revisions', 'book_covers', 'tags', 'book_tags', 'reviews',
+        'favorites', 'shelves', 'shelf_books', 'loans', 'reservations', 'fines', 'fine_payments', 'series',
+        'book_translations', 'book_copies', 'publishers', 'refresh_tokens', 'revoked_tokens', 'password_resets',
+        'email_verifications', 'sessions', 'user_identities', 'auth_events', 'password_history',
+        'idempotency_keys', 'quota_usage', 'audit_log', 'outbox']
+    LOOP
+        EXECUTE format('ALTER TABLE %I ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT current_tenant_id()', t);
+        IF NOT EXISTS (SELECT 1 FROM pg_class WHERE oid = t::regclass AND relforcerowsecurity) THEN
+            EXECUTE format('ALTER TABLE %I ENABLE ROW LEVEL SECURITY', t);
+            EXECUTE format('ALTER TABLE %I FORCE ROW LEVEL SECURITY', t);
+        END IF;
+        IF NOT EXISTS (SELECT 1 FROM pg_policies WHERE tablename = t AND policyname = 'tenant_isolation') THEN
+            EXECUTE format('CREATE POLICY tenant_isolation ON %I USING (tenant_id = current_tenant_id())', t);
+        END IF;
+    END LOOP;
+END $$;
+
+-- Book IDs, usernames and the other names unique in a library are unique within their library only, and
+-- the rows of a book reference it together with its library
+DO $$
+DECLARE
+    t TEXT;
+BEGIN
+    IF EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'books_tenant_pkey') THEN
+        RETURN;
+    END IF;
+    ALTER TABLE books DROP CONSTRAINT books_pkey CASCADE;
+    ALTER TABLE books ADD CONSTRAINT books_tenant_pkey PRIMARY KEY (tenant_id, id);
+    FOREACH t IN ARRAY ARRAY['book_covers', 'book_tags', 'reviews', 'favorites', 'shelf_books', 'loans',
+        'reservations', 'book_translations', 'book_copies']
+    LOOP
+        EXECUTE format('ALTER TABLE %I ADD FOREIGN KEY (tenant_id, book_id) REFERENCES books (tenant_id, id) ON DELETE CASCADE', t);
+    END LOOP;
+    ALTER TABLE book_covers DROP CONSTRAINT book_covers_pkey, ADD PRIMARY KEY (tenant_id, book_id);
+    ALTER TABLE book_translations DROP CONSTRAINT book_translations_pkey,
+        ADD PRIMARY KEY (tenant_id, book_id, language);
+    ALTER TABLE user_identities DROP CONSTRAINT user_identities_pkey,
+        ADD PRIMARY KEY (tenant_id, issuer, subject);
+    ALTER TABLE users DROP CONSTRAINT users_username_key, ADD UNIQUE (tenant_id, username);
+    ALTER TABLE tags DROP CONSTRAINT tags_name_key, ADD UNIQUE (tenant_id, name);
+    ALTER TABLE series DROP CONSTRAINT series_name_key, ADD UNIQUE (tenant_id, name);
+    ALTER TABLE book_copies DROP CONSTRAINT book_copies_barcode_key, ADD UNIQUE (tenant_id, barcode);
+    DROP INDEX idx_publishers_name;
+    CREATE UNIQUE INDEX idx_publishers_name ON publishers (tenant_id, (lower(name)));
+    DROP INDEX idx_users_email;
+    CREATE UNIQUE INDEX idx_users_email ON users (tenant_id, (lower(email))) WHERE email IS NOT NULL;
+END $$;
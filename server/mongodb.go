@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	pb "example/grpc_demo/library"
+	"example/grpc_demo/server/storage"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// The values of STORAGE_BACKEND
+const (
+	storagePostgres = "postgres"
+	storageMongoDB  = "mongodb"
+)
+
+// mongoConnectTimeout bounds the connection to MongoDB and the creation of its indexes at startup
+const mongoConnectTimeout = 30 * time.Second
+
+// openMongoStore connects to the MongoDB deployment of cfg and returns the store of its database, with
+// the function disconnecting from it
+func openMongoStore(cfg MongoDBConfig) (*storage.Mongo, func(), error) {
+	ctx, cancel := context.WithTimeout(context.Background(), mongoConnectTimeout)
+	defer cancel()
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(cfg.URI))
+	if err != nil {
+		return nil, nil, err
+	}
+	disconnect := func() { client.Disconnect(context.Background()) }
+	if err := client.Ping(ctx, nil); err != nil {
+		disconnect()
+		return nil, nil, err
+	}
+	store, err := storage.NewMongo(ctx, client.Database(cfg.Database))
+	if err != nil {
+		disconnect()
+		return nil, nil, err
+	}
+	return store, disconnect, nil
+}
+
+// mongoServes reports whether method is served with the mongodb backend: those of the book catalog, as
+// in demo mode, and of accounts, which stay in PostgreSQL. The others keep rows referring to books in
+// PostgreSQL, such as loans and reviews.
+func mongoServes(method string) bool {
+	return demoMethods[method] || strings.HasPrefix(method, "/"+pb.UserService_ServiceDesc.ServiceName+"/")
+}
+
+// CreateMongoInterceptor refuses the calls the mongodb backend can't serve
+func CreateMongoInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if !mongoServes(info.FullMethod) {
+			return nil, status.Error(codes.Unimplemented, "Not available with MongoDB")
+		}
+		return handler(ctx, req)
+	}
+}
+
+// CreateStreamMongoInterceptor refuses the streams the mongodb backend can't serve
+func CreateStreamMongoInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if !mongoServes(info.FullMethod) {
+			return status.Error(codes.Unimplemented, "Not available with MongoDB")
+		}
+		return handler(srv, ss)
+	}
+}
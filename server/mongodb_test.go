@@ -0,0 +1,25 @@
+package main
+
+import (
+	"testing"
+
+	pb "example/grpc_demo/library"
+)
+
+func TestMongoServes(t *testing.T) {
+	for method, want := range map[string]bool{
+		pb.LibraryService_ListBooks_FullMethodName:        true,
+		pb.LibraryService_DeleteBook_FullMethodName:       true,
+		pb.UserService_Login_FullMethodName:               true,
+		pb.UserService_GetProfile_FullMethodName:          true,
+		pb.ServerInfoService_GetServerInfo_FullMethodName: true,
+		// Loans, reviews and tags refer to books in PostgreSQL
+		pb.LoanService_BorrowBook_FullMethodName:  false,
+		pb.ReviewService_AddReview_FullMethodName: false,
+		pb.TagService_TagBook_FullMethodName:      false,
+	} {
+		if got := mongoServes(method); got != want {
+			t.Errorf("mongoServes(%s) = %v, want %v", method, got, want)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// certIdentities maps client certificate common names to the username they act as.
+// An empty username marks a service identity, which is authenticated without being a user.
+type certIdentities map[string]string
+
+// newTLSConfig returns the server TLS configuration of cfg, or nil to serve plaintext when it has no certificate.
+// With a client CA, clients may also present a certificate signed by that CA (mutual TLS).
+func newTLSConfig(cfg TLSConfig) (*tls.Config, error) {
+	if cfg.CertFile == "" {
+		return nil, nil
+	}
+	cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+	config := &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12}
+	if cfg.ClientCAFile != "" {
+		pool, err := loadCertPool(cfg.ClientCAFile)
+		if err != nil {
+			return nil, err
+		}
+		// Certificates are optional so that token-authenticated clients keep working
+		config.ClientCAs = pool
+		config.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+	return config, nil
+}
+
+// loadCertPool reads the PEM certificates in file into a new pool
+func loadCertPool(file string) (*x509.CertPool, error) {
+	pemData, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pemData) {
+		return nil, fmt.Errorf("no certificates found in %s", file)
+	}
+	return pool, nil
+}
+
+// parseCertIdentities parses the MTLS_IDENTITIES entries: "CN=username" for certificates acting as a user
+// and bare "CN" for service identities
+func parseCertIdentities(entries []string) (certIdentities, error) {
+	identities := certIdentities{}
+	for _, entry := range entries {
+		cn, username, _ := strings.Cut(entry, "=")
+		cn, username = strings.TrimSpace(cn), strings.TrimSpace(username)
+		if cn == "" {
+			return nil, fmt.Errorf("invalid MTLS_IDENTITIES entry %q", entry)
+		}
+		if _, dup := identities[cn]; dup {
+			return nil, fmt.Errorf("duplicate MTLS_IDENTITIES entry for %q", cn)
+		}
+		identities[cn] = username
+	}
+	return identities, nil
+}
+
+// gatewayDialCredentials returns the credentials the REST gateway uses to reach the gRPC server over loopback.
+// The server's own certificate is trusted in addition to the system roots, so self-signed certificates work
+// as long as they are valid for "localhost".
+func gatewayDialCredentials(config *tls.Config) credentials.TransportCredentials {
+	if config == nil {
+		return insecure.NewCredentials()
+	}
+	roots, err := x509.SystemCertPool()
+	if err != nil {
+		roots = x509.NewCertPool()
+	}
+	for _, cert := range config.Certificates {
+		for _, der := range cert.Certificate {
+			if c, err := x509.ParseCertificate(der); err == nil {
+				roots.AddCert(c)
+			}
+		}
+	}
+	return credentials.NewTLS(&tls.Config{RootCAs: roots, MinVersion: tls.VersionTLS12})
+}
+
+// verifiedClientCommonName returns the common name of the client certificate verified during the TLS handshake
+func verifiedClientCommonName(ctx context.Context) (string, bool) {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return "", false
+	}
+	info, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(info.State.VerifiedChains) == 0 || len(info.State.PeerCertificates) == 0 {
+		return "", false
+	}
+	return info.State.PeerCertificates[0].Subject.CommonName, true
+}
+
+// authenticateClientCert authenticates a request without a bearer token by its client certificate.
+// It reports false when the request should be authenticated by token instead.
+func authenticateClientCert(ctx context.Context, db *pgxpool.Pool, identities certIdentities) (context.Context, bool, error) {
+	if md, _ := metadata.FromIncomingContext(ctx); len(md.Get("authorization")) > 0 {
+		return ctx, false, nil
+	}
+	cn, ok := verifiedClientCommonName(ctx)
+	if !ok {
+		return ctx, false, nil
+	}
+	username, listed := identities[cn]
+	if !listed {
+		return nil, false, status.Errorf(codes.Unauthenticated, "client certificate %q is not authorized", cn)
+	}
+	if username == "" {
+		ctx = context.WithValue(ctx, serviceKey, cn)
+		return context.WithValue(ctx, roleKey, serviceRole), true, nil
+	}
+
+	var userID int
+	var role, state string
+	if err := db.QueryRow(ctx, "SELECT id, role, state FROM users WHERE username=$1", username).Scan(&userID, &role, &state); err != nil {
+		return nil, false, status.Errorf(codes.Unauthenticated, "client certificate %q maps to unknown user %q", cn, username)
+	}
+	if err := checkAccountState(state); err != nil {
+		return nil, false, status.Errorf(codes.Unauthenticated, "client certificate %q maps to user %q: %v", cn, username, err)
+	}
+	ctx = context.WithValue(ctx, userIDKey, userID)
+	ctx = context.WithValue(ctx, usernameKey, username)
+	ctx = context.WithValue(ctx, roleKey, role)
+	return ctx, true, nil
+}
+
+// serviceFromContext returns the service identity of a request authenticated by client certificate
+func serviceFromContext(ctx context.Context) (string, bool) {
+	name, ok := ctx.Value(serviceKey).(string)
+	return name, ok
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+func TestParseCertIdentities(t *testing.T) {
+	cfg, err := loadTestConfig(t, map[string]string{"MTLS_IDENTITIES": " billing , reporting=alice,"})
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	got, err := parseCertIdentities(cfg.TLS.Identities)
+	if err != nil {
+		t.Fatalf("parseCertIdentities() error = %v", err)
+	}
+	if len(got) != 2 || got["billing"] != "" || got["reporting"] != "alice" {
+		t.Errorf("parseCertIdentities() = %v", got)
+	}
+
+	for _, bad := range [][]string{{"=alice"}, {"billing", "billing=bob"}} {
+		if _, err := parseCertIdentities(bad); err == nil {
+			t.Errorf("parseCertIdentities(%q) should fail", bad)
+		}
+	}
+}
+
+func TestNewTLSConfig(t *testing.T) {
+	if config, err := newTLSConfig(TLSConfig{}); config != nil || err != nil {
+		t.Errorf("newTLSConfig() = %v, %v, want plaintext", config, err)
+	}
+	if _, err := newTLSConfig(TLSConfig{CertFile: "missing.pem", KeyFile: "missing-key.pem"}); err == nil {
+		t.Error("newTLSConfig() of missing files should fail")
+	}
+}
+
+func TestAuthenticateClientCert(t *testing.T) {
+	withCert := func(cn string, verified bool) context.Context {
+		cert := &x509.Certificate{Subject: pkix.Name{CommonName: cn}}
+		state := tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}}
+		if verified {
+			state.VerifiedChains = [][]*x509.Certificate{{cert}}
+		}
+		return peer.NewContext(context.Background(), &peer.Peer{AuthInfo: credentials.TLSInfo{State: state}})
+	}
+	identities := certIdentities{"billing": ""}
+
+	ctx, ok, err := authenticateClientCert(withCert("billing", true), nil, identities)
+	if err != nil || !ok {
+		t.Fatalf("authenticateClientCert(service) = %v, %v", ok, err)
+	}
+	if name, ok := serviceFromContext(ctx); !ok || name != "billing" {
+		t.Errorf("serviceFromContext() = %q, %v, want billing", name, ok)
+	}
+	if _, _, ok := userFromContext(ctx); ok {
+		t.Error("a service identity should not be a user")
+	}
+
+	if _, ok, err := authenticateClientCert(withCert("billing", false), nil, identities); ok || err != nil {
+		t.Errorf("unverified certificate = %v, %v, want token authentication", ok, err)
+	}
+
+	_, _, err = authenticateClientCert(withCert("intruder", true), nil, identities)
+	if status.Code(err) != codes.Unauthenticated {
+		t.Errorf("unlisted certificate error = %v, want Unauthenticated", err)
+	}
+
+	tokenCtx := metadata.NewIncomingContext(withCert("billing", true), metadata.Pairs("authorization", "Bearer abc"))
+	if _, ok, err := authenticateClientCert(tokenCtx, nil, identities); ok || err != nil {
+		t.Errorf("request with token = %v, %v, want token authentication", ok, err)
+	}
+}
@@ -0,0 +1,287 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	pb "example/grpc_demo/library"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// notificationBuffer is how many undelivered notifications a subscriber may queue before new ones are dropped
+const notificationBuffer = 16
+
+// notificationEvent is a published notification and its number in the sequence of its user's notifications
+type notificationEvent struct {
+	seq uint64
+	n   *pb.Notification
+}
+
+// recipient is a user of a library; user IDs are only unique within one
+type recipient struct {
+	tenant string
+	userID int
+}
+
+// recipientOf is the user userID of the library of ctx
+func recipientOf(ctx context.Context, userID int) recipient {
+	return recipient{tenant: tenantFromContext(ctx), userID: userID}
+}
+
+// notificationHub fans out notifications to the user's open Subscribe streams, and keeps the recent ones
+// of each user so that streams resuming after a reconnect get those they missed. It is in-process only:
+// a restart loses the notifications kept.
+type notificationHub struct {
+	mu   sync.Mutex
+	subs map[recipient]map[chan notificationEvent]struct{}
+	// epoch tells this process's resume tokens from an earlier one's, as sequences start over at every start
+	epoch string
+	// last is the sequence number of each user's latest notification, and history their most recent ones
+	last        map[recipient]uint64
+	history     map[recipient][]notificationEvent
+	historySize int
+	keepalive   time.Duration
+}
+
+func newNotificationHub(cfg NotificationConfig) *notificationHub {
+	return &notificationHub{
+		subs:        make(map[recipient]map[chan notificationEvent]struct{}),
+		epoch:       strconv.FormatInt(time.Now().UnixNano(), 36),
+		last:        make(map[recipient]uint64),
+		history:     make(map[recipient][]notificationEvent),
+		historySize: cfg.History,
+		keepalive:   cfg.KeepaliveInterval,
+	}
+}
+
+// subscribe registers a channel for a user's notifications; the returned func unregisters it
+func (h *notificationHub) subscribe(to recipient) (<-chan notificationEvent, func()) {
+	ch := make(chan notificationEvent, notificationBuffer)
+	h.mu.Lock()
+	if h.subs[to] == nil {
+		h.subs[to] = make(map[chan notificationEvent]struct{})
+	}
+	h.subs[to][ch] = struct{}{}
+	h.mu.Unlock()
+
+	return ch, func() {
+		h.mu.Lock()
+		delete(h.subs[to], ch)
+		if len(h.subs[to]) == 0 {
+			delete(h.subs, to)
+		}
+		h.mu.Unlock()
+	}
+}
+
+// publish numbers a notification, keeps it and delivers it to every stream the user has open without
+// blocking. A nil hub discards notifications, so handlers work without one.
+func (h *notificationHub) publish(to recipient, n *pb.Notification) {
+	if h == nil {
+		return
+	}
+	if n.CreatedAt == nil {
+		n.CreatedAt = timestamppb.Now()
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.last[to]++
+	e := notificationEvent{seq: h.last[to], n: n}
+	n.ResumeToken = h.resumeToken(e.seq)
+	if h.historySize > 0 {
+		history := append(h.history[to], e)
+		if len(history) > h.historySize {
+			history = slices.Clone(history[len(history)-h.historySize:])
+		}
+		h.history[to] = history
+	}
+	for ch := range h.subs[to] {
+		select {
+		case ch <- e:
+		default:
+			// The stream catches up from the history when it sees the gap
+			slog.Warn("dropping notification: subscriber is not keeping up", "type", n.GetType().String(), "user_id", to.userID)
+		}
+	}
+}
+
+// resumeToken is the token of the position after notification seq
+func (h *notificationHub) resumeToken(seq uint64) string {
+	return h.epoch + "." + strconv.FormatUint(seq, 10)
+}
+
+// resumePosition is the sequence number a stream resuming after token starts from; a token of an earlier
+// process resumes from the start of this one's, with the events before that missed. An empty token starts
+// from the user's latest notification.
+func (h *notificationHub) resumePosition(to recipient, token string) (seq uint64, missed bool, err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if token == "" {
+		return h.last[to], false, nil
+	}
+	epoch, rawSeq, ok := strings.Cut(token, ".")
+	seq, parseErr := strconv.ParseUint(rawSeq, 10, 64)
+	if !ok || parseErr != nil {
+		return 0, false, badRequest("resume_token", "not a token of a notification")
+	}
+	if epoch != h.epoch {
+		return 0, true, nil
+	}
+	if seq > h.last[to] {
+		return 0, false, badRequest("resume_token", "not a token of a notification")
+	}
+	return seq, false, nil
+}
+
+// latest is the sequence number of the user's latest notification
+func (h *notificationHub) latest(to recipient) uint64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.last[to]
+}
+
+// since returns the kept notifications of the user after seq; complete is false when some of them are
+// no longer kept
+func (h *notificationHub) since(to recipient, seq uint64) (events []notificationEvent, complete bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	history := h.history[to]
+	i, _ := slices.BinarySearchFunc(history, seq+1, func(e notificationEvent, seq uint64) int { return cmp.Compare(e.seq, seq) })
+	events = slices.Clone(history[i:])
+	next := h.last[to] + 1
+	if len(events) > 0 {
+		next = events[0].seq
+	}
+	return events, next == seq+1
+}
+
+// notifyHoldReady tells the owner of a promoted reservation that their copy is waiting.
+// It runs after the promoting transaction commits, so failures only cost the notification.
+func (s *server) notifyHoldReady(ctx context.Context, reservationID int64) {
+	if reservationID == 0 || s.notifications == nil {
+		return
+	}
+	var userID int
+	var bookID, title string
+	err := s.db.QueryRow(ctx,
+		"SELECT r.user_id, r.book_id, b.title FROM reservations r JOIN books b ON b.id = r.book_id WHERE r.id=$1",
+		reservationID).Scan(&userID, &bookID, &title)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to load reservation for notification", "reservation_id", reservationID, "error", err)
+		return
+	}
+	s.notifications.publish(recipientOf(ctx, userID), &pb.Notification{
+		Type:        pb.NotificationType_NOTIFICATION_TYPE_HOLD_READY,
+		Message:     fmt.Sprintf("Your reserved book %q is available for pickup", title),
+		BookId:      bookID,
+		ReferenceId: reservationID,
+	})
+}
+
+func (s *server) Subscribe(req *pb.NotificationRequest, stream pb.NotificationService_SubscribeServer) error {
+	ctx := stream.Context()
+	userID, _, ok := userFromContext(ctx)
+	if !ok {
+		return status.Error(codes.Unauthenticated, "authentication required")
+	}
+	if s.notifications == nil {
+		return status.Error(codes.Unimplemented, "notifications are not enabled")
+	}
+	hub, to := s.notifications, recipientOf(ctx, userID)
+
+	// Subscribing before reading the position means no event falls between the two; events delivered
+	// again by both are skipped by their sequence number
+	events, unsubscribe := hub.subscribe(to)
+	defer unsubscribe()
+	position, missed, err := hub.resumePosition(to, req.GetResumeToken())
+	if err != nil {
+		return err
+	}
+
+	types := req.GetTypes()
+	// sent tells whether anything went out since the last keepalive tick
+	sent := false
+	// deliver sends the events after position that the stream has not sent yet
+	deliver := func(pending []notificationEvent, complete bool) error {
+		if !complete || missed {
+			missed = false
+			if len(pending) > 0 {
+				position = pending[0].seq - 1
+			} else {
+				position = hub.latest(to)
+			}
+			if err := stream.Send(&pb.Notification{
+				Type:        pb.NotificationType_NOTIFICATION_TYPE_EVENTS_MISSED,
+				Message:     localize(ctx, "Some notifications could not be delivered"),
+				CreatedAt:   timestamppb.Now(),
+				ResumeToken: hub.resumeToken(position),
+			}); err != nil {
+				return err
+			}
+		}
+		for _, e := range pending {
+			if e.seq <= position {
+				continue
+			}
+			position = e.seq
+			if len(types) > 0 && !slices.Contains(types, e.n.GetType()) {
+				continue
+			}
+			if err := stream.Send(e.n); err != nil {
+				return err
+			}
+			sent = true
+		}
+		return nil
+	}
+	if err := deliver(hub.since(to, position)); err != nil {
+		return err
+	}
+
+	var keepalive <-chan time.Time
+	if hub.keepalive > 0 {
+		ticker := time.NewTicker(hub.keepalive)
+		defer ticker.Stop()
+		keepalive = ticker.C
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case e := <-events:
+			if e.seq <= position {
+				continue
+			}
+			pending, complete := []notificationEvent{e}, true
+			if e.seq > position+1 {
+				// Events were dropped while the stream was behind
+				pending, complete = hub.since(to, position)
+			}
+			if err := deliver(pending, complete); err != nil {
+				return err
+			}
+		case <-keepalive:
+			if sent {
+				sent = false
+				continue
+			}
+			if err := stream.Send(&pb.Notification{
+				Type:        pb.NotificationType_NOTIFICATION_TYPE_KEEPALIVE,
+				CreatedAt:   timestamppb.Now(),
+				ResumeToken: hub.resumeToken(position),
+			}); err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	pb "example/grpc_demo/library"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func testNotificationHub() *notificationHub {
+	return newNotificationHub(NotificationConfig{History: 5})
+}
+
+func TestNotificationHubDeliversToSubscriber(t *testing.T) {
+	hub := testNotificationHub()
+	events, unsubscribe := hub.subscribe(recipient{userID: 1})
+	other, unsubscribeOther := hub.subscribe(recipient{userID: 2})
+	defer unsubscribeOther()
+	// The user with the same ID in another library
+	elsewhere, unsubscribeElsewhere := hub.subscribe(recipient{tenant: "riverside", userID: 1})
+	defer unsubscribeElsewhere()
+
+	hub.publish(recipient{userID: 1}, &pb.Notification{Type: pb.NotificationType_NOTIFICATION_TYPE_HOLD_READY, BookId: "book1"})
+
+	select {
+	case e := <-events:
+		if e.n.GetBookId() != "book1" || e.n.GetCreatedAt() == nil || e.seq != 1 || e.n.GetResumeToken() != hub.resumeToken(1) {
+			t.Errorf("received %v (#%d), want book1 #1 with a timestamp and resume token", e.n, e.seq)
+		}
+	default:
+		t.Fatal("subscriber did not receive the notification")
+	}
+	select {
+	case e := <-other:
+		t.Errorf("other user received %v", e.n)
+	case e := <-elsewhere:
+		t.Errorf("user of another library received %v", e.n)
+	default:
+	}
+
+	unsubscribe()
+	if _, ok := hub.subs[recipient{userID: 1}]; ok {
+		t.Error("unsubscribe did not remove the user's subscription")
+	}
+	hub.publish(recipient{userID: 1}, &pb.Notification{})
+}
+
+func TestNotificationHubDropsWhenBufferFull(t *testing.T) {
+	hub := testNotificationHub()
+	events, unsubscribe := hub.subscribe(recipient{userID: 1})
+	defer unsubscribe()
+
+	for range notificationBuffer + 5 {
+		hub.publish(recipient{userID: 1}, &pb.Notification{})
+	}
+	if got := len(events); got != notificationBuffer {
+		t.Errorf("queued %d notifications, want %d", got, notificationBuffer)
+	}
+}
+
+func TestNilNotificationHubPublish(t *testing.T) {
+	var hub *notificationHub
+	hub.publish(recipient{userID: 1}, &pb.Notification{})
+}
+
+func TestNotificationHubHistory(t *testing.T) {
+	hub := testNotificationHub()
+	for range 8 {
+		hub.publish(recipient{userID: 1}, &pb.Notification{})
+	}
+	// Only the last 5 are kept
+	if events, complete := hub.since(recipient{userID: 1}, 5); len(events) != 3 || events[0].seq != 6 || !complete {
+		t.Errorf("since(5) = %d events from #%d, complete %v; want #6 to #8", len(events), events[0].seq, complete)
+	}
+	if events, complete := hub.since(recipient{userID: 1}, 1); len(events) != 5 || complete {
+		t.Errorf("since(1) = %d events, complete %v; want the 5 kept, incomplete", len(events), complete)
+	}
+	if events, complete := hub.since(recipient{userID: 1}, 8); len(events) != 0 || !complete {
+		t.Errorf("since(8) = %d events, complete %v; want none, complete", len(events), complete)
+	}
+	if events, complete := hub.since(recipient{userID: 2}, 0); len(events) != 0 || !complete {
+		t.Errorf("since(0) of a user without notifications = %d events, complete %v", len(events), complete)
+	}
+}
+
+func TestNotificationHubResumePosition(t *testing.T) {
+	hub := testNotificationHub()
+	hub.publish(recipient{userID: 1}, &pb.Notification{})
+	hub.publish(recipient{userID: 1}, &pb.Notification{})
+
+	if seq, missed, err := hub.resumePosition(recipient{userID: 1}, ""); seq != 2 || missed || err != nil {
+		t.Errorf("without a token: position %d, missed %v, error %v; want the latest", seq, missed, err)
+	}
+	if seq, missed, err := hub.resumePosition(recipient{userID: 1}, hub.resumeToken(1)); seq != 1 || missed || err != nil {
+		t.Errorf("after #1: position %d, missed %v, error %v", seq, missed, err)
+	}
+	// A token of an earlier process resumes from the start, with what came before it missed
+	if seq, missed, err := hub.resumePosition(recipient{userID: 1}, "earlier.7"); seq != 0 || !missed || err != nil {
+		t.Errorf("earlier epoch: position %d, missed %v, error %v", seq, missed, err)
+	}
+	for _, token := range []string{"garbage", hub.epoch + ".x", hub.resumeToken(3)} {
+		if _, _, err := hub.resumePosition(recipient{userID: 1}, token); status.Code(err) != codes.InvalidArgument {
+			t.Errorf("token %q: error = %v, want InvalidArgument", token, err)
+		}
+	}
+}
+
+// subscribeStream records what a Subscribe call sends
+type subscribeStream struct {
+	grpc.ServerStream
+	ctx  context.Context
+	mu   sync.Mutex
+	sent []*pb.Notification
+}
+
+func (s *subscribeStream) Context() context.Context { return s.ctx }
+
+func (s *subscribeStream) Send(n *pb.Notification) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sent = append(s.sent, n)
+	return nil
+}
+
+func (s *subscribeStream) notifications() []*pb.Notification {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*pb.Notification(nil), s.sent...)
+}
+
+// subscribe runs Subscribe for user 1 until the returned func is called, which returns what it sent
+func subscribe(t *testing.T, s *server, req *pb.NotificationRequest) func() []*pb.Notification {
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), userIDKey, 1))
+	stream := &subscribeStream{ctx: ctx}
+	done := make(chan error)
+	go func() { done <- s.Subscribe(req, stream) }()
+	return func() []*pb.Notification {
+		// Let the stream take in what was published before stopping it
+		time.Sleep(50 * time.Millisecond)
+		cancel()
+		if err := <-done; err != nil {
+			t.Errorf("Subscribe() error = %v", err)
+		}
+		return stream.notifications()
+	}
+}
+
+func notificationTypes(sent []*pb.Notification) []pb.NotificationType {
+	types := make([]pb.NotificationType, len(sent))
+	for i, n := range sent {
+		types[i] = n.GetType()
+	}
+	return types
+}
+
+func TestSubscribeResume(t *testing.T) {
+	s := &server{notifications: testNotificationHub()}
+	s.notifications.publish(recipient{userID: 1}, &pb.Notification{Type: pb.NotificationType_NOTIFICATION_TYPE_HOLD_READY, BookId: "b1"})
+	s.notifications.publish(recipient{userID: 1}, &pb.Notification{Type: pb.NotificationType_NOTIFICATION_TYPE_HOLD_READY, BookId: "b2"})
+
+	// Resuming after b1 replays b2, then streams new notifications
+	stop := subscribe(t, s, &pb.NotificationRequest{ResumeToken: s.notifications.resumeToken(1)})
+	time.Sleep(20 * time.Millisecond)
+	s.notifications.publish(recipient{userID: 1}, &pb.Notification{Type: pb.NotificationType_NOTIFICATION_TYPE_HOLD_READY, BookId: "b3"})
+	sent := stop()
+	if len(sent) != 2 || sent[0].GetBookId() != "b2" || sent[1].GetBookId() != "b3" {
+		t.Fatalf("sent %v, want b2 and b3", sent)
+	}
+
+	// Without a token only new notifications are sent
+	stop = subscribe(t, s, &pb.NotificationRequest{})
+	if sent := stop(); len(sent) != 0 {
+		t.Errorf("without a token: sent %v, want nothing", sent)
+	}
+}
+
+func TestSubscribeEventsMissed(t *testing.T) {
+	s := &server{notifications: testNotificationHub()}
+	for range 8 {
+		s.notifications.publish(recipient{userID: 1}, &pb.Notification{Type: pb.NotificationType_NOTIFICATION_TYPE_HOLD_READY})
+	}
+	// #2 and #3 are no longer kept, so the stream says so before replaying #4 to #8
+	sent := subscribe(t, s, &pb.NotificationRequest{ResumeToken: s.notifications.resumeToken(1)})()
+	if len(sent) != 6 || sent[0].GetType() != pb.NotificationType_NOTIFICATION_TYPE_EVENTS_MISSED ||
+		sent[0].GetResumeToken() != s.notifications.resumeToken(3) || sent[1].GetResumeToken() != s.notifications.resumeToken(4) {
+		t.Errorf("sent %v, want EVENTS_MISSED and then #4 to #8", notificationTypes(sent))
+	}
+}
+
+func TestSubscribeKeepalive(t *testing.T) {
+	hub := newNotificationHub(NotificationConfig{KeepaliveInterval: 10 * time.Millisecond, History: 5})
+	s := &server{notifications: hub}
+	hub.publish(recipient{userID: 1}, &pb.Notification{Type: pb.NotificationType_NOTIFICATION_TYPE_HOLD_READY})
+	// Filtered out notifications still move the position that keepalives resume from
+	hub.publish(recipient{userID: 1}, &pb.Notification{Type: pb.NotificationType_NOTIFICATION_TYPE_LOAN_OVERDUE})
+
+	sent := subscribe(t, s, &pb.NotificationRequest{
+		Types:       []pb.NotificationType{pb.NotificationType_NOTIFICATION_TYPE_HOLD_READY},
+		ResumeToken: hub.resumeToken(0),
+	})()
+	if len(sent) < 2 || sent[0].GetType() != pb.NotificationType_NOTIFICATION_TYPE_HOLD_READY {
+		t.Fatalf("sent %v, want the hold and keepalives", notificationTypes(sent))
+	}
+	for _, n := range sent[1:] {
+		if n.GetType() != pb.NotificationType_NOTIFICATION_TYPE_KEEPALIVE || n.GetResumeToken() != hub.resumeToken(2) {
+			t.Errorf("sent %v with token %s, want keepalives resuming after #2", n.GetType(), n.GetResumeToken())
+		}
+	}
+}
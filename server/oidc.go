@@ -0,0 +1,383 @@
+package main
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	pb "example/grpc_demo/library"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/jackc/pgx/v5"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	oidcHTTPTimeout = 5 * time.Second
+	// jwksCacheTTL is how long fetched signing keys are trusted before they are fetched again
+	jwksCacheTTL = time.Hour
+	// jwksMinRefresh limits refetches triggered by tokens signed with an unknown key
+	jwksMinRefresh = time.Minute
+	// oidcClockSkew tolerates small clock differences with the provider
+	oidcClockSkew = time.Minute
+	// maxOIDCUsernameLength bounds usernames derived from provider claims
+	maxOIDCUsernameLength = 50
+)
+
+// oidcSigningMethods are the ID token algorithms accepted from the provider
+var oidcSigningMethods = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512"}
+
+// oidcClaims are the ID token claims used to identify and provision users
+type oidcClaims struct {
+	Email             string `json:"email"`
+	EmailVerified     bool   `json:"email_verified"`
+	PreferredUsername string `json:"preferred_username"`
+	jwt.RegisteredClaims
+}
+
+// oidcVerifier validates ID tokens issued by one OpenID Connect provider against its published keys
+type oidcVerifier struct {
+	issuer   string
+	clientID string
+	client   *http.Client
+
+	mu        sync.Mutex
+	jwksURL   string
+	keys      map[string]crypto.PublicKey
+	fetchedAt time.Time
+}
+
+// newOIDCVerifier configures ID token login from the issuer and client ID of cfg; it returns nil when no issuer
+// is configured. The signing keys are read from cfg.JWKSURL, or from the issuer's discovery document when that is unset.
+func newOIDCVerifier(cfg OIDCConfig) *oidcVerifier {
+	issuer := strings.TrimSuffix(cfg.Issuer, "/")
+	if issuer == "" {
+		return nil
+	}
+	return &oidcVerifier{
+		issuer:   issuer,
+		clientID: cfg.ClientID,
+		client:   &http.Client{Timeout: oidcHTTPTimeout},
+		jwksURL:  cfg.JWKSURL,
+	}
+}
+
+// Verify checks the signature, issuer, audience and lifetime of an ID token
+func (v *oidcVerifier) Verify(ctx context.Context, rawToken string) (*oidcClaims, error) {
+	claims := &oidcClaims{}
+	_, err := jwt.ParseWithClaims(rawToken, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return v.key(ctx, kid)
+	},
+		jwt.WithValidMethods(oidcSigningMethods),
+		jwt.WithIssuer(v.issuer),
+		jwt.WithAudience(v.clientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(oidcClockSkew),
+	)
+	if err != nil {
+		return nil, err
+	}
+	if claims.Subject == "" {
+		return nil, errors.New("ID token has no subject")
+	}
+	return claims, nil
+}
+
+// key returns the provider key with the given ID, fetching the key set when it is stale or lacks the key
+func (v *oidcVerifier) key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	now := time.Now()
+	key, ok := v.keys[kid]
+	stale := now.Sub(v.fetchedAt) > jwksCacheTTL
+	if ok && !stale {
+		return key, nil
+	}
+	if stale || now.Sub(v.fetchedAt) > jwksMinRefresh {
+		keys, err := v.fetchKeys(ctx)
+		if err != nil {
+			if ok {
+				// Keep using a known key while the provider is unreachable
+				slog.Warn("failed to refresh OIDC signing keys", "error", err)
+				return key, nil
+			}
+			return nil, err
+		}
+		v.keys, v.fetchedAt = keys, now
+		if key, ok = keys[kid]; ok {
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
+// fetchKeys downloads the provider's key set, discovering its location first if needed
+func (v *oidcVerifier) fetchKeys(ctx context.Context) (map[string]crypto.PublicKey, error) {
+	if v.jwksURL == "" {
+		var discovery struct {
+			Issuer  string `json:"issuer"`
+			JWKSURI string `json:"jwks_uri"`
+		}
+		if err := v.getJSON(ctx, v.issuer+"/.well-known/openid-configuration", &discovery); err != nil {
+			return nil, fmt.Errorf("OIDC discovery failed: %w", err)
+		}
+		if discovery.JWKSURI == "" {
+			return nil, errors.New("OIDC discovery document has no jwks_uri")
+		}
+		v.jwksURL = discovery.JWKSURI
+	}
+	var set jsonWebKeySet
+	if err := v.getJSON(ctx, v.jwksURL, &set); err != nil {
+		return nil, fmt.Errorf("failed to fetch OIDC signing keys: %w", err)
+	}
+	return set.publicKeys()
+}
+
+func (v *oidcVerifier) getJSON(ctx context.Context, url string, dst any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s returned HTTP %d", url, resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(dst)
+}
+
+// jsonWebKeySet is a JWKS document (RFC 7517)
+type jsonWebKeySet struct {
+	Keys []jsonWebKey `json:"keys"`
+}
+
+type jsonWebKey struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Use string `json:"use,omitempty"`
+	Alg string `json:"alg,omitempty"`
+	// RSA
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// EC and OKP
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// publicKeys returns the signature keys of the set by key ID, skipping encryption keys and unsupported key types
+func (s jsonWebKeySet) publicKeys() (map[string]crypto.PublicKey, error) {
+	keys := make(map[string]crypto.PublicKey)
+	for _, k := range s.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		var (
+			key crypto.PublicKey
+			err error
+		)
+		switch k.Kty {
+		case "RSA":
+			key, err = k.rsaKey()
+		case "EC":
+			key, err = k.ecKey()
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid key %q: %w", k.Kid, err)
+		}
+		keys[k.Kid] = key
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("key set has no usable signing keys")
+	}
+	return keys, nil
+}
+
+func (k jsonWebKey) rsaKey() (*rsa.PublicKey, error) {
+	n, err := base64.RawURLEncoding.DecodeString(k.N)
+	if err != nil {
+		return nil, err
+	}
+	e, err := base64.RawURLEncoding.DecodeString(k.E)
+	if err != nil {
+		return nil, err
+	}
+	exponent := new(big.Int).SetBytes(e)
+	if len(n) == 0 || !exponent.IsInt64() || exponent.Int64() < 3 || exponent.Int64() > 1<<31-1 {
+		return nil, errors.New("malformed RSA key")
+	}
+	return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exponent.Int64())}, nil
+}
+
+func (k jsonWebKey) ecKey() (*ecdsa.PublicKey, error) {
+	var curve elliptic.Curve
+	switch k.Crv {
+	case "P-256":
+		curve = elliptic.P256()
+	case "P-384":
+		curve = elliptic.P384()
+	case "P-521":
+		curve = elliptic.P521()
+	default:
+		return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+	}
+	x, err := base64.RawURLEncoding.DecodeString(k.X)
+	if err != nil {
+		return nil, err
+	}
+	y, err := base64.RawURLEncoding.DecodeString(k.Y)
+	if err != nil {
+		return nil, err
+	}
+	key := &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+	if !curve.IsOnCurve(key.X, key.Y) {
+		return nil, errors.New("point is not on the curve")
+	}
+	return key, nil
+}
+
+// oidcUsername derives a local username from the ID token, preferring the provider's username
+func oidcUsername(claims *oidcClaims) string {
+	name := claims.PreferredUsername
+	if name == "" {
+		name, _, _ = strings.Cut(claims.Email, "@")
+	}
+	name = strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '_', r == '-':
+			return r
+		}
+		return -1
+	}, name)
+	if len(name) > maxOIDCUsernameLength {
+		name = name[:maxOIDCUsernameLength]
+	}
+	if name == "" {
+		name = "user"
+	}
+	return name
+}
+
+func (s *server) LoginWithIdToken(ctx context.Context, req *pb.IdTokenLoginRequest) (*pb.AuthResponse, error) {
+	if s.oidc == nil {
+		return nil, status.Error(codes.FailedPrecondition, "OIDC login is not configured")
+	}
+	if req.GetIdToken() == "" {
+		return nil, status.Error(codes.InvalidArgument, "ID token is required")
+	}
+	claims, err := s.oidc.Verify(ctx, req.GetIdToken())
+	if err != nil {
+		return nil, status.Errorf(codes.Unauthenticated, "invalid ID token: %v", err)
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "database error: %v", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Serialize first logins of the same identity so it is provisioned once
+	if _, err := tx.Exec(ctx, "SELECT pg_advisory_xact_lock(hashtext($1 || ' ' || $2))", claims.Issuer, claims.Subject); err != nil {
+		return nil, status.Errorf(codes.Internal, "database error: %v", err)
+	}
+	var (
+		userID   int
+		username string
+	)
+	err = tx.QueryRow(ctx,
+		`SELECT u.id, u.username FROM user_identities i JOIN users u ON u.id = i.user_id
+		 WHERE i.issuer=$1 AND i.subject=$2`,
+		claims.Issuer, claims.Subject).Scan(&userID, &username)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return nil, status.Errorf(codes.Internal, "database error: %v", err)
+	}
+	provisioned := errors.Is(err, pgx.ErrNoRows)
+	if provisioned {
+		if userID, username, err = s.provisionOIDCUser(ctx, tx, claims); err != nil {
+			return nil, err
+		}
+	}
+
+	resp, err := issueTokens(ctx, tx, userID, username, 0, "Login successful")
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to issue tokens: %v", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, status.Errorf(codes.Internal, "database error: %v", err)
+	}
+	if provisioned {
+		s.recordAuthEvent(ctx, pb.AuthEventType_AUTH_EVENT_TYPE_REGISTER, userID, username, "oidc")
+	}
+	s.recordLogin(ctx, userID)
+	s.recordAuthEvent(ctx, pb.AuthEventType_AUTH_EVENT_TYPE_LOGIN, userID, username, "oidc")
+	return resp, nil
+}
+
+// provisionOIDCUser creates the local user for a first login with an ID token.
+// The user has no password; a verified email address is copied unless another account already uses it,
+// as linking to an existing account by email would let the provider take it over.
+func (s *server) provisionOIDCUser(ctx context.Context, tx pgx.Tx, claims *oidcClaims) (int, string, error) {
+	var email string
+	if claims.EmailVerified && claims.Email != "" {
+		if normalized, err := normalizeEmail(claims.Email); err == nil {
+			var taken bool
+			err := tx.QueryRow(ctx, "SELECT EXISTS(SELECT 1 FROM users WHERE lower(email)=lower($1))", normalized).Scan(&taken)
+			if err != nil {
+				return 0, "", status.Errorf(codes.Internal, "database error: %v", err)
+			}
+			if !taken {
+				email = normalized
+			}
+		}
+	}
+	if s.runtime().requireEmail && email == "" {
+		return 0, "", status.Error(codes.FailedPrecondition, "the identity provider did not supply an unused, verified email address")
+	}
+
+	base := oidcUsername(claims)
+	var userID int
+	username := base
+	for n := 2; ; n++ {
+		err := tx.QueryRow(ctx,
+			`INSERT INTO users (username, password_hash, email, email_verified_at)
+			 VALUES ($1, '', NULLIF($2, ''), CASE WHEN $2 = '' THEN NULL ELSE NOW() END)
+			 ON CONFLICT (tenant_id, username) DO NOTHING RETURNING id`,
+			username, email).Scan(&userID)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, pgx.ErrNoRows) {
+			return 0, "", status.Errorf(codes.Internal, "failed to create user: %v", err)
+		}
+		suffix := "-" + strconv.Itoa(n)
+		username = base[:min(len(base), maxOIDCUsernameLength-len(suffix))] + suffix
+	}
+
+	_, err := tx.Exec(ctx, "INSERT INTO user_identities (issuer, subject, user_id) VALUES ($1, $2, $3)",
+		claims.Issuer, claims.Subject, userID)
+	if err != nil {
+		return 0, "", status.Errorf(codes.Internal, "failed to link identity: %v", err)
+	}
+	return userID, username, nil
+}
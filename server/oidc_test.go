@@ -0,0 +1,129 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestOIDCVerify(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b64 := func(b []byte) string { return base64.RawURLEncoding.EncodeToString(b) }
+	jwks := map[string]any{"keys": []map[string]string{
+		{"kid": "rsa-1", "kty": "RSA", "use": "sig", "n": b64(rsaKey.N.Bytes()), "e": b64(big.NewInt(int64(rsaKey.E)).Bytes())},
+		{"kid": "ec-1", "kty": "EC", "crv": "P-256", "x": b64(ecKey.X.Bytes()), "y": b64(ecKey.Y.Bytes())},
+		{"kid": "enc-1", "kty": "RSA", "use": "enc", "n": "AQAB", "e": "AQAB"},
+	}}
+
+	var ts *httptest.Server
+	ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/.well-known/openid-configuration":
+			json.NewEncoder(w).Encode(map[string]string{"issuer": ts.URL, "jwks_uri": ts.URL + "/keys"})
+		case "/keys":
+			json.NewEncoder(w).Encode(jwks)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+
+	v := newOIDCVerifier(OIDCConfig{Issuer: ts.URL + "/", ClientID: "library"})
+	if v == nil || v.issuer != ts.URL {
+		t.Fatalf("newOIDCVerifier() = %+v", v)
+	}
+
+	sign := func(method jwt.SigningMethod, kid string, key any, edit func(*oidcClaims)) string {
+		claims := &oidcClaims{
+			Email:         "reader@example.com",
+			EmailVerified: true,
+			RegisteredClaims: jwt.RegisteredClaims{
+				Issuer:    ts.URL,
+				Subject:   "1234",
+				Audience:  jwt.ClaimStrings{"library"},
+				ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+				IssuedAt:  jwt.NewNumericDate(time.Now()),
+			},
+		}
+		if edit != nil {
+			edit(claims)
+		}
+		token := jwt.NewWithClaims(method, claims)
+		token.Header["kid"] = kid
+		signed, err := token.SignedString(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return signed
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr bool
+	}{
+		{"RSA", sign(jwt.SigningMethodRS256, "rsa-1", rsaKey, nil), false},
+		{"EC", sign(jwt.SigningMethodES256, "ec-1", ecKey, nil), false},
+		{"wrong audience", sign(jwt.SigningMethodRS256, "rsa-1", rsaKey, func(c *oidcClaims) { c.Audience = jwt.ClaimStrings{"other"} }), true},
+		{"wrong issuer", sign(jwt.SigningMethodRS256, "rsa-1", rsaKey, func(c *oidcClaims) { c.Issuer = "https://evil.example.com" }), true},
+		{"expired", sign(jwt.SigningMethodRS256, "rsa-1", rsaKey, func(c *oidcClaims) {
+			c.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Hour))
+		}), true},
+		{"no expiry", sign(jwt.SigningMethodRS256, "rsa-1", rsaKey, func(c *oidcClaims) { c.ExpiresAt = nil }), true},
+		{"no subject", sign(jwt.SigningMethodRS256, "rsa-1", rsaKey, func(c *oidcClaims) { c.Subject = "" }), true},
+		{"unknown key", sign(jwt.SigningMethodRS256, "rsa-2", rsaKey, nil), true},
+		{"key type mismatch", sign(jwt.SigningMethodRS256, "ec-1", rsaKey, nil), true},
+		{"HMAC", sign(jwt.SigningMethodHS256, "rsa-1", []byte("secret"), nil), true},
+	}
+	for _, tt := range tests {
+		claims, err := v.Verify(t.Context(), tt.token)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: Verify() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+		if err == nil && (claims.Subject != "1234" || claims.Email != "reader@example.com" || !claims.EmailVerified) {
+			t.Errorf("%s: Verify() claims = %+v", tt.name, claims)
+		}
+	}
+}
+
+func TestNewOIDCVerifier(t *testing.T) {
+	if v := newOIDCVerifier(OIDCConfig{ClientID: "library"}); v != nil {
+		t.Errorf("newOIDCVerifier() = %v, want disabled without an issuer", v)
+	}
+}
+
+func TestOIDCUsername(t *testing.T) {
+	tests := []struct {
+		claims oidcClaims
+		want   string
+	}{
+		{oidcClaims{PreferredUsername: "jane.doe", Email: "jane@example.com"}, "jane.doe"},
+		{oidcClaims{Email: "jane+library@example.com"}, "janelibrary"},
+		{oidcClaims{PreferredUsername: "Jöhn Smith"}, "JhnSmith"},
+		{oidcClaims{}, "user"},
+		{oidcClaims{PreferredUsername: strings.Repeat("a", 80)}, strings.Repeat("a", maxOIDCUsernameLength)},
+	}
+	for _, tt := range tests {
+		if got := oidcUsername(&tt.claims); got != tt.want {
+			t.Errorf("oidcUsername(%+v) = %q, want %q", tt.claims, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,275 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"example/grpc_demo/server/storage"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// outboxLockKey prefixes the advisory locks held by the replica relaying the outbox of a library, so that
+// replicas never publish the events of one library out of order
+const outboxLockKey = "outbox:"
+
+// outboxWebhookTimeout bounds each delivery to OUTBOX_WEBHOOK_URL
+const outboxWebhookTimeout = 10 * time.Second
+
+// outboxEvent is an event of the outbox table, as published. IDs are unique within a library, so
+// consumers tell events apart, and drop those delivered twice, by tenant and ID.
+type outboxEvent struct {
+	ID          int64           `json:"id"`
+	Type        string          `json:"type"`
+	AggregateID string          `json:"aggregate_id"`
+	Tenant      string          `json:"tenant,omitempty"`
+	CreatedAt   time.Time       `json:"created_at"`
+	Payload     json.RawMessage `json:"payload"`
+}
+
+// eventPublisher delivers the events of the outbox. An event published without error is marked sent and
+// not published again, barring a crash before the mark commits.
+type eventPublisher interface {
+	Publish(ctx context.Context, e outboxEvent) error
+}
+
+// webhookPublisher posts each event as JSON to a URL, which must answer with a 2xx status once it has
+// taken the event
+type webhookPublisher struct {
+	url    string
+	client *http.Client
+}
+
+func (p webhookPublisher) Publish(ctx context.Context, e outboxEvent) error {
+	body, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Event-Type", e.Type)
+	req.Header.Set("X-Event-Id", strconv.FormatInt(e.ID, 10))
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return errors.New("webhook answered " + resp.Status)
+	}
+	return nil
+}
+
+// logPublisher logs the events when no webhook is configured, so that the outbox is emptied all the same
+type logPublisher struct{}
+
+func (logPublisher) Publish(ctx context.Context, e outboxEvent) error {
+	slog.InfoContext(ctx, "outbox event", "id", e.ID, "type", e.Type, "aggregate_id", e.AggregateID, "tenant", e.Tenant)
+	return nil
+}
+
+// eventOutbox is where book changes write their events: the outbox table, or the outbox collection of the
+// MongoDB catalog. The relay reads and marks them in a transaction holding the lock of their library.
+type eventOutbox interface {
+	// pending returns the oldest events of the library of ctx not sent yet, up to limit
+	pending(ctx context.Context, tx pgx.Tx, limit int) ([]outboxEvent, error)
+	// mark records an attempt to publish the event with id, which failed with publishErr if not nil
+	mark(ctx context.Context, tx pgx.Tx, id int64, publishErr error) error
+	// oldest returns when the oldest event of the library of ctx not sent yet was written, the zero time
+	// when there is none
+	oldest(ctx context.Context) (time.Time, error)
+}
+
+// tableOutbox is the outbox table
+type tableOutbox struct {
+	db *pgxpool.Pool
+}
+
+func (o tableOutbox) pending(ctx context.Context, tx pgx.Tx, limit int) ([]outboxEvent, error) {
+	rows, err := tx.Query(ctx,
+		"SELECT id, event_type, aggregate_id, payload, created_at FROM outbox WHERE sent_at IS NULL ORDER BY id LIMIT $1", limit)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (outboxEvent, error) {
+		e := outboxEvent{Tenant: tenantFromContext(ctx)}
+		err := row.Scan(&e.ID, &e.Type, &e.AggregateID, &e.Payload, &e.CreatedAt)
+		return e, err
+	})
+}
+
+func (o tableOutbox) mark(ctx context.Context, tx pgx.Tx, id int64, publishErr error) error {
+	if publishErr != nil {
+		_, err := tx.Exec(ctx, "UPDATE outbox SET attempts = attempts + 1, last_error = $2 WHERE id = $1", id, publishErr.Error())
+		return err
+	}
+	_, err := tx.Exec(ctx, "UPDATE outbox SET attempts = attempts + 1, sent_at = NOW() WHERE id = $1", id)
+	return err
+}
+
+func (o tableOutbox) oldest(ctx context.Context) (time.Time, error) {
+	var createdAt time.Time
+	err := o.db.QueryRow(ctx, "SELECT created_at FROM outbox WHERE sent_at IS NULL ORDER BY id LIMIT 1").Scan(&createdAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return time.Time{}, nil
+	}
+	return createdAt, err
+}
+
+// mongoOutbox is the outbox collection of the MongoDB catalog, which has a single library. Events are
+// marked as they are published rather than when the transaction commits, so a crash publishes fewer of
+// them twice.
+type mongoOutbox struct {
+	catalog *storage.Mongo
+}
+
+func (o mongoOutbox) pending(ctx context.Context, _ pgx.Tx, limit int) ([]outboxEvent, error) {
+	pending, err := o.catalog.PendingEvents(ctx, limit)
+	if err != nil {
+		return nil, err
+	}
+	events := make([]outboxEvent, len(pending))
+	for i, e := range pending {
+		events[i] = outboxEvent{ID: e.ID, Type: e.Type, AggregateID: e.AggregateID, CreatedAt: e.CreatedAt, Payload: e.Payload}
+	}
+	return events, nil
+}
+
+func (o mongoOutbox) mark(ctx context.Context, _ pgx.Tx, id int64, publishErr error) error {
+	return o.catalog.MarkEvent(ctx, id, publishErr)
+}
+
+func (o mongoOutbox) oldest(ctx context.Context) (time.Time, error) {
+	return o.catalog.OldestPendingEvent(ctx)
+}
+
+// outboxRelay publishes the events written to the outbox by book changes, in the order they were written.
+// Every event of a committed change is published at least once: one that fails stays first in line and
+// is retried on the next run.
+type outboxRelay struct {
+	db        *pgxpool.Pool
+	outbox    eventOutbox
+	publisher eventPublisher
+	batchSize int
+}
+
+// newOutboxRelay returns the relay of the outbox table, or of the outbox collection of catalog when it
+// isn't nil
+func newOutboxRelay(db *pgxpool.Pool, catalog *storage.Mongo, cfg OutboxConfig) *outboxRelay {
+	var publisher eventPublisher = logPublisher{}
+	if cfg.WebhookURL != "" {
+		publisher = webhookPublisher{url: cfg.WebhookURL, client: &http.Client{Timeout: outboxWebhookTimeout}}
+	}
+	var outbox eventOutbox = tableOutbox{db}
+	if catalog != nil {
+		outbox = mongoOutbox{catalog}
+	}
+	return &outboxRelay{db: db, outbox: outbox, publisher: publisher, batchSize: cfg.BatchSize}
+}
+
+// run relays the outbox every interval until ctx is cancelled
+func (r *outboxRelay) run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := r.relay(ctx); err != nil {
+			slog.ErrorContext(ctx, "outbox relay failed", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// relay publishes the pending events of every library
+func (r *outboxRelay) relay(ctx context.Context) error {
+	var lag time.Duration
+	err := forEachTenant(ctx, r.db, func(ctx context.Context) error {
+		if err := r.relayTenant(ctx); err != nil {
+			return err
+		}
+		oldest, err := r.oldestPending(ctx)
+		lag = max(lag, oldest)
+		return err
+	})
+	outboxLag.Set(lag.Seconds())
+	return err
+}
+
+// relayTenant publishes the pending events of the library of ctx a batch at a time, until none are left or
+// one fails
+func (r *outboxRelay) relayTenant(ctx context.Context) error {
+	for {
+		sent, err := r.relayBatch(ctx)
+		if err != nil || sent < r.batchSize {
+			return err
+		}
+	}
+}
+
+// relayBatch publishes the oldest pending events of the library of ctx, up to batchSize, and returns how
+// many were sent. It sends none when another replica holds the library's lock, and stops at the first
+// that fails, which records the error for the event.
+func (r *outboxRelay) relayBatch(ctx context.Context) (int, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(context.WithoutCancel(ctx))
+
+	// A transaction lock, released by the commit or rollback whatever happens to the replica
+	var leader bool
+	if err := tx.QueryRow(ctx, "SELECT pg_try_advisory_xact_lock(hashtext($1))", outboxLockKey+tenantFromContext(ctx)).Scan(&leader); err != nil {
+		return 0, err
+	}
+	if !leader {
+		return 0, nil
+	}
+	events, err := r.outbox.pending(ctx, tx, r.batchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, e := range events {
+		if err := r.publisher.Publish(ctx, e); err != nil {
+			outboxFailures.Inc()
+			slog.WarnContext(ctx, "failed to publish outbox event", "id", e.ID, "type", e.Type, "tenant", e.Tenant, "error", err)
+			if err := r.outbox.mark(ctx, tx, e.ID, err); err != nil {
+				return 0, err
+			}
+			break
+		}
+		if err := r.outbox.mark(ctx, tx, e.ID, nil); err != nil {
+			return 0, err
+		}
+		outboxPublished.WithLabelValues(e.Type).Inc()
+		sent++
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("marking events sent: %w", err)
+	}
+	return sent, nil
+}
+
+// oldestPending returns the age of the oldest event of the library of ctx not sent yet, 0 when there is none
+func (r *outboxRelay) oldestPending(ctx context.Context) (time.Duration, error) {
+	createdAt, err := r.outbox.oldest(ctx)
+	if err != nil || createdAt.IsZero() {
+		return 0, err
+	}
+	return time.Since(createdAt), nil
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	pb "example/grpc_demo/library"
+	"example/grpc_demo/server/storage"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func TestWebhookPublisher(t *testing.T) {
+	var got outboxEvent
+	status := http.StatusAccepted
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Event-Type") != "book.created" || r.Header.Get("X-Event-Id") != "7" {
+			t.Errorf("event headers = %v", r.Header)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Error(err)
+		}
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	p := webhookPublisher{url: srv.URL, client: srv.Client()}
+	e := outboxEvent{ID: 7, Type: "book.created", AggregateID: "b1", Tenant: "riverside", Payload: json.RawMessage(`{"book":{"id":"b1"}}`)}
+	if err := p.Publish(context.Background(), e); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+	if got.ID != 7 || got.AggregateID != "b1" || got.Tenant != "riverside" || string(got.Payload) != `{"book":{"id":"b1"}}` {
+		t.Errorf("webhook received %+v", got)
+	}
+
+	status = http.StatusServiceUnavailable
+	if err := p.Publish(context.Background(), e); err == nil {
+		t.Error("Publish() to a failing webhook succeeded")
+	}
+}
+
+// recordingPublisher keeps the events published, failing with err when set
+type recordingPublisher struct {
+	events []outboxEvent
+	err    error
+}
+
+func (p *recordingPublisher) Publish(ctx context.Context, e outboxEvent) error {
+	if p.err != nil {
+		return p.err
+	}
+	p.events = append(p.events, e)
+	return nil
+}
+
+// TestOutboxRelay runs against the database of STORAGE_TEST_DATABASE_URL, whose outbox it empties
+func TestOutboxRelay(t *testing.T) {
+	dsn := os.Getenv("STORAGE_TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("STORAGE_TEST_DATABASE_URL is not set")
+	}
+	ctx := withTenant(context.Background(), "")
+	pool, err := pgxpool.New(ctx, dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Close()
+	if err := RunMigrations(pool); err != nil {
+		t.Fatalf("migrations: %v", err)
+	}
+	cleanup := func() {
+		if _, err := pool.Exec(ctx, "DELETE FROM books WHERE id LIKE 'outbox-test-%'"); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := pool.Exec(ctx, "DELETE FROM outbox"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cleanup()
+	defer cleanup()
+
+	store := storage.NewPostgres(pool)
+	if err := store.Books().Create(ctx, &pb.Book{Id: "outbox-test-1", Title: "Dune"}); err != nil {
+		t.Fatal(err)
+	}
+	// A change rolled back leaves no event behind
+	failed := errors.New("rolled back")
+	err = store.InTx(ctx, func(ctx context.Context) error {
+		if err := store.Books().Create(ctx, &pb.Book{Id: "outbox-test-2", Title: "Emma"}); err != nil {
+			return err
+		}
+		return failed
+	})
+	if !errors.Is(err, failed) {
+		t.Fatalf("InTx() error = %v", err)
+	}
+	if _, err := store.Books().Delete(ctx, "outbox-test-1"); err != nil {
+		t.Fatal(err)
+	}
+
+	publisher := &recordingPublisher{err: errors.New("unreachable")}
+	relay := &outboxRelay{db: pool, outbox: tableOutbox{pool}, publisher: publisher, batchSize: 1}
+	if err := relay.relayTenant(ctx); err != nil {
+		t.Fatal(err)
+	}
+	var attempts int
+	var lastError string
+	if err := pool.QueryRow(ctx, "SELECT attempts, last_error FROM outbox ORDER BY id LIMIT 1").Scan(&attempts, &lastError); err != nil {
+		t.Fatal(err)
+	}
+	if attempts != 1 || lastError != "unreachable" {
+		t.Errorf("failed event has %d attempts, last error %q; want 1 and unreachable", attempts, lastError)
+	}
+
+	publisher.err = nil
+	if err := relay.relayTenant(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if len(publisher.events) != 2 || publisher.events[0].Type != "book.created" || publisher.events[1].Type != "book.deleted" {
+		t.Fatalf("published %+v, want the creation and deletion of outbox-test-1", publisher.events)
+	}
+	var payload struct {
+		Previous json.RawMessage `json:"previous"`
+	}
+	if err := json.Unmarshal(publisher.events[1].Payload, &payload); err != nil || len(payload.Previous) == 0 {
+		t.Errorf("deletion payload %s, want the deleted book", publisher.events[1].Payload)
+	}
+	if lag, err := relay.oldestPending(ctx); lag != 0 || err != nil {
+		t.Errorf("oldestPending() = %s, %v after relaying everything", lag, err)
+	}
+}
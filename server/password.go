@@ -0,0 +1,204 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Argon2id defaults, the OWASP minimum recommendation (19 MiB, 2 passes, 1 lane)
+const (
+	defaultArgon2MemoryKiB   = 19 * 1024
+	defaultArgon2Iterations  = 2
+	defaultArgon2Parallelism = 1
+	argon2SaltLength         = 16
+	argon2KeyLength          = 32
+)
+
+// PasswordHasher hashes passwords with one algorithm and checks hashes it produced
+type PasswordHasher interface {
+	Hash(password string) (string, error)
+	// Recognizes reports whether hash was produced by this algorithm
+	Recognizes(hash string) bool
+	Verify(hash, password string) (bool, error)
+	// NeedsRehash reports whether hash uses weaker parameters than the hasher is configured with
+	NeedsRehash(hash string) bool
+}
+
+// passwordHashing hashes new passwords with the current hasher and still verifies hashes of the others,
+// so stored hashes are upgraded as users log in
+type passwordHashing struct {
+	current PasswordHasher
+	hashers []PasswordHasher
+
+	// dummy is a hash of the current algorithm that unmatched hashes are verified against, so checking
+	// the password of a missing account takes as long as a wrong password
+	dummyOnce sync.Once
+	dummy     string
+}
+
+// newPasswordHashing hashes new passwords with cfg.HashAlgorithm ("argon2id" or "bcrypt"), tuned with the Argon2id
+// parameters of cfg or bcryptCost, and still verifies hashes of the other algorithm
+func newPasswordHashing(cfg PasswordConfig, bcryptCost int) *passwordHashing {
+	argon := &argon2idHasher{
+		memoryKiB:   uint32(cfg.Argon2MemoryKiB),
+		iterations:  uint32(cfg.Argon2Iterations),
+		parallelism: uint32(cfg.Argon2Parallelism),
+	}
+	bc := bcryptHasher{cost: bcryptCost}
+	if strings.EqualFold(cfg.HashAlgorithm, "bcrypt") {
+		return &passwordHashing{current: bc, hashers: []PasswordHasher{bc, argon}}
+	}
+	return &passwordHashing{current: argon, hashers: []PasswordHasher{argon, bc}}
+}
+
+// Hash hashes a new password with the current algorithm
+func (p *passwordHashing) Hash(password string) (string, error) {
+	return p.current.Hash(password)
+}
+
+// Verify checks password against a stored hash of any known algorithm. rehash is true when the password matched
+// but the hash should be replaced with Hash(password). Hashes of no known algorithm, such as the empty hash of
+// accounts that only log in through OIDC or of users that don't exist, never match, but take as long to check.
+func (p *passwordHashing) Verify(hash, password string) (ok, rehash bool, err error) {
+	for _, h := range p.hashers {
+		if !h.Recognizes(hash) {
+			continue
+		}
+		ok, err := h.Verify(hash, password)
+		if !ok || err != nil {
+			return false, false, err
+		}
+		return true, h != p.current || h.NeedsRehash(hash), nil
+	}
+	p.dummyOnce.Do(func() {
+		var secret [16]byte
+		rand.Read(secret[:])
+		p.dummy, _ = p.current.Hash(base64.RawStdEncoding.EncodeToString(secret[:]))
+	})
+	p.current.Verify(p.dummy, password)
+	return false, false, nil
+}
+
+// upgradePasswordHash replaces a user's outdated hash after a successful login. The old hash is matched so a
+// password changed meanwhile is not overwritten; failures are only logged as the old hash still works.
+func (s *server) upgradePasswordHash(ctx context.Context, userID int, oldHash, password string) {
+	hash, err := s.passwords.Hash(password)
+	if err == nil {
+		_, err = s.db.Exec(ctx, "UPDATE users SET password_hash=$1 WHERE id=$2 AND password_hash=$3", hash, userID, oldHash)
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to upgrade password hash", "user_id", userID, "error", err)
+	}
+}
+
+// bcryptHasher produces "$2a$..." hashes
+type bcryptHasher struct {
+	cost int
+}
+
+func (h bcryptHasher) Hash(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), h.cost)
+	return string(hash), err
+}
+
+func (h bcryptHasher) Recognizes(hash string) bool {
+	return strings.HasPrefix(hash, "$2a$") || strings.HasPrefix(hash, "$2b$") || strings.HasPrefix(hash, "$2y$")
+}
+
+func (h bcryptHasher) Verify(hash, password string) (bool, error) {
+	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (h bcryptHasher) NeedsRehash(hash string) bool {
+	cost, err := bcrypt.Cost([]byte(hash))
+	return err != nil || cost < h.cost
+}
+
+// argon2idHasher produces hashes in the PHC string format,
+// "$argon2id$v=19$m=<KiB>,t=<iterations>,p=<parallelism>$<salt>$<key>" with unpadded base64
+type argon2idHasher struct {
+	memoryKiB   uint32
+	iterations  uint32
+	parallelism uint32
+}
+
+// argon2idHash is a decoded Argon2id PHC string
+type argon2idHash struct {
+	params argon2idHasher
+	salt   []byte
+	key    []byte
+}
+
+func (h *argon2idHasher) Hash(password string) (string, error) {
+	salt := make([]byte, argon2SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := argon2.IDKey([]byte(password), salt, h.iterations, h.memoryKiB, uint8(h.parallelism), argon2KeyLength)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2.Version, h.memoryKiB, h.iterations, h.parallelism,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+func (h *argon2idHasher) Recognizes(hash string) bool {
+	return strings.HasPrefix(hash, "$argon2id$")
+}
+
+func (h *argon2idHasher) Verify(hash, password string) (bool, error) {
+	decoded, err := parseArgon2idHash(hash)
+	if err != nil {
+		return false, err
+	}
+	p := decoded.params
+	key := argon2.IDKey([]byte(password), decoded.salt, p.iterations, p.memoryKiB, uint8(p.parallelism), uint32(len(decoded.key)))
+	return subtle.ConstantTimeCompare(key, decoded.key) == 1, nil
+}
+
+func (h *argon2idHasher) NeedsRehash(hash string) bool {
+	decoded, err := parseArgon2idHash(hash)
+	if err != nil {
+		return true
+	}
+	p := decoded.params
+	return p.memoryKiB < h.memoryKiB || p.iterations < h.iterations || p.parallelism != h.parallelism
+}
+
+func parseArgon2idHash(hash string) (*argon2idHash, error) {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return nil, errors.New("malformed argon2id hash")
+	}
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return nil, fmt.Errorf("unsupported argon2id version %q", parts[2])
+	}
+	decoded := &argon2idHash{}
+	p := &decoded.params
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.memoryKiB, &p.iterations, &p.parallelism); err != nil {
+		return nil, fmt.Errorf("malformed argon2id parameters %q", parts[3])
+	}
+	if p.iterations == 0 || p.parallelism == 0 || p.parallelism > 255 {
+		return nil, fmt.Errorf("invalid argon2id parameters %q", parts[3])
+	}
+	var err error
+	if decoded.salt, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil {
+		return nil, fmt.Errorf("malformed argon2id salt: %w", err)
+	}
+	if decoded.key, err = base64.RawStdEncoding.DecodeString(parts[5]); err != nil || len(decoded.key) == 0 {
+		return nil, errors.New("malformed argon2id key")
+	}
+	return decoded, nil
+}
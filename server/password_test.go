@@ -0,0 +1,135 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// testArgon2id keeps tests fast; production uses the defaults
+func testArgon2id() *argon2idHasher {
+	return &argon2idHasher{memoryKiB: 64, iterations: 1, parallelism: 1}
+}
+
+func TestArgon2idHasher(t *testing.T) {
+	h := testArgon2id()
+	hash, err := h.Hash("password123")
+	if err != nil {
+		t.Fatalf("Hash() error = %v", err)
+	}
+	if !strings.HasPrefix(hash, "$argon2id$v=19$m=64,t=1,p=1$") {
+		t.Errorf("Hash() = %q, want a PHC string with the configured parameters", hash)
+	}
+	if !h.Recognizes(hash) {
+		t.Error("Recognizes() = false for its own hash")
+	}
+	if ok, err := h.Verify(hash, "password123"); !ok || err != nil {
+		t.Errorf("Verify(correct) = %v, %v, want true", ok, err)
+	}
+	if ok, err := h.Verify(hash, "wrong"); ok || err != nil {
+		t.Errorf("Verify(wrong) = %v, %v, want false, nil", ok, err)
+	}
+	other, _ := h.Hash("password123")
+	if other == hash {
+		t.Error("hashes of the same password should use different salts")
+	}
+
+	if h.NeedsRehash(hash) {
+		t.Error("NeedsRehash() = true for current parameters")
+	}
+	stronger := &argon2idHasher{memoryKiB: 128, iterations: 1, parallelism: 1}
+	if !stronger.NeedsRehash(hash) {
+		t.Error("NeedsRehash() = false after raising the memory cost")
+	}
+
+	for _, malformed := range []string{"$argon2id$v=19$m=64,t=1,p=1$c2FsdA", "$argon2id$v=16$m=64,t=1,p=1$c2FsdA$a2V5", "$argon2id$v=19$m=64,t=0,p=1$c2FsdA$a2V5"} {
+		if _, err := h.Verify(malformed, "password123"); err == nil {
+			t.Errorf("Verify(%q) succeeded, want an error", malformed)
+		}
+	}
+}
+
+func TestPasswordHashingUpgrade(t *testing.T) {
+	argon := testArgon2id()
+	p := &passwordHashing{current: argon, hashers: []PasswordHasher{argon, bcryptHasher{cost: bcrypt.MinCost}}}
+
+	legacy, err := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, rehash, err := p.Verify(string(legacy), "password123"); !ok || !rehash || err != nil {
+		t.Errorf("Verify(bcrypt hash) = %v, %v, %v, want a match that needs rehashing", ok, rehash, err)
+	}
+	if ok, rehash, _ := p.Verify(string(legacy), "wrong"); ok || rehash {
+		t.Errorf("Verify(bcrypt hash, wrong) = %v, %v, want no match", ok, rehash)
+	}
+
+	current, err := p.Hash("password123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, rehash, err := p.Verify(current, "password123"); !ok || rehash || err != nil {
+		t.Errorf("Verify(current hash) = %v, %v, %v, want a match without rehashing", ok, rehash, err)
+	}
+
+	// Accounts provisioned through OIDC have no password
+	if ok, _, err := p.Verify("", ""); ok || err != nil {
+		t.Errorf("Verify(empty hash) = %v, %v, want false, nil", ok, err)
+	}
+}
+
+// countingHasher counts the hashes it verifies
+type countingHasher struct {
+	bcryptHasher
+	verified []string
+}
+
+func (h *countingHasher) Verify(hash, password string) (bool, error) {
+	h.verified = append(h.verified, hash)
+	return h.bcryptHasher.Verify(hash, password)
+}
+
+func TestPasswordHashingUnmatchedHash(t *testing.T) {
+	h := &countingHasher{bcryptHasher: bcryptHasher{cost: bcrypt.MinCost}}
+	p := &passwordHashing{current: h, hashers: []PasswordHasher{h}}
+
+	// Missing accounts and accounts without a password spend the time of a real check
+	for _, hash := range []string{"", "!unknown"} {
+		if ok, rehash, err := p.Verify(hash, "password123"); ok || rehash || err != nil {
+			t.Errorf("Verify(%q) = %v, %v, %v, want false, false, nil", hash, ok, rehash, err)
+		}
+	}
+	if len(h.verified) != 2 || !h.Recognizes(h.verified[0]) || h.verified[1] != h.verified[0] {
+		t.Errorf("verified hashes = %q, want the same bcrypt hash twice", h.verified)
+	}
+}
+
+func TestBcryptHasherNeedsRehash(t *testing.T) {
+	weak, _ := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+	if !(bcryptHasher{cost: bcrypt.MinCost + 1}).NeedsRehash(string(weak)) {
+		t.Error("NeedsRehash() = false for a hash below the configured cost")
+	}
+	if (bcryptHasher{cost: bcrypt.MinCost}).NeedsRehash(string(weak)) {
+		t.Error("NeedsRehash() = true for a hash at the configured cost")
+	}
+}
+
+func TestNewPasswordHashing(t *testing.T) {
+	cfg, err := loadTestConfig(t, nil)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	p := newPasswordHashing(cfg.Passwords, cfg.Auth.BcryptCost)
+	if a, ok := p.current.(*argon2idHasher); !ok || a.memoryKiB != defaultArgon2MemoryKiB {
+		t.Errorf("default hasher = %#v, want Argon2id with default parameters", p.current)
+	}
+
+	cfg, err = loadTestConfig(t, map[string]string{"PASSWORD_HASH_ALGORITHM": "bcrypt", "BCRYPT_COST": "12"})
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if p := newPasswordHashing(cfg.Passwords, cfg.Auth.BcryptCost); p.current != (bcryptHasher{cost: 12}) {
+		t.Errorf("newPasswordHashing() = %#v, want bcrypt cost 12", p.current)
+	}
+}
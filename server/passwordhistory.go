@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	pb "example/grpc_demo/library"
+
+	"github.com/jackc/pgx/v5"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// defaultPasswordHistoryDepth is how many of a user's passwords, the current one included, cannot be chosen again
+const defaultPasswordHistoryDepth = 5
+
+// setPassword replaces the user's password after checking it against the password history. The old hash
+// is kept in the history, which is trimmed to what the configured depth needs.
+func (s *server) setPassword(ctx context.Context, q querier, userID int, password string) error {
+	if s.passwordHistory > 0 {
+		// The current hash comes first, followed by the previous ones still remembered
+		rows, err := q.Query(ctx,
+			`(SELECT password_hash FROM users WHERE id=$1)
+			 UNION ALL
+			 (SELECT password_hash FROM password_history WHERE user_id=$1 ORDER BY created_at DESC, id DESC LIMIT $2)`,
+			userID, s.passwordHistory-1)
+		if err != nil {
+			return status.Errorf(codes.Internal, "database error: %v", err)
+		}
+		hashes, err := pgx.CollectRows(rows, pgx.RowTo[string])
+		if err != nil {
+			return status.Errorf(codes.Internal, "database error: %v", err)
+		}
+		for _, hash := range hashes {
+			reused, _, err := s.passwords.Verify(hash, password)
+			if err != nil {
+				return status.Errorf(codes.Internal, "failed to check password history: %v", err)
+			}
+			if reused {
+				return authError(codes.InvalidArgument, reasonPasswordReused,
+					fmt.Sprintf("the new password must differ from your last %d passwords", s.passwordHistory))
+			}
+		}
+	}
+
+	hash, err := s.passwords.Hash(password)
+	if err != nil {
+		return status.Errorf(codes.Internal, "failed to hash password: %v", err)
+	}
+	// Accounts created through OIDC have no password to remember
+	_, err = q.Exec(ctx,
+		"INSERT INTO password_history (user_id, password_hash) SELECT id, password_hash FROM users WHERE id=$1 AND password_hash <> ''",
+		userID)
+	if err != nil {
+		return status.Errorf(codes.Internal, "database error: %v", err)
+	}
+	if _, err := q.Exec(ctx, "UPDATE users SET password_hash=$1 WHERE id=$2", hash, userID); err != nil {
+		return status.Errorf(codes.Internal, "failed to update password: %v", err)
+	}
+	_, err = q.Exec(ctx,
+		`DELETE FROM password_history WHERE user_id=$1 AND id NOT IN (
+			SELECT id FROM password_history WHERE user_id=$1 ORDER BY created_at DESC, id DESC LIMIT $2)`,
+		userID, max(s.passwordHistory-1, 0))
+	if err != nil {
+		return status.Errorf(codes.Internal, "database error: %v", err)
+	}
+	return nil
+}
+
+func (s *server) ChangePassword(ctx context.Context, req *pb.ChangePasswordRequest) (*pb.ChangePasswordResponse, error) {
+	userID, _, ok := userFromContext(ctx)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "authentication required")
+	}
+	if req.GetCurrentPassword() == "" {
+		return nil, authError(codes.InvalidArgument, reasonMissingCredentials, "current password is required")
+	}
+	if msg := validateNewPassword(req.GetNewPassword()); msg != "" {
+		return nil, status.Error(codes.InvalidArgument, msg)
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "database error: %v", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var hash string
+	if err := tx.QueryRow(ctx, "SELECT password_hash FROM users WHERE id=$1 FOR UPDATE", userID).Scan(&hash); err != nil {
+		return nil, status.Errorf(codes.Internal, "database error: %v", err)
+	}
+	ok, _, err = s.passwords.Verify(hash, req.GetCurrentPassword())
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to verify password: %v", err)
+	}
+	if !ok {
+		return nil, authError(codes.PermissionDenied, reasonInvalidCredentials, "current password is incorrect")
+	}
+	if err := s.setPassword(ctx, tx, userID, req.GetNewPassword()); err != nil {
+		return nil, err
+	}
+
+	// The caller stays logged in; everyone else who knew the old password does not
+	var current int64
+	if claims, ok := claimsFromContext(ctx); ok {
+		current = claims.SessionID
+	}
+	revoked, err := revokeOtherSessions(ctx, tx, userID, current)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to revoke sessions: %v", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, status.Errorf(codes.Internal, "database error: %v", err)
+	}
+	return &pb.ChangePasswordResponse{Message: localize(ctx, "Password changed"), RevokedSessions: int32(revoked)}, nil
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	pb "example/grpc_demo/library"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestPasswordHistoryDepthConfig(t *testing.T) {
+	for raw, want := range map[string]int{"": defaultPasswordHistoryDepth, "0": 0, "12": 12} {
+		cfg, err := loadTestConfig(t, map[string]string{"PASSWORD_HISTORY_DEPTH": raw})
+		if err != nil {
+			t.Errorf("PASSWORD_HISTORY_DEPTH=%q: error = %v", raw, err)
+		} else if cfg.Passwords.HistoryDepth != want {
+			t.Errorf("PASSWORD_HISTORY_DEPTH=%q: got %d, want %d", raw, cfg.Passwords.HistoryDepth, want)
+		}
+	}
+	for _, raw := range []string{"-1", "many", "51"} {
+		if _, err := loadTestConfig(t, map[string]string{"PASSWORD_HISTORY_DEPTH": raw}); err == nil {
+			t.Errorf("PASSWORD_HISTORY_DEPTH=%q: expected an error", raw)
+		}
+	}
+}
+
+func TestChangePasswordValidation(t *testing.T) {
+	s := &server{passwordHistory: defaultPasswordHistoryDepth}
+	ctx := context.WithValue(context.Background(), userIDKey, 1)
+	ctx = context.WithValue(ctx, usernameKey, "testuser")
+
+	_, err := s.ChangePassword(ctx, &pb.ChangePasswordRequest{NewPassword: "new-password"})
+	assertAuthError(t, err, codes.InvalidArgument, reasonMissingCredentials)
+
+	for _, newPassword := range []string{"", strings.Repeat("x", maxPasswordLength+1)} {
+		_, err := s.ChangePassword(ctx, &pb.ChangePasswordRequest{CurrentPassword: "old-password", NewPassword: newPassword})
+		if status.Code(err) != codes.InvalidArgument {
+			t.Errorf("new password of %d bytes: error = %v, want InvalidArgument", len(newPassword), err)
+		}
+	}
+
+	_, err = s.ChangePassword(context.Background(), &pb.ChangePasswordRequest{CurrentPassword: "old-password", NewPassword: "new-password"})
+	if status.Code(err) != codes.Unauthenticated {
+		t.Errorf("anonymous ChangePassword() error = %v, want Unauthenticated", err)
+	}
+}
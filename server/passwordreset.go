@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+
+	pb "example/grpc_demo/library"
+
+	"github.com/jackc/pgx/v5"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// passwordResetTTL is how long a password reset token can be used
+	passwordResetTTL = time.Hour
+	// maxPasswordLength is the longest password bcrypt can hash, in bytes
+	maxPasswordLength = 72
+)
+
+// passwordResetSentMessage is returned whether or not the user exists, so the RPC cannot be used to find accounts
+const passwordResetSentMessage = "If the account exists, a password reset token has been sent"
+
+// validateNewPassword returns the message for an unusable password, or "" if it is acceptable
+func validateNewPassword(password string) string {
+	if password == "" {
+		return "password is required"
+	}
+	if len(password) > maxPasswordLength {
+		return "password is too long"
+	}
+	return ""
+}
+
+func (s *server) RequestPasswordReset(ctx context.Context, req *pb.PasswordResetRequest) (*pb.PasswordResetResponse, error) {
+	username := strings.TrimSpace(req.GetUsername())
+	if username == "" {
+		return nil, status.Error(codes.InvalidArgument, "username is required")
+	}
+
+	// A token is made and stored in one statement whether or not the account exists, and its mail queued
+	// rather than sent, so that the call takes as long either way
+	token, hash, err := newOpaqueToken()
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to generate reset token: %v", err)
+	}
+	expiresAt := time.Now().Add(passwordResetTTL)
+	var email *string
+	// Only the newest token works
+	err = s.db.QueryRow(ctx,
+		`WITH u AS (SELECT id, email FROM users WHERE username = $1),
+		 used AS (UPDATE password_resets SET used_at = NOW() WHERE user_id = (SELECT id FROM u) AND used_at IS NULL),
+		 created AS (INSERT INTO password_resets (user_id, token_hash, expires_at) SELECT id, $2, $3 FROM u)
+		 SELECT email FROM u`, username, hash, expiresAt).Scan(&email)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return nil, status.Errorf(codes.Internal, "failed to store reset token: %v", err)
+	}
+
+	// The mail is sent in the background and a delivery failure only logged; reporting it would reveal that
+	// the account exists
+	if err == nil {
+		to := mailRecipient{username: username}
+		if email != nil {
+			to.email = *email
+		}
+		if err := s.mailer.SendPasswordReset(ctx, to, token, expiresAt); err != nil {
+			slog.ErrorContext(ctx, "failed to send password reset", "username", username, "error", err)
+		}
+	}
+	return &pb.PasswordResetResponse{Message: localize(ctx, passwordResetSentMessage)}, nil
+}
+
+func (s *server) ConfirmPasswordReset(ctx context.Context, req *pb.ConfirmPasswordResetRequest) (*pb.PasswordResetResponse, error) {
+	if req.GetToken() == "" {
+		return nil, status.Error(codes.InvalidArgument, "reset token is required")
+	}
+	if msg := validateNewPassword(req.GetNewPassword()); msg != "" {
+		return nil, status.Error(codes.InvalidArgument, msg)
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "database error: %v", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var (
+		id        int64
+		userID    int
+		expiresAt time.Time
+		used      bool
+	)
+	err = tx.QueryRow(ctx,
+		"SELECT id, user_id, expires_at, used_at IS NOT NULL FROM password_resets WHERE token_hash=$1 FOR UPDATE",
+		hashOpaqueToken(req.GetToken())).Scan(&id, &userID, &expiresAt, &used)
+	if errors.Is(err, pgx.ErrNoRows) || used || !expiresAt.After(time.Now()) {
+		return nil, status.Error(codes.InvalidArgument, "invalid or expired reset token")
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "database error: %v", err)
+	}
+
+	if err := s.setPassword(ctx, tx, userID, req.GetNewPassword()); err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(ctx, "UPDATE password_resets SET used_at=NOW() WHERE id=$1", id); err != nil {
+		return nil, status.Errorf(codes.Internal, "database error: %v", err)
+	}
+	// Whoever knew the old password must not stay logged in
+	if _, err := revokeAllSessions(ctx, tx, userID); err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to revoke sessions: %v", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, status.Errorf(codes.Internal, "database error: %v", err)
+	}
+	return &pb.PasswordResetResponse{Message: localize(ctx, "Password updated; log in with the new password")}, nil
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	pb "example/grpc_demo/library"
+)
+
+func TestValidateNewPassword(t *testing.T) {
+	tests := []struct {
+		name     string
+		password string
+		want     string
+	}{
+		{"valid", "correct horse battery staple", ""},
+		{"empty", "", "password is required"},
+		{"longest bcrypt accepts", strings.Repeat("x", maxPasswordLength), ""},
+		{"too long for bcrypt", strings.Repeat("x", maxPasswordLength+1), "password is too long"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := validateNewPassword(tt.password); got != tt.want {
+				t.Errorf("validateNewPassword() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestRequestPasswordReset runs against the database of STORAGE_TEST_DATABASE_URL
+func TestRequestPasswordReset(t *testing.T) {
+	pool := testDatabase(t, "reset-test-")
+	mailer := failingMailer{sent: make(chan mailRecipient, 1)}
+	queue := newMailQueue(mailer)
+	s := &server{db: pool, mailer: queue}
+	ctx := testUser(t, pool, "reset-test-ana")
+
+	// The answer is the same for an account that exists, whose mail fails, and one that doesn't
+	for _, username := range []string{"reset-test-ana", "reset-test-nobody"} {
+		resp, err := s.RequestPasswordReset(ctx, &pb.PasswordResetRequest{Username: username})
+		if err != nil || resp.GetMessage() != passwordResetSentMessage {
+			t.Errorf("RequestPasswordReset(%s) = %v, %v, want %q", username, resp, err, passwordResetSentMessage)
+		}
+	}
+	if len(queue.messages) != 1 {
+		t.Fatalf("%d messages queued, want only that of reset-test-ana", len(queue.messages))
+	}
+	go queue.run(ctx)
+	select {
+	case to := <-mailer.sent:
+		if to.username != "reset-test-ana" {
+			t.Errorf("reset sent to %q, want reset-test-ana", to.username)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the reset was never sent")
+	}
+
+	var tokens int
+	if err := pool.QueryRow(ctx, "SELECT count(*) FROM password_resets r JOIN users u ON u.id = r.user_id WHERE u.username = 'reset-test-ana' AND r.used_at IS NULL").Scan(&tokens); err != nil {
+		t.Fatal(err)
+	}
+	if tokens != 1 {
+		t.Errorf("%d usable reset tokens, want 1", tokens)
+	}
+}
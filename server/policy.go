@@ -0,0 +1,233 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Access levels of an authorization rule
+const (
+	accessAnonymous     = "anonymous"
+	accessAuthenticated = "authenticated"
+)
+
+// Roles known to the server; services authenticated by client certificate get serviceRole
+const (
+	userRole    = "user"
+	adminRole   = "admin"
+	serviceRole = "service"
+)
+
+// Scopes that CreateScopedToken can restrict a token to
+const (
+	scopeBooksRead = "books:read"
+	scopeLoansRead = "loans:read"
+)
+
+// scopedMethods are the read-only methods each scope opens to scoped tokens in the default policy
+var scopedMethods = map[string][]string{
+	scopeBooksRead: {
+		"/library.LibraryService/GetBook",
+		"/library.LibraryService/ListBooks",
+		"/library.LibraryService/SearchBooks",
+		"/library.LibraryService/GetBookHistory",
+		"/library.LibraryService/ExportBooks",
+		"/library.LibraryService/DownloadCover",
+		"/library.LibraryService/LookupByISBN",
+		"/library.LibraryService/ListBookTranslations",
+		"/library.LibraryService/FindBookLocation",
+		"/library.LibraryService/ListCopies",
+		"/library.LibraryService/GetBookByBarcode",
+		"/library.LibraryService/GetBarcodeImage",
+		"/library.LibraryService/GetRelatedBooks",
+		"/library.LibraryService/ListBooksInSeries",
+		"/library.LibraryService/GetRecommendations",
+		"/library.TagService/ListTags",
+		"/library.ReviewService/ListReviews",
+		"/library.PublisherService/ListPublishers",
+		"/library.PublisherService/ListBooksByPublisher",
+	},
+	scopeLoansRead: {
+		"/library.LoanService/ListMyLoans",
+		"/library.LoanService/ListReservations",
+		"/library.LoanService/ListMyFines",
+	},
+}
+
+// authRule is the access required to call a method
+type authRule struct {
+	// Access is "anonymous" (no credentials needed) or "authenticated"
+	Access string `json:"access"`
+	// Roles, when set, admits only callers with one of these roles
+	Roles []string `json:"roles,omitempty"`
+	// Scopes, when set, must all be granted to tokens restricted to scopes; unrestricted tokens pass
+	Scopes []string `json:"scopes,omitempty"`
+}
+
+// authPolicy maps full method names ("/library.LibraryService/AddBook") or whole services
+// ("/library.LibraryService/*") to rules. Exact methods win over services, which win over Default.
+type authPolicy struct {
+	Default authRule            `json:"default"`
+	Methods map[string]authRule `json:"methods"`
+}
+
+// defaultAuthPolicy requires authentication for everything except the publicMethods,
+// and lets scoped tokens call the scopedMethods of their scopes
+func defaultAuthPolicy() *authPolicy {
+	p := &authPolicy{Default: authRule{Access: accessAuthenticated}, Methods: make(map[string]authRule)}
+	for method := range publicMethods {
+		p.Methods[method] = authRule{Access: accessAnonymous}
+	}
+	for scope, methods := range scopedMethods {
+		for _, method := range methods {
+			p.Methods[method] = authRule{Access: accessAuthenticated, Scopes: []string{scope}}
+		}
+	}
+	return p
+}
+
+// loadAuthPolicy loads file (AUTH_POLICY_FILE), a JSON policy whose rules are layered over the defaults
+func loadAuthPolicy(file string) (*authPolicy, error) {
+	policy := defaultAuthPolicy()
+	if file == "" {
+		return policy, nil
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var override authPolicy
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&override); err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	if override.Default.Access != "" {
+		policy.Default = override.Default
+	}
+	for method, rule := range override.Methods {
+		policy.Methods[method] = rule
+	}
+	if err := policy.validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	return policy, nil
+}
+
+func (r authRule) validate() error {
+	switch r.Access {
+	case accessAnonymous:
+		if len(r.Roles) > 0 || len(r.Scopes) > 0 {
+			return fmt.Errorf("anonymous access cannot require roles or scopes")
+		}
+	case accessAuthenticated:
+	default:
+		return fmt.Errorf("access must be %q or %q, got %q", accessAnonymous, accessAuthenticated, r.Access)
+	}
+	return nil
+}
+
+func (p *authPolicy) validate() error {
+	if err := p.Default.validate(); err != nil {
+		return fmt.Errorf("default: %w", err)
+	}
+	for method, rule := range p.Methods {
+		service, name, ok := strings.Cut(strings.TrimPrefix(method, "/"), "/")
+		if !strings.HasPrefix(method, "/") || !ok || service == "" || name == "" {
+			return fmt.Errorf("invalid method %q, want /package.Service/Method or /package.Service/*", method)
+		}
+		if err := rule.validate(); err != nil {
+			return fmt.Errorf("%s: %w", method, err)
+		}
+	}
+	return nil
+}
+
+// checkMethods reports policy entries that match no method registered on the server, which are usually typos
+func (p *authPolicy) checkMethods(services map[string]grpc.ServiceInfo) error {
+	for method := range p.Methods {
+		service, name, _ := strings.Cut(strings.TrimPrefix(method, "/"), "/")
+		info, ok := services[service]
+		if ok && (name == "*" || slices.ContainsFunc(info.Methods, func(m grpc.MethodInfo) bool { return m.Name == name })) {
+			continue
+		}
+		return fmt.Errorf("authorization policy names unknown method %q", method)
+	}
+	return nil
+}
+
+// knownScopes lists the scopes some rule asks for, which are the ones worth granting to a token
+func (p *authPolicy) knownScopes() []string {
+	var scopes []string
+	for _, rule := range append([]authRule{p.Default}, slices.Collect(maps.Values(p.Methods))...) {
+		for _, scope := range rule.Scopes {
+			if !slices.Contains(scopes, scope) {
+				scopes = append(scopes, scope)
+			}
+		}
+	}
+	slices.Sort(scopes)
+	return scopes
+}
+
+// rule returns the rule for a full method name
+func (p *authPolicy) rule(method string) authRule {
+	if r, ok := p.Methods[method]; ok {
+		return r
+	}
+	if i := strings.LastIndex(method, "/"); i > 0 {
+		if r, ok := p.Methods[method[:i]+"/*"]; ok {
+			return r
+		}
+	}
+	return p.Default
+}
+
+// authorize checks an authenticated caller against the rule for method
+func (p *authPolicy) authorize(ctx context.Context, method string) error {
+	rule := p.rule(method)
+	if len(rule.Roles) > 0 {
+		role, _ := roleFromContext(ctx)
+		if !slices.Contains(rule.Roles, role) {
+			return status.Errorf(codes.PermissionDenied, "%s requires role %s", method, strings.Join(rule.Roles, " or "))
+		}
+	}
+	if claims, ok := claimsFromContext(ctx); ok && claims.Scopes != nil {
+		for _, scope := range rule.Scopes {
+			if !slices.Contains(claims.Scopes, scope) {
+				return status.Errorf(codes.PermissionDenied, "token is missing scope %q", scope)
+			}
+		}
+		// A scoped token can only call methods that ask for scopes it has
+		if len(rule.Scopes) == 0 {
+			return status.Errorf(codes.PermissionDenied, "token scopes do not allow %s", method)
+		}
+	}
+	return nil
+}
+
+// roleFromContext returns the role of the authenticated caller
+func roleFromContext(ctx context.Context) (string, bool) {
+	role, ok := ctx.Value(roleKey).(string)
+	return role, ok
+}
+
+// grantAdminRoles makes the listed users (ADMIN_USERNAMES) administrators
+func grantAdminRoles(ctx context.Context, db *pgxpool.Pool, usernames []string) error {
+	if len(usernames) == 0 {
+		return nil
+	}
+	_, err := db.Exec(ctx, "UPDATE users SET role=$1 WHERE username = ANY($2)", adminRole, usernames)
+	return err
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// writePolicy writes policy to a file and returns its path
+func writePolicy(t *testing.T, policy string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "policy.json")
+	if err := os.WriteFile(path, []byte(policy), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestAuthPolicyRule(t *testing.T) {
+	p, err := loadAuthPolicy(writePolicy(t, `{
+		"methods": {
+			"/library.LibraryService/*": {"access": "authenticated", "roles": ["admin"]},
+			"/library.LibraryService/ListBooks": {"access": "anonymous"}
+		}
+	}`))
+	if err != nil {
+		t.Fatalf("loadAuthPolicy() error = %v", err)
+	}
+
+	tests := []struct {
+		method string
+		access string
+		roles  int
+	}{
+		{"/library.UserService/Login", accessAnonymous, 0},
+		{"/library.LibraryService/ListBooks", accessAnonymous, 0},
+		{"/library.LibraryService/DeleteBook", accessAuthenticated, 1},
+		{"/library.LoanService/BorrowBook", accessAuthenticated, 0},
+		{"/library.NewService/NewMethod", accessAuthenticated, 0},
+	}
+	for _, tt := range tests {
+		r := p.rule(tt.method)
+		if r.Access != tt.access || len(r.Roles) != tt.roles {
+			t.Errorf("rule(%s) = %+v, want access %s with %d roles", tt.method, r, tt.access, tt.roles)
+		}
+	}
+}
+
+func TestLoadAuthPolicyErrors(t *testing.T) {
+	for _, policy := range []string{
+		`{"default": {"access": "everyone"}}`,
+		`{"methods": {"AddBook": {"access": "authenticated"}}}`,
+		`{"methods": {"/library.LibraryService/AddBook": {"access": "anonymous", "roles": ["admin"]}}}`,
+		`{"methods": {"/library.LibraryService/AddBook": {"access": "authenticated", "role": "admin"}}}`,
+		`not json`,
+	} {
+		if _, err := loadAuthPolicy(writePolicy(t, policy)); err == nil {
+			t.Errorf("loadAuthPolicy(%s) should fail", policy)
+		}
+	}
+
+	p, err := loadAuthPolicy("")
+	if err != nil {
+		t.Fatalf("loadAuthPolicy() error = %v", err)
+	}
+	for method := range publicMethods {
+		if p.rule(method).Access != accessAnonymous {
+			t.Errorf("%s should be anonymous by default", method)
+		}
+	}
+}
+
+func TestAuthPolicyAuthorize(t *testing.T) {
+	p := defaultAuthPolicy()
+	p.Methods["/library.LibraryService/DeleteBook"] = authRule{Access: accessAuthenticated, Roles: []string{adminRole}}
+	p.Methods["/library.LibraryService/ListBooks"] = authRule{Access: accessAuthenticated, Scopes: []string{"books:read"}}
+
+	withRole := func(role string, scopes []string) context.Context {
+		ctx := context.WithValue(context.Background(), roleKey, role)
+		return context.WithValue(ctx, claimsKey, &Claims{Scopes: scopes})
+	}
+	tests := []struct {
+		name   string
+		ctx    context.Context
+		method string
+		want   codes.Code
+	}{
+		{"admin deletes", withRole(adminRole, nil), "/library.LibraryService/DeleteBook", codes.OK},
+		{"user cannot delete", withRole(userRole, nil), "/library.LibraryService/DeleteBook", codes.PermissionDenied},
+		{"unrestricted token", withRole(userRole, nil), "/library.LibraryService/AddBook", codes.OK},
+		{"scoped token with scope", withRole(userRole, []string{"books:read"}), "/library.LibraryService/ListBooks", codes.OK},
+		{"scoped token without scope", withRole(userRole, []string{"other"}), "/library.LibraryService/ListBooks", codes.PermissionDenied},
+		{"scoped token on unscoped method", withRole(userRole, []string{"books:read"}), "/library.LibraryService/AddBook", codes.PermissionDenied},
+	}
+	for _, tt := range tests {
+		if got := status.Code(p.authorize(tt.ctx, tt.method)); got != tt.want {
+			t.Errorf("%s: authorize() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestAuthPolicyCheckMethods(t *testing.T) {
+	services := map[string]grpc.ServiceInfo{
+		"library.LibraryService": {Methods: []grpc.MethodInfo{{Name: "AddBook"}}},
+		"library.UserService":    {Methods: []grpc.MethodInfo{{Name: "Login"}}},
+	}
+	p := &authPolicy{Methods: map[string]authRule{
+		"/library.LibraryService/AddBook": {Access: accessAuthenticated},
+		"/library.UserService/*":          {Access: accessAnonymous},
+	}}
+	if err := p.checkMethods(services); err != nil {
+		t.Errorf("checkMethods() error = %v", err)
+	}
+	p.Methods["/library.LibraryService/AddBok"] = authRule{Access: accessAuthenticated}
+	if err := p.checkMethods(services); err == nil {
+		t.Error("checkMethods() should report a misspelled method")
+	}
+}
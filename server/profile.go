@@ -0,0 +1,211 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	pb "example/grpc_demo/library"
+
+	"github.com/jackc/pgx/v5"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	// maxDisplayNameLength bounds display names
+	maxDisplayNameLength = 100
+	// maxPreferredPageSize bounds the page size a user can prefer
+	maxPreferredPageSize = 100
+)
+
+// recordLogin updates the login time, address and count shown in the user's profile.
+// Like the auth events, it must not fail a login, so errors are only logged.
+func (s *server) recordLogin(ctx context.Context, userID int) {
+	_, ip := sessionDevice(ctx)
+	_, err := s.db.Exec(ctx, "UPDATE users SET last_login_at=NOW(), last_login_ip=$2, login_count=login_count+1 WHERE id=$1",
+		userID, ip)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to record login", "user_id", userID, "error", err)
+	}
+}
+
+// loadProfile reads the profile of a user, locking the row when forUpdate is set
+func loadProfile(ctx context.Context, q querier, userID int, forUpdate bool) (*pb.Profile, error) {
+	query := `SELECT username, display_name, COALESCE(email, ''), email_verified_at IS NOT NULL, preferences,
+		last_login_at, last_login_ip, login_count, created_at, updated_at FROM users WHERE id=$1`
+	if forUpdate {
+		query += " FOR UPDATE"
+	}
+	p := &pb.Profile{Preferences: &pb.ProfilePreferences{}}
+	var prefs []byte
+	var lastLogin *time.Time
+	var createdAt, updatedAt time.Time
+	err := q.QueryRow(ctx, query, userID).Scan(&p.Username, &p.DisplayName, &p.Email, &p.EmailVerified, &prefs,
+		&lastLogin, &p.LastLoginIp, &p.LoginCount, &createdAt, &updatedAt)
+	if err != nil {
+		return nil, err
+	}
+	p.CreatedAt, p.UpdatedAt = timestamppb.New(createdAt), timestamppb.New(updatedAt)
+	if lastLogin != nil {
+		p.LastLoginAt = timestamppb.New(*lastLogin)
+	}
+	if err := (protojson.UnmarshalOptions{DiscardUnknown: true}).Unmarshal(prefs, p.Preferences); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// applyProfileUpdate copies the masked fields of update into p; an empty mask copies every editable field
+func applyProfileUpdate(p, update *pb.Profile, paths []string) error {
+	if len(paths) == 0 {
+		paths = []string{"display_name", "email", "preferences"}
+	}
+	prefs, newPrefs := p.GetPreferences(), update.GetPreferences()
+	if prefs == nil {
+		prefs = &pb.ProfilePreferences{}
+		p.Preferences = prefs
+	}
+	for _, path := range paths {
+		switch path {
+		case "display_name":
+			p.DisplayName = update.GetDisplayName()
+		case "email":
+			p.Email = update.GetEmail()
+		case "preferences":
+			p.Preferences = &pb.ProfilePreferences{
+				Language:           newPrefs.GetLanguage(),
+				PageSize:           newPrefs.GetPageSize(),
+				EmailNotifications: newPrefs.GetEmailNotifications(),
+			}
+			prefs = p.Preferences
+		case "preferences.language":
+			prefs.Language = newPrefs.GetLanguage()
+		case "preferences.page_size":
+			prefs.PageSize = newPrefs.GetPageSize()
+		case "preferences.email_notifications":
+			prefs.EmailNotifications = newPrefs.GetEmailNotifications()
+		default:
+			return status.Errorf(codes.InvalidArgument, "field %q cannot be updated", path)
+		}
+	}
+	return nil
+}
+
+// validateProfile normalizes the editable fields of a profile
+func validateProfile(p *pb.Profile, requireEmail bool) error {
+	p.DisplayName = strings.TrimSpace(p.GetDisplayName())
+	if len(p.GetDisplayName()) > maxDisplayNameLength {
+		return status.Error(codes.InvalidArgument, "display name is too long")
+	}
+	if p.GetEmail() != "" {
+		email, err := normalizeEmail(p.GetEmail())
+		if err != nil {
+			return status.Error(codes.InvalidArgument, err.Error())
+		}
+		p.Email = email
+	} else if requireEmail {
+		return status.Error(codes.InvalidArgument, "email address is required")
+	}
+	prefs := p.GetPreferences()
+	if prefs.GetLanguage() != "" {
+		lang, err := normalizeLanguage(prefs.GetLanguage())
+		if err != nil {
+			return status.Errorf(codes.InvalidArgument, "invalid language %q", prefs.GetLanguage())
+		}
+		prefs.Language = lang
+	}
+	if prefs.GetPageSize() < 0 || prefs.GetPageSize() > maxPreferredPageSize {
+		return status.Errorf(codes.InvalidArgument, "page size must be between 0 and %d", maxPreferredPageSize)
+	}
+	return nil
+}
+
+func (s *server) GetProfile(ctx context.Context, req *pb.GetProfileRequest) (*pb.Profile, error) {
+	userID, _, ok := userFromContext(ctx)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "authentication required")
+	}
+	p, err := loadProfile(ctx, s.db, userID, false)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, notFound(resourceUser, strconv.Itoa(userID), "user not found")
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to load profile: %v", err)
+	}
+	return p, nil
+}
+
+func (s *server) UpdateProfile(ctx context.Context, req *pb.UpdateProfileRequest) (*pb.Profile, error) {
+	userID, username, ok := userFromContext(ctx)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "authentication required")
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "database error: %v", err)
+	}
+	defer tx.Rollback(ctx)
+
+	p, err := loadProfile(ctx, tx, userID, true)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, notFound(resourceUser, strconv.Itoa(userID), "user not found")
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to load profile: %v", err)
+	}
+	oldEmail := p.GetEmail()
+	if err := applyProfileUpdate(p, req.GetProfile(), req.GetUpdateMask().GetPaths()); err != nil {
+		return nil, err
+	}
+	if err := validateProfile(p, s.runtime().requireEmail); err != nil {
+		return nil, err
+	}
+
+	prefs, err := protojson.Marshal(p.GetPreferences())
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to encode preferences: %v", err)
+	}
+	emailChanged := !strings.EqualFold(p.GetEmail(), oldEmail)
+	var updatedAt time.Time
+	err = tx.QueryRow(ctx,
+		`UPDATE users SET display_name=$1, email=NULLIF($2, ''), preferences=$3,
+			email_verified_at = CASE WHEN $4 THEN NULL ELSE email_verified_at END
+		 WHERE id=$5 RETURNING updated_at`,
+		p.GetDisplayName(), p.GetEmail(), prefs, emailChanged, userID).Scan(&updatedAt)
+	if isUniqueViolation(err) {
+		return nil, status.Error(codes.AlreadyExists, "email address already registered")
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to update profile: %v", err)
+	}
+	p.UpdatedAt = timestamppb.New(updatedAt)
+
+	var verifyToken string
+	var verifyExpiresAt time.Time
+	if emailChanged {
+		p.EmailVerified = false
+		if p.GetEmail() != "" {
+			verifyToken, verifyExpiresAt, err = issueEmailVerification(ctx, tx, userID, p.GetEmail())
+			if err != nil {
+				return nil, status.Errorf(codes.Internal, "failed to issue verification token: %v", err)
+			}
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, status.Errorf(codes.Internal, "database error: %v", err)
+	}
+
+	if verifyToken != "" {
+		if err := s.mailer.SendEmailVerification(ctx, mailRecipient{username: username, email: p.GetEmail()}, verifyToken, verifyExpiresAt); err != nil {
+			slog.ErrorContext(ctx, "failed to send email verification", "username", username, "error", err)
+		}
+	}
+	return p, nil
+}
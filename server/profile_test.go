@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	pb "example/grpc_demo/library"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+func TestApplyProfileUpdate(t *testing.T) {
+	current := func() *pb.Profile {
+		return &pb.Profile{
+			Username:    "reader",
+			DisplayName: "Reader",
+			Email:       "reader@example.com",
+			Preferences: &pb.ProfilePreferences{Language: "en", PageSize: 20, EmailNotifications: true},
+		}
+	}
+	update := &pb.Profile{
+		Username:    "someone-else",
+		DisplayName: "New Name",
+		Preferences: &pb.ProfilePreferences{Language: "pt-BR"},
+	}
+
+	p := current()
+	if err := applyProfileUpdate(p, update, []string{"display_name", "preferences.language"}); err != nil {
+		t.Fatalf("applyProfileUpdate() error = %v", err)
+	}
+	if p.GetUsername() != "reader" || p.GetDisplayName() != "New Name" || p.GetEmail() != "reader@example.com" {
+		t.Errorf("masked update changed unexpected fields: %v", p)
+	}
+	if prefs := p.GetPreferences(); prefs.GetLanguage() != "pt-BR" || prefs.GetPageSize() != 20 || !prefs.GetEmailNotifications() {
+		t.Errorf("masked update preferences = %v", prefs)
+	}
+
+	p = current()
+	if err := applyProfileUpdate(p, update, nil); err != nil {
+		t.Fatalf("applyProfileUpdate() error = %v", err)
+	}
+	if p.GetUsername() != "reader" || p.GetEmail() != "" || p.GetPreferences().GetPageSize() != 0 {
+		t.Errorf("full update = %v, want every editable field replaced", p)
+	}
+
+	for _, path := range []string{"username", "email_verified", "preferences.theme"} {
+		err := applyProfileUpdate(current(), update, []string{path})
+		if status.Code(err) != codes.InvalidArgument {
+			t.Errorf("applyProfileUpdate(%q) error = %v, want InvalidArgument", path, err)
+		}
+	}
+}
+
+func TestValidateProfile(t *testing.T) {
+	tests := []struct {
+		name         string
+		profile      *pb.Profile
+		requireEmail bool
+		wantErr      bool
+	}{
+		{"empty", &pb.Profile{}, false, false},
+		{"valid", &pb.Profile{DisplayName: " Reader ", Email: "reader@example.com", Preferences: &pb.ProfilePreferences{Language: "PT-br", PageSize: 50}}, false, false},
+		{"long display name", &pb.Profile{DisplayName: strings.Repeat("x", maxDisplayNameLength+1)}, false, true},
+		{"bad email", &pb.Profile{Email: "nope"}, false, true},
+		{"email required", &pb.Profile{}, true, true},
+		{"bad language", &pb.Profile{Preferences: &pb.ProfilePreferences{Language: "not a tag!"}}, false, true},
+		{"page size too large", &pb.Profile{Preferences: &pb.ProfilePreferences{PageSize: maxPreferredPageSize + 1}}, false, true},
+		{"negative page size", &pb.Profile{Preferences: &pb.ProfilePreferences{PageSize: -1}}, false, true},
+	}
+	for _, tt := range tests {
+		err := validateProfile(tt.profile, tt.requireEmail)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: validateProfile() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+
+	p := &pb.Profile{DisplayName: " Reader ", Preferences: &pb.ProfilePreferences{Language: "PT-br"}}
+	if err := validateProfile(p, false); err != nil {
+		t.Fatalf("validateProfile() error = %v", err)
+	}
+	if p.GetDisplayName() != "Reader" || p.GetPreferences().GetLanguage() != "pt-br" {
+		t.Errorf("validateProfile() normalized to %q, %q", p.GetDisplayName(), p.GetPreferences().GetLanguage())
+	}
+}
+
+// TestLastLogin checks a login is recorded with the caller's address and shown in the profile, against a
+// fake database
+func TestLastLogin(t *testing.T) {
+	pool, db := newFakeDatabase(t)
+	s := &server{db: pool}
+	at := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	db.answer("UPDATE users SET last_login_at=NOW()", "UPDATE 1")
+	db.answer("WHERE id=('3')", "SELECT 1", []any{"reader", "Reader", "reader@example.com", true, `{"language":"en"}`, at, "203.0.113.7", 4, at, at})
+	db.answer("WHERE id=('4')", "SELECT 1", []any{"newcomer", "", "", false, `{}`, nil, "", 0, at, at})
+
+	ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP("203.0.113.7"), Port: 50123}})
+	s.recordLogin(ctx, 3)
+	if !db.received("last_login_ip=('203.0.113.7'), login_count=login_count+1 WHERE id=('3')") {
+		t.Error("recordLogin() didn't count a login of user 3 from the caller's address")
+	}
+
+	p, err := s.GetProfile(context.WithValue(context.Background(), userIDKey, 3), &pb.GetProfileRequest{})
+	if err != nil {
+		t.Fatalf("GetProfile() error = %v", err)
+	}
+	if !p.GetLastLoginAt().AsTime().Equal(at) || p.GetLastLoginIp() != "203.0.113.7" || p.GetLoginCount() != 4 {
+		t.Errorf("GetProfile() = %v, want the last login at %v from 203.0.113.7 and 4 logins", p, at)
+	}
+	if p.GetPreferences().GetLanguage() != "en" {
+		t.Errorf("GetProfile() preferences = %v, want the stored ones", p.GetPreferences())
+	}
+	// A user who never logged in has no last login
+	p, err = s.GetProfile(context.WithValue(context.Background(), userIDKey, 4), &pb.GetProfileRequest{})
+	if err != nil || p.GetLastLoginAt() != nil || p.GetLoginCount() != 0 {
+		t.Errorf("GetProfile() of a new user = %v, %v, want no last login", p, err)
+	}
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	pb "example/grpc_demo/library"
+	"example/grpc_demo/server/storage"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// maxPublisherNameLength bounds publisher names
+	maxPublisherNameLength = 200
+	// maxPublisherWebsiteLength bounds publisher website URLs
+	maxPublisherWebsiteLength = 500
+)
+
+// validatePublisher returns the message for an invalid book publisher, or "" if it is acceptable
+func validatePublisher(book *pb.Book) string {
+	if book.GetPublisherId() < 0 {
+		return "Invalid publisher ID"
+	}
+	if len(strings.TrimSpace(book.GetPublisher())) > maxPublisherNameLength {
+		return "Publisher name is too long"
+	}
+	return ""
+}
+
+// validPublisherFields trims a publisher's name and website and reports whether they are acceptable
+func validPublisherFields(p *pb.Publisher) (name, website string, err error) {
+	name = strings.TrimSpace(p.GetName())
+	website = strings.TrimSpace(p.GetWebsite())
+	if name == "" {
+		return "", "", status.Error(codes.InvalidArgument, "Publisher name is required")
+	}
+	if len(name) > maxPublisherNameLength {
+		return "", "", status.Error(codes.InvalidArgument, "Publisher name is too long")
+	}
+	if len(website) > maxPublisherWebsiteLength {
+		return "", "", status.Error(codes.InvalidArgument, "Publisher website is too long")
+	}
+	return name, website, nil
+}
+
+func (s *server) CreatePublisher(ctx context.Context, req *pb.Publisher) (*pb.Publisher, error) {
+	name, website, err := validPublisherFields(req)
+	if err != nil {
+		return nil, err
+	}
+	created := &pb.Publisher{Name: name, Website: website}
+	err = s.db.QueryRow(ctx, "INSERT INTO publishers (name, website) VALUES ($1, $2) RETURNING id", name, website).Scan(&created.Id)
+	if isUniqueViolation(err) {
+		return nil, status.Errorf(codes.AlreadyExists, "publisher %q already exists", name)
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to create publisher: %v", err)
+	}
+	return created, nil
+}
+
+func (s *server) UpdatePublisher(ctx context.Context, req *pb.Publisher) (*pb.Publisher, error) {
+	if req.GetId() <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "Publisher ID is required")
+	}
+	name, website, err := validPublisherFields(req)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "database error: %v", err)
+	}
+	defer tx.Rollback(ctx)
+
+	res, err := tx.Exec(ctx, "UPDATE publishers SET name=$1, website=$2 WHERE id=$3", name, website, req.GetId())
+	if isUniqueViolation(err) {
+		return nil, status.Errorf(codes.AlreadyExists, "publisher %q already exists", name)
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to update publisher: %v", err)
+	}
+	if res.RowsAffected() == 0 {
+		return nil, notFound(resourcePublisher, strconv.Itoa(int(req.GetId())), "Publisher not found")
+	}
+	// Keep the name stored on each book in step with the publisher record
+	res, err = tx.Exec(ctx, "UPDATE books SET publisher=$1 WHERE publisher_id=$2", name, req.GetId())
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to update publisher: %v", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to update publisher: %v", err)
+	}
+	return &pb.Publisher{Id: req.GetId(), Name: name, Website: website, BookCount: int32(res.RowsAffected())}, nil
+}
+
+func (s *server) ListPublishers(ctx context.Context, req *pb.ListPublishersRequest) (*pb.ListPublishersResponse, error) {
+	page, err := pageOf(req, "publishers", "", int64(0))
+	if err != nil {
+		return nil, err
+	}
+	var filter sqlFilter
+	order := storage.Keyset{Columns: []string{"p.name", "p.id"}}
+	page.where(&filter, order)
+	rows, err := s.db.Query(ctx,
+		"SELECT p.id, p.name, p.website, COUNT(b.id) FROM publishers p LEFT JOIN books b ON b.publisher_id = p.id"+
+			filter.clause()+" GROUP BY p.id"+order.OrderBy()+page.limitOffset(&filter),
+		filter.args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	resp := &pb.ListPublishersResponse{}
+	for rows.Next() {
+		p := &pb.Publisher{}
+		if err := rows.Scan(&p.Id, &p.Name, &p.Website, &p.BookCount); err != nil {
+			return nil, err
+		}
+		resp.Publishers = append(resp.Publishers, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	resp.Publishers, resp.NextPageToken = nextPage(page, resp.Publishers, func(p *pb.Publisher) []any { return []any{p.GetName(), int64(p.GetId())} })
+	if err := s.db.QueryRow(ctx, "SELECT COUNT(*) FROM publishers").Scan(&resp.TotalCount); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (s *server) ListBooksByPublisher(ctx context.Context, req *pb.PublisherBooksRequest) (*pb.ListBookResponse, error) {
+	if req.GetId() <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "Publisher ID is required")
+	}
+	var exists bool
+	if err := s.db.QueryRow(ctx, "SELECT EXISTS(SELECT 1 FROM publishers WHERE id=$1)", req.GetId()).Scan(&exists); err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, notFound(resourcePublisher, strconv.Itoa(int(req.GetId())), "Publisher not found")
+	}
+
+	page, err := pageOf(req, fmt.Sprintf("publisher books %d", req.GetId()), "", "")
+	if err != nil {
+		return nil, err
+	}
+	var filter sqlFilter
+	filter.where("publisher_id = " + filter.arg(req.GetId()))
+	order := storage.Keyset{Columns: []string{"title", "id"}}
+	page.where(&filter, order)
+	rows, err := s.db.Query(ctx, "SELECT "+storage.BookColumns+" FROM books"+filter.clause()+order.OrderBy()+page.limitOffset(&filter), filter.args...)
+	if err != nil {
+		return nil, err
+	}
+	books, err := storage.CollectBooks(rows)
+	if err != nil {
+		return nil, err
+	}
+	books, next := nextPage(page, books, func(b *pb.Book) []any { return []any{b.GetTitle(), b.GetId()} })
+	if err := s.enrichBooks(ctx, books); err != nil {
+		return nil, err
+	}
+
+	var totalCount int32
+	if err := s.db.QueryRow(ctx, "SELECT COUNT(*) FROM books WHERE publisher_id=$1", req.GetId()).Scan(&totalCount); err != nil {
+		return nil, err
+	}
+	return &pb.ListBookResponse{Books: books, TotalCount: totalCount, NextPageToken: next}, nil
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	pb "example/grpc_demo/library"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestValidatePublisher(t *testing.T) {
+	tests := []struct {
+		name string
+		in   *pb.Book
+		want string
+	}{
+		{"no publisher", &pb.Book{}, ""},
+		{"by name", &pb.Book{Publisher: "Example Press"}, ""},
+		{"by id", &pb.Book{PublisherId: 3}, ""},
+		{"negative id", &pb.Book{PublisherId: -1}, "Invalid publisher ID"},
+		{"long name", &pb.Book{Publisher: strings.Repeat("x", maxPublisherNameLength+1)}, "Publisher name is too long"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := validatePublisher(tt.in); got != tt.want {
+				t.Errorf("validatePublisher() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidPublisherFields(t *testing.T) {
+	name, website, err := validPublisherFields(&pb.Publisher{Name: " Example Press ", Website: " https://example.com "})
+	if err != nil {
+		t.Fatalf("validPublisherFields() error = %v", err)
+	}
+	if name != "Example Press" || website != "https://example.com" {
+		t.Errorf("validPublisherFields() = %q, %q, want trimmed values", name, website)
+	}
+
+	for _, p := range []*pb.Publisher{
+		{Name: "  "},
+		{Name: strings.Repeat("x", maxPublisherNameLength+1)},
+		{Name: "Example Press", Website: strings.Repeat("x", maxPublisherWebsiteLength+1)},
+	} {
+		if _, _, err := validPublisherFields(p); status.Code(err) != codes.InvalidArgument {
+			t.Errorf("validPublisherFields(%v) code = %v, want InvalidArgument", p, status.Code(err))
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// purgeBatchSize bounds the rows each DELETE of the purge removes, so it never holds locks for long
+const purgeBatchSize = 1000
+
+// purgeLockKey names the advisory lock held by the replica running the purge
+const purgeLockKey = "purge"
+
+// purgeTarget is a table whose rows are deleted for good once they have been dead for the retention period
+type purgeTarget struct {
+	// table labels the purge_rows_deleted_total metric
+	table string
+	// query deletes up to $2 rows dead for more than $1 seconds
+	query string
+}
+
+// purgeTargets are the tables the purge empties of dead rows. Sessions are kept: a revoked session is what
+// makes the access tokens issued for it fail, until they expire.
+var purgeTargets = []purgeTarget{
+	// Refresh tokens rotated or revoked are kept until they expire, as presenting one again revokes every
+	// session of its user
+	{"refresh_tokens", `DELETE FROM refresh_tokens WHERE id IN (
+		SELECT id FROM refresh_tokens WHERE expires_at < NOW() - make_interval(secs => $1) LIMIT $2)`},
+	// Revoked access tokens are refused by their expiry once it has passed
+	{"revoked_tokens", `DELETE FROM revoked_tokens WHERE jti IN (
+		SELECT jti FROM revoked_tokens WHERE expires_at < NOW() - make_interval(secs => $1) LIMIT $2)`},
+	{"password_resets", `DELETE FROM password_resets WHERE id IN (
+		SELECT id FROM password_resets WHERE LEAST(expires_at, used_at) < NOW() - make_interval(secs => $1) LIMIT $2)`},
+	{"email_verifications", `DELETE FROM email_verifications WHERE id IN (
+		SELECT id FROM email_verifications WHERE LEAST(expires_at, used_at) < NOW() - make_interval(secs => $1) LIMIT $2)`},
+	// Events stay in the outbox a while after they are published, to look into what consumers received
+	{"outbox", `DELETE FROM outbox WHERE id IN (
+		SELECT id FROM outbox WHERE sent_at < NOW() - make_interval(secs => $1) LIMIT $2)`},
+}
+
+// runPurgeJob purges dead rows and applies the retention policies every cfg.Interval until ctx is cancelled
+func runPurgeJob(ctx context.Context, db *pgxpool.Pool, cfg PurgeConfig, policies RetentionConfig) {
+	ticker := time.NewTicker(cfg.Interval)
+	defer ticker.Stop()
+	for {
+		if err := purge(ctx, db, cfg.Retention, policies); err != nil {
+			slog.ErrorContext(ctx, "purge failed", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// purge deletes the rows of purgeTargets dead for longer than retention and applies policies, in every
+// library. Only one replica purges at a time: the others find the advisory lock taken and skip their turn,
+// as the deletes would find nothing left.
+func purge(ctx context.Context, db *pgxpool.Pool, retention time.Duration, policies RetentionConfig) error {
+	conn, err := db.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	// A session lock, as the batches commit one by one; it goes with the connection if the replica dies
+	var leader bool
+	if err := conn.QueryRow(ctx, "SELECT pg_try_advisory_lock(hashtext($1))", purgeLockKey).Scan(&leader); err != nil {
+		return err
+	}
+	if !leader {
+		return nil
+	}
+	defer func() {
+		if _, err := conn.Exec(context.WithoutCancel(ctx), "SELECT pg_advisory_unlock(hashtext($1))", purgeLockKey); err != nil {
+			// Closing the connection releases the lock
+			conn.Conn().Close(context.WithoutCancel(ctx))
+		}
+	}()
+
+	// The lock's connection stays on the default library; the deletes run on connections of each library
+	return forEachTenant(ctx, db, func(ctx context.Context) error {
+		if err := purgeTables(ctx, db, retention); err != nil {
+			return err
+		}
+		return applyRetention(ctx, db, policies)
+	})
+}
+
+// purgeTables deletes the rows of purgeTargets dead for longer than retention in the library of ctx
+func purgeTables(ctx context.Context, db *pgxpool.Pool, retention time.Duration) error {
+	for _, target := range purgeTargets {
+		var deleted int64
+		for {
+			tag, err := db.Exec(ctx, target.query, retention.Seconds(), purgeBatchSize)
+			if err != nil {
+				return fmt.Errorf("%s: %w", target.table, err)
+			}
+			deleted += tag.RowsAffected()
+			purgedRows.WithLabelValues(target.table).Add(float64(tag.RowsAffected()))
+			if tag.RowsAffected() < purgeBatchSize {
+				break
+			}
+		}
+		if deleted > 0 {
+			slog.InfoContext(ctx, "purged dead rows", "table", target.table, "rows", deleted, "tenant", tenantFromContext(ctx))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// Quotas counted in quota_usage
+const (
+	quotaBooksAdded   = "books_added"
+	quotaBooksDeleted = "books_deleted"
+)
+
+// limit is the daily limit of quota, 0 for none
+func (c QuotaConfig) limit(quota string) int {
+	switch quota {
+	case quotaBooksAdded:
+		return c.BooksAddedPerDay
+	case quotaBooksDeleted:
+		return c.BooksDeletedPerDay
+	}
+	return 0
+}
+
+// quotaExceededError is returned when the caller has used up a daily quota
+type quotaExceededError struct {
+	quota string
+	limit int
+}
+
+func (e *quotaExceededError) Error() string {
+	return fmt.Sprintf("daily quota %s of %d exceeded", e.quota, e.limit)
+}
+
+// status is the ResourceExhausted status for the error, with a QuotaFailure naming the quota and a
+// RetryInfo for when it resets at midnight UTC
+func (e *quotaExceededError) status(now time.Time) error {
+	reset := now.UTC().Truncate(24 * time.Hour).Add(24 * time.Hour).Sub(now)
+	st := status.Newf(codes.ResourceExhausted, "Daily limit of %d %s reached; it resets at midnight UTC", e.limit, quotaDescriptions[e.quota])
+	return withDetails(st,
+		&errdetails.QuotaFailure{Violations: []*errdetails.QuotaFailure_Violation{{Subject: "user", Description: e.Error()}}},
+		&errdetails.RetryInfo{RetryDelay: durationpb.New(reset.Round(time.Second))})
+}
+
+// quotaDescriptions names what each quota counts in error messages
+var quotaDescriptions = map[string]string{
+	quotaBooksAdded:   "books added",
+	quotaBooksDeleted: "books deleted",
+}
+
+// quotaStatus converts a quotaExceededError to its status; ok is false for other errors
+func quotaStatus(err error) (error, bool) {
+	var quotaErr *quotaExceededError
+	if !errors.As(err, &quotaErr) {
+		return nil, false
+	}
+	return quotaErr.status(time.Now()), true
+}
+
+// takeQuota counts one use of quota by the caller for today (UTC), failing with a quotaExceededError
+// when the daily limit is reached. Called in the unit of work of the change, it is given back if that
+// rolls back. Calls without a user, such as from services authenticated by certificate, have no quota.
+func (s *server) takeQuota(ctx context.Context, quota string) error {
+	limit := s.runtime().quotas.limit(quota)
+	userID, _, ok := userFromContext(ctx)
+	if limit <= 0 || !ok {
+		return nil
+	}
+	var used int
+	err := s.conn(ctx).QueryRow(ctx,
+		`INSERT INTO quota_usage (user_id, quota, day, used) VALUES ($1, $2, (NOW() AT TIME ZONE 'UTC')::date, 1)
+		 ON CONFLICT (user_id, quota, day) DO UPDATE SET used = quota_usage.used + 1
+		 WHERE quota_usage.used < $3
+		 RETURNING used`,
+		userID, quota, limit).Scan(&used)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return &quotaExceededError{quota: quota, limit: limit}
+	}
+	return err
+}
+
+// quotaLeft returns how many more uses of quota the caller has today (UTC), or -1 without a limit, for
+// changes made in bulk. The caller's usage stays locked until the unit of work of ctx ends, which counts the
+// uses it makes with useQuota.
+func (s *server) quotaLeft(ctx context.Context, quota string) (int, error) {
+	limit := s.runtime().quotas.limit(quota)
+	userID, _, ok := userFromContext(ctx)
+	if limit <= 0 || !ok {
+		return -1, nil
+	}
+	var used int
+	err := s.conn(ctx).QueryRow(ctx,
+		`INSERT INTO quota_usage (user_id, quota, day, used) VALUES ($1, $2, (NOW() AT TIME ZONE 'UTC')::date, 0)
+		 ON CONFLICT (user_id, quota, day) DO UPDATE SET used = quota_usage.used
+		 RETURNING used`,
+		userID, quota).Scan(&used)
+	if err != nil {
+		return 0, err
+	}
+	return max(limit-used, 0), nil
+}
+
+// useQuota counts n uses of quota by the caller for today, within what quotaLeft returned
+func (s *server) useQuota(ctx context.Context, quota string, n int) error {
+	userID, _, ok := userFromContext(ctx)
+	if s.runtime().quotas.limit(quota) <= 0 || !ok || n == 0 {
+		return nil
+	}
+	_, err := s.conn(ctx).Exec(ctx,
+		"UPDATE quota_usage SET used = used + $3 WHERE user_id=$1 AND quota=$2 AND day=(NOW() AT TIME ZONE 'UTC')::date",
+		userID, quota, n)
+	return err
+}
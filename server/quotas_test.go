@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestQuotaExceededStatus(t *testing.T) {
+	now := time.Date(2024, 3, 1, 22, 30, 0, 0, time.UTC)
+	err := (&quotaExceededError{quota: quotaBooksAdded, limit: 50}).status(now)
+	st := status.Convert(err)
+	if st.Code() != codes.ResourceExhausted {
+		t.Fatalf("code = %v, want ResourceExhausted", st.Code())
+	}
+	var quota *errdetails.QuotaFailure
+	var retry *errdetails.RetryInfo
+	for _, d := range st.Details() {
+		switch d := d.(type) {
+		case *errdetails.QuotaFailure:
+			quota = d
+		case *errdetails.RetryInfo:
+			retry = d
+		}
+	}
+	if quota == nil || len(quota.GetViolations()) != 1 {
+		t.Errorf("details = %v, want a QuotaFailure", st.Details())
+	}
+	// The quota resets at midnight UTC
+	if retry == nil || retry.GetRetryDelay().AsDuration() != 90*time.Minute {
+		t.Errorf("retry info = %v, want 1h30m", retry)
+	}
+
+	if _, ok := quotaStatus(status.Error(codes.Internal, "database error")); ok {
+		t.Error("quotaStatus() converted another error")
+	}
+}
+
+func TestTakeQuotaWithoutLimit(t *testing.T) {
+	s := &server{settings: newSettings(&runtimeSettings{quotas: QuotaConfig{BooksAddedPerDay: 10}})}
+	ctx := context.WithValue(context.Background(), userIDKey, 1)
+	// Neither needs the database: deletions have no limit, and calls without a user have no quota
+	if err := s.takeQuota(ctx, quotaBooksDeleted); err != nil {
+		t.Errorf("takeQuota() without a limit error = %v", err)
+	}
+	if err := s.takeQuota(context.Background(), quotaBooksAdded); err != nil {
+		t.Errorf("takeQuota() without a user error = %v", err)
+	}
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	pb "example/grpc_demo/library"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+const (
+	// Default attempts per minute for each client IP and for each username
+	defaultAuthRateLimitPerIP   = 20
+	defaultAuthRateLimitPerUser = 5
+	// rateLimitSweepInterval is how often idle buckets, which are full again, are dropped
+	rateLimitSweepInterval = time.Minute
+)
+
+// rateLimitedMethods are the RPCs throttled before their handler runs, as each one costs a bcrypt hash
+var rateLimitedMethods = map[string]bool{
+	"/library.UserService/Register": true,
+	"/library.UserService/Login":    true,
+	// Throttled by client IP only, to slow down guessing the current password with a stolen token
+	"/library.UserService/ChangePassword": true,
+}
+
+// authRateLimiter throttles brute-force attempts by client IP and by the username being tried
+type authRateLimiter struct {
+	// mu guards the limiters, which are replaced when the configuration is reloaded
+	mu      sync.RWMutex
+	perIP   *tokenBucketLimiter
+	perUser *tokenBucketLimiter
+}
+
+// newAuthRateLimiter allows the attempts per minute (and in a burst) of cfg; 0 disables that limit
+func newAuthRateLimiter(cfg RateLimitConfig) *authRateLimiter {
+	return &authRateLimiter{perIP: newTokenBucketLimiter(cfg.PerIP), perUser: newTokenBucketLimiter(cfg.PerUser)}
+}
+
+// setLimits changes the attempts allowed per minute to those of cfg. A limit that is unchanged keeps its
+// buckets, so reloading the configuration doesn't hand every client a fresh burst.
+func (l *authRateLimiter) setLimits(cfg RateLimitConfig) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.perIP.perMinute() != cfg.PerIP {
+		l.perIP = newTokenBucketLimiter(cfg.PerIP)
+	}
+	if l.perUser.perMinute() != cfg.PerUser {
+		l.perUser = newTokenBucketLimiter(cfg.PerUser)
+	}
+}
+
+// allow takes a token for the client IP and then for the username, reporting how long to wait when either is empty
+func (l *authRateLimiter) allow(ip, username string) (bool, time.Duration) {
+	l.mu.RLock()
+	perIP, perUser := l.perIP, l.perUser
+	l.mu.RUnlock()
+	if ip != "" {
+		if ok, wait := perIP.allow(ip); !ok {
+			return false, wait
+		}
+	}
+	if username != "" {
+		return perUser.allow(username)
+	}
+	return true, 0
+}
+
+// CreateAuthRateLimitInterceptor rejects Register and Login calls over the limit with ResourceExhausted,
+// before they reach bcrypt or the database
+func CreateAuthRateLimitInterceptor(limiter *authRateLimiter) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if !rateLimitedMethods[info.FullMethod] {
+			return handler(ctx, req)
+		}
+		var username string
+		switch r := req.(type) {
+		case *pb.UserCredentials:
+			username = r.GetUsername()
+		case *pb.User:
+			username = r.GetUsername()
+		}
+		// Usernames are only unique within a library
+		if tenant := tenantFromContext(ctx); tenant != "" && username != "" {
+			username = tenant + "/" + username
+		}
+		_, ip := sessionDevice(ctx)
+		if ok, wait := limiter.allow(ip, username); !ok {
+			return nil, rateLimitError(wait)
+		}
+		return handler(ctx, req)
+	}
+}
+
+// rateLimitError tells the client when to retry, rounded up to whole seconds
+func rateLimitError(wait time.Duration) error {
+	wait = time.Duration(math.Ceil(wait.Seconds())) * time.Second
+	st := status.Newf(codes.ResourceExhausted, "too many attempts; retry in %s", wait)
+	withDetails, err := st.WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(wait)})
+	if err != nil {
+		return st.Err()
+	}
+	return withDetails.Err()
+}
+
+// tokenBucketLimiter keeps one token bucket per key, refilled at perMinute tokens per minute up to perMinute
+type tokenBucketLimiter struct {
+	mu        sync.Mutex
+	now       func() time.Time
+	rate      float64 // tokens per second
+	burst     float64
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// newTokenBucketLimiter returns nil, which allows everything, when perMinute is 0
+func newTokenBucketLimiter(perMinute int) *tokenBucketLimiter {
+	if perMinute == 0 {
+		return nil
+	}
+	return &tokenBucketLimiter{
+		now:     time.Now,
+		rate:    float64(perMinute) / 60,
+		burst:   float64(perMinute),
+		buckets: make(map[string]*tokenBucket),
+	}
+}
+
+// perMinute is the limit the limiter was created with
+func (l *tokenBucketLimiter) perMinute() int {
+	if l == nil {
+		return 0
+	}
+	return int(l.burst)
+}
+
+func (l *tokenBucketLimiter) allow(key string) (bool, time.Duration) {
+	if l == nil {
+		return true, 0
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	if now.Sub(l.lastSweep) >= rateLimitSweepInterval {
+		for k, b := range l.buckets {
+			if b.tokens+now.Sub(b.last).Seconds()*l.rate >= l.burst {
+				delete(l.buckets, k)
+			}
+		}
+		l.lastSweep = now
+	}
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &tokenBucket{tokens: l.burst, last: now}
+		l.buckets[key] = b
+	}
+	b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+	b.last = now
+	if b.tokens < 1 {
+		return false, time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+	}
+	b.tokens--
+	return true, 0
+}
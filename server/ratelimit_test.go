@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	pb "example/grpc_demo/library"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+func TestTokenBucketLimiter(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	l := newTokenBucketLimiter(6) // one token every 10 seconds
+	l.now = func() time.Time { return now }
+
+	for i := 0; i < 6; i++ {
+		if ok, _ := l.allow("a"); !ok {
+			t.Fatalf("attempt %d within the burst was rejected", i+1)
+		}
+	}
+	ok, wait := l.allow("a")
+	if ok || wait != 10*time.Second {
+		t.Fatalf("allow() after burst = %v, %v, want false, 10s", ok, wait)
+	}
+	if ok, _ := l.allow("b"); !ok {
+		t.Error("other keys should have their own bucket")
+	}
+
+	now = now.Add(10 * time.Second)
+	if ok, _ := l.allow("a"); !ok {
+		t.Error("a token should have been refilled after 10s")
+	}
+	if ok, _ := l.allow("a"); ok {
+		t.Error("only one token should have been refilled")
+	}
+
+	// Idle buckets refill completely and are swept
+	now = now.Add(time.Hour)
+	l.allow("c")
+	if _, ok := l.buckets["b"]; ok {
+		t.Error("full bucket was not swept")
+	}
+}
+
+func TestTokenBucketLimiterDisabled(t *testing.T) {
+	l := newTokenBucketLimiter(0)
+	for i := 0; i < 100; i++ {
+		if ok, _ := l.allow("a"); !ok {
+			t.Fatal("disabled limiter rejected an attempt")
+		}
+	}
+}
+
+func TestAuthRateLimitInterceptor(t *testing.T) {
+	limiter := &authRateLimiter{perIP: newTokenBucketLimiter(3), perUser: newTokenBucketLimiter(2)}
+	interceptor := CreateAuthRateLimitInterceptor(limiter)
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) { return "ok", nil }
+	call := func(ip, method string, req interface{}) error {
+		ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP(ip), Port: 40000}})
+		_, err := interceptor(ctx, req, &grpc.UnaryServerInfo{FullMethod: method}, handler)
+		return err
+	}
+	login := "/library.UserService/Login"
+
+	// Per username: a third attempt at alice fails even from a new address
+	for _, ip := range []string{"203.0.113.1", "203.0.113.2"} {
+		if err := call(ip, login, &pb.UserCredentials{Username: "alice"}); err != nil {
+			t.Fatalf("Login() error = %v", err)
+		}
+	}
+	err := call("203.0.113.3", login, &pb.UserCredentials{Username: "alice"})
+	if status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("third attempt for alice: error = %v, want ResourceExhausted", err)
+	}
+	var retry *errdetails.RetryInfo
+	for _, d := range status.Convert(err).Details() {
+		if r, ok := d.(*errdetails.RetryInfo); ok {
+			retry = r
+		}
+	}
+	if retry == nil || retry.GetRetryDelay().AsDuration() != 30*time.Second {
+		t.Errorf("RetryInfo = %v, want 30s", retry)
+	}
+
+	// Per IP: Register counts too, and the address is exhausted after three attempts
+	for _, name := range []string{"bob", "carol"} {
+		if err := call("198.51.100.7", "/library.UserService/Register", &pb.User{Username: name}); err != nil {
+			t.Fatalf("Register(%s) error = %v", name, err)
+		}
+	}
+	if err := call("198.51.100.7", login, &pb.UserCredentials{Username: "dave"}); err != nil {
+		t.Fatalf("third attempt from the address: error = %v", err)
+	}
+	if err := call("198.51.100.7", login, &pb.UserCredentials{Username: "erin"}); status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("fourth attempt from the address: error = %v, want ResourceExhausted", err)
+	}
+
+	// Other methods are not limited
+	for i := 0; i < 10; i++ {
+		if err := call("198.51.100.7", "/library.LibraryService/GetBook", &pb.BookRequest{}); err != nil {
+			t.Fatalf("GetBook() error = %v", err)
+		}
+	}
+}
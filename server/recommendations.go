@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+
+	pb "example/grpc_demo/library"
+	"example/grpc_demo/server/storage"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	defaultRecommendationLimit = 10
+	maxRecommendationLimit     = 50
+)
+
+// recommendationsQuery scores books against the user's "seed" books (favorites,
+// well-rated reviews, shelved books and recent loans): a shared author counts
+// more than each shared tag. Seed books themselves are never recommended.
+var recommendationsQuery = `
+WITH seeds AS (
+    SELECT book_id FROM favorites WHERE user_id = $1
+    UNION
+    SELECT book_id FROM reviews WHERE user_id = $1 AND rating >= 4
+    UNION
+    SELECT sb.book_id FROM shelf_books sb JOIN shelves s ON s.id = sb.shelf_id WHERE s.user_id = $1
+    UNION
+    SELECT book_id FROM loans WHERE user_id = $1 AND borrowed_at > NOW() - INTERVAL '90 days'
+),
+seed_authors AS (
+    SELECT DISTINCT b.author FROM books b JOIN seeds ON seeds.book_id = b.id WHERE b.author <> ''
+),
+seed_tags AS (
+    SELECT DISTINCT bt.tag_id FROM book_tags bt JOIN seeds ON seeds.book_id = bt.book_id
+),
+scores AS (
+    SELECT b.id AS book_id, 2 AS score FROM books b JOIN seed_authors a ON a.author = b.author
+    UNION ALL
+    SELECT bt.book_id, 1 FROM book_tags bt JOIN seed_tags t ON t.tag_id = bt.tag_id
+)
+SELECT ` + storage.QualifiedBookColumns("b") + `
+FROM books b
+JOIN (SELECT book_id, SUM(score) AS score FROM scores GROUP BY book_id) r ON r.book_id = b.id
+WHERE b.id NOT IN (SELECT book_id FROM seeds)
+ORDER BY r.score DESC, b.id
+LIMIT $2`
+
+// relatedBooksQuery scores other books against one book: the same series or author
+// counts more than each shared tag (tags stand in for genre).
+var relatedBooksQuery = `
+WITH source AS (
+    SELECT id, author, series_id FROM books WHERE id = $1
+),
+scores AS (
+    SELECT b.id AS book_id, 3 AS score FROM books b JOIN source s ON s.series_id = b.series_id
+    UNION ALL
+    SELECT b.id, 2 FROM books b JOIN source s ON s.author = b.author WHERE s.author <> ''
+    UNION ALL
+    SELECT bt.book_id, 1 FROM book_tags bt
+    JOIN book_tags st ON st.tag_id = bt.tag_id AND st.book_id = $1
+)
+SELECT ` + storage.QualifiedBookColumns("b") + `
+FROM books b
+JOIN (SELECT book_id, SUM(score) AS score FROM scores GROUP BY book_id) r ON r.book_id = b.id
+WHERE b.id <> $1
+ORDER BY r.score DESC, b.id
+LIMIT $2`
+
+// clampRecommendationLimit applies the default and maximum number of suggested books
+func clampRecommendationLimit(limit int32) int32 {
+	if limit < 1 {
+		return defaultRecommendationLimit
+	}
+	return min(limit, maxRecommendationLimit)
+}
+
+func (s *server) GetRecommendations(ctx context.Context, req *pb.RecommendationRequest) (*pb.ListBookResponse, error) {
+	userID, _, ok := userFromContext(ctx)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "authentication required")
+	}
+	rows, err := s.db.Query(ctx, recommendationsQuery, userID, clampRecommendationLimit(req.GetLimit()))
+	if err != nil {
+		return nil, err
+	}
+	books, err := storage.CollectBooks(rows)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.enrichBooks(ctx, books); err != nil {
+		return nil, err
+	}
+	return &pb.ListBookResponse{Books: books, TotalCount: int32(len(books))}, nil
+}
+
+func (s *server) GetRelatedBooks(ctx context.Context, req *pb.BookRequest) (*pb.ListBookResponse, error) {
+	if req.GetId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "Book ID is required")
+	}
+	var exists bool
+	if err := s.db.QueryRow(ctx, "SELECT EXISTS(SELECT 1 FROM books WHERE id=$1)", req.GetId()).Scan(&exists); err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, notFound(resourceBook, req.GetId(), "Book not found")
+	}
+
+	rows, err := s.db.Query(ctx, relatedBooksQuery, req.GetId(), clampRecommendationLimit(req.GetLimit()))
+	if err != nil {
+		return nil, err
+	}
+	books, err := storage.CollectBooks(rows)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.enrichBooks(ctx, books); err != nil {
+		return nil, err
+	}
+	return &pb.ListBookResponse{Books: books, TotalCount: int32(len(books))}, nil
+}
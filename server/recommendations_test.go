@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	pb "example/grpc_demo/library"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestClampRecommendationLimit(t *testing.T) {
+	tests := []struct {
+		limit, want int32
+	}{
+		{0, defaultRecommendationLimit},
+		{-3, defaultRecommendationLimit},
+		{1, 1},
+		{maxRecommendationLimit, maxRecommendationLimit},
+		{maxRecommendationLimit + 1, maxRecommendationLimit},
+	}
+	for _, tt := range tests {
+		if got := clampRecommendationLimit(tt.limit); got != tt.want {
+			t.Errorf("clampRecommendationLimit(%d) = %d, want %d", tt.limit, got, tt.want)
+		}
+	}
+}
+
+// TestRecommendations checks books are recommended from the caller's history, against a fake database
+func TestRecommendations(t *testing.T) {
+	s := &server{}
+	if _, err := s.GetRecommendations(context.Background(), &pb.RecommendationRequest{}); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("GetRecommendations() without a user error = %v, want Unauthenticated", err)
+	}
+	if _, err := s.GetRelatedBooks(context.Background(), &pb.BookRequest{}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("GetRelatedBooks() without a book error = %v, want InvalidArgument", err)
+	}
+
+	pool, db := newFakeDatabase(t)
+	s = &server{db: pool}
+	at := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	// The columns of storage.BookColumns
+	db.answer("WITH seeds AS", "SELECT 1", []any{"b2", "Children of Dune", "Frank Herbert", "", "", "", 1, 1, "", "", "", "", nil, nil, at, at})
+	db.answer("FROM book_tags bt JOIN tags", "SELECT 0")
+	db.answer("JOIN series s", "SELECT 0")
+	db.answer("FROM reviews WHERE book_id = ANY", "SELECT 0")
+	db.answer("SELECT EXISTS(SELECT 1 FROM books", "SELECT 1", []any{false})
+
+	ctx := context.WithValue(context.Background(), userIDKey, 2)
+	resp, err := s.GetRecommendations(ctx, &pb.RecommendationRequest{Limit: maxRecommendationLimit + 10})
+	if err != nil {
+		t.Fatalf("GetRecommendations() error = %v", err)
+	}
+	if resp.GetTotalCount() != 1 || resp.GetBooks()[0].GetTitle() != "Children of Dune" {
+		t.Errorf("GetRecommendations() = %v, want Children of Dune", resp)
+	}
+	// Seeds are the caller's books only, and no more than the most books allowed are suggested
+	if !db.received("FROM favorites WHERE user_id = ('2')") || !db.received("LIMIT ('50')") {
+		t.Error("recommendations weren't made from the caller's books with the limit clamped")
+	}
+
+	if _, err := s.GetRelatedBooks(ctx, &pb.BookRequest{Id: "missing"}); status.Code(err) != codes.NotFound {
+		t.Errorf("GetRelatedBooks() of an unknown book error = %v, want NotFound", err)
+	}
+}
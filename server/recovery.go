@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"runtime"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// recoverRPC turns a panic of the call of ctx into an Internal error, logging and reporting it with the
+// stack of the panic. It must be deferred directly.
+func recoverRPC(ctx context.Context, reporter errorReporter, method string, err *error) {
+	p := recover()
+	if p == nil {
+		return
+	}
+	*err = status.Error(codes.Internal, "internal error")
+	stack := make([]uintptr, maxStackDepth)
+	// From the frame that panicked, after runtime.Callers, recoverRPC and the runtime's panic handling
+	stack = stack[:runtime.Callers(3, stack)]
+	slog.ErrorContext(ctx, "panic in RPC handler", "method", method, "request_id", requestIDFromContext(ctx), "panic", fmt.Sprint(p))
+	if reporter == nil {
+		return
+	}
+	r := newErrorReport(ctx, method, *err)
+	r.message, r.panicked, r.stack = fmt.Sprint(p), true, stack
+	if caller, ok := ctx.Value(rpcLogKey{}).(*rpcCaller); ok {
+		// The logging interceptor would report the Internal error again
+		caller.reported = true
+	}
+	reporter.Report(ctx, r)
+}
+
+// CreateRecoveryInterceptor answers unary calls whose handler panics with an Internal error instead of
+// crashing the server
+func CreateRecoveryInterceptor(reporter errorReporter) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+		defer recoverRPC(ctx, reporter, info.FullMethod, &err)
+		return handler(ctx, req)
+	}
+}
+
+// CreateStreamRecoveryInterceptor answers streaming calls whose handler panics with an Internal error
+func CreateStreamRecoveryInterceptor(reporter errorReporter) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		defer recoverRPC(ss.Context(), reporter, info.FullMethod, &err)
+		return handler(srv, ss)
+	}
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	pb "example/grpc_demo/library"
+
+	"github.com/jackc/pgx/v5"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// newOpaqueToken returns a random single-purpose token (refresh or password reset) and the hash stored for it
+func newOpaqueToken() (token, hash string, err error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", err
+	}
+	token = base64.RawURLEncoding.EncodeToString(buf)
+	return token, hashOpaqueToken(token), nil
+}
+
+// hashOpaqueToken returns the SHA-256 digest stored in place of the token,
+// so a leaked table cannot be replayed as tokens
+func hashOpaqueToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// issueTokens signs an access token and stores a new refresh token for a session of the user.
+// A sessionID of 0 starts a new session for the calling device.
+func issueTokens(ctx context.Context, q querier, userID int, username string, sessionID int64, message string) (*pb.AuthResponse, error) {
+	if sessionID == 0 {
+		var err error
+		if sessionID, err = startSession(ctx, q, userID); err != nil {
+			return nil, err
+		}
+	}
+	now := time.Now()
+	claims := newAccessClaims(userID, username, sessionID)
+	claims.DeviceFingerprint = deviceFingerprint(ctx)
+	claims.TenantID = tenantFromContext(ctx)
+	token, err := signAccessToken(claims)
+	if err != nil {
+		return nil, err
+	}
+	refresh, hash, err := newOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+	refreshExpiresAt := now.Add(tokenTTLs.refresh)
+	_, err = q.Exec(ctx, "INSERT INTO refresh_tokens (user_id, session_id, token_hash, expires_at) VALUES ($1, $2, $3, $4)",
+		userID, sessionID, hash, refreshExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.AuthResponse{
+		Message:          localize(ctx, message),
+		Token:            token,
+		RefreshToken:     refresh,
+		ExpiresAt:        timestamppb.New(now.Add(tokenTTLs.access)),
+		RefreshExpiresAt: timestamppb.New(refreshExpiresAt),
+	}, nil
+}
+
+func (s *server) RefreshToken(ctx context.Context, req *pb.RefreshTokenRequest) (*pb.AuthResponse, error) {
+	refreshToken := refreshTokenFromRequest(ctx, req.GetRefreshToken())
+	if refreshToken == "" {
+		return nil, status.Error(codes.InvalidArgument, "refresh token is required")
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "database error: %v", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var (
+		id        int64
+		userID    int
+		username  string
+		sessionID *int64
+		expiresAt time.Time
+		used      bool
+		ended     bool
+		device    string
+	)
+	err = tx.QueryRow(ctx,
+		`SELECT r.id, r.user_id, u.username, r.session_id, r.expires_at, r.revoked_at IS NOT NULL, s.revoked_at IS NOT NULL,
+		 COALESCE(s.device_fingerprint, '')
+		 FROM refresh_tokens r JOIN users u ON u.id = r.user_id
+		 LEFT JOIN sessions s ON s.id = r.session_id
+		 WHERE r.token_hash=$1 FOR UPDATE OF r`,
+		hashOpaqueToken(refreshToken)).Scan(&id, &userID, &username, &sessionID, &expiresAt, &used, &ended, &device)
+	if errors.Is(err, pgx.ErrNoRows) {
+		s.recordAuthEvent(ctx, pb.AuthEventType_AUTH_EVENT_TYPE_TOKEN_REFRESH_FAILED, 0, "", "unknown token")
+		return nil, status.Error(codes.Unauthenticated, "invalid refresh token")
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "database error: %v", err)
+	}
+
+	// Tokens of a session that was logged out were revoked on purpose, not replayed
+	if ended {
+		s.recordAuthEvent(ctx, pb.AuthEventType_AUTH_EVENT_TYPE_TOKEN_REFRESH_FAILED, userID, username, "session revoked")
+		return nil, status.Error(codes.Unauthenticated, "session has been revoked; log in again")
+	}
+	if used {
+		// A replayed refresh token was probably stolen, so end every session of the user
+		if _, err := revokeAllSessions(ctx, tx, userID); err != nil {
+			return nil, status.Errorf(codes.Internal, "database error: %v", err)
+		}
+		if err := tx.Commit(ctx); err != nil {
+			return nil, status.Errorf(codes.Internal, "database error: %v", err)
+		}
+		s.recordAuthEvent(ctx, pb.AuthEventType_AUTH_EVENT_TYPE_TOKEN_REFRESH_FAILED, userID, username, "token reused; all sessions revoked")
+		return nil, status.Error(codes.Unauthenticated, "refresh token was already used; log in again")
+	}
+	// A refresh token replayed from another device is refused, but the session survives for its owner
+	if !sameDevice(ctx, device) {
+		s.recordAuthEvent(ctx, pb.AuthEventType_AUTH_EVENT_TYPE_TOKEN_REFRESH_FAILED, userID, username, "device mismatch")
+		return nil, status.Error(codes.Unauthenticated, "refresh token was issued to another device")
+	}
+	if !expiresAt.After(time.Now()) {
+		s.recordAuthEvent(ctx, pb.AuthEventType_AUTH_EVENT_TYPE_TOKEN_REFRESH_FAILED, userID, username, "token expired")
+		return nil, status.Error(codes.Unauthenticated, "refresh token expired; log in again")
+	}
+
+	if _, err := tx.Exec(ctx, "UPDATE refresh_tokens SET revoked_at=NOW() WHERE id=$1", id); err != nil {
+		return nil, status.Errorf(codes.Internal, "database error: %v", err)
+	}
+	// Refresh tokens issued before sessions existed start a new session
+	var session int64
+	if sessionID != nil {
+		session = *sessionID
+	}
+	resp, err := issueTokens(ctx, tx, userID, username, session, "Token refreshed")
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to issue tokens: %v", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, status.Errorf(codes.Internal, "database error: %v", err)
+	}
+	s.recordAuthEvent(ctx, pb.AuthEventType_AUTH_EVENT_TYPE_TOKEN_REFRESH, userID, username, "")
+	return resp, nil
+}
@@ -0,0 +1,45 @@
+package main
+
+import "testing"
+
+func TestNewOpaqueToken(t *testing.T) {
+	token, hash, err := newOpaqueToken()
+	if err != nil {
+		t.Fatalf("newOpaqueToken() error = %v", err)
+	}
+	if len(token) < 40 {
+		t.Errorf("refresh token %q is too short", token)
+	}
+	if hash != hashOpaqueToken(token) {
+		t.Error("stored hash does not match the token")
+	}
+	if hash == token {
+		t.Error("refresh token must not be stored in plain text")
+	}
+
+	other, _, err := newOpaqueToken()
+	if err != nil {
+		t.Fatalf("newOpaqueToken() error = %v", err)
+	}
+	if other == token {
+		t.Error("refresh tokens should be unique")
+	}
+}
+
+func TestPublicMethods(t *testing.T) {
+	for _, method := range []string{
+		"/library.UserService/Register",
+		"/library.UserService/Login",
+		"/library.UserService/RefreshToken",
+		"/library.UserService/LoginWithIdToken",
+		"/library.UserService/RequestPasswordReset",
+		"/library.UserService/ConfirmPasswordReset",
+	} {
+		if !publicMethods[method] {
+			t.Errorf("%s should not require a token", method)
+		}
+	}
+	if publicMethods["/library.LibraryService/AddBook"] {
+		t.Error("AddBook should require a token")
+	}
+}
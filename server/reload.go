@@ -0,0 +1,208 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"io/fs"
+	"log/slog"
+	"os"
+	"os/signal"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"syscall"
+
+	"github.com/joho/godotenv"
+	"google.golang.org/grpc"
+)
+
+// runtimeSettings are the settings a reload changes without a restart. They are swapped as a whole, so a
+// call sees either the old or the new version of all of them.
+type runtimeSettings struct {
+	// policy decides which methods need credentials, roles or scopes
+	policy *authPolicy
+	// requireEmail makes accounts verify their email address before they can log in
+	requireEmail bool
+	duplicates   duplicatePolicy
+	quotas       QuotaConfig
+}
+
+// newSettings returns the holder of settings, shared by the server and its interceptors
+func newSettings(settings *runtimeSettings) *atomic.Pointer[runtimeSettings] {
+	p := new(atomic.Pointer[runtimeSettings])
+	p.Store(settings)
+	return p
+}
+
+// runtime returns the settings in effect; a server created without settings has the zero ones
+func (s *server) runtime() *runtimeSettings {
+	if s.settings != nil {
+		if settings := s.settings.Load(); settings != nil {
+			return settings
+		}
+	}
+	return &runtimeSettings{}
+}
+
+// newRuntimeSettings loads the reloadable settings of cfg
+func newRuntimeSettings(cfg *Config) (*runtimeSettings, error) {
+	policy, err := loadAuthPolicy(cfg.Auth.PolicyFile)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.GRPC.Channelz {
+		restrictChannelz(policy)
+	}
+	duplicates, err := parseDuplicatePolicy(cfg.Catalog.DuplicateChecks)
+	if err != nil {
+		return nil, err
+	}
+	return &runtimeSettings{policy: policy, requireEmail: cfg.Auth.RequireVerifiedEmail, duplicates: duplicates, quotas: cfg.Quotas}, nil
+}
+
+// dotEnv applies the settings of a .env file to the environment, except those the process was started
+// with, which take precedence
+type dotEnv struct {
+	path string
+	// inherited are the variables set before the file was first read
+	inherited map[string]bool
+	// loaded are the variables the file set last time
+	loaded map[string]bool
+}
+
+func newDotEnv(path string) *dotEnv {
+	inherited := make(map[string]bool)
+	for _, kv := range os.Environ() {
+		name, _, _ := strings.Cut(kv, "=")
+		inherited[name] = true
+	}
+	return &dotEnv{path: path, inherited: inherited, loaded: make(map[string]bool)}
+}
+
+// load reads the file again; variables removed from it since the last load are unset. A missing file
+// sets nothing.
+func (e *dotEnv) load() error {
+	values, err := godotenv.Read(e.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		values = nil
+	} else if err != nil {
+		return err
+	}
+	for name := range e.loaded {
+		if _, ok := values[name]; !ok {
+			os.Unsetenv(name)
+		}
+	}
+	e.loaded = make(map[string]bool)
+	for name, value := range values {
+		if e.inherited[name] {
+			continue
+		}
+		os.Setenv(name, value)
+		e.loaded[name] = true
+	}
+	return nil
+}
+
+// reloader applies changes of the configuration file, the .env file and the authorization policy file to
+// the running server. Log levels, rate limits, the authorization policy, REQUIRE_VERIFIED_EMAIL,
+// DUPLICATE_CHECK and quotas change in place; other settings need a restart, as they are bound to the
+// listener, the database pool or the interceptors.
+type reloader struct {
+	env         *dotEnv
+	flags       *flag.FlagSet
+	services    map[string]grpc.ServiceInfo
+	logging     loggingConfig
+	rateLimiter *authRateLimiter
+	settings    *atomic.Pointer[runtimeSettings]
+	// cfg is the configuration last applied
+	cfg *Config
+}
+
+// reload reads every source again and applies the result, or nothing if any setting is invalid
+func (r *reloader) reload() error {
+	if err := r.env.load(); err != nil {
+		return err
+	}
+	cfg, err := LoadConfig(r.flags)
+	if err != nil {
+		return err
+	}
+	settings, err := newRuntimeSettings(cfg)
+	if err != nil {
+		return err
+	}
+	if err := settings.policy.checkMethods(r.services); err != nil {
+		return err
+	}
+
+	r.logging.setLevels(cfg.Logging)
+	r.rateLimiter.setLimits(cfg.RateLimits)
+	r.settings.Store(settings)
+	if changed := restartSettingsChanged(r.cfg, cfg); len(changed) > 0 {
+		slog.Warn("configuration changes that need a restart were not applied", "sections", changed)
+	}
+	r.cfg = cfg
+	return nil
+}
+
+// restartSettingsChanged lists the sections of the configuration that changed and are only read at startup.
+// The settings a reload applies are left out of the comparison.
+func restartSettingsChanged(old, cfg *Config) []string {
+	old, cfg = withoutReloadable(old), withoutReloadable(cfg)
+	var changed []string
+	for _, section := range []struct {
+		name     string
+		old, cfg any
+	}{
+		{"grpc", old.GRPC, cfg.GRPC},
+		{"gateway", old.Gateway, cfg.Gateway},
+		{"database", old.Database, cfg.Database},
+		{"auth", old.Auth, cfg.Auth},
+		{"timeouts", old.Timeouts, cfg.Timeouts},
+		{"concurrency", old.Concurrency, cfg.Concurrency},
+		{"cache", old.Cache, cfg.Cache},
+		{"shadow", old.Shadow, cfg.Shadow},
+		{"idempotency", old.Idempotency, cfg.Idempotency},
+		{"notifications", old.Notifications, cfg.Notifications},
+		{"purge", old.Purge, cfg.Purge},
+		{"tenants", old.Tenants, cfg.Tenants},
+		{"tls", old.TLS, cfg.TLS},
+		{"passwords", old.Passwords, cfg.Passwords},
+		{"challenge", old.Challenge, cfg.Challenge},
+		{"oidc", old.OIDC, cfg.OIDC},
+		{"mail", old.Mail, cfg.Mail},
+		{"circulation", old.Circulation, cfg.Circulation},
+		{"catalog", old.Catalog, cfg.Catalog},
+		{"logging", old.Logging, cfg.Logging},
+		{"error_reporting", old.ErrorReporting, cfg.ErrorReporting},
+	} {
+		if !reflect.DeepEqual(section.old, section.cfg) {
+			changed = append(changed, section.name)
+		}
+	}
+	return changed
+}
+
+// withoutReloadable returns a copy of cfg without the settings a reload applies in place
+func withoutReloadable(cfg *Config) *Config {
+	c := *cfg
+	c.Auth.PolicyFile, c.Auth.RequireVerifiedEmail = "", false
+	c.Logging.Level, c.Logging.RPCLevel = "", ""
+	c.Catalog.DuplicateChecks = nil
+	return &c
+}
+
+// reloadOnSIGHUP reloads the configuration whenever the process receives SIGHUP, e.g. from
+// `kill -HUP <pid>` or `systemctl reload`. Listeners and open streams are not affected.
+func (r *reloader) reloadOnSIGHUP() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+	for range signals {
+		if err := r.reload(); err != nil {
+			slog.Error("configuration reload failed; keeping the current configuration", "error", err)
+			continue
+		}
+		slog.Info("configuration reloaded")
+	}
+}
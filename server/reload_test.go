@@ -0,0 +1,186 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+
+	pb "example/grpc_demo/library"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// unsetEnv removes names from the environment for the duration of the test
+func unsetEnv(t *testing.T, names ...string) {
+	t.Helper()
+	for _, name := range names {
+		t.Setenv(name, "")
+		os.Unsetenv(name)
+	}
+}
+
+func writeTestFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDotEnvLoad(t *testing.T) {
+	unsetEnv(t, "LOG_LEVEL", "DUPLICATE_CHECK")
+	t.Setenv("LOG_FORMAT", "text")
+	path := filepath.Join(t.TempDir(), ".env")
+	writeTestFile(t, path, "LOG_LEVEL=debug\nLOG_FORMAT=json\nDUPLICATE_CHECK=isbn\n")
+
+	env := newDotEnv(path)
+	if err := env.load(); err != nil {
+		t.Fatalf("load() error = %v", err)
+	}
+	if os.Getenv("LOG_LEVEL") != "debug" || os.Getenv("DUPLICATE_CHECK") != "isbn" {
+		t.Errorf("LOG_LEVEL = %q, DUPLICATE_CHECK = %q; want the file's values", os.Getenv("LOG_LEVEL"), os.Getenv("DUPLICATE_CHECK"))
+	}
+	// The process environment wins over the file
+	if os.Getenv("LOG_FORMAT") != "text" {
+		t.Errorf("LOG_FORMAT = %q, want the inherited text", os.Getenv("LOG_FORMAT"))
+	}
+
+	// A variable removed from the file is unset by the next load
+	writeTestFile(t, path, "LOG_LEVEL=warn\n")
+	if err := env.load(); err != nil {
+		t.Fatalf("load() error = %v", err)
+	}
+	if _, ok := os.LookupEnv("DUPLICATE_CHECK"); ok || os.Getenv("LOG_LEVEL") != "warn" {
+		t.Errorf("after reload: LOG_LEVEL = %q, DUPLICATE_CHECK set = %v", os.Getenv("LOG_LEVEL"), ok)
+	}
+
+	if err := newDotEnv(filepath.Join(t.TempDir(), "missing")).load(); err != nil {
+		t.Errorf("missing file: load() error = %v", err)
+	}
+}
+
+// testReloader returns a reloader of the configuration in the .env file at path
+func testReloader(t *testing.T, path string) *reloader {
+	t.Helper()
+	for _, s := range (&Config{}).settings() {
+		unsetEnv(t, s.env)
+	}
+	unsetEnv(t, "CONFIG_FILE")
+	env := newDotEnv(path)
+	if err := env.load(); err != nil {
+		t.Fatal(err)
+	}
+
+	fs := flag.NewFlagSet("server", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	bindConfigFlags(fs)
+	cfg, err := LoadConfig(fs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	settings, err := newRuntimeSettings(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	logging := newLogging(io.Discard, cfg.Logging)
+	limiter := newAuthRateLimiter(cfg.RateLimits)
+
+	s := grpc.NewServer()
+	srv := &server{}
+	pb.RegisterUserServiceServer(s, srv)
+	pb.RegisterLibraryServiceServer(s, srv)
+	pb.RegisterTagServiceServer(s, srv)
+	pb.RegisterReviewServiceServer(s, srv)
+	pb.RegisterFavoriteServiceServer(s, srv)
+	pb.RegisterShelfServiceServer(s, srv)
+	pb.RegisterLoanServiceServer(s, srv)
+	pb.RegisterNotificationServiceServer(s, srv)
+	pb.RegisterPublisherServiceServer(s, srv)
+	pb.RegisterServerInfoServiceServer(s, srv)
+	healthpb.RegisterHealthServer(s, health.NewServer())
+	return &reloader{env: env, flags: fs, services: s.GetServiceInfo(), logging: logging, rateLimiter: limiter,
+		settings: newSettings(settings), cfg: cfg}
+}
+
+func TestReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	writeTestFile(t, path, "")
+	r := testReloader(t, path)
+	before := r.settings.Load()
+	if before.requireEmail || !before.duplicates.isbn || r.logging.level.Level() != slog.LevelInfo {
+		t.Fatalf("initial settings = %+v, log level %v", before, r.logging.level.Level())
+	}
+
+	policy := filepath.Join(t.TempDir(), "policy.json")
+	writeTestFile(t, policy, `{"methods": {"/library.LibraryService/ListBooks": {"access": "authenticated"}}}`)
+	writeTestFile(t, path, "LOG_LEVEL=debug\nLOG_RPC_LEVEL=warn\nAUTH_RATE_LIMIT_PER_IP=7\nREQUIRE_VERIFIED_EMAIL=true\n"+
+		"DUPLICATE_CHECK=off\nQUOTA_BOOKS_ADDED_PER_DAY=5\nAUTH_POLICY_FILE="+policy+"\n")
+	if err := r.reload(); err != nil {
+		t.Fatalf("reload() error = %v", err)
+	}
+	after := r.settings.Load()
+	if !after.requireEmail || after.duplicates.isbn || after.quotas.BooksAddedPerDay != 5 {
+		t.Errorf("reloaded settings = %+v", after)
+	}
+	if after.policy.rule("/library.LibraryService/ListBooks").Access != accessAuthenticated {
+		t.Error("reloaded policy does not have the ListBooks rule")
+	}
+	if r.logging.level.Level() != slog.LevelDebug || r.logging.rpcOKAt.Level() != slog.LevelWarn {
+		t.Errorf("log levels = %v, %v; want debug, warn", r.logging.level.Level(), r.logging.rpcOKAt.Level())
+	}
+	if got := r.rateLimiter.perIP.perMinute(); got != 7 {
+		t.Errorf("per-IP limit = %d, want 7", got)
+	}
+
+	// An invalid setting leaves everything as it was
+	writeTestFile(t, path, "LOG_LEVEL=error\nDUPLICATE_CHECK=sometimes\n")
+	if err := r.reload(); err == nil {
+		t.Fatal("reload() accepted an invalid DUPLICATE_CHECK")
+	}
+	if r.settings.Load() != after || r.logging.level.Level() != slog.LevelDebug {
+		t.Error("a failed reload changed the settings")
+	}
+	writeTestFile(t, path, "AUTH_POLICY_FILE="+filepath.Join(t.TempDir(), "missing.json")+"\n")
+	if err := r.reload(); err == nil || r.settings.Load() != after {
+		t.Errorf("reload() with a missing policy file: error = %v", err)
+	}
+}
+
+func TestRestartSettingsChanged(t *testing.T) {
+	old := defaultConfig()
+	cfg := defaultConfig()
+	cfg.Quotas.BooksAddedPerDay = 1
+	cfg.Logging.Level = "debug"
+	cfg.Catalog.DuplicateChecks = []string{"off"}
+	if changed := restartSettingsChanged(&old, &cfg); len(changed) != 0 {
+		t.Errorf("reloadable changes reported as needing a restart: %v", changed)
+	}
+	cfg.Database.Host = "db.internal"
+	cfg.GRPC.MaxRecvMsgSize = 1 << 20
+	if changed := restartSettingsChanged(&old, &cfg); len(changed) != 2 || changed[0] != "grpc" || changed[1] != "database" {
+		t.Errorf("changed = %v, want grpc and database", changed)
+	}
+}
+
+func TestAuthRateLimiterSetLimits(t *testing.T) {
+	limiter := &authRateLimiter{perIP: newTokenBucketLimiter(1), perUser: newTokenBucketLimiter(5)}
+	if ok, _ := limiter.allow("203.0.113.1", ""); !ok {
+		t.Fatal("first attempt rejected")
+	}
+	// An unchanged limit keeps its buckets: the address is still out of attempts
+	limiter.setLimits(RateLimitConfig{PerIP: 1, PerUser: 10})
+	if ok, _ := limiter.allow("203.0.113.1", ""); ok {
+		t.Error("setLimits() with the same per-IP limit refilled the bucket")
+	}
+	if limiter.perUser.perMinute() != 10 {
+		t.Errorf("per-user limit = %d, want 10", limiter.perUser.perMinute())
+	}
+	limiter.setLimits(RateLimitConfig{PerUser: 10})
+	if ok, _ := limiter.allow("203.0.113.1", ""); !ok {
+		t.Error("disabled per-IP limit rejected an attempt")
+	}
+}
@@ -0,0 +1,236 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	pb "example/grpc_demo/library"
+
+	"github.com/jackc/pgx/v5"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// reservationHoldPeriod is how long a returned copy is held for the next user in the queue
+const reservationHoldPeriod = 3 * 24 * time.Hour
+
+// reservationStatusNames maps statuses to the values stored in reservations.status
+var reservationStatusNames = map[pb.ReservationStatus]string{
+	pb.ReservationStatus_RESERVATION_STATUS_WAITING:   "waiting",
+	pb.ReservationStatus_RESERVATION_STATUS_READY:     "ready",
+	pb.ReservationStatus_RESERVATION_STATUS_FULFILLED: "fulfilled",
+	pb.ReservationStatus_RESERVATION_STATUS_CANCELLED: "cancelled",
+	pb.ReservationStatus_RESERVATION_STATUS_EXPIRED:   "expired",
+}
+
+// parseReservationStatus converts a stored status back into its enum value
+func parseReservationStatus(name string) pb.ReservationStatus {
+	for st, n := range reservationStatusNames {
+		if n == name {
+			return st
+		}
+	}
+	return pb.ReservationStatus_RESERVATION_STATUS_UNSPECIFIED
+}
+
+// reservationColumns is the column list read by scanReservation; reservations are aliased r and books b.
+// The queue position counts waiting reservations for the same book created no later than this one.
+const reservationColumns = `r.id, r.book_id, b.title, r.status, r.created_at, r.expires_at,
+	CASE WHEN r.status = 'waiting' THEN (
+		SELECT COUNT(*) FROM reservations q
+		WHERE q.book_id = r.book_id AND q.status = 'waiting' AND (q.created_at, q.id) <= (r.created_at, r.id)
+	) ELSE 0 END`
+
+// scanReservation reads a single reservation selected with reservationColumns
+func scanReservation(row pgx.Row) (*pb.Reservation, error) {
+	var (
+		res       pb.Reservation
+		st        string
+		createdAt time.Time
+		expiresAt *time.Time
+		position  int64
+	)
+	if err := row.Scan(&res.Id, &res.BookId, &res.BookTitle, &st, &createdAt, &expiresAt, &position); err != nil {
+		return nil, err
+	}
+	res.Status = parseReservationStatus(st)
+	res.CreatedAt = timestamppb.New(createdAt)
+	if expiresAt != nil {
+		res.ExpiresAt = timestamppb.New(*expiresAt)
+	}
+	res.QueuePosition = int32(position)
+	return &res, nil
+}
+
+// releaseCopy hands a copy that became free (returned loan, cancelled or expired hold)
+// to the first user waiting for the book, or puts it back on the shelf if nobody is.
+// It returns the ID of the promoted reservation, or 0 if the copy went back on the shelf.
+func releaseCopy(ctx context.Context, tx pgx.Tx, bookID string) (int64, error) {
+	var promoted int64
+	err := tx.QueryRow(ctx,
+		`UPDATE reservations SET status='ready', ready_at=NOW(), expires_at=NOW() + $2::interval
+		 WHERE id = (
+			SELECT id FROM reservations WHERE book_id=$1 AND status='waiting'
+			ORDER BY created_at, id LIMIT 1 FOR UPDATE SKIP LOCKED
+		 )
+		 RETURNING id`,
+		bookID, reservationHoldPeriod.String()).Scan(&promoted)
+	if err == nil {
+		return promoted, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return 0, err
+	}
+	_, err = tx.Exec(ctx, "UPDATE books SET available_copies = available_copies + 1 WHERE id=$1", bookID)
+	return 0, err
+}
+
+// claimHold marks the user's ready reservation for a book as fulfilled, reporting whether one existed
+func claimHold(ctx context.Context, tx pgx.Tx, bookID string, userID int) (bool, error) {
+	res, err := tx.Exec(ctx,
+		"UPDATE reservations SET status='fulfilled' WHERE book_id=$1 AND user_id=$2 AND status='ready' AND expires_at > NOW()",
+		bookID, userID)
+	if err != nil {
+		return false, err
+	}
+	return res.RowsAffected() > 0, nil
+}
+
+func (s *server) ReserveBook(ctx context.Context, req *pb.ReserveRequest) (*pb.Reservation, error) {
+	userID, _, ok := userFromContext(ctx)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "authentication required")
+	}
+	if req.GetBookId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "Book ID is required")
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "database error: %v", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var available int32
+	err = tx.QueryRow(ctx, "SELECT available_copies FROM books WHERE id=$1", req.GetBookId()).Scan(&available)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, notFound(resourceBook, req.GetBookId(), "Book not found")
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "database error: %v", err)
+	}
+	if available > 0 {
+		return nil, status.Error(codes.FailedPrecondition, "copies are available; borrow the book instead")
+	}
+
+	var onLoan bool
+	err = tx.QueryRow(ctx, "SELECT EXISTS(SELECT 1 FROM loans WHERE book_id=$1 AND user_id=$2 AND returned_at IS NULL)", req.GetBookId(), userID).Scan(&onLoan)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "database error: %v", err)
+	}
+	if onLoan {
+		return nil, status.Error(codes.FailedPrecondition, "you already have this book on loan")
+	}
+
+	var id int64
+	err = tx.QueryRow(ctx, "INSERT INTO reservations (book_id, user_id) VALUES ($1, $2) RETURNING id", req.GetBookId(), userID).Scan(&id)
+	if isUniqueViolation(err) {
+		return nil, status.Error(codes.AlreadyExists, "you already have a reservation for this book")
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to create reservation: %v", err)
+	}
+
+	reservation, err := scanReservation(tx.QueryRow(ctx, "SELECT "+reservationColumns+" FROM reservations r JOIN books b ON b.id = r.book_id WHERE r.id=$1", id))
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to load reservation: %v", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to create reservation: %v", err)
+	}
+	return reservation, nil
+}
+
+func (s *server) CancelReservation(ctx context.Context, req *pb.ReservationRequest) (*pb.Reservation, error) {
+	userID, _, ok := userFromContext(ctx)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "authentication required")
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "database error: %v", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var bookID, st string
+	err = tx.QueryRow(ctx, "SELECT book_id, status FROM reservations WHERE id=$1 AND user_id=$2 FOR UPDATE", req.GetId(), userID).Scan(&bookID, &st)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, notFound(resourceReservation, strconv.FormatInt(req.GetId(), 10), "Reservation not found")
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "database error: %v", err)
+	}
+	if st != "waiting" && st != "ready" {
+		return nil, status.Errorf(codes.FailedPrecondition, "reservation is already %s", st)
+	}
+
+	if _, err := tx.Exec(ctx, "UPDATE reservations SET status='cancelled' WHERE id=$1", req.GetId()); err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to cancel reservation: %v", err)
+	}
+	// A held copy goes to the next user in line
+	var promoted int64
+	if st == "ready" {
+		if promoted, err = releaseCopy(ctx, tx, bookID); err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to release held copy: %v", err)
+		}
+	}
+
+	reservation, err := scanReservation(tx.QueryRow(ctx, "SELECT "+reservationColumns+" FROM reservations r JOIN books b ON b.id = r.book_id WHERE r.id=$1", req.GetId()))
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to load reservation: %v", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to cancel reservation: %v", err)
+	}
+	s.cache.invalidate()
+	s.notifyHoldReady(ctx, promoted)
+	return reservation, nil
+}
+
+func (s *server) ListReservations(ctx context.Context, req *pb.ListReservationsRequest) (*pb.ListReservationsResponse, error) {
+	userID, _, ok := userFromContext(ctx)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "authentication required")
+	}
+
+	var filter sqlFilter
+	filter.where("r.user_id = " + filter.arg(userID))
+	if !req.GetIncludeClosed() {
+		filter.where("r.status IN ('waiting', 'ready')")
+	}
+	query := "SELECT " + reservationColumns + " FROM reservations r JOIN books b ON b.id = r.book_id" + filter.clause() +
+		" ORDER BY r.created_at DESC, r.id DESC"
+
+	rows, err := s.db.Query(ctx, query, filter.args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var reservations []*pb.Reservation
+	for rows.Next() {
+		r, err := scanReservation(rows)
+		if err != nil {
+			return nil, err
+		}
+		reservations = append(reservations, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return &pb.ListReservationsResponse{Reservations: reservations}, nil
+}
@@ -0,0 +1,19 @@
+package main
+
+import (
+	"testing"
+
+	pb "example/grpc_demo/library"
+)
+
+func TestReservationStatusRoundTrip(t *testing.T) {
+	for st, name := range reservationStatusNames {
+		if got := parseReservationStatus(name); got != st {
+			t.Errorf("parseReservationStatus(%q) = %v, want %v", name, got, st)
+		}
+	}
+
+	if got := parseReservationStatus("unknown"); got != pb.ReservationStatus_RESERVATION_STATUS_UNSPECIFIED {
+		t.Errorf("parseReservationStatus(unknown) = %v, want UNSPECIFIED", got)
+	}
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"container/list"
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	pb "example/grpc_demo/library"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+)
+
+// cachedMethods are the reads whose responses are cached. Their responses depend only on the request and
+// the caller's languages, not on who the caller is.
+var cachedMethods = map[string]bool{
+	pb.LibraryService_GetBook_FullMethodName:   true,
+	pb.LibraryService_ListBooks_FullMethodName: true,
+}
+
+// invalidatesCache tells whether a successful call to method may change cached responses: every mutation
+// recorded in the audit log, but those of users' accounts
+func invalidatesCache(method string) bool {
+	return auditedMethods[method] && !strings.HasPrefix(method, "/library.UserService/")
+}
+
+// responseCache is an in-memory LRU cache of responses, each kept for the configured TTL. Mutations
+// handled by this process, and the circulation sweep when it passes on expired holds, empty it; those of
+// other replicas show up within a TTL.
+type responseCache struct {
+	mu      sync.Mutex
+	size    int
+	ttl     time.Duration
+	entries map[string]*list.Element
+	// recent holds the *cacheEntry values, most recently used first
+	recent *list.List
+	// generation counts invalidations, so that a response read before one isn't cached after it
+	generation uint64
+}
+
+type cacheEntry struct {
+	key     string
+	resp    proto.Message
+	expires time.Time
+}
+
+// newResponseCache returns the cache of cfg, or nil when caching is off
+func newResponseCache(cfg CacheConfig) *responseCache {
+	if cfg.Size == 0 {
+		return nil
+	}
+	return &responseCache{size: cfg.Size, ttl: cfg.TTL, entries: make(map[string]*list.Element), recent: list.New()}
+}
+
+// get returns a copy of the response cached under key, and the generation to store a fresh one with
+// when there is none
+func (c *responseCache) get(key string) (resp proto.Message, generation uint64, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[key]
+	if !ok {
+		return nil, c.generation, false
+	}
+	entry := e.Value.(*cacheEntry)
+	if time.Now().After(entry.expires) {
+		c.recent.Remove(e)
+		delete(c.entries, key)
+		return nil, c.generation, false
+	}
+	c.recent.MoveToFront(e)
+	return proto.Clone(entry.resp), c.generation, true
+}
+
+// put caches resp under key unless the cache was invalidated since generation, evicting the least
+// recently used response when the cache is full
+func (c *responseCache) put(key string, resp proto.Message, generation uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if generation != c.generation {
+		return
+	}
+	entry := &cacheEntry{key: key, resp: resp, expires: time.Now().Add(c.ttl)}
+	if e, ok := c.entries[key]; ok {
+		e.Value = entry
+		c.recent.MoveToFront(e)
+		return
+	}
+	c.entries[key] = c.recent.PushFront(entry)
+	if c.recent.Len() > c.size {
+		oldest := c.recent.Back()
+		c.recent.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key)
+	}
+}
+
+// invalidate empties the cache, if there is one
+func (c *responseCache) invalidate() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.generation++
+	clear(c.entries)
+	c.recent.Init()
+}
+
+// responseCacheKey identifies the response to a call of ctx to method with req: the request's bytes, the
+// languages the caller asked titles and descriptions in, and the library it is for
+func responseCacheKey(ctx context.Context, method string, req proto.Message) (string, error) {
+	data, err := proto.MarshalOptions{Deterministic: true}.Marshal(req)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	b.WriteString(method)
+	b.WriteByte(0)
+	b.Write(data)
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, key := range localeHeaders {
+		b.WriteByte(0)
+		b.WriteString(strings.Join(md.Get(key), ","))
+	}
+	b.WriteByte(0)
+	b.WriteString(tenantFromContext(ctx))
+	return b.String(), nil
+}
+
+// CreateCacheInterceptor answers cachedMethods from cache when it can, and invalidates it when a mutation
+// succeeds. It runs after the auth interceptors, so that only callers allowed to make a call get its
+// cached response.
+func CreateCacheInterceptor(cache *responseCache) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if cache == nil {
+			return handler(ctx, req)
+		}
+		if !cachedMethods[info.FullMethod] {
+			resp, err := handler(ctx, req)
+			if err == nil && invalidatesCache(info.FullMethod) {
+				cache.invalidate()
+			}
+			return resp, err
+		}
+
+		m, ok := req.(proto.Message)
+		if !ok {
+			return handler(ctx, req)
+		}
+		key, err := responseCacheKey(ctx, info.FullMethod, m)
+		if err != nil {
+			return handler(ctx, req)
+		}
+		cached, generation, ok := cache.get(key)
+		if ok {
+			responseCacheRequests.WithLabelValues(info.FullMethod, "hit").Inc()
+			return cached, nil
+		}
+		responseCacheRequests.WithLabelValues(info.FullMethod, "miss").Inc()
+		resp, err := handler(ctx, req)
+		if err != nil {
+			return resp, err
+		}
+		if fresh, ok := resp.(proto.Message); ok {
+			cache.put(key, proto.Clone(fresh), generation)
+		}
+		return resp, nil
+	}
+}
+
+// CreateStreamCacheInterceptor invalidates the cache when a streaming mutation, such as BatchAddBooks, ends.
+// Even a failed one may have written some of its messages.
+func CreateStreamCacheInterceptor(cache *responseCache) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		err := handler(srv, ss)
+		if cache != nil && invalidatesCache(info.FullMethod) {
+			cache.invalidate()
+		}
+		return err
+	}
+}
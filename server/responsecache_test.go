@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	pb "example/grpc_demo/library"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestResponseCacheLRU(t *testing.T) {
+	cache := newResponseCache(CacheConfig{Size: 2, TTL: time.Minute})
+	_, generation, _ := cache.get("a")
+	cache.put("a", &pb.Book{Id: "a"}, generation)
+	cache.put("b", &pb.Book{Id: "b"}, generation)
+	// Reading a makes b the least recently used, evicted by c
+	if resp, _, ok := cache.get("a"); !ok || resp.(*pb.Book).GetId() != "a" {
+		t.Fatalf("get(a) = %v, %v", resp, ok)
+	}
+	cache.put("c", &pb.Book{Id: "c"}, generation)
+	if _, _, ok := cache.get("b"); ok {
+		t.Error("b was not evicted")
+	}
+	for _, key := range []string{"a", "c"} {
+		if _, _, ok := cache.get(key); !ok {
+			t.Errorf("%s was evicted", key)
+		}
+	}
+
+	// Copies are returned, so callers can't change what is cached
+	resp, _, _ := cache.get("a")
+	resp.(*pb.Book).Title = "changed"
+	if resp, _, _ := cache.get("a"); resp.(*pb.Book).GetTitle() != "" {
+		t.Error("the cached response was changed through a copy")
+	}
+
+	cache.invalidate()
+	if _, _, ok := cache.get("a"); ok {
+		t.Error("invalidate() left a")
+	}
+	// A response read before the invalidation is not cached after it
+	cache.put("d", &pb.Book{Id: "d"}, generation)
+	if _, _, ok := cache.get("d"); ok {
+		t.Error("a response of the previous generation was cached")
+	}
+}
+
+func TestResponseCacheTTL(t *testing.T) {
+	cache := newResponseCache(CacheConfig{Size: 10, TTL: time.Millisecond})
+	cache.put("a", &pb.Book{Id: "a"}, 0)
+	time.Sleep(5 * time.Millisecond)
+	if _, _, ok := cache.get("a"); ok {
+		t.Error("an expired response was served")
+	}
+	if len(cache.entries) != 0 || cache.recent.Len() != 0 {
+		t.Error("the expired response was kept")
+	}
+	if newResponseCache(CacheConfig{TTL: time.Minute}) != nil {
+		t.Error("a cache of size 0 was created")
+	}
+}
+
+func TestResponseCacheKey(t *testing.T) {
+	ctx := context.Background()
+	key := func(ctx context.Context, req *pb.ListBookRequest) string {
+		k, err := responseCacheKey(ctx, pb.LibraryService_ListBooks_FullMethodName, req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return k
+	}
+	first := key(ctx, &pb.ListBookRequest{Page: 1, PageSize: 10})
+	if key(ctx, &pb.ListBookRequest{Page: 1, PageSize: 10}) != first {
+		t.Error("equal requests have different keys")
+	}
+	if key(ctx, &pb.ListBookRequest{Page: 2, PageSize: 10}) == first {
+		t.Error("different pages have the same key")
+	}
+	portuguese := metadata.NewIncomingContext(ctx, metadata.Pairs("accept-language", "pt-BR"))
+	if key(portuguese, &pb.ListBookRequest{Page: 1, PageSize: 10}) == first {
+		t.Error("different languages have the same key")
+	}
+	if key(withTenant(ctx, "riverside"), &pb.ListBookRequest{Page: 1, PageSize: 10}) == first {
+		t.Error("different libraries have the same key")
+	}
+}
+
+func TestCacheInterceptor(t *testing.T) {
+	cache := newResponseCache(CacheConfig{Size: 10, TTL: time.Minute})
+	interceptor := CreateCacheInterceptor(cache)
+	getBook := &grpc.UnaryServerInfo{FullMethod: pb.LibraryService_GetBook_FullMethodName}
+	calls := 0
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		calls++
+		return &pb.Book{Id: req.(*pb.BookRequest).GetId()}, nil
+	}
+	hits := testutil.ToFloat64(responseCacheRequests.WithLabelValues(getBook.FullMethod, "hit"))
+
+	for range 3 {
+		resp, err := interceptor(context.Background(), &pb.BookRequest{Id: "b1"}, getBook, handler)
+		if err != nil || resp.(*pb.Book).GetId() != "b1" {
+			t.Fatalf("interceptor() = %v, %v", resp, err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("handler called %d times, want once", calls)
+	}
+	if got := testutil.ToFloat64(responseCacheRequests.WithLabelValues(getBook.FullMethod, "hit")) - hits; got != 2 {
+		t.Errorf("%v hits counted, want 2", got)
+	}
+
+	// Errors are not cached
+	failing := func(context.Context, interface{}) (interface{}, error) {
+		return nil, status.Error(codes.NotFound, "Book not found")
+	}
+	interceptor(context.Background(), &pb.BookRequest{Id: "missing"}, getBook, failing)
+	if _, err := interceptor(context.Background(), &pb.BookRequest{Id: "missing"}, getBook, handler); err != nil || calls != 2 {
+		t.Errorf("after a failure: error %v, %d handler calls", err, calls)
+	}
+
+	// A failed mutation keeps the cache, a successful one empties it, and reads of other services don't
+	ok := func(context.Context, interface{}) (interface{}, error) { return &pb.BookResponse{}, nil }
+	interceptor(context.Background(), &pb.Book{Id: "b1"}, &grpc.UnaryServerInfo{FullMethod: pb.LibraryService_UpdateBook_FullMethodName}, failing)
+	interceptor(context.Background(), &pb.ListTagsRequest{}, &grpc.UnaryServerInfo{FullMethod: pb.TagService_ListTags_FullMethodName}, ok)
+	interceptor(context.Background(), &pb.LogoutRequest{}, &grpc.UnaryServerInfo{FullMethod: pb.UserService_Logout_FullMethodName}, ok)
+	if len(cache.entries) != 2 {
+		t.Fatalf("%d responses cached, want 2", len(cache.entries))
+	}
+	interceptor(context.Background(), &pb.Book{Id: "b1"}, &grpc.UnaryServerInfo{FullMethod: pb.LibraryService_UpdateBook_FullMethodName}, ok)
+	if len(cache.entries) != 0 {
+		t.Errorf("%d responses cached after UpdateBook, want none", len(cache.entries))
+	}
+}
+
+func TestStreamCacheInterceptor(t *testing.T) {
+	cache := newResponseCache(CacheConfig{Size: 10, TTL: time.Minute})
+	cache.put("a", &pb.Book{Id: "a"}, 0)
+	interceptor := CreateStreamCacheInterceptor(cache)
+	// A failed import may still have added books
+	interceptor(nil, nil, &grpc.StreamServerInfo{FullMethod: pb.LibraryService_ImportBooks_FullMethodName}, func(interface{}, grpc.ServerStream) error {
+		return status.Error(codes.Internal, "failed")
+	})
+	if len(cache.entries) != 0 {
+		t.Error("ImportBooks did not empty the cache")
+	}
+	// A nil cache is a no-op
+	CreateStreamCacheInterceptor(nil)(nil, nil, &grpc.StreamServerInfo{FullMethod: pb.LibraryService_ImportBooks_FullMethodName}, func(interface{}, grpc.ServerStream) error { return nil })
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// The actions of retention rules, which label their metrics
+const (
+	retentionAnonymize = "anonymize"
+	retentionDelete    = "delete"
+)
+
+// retentionRule is what a retention policy does to the rows of a table once they are old enough
+type retentionRule struct {
+	table, action string
+	// where selects the rows older than $1 seconds the rule still has to change
+	where string
+	// set clears the personal data of the rows anonymized
+	set string
+	// age is the age of the rows the rule changes in cfg, 0 to leave them be
+	age func(cfg RetentionConfig) time.Duration
+}
+
+// retentionRules are run in order, each in batches of purgeBatchSize rows. Deleting first spares
+// anonymizing the rows about to go.
+var retentionRules = []retentionRule{
+	{
+		table: "auth_events", action: retentionDelete,
+		where: "created_at < NOW() - make_interval(secs => $1)",
+		age:   func(cfg RetentionConfig) time.Duration { return cfg.AuthEvents.DeleteAfter },
+	},
+	{
+		table: "audit_log", action: retentionDelete,
+		where: "created_at < NOW() - make_interval(secs => $1)",
+		age:   func(cfg RetentionConfig) time.Duration { return cfg.AuditLog.DeleteAfter },
+	},
+	// Sign-ins stay attributed to their account; where they came from is forgotten
+	{
+		table: "auth_events", action: retentionAnonymize,
+		where: "created_at < NOW() - make_interval(secs => $1) AND (ip_address <> '' OR user_agent <> '')",
+		set:   "ip_address = '', user_agent = ''",
+		age:   func(cfg RetentionConfig) time.Duration { return cfg.AuthEvents.AnonymizeAfter },
+	},
+	// Changes stay recorded, no longer attributed to anyone
+	{
+		table: "audit_log", action: retentionAnonymize,
+		where: "created_at < NOW() - make_interval(secs => $1) AND (user_id IS NOT NULL OR username <> '')",
+		set:   "user_id = NULL, username = ''",
+		age:   func(cfg RetentionConfig) time.Duration { return cfg.AuditLog.AnonymizeAfter },
+	},
+}
+
+// query changes up to $2 of the rows of the rule older than $1 seconds
+func (r retentionRule) query() string {
+	batch := "SELECT id FROM " + r.table + " WHERE " + r.where + " LIMIT $2"
+	if r.action == retentionDelete {
+		return "DELETE FROM " + r.table + " WHERE id IN (" + batch + ")"
+	}
+	return "UPDATE " + r.table + " SET " + r.set + " WHERE id IN (" + batch + ")"
+}
+
+// countQuery counts the rows of the rule older than $1 seconds, which a dry run reports
+func (r retentionRule) countQuery() string {
+	return "SELECT COUNT(*) FROM " + r.table + " WHERE " + r.where
+}
+
+// applyRetention runs the rules of retentionRules with the ages of cfg in the library of ctx, or only
+// counts the rows they would change when cfg.DryRun is set
+func applyRetention(ctx context.Context, db *pgxpool.Pool, cfg RetentionConfig) error {
+	for _, rule := range retentionRules {
+		age := rule.age(cfg)
+		if age <= 0 {
+			continue
+		}
+		if cfg.DryRun {
+			var rows int64
+			if err := db.QueryRow(ctx, rule.countQuery(), age.Seconds()).Scan(&rows); err != nil {
+				return fmt.Errorf("%s: %w", rule.table, err)
+			}
+			retentionDryRunRows.WithLabelValues(rule.table, rule.action).Set(float64(rows))
+			slog.InfoContext(ctx, "retention dry run", "table", rule.table, "action", rule.action, "rows", rows, "older_than", age,
+				"tenant", tenantFromContext(ctx))
+			continue
+		}
+		var changed int64
+		for {
+			tag, err := db.Exec(ctx, rule.query(), age.Seconds(), purgeBatchSize)
+			if err != nil {
+				return fmt.Errorf("%s: %w", rule.table, err)
+			}
+			changed += tag.RowsAffected()
+			retentionRows.WithLabelValues(rule.table, rule.action).Add(float64(tag.RowsAffected()))
+			if tag.RowsAffected() < purgeBatchSize {
+				break
+			}
+		}
+		if changed > 0 {
+			slog.InfoContext(ctx, "applied retention policy", "table", rule.table, "action", rule.action, "rows", changed,
+				"tenant", tenantFromContext(ctx))
+		}
+	}
+	return nil
+}
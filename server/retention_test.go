@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func TestRetentionRuleQuery(t *testing.T) {
+	for _, tt := range []struct {
+		rule retentionRule
+		want string
+	}{
+		{
+			retentionRule{table: "audit_log", action: retentionDelete, where: "created_at < $1"},
+			"DELETE FROM audit_log WHERE id IN (SELECT id FROM audit_log WHERE created_at < $1 LIMIT $2)",
+		},
+		{
+			retentionRule{table: "auth_events", action: retentionAnonymize, where: "created_at < $1", set: "ip_address = ''"},
+			"UPDATE auth_events SET ip_address = '' WHERE id IN (SELECT id FROM auth_events WHERE created_at < $1 LIMIT $2)",
+		},
+	} {
+		if got := tt.rule.query(); got != tt.want {
+			t.Errorf("query() = %q, want %q", got, tt.want)
+		}
+	}
+
+	// Every policy of the configuration has its rules
+	cfg := RetentionConfig{
+		AuthEvents: RetentionPolicy{AnonymizeAfter: 1, DeleteAfter: 2},
+		AuditLog:   RetentionPolicy{AnonymizeAfter: 3, DeleteAfter: 4},
+	}
+	seen := map[time.Duration]bool{}
+	for _, rule := range retentionRules {
+		seen[rule.age(cfg)] = true
+	}
+	if len(seen) != 4 {
+		t.Errorf("retentionRules cover ages %v, want 1 to 4", seen)
+	}
+}
+
+// TestApplyRetention runs against the database of STORAGE_TEST_DATABASE_URL, adding sign-in events that it
+// removes afterwards
+func TestApplyRetention(t *testing.T) {
+	dsn := os.Getenv("STORAGE_TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("STORAGE_TEST_DATABASE_URL is not set")
+	}
+	ctx := context.Background()
+	pool, err := pgxpool.New(ctx, dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Close()
+	if err := RunMigrations(pool); err != nil {
+		t.Fatalf("migrations: %v", err)
+	}
+	const username = "retention-test"
+	cleanup := func() {
+		if _, err := pool.Exec(ctx, "DELETE FROM auth_events WHERE username = $1", username); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cleanup()
+	defer cleanup()
+	for _, age := range []string{"1 day", "40 days", "100 days"} {
+		_, err := pool.Exec(ctx,
+			`INSERT INTO auth_events (username, event, ip_address, user_agent, created_at)
+			 VALUES ($1, 'login', '192.0.2.1', 'curl', NOW() - $2::interval)`, username, age)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	events := func() (total, anonymized int) {
+		err := pool.QueryRow(ctx,
+			"SELECT COUNT(*), COUNT(*) FILTER (WHERE ip_address = '' AND user_agent = '') FROM auth_events WHERE username = $1",
+			username).Scan(&total, &anonymized)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return total, anonymized
+	}
+
+	cfg := RetentionConfig{AuthEvents: RetentionPolicy{AnonymizeAfter: 30 * 24 * time.Hour, DeleteAfter: 90 * 24 * time.Hour}, DryRun: true}
+	if err := applyRetention(ctx, pool, cfg); err != nil {
+		t.Fatalf("dry run: %v", err)
+	}
+	if total, anonymized := events(); total != 3 || anonymized != 0 {
+		t.Errorf("after a dry run: %d events, %d anonymized; want 3 and 0", total, anonymized)
+	}
+
+	cfg.DryRun = false
+	if err := applyRetention(ctx, pool, cfg); err != nil {
+		t.Fatal(err)
+	}
+	if total, anonymized := events(); total != 2 || anonymized != 1 {
+		t.Errorf("after applying: %d events, %d anonymized; want 2 and 1", total, anonymized)
+	}
+}
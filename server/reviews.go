@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	pb "example/grpc_demo/library"
+	"example/grpc_demo/server/storage"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func (s *server) AddReview(ctx context.Context, review *pb.Review) (*pb.ReviewResponse, error) {
+	userID, _, ok := userFromContext(ctx)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "authentication required")
+	}
+	if review.GetBookId() == "" {
+		return nil, badRequest("book_id", "Book ID is required")
+	}
+	if review.GetRating() < 1 || review.GetRating() > 5 {
+		return nil, badRequest("rating", "Rating must be between 1 and 5")
+	}
+
+	var exists bool
+	err := s.db.QueryRow(ctx, "SELECT EXISTS(SELECT 1 FROM books WHERE id=$1)", review.GetBookId()).Scan(&exists)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "database error: %v", err)
+	}
+	if !exists {
+		return nil, notFound(resourceBook, review.GetBookId(), "Book not found")
+	}
+
+	// A user has one review per book; reviewing again replaces it
+	var id int64
+	err = s.db.QueryRow(ctx,
+		`INSERT INTO reviews (book_id, user_id, rating, comment) VALUES ($1, $2, $3, $4)
+		 ON CONFLICT (book_id, user_id) DO UPDATE SET rating=EXCLUDED.rating, comment=EXCLUDED.comment, created_at=NOW()
+		 RETURNING id`,
+		review.GetBookId(), userID, review.GetRating(), review.GetComment()).Scan(&id)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to save review: %v", err)
+	}
+	return &pb.ReviewResponse{Id: id}, nil
+}
+
+func (s *server) ListReviews(ctx context.Context, req *pb.ListReviewsRequest) (*pb.ListReviewsResponse, error) {
+	if req.GetBookId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "Book ID is required")
+	}
+	page, err := pageOf(req, "reviews "+req.GetBookId(), time.Time{}, int64(0))
+	if err != nil {
+		return nil, err
+	}
+
+	var filter sqlFilter
+	filter.where("r.book_id = " + filter.arg(req.GetBookId()))
+	order := storage.Keyset{Columns: []string{"r.created_at", "r.id"}, Desc: true}
+	page.where(&filter, order)
+	rows, err := s.db.Query(ctx,
+		"SELECT r.id, r.user_id, u.username, r.rating, r.comment, r.created_at FROM reviews r JOIN users u ON u.id = r.user_id"+
+			filter.clause()+order.OrderBy()+page.limitOffset(&filter),
+		filter.args...)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "database error: %v", err)
+	}
+	defer rows.Close()
+
+	var reviews []*pb.Review
+	for rows.Next() {
+		r := &pb.Review{BookId: req.GetBookId()}
+		var createdAt time.Time
+		if err := rows.Scan(&r.Id, &r.UserId, &r.Username, &r.Rating, &r.Comment, &createdAt); err != nil {
+			return nil, status.Errorf(codes.Internal, "database error: %v", err)
+		}
+		r.CreatedAt = timestamppb.New(createdAt)
+		reviews = append(reviews, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, status.Errorf(codes.Internal, "database error: %v", err)
+	}
+
+	reviews, next := nextPage(page, reviews, func(r *pb.Review) []any { return []any{r.GetCreatedAt().AsTime(), r.GetId()} })
+	resp := &pb.ListReviewsResponse{Reviews: reviews, NextPageToken: next}
+	err = s.db.QueryRow(ctx,
+		"SELECT COUNT(*), COALESCE(AVG(rating), 0)::float8 FROM reviews WHERE book_id=$1",
+		req.GetBookId()).Scan(&resp.TotalCount, &resp.AverageRating)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "database error: %v", err)
+	}
+	return resp, nil
+}
+
+func (s *server) DeleteReview(ctx context.Context, req *pb.ReviewRequest) (*pb.ReviewResponse, error) {
+	userID, _, ok := userFromContext(ctx)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "authentication required")
+	}
+	if req.GetId() == 0 {
+		return nil, badRequest("id", "Review ID is required")
+	}
+
+	// Users can only delete their own reviews
+	res, err := s.db.Exec(ctx, "DELETE FROM reviews WHERE id=$1 AND user_id=$2", req.GetId(), userID)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to delete review: %v", err)
+	}
+	if res.RowsAffected() == 0 {
+		return nil, notFound(resourceReview, strconv.FormatInt(req.GetId(), 10), "Review not found")
+	}
+	return &pb.ReviewResponse{Id: req.GetId()}, nil
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	pb "example/grpc_demo/library"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestAddReviewValidation(t *testing.T) {
+	s := &server{}
+	if _, err := s.AddReview(context.Background(), &pb.Review{BookId: "b1", Rating: 5}); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("AddReview() without a user error = %v, want Unauthenticated", err)
+	}
+	ctx := context.WithValue(context.Background(), userIDKey, 1)
+	for _, rating := range []int32{0, 6} {
+		if _, err := s.AddReview(ctx, &pb.Review{BookId: "b1", Rating: rating}); status.Code(err) != codes.InvalidArgument {
+			t.Errorf("AddReview() rated %d error = %v, want InvalidArgument", rating, err)
+		}
+	}
+	if _, err := s.AddReview(ctx, &pb.Review{Rating: 5}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("AddReview() without a book error = %v, want InvalidArgument", err)
+	}
+	if _, err := s.ListReviews(ctx, &pb.ListReviewsRequest{}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("ListReviews() without a book error = %v, want InvalidArgument", err)
+	}
+	if _, err := s.ListReviews(ctx, &pb.ListReviewsRequest{BookId: "b1", PageToken: "garbage"}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("ListReviews() with a malformed page token error = %v, want InvalidArgument", err)
+	}
+	if _, err := s.DeleteReview(context.Background(), &pb.ReviewRequest{Id: 1}); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("DeleteReview() without a user error = %v, want Unauthenticated", err)
+	}
+	if _, err := s.DeleteReview(ctx, &pb.ReviewRequest{}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("DeleteReview() without an ID error = %v, want InvalidArgument", err)
+	}
+}
+
+// TestReviewPermissions checks reviews are written and deleted as the caller, against a fake database
+func TestReviewPermissions(t *testing.T) {
+	pool, db := newFakeDatabase(t)
+	s := &server{db: pool}
+	alice := context.WithValue(context.Background(), userIDKey, 1)
+	bob := context.WithValue(context.Background(), userIDKey, 2)
+	db.answer("FROM books WHERE id=('b1')", "SELECT 1", []any{true})
+	db.answer("FROM books WHERE id=", "SELECT 1", []any{false})
+	db.answer("INSERT INTO reviews", "INSERT 0 1", []any{int64(7)})
+	// Review 7 is alice's
+	db.answer("DELETE FROM reviews WHERE id=('7') AND user_id=('1')", "DELETE 1")
+	db.answer("DELETE FROM reviews", "DELETE 0")
+
+	if _, err := s.AddReview(alice, &pb.Review{BookId: "b2", Rating: 4}); status.Code(err) != codes.NotFound {
+		t.Errorf("AddReview() of an unknown book error = %v, want NotFound", err)
+	}
+	if db.received("INSERT INTO reviews") {
+		t.Error("a review of an unknown book was written")
+	}
+	if resp, err := s.AddReview(alice, &pb.Review{BookId: "b1", Rating: 4}); err != nil || resp.GetId() != 7 {
+		t.Fatalf("AddReview() = %v, %v, want review 7", resp, err)
+	}
+	if !db.received("VALUES (('b1'), ('1'), ('4'), (''))") {
+		t.Error("the review was not written as alice's")
+	}
+
+	if _, err := s.DeleteReview(bob, &pb.ReviewRequest{Id: 7}); status.Code(err) != codes.NotFound {
+		t.Errorf("DeleteReview() of another user's review error = %v, want NotFound", err)
+	}
+	if resp, err := s.DeleteReview(alice, &pb.ReviewRequest{Id: 7}); err != nil || resp.GetId() != 7 {
+		t.Errorf("DeleteReview() of alice's review = %v, %v", resp, err)
+	}
+}
+
+// TestReviews runs against the database of STORAGE_TEST_DATABASE_URL
+func TestReviews(t *testing.T) {
+	pool := testDatabase(t, "reviews-test-")
+	s := &server{db: pool}
+	alice := testUser(t, pool, "reviews-test-alice")
+	bob := testUser(t, pool, "reviews-test-bob")
+	testBook(t, pool, "reviews-test-dune", 1)
+
+	if _, err := s.AddReview(alice, &pb.Review{BookId: "reviews-test-missing", Rating: 4}); status.Code(err) != codes.NotFound {
+		t.Errorf("AddReview() of an unknown book error = %v, want NotFound", err)
+	}
+	first, err := s.AddReview(alice, &pb.Review{BookId: "reviews-test-dune", Rating: 2})
+	if err != nil {
+		t.Fatalf("AddReview() error = %v", err)
+	}
+	// Reviewing again replaces the review
+	again, err := s.AddReview(alice, &pb.Review{BookId: "reviews-test-dune", Rating: 4, Comment: "Better the second time"})
+	if err != nil || again.GetId() != first.GetId() {
+		t.Fatalf("AddReview() again = %v, %v, want review %d replaced", again, err, first.GetId())
+	}
+	if _, err := s.AddReview(bob, &pb.Review{BookId: "reviews-test-dune", Rating: 5}); err != nil {
+		t.Fatalf("AddReview() error = %v", err)
+	}
+
+	resp, err := s.ListReviews(alice, &pb.ListReviewsRequest{BookId: "reviews-test-dune", PageSize: 1})
+	if err != nil {
+		t.Fatalf("ListReviews() error = %v", err)
+	}
+	if resp.GetTotalCount() != 2 || resp.GetAverageRating() != 4.5 || len(resp.GetReviews()) != 1 || resp.GetNextPageToken() == "" {
+		t.Errorf("ListReviews() = %v, want the first of 2 reviews averaging 4.5", resp)
+	}
+	next, err := s.ListReviews(alice, &pb.ListReviewsRequest{BookId: "reviews-test-dune", PageSize: 1, PageToken: resp.GetNextPageToken()})
+	if err != nil || len(next.GetReviews()) != 1 || next.GetReviews()[0].GetId() == resp.GetReviews()[0].GetId() {
+		t.Errorf("ListReviews() of the next page = %v, %v", next, err)
+	}
+
+	// Users can only delete their own reviews
+	if _, err := s.DeleteReview(bob, &pb.ReviewRequest{Id: first.GetId()}); status.Code(err) != codes.NotFound {
+		t.Errorf("DeleteReview() of another user's review error = %v, want NotFound", err)
+	}
+	if _, err := s.DeleteReview(alice, &pb.ReviewRequest{Id: first.GetId()}); err != nil {
+		t.Errorf("DeleteReview() error = %v", err)
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	pb "example/grpc_demo/library"
+
+	"github.com/golang-jwt/jwt/v5"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	// Lifetime of scoped tokens when the request sets none, and the longest allowed
+	defaultScopedTokenTTL = 30 * 24 * time.Hour
+	maxScopedTokenTTL     = 90 * 24 * time.Hour
+	minScopedTokenTTL     = time.Minute
+	maxScopedTokenName    = 100
+)
+
+func (s *server) CreateScopedToken(ctx context.Context, req *pb.CreateScopedTokenRequest) (*pb.ScopedToken, error) {
+	userID, username, ok := userFromContext(ctx)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "authentication required")
+	}
+
+	known := s.runtime().policy.knownScopes()
+	var scopes []string
+	for _, scope := range req.GetScopes() {
+		if !slices.Contains(known, scope) {
+			return nil, status.Errorf(codes.InvalidArgument, "unknown scope %q (available: %s)", scope, strings.Join(known, ", "))
+		}
+		if !slices.Contains(scopes, scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+	if len(scopes) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "at least one scope is required")
+	}
+	slices.Sort(scopes)
+
+	ttl := defaultScopedTokenTTL
+	if req.GetTtl() != nil {
+		ttl = req.GetTtl().AsDuration()
+	}
+	if ttl < minScopedTokenTTL || ttl > maxScopedTokenTTL {
+		return nil, status.Errorf(codes.InvalidArgument, "ttl must be between %s and %s", minScopedTokenTTL, maxScopedTokenTTL)
+	}
+	name := strings.TrimSpace(req.GetName())
+	if len(name) > maxScopedTokenName {
+		return nil, status.Errorf(codes.InvalidArgument, "name must be at most %d characters", maxScopedTokenName)
+	}
+
+	// The session is what ListSessions shows and RevokeSession ends; it is not tied to the caller's device
+	label := fmt.Sprintf("scoped token (%s)", strings.Join(scopes, ", "))
+	if name != "" {
+		label = fmt.Sprintf("scoped token %q (%s)", name, strings.Join(scopes, ", "))
+	}
+	_, ip := sessionDevice(ctx)
+	var sessionID int64
+	err := s.db.QueryRow(ctx, "INSERT INTO sessions (user_id, user_agent, ip_address) VALUES ($1, $2, $3) RETURNING id",
+		userID, label, ip).Scan(&sessionID)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "database error: %v", err)
+	}
+
+	expiresAt := time.Now().Add(ttl)
+	claims := newAccessClaims(userID, username, sessionID)
+	claims.Scopes = scopes
+	claims.TenantID = tenantFromContext(ctx)
+	claims.ExpiresAt = jwt.NewNumericDate(expiresAt)
+	token, err := signAccessToken(claims)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to sign token: %v", err)
+	}
+	return &pb.ScopedToken{
+		Token:     token,
+		Scopes:    scopes,
+		ExpiresAt: timestamppb.New(expiresAt),
+		SessionId: sessionID,
+	}, nil
+}
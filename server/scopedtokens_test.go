@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	pb "example/grpc_demo/library"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+func TestDefaultPolicyScopes(t *testing.T) {
+	p := defaultAuthPolicy()
+	if got := p.knownScopes(); len(got) != 2 || got[0] != scopeBooksRead || got[1] != scopeLoansRead {
+		t.Errorf("knownScopes() = %v, want [%s %s]", got, scopeBooksRead, scopeLoansRead)
+	}
+
+	readOnly := context.WithValue(context.Background(), claimsKey, &Claims{Scopes: []string{scopeBooksRead}})
+	for method, want := range map[string]codes.Code{
+		"/library.LibraryService/ListBooks":      codes.OK,
+		"/library.LibraryService/GetBook":        codes.OK,
+		"/library.TagService/ListTags":           codes.OK,
+		"/library.LibraryService/AddBook":        codes.PermissionDenied,
+		"/library.LoanService/ListMyLoans":       codes.PermissionDenied,
+		"/library.UserService/CreateScopedToken": codes.PermissionDenied,
+	} {
+		if err := p.authorize(readOnly, method); status.Code(err) != want {
+			t.Errorf("books:read token calling %s: error = %v, want %v", method, err, want)
+		}
+	}
+
+	// Unrestricted tokens are unaffected by the scopes on read methods
+	full := context.WithValue(context.Background(), claimsKey, &Claims{})
+	if err := p.authorize(full, "/library.LibraryService/ListBooks"); err != nil {
+		t.Errorf("unrestricted token calling ListBooks: error = %v", err)
+	}
+}
+
+func TestCreateScopedTokenValidation(t *testing.T) {
+	s := &server{settings: newSettings(&runtimeSettings{policy: defaultAuthPolicy()})}
+	ctx := context.WithValue(context.Background(), userIDKey, 1)
+	ctx = context.WithValue(ctx, usernameKey, "testuser")
+
+	tests := []struct {
+		name string
+		req  *pb.CreateScopedTokenRequest
+	}{
+		{"no scopes", &pb.CreateScopedTokenRequest{}},
+		{"unknown scope", &pb.CreateScopedTokenRequest{Scopes: []string{"books:write"}}},
+		{"ttl too long", &pb.CreateScopedTokenRequest{Scopes: []string{scopeBooksRead}, Ttl: durationpb.New(365 * 24 * time.Hour)}},
+		{"ttl too short", &pb.CreateScopedTokenRequest{Scopes: []string{scopeBooksRead}, Ttl: durationpb.New(time.Second)}},
+		{"name too long", &pb.CreateScopedTokenRequest{Scopes: []string{scopeBooksRead}, Name: string(make([]byte, maxScopedTokenName+1))}},
+	}
+	for _, tt := range tests {
+		if _, err := s.CreateScopedToken(ctx, tt.req); status.Code(err) != codes.InvalidArgument {
+			t.Errorf("%s: CreateScopedToken() error = %v, want InvalidArgument", tt.name, err)
+		}
+	}
+
+	if _, err := s.CreateScopedToken(context.Background(), &pb.CreateScopedTokenRequest{Scopes: []string{scopeBooksRead}}); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("anonymous CreateScopedToken() error = %v, want Unauthenticated", err)
+	}
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"context"
+
+	pb "example/grpc_demo/library"
+	"example/grpc_demo/server/storage"
+)
+
+// maxSearchLength bounds search queries, which each word makes costlier
+const maxSearchLength = 200
+
+func (s *server) SearchBooks(ctx context.Context, req *pb.SearchBooksRequest) (*pb.ListBookResponse, error) {
+	if len(req.GetQuery()) > maxSearchLength {
+		return nil, badRequest("query", "Search query is too long")
+	}
+	if len(storage.SearchTerms(req.GetQuery())) == 0 {
+		return nil, badRequest("query", "Search query is required")
+	}
+	pageSize, offset := pageBounds(req.GetPage(), req.GetPageSize())
+	prefs, err := languagePreferences(ctx, req.GetLocale())
+	if err != nil {
+		return nil, err
+	}
+
+	filter := storage.BookFilter{Search: req.GetQuery(), Limit: pageSize, Offset: offset}
+	books, err := s.books.List(ctx, filter, prefs)
+	if err != nil {
+		return nil, err
+	}
+	totalCount, err := s.books.Count(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.ListBookResponse{Books: books, TotalCount: totalCount}, nil
+}
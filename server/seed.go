@@ -0,0 +1,285 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+	"time"
+
+	pb "example/grpc_demo/library"
+	"example/grpc_demo/server/storage"
+)
+
+// seedActor is who the book history records the seeded books as added by
+var seedActor = storage.Actor{Name: "seed"}
+
+// seedOptions are the flags of the seed subcommand
+type seedOptions struct {
+	books, users int
+	// password is shared by every seeded user
+	password string
+	// seed makes the generated data reproducible
+	seed uint64
+	// tenant is the library seeded, "" for the default one
+	tenant string
+}
+
+// runSeed is the seed subcommand: it adds generated books and users to the configured database, migrating
+// it first, and exits
+func runSeed(args []string) {
+	fs := flag.NewFlagSet("seed", flag.ExitOnError)
+	var opts seedOptions
+	fs.IntVar(&opts.books, "books", 1000, "Books to add")
+	fs.IntVar(&opts.users, "users", 20, "Users to add")
+	fs.StringVar(&opts.password, "password", "password123", "Password of every added user")
+	fs.Uint64Var(&opts.seed, "seed", 0, "Seed of the generated data, to generate the same data again (default: random)")
+	fs.StringVar(&opts.tenant, "tenant", "", "Library to seed, when the database hosts several (default: the default library)")
+	bindConfigFlags(fs)
+	fs.Parse(args)
+	if opts.books < 0 || opts.users < 0 {
+		fatal("invalid seed options", "error", "-books and -users must not be negative")
+	}
+	if opts.seed == 0 {
+		opts.seed = rand.Uint64()
+	}
+
+	if err := newDotEnv("../.env").load(); err != nil {
+		fatal("invalid .env file", "error", err)
+	}
+	cfg, err := LoadConfig(fs)
+	if err != nil {
+		fatal("invalid configuration", "error", err)
+	}
+	passwords := newPasswordHashing(cfg.Passwords, cfg.Auth.BcryptCost)
+	dbpool, err := NewDBPool(cfg.Database)
+	if err != nil {
+		fatal("failed to connect to database", "error", err)
+	}
+	defer dbpool.Close()
+	if err := RunMigrations(dbpool); err != nil {
+		fatal("failed to run migrations", "error", err)
+	}
+
+	ctx := withTenant(context.Background(), opts.tenant)
+	if opts.tenant != "" {
+		exists, err := newTenantRegistry(dbpool).exists(ctx, opts.tenant)
+		if err != nil {
+			fatal("failed to look up the library", "error", err)
+		}
+		if !exists {
+			fatal("invalid seed options", "error", fmt.Sprintf("library %q not found", opts.tenant))
+		}
+	}
+
+	var store storage.Store = storage.NewPostgres(dbpool)
+	if cfg.Storage.Backend == storageMongoDB {
+		catalog, disconnect, err := openMongoStore(cfg.Storage.MongoDB)
+		if err != nil {
+			fatal("failed to connect to MongoDB", "error", err)
+		}
+		defer disconnect()
+		store = storage.Split(catalog, store)
+	}
+
+	slog.Info("seeding database", "books", opts.books, "users", opts.users, "seed", opts.seed, "tenant", opts.tenant)
+	start := time.Now()
+	res, err := seedStore(ctx, store, passwords, opts)
+	if err != nil {
+		fatal("seeding failed", "error", err)
+	}
+	slog.Info("database seeded", "books", res.books, "users", res.users, "users_skipped", res.usersTaken, "duration", time.Since(start))
+}
+
+// seedResult counts what seedStore added
+type seedResult struct {
+	books, users int
+	// usersTaken counts the generated usernames that were already registered
+	usersTaken int
+}
+
+// seedStore adds the books and users of opts to store. Books are added in batches as BatchAddBooks adds them;
+// usernames that are taken, e.g. by an earlier run with the same seed, are skipped.
+func seedStore(ctx context.Context, store storage.Store, passwords *passwordHashing, opts seedOptions) (seedResult, error) {
+	var res seedResult
+	ctx = storage.WithActor(ctx, seedActor)
+	gen := newSeedGenerator(opts.seed)
+
+	for added := 0; added < opts.books; {
+		books := make([]*pb.Book, min(batchSize, opts.books-added))
+		for i := range books {
+			books[i] = gen.book()
+		}
+		results, err := store.Books().CreateMany(ctx, books)
+		if err != nil {
+			return res, err
+		}
+		for i, err := range results {
+			if err != nil {
+				return res, fmt.Errorf("book %s: %w", books[i].GetId(), err)
+			}
+		}
+		added += len(books)
+		res.books = added
+	}
+
+	if opts.users == 0 {
+		return res, nil
+	}
+	// One hash serves every user: each is salted, and hashing is slow on purpose
+	hash, err := passwords.Hash(opts.password)
+	if err != nil {
+		return res, err
+	}
+	for range opts.users {
+		_, err := store.Users().Create(ctx, storage.NewUser{Username: gen.username(), PasswordHash: hash, State: accountActive})
+		switch {
+		case errors.Is(err, storage.ErrAlreadyExists):
+			res.usersTaken++
+		case err != nil:
+			return res, err
+		default:
+			res.users++
+		}
+	}
+	return res, nil
+}
+
+// seedGenerator makes up books and users from word lists, the same ones for the same seed. Books and users
+// are drawn from streams of their own, so the users of a seed don't depend on how many books it made.
+type seedGenerator struct {
+	rand, userRand *rand.Rand
+	// usernames counts the users generated, to number their usernames
+	usernames int
+}
+
+func newSeedGenerator(seed uint64) *seedGenerator {
+	return &seedGenerator{rand: rand.New(rand.NewPCG(seed, 1)), userRand: rand.New(rand.NewPCG(seed, 2))}
+}
+
+// pick returns a random element of words, drawn from the stream of books
+func (g *seedGenerator) pick(words []string) string {
+	return words[g.rand.IntN(len(words))]
+}
+
+// seedGenre is a section of the library and the words its titles and descriptions are made of
+type seedGenre struct {
+	section string
+	// kind is what its books are called in descriptions
+	kind     string
+	nouns    []string
+	subjects []string
+}
+
+var (
+	seedFirstNames = []string{"Ada", "Alice", "Amara", "Ana", "Arthur", "Beatriz", "Carlos", "Chen", "Clara", "Daniel",
+		"Elena", "Emil", "Fatima", "Felix", "Grace", "Hana", "Hugo", "Ines", "Ivan", "James", "Joana", "Kenji", "Lara",
+		"Leo", "Lucia", "Malik", "Maria", "Mateus", "Nadia", "Noah", "Olga", "Omar", "Paulo", "Priya", "Rafael", "Rosa",
+		"Samuel", "Sofia", "Tomas", "Yara"}
+	seedLastNames = []string{"Almeida", "Andersen", "Barros", "Bennett", "Carvalho", "Costa", "Dubois", "Eriksson",
+		"Ferreira", "Fischer", "Garcia", "Haddad", "Ivanova", "Kowalski", "Lima", "Lopes", "Martins", "Moreau", "Nakamura",
+		"Novak", "Okafor", "Oliveira", "Park", "Pereira", "Quinn", "Ribeiro", "Rossi", "Santos", "Silva", "Sousa",
+		"Tanaka", "Vieira", "Walsh", "Yilmaz", "Zhang"}
+	seedPublishers = []string{"Blue Heron Press", "Cinder & Ash", "Harbor Light Books", "Lantern House", "Meridian",
+		"Northwind Publishing", "Old Mill Editions", "Paper Kite", "Quarry Books", "Red Fern", "Silver Birch",
+		"Two Rivers Press"}
+	seedBranches   = []string{"Main", "Riverside", "Hillcrest"}
+	seedAdjectives = []string{"Silent", "Broken", "Hidden", "Last", "Golden", "Forgotten", "Distant", "Crimson",
+		"Endless", "Burning", "Quiet", "Winter", "Hollow", "Wandering", "Secret", "Lost"}
+	seedPlaces = []string{"the North", "the Valley", "Alexandria", "the Harbor", "the Old City", "the Islands",
+		"the Mountain", "Lisbon", "the Desert", "the River"}
+	seedGenres = []seedGenre{
+		{"Science Fiction", "science fiction novel", []string{"Star", "Signal", "Colony", "Machine", "Orbit", "Nebula", "Frontier", "Android"},
+			[]string{"the crew of a failing generation ship", "the first contact with a patient alien mind",
+				"an engineer who wakes up on a colony that forgot Earth", "an AI negotiating its own freedom"}},
+		{"Fantasy", "fantasy novel", []string{"Crown", "Dragon", "Sword", "Throne", "Witch", "Oath", "Kingdom", "Shadow"},
+			[]string{"an exiled heir gathering unlikely allies", "a thief bound by an ancient oath",
+				"two rival mages forced to share a tower", "a cartographer mapping a land that keeps changing"}},
+		{"Mystery", "mystery", []string{"Murder", "Alibi", "Witness", "Clue", "Case", "Stranger", "Letter", "Verdict"},
+			[]string{"a retired detective pulled back by an unsigned letter", "a death at a snowed-in country house",
+				"a journalist who finds her own name in a cold case", "a locked-room puzzle on a night train"}},
+		{"Romance", "romance", []string{"Heart", "Summer", "Promise", "Kiss", "Garden", "Wedding", "Letter", "Bookshop"},
+			[]string{"two rival bakers on the same street", "old friends meeting again at a wedding",
+				"a bookseller and the stranger who never buys anything", "a summer that changes two families"}},
+		{"History", "work of history", []string{"Empire", "Revolution", "War", "Republic", "Silk Road", "Dynasty", "Voyage", "Plague"},
+			[]string{"the trade routes that shaped the ancient world", "the people who lived through a forgotten war",
+				"the rise and fall of a merchant empire", "everyday life in a medieval port city"}},
+		{"Children", "picture book", []string{"Bear", "Fox", "Moon", "Garden", "Adventure", "Friend", "Balloon", "Treehouse"},
+			[]string{"a small fox looking for the way home", "friends who build a treehouse together",
+				"a bear who cannot fall asleep", "a balloon trip around the world"}},
+	}
+	seedDescriptionEndings = []string{"A story about courage and second chances.", "Told with warmth and wit.",
+		"The first book of an acclaimed author.", "A bestseller translated into many languages.",
+		"Now with a new introduction.", "Winner of a regional book prize.", ""}
+)
+
+// title makes up a title from the words of genre
+func (g *seedGenerator) title(genre seedGenre) string {
+	noun := g.pick(genre.nouns)
+	switch g.rand.IntN(5) {
+	case 0:
+		return "The " + g.pick(seedAdjectives) + " " + noun
+	case 1:
+		return "The " + noun + " of " + g.pick(seedPlaces)
+	case 2:
+		return g.pick(seedAdjectives) + " " + noun
+	case 3:
+		return "A " + noun + " in " + g.pick(seedPlaces)
+	default:
+		return "The " + noun + " and the " + g.pick(genre.nouns)
+	}
+}
+
+// isbn makes up an ISBN-13 with a valid check digit
+func (g *seedGenerator) isbn() string {
+	digits := "978" + fmt.Sprintf("%09d", g.rand.IntN(1_000_000_000))
+	sum := 0
+	for i, c := range digits {
+		d := int(c - '0')
+		if i%2 == 1 {
+			d *= 3
+		}
+		sum += d
+	}
+	return digits + strconv.Itoa((10-sum%10)%10)
+}
+
+// book makes up a book, its copies, where it is shelved, and sometimes the series it belongs to
+func (g *seedGenerator) book() *pb.Book {
+	genre := seedGenres[g.rand.IntN(len(seedGenres))]
+	title := g.title(genre)
+	copies := int32(1 + g.rand.IntN(3))
+	if g.rand.IntN(10) == 0 {
+		copies += int32(g.rand.IntN(5))
+	}
+	book := &pb.Book{
+		Id:              newBookID(),
+		Title:           title,
+		Author:          g.pick(seedFirstNames) + " " + g.pick(seedLastNames),
+		Isbn:            g.isbn(),
+		Publisher:       g.pick(seedPublishers),
+		Description:     strings.TrimSpace(fmt.Sprintf("A %s about %s. %s", genre.kind, g.pick(genre.subjects), g.pick(seedDescriptionEndings))),
+		TotalCopies:     copies,
+		AvailableCopies: copies,
+		Location: &pb.Location{
+			Branch:  g.pick(seedBranches),
+			Section: genre.section,
+			Shelf:   fmt.Sprintf("%c%d", 'A'+rune(g.rand.IntN(8)), 1+g.rand.IntN(12)),
+		},
+	}
+	if g.rand.IntN(8) == 0 {
+		book.Series = &pb.BookSeries{Name: title + " Saga", Volume: int32(1 + g.rand.IntN(5))}
+	}
+	return book
+}
+
+// username makes up a username such as ana.silva7, numbered so the usernames of a run don't collide
+func (g *seedGenerator) username() string {
+	g.usernames++
+	first, last := seedFirstNames[g.userRand.IntN(len(seedFirstNames))], seedLastNames[g.userRand.IntN(len(seedLastNames))]
+	return strings.ToLower(first+"."+last) + strconv.Itoa(g.usernames)
+}
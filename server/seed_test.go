@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"example/grpc_demo/server/storage"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestSeedGenerator(t *testing.T) {
+	a, b := newSeedGenerator(42), newSeedGenerator(42)
+	for range 200 {
+		book := a.book()
+		if err := validateBook(book); err != nil || book.GetIsbn() == "" || book.GetDescription() == "" {
+			t.Fatalf("generated book %v: %v", book, err)
+		}
+		if other := b.book(); other.GetTitle() != book.GetTitle() || other.GetIsbn() != book.GetIsbn() {
+			t.Fatalf("the same seed generated %q (%s) and %q (%s)", book.GetTitle(), book.GetIsbn(), other.GetTitle(), other.GetIsbn())
+		}
+	}
+	if a.username() != b.username() {
+		t.Error("the same seed generated different usernames")
+	}
+}
+
+func TestSeedStore(t *testing.T) {
+	store := storage.NewMemory()
+	bc := bcryptHasher{cost: bcrypt.MinCost}
+	passwords := &passwordHashing{current: bc, hashers: []PasswordHasher{bc}}
+	opts := seedOptions{books: batchSize + 20, users: 5, password: "secret", seed: 7}
+
+	res, err := seedStore(context.Background(), store, passwords, opts)
+	if err != nil {
+		t.Fatalf("seedStore() error = %v", err)
+	}
+	if res.books != opts.books || res.users != opts.users {
+		t.Errorf("seedStore() = %+v, want %d books and %d users", res, opts.books, opts.users)
+	}
+	if n, _ := store.Books().Count(context.Background(), storage.BookFilter{}); n != int32(opts.books) {
+		t.Errorf("%d books stored, want %d", n, opts.books)
+	}
+	user, err := store.Users().GetByUsername(context.Background(), newSeedGenerator(opts.seed).username())
+	if err != nil || user.State != accountActive {
+		t.Fatalf("first seeded user = %+v, %v", user, err)
+	}
+	if ok, _, _ := passwords.Verify(user.PasswordHash, "secret"); !ok {
+		t.Error("seeded user does not have the given password")
+	}
+
+	// The usernames of a seed are taken after its first run
+	res, err = seedStore(context.Background(), store, passwords, seedOptions{users: 5, password: "secret", seed: 7})
+	if err != nil || res.users != 0 || res.usersTaken != 5 {
+		t.Errorf("seedStore() again = %+v, %v; want the 5 users skipped", res, err)
+	}
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// selfCheckTimeout bounds each startup check
+const selfCheckTimeout = 10 * time.Second
+
+var (
+	createTablePattern = regexp.MustCompile(`(?i)CREATE TABLE IF NOT EXISTS (\w+)`)
+	addColumnPattern   = regexp.MustCompile(`(?i)ALTER TABLE (\w+) ADD COLUMN IF NOT EXISTS (\w+)`)
+)
+
+// schemaColumn is a column added to an existing table by a migration
+type schemaColumn struct {
+	table, column string
+}
+
+// expectedSchema lists the tables and the added columns that migrations creates, along with the appTables
+func expectedSchema(migrations string) (tables []string, columns []schemaColumn) {
+	tables = slices.Clone(appTables)
+	for _, m := range createTablePattern.FindAllStringSubmatch(migrations, -1) {
+		if table := strings.ToLower(m[1]); !slices.Contains(tables, table) {
+			tables = append(tables, table)
+		}
+	}
+	for _, m := range addColumnPattern.FindAllStringSubmatch(migrations, -1) {
+		columns = append(columns, schemaColumn{table: strings.ToLower(m[1]), column: strings.ToLower(m[2])})
+	}
+	return tables, columns
+}
+
+// missingSchema lists the tables and columns that are not among the existing columns of each table
+func missingSchema(tables []string, columns []schemaColumn, existing map[string]map[string]bool) []string {
+	var missing []string
+	for _, table := range tables {
+		if existing[table] == nil {
+			missing = append(missing, "table "+table)
+		}
+	}
+	for _, c := range columns {
+		if existing[c.table] != nil && !existing[c.table][c.column] {
+			missing = append(missing, "column "+c.table+"."+c.column)
+		}
+	}
+	return missing
+}
+
+// checkSchema verifies that every table and column of migrations exists in the database
+func checkSchema(ctx context.Context, db querier, migrations string) error {
+	rows, err := db.Query(ctx,
+		"SELECT table_name, column_name FROM information_schema.columns WHERE table_schema = current_schema()")
+	if err != nil {
+		return fmt.Errorf("failed to read the database schema: %w", err)
+	}
+	defer rows.Close()
+	existing := make(map[string]map[string]bool)
+	for rows.Next() {
+		var table, column string
+		if err := rows.Scan(&table, &column); err != nil {
+			return fmt.Errorf("failed to read the database schema: %w", err)
+		}
+		if existing[table] == nil {
+			existing[table] = make(map[string]bool)
+		}
+		existing[table][column] = true
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read the database schema: %w", err)
+	}
+
+	tables, columns := expectedSchema(migrations)
+	if missing := missingSchema(tables, columns, existing); len(missing) > 0 {
+		return fmt.Errorf("the database is missing %s: %s has not been fully applied; check that DB_NAME is the "+
+			"right database and that DB_USER may create tables in its search_path schema", strings.Join(missing, ", "), migrationsFile)
+	}
+	return nil
+}
+
+// checkMigrations verifies that the schema of migrationsFile is in place in the database of pool
+func checkMigrations(pool *pgxpool.Pool) error {
+	data, err := os.ReadFile(migrationsFile)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), selfCheckTimeout)
+	defer cancel()
+	return checkSchema(ctx, pool, string(data))
+}
+
+// checkJWTSecret refuses the development JWT secret, with which anyone can mint valid tokens, unless
+// tokens are signed with JWT_PRIVATE_KEY_FILE or ALLOW_INSECURE_JWT_SECRET=true says this is a development setup
+func checkJWTSecret(keys *jwtKeySet, cfg AuthConfig) error {
+	if keys != nil || cfg.JWTSecret != defaultJWTSecret || cfg.AllowInsecureJWTSecret {
+		return nil
+	}
+	return errors.New("JWT_SECRET is the development default, so anyone could sign valid tokens: set JWT_SECRET to a " +
+		"long random value (e.g. from `openssl rand -base64 32`) or JWT_PRIVATE_KEY_FILE, or set " +
+		"ALLOW_INSECURE_JWT_SECRET=true for local development")
+}
+
+// checkUpstream verifies that the gateway can reach the gRPC server at target with creds, by calling its
+// health service
+func checkUpstream(ctx context.Context, target string, creds credentials.TransportCredentials) error {
+	conn, err := grpc.NewClient(target, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return fmt.Errorf("invalid GATEWAY_UPSTREAM %q: %w", target, err)
+	}
+	defer conn.Close()
+	ctx, cancel := context.WithTimeout(ctx, selfCheckTimeout)
+	defer cancel()
+	if _, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{}, grpc.WaitForReady(true)); err != nil {
+		return fmt.Errorf("the gateway cannot reach the gRPC server at GATEWAY_UPSTREAM %q: %w; check that it "+
+			"points at GRPC_ADDR and, with TLS, that the server certificate is valid for that host", target, err)
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"net"
+	"os"
+	"slices"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func TestExpectedSchema(t *testing.T) {
+	data, err := os.ReadFile(migrationsFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tables, columns := expectedSchema(string(data))
+	for _, table := range append(slices.Clone(appTables), "users", "books") {
+		if !slices.Contains(tables, table) {
+			t.Errorf("expected tables %v do not include %s", tables, table)
+		}
+	}
+	if !slices.Contains(columns, schemaColumn{table: "users", column: "role"}) {
+		t.Errorf("expected columns %v do not include users.role", columns)
+	}
+}
+
+func TestMissingSchema(t *testing.T) {
+	existing := map[string]map[string]bool{
+		"users": {"id": true, "username": true, "role": true},
+		"books": {"id": true},
+	}
+	tables := []string{"users", "books", "audit_log"}
+	columns := []schemaColumn{{"users", "role"}, {"books", "isbn"}, {"audit_log", "method"}}
+	// A missing table is reported once, not with each of its columns
+	if got, want := missingSchema(tables, columns, existing), []string{"table audit_log", "column books.isbn"}; !slices.Equal(got, want) {
+		t.Errorf("missingSchema() = %v, want %v", got, want)
+	}
+	existing["audit_log"] = map[string]bool{"method": true}
+	existing["books"]["isbn"] = true
+	if got := missingSchema(tables, columns, existing); len(got) != 0 {
+		t.Errorf("missingSchema() = %v, want nothing", got)
+	}
+}
+
+func TestCheckJWTSecret(t *testing.T) {
+	if err := checkJWTSecret(nil, AuthConfig{JWTSecret: defaultJWTSecret}); err == nil {
+		t.Error("the development secret was accepted")
+	}
+	if err := checkJWTSecret(nil, AuthConfig{JWTSecret: "a-real-secret"}); err != nil {
+		t.Errorf("checkJWTSecret() error = %v", err)
+	}
+	// The secret is unused when tokens are signed with a private key
+	if err := checkJWTSecret(&jwtKeySet{}, AuthConfig{JWTSecret: defaultJWTSecret}); err != nil {
+		t.Errorf("with a signing key: error = %v", err)
+	}
+	if err := checkJWTSecret(nil, AuthConfig{JWTSecret: defaultJWTSecret, AllowInsecureJWTSecret: true}); err != nil {
+		t.Errorf("with ALLOW_INSECURE_JWT_SECRET: error = %v", err)
+	}
+}
+
+func TestCheckUpstream(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := grpc.NewServer()
+	healthServer := health.NewServer()
+	// The check only needs the server to answer, not to be serving yet
+	healthServer.SetServingStatus("", healthpb.HealthCheckResponse_NOT_SERVING)
+	healthpb.RegisterHealthServer(s, healthServer)
+	go s.Serve(lis)
+	defer s.Stop()
+
+	if err := checkUpstream(context.Background(), lis.Addr().String(), insecure.NewCredentials()); err != nil {
+		t.Errorf("checkUpstream() error = %v", err)
+	}
+
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	closed.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	if err := checkUpstream(ctx, closed.Addr().String(), insecure.NewCredentials()); err == nil {
+		t.Error("checkUpstream() succeeded without a server")
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"strings"
+
+	pb "example/grpc_demo/library"
+	"example/grpc_demo/server/storage"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// maxSeriesNameLength bounds series names
+const maxSeriesNameLength = 200
+
+// normalizeSeries trims the series name; a series without a name means "no series"
+func normalizeSeries(series *pb.BookSeries) *pb.BookSeries {
+	name := strings.TrimSpace(series.GetName())
+	if name == "" {
+		return nil
+	}
+	return &pb.BookSeries{Name: name, Volume: series.GetVolume()}
+}
+
+// validateSeries returns the message for an invalid series, or "" if it is acceptable
+func validateSeries(series *pb.BookSeries) string {
+	if len(strings.TrimSpace(series.GetName())) > maxSeriesNameLength {
+		return "Series name is too long"
+	}
+	if series.GetVolume() < 0 {
+		return "Series volume cannot be negative"
+	}
+	return ""
+}
+
+func (s *server) ListBooksInSeries(ctx context.Context, req *pb.SeriesRequest) (*pb.ListBookResponse, error) {
+	name := strings.TrimSpace(req.GetName())
+	if name == "" {
+		return nil, status.Error(codes.InvalidArgument, "Series name is required")
+	}
+
+	var exists bool
+	if err := s.db.QueryRow(ctx, "SELECT EXISTS(SELECT 1 FROM series WHERE name=$1)", name).Scan(&exists); err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, notFound(resourceSeries, name, "Series not found")
+	}
+
+	// Unnumbered books follow the numbered volumes
+	rows, err := s.db.Query(ctx,
+		"SELECT "+storage.QualifiedBookColumns("b")+" FROM books b JOIN series s ON s.id = b.series_id WHERE s.name=$1 ORDER BY b.series_volume NULLS LAST, b.title, b.id",
+		name)
+	if err != nil {
+		return nil, err
+	}
+	books, err := storage.CollectBooks(rows)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.enrichBooks(ctx, books); err != nil {
+		return nil, err
+	}
+	return &pb.ListBookResponse{Books: books, TotalCount: int32(len(books))}, nil
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	pb "example/grpc_demo/library"
+
+	"google.golang.org/protobuf/proto"
+)
+
+func TestNormalizeSeries(t *testing.T) {
+	tests := []struct {
+		name string
+		in   *pb.BookSeries
+		want *pb.BookSeries
+	}{
+		{"nil", nil, nil},
+		{"blank name", &pb.BookSeries{Name: "  ", Volume: 2}, nil},
+		{"trimmed", &pb.BookSeries{Name: " The Expanse ", Volume: 2}, &pb.BookSeries{Name: "The Expanse", Volume: 2}},
+		{"unnumbered", &pb.BookSeries{Name: "Discworld"}, &pb.BookSeries{Name: "Discworld"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := normalizeSeries(tt.in); !proto.Equal(got, tt.want) {
+				t.Errorf("normalizeSeries() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateSeries(t *testing.T) {
+	tests := []struct {
+		name string
+		in   *pb.BookSeries
+		want string
+	}{
+		{"no series", nil, ""},
+		{"valid", &pb.BookSeries{Name: "The Expanse", Volume: 1}, ""},
+		{"negative volume", &pb.BookSeries{Name: "The Expanse", Volume: -1}, "Series volume cannot be negative"},
+		{"long name", &pb.BookSeries{Name: strings.Repeat("x", maxSeriesNameLength+1)}, "Series name is too long"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := validateSeries(tt.in); got != tt.want {
+				t.Errorf("validateSeries() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
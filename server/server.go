@@ -2,25 +2,69 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
-	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"net"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
 
 	pb "example/grpc_demo/library"
+	"example/grpc_demo/server/storage"
 
+	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgxpool"
-	"golang.org/x/crypto/bcrypt"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 )
 
 type server struct {
 	pb.UnimplementedUserServiceServer
 	pb.UnimplementedLibraryServiceServer
-	db *pgxpool.Pool
+	pb.UnimplementedTagServiceServer
+	pb.UnimplementedReviewServiceServer
+	pb.UnimplementedFavoriteServiceServer
+	pb.UnimplementedShelfServiceServer
+	pb.UnimplementedLoanServiceServer
+	pb.UnimplementedNotificationServiceServer
+	pb.UnimplementedPublisherServiceServer
+	pb.UnimplementedServerInfoServiceServer
+	pb.UnimplementedTenantServiceServer
+	db            *pgxpool.Pool
+	bookMetadata  BookMetadataProvider
+	fines         finePolicy
+	notifications *notificationHub
+	mailer        accountMailer
+	oidc          *oidcVerifier
+	denylist      tokenDenylist
+	passwords     *passwordHashing
+	// settings are the settings changed by reloading the configuration
+	settings *atomic.Pointer[runtimeSettings]
+	// challenge, when set, must accept a bot challenge token before Register creates an account
+	challenge challengeVerifier
+	// passwordHistory is how many recent passwords of a user cannot be chosen again
+	passwordHistory int
+	// info is what GetServerInfo answers
+	info *pb.ServerInfo
+	// books and users keep the catalog and the accounts, and tx runs the units of work spanning them
+	books storage.BookRepository
+	users storage.UserRepository
+	tx    storage.Transactor
+	// tenants, when multiple libraries are enabled, knows the libraries hosted besides the default one
+	tenants *tenantRegistry
+	// cache, when set, holds GetBook and ListBooks responses; the loans and the circulation sweep empty it
+	// when the copies available change
+	cache *responseCache
 }
 
 func (s *server) Register(ctx context.Context, user *pb.User) (*pb.AuthResponse, error) {
@@ -29,41 +73,88 @@ func (s *server) Register(ctx context.Context, user *pb.User) (*pb.AuthResponse,
 
 	// Validate input
 	if username == "" || password == "" {
-		return &pb.AuthResponse{Message: "Username and password are required"}, nil
+		return nil, authError(codes.InvalidArgument, reasonMissingCredentials, "Username and password are required")
+	}
+	var email string
+	if user.GetEmail() != "" {
+		var err error
+		if email, err = normalizeEmail(user.GetEmail()); err != nil {
+			return nil, authError(codes.InvalidArgument, reasonInvalidEmail, "Invalid email address")
+		}
+	} else if s.runtime().requireEmail {
+		return nil, authError(codes.InvalidArgument, reasonEmailRequired, "Email address is required")
+	}
+	if err := s.verifyChallenge(ctx, user.GetChallengeToken()); err != nil {
+		return nil, err
 	}
 
 	// Check if user exists
-	var exists bool
-	err := s.db.QueryRow(ctx, "SELECT EXISTS(SELECT 1 FROM users WHERE username=$1)", username).Scan(&exists)
+	exists, err := s.users.UsernameExists(ctx, username)
 	if err != nil {
-		return &pb.AuthResponse{Message: "Database error"}, err
+		return nil, status.Errorf(codes.Internal, "database error: %v", err)
 	}
 	if exists {
-		return &pb.AuthResponse{Message: "Username already exists"}, nil
+		return nil, authError(codes.AlreadyExists, reasonUsernameTaken, "Username already exists")
+	}
+	if email != "" {
+		exists, err := s.users.EmailExists(ctx, email)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "database error: %v", err)
+		}
+		if exists {
+			return nil, authError(codes.AlreadyExists, reasonEmailTaken, "Email address already registered")
+		}
 	}
 
-	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	hash, err := s.passwords.Hash(password)
 	if err != nil {
-		return &pb.AuthResponse{Message: "Failed to hash password"}, err
+		return nil, status.Errorf(codes.Internal, "failed to hash password: %v", err)
 	}
 
-	// Insert user and get the generated ID
+	state := s.registrationState()
 	var userID int
-	err = s.db.QueryRow(ctx, "INSERT INTO users (username, password_hash) VALUES ($1, $2) RETURNING id", username, string(hash)).Scan(&userID)
-	if err != nil {
-		return &pb.AuthResponse{Message: "Failed to create user"}, err
-	}
+	var verifyToken string
+	var verifyExpiresAt time.Time
+	var resp *pb.AuthResponse
+	err = s.tx.InTx(ctx, func(ctx context.Context) error {
+		var err error
+		userID, err = s.users.Create(ctx, storage.NewUser{Username: username, PasswordHash: hash, Email: email, State: state})
+		if errors.Is(err, storage.ErrAlreadyExists) {
+			return authError(codes.AlreadyExists, reasonUsernameTaken, "Username or email address already exists")
+		}
+		if err != nil {
+			return status.Errorf(codes.Internal, "failed to create user: %v", err)
+		}
+
+		if email != "" {
+			verifyToken, verifyExpiresAt, err = issueEmailVerification(ctx, s.conn(ctx), userID, email)
+			if err != nil {
+				return status.Errorf(codes.Internal, "failed to create user: %v", err)
+			}
+		}
 
-	// Generate JWT token
-	token, err := GenerateJWT(userID, username)
+		// A pending account cannot log in until its address is verified, so no tokens are issued
+		resp = &pb.AuthResponse{Message: localize(ctx, "User registered; verify your email address to log in")}
+		if state == accountActive {
+			resp, err = issueTokens(ctx, s.conn(ctx), userID, username, 0, "User registered successfully")
+			if err != nil {
+				return status.Errorf(codes.Internal, "failed to issue tokens: %v", err)
+			}
+		}
+		return nil
+	})
 	if err != nil {
-		return &pb.AuthResponse{Message: "Failed to generate token"}, err
+		return nil, txStatus(err, "failed to create user")
 	}
 
-	return &pb.AuthResponse{
-		Message: "User registered successfully",
-		Token:   token,
-	}, nil
+	s.recordAuthEvent(ctx, pb.AuthEventType_AUTH_EVENT_TYPE_REGISTER, userID, username, "")
+
+	if verifyToken != "" {
+		if err := s.mailer.SendEmailVerification(ctx, mailRecipient{username: username, email: email}, verifyToken, verifyExpiresAt); err != nil {
+			slog.ErrorContext(ctx, "failed to send email verification", "username", username, "error", err)
+		}
+	}
+	return resp, nil
 }
 
 func (s *server) Login(ctx context.Context, creds *pb.UserCredentials) (*pb.AuthResponse, error) {
@@ -72,188 +163,889 @@ func (s *server) Login(ctx context.Context, creds *pb.UserCredentials) (*pb.Auth
 
 	// Validate input
 	if username == "" || password == "" {
-		return &pb.AuthResponse{Message: "Username and password are required"}, nil
+		return nil, authError(codes.InvalidArgument, reasonMissingCredentials, "Username and password are required")
 	}
 
-	var userID int
-	var hash string
-	err := s.db.QueryRow(ctx, "SELECT id, password_hash FROM users WHERE username=$1", username).Scan(&userID, &hash)
+	user, err := s.users.GetByUsername(ctx, username)
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
+		return nil, status.Errorf(codes.Internal, "database error: %v", err)
+	}
 	if err != nil {
-		return &pb.AuthResponse{Message: "Invalid username or password"}, nil
+		// Checking the password against no hash takes as long as a wrong password, so the time of the
+		// response doesn't tell which usernames exist
+		s.passwords.Verify("", password)
+		s.recordAuthEvent(ctx, pb.AuthEventType_AUTH_EVENT_TYPE_LOGIN_FAILED, 0, username, "unknown user")
+		return nil, authError(codes.Unauthenticated, reasonInvalidCredentials, "Invalid username or password")
 	}
+	userID := user.ID
 
-	err = bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+	match, rehash, err := s.passwords.Verify(user.PasswordHash, password)
 	if err != nil {
-		return &pb.AuthResponse{Message: "Invalid username or password"}, nil
+		slog.ErrorContext(ctx, "unreadable password hash", "username", username, "error", err)
+	}
+	if !match {
+		s.recordAuthEvent(ctx, pb.AuthEventType_AUTH_EVENT_TYPE_LOGIN_FAILED, userID, username, "wrong password")
+		return nil, authError(codes.Unauthenticated, reasonInvalidCredentials, "Invalid username or password")
+	}
+	// Accounts created before emails were required have no address and may still log in; pending accounts
+	// stay locked until verified even if the requirement has been lifted since
+	if user.State == accountPending || (s.runtime().requireEmail && user.EmailUnverified) {
+		s.recordAuthEvent(ctx, pb.AuthEventType_AUTH_EVENT_TYPE_LOGIN_FAILED, userID, username, "email not verified")
+		return nil, authError(codes.FailedPrecondition, reasonEmailNotVerified, "Email address not verified")
+	}
+	if err := checkAccountState(user.State); err != nil {
+		return nil, status.Errorf(codes.PermissionDenied, "account cannot log in: %v", err)
+	}
+	if rehash {
+		s.upgradePasswordHash(ctx, userID, user.PasswordHash, password)
 	}
 
-	// Generate JWT token
-	token, err := GenerateJWT(userID, username)
+	resp, err := issueTokens(ctx, s.db, userID, username, 0, "Login successful")
 	if err != nil {
-		return &pb.AuthResponse{Message: "Failed to generate token"}, err
+		return nil, status.Errorf(codes.Internal, "failed to issue tokens: %v", err)
 	}
+	s.recordLogin(ctx, userID)
+	s.recordAuthEvent(ctx, pb.AuthEventType_AUTH_EVENT_TYPE_LOGIN, userID, username, "")
+	return resp, nil
+}
 
-	return &pb.AuthResponse{
-		Message: "Login successful",
-		Token:   token,
-	}, nil
+// txStatus returns the status to report for err, the failure of a unit of work: the status returned by
+// the work itself, or Internal with msg when the transaction couldn't be started or committed
+func txStatus(err error, msg string) error {
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	return status.Errorf(codes.Internal, "%s: %v", msg, err)
 }
 
 func (s *server) AddBook(ctx context.Context, book *pb.Book) (*pb.BookResponse, error) {
+	if err := s.addBook(ctx, book); err != nil {
+		return nil, err
+	}
+	return &pb.BookResponse{Id: book.GetId()}, nil
+}
+
+// validateBook checks the fields of a book about to be stored
+func validateBook(book *pb.Book) error {
+	if book.GetId() == "" {
+		return badRequest("id", "Book ID is required")
+	}
+	if !validISBN(book.GetIsbn()) {
+		return badRequest("isbn", "Invalid ISBN")
+	}
+	if book.GetTotalCopies() < 0 {
+		return badRequest("total_copies", "Total copies cannot be negative")
+	}
+	if msg := validateSeries(book.GetSeries()); msg != "" {
+		return badRequest("series", msg)
+	}
+	if msg := validateLocation(book.GetLocation()); msg != "" {
+		return badRequest("location", msg)
+	}
+	if msg := validatePublisher(book); msg != "" {
+		return badRequest("publisher", msg)
+	}
+	return nil
+}
+
+// normalizeBook puts the fields of a validated book in the form they are stored in
+func normalizeBook(book *pb.Book) {
+	book.Isbn = normalizeISBN(book.GetIsbn())
+	book.Location = normalizeLocation(book.GetLocation())
+	book.Series = normalizeSeries(book.GetSeries())
+}
+
+// newBookID identifies a book added without an ID. UUIDv7s start with their creation time, so the books
+// added together stay close in the index of the primary key.
+func newBookID() string {
+	return uuid.Must(uuid.NewV7()).String()
+}
+
+// addBook validates and stores a new book, returning the status to report when it can't be added. A book
+// without an ID is given a new one.
+func (s *server) addBook(ctx context.Context, book *pb.Book) error {
 	if book.GetId() == "" {
-		return &pb.BookResponse{Id: "", Message: "Book ID is required"}, nil
+		book.Id = newBookID()
+	}
+	if err := validateBook(book); err != nil {
+		return err
+	}
+	if !book.GetAllowDuplicate() {
+		dupID, reason, err := s.runtime().duplicates.findDuplicate(ctx, s.books, book)
+		if err != nil {
+			return status.Errorf(codes.Internal, "database error: %v", err)
+		}
+		// Most often the book itself, sent again
+		if dupID == book.GetId() {
+			return alreadyExists(resourceBook, book.GetId(), "Book already exists")
+		}
+		if dupID != "" {
+			return duplicateError(dupID, reason)
+		}
+	}
+	// The insert itself finds a taken ID, so that concurrent calls can't both add the book
+	err := s.insertBook(ctx, book)
+	if errors.Is(err, storage.ErrAlreadyExists) {
+		return alreadyExists(resourceBook, book.GetId(), "Book already exists")
+	}
+	if errors.Is(err, storage.ErrPublisherNotFound) {
+		return notFound(resourcePublisher, strconv.Itoa(int(book.GetPublisherId())), "Publisher not found")
+	}
+	if st, ok := quotaStatus(err); ok {
+		return st
 	}
-	// Check if book exists
-	var exists bool
-	err := s.db.QueryRow(ctx, "SELECT EXISTS(SELECT 1 FROM books WHERE id=$1)", book.GetId()).Scan(&exists)
 	if err != nil {
-		return &pb.BookResponse{Id: book.GetId(), Message: "Database error"}, err
+		return status.Errorf(codes.Internal, "failed to add book: %v", err)
 	}
-	if exists {
-		return &pb.BookResponse{Id: book.GetId(), Message: "Book already exists"}, nil
+	return nil
+}
+
+// insertBook stores a validated book, counting it against the caller's quota in the same transaction
+func (s *server) insertBook(ctx context.Context, book *pb.Book) error {
+	prepareBook(book)
+	return s.tx.InTx(ctx, func(ctx context.Context) error {
+		if err := s.takeQuota(ctx, quotaBooksAdded); err != nil {
+			return err
+		}
+		return s.books.Create(ctx, book)
+	})
+}
+
+// prepareBook sets the fields of a validated book that are not the caller's to choose before it is stored
+func prepareBook(book *pb.Book) {
+	// allow_duplicate only applies to the request and is not part of the stored book
+	book.AllowDuplicate = false
+	normalizeBook(book)
+	if book.GetTotalCopies() < 1 {
+		book.TotalCopies = 1
 	}
-	_, err = s.db.Exec(ctx, "INSERT INTO books (id, title, author) VALUES ($1, $2, $3)", book.GetId(), book.GetTitle(), book.GetAuthor())
+	book.AvailableCopies = book.GetTotalCopies()
+}
+
+// addBooks adds books as addBook would, with a few round trips to the database for all of them, and returns
+// the outcome of each. Books duplicating one before them are refused too. It fails only when the database does.
+func (s *server) addBooks(ctx context.Context, books []*pb.Book) ([]error, error) {
+	results := make([]error, len(books))
+	policy := s.runtime().duplicates
+	var valid []int
+	var keys, lookups []storage.BookKeys
+	for i, b := range books {
+		if b.GetId() == "" {
+			b.Id = newBookID()
+		}
+		if results[i] = validateBook(b); results[i] != nil {
+			continue
+		}
+		k := policy.matchKeys(b)
+		valid, keys = append(valid, i), append(keys, k)
+		if b.GetAllowDuplicate() {
+			k = storage.BookKeys{ID: b.GetId()}
+		}
+		lookups = append(lookups, k)
+	}
+	if len(valid) == 0 {
+		return results, nil
+	}
+	matches, err := s.books.Match(ctx, lookups)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "database error: %v", err)
+	}
+
+	// The books accepted so far, by what later books could match
+	type titleAuthor struct{ title, author string }
+	ids, isbns, titleAuthors := make(map[string]bool), make(map[string]string), make(map[titleAuthor]string)
+	var accepted []int
+	for j, i := range valid {
+		b, k, m := books[i], lookups[j], matches[j]
+		if m.ISBN == "" && k.ISBN != "" {
+			m.ISBN = isbns[k.ISBN]
+		}
+		if m.TitleAuthor == "" && k.TitleKey != "" {
+			m.TitleAuthor = titleAuthors[titleAuthor{k.TitleKey, k.AuthorKey}]
+		}
+		switch {
+		case m.Exists || ids[b.GetId()]:
+			results[i] = alreadyExists(resourceBook, b.GetId(), "Book already exists")
+		case m.ISBN != "":
+			results[i] = duplicateError(m.ISBN, "same ISBN")
+		case m.TitleAuthor != "":
+			results[i] = duplicateError(m.TitleAuthor, "same title and author")
+		default:
+			accepted = append(accepted, i)
+			ids[b.GetId()] = true
+			if k := keys[j]; k.ISBN != "" && isbns[k.ISBN] == "" {
+				isbns[k.ISBN] = b.GetId()
+			}
+			if k := keys[j]; k.TitleKey != "" && titleAuthors[titleAuthor{k.TitleKey, k.AuthorKey}] == "" {
+				titleAuthors[titleAuthor{k.TitleKey, k.AuthorKey}] = b.GetId()
+			}
+		}
+	}
+
+	err = s.tx.InTx(ctx, func(ctx context.Context) error {
+		left, err := s.quotaLeft(ctx, quotaBooksAdded)
+		if err != nil {
+			return err
+		}
+		var rows []*pb.Book
+		var rowResults []int
+		for n, i := range accepted {
+			if left >= 0 && n >= left {
+				exceeded := &quotaExceededError{quota: quotaBooksAdded, limit: s.runtime().quotas.limit(quotaBooksAdded)}
+				results[i] = exceeded.status(time.Now())
+				continue
+			}
+			prepareBook(books[i])
+			rows, rowResults = append(rows, books[i]), append(rowResults, i)
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+		stored, err := s.books.CreateMany(ctx, rows)
+		if err != nil {
+			return err
+		}
+		created := 0
+		for n, err := range stored {
+			b := rows[n]
+			switch {
+			case err == nil:
+				created++
+			case errors.Is(err, storage.ErrAlreadyExists):
+				// Added meanwhile by another call
+				results[rowResults[n]] = alreadyExists(resourceBook, b.GetId(), "Book already exists")
+			case errors.Is(err, storage.ErrPublisherNotFound):
+				results[rowResults[n]] = notFound(resourcePublisher, strconv.Itoa(int(b.GetPublisherId())), "Publisher not found")
+			default:
+				results[rowResults[n]] = status.Errorf(codes.Internal, "failed to add book: %v", err)
+			}
+		}
+		return s.useQuota(ctx, quotaBooksAdded, created)
+	})
 	if err != nil {
-		return &pb.BookResponse{Id: book.GetId(), Message: "Failed to add book"}, err
+		return nil, status.Errorf(codes.Internal, "failed to add books: %v", err)
 	}
-	return &pb.BookResponse{Id: book.GetId(), Message: "Book added successfully"}, nil
+	return results, nil
 }
 
 func (s *server) UpdateBook(ctx context.Context, book *pb.Book) (*pb.BookResponse, error) {
-	if book.GetId() == "" {
-		return &pb.BookResponse{Id: "", Message: "Book ID is required"}, nil
+	err := s.tx.InTx(ctx, func(ctx context.Context) error {
+		return s.updateBook(ctx, nil, book)
+	})
+	if err != nil {
+		return nil, txStatus(err, "failed to update book")
+	}
+	return &pb.BookResponse{Id: book.GetId()}, nil
+}
+
+// updateBook validates and applies one book update, recording its revision. Run in a unit of work,
+// it locks the book until the work ends; old is the book as the caller already locked it, or nil to
+// lock it here. On failure it returns the status to report for the book.
+func (s *server) updateBook(ctx context.Context, old, book *pb.Book) error {
+	if err := validateBook(book); err != nil {
+		return err
+	}
+
+	if old == nil {
+		var err error
+		old, err = s.books.GetForUpdate(ctx, book.GetId())
+		if errors.Is(err, storage.ErrNotFound) {
+			return notFound(resourceBook, book.GetId(), "Book not found")
+		}
+		if err != nil {
+			return status.Errorf(codes.Internal, "database error: %v", err)
+		}
+	}
+	// total_copies of 0 keeps the current count; copies on loan stay on loan
+	copies := book.GetTotalCopies()
+	if copies == 0 {
+		copies = old.GetTotalCopies()
+	}
+	available := old.GetAvailableCopies() + copies - old.GetTotalCopies()
+	if available < 0 {
+		return status.Error(codes.FailedPrecondition, "Cannot reduce copies below the number on loan")
+	}
+	normalizeBook(book)
+	book.TotalCopies = copies
+	book.AvailableCopies = available
+	err := s.books.Update(ctx, old, book)
+	if errors.Is(err, storage.ErrNotOwner) {
+		return status.Error(codes.PermissionDenied, "Book was added by another user")
+	}
+	if errors.Is(err, storage.ErrPublisherNotFound) {
+		return notFound(resourcePublisher, strconv.Itoa(int(book.GetPublisherId())), "Publisher not found")
 	}
-	res, err := s.db.Exec(ctx, "UPDATE books SET title=$1, author=$2 WHERE id=$3", book.GetTitle(), book.GetAuthor(), book.GetId())
 	if err != nil {
-		return &pb.BookResponse{Id: book.GetId(), Message: "Failed to update book"}, err
+		return status.Errorf(codes.Internal, "failed to update book: %v", err)
 	}
-	if res.RowsAffected() == 0 {
-		return &pb.BookResponse{Id: book.GetId(), Message: "Book not found"}, nil
+	return nil
+}
+
+// rolledBack is reported for BulkUpdateBooks entries undone because another entry failed
+var rolledBack = status.New(codes.Aborted, "Not updated: bulk update rolled back")
+
+func (s *server) BulkUpdateBooks(ctx context.Context, req *pb.BulkUpdateRequest) (*pb.BatchResponse, error) {
+	books := req.GetBooks()
+	if len(books) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "at least one book is required")
+	}
+
+	// Every entry is reported; the first failure rolls the whole batch back
+	responses := make([]*pb.BookResponse, len(books))
+	var failed bool
+	err := s.tx.InTx(ctx, func(ctx context.Context) error {
+		for i, book := range books {
+			err := s.updateBook(ctx, nil, book)
+			if err == nil {
+				responses[i] = &pb.BookResponse{Id: book.GetId()}
+				continue
+			}
+			if status.Code(err) == codes.Internal {
+				slog.ErrorContext(ctx, "bulk update of book failed", "book_id", book.GetId(), "error", err)
+			}
+			responses[i] = &pb.BookResponse{Id: book.GetId(), Error: status.Convert(err).Proto()}
+			for j, other := range books {
+				if j != i {
+					responses[j] = &pb.BookResponse{Id: other.GetId(), Error: rolledBack.Proto()}
+				}
+			}
+			failed = true
+			return err
+		}
+		return nil
+	})
+	if failed {
+		return &pb.BatchResponse{Responses: responses}, nil
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to commit bulk update: %v", err)
 	}
-	return &pb.BookResponse{Id: book.GetId(), Message: "Book updated successfully"}, nil
+	return &pb.BatchResponse{Responses: responses}, nil
 }
 
 func (s *server) DeleteBook(ctx context.Context, req *pb.BookRequest) (*pb.BookResponse, error) {
 	if req.GetId() == "" {
-		return &pb.BookResponse{Id: "", Message: "Book ID is required"}, nil
+		return nil, badRequest("id", "Book ID is required")
 	}
-	res, err := s.db.Exec(ctx, "DELETE FROM books WHERE id=$1", req.GetId())
+	err := s.tx.InTx(ctx, func(ctx context.Context) error {
+		_, err := s.books.Delete(ctx, req.GetId())
+		if errors.Is(err, storage.ErrNotFound) {
+			return notFound(resourceBook, req.GetId(), "Book not found")
+		}
+		if errors.Is(err, storage.ErrNotOwner) {
+			return status.Error(codes.PermissionDenied, "Book was added by another user")
+		}
+		if err != nil {
+			return status.Errorf(codes.Internal, "failed to delete book: %v", err)
+		}
+		if err := s.takeQuota(ctx, quotaBooksDeleted); err != nil {
+			if st, ok := quotaStatus(err); ok {
+				return st
+			}
+			return status.Errorf(codes.Internal, "database error: %v", err)
+		}
+		return nil
+	})
 	if err != nil {
-		return &pb.BookResponse{Id: req.GetId(), Message: "Failed to delete book"}, err
-	}
-	if res.RowsAffected() == 0 {
-		return &pb.BookResponse{Id: req.GetId(), Message: "Book not found"}, nil
+		return nil, txStatus(err, "failed to delete book")
 	}
-	return &pb.BookResponse{Id: req.GetId(), Message: "Book deleted successfully"}, nil
+	return &pb.BookResponse{Id: req.GetId()}, nil
 }
 
-func (s *server) ListBooks(ctx context.Context, req *pb.ListBookRequest) (*pb.ListBookResponse, error) {
-	page := req.GetPage()
-	pageSize := req.GetPageSize()
-	if page < 1 {
-		page = 1
+// enrichBooks loads the data stored outside the books table (tags, series, ratings)
+func (s *server) enrichBooks(ctx context.Context, books []*pb.Book) error {
+	return storage.LoadBookDetails(ctx, s.db, books)
+}
+
+func (s *server) GetBook(ctx context.Context, req *pb.BookRequest) (*pb.Book, error) {
+	if req.GetId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "Book ID is required")
 	}
-	if pageSize < 1 {
-		pageSize = 10
+	prefs, err := languagePreferences(ctx, req.GetLocale())
+	if err != nil {
+		return nil, err
 	}
-	offset := (page - 1) * pageSize
+	book, err := s.books.Get(ctx, req.GetId(), prefs)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, notFound(resourceBook, req.GetId(), "Book not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return book, nil
+}
 
-	rows, err := s.db.Query(ctx, "SELECT id, title, author FROM books ORDER BY id LIMIT $1 OFFSET $2", pageSize, offset)
+func (s *server) ListBooks(ctx context.Context, req *pb.ListBookRequest) (*pb.ListBookResponse, error) {
+	prefs, err := languagePreferences(ctx, req.GetLocale())
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
 
-	var books []*pb.Book
-	for rows.Next() {
-		var b pb.Book
-		if err := rows.Scan(&b.Id, &b.Title, &b.Author); err != nil {
+	filter := storage.BookFilter{
+		Tags:        normalizeTags(req.GetTags()),
+		Branch:      strings.TrimSpace(req.GetBranch()),
+		Section:     strings.TrimSpace(req.GetSection()),
+		PublisherID: req.GetPublisherId(),
+		Author:      strings.TrimSpace(req.GetAuthor()),
+		NewestFirst: req.GetNewestFirst(),
+	}
+	// The key of any book has the shape of the listing's
+	page, err := pageOf(req, filter.PageScope(), filter.PageKey(&pb.Book{})...)
+	if err != nil {
+		return nil, err
+	}
+	filter.Limit, filter.Offset, filter.After = page.limit+1, page.offset, page.after
+	books, err := s.books.List(ctx, filter, prefs)
+	if err != nil {
+		return nil, err
+	}
+	books, next := nextPage(page, books, filter.PageKey)
+	// Counting a large catalog scans all of it; unfiltered listings settle for an estimate unless asked not to
+	if filter.MatchesAll() && !req.GetExactCount() {
+		totalCount, exact, err := s.books.EstimateCount(ctx)
+		if err != nil {
 			return nil, err
 		}
-		books = append(books, &b)
+		return &pb.ListBookResponse{Books: books, TotalCount: totalCount, TotalCountEstimated: !exact, NextPageToken: next}, nil
 	}
-	var totalCount int32
-	err = s.db.QueryRow(ctx, "SELECT COUNT(*) FROM books").Scan(&totalCount)
+	totalCount, err := s.books.Count(ctx, filter)
 	if err != nil {
 		return nil, err
 	}
-	return &pb.ListBookResponse{Books: books, TotalCount: totalCount}, nil
+	return &pb.ListBookResponse{Books: books, TotalCount: totalCount, NextPageToken: next}, nil
+}
+
+// batchAtomicHeader asks BatchAddBooks to add the books of the stream in one transaction: all of them or none
+const batchAtomicHeader = "x-batch-atomic"
+
+// notAdded is reported for the books of an atomic BatchAddBooks call undone, or not tried, because another failed
+var notAdded = status.New(codes.Aborted, "Not added: batch rolled back")
+
+// batchAtomic reports whether the caller set batchAtomicHeader
+func batchAtomic(ctx context.Context) (bool, error) {
+	values := metadata.ValueFromIncomingContext(ctx, batchAtomicHeader)
+	if len(values) == 0 {
+		return false, nil
+	}
+	atomic, err := strconv.ParseBool(strings.TrimSpace(values[0]))
+	if err != nil {
+		return false, status.Error(codes.InvalidArgument, "x-batch-atomic must be true or false")
+	}
+	return atomic, nil
+}
+
+// batchSize is how many books of a BatchAddBooks stream are checked and stored together
+const batchSize = 500
+
+// recvBooks receives the next batchSize books of stream; fewer means that the stream ended
+func recvBooks(stream pb.LibraryService_BatchAddBooksServer) ([]*pb.Book, error) {
+	var books []*pb.Book
+	for len(books) < batchSize {
+		book, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to receive book: %v", err)
+		}
+		books = append(books, book)
+	}
+	return books, nil
+}
+
+// bookResponses are the entries of a BatchResponse for books, one for each, with its error if it has one
+func bookResponses(books []*pb.Book, results []error) []*pb.BookResponse {
+	responses := make([]*pb.BookResponse, len(books))
+	for i, b := range books {
+		responses[i] = &pb.BookResponse{Id: b.GetId()}
+		if results[i] != nil {
+			responses[i].Error = status.Convert(results[i]).Proto()
+		}
+	}
+	return responses
 }
 
 func (s *server) BatchAddBooks(stream pb.LibraryService_BatchAddBooksServer) error {
-	var responses []*pb.BookResponse
 	ctx := stream.Context()
+	atomically, err := batchAtomic(ctx)
+	if err != nil {
+		return err
+	}
+	if atomically {
+		return s.batchAddBooksAtomically(stream)
+	}
+
+	// Each book succeeds or fails on its own; failures are reported with the status AddBook would return
+	var responses []*pb.BookResponse
 	for {
-		book, err := stream.Recv()
-		if err == io.EOF {
-			return stream.SendAndClose(&pb.BatchResponse{Responses: responses})
+		books, err := recvBooks(stream)
+		if err != nil {
+			return err
 		}
+		results, err := s.addBooks(ctx, books)
 		if err != nil {
-			return status.Errorf(codes.Internal, "failed to receive book: %v", err)
+			slog.ErrorContext(ctx, "batch add of books failed", "books", len(books), "error", err)
+			results = slices.Repeat([]error{err}, len(books))
 		}
-		if book.GetId() == "" {
-			responses = append(responses, &pb.BookResponse{Id: "", Message: "Book ID is required"})
-			continue
+		responses = append(responses, bookResponses(books, results)...)
+		if len(books) < batchSize {
+			return stream.SendAndClose(&pb.BatchResponse{Responses: responses})
 		}
-		var exists bool
-		err = s.db.QueryRow(ctx, "SELECT EXISTS(SELECT 1 FROM books WHERE id=$1)", book.GetId()).Scan(&exists)
+	}
+}
+
+// batchAddBooksAtomically adds the books of stream in one transaction. The first book that fails rolls back
+// the others, and those after it are received but not tried; every book is still reported.
+func (s *server) batchAddBooksAtomically(stream pb.LibraryService_BatchAddBooksServer) error {
+	ctx := stream.Context()
+	var responses []*pb.BookResponse
+	failed := -1
+	ended := false
+	err := s.tx.InTx(ctx, func(ctx context.Context) error {
+		for !ended {
+			books, err := recvBooks(stream)
+			if err != nil {
+				return err
+			}
+			ended = len(books) < batchSize
+			results, err := s.addBooks(ctx, books)
+			if err != nil {
+				slog.ErrorContext(ctx, "batch add of books failed", "books", len(books), "error", err)
+				return err
+			}
+			responses = append(responses, bookResponses(books, results)...)
+			if i := slices.IndexFunc(results, func(err error) bool { return err != nil }); i >= 0 {
+				failed = len(responses) - len(books) + i
+				return results[i]
+			}
+		}
+		return nil
+	})
+	if failed < 0 {
 		if err != nil {
-			responses = append(responses, &pb.BookResponse{Id: book.GetId(), Message: "Database error"})
-			continue
+			return txStatus(err, "failed to commit batch")
 		}
-		if exists {
-			responses = append(responses, &pb.BookResponse{Id: book.GetId(), Message: "Book already exists"})
-			continue
+		return stream.SendAndClose(&pb.BatchResponse{Responses: responses})
+	}
+
+	for i, r := range responses {
+		if i != failed {
+			r.Error = notAdded.Proto()
 		}
-		_, err = s.db.Exec(ctx, "INSERT INTO books (id, title, author) VALUES ($1, $2, $3)", book.GetId(), book.GetTitle(), book.GetAuthor())
+	}
+	for !ended {
+		books, err := recvBooks(stream)
 		if err != nil {
-			responses = append(responses, &pb.BookResponse{Id: book.GetId(), Message: "Failed to add book"})
-			continue
+			return err
+		}
+		ended = len(books) < batchSize
+		for _, b := range books {
+			responses = append(responses, &pb.BookResponse{Id: b.GetId(), Error: notAdded.Proto()})
 		}
-		responses = append(responses, &pb.BookResponse{Id: book.GetId(), Message: "Book added successfully"})
 	}
+	return stream.SendAndClose(&pb.BatchResponse{Responses: responses})
 }
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "seed" {
+		runSeed(os.Args[2:])
+		return
+	}
 	clearDB := flag.Bool("clear-db", false, "Clear all data from database on startup")
+	showVersion := flag.Bool("version", false, "Print the version and exit")
+	demo := flag.Bool("demo", false, "Serve a sample catalog from memory, without a database; only the book methods are available, to anyone")
+	bindConfigFlags(flag.CommandLine)
 	flag.Parse()
+	if *showVersion {
+		os.Stdout.WriteString(describeBuild(newServerInfo(nil)) + "\n")
+		return
+	}
+
+	// Settings in ../.env apply unless the environment already has them
+	env := newDotEnv("../.env")
+	if err := env.load(); err != nil {
+		fatal("invalid .env file", "error", err)
+	}
+	cfg, err := LoadConfig(flag.CommandLine)
+	if err != nil {
+		fatal("invalid configuration", "error", err)
+	}
+	logging := newLogging(os.Stderr, cfg.Logging)
+	// Messages of the standard log package, such as those of libraries, go through the same handler
+	slog.SetDefault(logging.logger)
+	useAuthConfig(cfg.Auth)
 
-	lis, err := net.Listen("tcp", ":50051")
+	lis, err := net.Listen("tcp", cfg.GRPC.Addr)
+	if err != nil {
+		fatal("failed to listen", "error", err)
+	}
+	shutdownTracing, err := setupTracing(context.Background())
 	if err != nil {
-		log.Fatalf("failed to listen: %v", err)
+		fatal("invalid tracing configuration", "error", err)
 	}
-	dbpool, err := NewDBPool()
+	defer shutdownTracing(context.Background())
+	errorReports, err := newErrorReporter(cfg.ErrorReporting)
 	if err != nil {
-		log.Fatalf("failed to connect to database: %v", err)
+		fatal("invalid error reporting configuration", "error", err)
+	}
+	var reporter errorReporter
+	if errorReports != nil {
+		reporter = errorReports
+		logging.reporter = reporter
+		go errorReports.run(context.Background())
+		slog.Info("reporting unexpected errors", "endpoint", errorReports.endpoint)
 	}
-	defer dbpool.Close()
+	var dbpool *pgxpool.Pool
+	// catalog keeps the books, and the events of their changes, when they are in MongoDB
+	var catalog *storage.Mongo
+	var store storage.Store
+	if *demo {
+		if store, err = newDemoStore(context.Background()); err != nil {
+			fatal("failed to create the demo catalog", "error", err)
+		}
+		slog.Warn("demo mode: serving a sample catalog from memory that anyone may change; changes are lost on exit")
+	} else {
+		dbpool, err = NewDBPool(cfg.Database)
+		if err != nil {
+			fatal("failed to connect to database", "error", err)
+		}
+		defer dbpool.Close()
+		metricsRegistry.MustRegister(newPoolCollector(dbpool))
 
-	if *clearDB {
-		fmt.Println("Clearing database...")
-		if err := ClearDatabase(dbpool); err != nil {
-			log.Fatalf("failed to clear database: %v", err)
+		if *clearDB {
+			slog.Info("clearing database")
+			if err := ClearDatabase(dbpool); err != nil {
+				fatal("failed to clear database", "error", err)
+			}
+			slog.Info("database cleared")
+		}
+
+		if err := RunMigrations(dbpool); err != nil {
+			fatal("failed to run migrations", "error", err)
+		}
+		if err := checkMigrations(dbpool); err != nil {
+			fatal("startup check failed", "error", err)
+		}
+		store = storage.NewPostgres(dbpool)
+		if cfg.Storage.Backend == storageMongoDB {
+			var disconnect func()
+			catalog, disconnect, err = openMongoStore(cfg.Storage.MongoDB)
+			if err != nil {
+				fatal("failed to connect to MongoDB", "error", err)
+			}
+			defer disconnect()
+			// Accounts stay in PostgreSQL, where sessions, roles and tokens refer to them
+			store = storage.Split(catalog, store)
+			slog.Info("keeping the book catalog in MongoDB, serving the catalog and accounts only", "database", cfg.Storage.MongoDB.Database)
 		}
-		fmt.Println("Database cleared successfully!")
 	}
 
-	if err := RunMigrations(dbpool); err != nil {
-		log.Fatalf("failed to run migrations: %v", err)
+	keys, err := loadJWTKeys(cfg.Auth)
+	if err != nil {
+		fatal("invalid JWT key configuration", "error", err)
+	}
+	jwtKeys = keys
+	// No tokens are issued in demo mode
+	if !*demo {
+		if err := checkJWTSecret(keys, cfg.Auth); err != nil {
+			fatal("startup check failed", "error", err)
+		}
+		if keys == nil && cfg.Auth.JWTSecret == defaultJWTSecret {
+			slog.Warn("ALLOW_INSECURE_JWT_SECRET is set; tokens are signed with the development secret")
+		}
+	}
+	denylist, err := newDenylist(cfg.Auth.RedisURL)
+	if err != nil {
+		fatal("invalid Redis configuration", "error", err)
+	}
+	if dbpool != nil {
+		if err := loadRevokedTokens(context.Background(), dbpool, denylist); err != nil {
+			fatal("failed to load revoked tokens", "error", err)
+		}
+	}
+	tlsConfig, err := newTLSConfig(cfg.TLS)
+	if err != nil {
+		fatal("invalid TLS configuration", "error", err)
+	}
+	identities, err := parseCertIdentities(cfg.TLS.Identities)
+	if err != nil {
+		fatal("invalid client certificate configuration", "error", err)
 	}
 
 	// Create gRPC server with database-aware authentication interceptors
-	s := grpc.NewServer(
-		grpc.UnaryInterceptor(CreateAuthInterceptor(dbpool)),
-		grpc.StreamInterceptor(CreateStreamAuthInterceptor(dbpool)),
-	)
-	pb.RegisterUserServiceServer(s, &server{db: dbpool})
-	pb.RegisterLibraryServiceServer(s, &server{db: dbpool})
-
-	// Start REST gateway in background
-	go StartGateway()
-
-	fmt.Println("gRPC Server is running on port: 50051")
-	fmt.Println("REST Gateway is running on port: 8080")
-	if err := s.Serve(lis); err != nil {
-		log.Fatalf("failed to serve: %v", err)
+	settings, err := newRuntimeSettings(cfg)
+	if err != nil {
+		fatal("invalid configuration", "error", err)
+	}
+	live := newSettings(settings)
+	if dbpool != nil {
+		if err := grantAdminRoles(context.Background(), dbpool, cfg.Auth.AdminUsernames); err != nil {
+			fatal("failed to grant admin roles", "error", err)
+		}
+	}
+	rateLimiter := newAuthRateLimiter(cfg.RateLimits)
+	auth := authOptions{users: store.Users(), denylist: denylist, identities: identities, settings: live}
+	idempotency := &pgIdempotencyStore{db: dbpool, ttl: cfg.Idempotency.KeyTTL}
+	audit := newAuditLog(dbpool)
+	limiter := newConcurrencyLimiter(cfg.Concurrency)
+	cache := newResponseCache(cfg.Cache)
+	var tenants *tenantRegistry
+	if cfg.Tenants.Enabled && dbpool != nil {
+		if err := checkTenantIsolation(context.Background(), dbpool); err != nil {
+			fatal("cannot host several libraries", "error", err)
+		}
+		tenants = newTenantRegistry(dbpool)
+	}
+	// Reports NOT_SERVING until the startup checks below pass, and while the database is unreachable
+	healthServer := health.NewServer()
+	healthServer.SetServingStatus("", healthpb.HealthCheckResponse_NOT_SERVING)
+	var supervisor *dbSupervisor
+	if dbpool != nil {
+		supervisor = newDBSupervisor(dbpool, healthServer, cfg.Database.Reconnect)
+	}
+	shadow, err := newShadowMirror(cfg.Shadow, gatewayDialCredentials(tlsConfig))
+	if err != nil {
+		fatal("invalid shadow configuration", "error", err)
+	}
+	unary := []grpc.UnaryServerInterceptor{CreateLocaleInterceptor(), CreateLoggingInterceptor(logging), CreateMetricsInterceptor(), CreateErrorInterceptor(), CreateRecoveryInterceptor(reporter), CreateConcurrencyInterceptor(limiter), CreateDegradedInterceptor(supervisor), CreateTimeoutInterceptor(cfg.Timeouts), CreateTenantInterceptor(tenants)}
+	stream := []grpc.StreamServerInterceptor{CreateStreamLocaleInterceptor(), CreateStreamLoggingInterceptor(logging), CreateStreamMetricsInterceptor(), CreateStreamErrorInterceptor(), CreateStreamRecoveryInterceptor(reporter), CreateStreamConcurrencyInterceptor(limiter), CreateStreamDegradedInterceptor(supervisor), CreateStreamTimeoutInterceptor(cfg.Timeouts), CreateStreamTenantInterceptor(tenants)}
+	if cfg.Storage.Backend == storageMongoDB && !*demo {
+		unary = append(unary, CreateMongoInterceptor())
+		stream = append(stream, CreateStreamMongoInterceptor())
+	}
+	if *demo {
+		// Authentication, idempotency keys and the audit log are kept in the database
+		unary = append(unary, CreateDemoInterceptor())
+		stream = append(stream, CreateStreamDemoInterceptor())
+	} else {
+		unary = append(unary, CreateAuthRateLimitInterceptor(rateLimiter), CreateAuthInterceptor(dbpool, auth), CreateIdempotencyInterceptor(idempotency), CreateAuditInterceptor(audit))
+		stream = append(stream, CreateStreamAuthInterceptor(dbpool, auth), CreateStreamIdempotencyInterceptor(idempotency), CreateStreamAuditInterceptor(audit))
+	}
+	unary = append(unary, CreateCacheInterceptor(cache), CreateShadowInterceptor(shadow))
+	stream = append(stream, CreateStreamCacheInterceptor(cache))
+	opts := []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(unary...),
+		grpc.ChainStreamInterceptor(stream...),
+		// Continues the caller's trace (such as the gateway's) in a span per RPC
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+	}
+	opts = append(opts, cfg.GRPC.serverOptions()...)
+	if cfg.GRPC.RequestTracing {
+		enableRequestTracing()
+	}
+	s, err := newGRPCServer(tlsConfig, opts...)
+	if err != nil {
+		fatal("invalid xDS configuration", "error", err)
+	}
+	if xdsEnabled() {
+		slog.Info("xDS mode enabled; waiting for the control plane's listener configuration")
+	}
+	mailer, err := newMailer(cfg.Mail)
+	if err != nil {
+		fatal("invalid mail configuration", "error", err)
+	}
+	mail := newMailQueue(mailer)
+	go mail.run(context.Background())
+	oidc := newOIDCVerifier(cfg.OIDC)
+	passwords := newPasswordHashing(cfg.Passwords, cfg.Auth.BcryptCost)
+	challenge := newChallenge(cfg.Challenge)
+	_, sharedDenylist := denylist.(*redisDenylist)
+	_, smtp := mailer.(*smtpMailer)
+	features := enabledFeatures(map[string]bool{
+		"tls":                 tlsConfig != nil,
+		"client_certificates": len(identities) > 0,
+		"xds":                 xdsEnabled(),
+		"jwt_signing_keys":    keys != nil,
+		"shared_denylist":     sharedDenylist,
+		"smtp":                smtp,
+		"oidc":                oidc != nil,
+		"challenge":           challenge != nil,
+		"error_reporting":     errorReports != nil,
+		"response_cache":      cache != nil,
+		"shadow":              shadow != nil,
+		"channelz":            cfg.GRPC.Channelz,
+		"request_tracing":     cfg.GRPC.RequestTracing,
+		"demo":                *demo,
+		"tenants":             tenants != nil,
+		"mongodb":             cfg.Storage.Backend == storageMongoDB && !*demo,
+	})
+	srv := &server{
+		db:              dbpool,
+		books:           store.Books(),
+		users:           store.Users(),
+		tx:              store,
+		bookMetadata:    newCachingMetadataProvider(newOpenLibraryProvider(cfg.Catalog.ISBNLookupURL), isbnCacheTTL, isbnCacheSize),
+		fines:           newFinePolicy(cfg.Circulation),
+		notifications:   newNotificationHub(cfg.Notifications),
+		mailer:          mail,
+		oidc:            oidc,
+		denylist:        denylist,
+		passwords:       passwords,
+		settings:        live,
+		challenge:       challenge,
+		passwordHistory: cfg.Passwords.HistoryDepth,
+		info:            newServerInfo(features),
+		tenants:         tenants,
+		cache:           cache,
+	}
+	pb.RegisterUserServiceServer(s, srv)
+	pb.RegisterLibraryServiceServer(s, srv)
+	pb.RegisterTagServiceServer(s, srv)
+	pb.RegisterReviewServiceServer(s, srv)
+	pb.RegisterFavoriteServiceServer(s, srv)
+	pb.RegisterShelfServiceServer(s, srv)
+	pb.RegisterLoanServiceServer(s, srv)
+	pb.RegisterNotificationServiceServer(s, srv)
+	pb.RegisterPublisherServiceServer(s, srv)
+	pb.RegisterServerInfoServiceServer(s, srv)
+	pb.RegisterTenantServiceServer(s, srv)
+	healthpb.RegisterHealthServer(s, healthServer)
+	if cfg.GRPC.Channelz {
+		enableChannelz(s, settings.policy)
+		slog.Info("channelz service enabled")
+	}
+	if err := settings.policy.checkMethods(s.GetServiceInfo()); err != nil {
+		fatal("invalid authorization policy", "error", err)
+	}
+	if err := cfg.Timeouts.checkMethods(s.GetServiceInfo()); err != nil {
+		fatal("invalid configuration", "error", err)
+	}
+	if err := cfg.Concurrency.checkMethods(s.GetServiceInfo()); err != nil {
+		fatal("invalid configuration", "error", err)
+	}
+	if err := cfg.Shadow.checkMethods(s.GetServiceInfo()); err != nil {
+		fatal("invalid configuration", "error", err)
+	}
+
+	// Mark overdue loans, accrue fines and expire uncollected holds, purge dead tokens and publish the events
+	// of book changes in the background
+	if dbpool != nil {
+		go srv.runCirculation(context.Background(), cfg.Circulation.Interval)
+		go idempotency.runPurge(context.Background(), idempotencyPurgeInterval)
+		go runPurgeJob(context.Background(), dbpool, cfg.Purge, cfg.Retention)
+		go newOutboxRelay(dbpool, catalog, cfg.Outbox).run(context.Background(), cfg.Outbox.Interval)
+		go audit.run(context.Background())
+	}
+
+	reloads := &reloader{env: env, flags: flag.CommandLine, services: s.GetServiceInfo(), logging: logging, rateLimiter: rateLimiter, settings: live, cfg: cfg}
+	go reloads.reloadOnSIGHUP()
+
+	served := make(chan error, 1)
+	go func() { served <- s.Serve(lis) }()
+
+	// The gateway only starts once it can reach the gRPC server, and the server reports SERVING from then on
+	creds := gatewayDialCredentials(tlsConfig)
+	if err := checkUpstream(context.Background(), cfg.Gateway.Upstream, creds); err != nil {
+		fatal("startup check failed", "error", err)
+	}
+	healthServer.SetServingStatus("", healthpb.HealthCheckResponse_SERVING)
+	if supervisor != nil {
+		go supervisor.run(context.Background())
+	}
+	go StartGateway(cfg, creds)
+
+	slog.Info("gRPC server running", "addr", cfg.GRPC.Addr, "version", srv.info.GetVersion(), "commit", srv.info.GetGitCommit())
+	if err := <-served; err != nil {
+		fatal("failed to serve", "error", err)
 	}
 }
@@ -1,12 +1,18 @@
 package main
 
 import (
+	"context"
+	"strings"
 	"testing"
 	"time"
 
 	pb "example/grpc_demo/library"
+	"example/grpc_demo/server/storage"
 
 	"golang.org/x/crypto/bcrypt"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 func TestPasswordHashing(t *testing.T) {
@@ -63,78 +69,129 @@ func TestPasswordStrength(t *testing.T) {
 	}
 }
 
+// assertAuthError checks the status code and ErrorInfo reason of a Register or Login failure
+func assertAuthError(t *testing.T, err error, wantCode codes.Code, wantReason string) {
+	t.Helper()
+	st, ok := status.FromError(err)
+	if !ok || st.Code() != wantCode {
+		t.Fatalf("error = %v, want code %v", err, wantCode)
+	}
+	for _, d := range st.Details() {
+		if info, ok := d.(*errdetails.ErrorInfo); ok {
+			if info.GetReason() != wantReason || info.GetDomain() != authErrorDomain {
+				t.Errorf("ErrorInfo = %s/%s, want %s/%s", info.GetDomain(), info.GetReason(), authErrorDomain, wantReason)
+			}
+			return
+		}
+	}
+	t.Errorf("error %v has no ErrorInfo detail", err)
+}
+
 func TestRegisterValidation(t *testing.T) {
 	tests := []struct {
-		name    string
-		user    *pb.User
-		wantErr bool
-		wantMsg string
+		name         string
+		requireEmail bool
+		user         *pb.User
+		wantReason   string
 	}{
-		{
-			name:    "Empty username",
-			user:    &pb.User{Username: "", Password: "password123"},
-			wantErr: false, // Returns response with error message, not error
-			wantMsg: "Username and password are required",
-		},
-		{
-			name:    "Empty password",
-			user:    &pb.User{Username: "testuser", Password: ""},
-			wantErr: false,
-			wantMsg: "Username and password are required",
-		},
-		{
-			name:    "Both empty",
-			user:    &pb.User{Username: "", Password: ""},
-			wantErr: false,
-			wantMsg: "Username and password are required",
-		},
+		{"Empty username", false, &pb.User{Username: "", Password: "password123"}, reasonMissingCredentials},
+		{"Empty password", false, &pb.User{Username: "testuser", Password: ""}, reasonMissingCredentials},
+		{"Both empty", false, &pb.User{Username: "", Password: ""}, reasonMissingCredentials},
+		{"Invalid email", false, &pb.User{Username: "testuser", Password: "password123", Email: "not-an-email"}, reasonInvalidEmail},
+		{"Email required", true, &pb.User{Username: "testuser", Password: "password123"}, reasonEmailRequired},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			// Note: This test only validates the input validation logic
-			// Full integration tests would require a proper database setup
-			if tt.user.Username == "" || tt.user.Password == "" {
-				// Simulate the validation logic
-				if tt.wantMsg != "Username and password are required" {
-					t.Errorf("Expected validation message not matched")
-				}
+			// Only input validation runs before the database is touched
+			s := &server{settings: newSettings(&runtimeSettings{requireEmail: tt.requireEmail})}
+			resp, err := s.Register(context.Background(), tt.user)
+			if resp != nil {
+				t.Errorf("Register() response = %v, want nil", resp)
 			}
+			assertAuthError(t, err, codes.InvalidArgument, tt.wantReason)
 		})
 	}
 }
 
+// fakeUsers is a UserRepository of taken usernames and addresses, failing Create with createErr. Its only
+// account with an ID is user, whose tokens revocation revokes.
+type fakeUsers struct {
+	usernames, emails map[string]bool
+	createErr         error
+	user              *storage.User
+	revocation        storage.Revocation
+}
+
+func (f *fakeUsers) UsernameExists(ctx context.Context, username string) (bool, error) {
+	return f.usernames[username], nil
+}
+
+func (f *fakeUsers) EmailExists(ctx context.Context, email string) (bool, error) {
+	return f.emails[strings.ToLower(email)], nil
+}
+
+func (f *fakeUsers) Create(ctx context.Context, user storage.NewUser) (int, error) {
+	return 0, f.createErr
+}
+
+func (f *fakeUsers) GetByUsername(ctx context.Context, username string) (*storage.User, error) {
+	return nil, storage.ErrNotFound
+}
+
+func (f *fakeUsers) GetByID(ctx context.Context, id int) (*storage.User, error) {
+	if f.user == nil || f.user.ID != id {
+		return nil, storage.ErrNotFound
+	}
+	u := *f.user
+	return &u, nil
+}
+
+func (f *fakeUsers) Revocation(ctx context.Context, id int, tokenID string, sessionID int64, checkToken bool) (*storage.Revocation, error) {
+	if _, err := f.GetByID(ctx, id); err != nil {
+		return nil, err
+	}
+	rev := f.revocation
+	return &rev, nil
+}
+
+func TestRegisterConflicts(t *testing.T) {
+	users := &fakeUsers{usernames: map[string]bool{"alice": true}, emails: map[string]bool{"alice@example.com": true}}
+	s := &server{
+		users:     users,
+		tx:        fakeTx{},
+		passwords: &passwordHashing{current: bcryptHasher{cost: bcrypt.MinCost}},
+	}
+	ctx := context.Background()
+
+	_, err := s.Register(ctx, &pb.User{Username: "alice", Password: "password123"})
+	assertAuthError(t, err, codes.AlreadyExists, reasonUsernameTaken)
+	_, err = s.Register(ctx, &pb.User{Username: "bob", Password: "password123", Email: "Alice@example.com"})
+	assertAuthError(t, err, codes.AlreadyExists, reasonEmailTaken)
+
+	// Taken between the check and the insert
+	users.createErr = storage.ErrAlreadyExists
+	_, err = s.Register(ctx, &pb.User{Username: "bob", Password: "password123"})
+	assertAuthError(t, err, codes.AlreadyExists, reasonUsernameTaken)
+}
+
 func TestLoginValidation(t *testing.T) {
 	tests := []struct {
-		name    string
-		creds   *pb.UserCredentials
-		wantMsg string
+		name  string
+		creds *pb.UserCredentials
 	}{
-		{
-			name:    "Empty username",
-			creds:   &pb.UserCredentials{Username: "", Password: "password123"},
-			wantMsg: "Username and password are required",
-		},
-		{
-			name:    "Empty password",
-			creds:   &pb.UserCredentials{Username: "testuser", Password: ""},
-			wantMsg: "Username and password are required",
-		},
-		{
-			name:    "Both empty",
-			creds:   &pb.UserCredentials{Username: "", Password: ""},
-			wantMsg: "Username and password are required",
-		},
+		{"Empty username", &pb.UserCredentials{Username: "", Password: "password123"}},
+		{"Empty password", &pb.UserCredentials{Username: "testuser", Password: ""}},
+		{"Both empty", &pb.UserCredentials{Username: "", Password: ""}},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			// Validate the input validation logic
-			if tt.creds.Username == "" || tt.creds.Password == "" {
-				if tt.wantMsg != "Username and password are required" {
-					t.Errorf("Expected validation message not matched")
-				}
+			resp, err := (&server{}).Login(context.Background(), tt.creds)
+			if resp != nil {
+				t.Errorf("Login() response = %v, want nil", resp)
 			}
+			assertAuthError(t, err, codes.InvalidArgument, reasonMissingCredentials)
 		})
 	}
 }
@@ -174,7 +231,7 @@ func TestJWTIntegration(t *testing.T) {
 	userID := 123
 	username := "testuser"
 
-	token, err := GenerateJWT(userID, username)
+	token, err := GenerateJWT(userID, username, 0)
 	if err != nil {
 		t.Fatalf("Failed to generate JWT: %v", err)
 	}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	pb "example/grpc_demo/library"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	// maxUserAgentLength bounds the user agent stored for a session
+	maxUserAgentLength = 512
+	// sessionTouchInterval throttles last_seen_at updates to one write per session per interval
+	sessionTouchInterval = time.Minute
+)
+
+// sessionDevice returns the user agent and IP address of the caller.
+// Requests proxied by the REST gateway arrive from a loopback address, so the
+// address the gateway appended to X-Forwarded-For is used for them instead.
+func sessionDevice(ctx context.Context) (userAgent, ip string) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if v := md.Get("grpcgateway-user-agent"); len(v) > 0 {
+		userAgent = v[0]
+	} else if v := md.Get("user-agent"); len(v) > 0 {
+		userAgent = v[0]
+	}
+	if len(userAgent) > maxUserAgentLength {
+		userAgent = userAgent[:maxUserAgentLength]
+	}
+
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		ip = p.Addr.String()
+		if host, _, err := net.SplitHostPort(ip); err == nil {
+			ip = host
+		}
+	}
+	if addr := net.ParseIP(ip); addr == nil || addr.IsLoopback() {
+		if fwd := md.Get(forwardedForHeader); len(fwd) > 0 {
+			hops := strings.Split(fwd[len(fwd)-1], ",")
+			ip = strings.TrimSpace(hops[len(hops)-1])
+		}
+	}
+	return userAgent, ip
+}
+
+// startSession records a new login of the user from the calling device
+func startSession(ctx context.Context, q querier, userID int) (int64, error) {
+	userAgent, ip := sessionDevice(ctx)
+	var id int64
+	err := q.QueryRow(ctx,
+		"INSERT INTO sessions (user_id, user_agent, ip_address, device_fingerprint) VALUES ($1, $2, $3, $4) RETURNING id",
+		userID, userAgent, ip, deviceFingerprint(ctx)).Scan(&id)
+	return id, err
+}
+
+// touchSession records that the session made a request. Failures only cost accuracy, so they are logged.
+func touchSession(ctx context.Context, db *pgxpool.Pool, sessionID int64) {
+	if sessionID == 0 {
+		return
+	}
+	_, err := db.Exec(ctx, "UPDATE sessions SET last_seen_at=NOW() WHERE id=$1 AND last_seen_at < $2",
+		sessionID, time.Now().Add(-sessionTouchInterval))
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to update last seen time of session", "session_id", sessionID, "error", err)
+	}
+}
+
+// revokeSession ends one session of the user and revokes its refresh tokens.
+// It reports whether the session was still active and how many refresh tokens were revoked.
+func revokeSession(ctx context.Context, q querier, userID int, sessionID int64) (bool, int64, error) {
+	res, err := q.Exec(ctx, "UPDATE sessions SET revoked_at=NOW() WHERE id=$1 AND user_id=$2 AND revoked_at IS NULL",
+		sessionID, userID)
+	if err != nil || res.RowsAffected() == 0 {
+		return false, 0, err
+	}
+	res, err = q.Exec(ctx, "UPDATE refresh_tokens SET revoked_at=NOW() WHERE session_id=$1 AND revoked_at IS NULL", sessionID)
+	if err != nil {
+		return false, 0, err
+	}
+	return true, res.RowsAffected(), nil
+}
+
+func (s *server) ListSessions(ctx context.Context, req *pb.ListSessionsRequest) (*pb.ListSessionsResponse, error) {
+	userID, _, ok := userFromContext(ctx)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "authentication required")
+	}
+	var current int64
+	if claims, ok := claimsFromContext(ctx); ok {
+		current = claims.SessionID
+	}
+
+	// A session is active while one of its access tokens or refresh tokens can still be used
+	now := time.Now()
+	rows, err := s.db.Query(ctx,
+		`SELECT s.id, s.user_agent, s.ip_address, s.issued_at, s.last_seen_at
+		 FROM sessions s
+		 WHERE s.user_id=$1 AND s.revoked_at IS NULL
+		   AND (s.last_seen_at > $2 OR EXISTS (
+			SELECT 1 FROM refresh_tokens r WHERE r.session_id = s.id AND r.revoked_at IS NULL AND r.expires_at > $3))
+		 ORDER BY s.last_seen_at DESC, s.id DESC`,
+		userID, now.Add(-tokenTTLs.access), now)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to list sessions: %v", err)
+	}
+	defer rows.Close()
+
+	resp := &pb.ListSessionsResponse{}
+	for rows.Next() {
+		var sess pb.Session
+		var issuedAt, lastSeenAt time.Time
+		if err := rows.Scan(&sess.Id, &sess.UserAgent, &sess.IpAddress, &issuedAt, &lastSeenAt); err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to read session: %v", err)
+		}
+		sess.IssuedAt = timestamppb.New(issuedAt)
+		sess.LastSeenAt = timestamppb.New(lastSeenAt)
+		sess.Current = sess.Id == current
+		resp.Sessions = append(resp.Sessions, &sess)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to list sessions: %v", err)
+	}
+	return resp, nil
+}
+
+func (s *server) RevokeSession(ctx context.Context, req *pb.RevokeSessionRequest) (*pb.LogoutResponse, error) {
+	userID, _, ok := userFromContext(ctx)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "authentication required")
+	}
+	if req.GetId() <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "session ID is required")
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "database error: %v", err)
+	}
+	defer tx.Rollback(ctx)
+
+	found, revoked, err := revokeSession(ctx, tx, userID, req.GetId())
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to revoke session: %v", err)
+	}
+	if !found {
+		return nil, notFound(resourceSession, strconv.FormatInt(req.GetId(), 10), "session not found")
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, status.Errorf(codes.Internal, "database error: %v", err)
+	}
+	return &pb.LogoutResponse{Message: localize(ctx, "Session revoked"), RevokedRefreshTokens: int32(revoked)}, nil
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+func TestSessionDevice(t *testing.T) {
+	withPeer := func(ip string, md metadata.MD) context.Context {
+		ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP(ip), Port: 40000}})
+		return metadata.NewIncomingContext(ctx, md)
+	}
+	tests := []struct {
+		name      string
+		ctx       context.Context
+		wantAgent string
+		wantIP    string
+	}{
+		{"direct gRPC", withPeer("203.0.113.7", metadata.Pairs("user-agent", "grpc-go/1.70")), "grpc-go/1.70", "203.0.113.7"},
+		{"gateway", withPeer("127.0.0.1", metadata.Pairs(
+			"user-agent", "grpc-go/1.70",
+			"grpcgateway-user-agent", "Mozilla/5.0",
+			"x-forwarded-for", "10.0.0.1, 198.51.100.4",
+		)), "Mozilla/5.0", "198.51.100.4"},
+		{"forwarded header ignored from remote peers", withPeer("203.0.113.7", metadata.Pairs("x-forwarded-for", "10.0.0.1")), "", "203.0.113.7"},
+		{"no peer", metadata.NewIncomingContext(context.Background(), metadata.MD{}), "", ""},
+		{"long user agent", withPeer("203.0.113.7", metadata.Pairs("user-agent", strings.Repeat("x", maxUserAgentLength+10))),
+			strings.Repeat("x", maxUserAgentLength), "203.0.113.7"},
+	}
+	for _, tt := range tests {
+		agent, ip := sessionDevice(tt.ctx)
+		if agent != tt.wantAgent || ip != tt.wantIP {
+			t.Errorf("%s: sessionDevice() = %q, %q, want %q, %q", tt.name, agent, ip, tt.wantAgent, tt.wantIP)
+		}
+	}
+}
+
+func TestGenerateJWTSessionID(t *testing.T) {
+	token, err := GenerateJWT(1, "testuser", 42)
+	if err != nil {
+		t.Fatalf("GenerateJWT() error = %v", err)
+	}
+	claims, err := ValidateJWT(token)
+	if err != nil {
+		t.Fatalf("ValidateJWT() error = %v", err)
+	}
+	if claims.SessionID != 42 {
+		t.Errorf("ValidateJWT() SessionID = %d, want 42", claims.SessionID)
+	}
+}
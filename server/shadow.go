@@ -0,0 +1,209 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+const (
+	// shadowHeader marks mirrored calls, so that a shadow configured to mirror calls of its own doesn't
+	// mirror them again
+	shadowHeader = "x-shadow-request"
+	// shadowTimeout bounds a mirrored call
+	shadowTimeout = 10 * time.Second
+	// shadowMaxInFlight bounds the mirrored calls waiting for the shadow, so a slow one can't pile up goroutines
+	shadowMaxInFlight = 64
+	// maxShadowDiffs bounds the fields logged for a diverging response
+	maxShadowDiffs = 10
+)
+
+// checkMethods reports a mirrored method the server doesn't have, most likely a typo
+func (c ShadowConfig) checkMethods(services map[string]grpc.ServiceInfo) error {
+	for _, method := range c.Methods {
+		if !hasMethod(services, method) {
+			return fmt.Errorf("shadowing set for unknown method %q", method)
+		}
+	}
+	return nil
+}
+
+// shadowMirror replays a share of the calls to read methods against a second deployment, such as one
+// running a new storage implementation, and logs the responses that differ from this server's. Callers
+// only ever get this server's response; the shadow is called in the background.
+type shadowMirror struct {
+	conn     *grpc.ClientConn
+	percent  int
+	methods  map[string]bool
+	inFlight chan struct{}
+}
+
+// newShadowMirror connects to the shadow of cfg with creds, returning nil when shadowing is off
+func newShadowMirror(cfg ShadowConfig, creds credentials.TransportCredentials) (*shadowMirror, error) {
+	if cfg.Upstream == "" || cfg.Percent == 0 {
+		return nil, nil
+	}
+	conn, err := grpc.NewClient(cfg.Upstream, grpc.WithTransportCredentials(creds), grpc.WithStatsHandler(otelgrpc.NewClientHandler()))
+	if err != nil {
+		return nil, fmt.Errorf("invalid SHADOW_UPSTREAM %q: %w", cfg.Upstream, err)
+	}
+	m := &shadowMirror{conn: conn, percent: cfg.Percent, methods: make(map[string]bool), inFlight: make(chan struct{}, shadowMaxInFlight)}
+	for _, method := range cfg.Methods {
+		m.methods[method] = true
+	}
+	return m, nil
+}
+
+// sampled tells whether the call of ctx to method is to be mirrored
+func (m *shadowMirror) sampled(ctx context.Context, method string) bool {
+	if !m.methods[method] || rand.IntN(100) >= m.percent {
+		return false
+	}
+	if len(metadata.ValueFromIncomingContext(ctx, shadowHeader)) > 0 {
+		return false
+	}
+	// Services authenticate with their client certificate, which the shadow call can't present
+	_, isService := serviceFromContext(ctx)
+	return !isService
+}
+
+// shadowMetadata returns the metadata the call of ctx is mirrored with: the caller's, so that the shadow
+// authenticates and localizes the same way, with this call's request ID
+func shadowMetadata(ctx context.Context) metadata.MD {
+	md, _ := metadata.FromIncomingContext(ctx)
+	md = md.Copy()
+	for key := range md {
+		if strings.HasPrefix(key, ":") || strings.HasPrefix(key, "grpc-") || key == "content-type" || key == "user-agent" {
+			delete(md, key)
+		}
+	}
+	md.Set(requestIDHeader, requestIDFromContext(ctx))
+	md.Set(shadowHeader, "true")
+	return md
+}
+
+// mirror calls method with req on the shadow in the background, and compares the outcome with resp and
+// err, those of this server. The call is skipped when too many are already waiting for the shadow.
+func (m *shadowMirror) mirror(ctx context.Context, method string, req, resp proto.Message, err error) {
+	select {
+	case m.inFlight <- struct{}{}:
+	default:
+		shadowRequests.WithLabelValues(method, "skipped").Inc()
+		return
+	}
+	// The shadow call outlives the caller's, but belongs to its trace
+	shadowCtx := trace.ContextWithSpanContext(context.Background(), trace.SpanContextFromContext(ctx))
+	shadowCtx = metadata.NewOutgoingContext(shadowCtx, shadowMetadata(ctx))
+	requestID := requestIDFromContext(ctx)
+	go func() {
+		defer func() { <-m.inFlight }()
+		shadowCtx, cancel := context.WithTimeout(shadowCtx, shadowTimeout)
+		defer cancel()
+		var reply proto.Message = &emptypb.Empty{}
+		if resp != nil {
+			reply = resp.ProtoReflect().New().Interface()
+		}
+		shadowErr := m.conn.Invoke(shadowCtx, method, req, reply)
+		result, attrs := compareShadow(resp, err, reply, shadowErr)
+		shadowRequests.WithLabelValues(method, result).Inc()
+		switch result {
+		case "mismatch":
+			slog.Warn("shadow response diverged", append(attrs, "method", method, "request_id", requestID)...)
+		case "error":
+			slog.Warn("shadow call failed", "method", method, "request_id", requestID, "error", shadowErr)
+		}
+	}()
+}
+
+// compareShadow classifies the outcome of a mirrored call: match, mismatch (with what differs), or error
+// when the shadow couldn't be reached in time
+func compareShadow(resp proto.Message, err error, shadowResp proto.Message, shadowErr error) (string, []any) {
+	code, shadowCode := status.Code(err), status.Code(shadowErr)
+	if code != shadowCode {
+		if err == nil && (shadowCode == codes.Unavailable || shadowCode == codes.DeadlineExceeded) {
+			return "error", nil
+		}
+		return "mismatch", []any{"code", code.String(), "shadow_code", shadowCode.String(), "shadow_error", status.Convert(shadowErr).Message()}
+	}
+	if err != nil {
+		return "match", nil
+	}
+	if diffs := protoDiff(resp, shadowResp); len(diffs) > 0 {
+		return "mismatch", []any{"fields", diffs}
+	}
+	return "match", nil
+}
+
+// protoDiff lists the paths of the fields of a and b that differ, such as books[3].title, descending into
+// messages and lists of the same length to find the innermost ones
+func protoDiff(a, b proto.Message) []string {
+	var diffs []string
+	diffMessage(a.ProtoReflect(), b.ProtoReflect(), "", &diffs)
+	return diffs
+}
+
+func diffMessage(a, b protoreflect.Message, prefix string, diffs *[]string) {
+	fields := a.Descriptor().Fields()
+	for i := 0; i < fields.Len() && len(*diffs) < maxShadowDiffs; i++ {
+		fd := fields.Get(i)
+		va, vb := a.Get(fd), b.Get(fd)
+		if va.Equal(vb) {
+			continue
+		}
+		path := prefix + string(fd.Name())
+		switch {
+		case fd.IsList() && fd.Message() != nil && va.List().Len() == vb.List().Len():
+			for j := 0; j < va.List().Len(); j++ {
+				if ea, eb := va.List().Get(j), vb.List().Get(j); !ea.Equal(eb) {
+					diffMessage(ea.Message(), eb.Message(), fmt.Sprintf("%s[%d].", path, j), diffs)
+				}
+			}
+		case fd.Message() != nil && !fd.IsList() && !fd.IsMap() && a.Has(fd) && b.Has(fd):
+			diffMessage(va.Message(), vb.Message(), path+".", diffs)
+		default:
+			*diffs = append(*diffs, path)
+		}
+	}
+}
+
+// CreateShadowInterceptor mirrors the sampled calls to the methods of mirror once this server has answered
+// them. It runs after the cache interceptor, so that responses served from the cache aren't compared with
+// the shadow's fresh ones.
+func CreateShadowInterceptor(mirror *shadowMirror) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if mirror == nil || !mirror.sampled(ctx, info.FullMethod) {
+			return handler(ctx, req)
+		}
+		m, ok := req.(proto.Message)
+		if !ok {
+			return handler(ctx, req)
+		}
+		// Copies, as the handler and the response's marshalling must not race with the shadow call
+		mirrored := proto.Clone(m)
+		resp, err := handler(ctx, req)
+		var answer proto.Message
+		if err == nil {
+			r, ok := resp.(proto.Message)
+			if !ok {
+				return resp, err
+			}
+			answer = proto.Clone(r)
+		}
+		mirror.mirror(ctx, info.FullMethod, mirrored, answer, err)
+		return resp, err
+	}
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"net"
+	"slices"
+	"testing"
+	"time"
+
+	pb "example/grpc_demo/library"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestProtoDiff(t *testing.T) {
+	a := &pb.ListBookResponse{TotalCount: 2, Books: []*pb.Book{{Id: "b1", Title: "Dune"}, {Id: "b2", Tags: []string{"sf"}}}}
+	b := &pb.ListBookResponse{TotalCount: 3, Books: []*pb.Book{{Id: "b1", Title: "Dune "}, {Id: "b2"}}}
+	if got, want := protoDiff(a, b), []string{"books[0].title", "books[1].tags", "total_count"}; !slices.Equal(got, want) {
+		t.Errorf("protoDiff() = %v, want %v", got, want)
+	}
+	b.Books = b.Books[:1]
+	if got := protoDiff(a, b); !slices.Contains(got, "books") {
+		t.Errorf("lists of different lengths: protoDiff() = %v", got)
+	}
+	if got := protoDiff(a, a); len(got) != 0 {
+		t.Errorf("protoDiff() of equal messages = %v", got)
+	}
+}
+
+func TestCompareShadow(t *testing.T) {
+	book := &pb.Book{Id: "b1"}
+	notFound := status.Error(codes.NotFound, "Book not found")
+	tests := []struct {
+		name             string
+		resp, shadowResp *pb.Book
+		err, shadowErr   error
+		want             string
+	}{
+		{"same response", book, &pb.Book{Id: "b1"}, nil, nil, "match"},
+		{"different response", book, &pb.Book{Id: "b2"}, nil, nil, "mismatch"},
+		{"same error", nil, nil, notFound, status.Error(codes.NotFound, "no such book"), "match"},
+		{"error on the shadow only", book, nil, nil, notFound, "mismatch"},
+		{"error here only", nil, book, notFound, nil, "mismatch"},
+		{"shadow unreachable", book, nil, nil, status.Error(codes.Unavailable, "connection refused"), "error"},
+	}
+	for _, tt := range tests {
+		if got, _ := compareShadow(tt.resp, tt.err, tt.shadowResp, tt.shadowErr); got != tt.want {
+			t.Errorf("%s: compareShadow() = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+// shadowLibrary is the shadow deployment, answering GetBook with a title of its own
+type shadowLibrary struct {
+	pb.UnimplementedLibraryServiceServer
+	title string
+	calls chan metadata.MD
+}
+
+func (s *shadowLibrary) GetBook(ctx context.Context, req *pb.BookRequest) (*pb.Book, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	s.calls <- md
+	return &pb.Book{Id: req.GetId(), Title: s.title}, nil
+}
+
+func TestShadowInterceptor(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	shadow := &shadowLibrary{title: "Dune", calls: make(chan metadata.MD, 1)}
+	s := grpc.NewServer()
+	pb.RegisterLibraryServiceServer(s, shadow)
+	go s.Serve(lis)
+	defer s.Stop()
+
+	mirror, err := newShadowMirror(ShadowConfig{Upstream: lis.Addr().String(), Percent: 100, Methods: []string{pb.LibraryService_GetBook_FullMethodName}}, insecure.NewCredentials())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mirror.conn.Close()
+	interceptor := CreateShadowInterceptor(mirror)
+	getBook := &grpc.UnaryServerInfo{FullMethod: pb.LibraryService_GetBook_FullMethodName}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return &pb.Book{Id: req.(*pb.BookRequest).GetId(), Title: "Dune"}, nil
+	}
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer token", acceptLanguageHeader, "pt-BR"))
+	ctx, requestID := withRequestID(ctx)
+
+	// waitFor waits until the shadow call's outcome is counted under result
+	waitFor := func(result string, before float64) {
+		t.Helper()
+		for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+			if testutil.ToFloat64(shadowRequests.WithLabelValues(getBook.FullMethod, result)) > before {
+				return
+			}
+		}
+		t.Fatalf("no %s was counted", result)
+	}
+
+	matches := testutil.ToFloat64(shadowRequests.WithLabelValues(getBook.FullMethod, "match"))
+	resp, err := interceptor(ctx, &pb.BookRequest{Id: "b1"}, getBook, handler)
+	if err != nil || resp.(*pb.Book).GetTitle() != "Dune" {
+		t.Fatalf("interceptor() = %v, %v", resp, err)
+	}
+	md := <-shadow.calls
+	for key, want := range map[string]string{"authorization": "Bearer token", acceptLanguageHeader: "pt-BR", requestIDHeader: requestID, shadowHeader: "true"} {
+		if got := md.Get(key); len(got) != 1 || got[0] != want {
+			t.Errorf("shadow call's %s = %v, want %s", key, got, want)
+		}
+	}
+	waitFor("match", matches)
+
+	mismatches := testutil.ToFloat64(shadowRequests.WithLabelValues(getBook.FullMethod, "mismatch"))
+	shadow.title = "Dune Messiah"
+	interceptor(ctx, &pb.BookRequest{Id: "b1"}, getBook, handler)
+	<-shadow.calls
+	waitFor("mismatch", mismatches)
+
+	// Calls that are themselves mirrored, and methods not listed, aren't mirrored
+	mirrored := metadata.NewIncomingContext(context.Background(), metadata.Pairs(shadowHeader, "true"))
+	interceptor(mirrored, &pb.BookRequest{Id: "b1"}, getBook, handler)
+	listBooks := func(context.Context, interface{}) (interface{}, error) { return &pb.ListBookResponse{}, nil }
+	interceptor(ctx, &pb.ListBookRequest{}, &grpc.UnaryServerInfo{FullMethod: pb.LibraryService_ListBooks_FullMethodName}, listBooks)
+	select {
+	case <-shadow.calls:
+		t.Error("a call was mirrored")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	if mirror, err := newShadowMirror(ShadowConfig{Percent: 100}, insecure.NewCredentials()); mirror != nil || err != nil {
+		t.Errorf("without an upstream: newShadowMirror() = %v, %v", mirror, err)
+	}
+}
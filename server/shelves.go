@@ -0,0 +1,176 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	pb "example/grpc_demo/library"
+	"example/grpc_demo/server/storage"
+
+	"github.com/jackc/pgx/v5"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// shelfOwnedBy reports whether the shelf exists and belongs to userID
+func (s *server) shelfOwnedBy(ctx context.Context, shelfID int64, userID int) (bool, error) {
+	var owned bool
+	err := s.db.QueryRow(ctx, "SELECT EXISTS(SELECT 1 FROM shelves WHERE id=$1 AND user_id=$2)", shelfID, userID).Scan(&owned)
+	return owned, err
+}
+
+func (s *server) CreateShelf(ctx context.Context, shelf *pb.Shelf) (*pb.ShelfResponse, error) {
+	userID, _, ok := userFromContext(ctx)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "authentication required")
+	}
+	name := strings.TrimSpace(shelf.GetName())
+	if name == "" {
+		return nil, badRequest("name", "Shelf name is required")
+	}
+
+	var id int64
+	err := s.db.QueryRow(ctx,
+		"INSERT INTO shelves (user_id, name, description) VALUES ($1, $2, $3) RETURNING id",
+		userID, name, shelf.GetDescription()).Scan(&id)
+	if isUniqueViolation(err) {
+		return nil, alreadyExists(resourceShelf, name, "Shelf already exists")
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to create shelf: %v", err)
+	}
+	return &pb.ShelfResponse{Id: id}, nil
+}
+
+func (s *server) ListShelves(ctx context.Context, req *pb.ListShelvesRequest) (*pb.ListShelvesResponse, error) {
+	userID, _, ok := userFromContext(ctx)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "authentication required")
+	}
+
+	rows, err := s.db.Query(ctx,
+		`SELECT s.id, s.name, s.description, s.created_at, COUNT(sb.book_id)
+		 FROM shelves s LEFT JOIN shelf_books sb ON sb.shelf_id = s.id
+		 WHERE s.user_id=$1 GROUP BY s.id ORDER BY s.name`,
+		userID)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "database error: %v", err)
+	}
+	defer rows.Close()
+
+	var shelves []*pb.Shelf
+	for rows.Next() {
+		var sh pb.Shelf
+		var createdAt time.Time
+		if err := rows.Scan(&sh.Id, &sh.Name, &sh.Description, &createdAt, &sh.BookCount); err != nil {
+			return nil, status.Errorf(codes.Internal, "database error: %v", err)
+		}
+		sh.CreatedAt = timestamppb.New(createdAt)
+		shelves = append(shelves, &sh)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, status.Errorf(codes.Internal, "database error: %v", err)
+	}
+	return &pb.ListShelvesResponse{Shelves: shelves}, nil
+}
+
+func (s *server) GetShelf(ctx context.Context, req *pb.ShelfRequest) (*pb.Shelf, error) {
+	userID, _, ok := userFromContext(ctx)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "authentication required")
+	}
+
+	// Shelves owned by other users are reported as missing
+	shelf := &pb.Shelf{Id: req.GetId()}
+	var createdAt time.Time
+	err := s.db.QueryRow(ctx,
+		"SELECT name, description, created_at FROM shelves WHERE id=$1 AND user_id=$2",
+		req.GetId(), userID).Scan(&shelf.Name, &shelf.Description, &createdAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, notFound(resourceShelf, strconv.FormatInt(req.GetId(), 10), "Shelf not found")
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "database error: %v", err)
+	}
+	shelf.CreatedAt = timestamppb.New(createdAt)
+
+	rows, err := s.db.Query(ctx,
+		"SELECT "+storage.QualifiedBookColumns("b")+" FROM shelf_books sb JOIN books b ON b.id = sb.book_id WHERE sb.shelf_id=$1 ORDER BY sb.added_at, b.id",
+		req.GetId())
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "database error: %v", err)
+	}
+	books, err := storage.CollectBooks(rows)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "database error: %v", err)
+	}
+	if err := s.enrichBooks(ctx, books); err != nil {
+		return nil, status.Errorf(codes.Internal, "database error: %v", err)
+	}
+	shelf.Books = books
+	shelf.BookCount = int32(len(books))
+	return shelf, nil
+}
+
+func (s *server) AddBookToShelf(ctx context.Context, req *pb.ShelfBookRequest) (*pb.ShelfResponse, error) {
+	userID, _, ok := userFromContext(ctx)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "authentication required")
+	}
+	if req.GetBookId() == "" {
+		return nil, badRequest("book_id", "Book ID is required")
+	}
+
+	owned, err := s.shelfOwnedBy(ctx, req.GetShelfId(), userID)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "database error: %v", err)
+	}
+	if !owned {
+		return nil, notFound(resourceShelf, strconv.FormatInt(req.GetShelfId(), 10), "Shelf not found")
+	}
+
+	var bookExists bool
+	if err := s.db.QueryRow(ctx, "SELECT EXISTS(SELECT 1 FROM books WHERE id=$1)", req.GetBookId()).Scan(&bookExists); err != nil {
+		return nil, status.Errorf(codes.Internal, "database error: %v", err)
+	}
+	if !bookExists {
+		return nil, notFound(resourceBook, req.GetBookId(), "Book not found")
+	}
+
+	_, err = s.db.Exec(ctx, "INSERT INTO shelf_books (shelf_id, book_id) VALUES ($1, $2) ON CONFLICT DO NOTHING", req.GetShelfId(), req.GetBookId())
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to add book to shelf: %v", err)
+	}
+	return &pb.ShelfResponse{Id: req.GetShelfId()}, nil
+}
+
+func (s *server) RemoveBookFromShelf(ctx context.Context, req *pb.ShelfBookRequest) (*pb.ShelfResponse, error) {
+	userID, _, ok := userFromContext(ctx)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "authentication required")
+	}
+	if req.GetBookId() == "" {
+		return nil, badRequest("book_id", "Book ID is required")
+	}
+
+	owned, err := s.shelfOwnedBy(ctx, req.GetShelfId(), userID)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "database error: %v", err)
+	}
+	if !owned {
+		return nil, notFound(resourceShelf, strconv.FormatInt(req.GetShelfId(), 10), "Shelf not found")
+	}
+
+	res, err := s.db.Exec(ctx, "DELETE FROM shelf_books WHERE shelf_id=$1 AND book_id=$2", req.GetShelfId(), req.GetBookId())
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to remove book from shelf: %v", err)
+	}
+	if res.RowsAffected() == 0 {
+		return nil, notFound(resourceShelfBook, req.GetBookId(), "Book is not on this shelf")
+	}
+	return &pb.ShelfResponse{Id: req.GetShelfId()}, nil
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	pb "example/grpc_demo/library"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestShelfValidation(t *testing.T) {
+	s := &server{}
+	anonymous := context.Background()
+	calls := map[string]func(ctx context.Context) error{
+		"CreateShelf": func(ctx context.Context) error {
+			_, err := s.CreateShelf(ctx, &pb.Shelf{Name: "To read"})
+			return err
+		},
+		"ListShelves": func(ctx context.Context) error {
+			_, err := s.ListShelves(ctx, &pb.ListShelvesRequest{})
+			return err
+		},
+		"GetShelf": func(ctx context.Context) error {
+			_, err := s.GetShelf(ctx, &pb.ShelfRequest{Id: 1})
+			return err
+		},
+		"AddBookToShelf": func(ctx context.Context) error {
+			_, err := s.AddBookToShelf(ctx, &pb.ShelfBookRequest{ShelfId: 1, BookId: "b1"})
+			return err
+		},
+		"RemoveBookFromShelf": func(ctx context.Context) error {
+			_, err := s.RemoveBookFromShelf(ctx, &pb.ShelfBookRequest{ShelfId: 1, BookId: "b1"})
+			return err
+		},
+	}
+	for name, call := range calls {
+		if err := call(anonymous); status.Code(err) != codes.Unauthenticated {
+			t.Errorf("%s() without a user error = %v, want Unauthenticated", name, err)
+		}
+	}
+
+	ctx := context.WithValue(context.Background(), userIDKey, 1)
+	if _, err := s.CreateShelf(ctx, &pb.Shelf{Name: "  "}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("CreateShelf() with a blank name error = %v, want InvalidArgument", err)
+	}
+	if _, err := s.AddBookToShelf(ctx, &pb.ShelfBookRequest{ShelfId: 1}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("AddBookToShelf() without a book error = %v, want InvalidArgument", err)
+	}
+	if _, err := s.RemoveBookFromShelf(ctx, &pb.ShelfBookRequest{ShelfId: 1}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("RemoveBookFromShelf() without a book error = %v, want InvalidArgument", err)
+	}
+}
+
+// TestShelfPermissions checks only the owner of a shelf sees and changes it, against a fake database
+func TestShelfPermissions(t *testing.T) {
+	pool, db := newFakeDatabase(t)
+	s := &server{db: pool}
+	alice := context.WithValue(context.Background(), userIDKey, 1)
+	bob := context.WithValue(context.Background(), userIDKey, 2)
+	// Shelf 3 is alice's
+	db.answer("FROM shelves WHERE id=('3') AND user_id=('1')", "SELECT 1", []any{true})
+	db.answer("SELECT EXISTS(SELECT 1 FROM shelves", "SELECT 1", []any{false})
+	db.answer("SELECT name, description, created_at FROM shelves", "SELECT 0")
+	db.answer("INSERT INTO shelves", "INSERT 0 1", []any{int64(3)})
+
+	if resp, err := s.CreateShelf(alice, &pb.Shelf{Name: " To read "}); err != nil || resp.GetId() != 3 {
+		t.Fatalf("CreateShelf() = %v, %v, want shelf 3", resp, err)
+	}
+	if !db.received("VALUES (('1'), ('To read'), (''))") {
+		t.Error("the shelf was not created as alice's with its name trimmed")
+	}
+
+	add := &pb.ShelfBookRequest{ShelfId: 3, BookId: "b1"}
+	if _, err := s.AddBookToShelf(bob, add); status.Code(err) != codes.NotFound {
+		t.Errorf("AddBookToShelf() on another user's shelf error = %v, want NotFound", err)
+	}
+	if _, err := s.RemoveBookFromShelf(bob, add); status.Code(err) != codes.NotFound {
+		t.Errorf("RemoveBookFromShelf() on another user's shelf error = %v, want NotFound", err)
+	}
+	if db.received("shelf_books") {
+		t.Error("the books of another user's shelf were changed")
+	}
+	// Shelves of other users are reported as missing
+	if _, err := s.GetShelf(bob, &pb.ShelfRequest{Id: 3}); status.Code(err) != codes.NotFound {
+		t.Errorf("GetShelf() of another user's shelf error = %v, want NotFound", err)
+	}
+	if !db.received("WHERE id=('3') AND user_id=('2')") {
+		t.Error("GetShelf() didn't look the shelf up as bob's")
+	}
+
+	db.answer("FROM books WHERE id=('b1')", "SELECT 1", []any{true})
+	db.answer("INSERT INTO shelf_books", "INSERT 0 1")
+	db.answer("DELETE FROM shelf_books", "DELETE 0")
+	if _, err := s.AddBookToShelf(alice, add); err != nil {
+		t.Errorf("AddBookToShelf() on alice's shelf error = %v", err)
+	}
+	if _, err := s.RemoveBookFromShelf(alice, &pb.ShelfBookRequest{ShelfId: 3, BookId: "b2"}); status.Code(err) != codes.NotFound {
+		t.Errorf("RemoveBookFromShelf() of a book not on the shelf error = %v, want NotFound", err)
+	}
+}
+
+// TestShelves runs against the database of STORAGE_TEST_DATABASE_URL
+func TestShelves(t *testing.T) {
+	pool := testDatabase(t, "shelves-test-")
+	s := &server{db: pool}
+	alice := testUser(t, pool, "shelves-test-alice")
+	bob := testUser(t, pool, "shelves-test-bob")
+	testBook(t, pool, "shelves-test-dune", 1)
+
+	shelf, err := s.CreateShelf(alice, &pb.Shelf{Name: " To read "})
+	if err != nil {
+		t.Fatalf("CreateShelf() error = %v", err)
+	}
+	if _, err := s.CreateShelf(alice, &pb.Shelf{Name: "To read"}); status.Code(err) != codes.AlreadyExists {
+		t.Errorf("CreateShelf() with a taken name error = %v, want AlreadyExists", err)
+	}
+	// Names are only unique per user
+	if _, err := s.CreateShelf(bob, &pb.Shelf{Name: "To read"}); err != nil {
+		t.Errorf("CreateShelf() by another user error = %v", err)
+	}
+
+	add := &pb.ShelfBookRequest{ShelfId: shelf.GetId(), BookId: "shelves-test-dune"}
+	if _, err := s.AddBookToShelf(bob, add); status.Code(err) != codes.NotFound {
+		t.Errorf("AddBookToShelf() on another user's shelf error = %v, want NotFound", err)
+	}
+	if _, err := s.AddBookToShelf(alice, &pb.ShelfBookRequest{ShelfId: shelf.GetId(), BookId: "shelves-test-missing"}); status.Code(err) != codes.NotFound {
+		t.Errorf("AddBookToShelf() of an unknown book error = %v, want NotFound", err)
+	}
+	if _, err := s.AddBookToShelf(alice, add); err != nil {
+		t.Fatalf("AddBookToShelf() error = %v", err)
+	}
+
+	got, err := s.GetShelf(alice, &pb.ShelfRequest{Id: shelf.GetId()})
+	if err != nil || got.GetName() != "To read" || got.GetBookCount() != 1 || got.GetBooks()[0].GetId() != "shelves-test-dune" {
+		t.Errorf("GetShelf() = %v, %v, want To read holding shelves-test-dune", got, err)
+	}
+	if _, err := s.GetShelf(bob, &pb.ShelfRequest{Id: shelf.GetId()}); status.Code(err) != codes.NotFound {
+		t.Errorf("GetShelf() of another user's shelf error = %v, want NotFound", err)
+	}
+	if resp, err := s.ListShelves(alice, &pb.ListShelvesRequest{}); err != nil || len(resp.GetShelves()) != 1 || resp.GetShelves()[0].GetBookCount() != 1 {
+		t.Errorf("ListShelves() = %v, %v, want one shelf of one book", resp, err)
+	}
+
+	if _, err := s.RemoveBookFromShelf(alice, add); err != nil {
+		t.Errorf("RemoveBookFromShelf() error = %v", err)
+	}
+	if _, err := s.RemoveBookFromShelf(alice, add); status.Code(err) != codes.NotFound {
+		t.Errorf("RemoveBookFromShelf() of a book not on the shelf error = %v, want NotFound", err)
+	}
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+)
+
+// maxSlowQueryLength bounds the statement text logged for a slow query, in bytes
+const maxSlowQueryLength = 1000
+
+type queryStartKey struct{}
+
+// queryStart is the statement a pgx call is running and when it started
+type queryStart struct {
+	sql string
+	at  time.Time
+}
+
+// reportSlowQuery logs a statement that took elapsed with the RPC and request it ran for, and counts it
+// in db_slow_queries_total. Arguments are left out, as they may hold passwords or personal data.
+// Statements run by background jobs have no method.
+func reportSlowQuery(ctx context.Context, sql string, elapsed time.Duration) {
+	method, ok := grpc.Method(ctx)
+	if !ok {
+		method = "background"
+	}
+	operation := sqlOperation(sql)
+	slowQueries.WithLabelValues(method, operation).Inc()
+	slog.WarnContext(ctx, "slow query",
+		"method", method,
+		"request_id", requestIDFromContext(ctx),
+		"operation", operation,
+		"duration", elapsed,
+		"sql", truncateUTF8(strings.Join(strings.Fields(sql), " "), maxSlowQueryLength))
+}
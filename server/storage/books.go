@@ -0,0 +1,684 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	pb "example/grpc_demo/library"
+
+	"github.com/jackc/pgx/v5"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// bookColumnNames are the books columns read by ScanBook, in scan order
+var bookColumnNames = []string{"id", "title", "author", "isbn", "cover_url", "publisher", "total_copies", "available_copies", "description",
+	"location_branch", "location_section", "location_shelf", "publisher_id", "created_by", "created_at", "updated_at"}
+
+// BookColumns is the column list read by ScanBook
+var BookColumns = strings.Join(bookColumnNames, ", ")
+
+// QualifiedBookColumns returns BookColumns prefixed with a table alias, for joins
+func QualifiedBookColumns(alias string) string {
+	cols := make([]string, len(bookColumnNames))
+	for i, c := range bookColumnNames {
+		cols[i] = alias + "." + c
+	}
+	return strings.Join(cols, ", ")
+}
+
+// ScanBook reads a single book selected (or returned) with BookColumns, and into extra the columns
+// selected after them
+func ScanBook(row pgx.Row, extra ...any) (*pb.Book, error) {
+	var b pb.Book
+	var loc pb.Location
+	var publisherID, ownerID *int32
+	var createdAt, updatedAt time.Time
+	dest := append([]any{&b.Id, &b.Title, &b.Author, &b.Isbn, &b.CoverUrl, &b.Publisher, &b.TotalCopies, &b.AvailableCopies, &b.Description,
+		&loc.Branch, &loc.Section, &loc.Shelf, &publisherID, &ownerID, &createdAt, &updatedAt}, extra...)
+	if err := row.Scan(dest...); err != nil {
+		return nil, err
+	}
+	b.CreatedAt, b.UpdatedAt = timestamppb.New(createdAt), timestamppb.New(updatedAt)
+	// A location with no fields set means "not recorded"
+	if loc.Branch != "" || loc.Section != "" || loc.Shelf != "" {
+		b.Location = &loc
+	}
+	if publisherID != nil {
+		b.PublisherId = *publisherID
+	}
+	if ownerID != nil {
+		b.OwnerId = *ownerID
+	}
+	return &b, nil
+}
+
+// CollectBooks scans every row selected with BookColumns and closes rows
+func CollectBooks(rows pgx.Rows) ([]*pb.Book, error) {
+	defer rows.Close()
+	var books []*pb.Book
+	for rows.Next() {
+		b, err := ScanBook(rows)
+		if err != nil {
+			return nil, err
+		}
+		books = append(books, b)
+	}
+	return books, rows.Err()
+}
+
+// MatchKey normalizes a title or author for duplicate matching: lowercase, letters and digits only.
+// It mirrors the regexp_replace(lower(...)) expression of FindByTitleAuthorKey and idx_books_title_author_key.
+func MatchKey(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, s)
+}
+
+// SearchTerms splits a search into the words it looks for: lowercase runs of letters and digits
+func SearchTerms(search string) []string {
+	return strings.FieldsFunc(strings.ToLower(search), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// prefixQuery is the tsquery matching every one of terms as a word prefix. Terms have letters and digits
+// only, so none of them needs quoting.
+func prefixQuery(terms []string) string {
+	parts := make([]string, len(terms))
+	for i, t := range terms {
+		parts[i] = t + ":*"
+	}
+	return strings.Join(parts, " & ")
+}
+
+// LoadBookDetails fills in the data stored outside the books table: tags, series and ratings
+func LoadBookDetails(ctx context.Context, q Querier, books []*pb.Book) error {
+	if err := loadBookTags(ctx, q, books); err != nil {
+		return err
+	}
+	if err := loadBookSeries(ctx, q, books); err != nil {
+		return err
+	}
+	return loadBookRatings(ctx, q, books)
+}
+
+// pgBooks is the BookRepository of a Postgres store
+type pgBooks struct {
+	db *Postgres
+}
+
+func (r pgBooks) Exists(ctx context.Context, id string) (bool, error) {
+	var exists bool
+	err := r.db.conn(ctx).QueryRow(ctx, "SELECT EXISTS(SELECT 1 FROM books WHERE tenant_id=$1 AND id=$2)", Tenant(ctx), id).Scan(&exists)
+	return exists, err
+}
+
+func (r pgBooks) FindByISBN(ctx context.Context, isbn string) (string, error) {
+	var id string
+	err := r.db.conn(ctx).QueryRow(ctx, "SELECT id FROM books WHERE tenant_id=$1 AND isbn=$2 ORDER BY id LIMIT 1", Tenant(ctx), isbn).Scan(&id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", nil
+	}
+	return id, err
+}
+
+func (r pgBooks) FindByTitleAuthorKey(ctx context.Context, titleKey, authorKey string) (string, error) {
+	var id string
+	err := r.db.conn(ctx).QueryRow(ctx,
+		`SELECT id FROM books
+		 WHERE tenant_id = $3 AND regexp_replace(lower(title), '[^[:alnum:]]', '', 'g') = $1
+			AND regexp_replace(lower(author), '[^[:alnum:]]', '', 'g') = $2
+		 ORDER BY id LIMIT 1`,
+		titleKey, authorKey, Tenant(ctx)).Scan(&id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", nil
+	}
+	return id, err
+}
+
+func (r pgBooks) Create(ctx context.Context, book *pb.Book) error {
+	book.OwnerId = int32(actorFromContext(ctx).UserID)
+	return r.db.InTx(ctx, func(ctx context.Context) error {
+		q := r.db.conn(ctx)
+		if err := resolvePublisher(ctx, q, book); err != nil {
+			return err
+		}
+		loc := book.GetLocation()
+		// A taken ID inserts nothing rather than failing, which would abort the transaction of ctx
+		var id string
+		err := q.QueryRow(ctx,
+			`INSERT INTO books (tenant_id, id, title, author, isbn, publisher, total_copies, available_copies, description, location_branch, location_section, location_shelf, publisher_id, created_by)
+			 VALUES ($14, $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, NULLIF($12, 0), NULLIF($13, 0))
+			 ON CONFLICT (tenant_id, id) DO NOTHING
+			 RETURNING id`,
+			book.GetId(), book.GetTitle(), book.GetAuthor(), book.GetIsbn(), book.GetPublisher(), book.GetTotalCopies(), book.GetAvailableCopies(),
+			book.GetDescription(), loc.GetBranch(), loc.GetSection(), loc.GetShelf(), book.GetPublisherId(), book.GetOwnerId(), Tenant(ctx)).Scan(&id)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrAlreadyExists
+		}
+		if err != nil {
+			return err
+		}
+		if book.GetSeries() != nil {
+			if err := setBookSeries(ctx, q, book.GetId(), book.GetSeries()); err != nil {
+				return err
+			}
+		}
+		return recordRevision(ctx, q, book.GetId(), pb.RevisionAction_REVISION_ACTION_CREATE, nil, book)
+	})
+}
+
+func (r pgBooks) Match(ctx context.Context, keys []BookKeys) ([]BookMatches, error) {
+	ids, isbns, titleKeys, authorKeys := make([]string, len(keys)), make([]string, len(keys)), make([]string, len(keys)), make([]string, len(keys))
+	for i, k := range keys {
+		ids[i], isbns[i], titleKeys[i], authorKeys[i] = k.ID, k.ISBN, k.TitleKey, k.AuthorKey
+	}
+	rows, err := r.db.conn(ctx).Query(ctx,
+		`SELECT EXISTS(SELECT 1 FROM books b WHERE b.tenant_id = $5 AND b.id = k.id),
+			COALESCE((SELECT b.id FROM books b WHERE b.tenant_id = $5 AND k.isbn <> '' AND b.isbn = k.isbn ORDER BY b.id LIMIT 1), ''),
+			COALESCE((SELECT b.id FROM books b
+				WHERE b.tenant_id = $5 AND k.title_key <> ''
+					AND regexp_replace(lower(b.title), '[^[:alnum:]]', '', 'g') = k.title_key
+					AND regexp_replace(lower(b.author), '[^[:alnum:]]', '', 'g') = k.author_key
+				ORDER BY b.id LIMIT 1), '')
+		 FROM unnest($1::text[], $2::text[], $3::text[], $4::text[]) WITH ORDINALITY AS k(id, isbn, title_key, author_key, n)
+		 ORDER BY k.n`,
+		ids, isbns, titleKeys, authorKeys, Tenant(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	matches := make([]BookMatches, 0, len(keys))
+	for rows.Next() {
+		var m BookMatches
+		if err := rows.Scan(&m.Exists, &m.ISBN, &m.TitleAuthor); err != nil {
+			return nil, err
+		}
+		matches = append(matches, m)
+	}
+	return matches, rows.Err()
+}
+
+func (r pgBooks) CreateMany(ctx context.Context, books []*pb.Book) ([]error, error) {
+	owner := int32(actorFromContext(ctx).UserID)
+	for _, b := range books {
+		b.OwnerId = owner
+	}
+	results := make([]error, len(books))
+	err := r.db.InTx(ctx, func(ctx context.Context) error {
+		q := r.db.conn(ctx)
+		clear(results)
+		if err := resolvePublishers(ctx, q, books, results); err != nil {
+			return err
+		}
+		seriesIDs, err := resolveSeries(ctx, q, books)
+		if err != nil {
+			return err
+		}
+
+		// Books given twice are stored once, like books already stored
+		var rows []*pb.Book
+		given := make(map[string]bool)
+		for i, b := range books {
+			if results[i] != nil {
+				continue
+			}
+			if given[b.GetId()] {
+				results[i] = ErrAlreadyExists
+				continue
+			}
+			given[b.GetId()] = true
+			rows = append(rows, b)
+		}
+		var (
+			ids, titles, authors, isbns, publishers, descriptions []string
+			branches, sections, shelves                           []string
+			totals, availables, publisherIDs, series, volumes     []int32
+		)
+		for _, b := range rows {
+			ids, titles, authors, isbns = append(ids, b.GetId()), append(titles, b.GetTitle()), append(authors, b.GetAuthor()), append(isbns, b.GetIsbn())
+			publishers, descriptions = append(publishers, b.GetPublisher()), append(descriptions, b.GetDescription())
+			loc := b.GetLocation()
+			branches, sections, shelves = append(branches, loc.GetBranch()), append(sections, loc.GetSection()), append(shelves, loc.GetShelf())
+			totals, availables, publisherIDs = append(totals, b.GetTotalCopies()), append(availables, b.GetAvailableCopies()), append(publisherIDs, b.GetPublisherId())
+			series, volumes = append(series, seriesIDs[b.GetSeries().GetName()]), append(volumes, b.GetSeries().GetVolume())
+		}
+		inserted, err := q.Query(ctx,
+			`INSERT INTO books (tenant_id, id, title, author, isbn, publisher, total_copies, available_copies, description, location_branch, location_section, location_shelf,
+				publisher_id, series_id, series_volume, created_by)
+			 SELECT $16::text, id, title, author, isbn, publisher, total_copies, available_copies, description, branch, section, shelf,
+				NULLIF(publisher_id, 0), NULLIF(series_id, 0), CASE WHEN series_volume > 0 THEN series_volume END, NULLIF($15::int, 0)
+			 FROM unnest($1::text[], $2::text[], $3::text[], $4::text[], $5::text[], $6::int[], $7::int[], $8::text[], $9::text[], $10::text[], $11::text[],
+				$12::int[], $13::int[], $14::int[])
+				AS b(id, title, author, isbn, publisher, total_copies, available_copies, description, branch, section, shelf, publisher_id, series_id, series_volume)
+			 ON CONFLICT (tenant_id, id) DO NOTHING
+			 RETURNING id`,
+			ids, titles, authors, isbns, publishers, totals, availables, descriptions, branches, sections, shelves, publisherIDs, series, volumes, owner, Tenant(ctx))
+		if err != nil {
+			return err
+		}
+		stored, err := pgx.CollectRows(inserted, pgx.RowTo[string])
+		if err != nil {
+			return err
+		}
+		created := make(map[string]bool, len(stored))
+		for _, id := range stored {
+			created[id] = true
+		}
+		var revisions []*pb.Book
+		for _, b := range rows {
+			if created[b.GetId()] {
+				revisions = append(revisions, b)
+			}
+		}
+		for i, b := range books {
+			if results[i] == nil && !created[b.GetId()] {
+				results[i] = ErrAlreadyExists
+			}
+		}
+		return recordCreations(ctx, q, revisions)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+func (r pgBooks) Get(ctx context.Context, id string, languages []string) (*pb.Book, error) {
+	q := r.db.conn(ctx)
+	book, err := ScanBook(q.QueryRow(ctx, "SELECT "+BookColumns+" FROM books WHERE tenant_id=$1 AND id=$2", Tenant(ctx), id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	books := []*pb.Book{book}
+	if err := LoadBookDetails(ctx, q, books); err != nil {
+		return nil, err
+	}
+	if err := localizeBooks(ctx, q, books, languages); err != nil {
+		return nil, err
+	}
+	return book, nil
+}
+
+func (r pgBooks) GetForUpdate(ctx context.Context, id string) (*pb.Book, error) {
+	q := r.db.conn(ctx)
+	book, err := ScanBook(q.QueryRow(ctx, "SELECT "+BookColumns+" FROM books WHERE tenant_id=$1 AND id=$2 FOR UPDATE", Tenant(ctx), id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := loadBookSeries(ctx, q, []*pb.Book{book}); err != nil {
+		return nil, err
+	}
+	return book, nil
+}
+
+// bookWhere returns the WHERE clause selecting the books of filter, with its arguments
+func bookWhere(filter BookFilter) (string, []any) {
+	var conds []string
+	var args []any
+	arg := func(v any) string {
+		args = append(args, v)
+		return "$" + strconv.Itoa(len(args))
+	}
+	if len(filter.Tags) > 0 {
+		conds = append(conds, "(tenant_id, id) IN (SELECT bt.tenant_id, bt.book_id FROM book_tags bt JOIN tags t ON t.id = bt.tag_id WHERE t.name = ANY("+
+			arg(filter.Tags)+") GROUP BY bt.tenant_id, bt.book_id HAVING COUNT(*) = "+arg(len(filter.Tags))+")")
+	}
+	if filter.Branch != "" {
+		conds = append(conds, "location_branch = "+arg(filter.Branch))
+	}
+	if filter.Section != "" {
+		conds = append(conds, "location_section = "+arg(filter.Section))
+	}
+	if filter.PublisherID != 0 {
+		conds = append(conds, "publisher_id = "+arg(filter.PublisherID))
+	}
+	if filter.Author != "" {
+		conds = append(conds, "author = "+arg(filter.Author))
+	}
+	if terms := SearchTerms(filter.Search); len(terms) > 0 {
+		conds = append(conds, "search_vector @@ to_tsquery('simple', "+arg(prefixQuery(terms))+")")
+	}
+	if len(filter.After) > 0 && len(SearchTerms(filter.Search)) == 0 {
+		conds = append(conds, filter.keyset().After(filter.After, arg))
+	}
+	if len(conds) == 0 {
+		return "", args
+	}
+	return " WHERE " + strings.Join(conds, " AND "), args
+}
+
+// tenantBookWhere returns the WHERE clause of bookWhere restricted to the books of the library tenant
+func tenantBookWhere(tenant string, filter BookFilter) (string, []any) {
+	where, args := bookWhere(filter)
+	args = append(args, tenant)
+	cond := "tenant_id = $" + strconv.Itoa(len(args))
+	if where == "" {
+		return " WHERE " + cond, args
+	}
+	return where + " AND " + cond, args
+}
+
+func (r pgBooks) List(ctx context.Context, filter BookFilter, languages []string) ([]*pb.Book, error) {
+	q := r.db.conn(ctx)
+	where, args := tenantBookWhere(Tenant(ctx), filter)
+	order := filter.keyset().OrderBy()
+	offset := filter.Offset
+	if terms := SearchTerms(filter.Search); len(terms) > 0 {
+		args = append(args, prefixQuery(terms))
+		order = " ORDER BY ts_rank(search_vector, to_tsquery('simple', $" + strconv.Itoa(len(args)) + ")) DESC, id"
+	} else if len(filter.After) > 0 {
+		offset = 0
+	}
+	args = append(args, filter.Limit, offset)
+	query := "SELECT " + BookColumns + " FROM books" + where + order + " LIMIT $" + strconv.Itoa(len(args)-1) + " OFFSET $" + strconv.Itoa(len(args))
+	rows, err := q.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	books, err := CollectBooks(rows)
+	if err != nil {
+		return nil, err
+	}
+	if err := LoadBookDetails(ctx, q, books); err != nil {
+		return nil, err
+	}
+	if err := localizeBooks(ctx, q, books, languages); err != nil {
+		return nil, err
+	}
+	return books, nil
+}
+
+func (r pgBooks) Each(ctx context.Context, fn func(*pb.Book) error) error {
+	rows, err := r.db.conn(ctx).Query(ctx, "SELECT "+BookColumns+" FROM books WHERE tenant_id=$1 ORDER BY id", Tenant(ctx))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		book, err := ScanBook(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(book); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func (r pgBooks) Count(ctx context.Context, filter BookFilter) (int32, error) {
+	filter.After = nil
+	where, args := tenantBookWhere(Tenant(ctx), filter)
+	var count int32
+	err := r.db.conn(ctx).QueryRow(ctx, "SELECT COUNT(*) FROM books"+where, args...).Scan(&count)
+	return count, err
+}
+
+// exactCountBelow is the estimate under which books are counted exactly: a small table is quick to count,
+// and its statistics, refreshed once enough of its rows changed, are then the least accurate
+const exactCountBelow = 10000
+
+// EstimateCount reads the planner's row estimate of the books table, kept by autovacuum and ANALYZE. The
+// estimate is of the books of every library, so books are counted once the database hosts several.
+func (r pgBooks) EstimateCount(ctx context.Context) (int32, bool, error) {
+	var estimate float64
+	var shared bool
+	err := r.db.conn(ctx).QueryRow(ctx,
+		"SELECT reltuples, EXISTS(SELECT 1 FROM tenants) FROM pg_class WHERE oid = 'books'::regclass").Scan(&estimate, &shared)
+	if err != nil {
+		return 0, false, err
+	}
+	// reltuples is -1 until the table is first vacuumed or analyzed
+	if estimate < exactCountBelow || shared {
+		count, err := r.Count(ctx, BookFilter{})
+		return count, true, err
+	}
+	return int32(min(estimate, math.MaxInt32)), false, nil
+}
+
+func (r pgBooks) Update(ctx context.Context, old, book *pb.Book) error {
+	return r.db.InTx(ctx, func(ctx context.Context) error {
+		q := r.db.conn(ctx)
+		owner, err := checkOwner(ctx, q, book.GetId())
+		if err != nil {
+			return err
+		}
+		book.OwnerId = owner
+		if err := resolvePublisher(ctx, q, book); err != nil {
+			return err
+		}
+		loc := book.GetLocation()
+		tag, err := q.Exec(ctx,
+			`UPDATE books SET title=$1, author=$2, isbn=$3, publisher=$4, total_copies=$5, available_copies=$6, description=$7,
+				location_branch=$8, location_section=$9, location_shelf=$10, publisher_id=NULLIF($11, 0)
+			 WHERE tenant_id=$13 AND id=$12`,
+			book.GetTitle(), book.GetAuthor(), book.GetIsbn(), book.GetPublisher(), book.GetTotalCopies(), book.GetAvailableCopies(), book.GetDescription(),
+			loc.GetBranch(), loc.GetSection(), loc.GetShelf(), book.GetPublisherId(), book.GetId(), Tenant(ctx))
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() == 0 {
+			return ErrNotFound
+		}
+		// Updates replace the whole book, so a missing series removes it from its series
+		if err := setBookSeries(ctx, q, book.GetId(), book.GetSeries()); err != nil {
+			return err
+		}
+		return recordRevision(ctx, q, book.GetId(), pb.RevisionAction_REVISION_ACTION_UPDATE, old, book)
+	})
+}
+
+func (r pgBooks) Delete(ctx context.Context, id string) (*pb.Book, error) {
+	var old *pb.Book
+	err := r.db.InTx(ctx, func(ctx context.Context) error {
+		q := r.db.conn(ctx)
+		if _, err := checkOwner(ctx, q, id); err != nil {
+			return err
+		}
+		var err error
+		old, err = ScanBook(q.QueryRow(ctx, "DELETE FROM books WHERE tenant_id=$1 AND id=$2 RETURNING "+BookColumns, Tenant(ctx), id))
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+		return recordRevision(ctx, q, id, pb.RevisionAction_REVISION_ACTION_DELETE, old, nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return old, nil
+}
+
+// checkOwner returns the owner of the stored book id, locking it until the unit of work of ctx ends, or
+// fails with ErrNotFound, or ErrNotOwner when the actor of ctx may not change it
+func checkOwner(ctx context.Context, q Querier, id string) (int32, error) {
+	var owner *int32
+	err := q.QueryRow(ctx, "SELECT created_by FROM books WHERE tenant_id=$1 AND id=$2 FOR UPDATE", Tenant(ctx), id).Scan(&owner)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, ErrNotFound
+	}
+	if err != nil {
+		return 0, err
+	}
+	if owner == nil {
+		owner = new(int32)
+	}
+	if !mayChange(ctx, *owner) {
+		return 0, ErrNotOwner
+	}
+	return *owner, nil
+}
+
+// resolvePublisher points a book at its publisher record before it is stored. A publisher ID wins and
+// its canonical name replaces the book's publisher; otherwise the name is matched case-insensitively,
+// creating the publisher on first use.
+func resolvePublisher(ctx context.Context, q Querier, book *pb.Book) error {
+	if id := book.GetPublisherId(); id != 0 {
+		var name string
+		err := q.QueryRow(ctx, "SELECT name FROM publishers WHERE tenant_id=$1 AND id=$2", Tenant(ctx), id).Scan(&name)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrPublisherNotFound
+		}
+		if err != nil {
+			return err
+		}
+		book.Publisher = name
+		return nil
+	}
+
+	name := strings.TrimSpace(book.GetPublisher())
+	if name == "" {
+		book.Publisher = ""
+		return nil
+	}
+	return q.QueryRow(ctx,
+		`INSERT INTO publishers (tenant_id, name) VALUES ($1, $2)
+		 ON CONFLICT (tenant_id, (lower(name))) DO UPDATE SET name=publishers.name RETURNING id, name`,
+		Tenant(ctx), name).Scan(&book.PublisherId, &book.Publisher)
+}
+
+// resolvePublishers resolves the publishers of books as resolvePublisher does, with two statements for all
+// of them, setting the result of each book whose publisher ID doesn't exist to ErrPublisherNotFound
+func resolvePublishers(ctx context.Context, q Querier, books []*pb.Book, results []error) error {
+	var ids []int32
+	var names []string
+	for _, b := range books {
+		if id := b.GetPublisherId(); id != 0 {
+			ids = append(ids, id)
+		} else if name := strings.TrimSpace(b.GetPublisher()); name != "" {
+			names = append(names, name)
+		}
+	}
+
+	known := make(map[int32]string)
+	if len(ids) > 0 {
+		rows, err := q.Query(ctx, "SELECT id, name FROM publishers WHERE tenant_id = $1 AND id = ANY($2)", Tenant(ctx), ids)
+		if err != nil {
+			return err
+		}
+		var id int32
+		var name string
+		if _, err := pgx.ForEachRow(rows, []any{&id, &name}, func() error {
+			known[id] = name
+			return nil
+		}); err != nil {
+			return err
+		}
+	}
+	// Names differing only in case are one publisher, created with the first of them
+	namedIDs, canonical := make(map[string]int32), make(map[string]string)
+	if len(names) > 0 {
+		rows, err := q.Query(ctx,
+			`WITH given AS (SELECT name, n FROM unnest($1::text[]) WITH ORDINALITY AS g(name, n)),
+			 upserted AS (
+				INSERT INTO publishers (tenant_id, name)
+				SELECT DISTINCT ON (lower(name)) $2::text, name FROM given ORDER BY lower(name), n
+				ON CONFLICT (tenant_id, (lower(name))) DO UPDATE SET name=publishers.name
+				RETURNING id, name)
+			 SELECT given.name, upserted.id, upserted.name FROM given JOIN upserted ON lower(upserted.name) = lower(given.name)`,
+			names, Tenant(ctx))
+		if err != nil {
+			return err
+		}
+		var given, name string
+		var id int32
+		if _, err := pgx.ForEachRow(rows, []any{&given, &id, &name}, func() error {
+			namedIDs[given], canonical[given] = id, name
+			return nil
+		}); err != nil {
+			return err
+		}
+	}
+
+	for i, b := range books {
+		if id := b.GetPublisherId(); id != 0 {
+			name, ok := known[id]
+			if !ok {
+				results[i] = ErrPublisherNotFound
+				continue
+			}
+			b.Publisher = name
+			continue
+		}
+		name := strings.TrimSpace(b.GetPublisher())
+		if name == "" {
+			b.Publisher = ""
+			continue
+		}
+		id, ok := namedIDs[name]
+		if !ok {
+			return fmt.Errorf("publisher %q was not resolved", name)
+		}
+		b.PublisherId, b.Publisher = id, canonical[name]
+	}
+	return nil
+}
+
+// resolveSeries creates the series of books on first use, with one statement, and returns their IDs by name
+func resolveSeries(ctx context.Context, q Querier, books []*pb.Book) (map[string]int32, error) {
+	var names []string
+	for _, b := range books {
+		if b.GetSeries() != nil {
+			names = append(names, b.GetSeries().GetName())
+		}
+	}
+	ids := make(map[string]int32)
+	if len(names) == 0 {
+		return ids, nil
+	}
+	rows, err := q.Query(ctx,
+		`INSERT INTO series (tenant_id, name) SELECT DISTINCT $2::text, unnest($1::text[])
+		 ON CONFLICT (tenant_id, name) DO UPDATE SET name=EXCLUDED.name RETURNING id, name`,
+		names, Tenant(ctx))
+	if err != nil {
+		return nil, err
+	}
+	var id int32
+	var name string
+	_, err = pgx.ForEachRow(rows, []any{&id, &name}, func() error {
+		ids[name] = id
+		return nil
+	})
+	return ids, err
+}
+
+// setBookSeries points a book at its series, creating the series on first use, or clears it when series is nil
+func setBookSeries(ctx context.Context, q Querier, bookID string, series *pb.BookSeries) error {
+	if series == nil {
+		_, err := q.Exec(ctx, "UPDATE books SET series_id=NULL, series_volume=NULL WHERE tenant_id=$1 AND id=$2", Tenant(ctx), bookID)
+		return err
+	}
+
+	var seriesID int32
+	err := q.QueryRow(ctx,
+		"INSERT INTO series (tenant_id, name) VALUES ($1, $2) ON CONFLICT (tenant_id, name) DO UPDATE SET name=EXCLUDED.name RETURNING id",
+		Tenant(ctx), series.GetName()).Scan(&seriesID)
+	if err != nil {
+		return err
+	}
+	var volume *int32
+	if series.GetVolume() > 0 {
+		v := series.GetVolume()
+		volume = &v
+	}
+	_, err = q.Exec(ctx, "UPDATE books SET series_id=$1, series_volume=$2 WHERE tenant_id=$3 AND id=$4", seriesID, volume, Tenant(ctx), bookID)
+	return err
+}
@@ -0,0 +1,71 @@
+package storage
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestBookWhere(t *testing.T) {
+	if where, args := bookWhere(BookFilter{Limit: 10}); where != "" || len(args) != 0 {
+		t.Errorf("bookWhere() of no filter = %q, %v", where, args)
+	}
+	for _, filter := range []BookFilter{{Limit: 10, NewestFirst: true}, {Search: "  "}, {Author: "Le Guin"}, {Tags: []string{"sf"}}, {Search: "dune"}} {
+		if where, _ := bookWhere(filter); filter.MatchesAll() != (where == "") {
+			t.Errorf("%+v: MatchesAll() = %t, bookWhere() = %q", filter, filter.MatchesAll(), where)
+		}
+	}
+
+	where, args := bookWhere(BookFilter{Tags: []string{"sf", "classic"}, Section: "Fiction", PublisherID: 3, Author: "Ursula K. Le Guin"})
+	want := " WHERE (tenant_id, id) IN (SELECT bt.tenant_id, bt.book_id FROM book_tags bt JOIN tags t ON t.id = bt.tag_id WHERE t.name = ANY($1)" +
+		" GROUP BY bt.tenant_id, bt.book_id HAVING COUNT(*) = $2)" +
+		" AND location_section = $3 AND publisher_id = $4 AND author = $5"
+	if where != want {
+		t.Errorf("bookWhere() = %q, want %q", where, want)
+	}
+	if len(args) != 5 || !slices.Equal(args[0].([]string), []string{"sf", "classic"}) || args[1] != 2 || args[2] != "Fiction" || args[3] != int32(3) ||
+		args[4] != "Ursula K. Le Guin" {
+		t.Errorf("bookWhere() args = %v", args)
+	}
+}
+
+func TestSearchWhere(t *testing.T) {
+	where, args := bookWhere(BookFilter{Branch: "Main", Search: "Tolkien's hob"})
+	if want := " WHERE location_branch = $1 AND search_vector @@ to_tsquery('simple', $2)"; where != want {
+		t.Errorf("bookWhere() = %q, want %q", where, want)
+	}
+	if len(args) != 2 || args[1] != "tolkien:* & s:* & hob:*" {
+		t.Errorf("bookWhere() args = %v", args)
+	}
+	// A search without words matches every book
+	if where, _ := bookWhere(BookFilter{Search: " & !:* "}); where != "" {
+		t.Errorf("bookWhere() of a search without words = %q", where)
+	}
+}
+
+func TestTenantBookWhere(t *testing.T) {
+	if where, args := tenantBookWhere("acme", BookFilter{}); where != " WHERE tenant_id = $1" || len(args) != 1 || args[0] != "acme" {
+		t.Errorf("tenantBookWhere() of no filter = %q, %v", where, args)
+	}
+	where, args := tenantBookWhere("", BookFilter{Author: "Le Guin"})
+	if want := " WHERE author = $1 AND tenant_id = $2"; where != want || len(args) != 2 || args[1] != "" {
+		t.Errorf("tenantBookWhere() = %q, %v, want %q", where, args, want)
+	}
+}
+
+func TestMatchKey(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"The Go Programming Language", "thegoprogramminglanguage"},
+		{"  the go-programming language! ", "thegoprogramminglanguage"},
+		{"Donovan, Alan A. A.", "donovanalanaa"},
+		{"Café 2", "café2"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := MatchKey(tt.in); got != tt.want {
+			t.Errorf("MatchKey(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package storage
+
+import (
+	"context"
+
+	pb "example/grpc_demo/library"
+)
+
+// booksByID indexes books by ID, returning the IDs in order
+func booksByID(books []*pb.Book) (map[string]*pb.Book, []string) {
+	byID := make(map[string]*pb.Book, len(books))
+	ids := make([]string, 0, len(books))
+	for _, b := range books {
+		byID[b.GetId()] = b
+		ids = append(ids, b.GetId())
+	}
+	return byID, ids
+}
+
+// loadBookTags fills in the tags of each book with a single query
+func loadBookTags(ctx context.Context, q Querier, books []*pb.Book) error {
+	if len(books) == 0 {
+		return nil
+	}
+	byID, ids := booksByID(books)
+
+	rows, err := q.Query(ctx,
+		"SELECT bt.book_id, t.name FROM book_tags bt JOIN tags t ON t.id = bt.tag_id WHERE bt.book_id = ANY($1) ORDER BY t.name",
+		ids)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var bookID, tag string
+		if err := rows.Scan(&bookID, &tag); err != nil {
+			return err
+		}
+		if b, ok := byID[bookID]; ok {
+			b.Tags = append(b.Tags, tag)
+		}
+	}
+	return rows.Err()
+}
+
+// loadBookSeries fills in the series of each book
+func loadBookSeries(ctx context.Context, q Querier, books []*pb.Book) error {
+	if len(books) == 0 {
+		return nil
+	}
+	byID, ids := booksByID(books)
+
+	rows, err := q.Query(ctx,
+		"SELECT b.id, s.name, COALESCE(b.series_volume, 0) FROM books b JOIN series s ON s.id = b.series_id WHERE b.id = ANY($1)",
+		ids)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var bookID string
+		series := &pb.BookSeries{}
+		if err := rows.Scan(&bookID, &series.Name, &series.Volume); err != nil {
+			return err
+		}
+		if b, ok := byID[bookID]; ok {
+			b.Series = series
+		}
+	}
+	return rows.Err()
+}
+
+// loadBookRatings fills in the average rating and review count of each book
+func loadBookRatings(ctx context.Context, q Querier, books []*pb.Book) error {
+	if len(books) == 0 {
+		return nil
+	}
+	byID, ids := booksByID(books)
+
+	rows, err := q.Query(ctx,
+		"SELECT book_id, AVG(rating)::float8, COUNT(*) FROM reviews WHERE book_id = ANY($1) GROUP BY book_id",
+		ids)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var bookID string
+		var avg float64
+		var count int32
+		if err := rows.Scan(&bookID, &avg, &count); err != nil {
+			return err
+		}
+		if b, ok := byID[bookID]; ok {
+			b.AverageRating = avg
+			b.ReviewCount = count
+		}
+	}
+	return rows.Err()
+}
+
+// localizeBooks replaces each book's title and description with its best translation in languages
+func localizeBooks(ctx context.Context, q Querier, books []*pb.Book, languages []string) error {
+	if len(books) == 0 || len(languages) == 0 {
+		return nil
+	}
+	byID, ids := booksByID(books)
+	rank := make(map[string]int, len(languages))
+	for i, p := range languages {
+		rank[p] = i
+	}
+
+	rows, err := q.Query(ctx,
+		"SELECT book_id, language, title, description FROM book_translations WHERE book_id = ANY($1) AND language = ANY($2)",
+		ids, languages)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	best := make(map[string]*pb.BookTranslation)
+	for rows.Next() {
+		t := &pb.BookTranslation{}
+		if err := rows.Scan(&t.BookId, &t.Language, &t.Title, &t.Description); err != nil {
+			return err
+		}
+		if cur, ok := best[t.GetBookId()]; !ok || rank[t.GetLanguage()] < rank[cur.GetLanguage()] {
+			best[t.GetBookId()] = t
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for id, t := range best {
+		b := byID[id]
+		b.Title = t.GetTitle()
+		// An untranslated description keeps the original
+		if t.GetDescription() != "" {
+			b.Description = t.GetDescription()
+		}
+		b.Language = t.GetLanguage()
+	}
+	return nil
+}
@@ -0,0 +1,522 @@
+package storage
+
+import (
+	"cmp"
+	"context"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	pb "example/grpc_demo/library"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Memory keeps books and users in memory, for tests and demo mode. It is safe for concurrent use. Units
+// of work run one at a time, and the changes of one that fails are undone; calls outside of a unit of
+// work see the changes of the one running.
+type Memory struct {
+	// work is held by the running unit of work
+	work sync.Mutex
+
+	mu              sync.Mutex
+	books           map[string]*pb.Book
+	users           map[string]*memoryUser
+	publishers      map[int32]string
+	lastUserID      int
+	lastPublisherID int32
+	revisions       []memoryRevision
+	// added orders books by when they were created, as created_at does in Postgres
+	added     map[string]uint64
+	lastAdded uint64
+}
+
+// memoryRevision is a change of a book, as recorded in book_revisions by Postgres
+type memoryRevision struct {
+	bookID   string
+	action   pb.RevisionAction
+	actor    Actor
+	old, new *pb.Book
+	at       time.Time
+}
+
+// memoryState is what a unit of work restores when it fails. Stored books and users are never modified
+// in place, so copies of the maps suffice.
+type memoryState struct {
+	books           map[string]*pb.Book
+	users           map[string]*memoryUser
+	publishers      map[int32]string
+	lastUserID      int
+	lastPublisherID int32
+	revisions       int
+	added           map[string]uint64
+	lastAdded       uint64
+}
+
+// NewMemory returns an empty in-memory store
+func NewMemory() *Memory {
+	return &Memory{books: make(map[string]*pb.Book), users: make(map[string]*memoryUser), publishers: make(map[int32]string),
+		added: make(map[string]uint64)}
+}
+
+// Books returns the book repository of the store
+func (m *Memory) Books() BookRepository {
+	return memBooks{m}
+}
+
+// Users returns the user repository of the store
+func (m *Memory) Users() UserRepository {
+	return memUsers{m}
+}
+
+// memoryTxKey marks the contexts of the unit of work of a store, which may be nested in that of another
+type memoryTxKey struct{ m *Memory }
+
+// InTx runs fn once the running unit of work, if any, ends
+func (m *Memory) InTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if ctx.Value(memoryTxKey{m}) != nil {
+		return fn(ctx)
+	}
+	m.work.Lock()
+	defer m.work.Unlock()
+
+	m.mu.Lock()
+	saved := memoryState{
+		books:           maps.Clone(m.books),
+		users:           maps.Clone(m.users),
+		publishers:      maps.Clone(m.publishers),
+		lastUserID:      m.lastUserID,
+		lastPublisherID: m.lastPublisherID,
+		revisions:       len(m.revisions),
+		added:           maps.Clone(m.added),
+		lastAdded:       m.lastAdded,
+	}
+	m.mu.Unlock()
+
+	err := fn(context.WithValue(ctx, memoryTxKey{m}, true))
+	if err != nil {
+		m.mu.Lock()
+		m.books, m.users, m.publishers = saved.books, saved.users, saved.publishers
+		m.lastUserID, m.lastPublisherID = saved.lastUserID, saved.lastPublisherID
+		m.revisions = m.revisions[:saved.revisions]
+		m.added, m.lastAdded = saved.added, saved.lastAdded
+		m.mu.Unlock()
+	}
+	return err
+}
+
+// memBooks is the BookRepository of a Memory store
+type memBooks struct {
+	m *Memory
+}
+
+// cloneBook copies a book, as stored or as returned
+func cloneBook(book *pb.Book) *pb.Book {
+	return proto.Clone(book).(*pb.Book)
+}
+
+// storedBook returns the copy of book that is stored: the data kept elsewhere by Postgres, such as tags and
+// ratings, and the fields of requests are left out
+func storedBook(book *pb.Book) *pb.Book {
+	b := cloneBook(book)
+	b.Tags, b.AverageRating, b.ReviewCount, b.Language, b.AllowDuplicate = nil, 0, 0, "", false
+	return b
+}
+
+func (r memBooks) Exists(ctx context.Context, id string) (bool, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	_, ok := r.m.books[id]
+	return ok, nil
+}
+
+// findBook returns the lowest ID of the books matching match, or ""
+func (m *Memory) findBook(match func(*pb.Book) bool) string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var found string
+	for id, b := range m.books {
+		if match(b) && (found == "" || id < found) {
+			found = id
+		}
+	}
+	return found
+}
+
+func (r memBooks) FindByISBN(ctx context.Context, isbn string) (string, error) {
+	return r.m.findBook(func(b *pb.Book) bool { return b.GetIsbn() == isbn }), nil
+}
+
+func (r memBooks) FindByTitleAuthorKey(ctx context.Context, titleKey, authorKey string) (string, error) {
+	return r.m.findBook(func(b *pb.Book) bool {
+		return MatchKey(b.GetTitle()) == titleKey && MatchKey(b.GetAuthor()) == authorKey
+	}), nil
+}
+
+// resolvePublisher is resolvePublisher of Postgres, on the publishers of m; m.mu is held
+func (m *Memory) resolvePublisher(book *pb.Book) error {
+	if id := book.GetPublisherId(); id != 0 {
+		name, ok := m.publishers[id]
+		if !ok {
+			return ErrPublisherNotFound
+		}
+		book.Publisher = name
+		return nil
+	}
+
+	name := strings.TrimSpace(book.GetPublisher())
+	if name == "" {
+		book.Publisher = ""
+		return nil
+	}
+	for id, existing := range m.publishers {
+		if strings.EqualFold(existing, name) {
+			book.PublisherId, book.Publisher = id, existing
+			return nil
+		}
+	}
+	m.lastPublisherID++
+	m.publishers[m.lastPublisherID] = name
+	book.PublisherId, book.Publisher = m.lastPublisherID, name
+	return nil
+}
+
+// record adds a revision made by the actor of ctx; m.mu is held
+func (m *Memory) record(ctx context.Context, bookID string, action pb.RevisionAction, old, new *pb.Book) {
+	m.revisions = append(m.revisions, memoryRevision{bookID: bookID, action: action, actor: actorFromContext(ctx), old: old, new: new, at: time.Now()})
+}
+
+func (r memBooks) Create(ctx context.Context, book *pb.Book) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	if _, ok := r.m.books[book.GetId()]; ok {
+		return ErrAlreadyExists
+	}
+	if err := r.m.resolvePublisher(book); err != nil {
+		return err
+	}
+	book.OwnerId = int32(actorFromContext(ctx).UserID)
+	b := storedBook(book)
+	b.CoverUrl = ""
+	b.CreatedAt = timestamppb.Now()
+	b.UpdatedAt = b.GetCreatedAt()
+	r.m.books[b.GetId()] = b
+	r.m.lastAdded++
+	r.m.added[b.GetId()] = r.m.lastAdded
+	r.m.record(ctx, b.GetId(), pb.RevisionAction_REVISION_ACTION_CREATE, nil, cloneBook(book))
+	return nil
+}
+
+func (r memBooks) Match(ctx context.Context, keys []BookKeys) ([]BookMatches, error) {
+	matches := make([]BookMatches, len(keys))
+	for i, k := range keys {
+		matches[i].Exists, _ = r.Exists(ctx, k.ID)
+		if k.ISBN != "" {
+			matches[i].ISBN, _ = r.FindByISBN(ctx, k.ISBN)
+		}
+		if k.TitleKey != "" {
+			matches[i].TitleAuthor, _ = r.FindByTitleAuthorKey(ctx, k.TitleKey, k.AuthorKey)
+		}
+	}
+	return matches, nil
+}
+
+func (r memBooks) CreateMany(ctx context.Context, books []*pb.Book) ([]error, error) {
+	results := make([]error, len(books))
+	for i, b := range books {
+		results[i] = r.Create(ctx, b)
+	}
+	return results, nil
+}
+
+func (r memBooks) Get(ctx context.Context, id string, languages []string) (*pb.Book, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	b, ok := r.m.books[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return cloneBook(b), nil
+}
+
+func (r memBooks) GetForUpdate(ctx context.Context, id string) (*pb.Book, error) {
+	return r.Get(ctx, id, nil)
+}
+
+// matches reports whether book is selected by filter. Tags aren't kept in memory, so no book has any.
+func (f BookFilter) matches(book *pb.Book) bool {
+	return len(f.Tags) == 0 &&
+		(f.Branch == "" || book.GetLocation().GetBranch() == f.Branch) &&
+		(f.Section == "" || book.GetLocation().GetSection() == f.Section) &&
+		(f.PublisherID == 0 || book.GetPublisherId() == f.PublisherID) &&
+		(f.Author == "" || book.GetAuthor() == f.Author) &&
+		searchRank(book, SearchTerms(f.Search)) > 0
+}
+
+// searchRank scores how well book matches terms, as the weights of the search_vector column of Postgres do:
+// 0 when a term starts no word of the book, and 1 without terms
+func searchRank(book *pb.Book, terms []string) float64 {
+	fields := []struct {
+		words  []string
+		weight float64
+	}{
+		{SearchTerms(book.GetTitle()), 1},
+		{SearchTerms(book.GetAuthor()), 0.4},
+		{SearchTerms(book.GetDescription()), 0.2},
+	}
+	rank := 1.0
+	for _, term := range terms {
+		best := 0.0
+		for _, f := range fields {
+			if f.weight > best && slices.ContainsFunc(f.words, func(w string) bool { return strings.HasPrefix(w, term) }) {
+				best = f.weight
+			}
+		}
+		if best == 0 {
+			return 0
+		}
+		rank += best
+	}
+	return rank
+}
+
+// filtered returns the books of filter in the order of List; m.mu is held
+func (m *Memory) filtered(filter BookFilter) []*pb.Book {
+	var books []*pb.Book
+	for _, b := range m.books {
+		if filter.matches(b) {
+			books = append(books, b)
+		}
+	}
+	terms := SearchTerms(filter.Search)
+	slices.SortFunc(books, func(a, b *pb.Book) int {
+		if len(terms) > 0 {
+			if c := cmp.Compare(searchRank(b, terms), searchRank(a, terms)); c != 0 {
+				return c
+			}
+		} else if filter.NewestFirst {
+			return cmp.Compare(m.added[b.GetId()], m.added[a.GetId()])
+		}
+		return strings.Compare(a.GetId(), b.GetId())
+	})
+	return books
+}
+
+// pageStart returns the index in books, sorted by filter, of the first book after the key filter.After
+func pageStart(books []*pb.Book, filter BookFilter) int {
+	id, _ := filter.After[len(filter.After)-1].(string)
+	if i := slices.IndexFunc(books, func(b *pb.Book) bool { return b.GetId() == id }); i >= 0 {
+		return i + 1
+	}
+	// The book was deleted since: the page starts with the first book its key sorts before
+	if !filter.NewestFirst {
+		i, _ := slices.BinarySearchFunc(books, id, func(b *pb.Book, id string) int { return strings.Compare(b.GetId(), id) })
+		return i
+	}
+	createdAt, _ := filter.After[0].(string)
+	after, err := time.Parse(time.RFC3339Nano, createdAt)
+	if err != nil {
+		return len(books)
+	}
+	i := slices.IndexFunc(books, func(b *pb.Book) bool {
+		if c := b.GetCreatedAt().AsTime().Compare(after); c != 0 {
+			return c < 0
+		}
+		return b.GetId() < id
+	})
+	if i < 0 {
+		return len(books)
+	}
+	return i
+}
+
+func (r memBooks) List(ctx context.Context, filter BookFilter, languages []string) ([]*pb.Book, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	books := r.m.filtered(filter)
+	start := min(int(max(filter.Offset, 0)), len(books))
+	if len(filter.After) > 0 && len(SearchTerms(filter.Search)) == 0 {
+		start = pageStart(books, filter)
+	}
+	end := min(start+int(max(filter.Limit, 0)), len(books))
+	page := make([]*pb.Book, 0, end-start)
+	for _, b := range books[start:end] {
+		page = append(page, cloneBook(b))
+	}
+	return page, nil
+}
+
+// Each calls fn with a copy of the books stored when it was called, so that fn may change them
+func (r memBooks) Each(ctx context.Context, fn func(*pb.Book) error) error {
+	r.m.mu.Lock()
+	books := r.m.filtered(BookFilter{})
+	for i, b := range books {
+		books[i] = cloneBook(b)
+	}
+	r.m.mu.Unlock()
+	for _, b := range books {
+		if err := fn(b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r memBooks) Count(ctx context.Context, filter BookFilter) (int32, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	return int32(len(r.m.filtered(filter))), nil
+}
+
+// EstimateCount counts the books, which costs no more than an estimate in memory
+func (r memBooks) EstimateCount(ctx context.Context) (int32, bool, error) {
+	count, err := r.Count(ctx, BookFilter{})
+	return count, true, err
+}
+
+func (r memBooks) Update(ctx context.Context, old, book *pb.Book) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	current, ok := r.m.books[book.GetId()]
+	if !ok {
+		return ErrNotFound
+	}
+	if !mayChange(ctx, current.GetOwnerId()) {
+		return ErrNotOwner
+	}
+	if err := r.m.resolvePublisher(book); err != nil {
+		return err
+	}
+	book.OwnerId = current.GetOwnerId()
+	b := storedBook(book)
+	b.CoverUrl = current.GetCoverUrl()
+	b.CreatedAt, b.UpdatedAt = current.GetCreatedAt(), timestamppb.Now()
+	r.m.books[b.GetId()] = b
+	r.m.record(ctx, b.GetId(), pb.RevisionAction_REVISION_ACTION_UPDATE, cloneBook(old), cloneBook(book))
+	return nil
+}
+
+func (r memBooks) Delete(ctx context.Context, id string) (*pb.Book, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	b, ok := r.m.books[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	if !mayChange(ctx, b.GetOwnerId()) {
+		return nil, ErrNotOwner
+	}
+	delete(r.m.books, id)
+	delete(r.m.added, id)
+	// Postgres returns the row alone, without the series kept in another table
+	old := cloneBook(b)
+	old.Series = nil
+	r.m.record(ctx, id, pb.RevisionAction_REVISION_ACTION_DELETE, old, nil)
+	return cloneBook(old), nil
+}
+
+// History numbers revisions by their position among those of every book, as the IDs of book_revisions do
+func (r memBooks) History(ctx context.Context, id string) ([]*pb.BookRevision, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	var revisions []*pb.BookRevision
+	for i, rev := range r.m.revisions {
+		if rev.bookID != id {
+			continue
+		}
+		revisions = append(revisions, &pb.BookRevision{
+			Id: int64(i + 1), BookId: id, Action: rev.action, UserId: int32(rev.actor.UserID), Username: rev.actor.Name,
+			ChangedAt: timestamppb.New(rev.at), OldBook: cloneBook(rev.old), NewBook: cloneBook(rev.new),
+		})
+	}
+	return revisions, nil
+}
+
+// memUsers is the UserRepository of a Memory store
+type memUsers struct {
+	m *Memory
+}
+
+// memoryUser is a stored account with its email address, which is never verified in memory
+type memoryUser struct {
+	User
+	email string
+}
+
+func (r memUsers) UsernameExists(ctx context.Context, username string) (bool, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	_, ok := r.m.users[username]
+	return ok, nil
+}
+
+func (r memUsers) EmailExists(ctx context.Context, email string) (bool, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	return r.m.emailTaken(email), nil
+}
+
+// emailTaken reports whether a user has the address, compared case-insensitively; m.mu is held
+func (m *Memory) emailTaken(email string) bool {
+	for _, u := range m.users {
+		if u.email != "" && strings.EqualFold(u.email, email) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r memUsers) Create(ctx context.Context, user NewUser) (int, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	if _, ok := r.m.users[user.Username]; ok || (user.Email != "" && r.m.emailTaken(user.Email)) {
+		return 0, ErrAlreadyExists
+	}
+	r.m.lastUserID++
+	r.m.users[user.Username] = &memoryUser{
+		User: User{
+			ID:              r.m.lastUserID,
+			Username:        user.Username,
+			PasswordHash:    user.PasswordHash,
+			State:           user.State,
+			EmailUnverified: user.Email != "",
+		},
+		email: user.Email,
+	}
+	return r.m.lastUserID, nil
+}
+
+func (r memUsers) GetByUsername(ctx context.Context, username string) (*User, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	u, ok := r.m.users[username]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	found := u.User
+	return &found, nil
+}
+
+func (r memUsers) GetByID(ctx context.Context, id int) (*User, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	for _, u := range r.m.users {
+		if u.ID == id {
+			found := u.User
+			found.PasswordHash, found.Role = "", "user"
+			return &found, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// Revocation reports nothing revoked, as sessions aren't kept in memory
+func (r memUsers) Revocation(ctx context.Context, id int, tokenID string, sessionID int64, checkToken bool) (*Revocation, error) {
+	if _, err := r.GetByID(ctx, id); err != nil {
+		return nil, err
+	}
+	return &Revocation{}, nil
+}
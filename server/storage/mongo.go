@@ -0,0 +1,782 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	pb "example/grpc_demo/library"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Mongo keeps books and users as documents in a MongoDB database. Units of work are multi-document
+// transactions, which need a replica set (a single-node one will do) running MongoDB 4.4 or later.
+//
+// The catalog is searched with a $text index on the title, author and description of books, weighted as
+// the search_vector column of Postgres. Unlike Postgres, which matches words starting with each term, it
+// matches whole words only: "tolk" finds no book by Tolkien.
+type Mongo struct {
+	db *mongo.Database
+}
+
+// textWeights are the weights of the fields of the books' text index, those of the search_vector column of
+// Postgres scaled to integers. Words are indexed as written, with no stemming or stop words, as Postgres'
+// simple configuration does.
+var textWeights = bson.D{{Key: "title", Value: 10}, {Key: "author", Value: 4}, {Key: "description", Value: 2}}
+
+// NewMongo returns the store of db, creating its indexes when they are missing
+func NewMongo(ctx context.Context, db *mongo.Database) (*Mongo, error) {
+	indexes := map[string][]mongo.IndexModel{
+		"books": {
+			{Keys: bson.D{{Key: "isbn", Value: 1}}},
+			{Keys: bson.D{{Key: "title_key", Value: 1}, {Key: "author_key", Value: 1}}},
+			{Keys: bson.D{{Key: "title", Value: "text"}, {Key: "author", Value: "text"}, {Key: "description", Value: "text"}},
+				Options: options.Index().SetWeights(textWeights).SetDefaultLanguage("none")},
+			{Keys: bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}},
+			{Keys: bson.D{{Key: "author", Value: 1}}},
+			{Keys: bson.D{{Key: "publisher_id", Value: 1}}},
+			{Keys: bson.D{{Key: "location.branch", Value: 1}, {Key: "location.section", Value: 1}}},
+		},
+		"publishers": {
+			{Keys: bson.D{{Key: "key", Value: 1}}, Options: options.Index().SetUnique(true)},
+		},
+		"users": {
+			{Keys: bson.D{{Key: "username", Value: 1}}, Options: options.Index().SetUnique(true)},
+			{Keys: bson.D{{Key: "email_key", Value: 1}},
+				Options: options.Index().SetUnique(true).SetPartialFilterExpression(bson.D{{Key: "email_key", Value: bson.D{{Key: "$exists", Value: true}}}})},
+		},
+		"book_revisions": {
+			{Keys: bson.D{{Key: "book_id", Value: 1}, {Key: "created_at", Value: 1}}},
+		},
+		"outbox": {
+			{Keys: bson.D{{Key: "sent_at", Value: 1}, {Key: "_id", Value: 1}}},
+			{Keys: bson.D{{Key: "sent_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(int32(sentEventRetention / time.Second))},
+		},
+	}
+	for collection, models := range indexes {
+		if _, err := db.Collection(collection).Indexes().CreateMany(ctx, models); err != nil {
+			return nil, err
+		}
+	}
+	return &Mongo{db: db}, nil
+}
+
+// Books returns the book repository of the store
+func (m *Mongo) Books() BookRepository {
+	return mongoBooks{m}
+}
+
+// Users returns the user repository of the store
+func (m *Mongo) Users() UserRepository {
+	return mongoUsers{m}
+}
+
+// InTx runs fn in a transaction of a session carried by the context it is given. fn runs once: the
+// transaction isn't retried when it fails with a transient error, such as a write conflict with another
+// one, as the driver's WithTransaction would, since fn may have effects outside it, such as on the
+// PostgreSQL transaction of a split store or on a stream it reads. The unit of work fails instead.
+func (m *Mongo) InTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if mongo.SessionFromContext(ctx) != nil {
+		return fn(ctx)
+	}
+	return m.db.Client().UseSession(ctx, func(sc mongo.SessionContext) error {
+		if err := sc.StartTransaction(); err != nil {
+			return err
+		}
+		if err := fn(sc); err != nil {
+			sc.AbortTransaction(context.WithoutCancel(sc))
+			return err
+		}
+		return sc.CommitTransaction(sc)
+	})
+}
+
+// nextID returns the next value of the counter name, the IDs of users, publishers and events. The counter is
+// incremented outside the unit of work of ctx, as a PostgreSQL sequence is, so that concurrent units of
+// work don't conflict on it; values of units of work rolled back are skipped.
+func (m *Mongo) nextID(ctx context.Context, name string) (int64, error) {
+	var counter struct {
+		Seq int64 `bson:"seq"`
+	}
+	err := m.db.Collection("counters").FindOneAndUpdate(withoutSession(ctx), bson.D{{Key: "_id", Value: name}}, bson.D{{Key: "$inc", Value: bson.D{{Key: "seq", Value: 1}}}},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)).Decode(&counter)
+	return counter.Seq, err
+}
+
+// withoutSession returns ctx without the session of its unit of work, for operations outside it
+func withoutSession(ctx context.Context) context.Context {
+	return mongo.NewSessionContext(ctx, nil)
+}
+
+// mongoBook is a book as stored in the books collection
+type mongoBook struct {
+	ID              string         `bson:"_id"`
+	Title           string         `bson:"title"`
+	Author          string         `bson:"author"`
+	ISBN            string         `bson:"isbn"`
+	CoverURL        string         `bson:"cover_url"`
+	Publisher       string         `bson:"publisher"`
+	PublisherID     int32          `bson:"publisher_id,omitempty"`
+	OwnerID         int32          `bson:"owner_id,omitempty"`
+	TotalCopies     int32          `bson:"total_copies"`
+	AvailableCopies int32          `bson:"available_copies"`
+	Description     string         `bson:"description"`
+	Location        *mongoLocation `bson:"location,omitempty"`
+	Series          *mongoSeries   `bson:"series,omitempty"`
+	// TitleKey and AuthorKey are the match keys of FindByTitleAuthorKey
+	TitleKey  string    `bson:"title_key"`
+	AuthorKey string    `bson:"author_key"`
+	CreatedAt time.Time `bson:"created_at"`
+	UpdatedAt time.Time `bson:"updated_at"`
+}
+
+type mongoLocation struct {
+	Branch  string `bson:"branch"`
+	Section string `bson:"section"`
+	Shelf   string `bson:"shelf"`
+}
+
+type mongoSeries struct {
+	Name   string `bson:"name"`
+	Volume int32  `bson:"volume"`
+}
+
+// newMongoBook returns the document of book, whose publisher is resolved
+func newMongoBook(book *pb.Book) *mongoBook {
+	d := &mongoBook{
+		ID: book.GetId(), Title: book.GetTitle(), Author: book.GetAuthor(), ISBN: book.GetIsbn(), CoverURL: book.GetCoverUrl(),
+		Publisher: book.GetPublisher(), PublisherID: book.GetPublisherId(), OwnerID: book.GetOwnerId(), TotalCopies: book.GetTotalCopies(),
+		AvailableCopies: book.GetAvailableCopies(), Description: book.GetDescription(),
+		TitleKey: MatchKey(book.GetTitle()), AuthorKey: MatchKey(book.GetAuthor()),
+	}
+	// A location with no fields set means "not recorded", as in Postgres
+	if loc := book.GetLocation(); loc.GetBranch() != "" || loc.GetSection() != "" || loc.GetShelf() != "" {
+		d.Location = &mongoLocation{Branch: loc.GetBranch(), Section: loc.GetSection(), Shelf: loc.GetShelf()}
+	}
+	if s := book.GetSeries(); s.GetName() != "" {
+		d.Series = &mongoSeries{Name: s.GetName(), Volume: s.GetVolume()}
+	}
+	return d
+}
+
+// book returns the book of the document
+func (d *mongoBook) book() *pb.Book {
+	b := &pb.Book{
+		Id: d.ID, Title: d.Title, Author: d.Author, Isbn: d.ISBN, CoverUrl: d.CoverURL, Publisher: d.Publisher,
+		PublisherId: d.PublisherID, OwnerId: d.OwnerID, TotalCopies: d.TotalCopies, AvailableCopies: d.AvailableCopies, Description: d.Description,
+		CreatedAt: timestamppb.New(d.CreatedAt), UpdatedAt: timestamppb.New(d.UpdatedAt),
+	}
+	if d.Location != nil {
+		b.Location = &pb.Location{Branch: d.Location.Branch, Section: d.Location.Section, Shelf: d.Location.Shelf}
+	}
+	if d.Series != nil {
+		b.Series = &pb.BookSeries{Name: d.Series.Name, Volume: d.Series.Volume}
+	}
+	return b
+}
+
+// mongoBooks is the BookRepository of a Mongo store. Tags, ratings and translations are kept by Postgres
+// only, so no book has any.
+type mongoBooks struct {
+	m *Mongo
+}
+
+func (r mongoBooks) books() *mongo.Collection {
+	return r.m.db.Collection("books")
+}
+
+// findID returns the lowest ID of the books matching filter, or ""
+func (r mongoBooks) findID(ctx context.Context, filter bson.D) (string, error) {
+	var d struct {
+		ID string `bson:"_id"`
+	}
+	err := r.books().FindOne(ctx, filter, options.FindOne().SetSort(bson.D{{Key: "_id", Value: 1}}).SetProjection(bson.D{{Key: "_id", Value: 1}})).Decode(&d)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return "", nil
+	}
+	return d.ID, err
+}
+
+func (r mongoBooks) Exists(ctx context.Context, id string) (bool, error) {
+	found, err := r.findID(ctx, bson.D{{Key: "_id", Value: id}})
+	return found != "", err
+}
+
+func (r mongoBooks) FindByISBN(ctx context.Context, isbn string) (string, error) {
+	return r.findID(ctx, bson.D{{Key: "isbn", Value: isbn}})
+}
+
+func (r mongoBooks) FindByTitleAuthorKey(ctx context.Context, titleKey, authorKey string) (string, error) {
+	return r.findID(ctx, bson.D{{Key: "title_key", Value: titleKey}, {Key: "author_key", Value: authorKey}})
+}
+
+func (r mongoBooks) Match(ctx context.Context, keys []BookKeys) ([]BookMatches, error) {
+	matches := make([]BookMatches, len(keys))
+	for i, k := range keys {
+		var err error
+		if matches[i].Exists, err = r.Exists(ctx, k.ID); err != nil {
+			return nil, err
+		}
+		if k.ISBN != "" {
+			if matches[i].ISBN, err = r.FindByISBN(ctx, k.ISBN); err != nil {
+				return nil, err
+			}
+		}
+		if k.TitleKey != "" {
+			if matches[i].TitleAuthor, err = r.FindByTitleAuthorKey(ctx, k.TitleKey, k.AuthorKey); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return matches, nil
+}
+
+// mongoPublisher is a publisher as stored in the publishers collection
+type mongoPublisher struct {
+	ID   int32  `bson:"_id"`
+	Name string `bson:"name"`
+	// Key is the lowercase name, which publishers are matched by
+	Key string `bson:"key"`
+}
+
+// resolvePublisher is resolvePublisher of Postgres, on the publishers collection
+func (r mongoBooks) resolvePublisher(ctx context.Context, book *pb.Book) error {
+	publishers := r.m.db.Collection("publishers")
+	var p mongoPublisher
+	if id := book.GetPublisherId(); id != 0 {
+		err := publishers.FindOne(ctx, bson.D{{Key: "_id", Value: id}}).Decode(&p)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return ErrPublisherNotFound
+		}
+		if err != nil {
+			return err
+		}
+		book.Publisher = p.Name
+		return nil
+	}
+
+	name := strings.TrimSpace(book.GetPublisher())
+	if name == "" {
+		book.Publisher = ""
+		return nil
+	}
+	key := strings.ToLower(name)
+	for {
+		err := publishers.FindOne(ctx, bson.D{{Key: "key", Value: key}}).Decode(&p)
+		if err == nil {
+			book.PublisherId, book.Publisher = p.ID, p.Name
+			return nil
+		}
+		if !errors.Is(err, mongo.ErrNoDocuments) {
+			return err
+		}
+		id, err := r.m.nextID(ctx, "publishers")
+		if err != nil {
+			return err
+		}
+		_, err = publishers.InsertOne(ctx, mongoPublisher{ID: int32(id), Name: name, Key: key})
+		if err == nil {
+			book.PublisherId, book.Publisher = int32(id), name
+			return nil
+		}
+		// Another book added the publisher meanwhile: it's found on the next turn
+		if !mongo.IsDuplicateKeyError(err) {
+			return err
+		}
+	}
+}
+
+// mongoRevision is a change of a book, as recorded in book_revisions by Postgres
+type mongoRevision struct {
+	BookID   string    `bson:"book_id"`
+	Action   string    `bson:"action"`
+	UserID   *int      `bson:"user_id,omitempty"`
+	Username string    `bson:"username"`
+	OldValue string    `bson:"old_value,omitempty"`
+	NewValue string    `bson:"new_value,omitempty"`
+	At       time.Time `bson:"created_at"`
+}
+
+// record stores a snapshot of a book change made by the actor of ctx, and adds its event to the outbox
+func (r mongoBooks) record(ctx context.Context, bookID string, action pb.RevisionAction, oldBook, newBook *pb.Book) error {
+	oldValue, err := marshalRevisionBook(oldBook)
+	if err != nil {
+		return err
+	}
+	newValue, err := marshalRevisionBook(newBook)
+	if err != nil {
+		return err
+	}
+	userID, username := revisionActor(ctx)
+	at := time.Now()
+	_, err = r.m.db.Collection("book_revisions").InsertOne(ctx, mongoRevision{
+		BookID: bookID, Action: revisionActionNames[action], UserID: userID, Username: username,
+		OldValue: string(oldValue), NewValue: string(newValue), At: at,
+	})
+	if err != nil {
+		return err
+	}
+	return r.m.enqueueBookEvent(ctx, bookID, action, oldValue, newValue, at)
+}
+
+// History numbers the revisions of a book from 1, as MongoDB has no sequence to give them IDs from
+func (r mongoBooks) History(ctx context.Context, id string) ([]*pb.BookRevision, error) {
+	cursor, err := r.m.db.Collection("book_revisions").Find(ctx, bson.D{{Key: "book_id", Value: id}},
+		options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	var docs []mongoRevision
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+	revisions := make([]*pb.BookRevision, len(docs))
+	for i, d := range docs {
+		var userID int32
+		if d.UserID != nil {
+			userID = int32(*d.UserID)
+		}
+		revisions[i], err = newRevision(int64(i+1), id, d.Action, userID, d.Username, []byte(d.OldValue), []byte(d.NewValue), d.At)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return revisions, nil
+}
+
+func (r mongoBooks) Create(ctx context.Context, book *pb.Book) error {
+	if err := r.resolvePublisher(ctx, book); err != nil {
+		return err
+	}
+	book.OwnerId = int32(actorFromContext(ctx).UserID)
+	d := newMongoBook(book)
+	d.CoverURL = ""
+	// MongoDB keeps milliseconds
+	d.CreatedAt = time.Now().Truncate(time.Millisecond)
+	d.UpdatedAt = d.CreatedAt
+	if _, err := r.books().InsertOne(ctx, d); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return ErrAlreadyExists
+		}
+		return err
+	}
+	return r.record(ctx, book.GetId(), pb.RevisionAction_REVISION_ACTION_CREATE, nil, book)
+}
+
+func (r mongoBooks) CreateMany(ctx context.Context, books []*pb.Book) ([]error, error) {
+	results := make([]error, len(books))
+	for i, b := range books {
+		// A duplicate key aborts the transaction the books may be created in, so taken IDs are looked up
+		// before inserting
+		exists, err := r.Exists(ctx, b.GetId())
+		if err != nil {
+			return nil, err
+		}
+		if exists {
+			results[i] = ErrAlreadyExists
+			continue
+		}
+		err = r.Create(ctx, b)
+		if err != nil && !errors.Is(err, ErrAlreadyExists) && !errors.Is(err, ErrPublisherNotFound) {
+			return nil, err
+		}
+		results[i] = err
+	}
+	return results, nil
+}
+
+// get returns the document of the book with id, or ErrNotFound
+func (r mongoBooks) get(ctx context.Context, id string) (*mongoBook, error) {
+	var d mongoBook
+	err := r.books().FindOne(ctx, bson.D{{Key: "_id", Value: id}}).Decode(&d)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+func (r mongoBooks) Get(ctx context.Context, id string, languages []string) (*pb.Book, error) {
+	d, err := r.get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return d.book(), nil
+}
+
+// GetForUpdate reads the book in the unit of work of ctx. MongoDB has no row locks: of two transactions
+// updating the book, the second to write fails with a write conflict, which fails its unit of work.
+func (r mongoBooks) GetForUpdate(ctx context.Context, id string) (*pb.Book, error) {
+	return r.Get(ctx, id, nil)
+}
+
+// mongoMatch returns the query of the books selected by filter, after the key of filter.After unless it
+// searches
+func mongoMatch(filter BookFilter) (bson.D, error) {
+	match := bson.D{}
+	if len(filter.Tags) > 0 {
+		match = append(match, bson.E{Key: "tags", Value: bson.D{{Key: "$all", Value: filter.Tags}}})
+	}
+	if filter.Branch != "" {
+		match = append(match, bson.E{Key: "location.branch", Value: filter.Branch})
+	}
+	if filter.Section != "" {
+		match = append(match, bson.E{Key: "location.section", Value: filter.Section})
+	}
+	if filter.PublisherID != 0 {
+		match = append(match, bson.E{Key: "publisher_id", Value: filter.PublisherID})
+	}
+	if filter.Author != "" {
+		match = append(match, bson.E{Key: "author", Value: filter.Author})
+	}
+	if terms := SearchTerms(filter.Search); len(terms) > 0 {
+		// Quoted, each term must be found, where $text would otherwise match any of them
+		phrases := make([]string, len(terms))
+		for i, term := range terms {
+			phrases[i] = `"` + term + `"`
+		}
+		match = append(match, bson.E{Key: "$text", Value: bson.D{{Key: "$search", Value: strings.Join(phrases, " ")}}})
+		return match, nil
+	}
+	if len(filter.After) == 0 {
+		return match, nil
+	}
+	id, _ := filter.After[len(filter.After)-1].(string)
+	if !filter.NewestFirst {
+		return append(match, bson.E{Key: "_id", Value: bson.D{{Key: "$gt", Value: id}}}), nil
+	}
+	createdAt, _ := filter.After[0].(string)
+	at, err := time.Parse(time.RFC3339Nano, createdAt)
+	if err != nil {
+		return nil, ErrInvalidPageToken
+	}
+	return append(match, bson.E{Key: "$or", Value: bson.A{
+		bson.D{{Key: "created_at", Value: bson.D{{Key: "$lt", Value: at}}}},
+		bson.D{{Key: "created_at", Value: at}, {Key: "_id", Value: bson.D{{Key: "$lt", Value: id}}}},
+	}}), nil
+}
+
+func (r mongoBooks) List(ctx context.Context, filter BookFilter, languages []string) ([]*pb.Book, error) {
+	match, err := mongoMatch(filter)
+	if err != nil {
+		return nil, err
+	}
+	offset := int64(max(filter.Offset, 0))
+	var cursor *mongo.Cursor
+	if len(SearchTerms(filter.Search)) > 0 {
+		if filter.Limit <= 0 {
+			return nil, nil
+		}
+		sort := bson.D{{Key: "score", Value: bson.D{{Key: "$meta", Value: "textScore"}}}, {Key: "_id", Value: 1}}
+		cursor, err = r.books().Find(ctx, match, options.Find().SetSort(sort).SetSkip(offset).SetLimit(int64(filter.Limit)))
+	} else {
+		sort := bson.D{{Key: "_id", Value: 1}}
+		if filter.NewestFirst {
+			sort = bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}
+		}
+		if len(filter.After) > 0 {
+			offset = 0
+		}
+		if filter.Limit <= 0 {
+			return nil, nil
+		}
+		cursor, err = r.books().Find(ctx, match, options.Find().SetSort(sort).SetSkip(offset).SetLimit(int64(filter.Limit)))
+	}
+	if err != nil {
+		return nil, err
+	}
+	var docs []*mongoBook
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+	books := make([]*pb.Book, len(docs))
+	for i, d := range docs {
+		books[i] = d.book()
+	}
+	return books, nil
+}
+
+func (r mongoBooks) Each(ctx context.Context, fn func(*pb.Book) error) error {
+	cursor, err := r.books().Find(ctx, bson.D{}, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+	for cursor.Next(ctx) {
+		var d mongoBook
+		if err := cursor.Decode(&d); err != nil {
+			return err
+		}
+		if err := fn(d.book()); err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
+
+func (r mongoBooks) Count(ctx context.Context, filter BookFilter) (int32, error) {
+	filter.After = nil
+	match, err := mongoMatch(filter)
+	if err != nil {
+		return 0, err
+	}
+	n, err := r.books().CountDocuments(ctx, match)
+	return int32(n), err
+}
+
+// EstimateCount reads the document count of the collection's metadata, which is only off after an
+// unclean shutdown
+func (r mongoBooks) EstimateCount(ctx context.Context) (int32, bool, error) {
+	estimate, err := r.books().EstimatedDocumentCount(ctx)
+	if err != nil {
+		return 0, false, err
+	}
+	if estimate < exactCountBelow {
+		count, err := r.Count(ctx, BookFilter{})
+		return count, true, err
+	}
+	return int32(min(estimate, int64(^uint32(0)>>1))), false, nil
+}
+
+func (r mongoBooks) Update(ctx context.Context, old, book *pb.Book) error {
+	current, err := r.get(ctx, book.GetId())
+	if err != nil {
+		return err
+	}
+	// Owners never change, so the book can't have been given to someone else since
+	if !mayChange(ctx, current.OwnerID) {
+		return ErrNotOwner
+	}
+	if err := r.resolvePublisher(ctx, book); err != nil {
+		return err
+	}
+	book.OwnerId = current.OwnerID
+	d := newMongoBook(book)
+	d.CoverURL, d.CreatedAt = current.CoverURL, current.CreatedAt
+	d.UpdatedAt = time.Now().Truncate(time.Millisecond)
+	res, err := r.books().ReplaceOne(ctx, bson.D{{Key: "_id", Value: book.GetId()}}, d)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return r.record(ctx, book.GetId(), pb.RevisionAction_REVISION_ACTION_UPDATE, old, book)
+}
+
+func (r mongoBooks) Delete(ctx context.Context, id string) (*pb.Book, error) {
+	current, err := r.get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !mayChange(ctx, current.OwnerID) {
+		return nil, ErrNotOwner
+	}
+	var d mongoBook
+	err = r.books().FindOneAndDelete(ctx, bson.D{{Key: "_id", Value: id}}).Decode(&d)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	old := d.book()
+	return old, r.record(ctx, id, pb.RevisionAction_REVISION_ACTION_DELETE, old, nil)
+}
+
+// mongoUser is an account as stored in the users collection
+type mongoUser struct {
+	ID           int    `bson:"_id"`
+	Username     string `bson:"username"`
+	PasswordHash string `bson:"password_hash"`
+	State        string `bson:"state"`
+	Email        string `bson:"email,omitempty"`
+	// EmailKey is the lowercase address, unique among accounts with one
+	EmailKey string `bson:"email_key,omitempty"`
+	// Role is left out for accounts with the "user" role
+	Role string `bson:"role,omitempty"`
+}
+
+// mongoUsers is the UserRepository of a Mongo store
+type mongoUsers struct {
+	m *Mongo
+}
+
+func (r mongoUsers) users() *mongo.Collection {
+	return r.m.db.Collection("users")
+}
+
+// exists reports whether an account matches filter
+func (r mongoUsers) exists(ctx context.Context, filter bson.D) (bool, error) {
+	n, err := r.users().CountDocuments(ctx, filter, options.Count().SetLimit(1))
+	return n > 0, err
+}
+
+func (r mongoUsers) UsernameExists(ctx context.Context, username string) (bool, error) {
+	return r.exists(ctx, bson.D{{Key: "username", Value: username}})
+}
+
+func (r mongoUsers) EmailExists(ctx context.Context, email string) (bool, error) {
+	return r.exists(ctx, bson.D{{Key: "email_key", Value: strings.ToLower(email)}})
+}
+
+func (r mongoUsers) Create(ctx context.Context, user NewUser) (int, error) {
+	id, err := r.m.nextID(ctx, "users")
+	if err != nil {
+		return 0, err
+	}
+	_, err = r.users().InsertOne(ctx, mongoUser{
+		ID: int(id), Username: user.Username, PasswordHash: user.PasswordHash, State: user.State,
+		Email: user.Email, EmailKey: strings.ToLower(user.Email),
+	})
+	if mongo.IsDuplicateKeyError(err) {
+		return 0, ErrAlreadyExists
+	}
+	if err != nil {
+		return 0, err
+	}
+	return int(id), nil
+}
+
+// GetByUsername returns the account with username. Addresses are verified by the flows kept in
+// Postgres, so any address of a Mongo account is unverified.
+func (r mongoUsers) GetByUsername(ctx context.Context, username string) (*User, error) {
+	var u mongoUser
+	err := r.users().FindOne(ctx, bson.D{{Key: "username", Value: username}}).Decode(&u)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &User{ID: u.ID, Username: u.Username, PasswordHash: u.PasswordHash, State: u.State, EmailUnverified: u.Email != ""}, nil
+}
+
+func (r mongoUsers) GetByID(ctx context.Context, id int) (*User, error) {
+	var u mongoUser
+	err := r.users().FindOne(ctx, bson.D{{Key: "_id", Value: id}}).Decode(&u)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	role := u.Role
+	if role == "" {
+		role = "user"
+	}
+	return &User{ID: u.ID, Username: u.Username, State: u.State, Role: role, EmailUnverified: u.Email != ""}, nil
+}
+
+// Revocation reports nothing revoked, as sessions and revoked tokens are kept in Postgres
+func (r mongoUsers) Revocation(ctx context.Context, id int, tokenID string, sessionID int64, checkToken bool) (*Revocation, error) {
+	exists, err := r.exists(ctx, bson.D{{Key: "_id", Value: id}})
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrNotFound
+	}
+	return &Revocation{}, nil
+}
+
+// sentEventRetention is how long sent events stay in the outbox collection, the default retention of
+// the outbox table of PostgreSQL
+const sentEventRetention = 7 * 24 * time.Hour
+
+// mongoEvent is an event as stored in the outbox collection. sent_at is missing until the event is sent,
+// and its TTL index then removes it after sentEventRetention.
+type mongoEvent struct {
+	ID          int64      `bson:"_id"`
+	Type        string     `bson:"event_type"`
+	AggregateID string     `bson:"aggregate_id"`
+	Payload     string     `bson:"payload"`
+	CreatedAt   time.Time  `bson:"created_at"`
+	SentAt      *time.Time `bson:"sent_at,omitempty"`
+	Attempts    int        `bson:"attempts"`
+	LastError   string     `bson:"last_error,omitempty"`
+}
+
+// bookEventPayload is the payload of the event of a book change, as enqueueBookEvent builds it in
+// PostgreSQL
+type bookEventPayload struct {
+	Book     json.RawMessage `json:"book,omitempty"`
+	Previous json.RawMessage `json:"previous,omitempty"`
+	Actor    string          `json:"actor,omitempty"`
+}
+
+// enqueueBookEvent adds the event of a book change, given as its revision's old and new values, to the
+// outbox collection in the unit of work of ctx, so that it is published once the change commits
+func (m *Mongo) enqueueBookEvent(ctx context.Context, bookID string, action pb.RevisionAction, oldValue, newValue []byte, at time.Time) error {
+	_, username := revisionActor(ctx)
+	payload, err := json.Marshal(bookEventPayload{Book: newValue, Previous: oldValue, Actor: username})
+	if err != nil {
+		return err
+	}
+	id, err := m.nextID(ctx, "outbox")
+	if err != nil {
+		return err
+	}
+	_, err = m.db.Collection("outbox").InsertOne(ctx, mongoEvent{
+		ID: id, Type: bookEventTypes[action], AggregateID: bookID, Payload: string(payload), CreatedAt: at,
+	})
+	return err
+}
+
+// Event is an event of the outbox collection waiting to be published
+type Event struct {
+	ID          int64
+	Type        string
+	AggregateID string
+	Payload     json.RawMessage
+	CreatedAt   time.Time
+}
+
+// PendingEvents returns up to limit events not sent yet, in the order they were written
+func (m *Mongo) PendingEvents(ctx context.Context, limit int) ([]Event, error) {
+	cursor, err := m.db.Collection("outbox").Find(ctx, bson.D{{Key: "sent_at", Value: nil}},
+		options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}).SetLimit(int64(limit)))
+	if err != nil {
+		return nil, err
+	}
+	var docs []mongoEvent
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+	events := make([]Event, len(docs))
+	for i, d := range docs {
+		events[i] = Event{ID: d.ID, Type: d.Type, AggregateID: d.AggregateID, Payload: json.RawMessage(d.Payload), CreatedAt: d.CreatedAt}
+	}
+	return events, nil
+}
+
+// MarkEvent records an attempt to publish the event with id: the event is sent when publishErr is nil,
+// and otherwise stays pending with publishErr as its last error
+func (m *Mongo) MarkEvent(ctx context.Context, id int64, publishErr error) error {
+	set := bson.D{{Key: "sent_at", Value: time.Now()}}
+	if publishErr != nil {
+		set = bson.D{{Key: "last_error", Value: publishErr.Error()}}
+	}
+	_, err := m.db.Collection("outbox").UpdateByID(ctx, id, bson.D{{Key: "$set", Value: set}, {Key: "$inc", Value: bson.D{{Key: "attempts", Value: 1}}}})
+	return err
+}
+
+// OldestPendingEvent returns when the oldest event not sent yet was written, or the zero time when all
+// were sent
+func (m *Mongo) OldestPendingEvent(ctx context.Context) (time.Time, error) {
+	var d mongoEvent
+	err := m.db.Collection("outbox").FindOne(ctx, bson.D{{Key: "sent_at", Value: nil}},
+		options.FindOne().SetSort(bson.D{{Key: "_id", Value: 1}})).Decode(&d)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return time.Time{}, nil
+	}
+	return d.CreatedAt, err
+}
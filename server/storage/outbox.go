@@ -0,0 +1,38 @@
+package storage
+
+import (
+	"context"
+
+	pb "example/grpc_demo/library"
+)
+
+// bookEventTypes maps the actions of book changes to the types of the events they add to the outbox
+var bookEventTypes = map[pb.RevisionAction]string{
+	pb.RevisionAction_REVISION_ACTION_CREATE: "book.created",
+	pb.RevisionAction_REVISION_ACTION_UPDATE: "book.updated",
+	pb.RevisionAction_REVISION_ACTION_DELETE: "book.deleted",
+}
+
+// enqueueBookEvent adds the event of a book change, given as its revision's old and new values, to the
+// outbox. Its payload holds the book after the change, the book before it and who made it, leaving out
+// what is missing. Written in the transaction of the change, the event is published once the change
+// commits, and never for a change rolled back.
+func enqueueBookEvent(ctx context.Context, q Querier, bookID string, action pb.RevisionAction, oldValue, newValue []byte) error {
+	_, username := revisionActor(ctx)
+	_, err := q.Exec(ctx,
+		`INSERT INTO outbox (event_type, aggregate_id, payload)
+		 VALUES ($1, $2, jsonb_strip_nulls(jsonb_build_object('book', $3::jsonb, 'previous', $4::jsonb, 'actor', NULLIF($5::text, ''))))`,
+		bookEventTypes[action], bookID, newValue, oldValue, username)
+	return err
+}
+
+// enqueueCreationEvents adds the events of books created at once, given as the JSON of each, to the outbox
+func enqueueCreationEvents(ctx context.Context, q Querier, ids, values []string) error {
+	_, username := revisionActor(ctx)
+	_, err := q.Exec(ctx,
+		`INSERT INTO outbox (event_type, aggregate_id, payload)
+		 SELECT $4, id, jsonb_strip_nulls(jsonb_build_object('book', value, 'actor', NULLIF($3::text, '')))
+		 FROM unnest($1::text[], $2::jsonb[]) WITH ORDINALITY AS e(id, value, n) ORDER BY n`,
+		ids, values, username, bookEventTypes[pb.RevisionAction_REVISION_ACTION_CREATE])
+	return err
+}
@@ -0,0 +1,122 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"hash/fnv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidPageToken is returned for a page token that is malformed or was issued for another listing
+var ErrInvalidPageToken = errors.New("invalid page token")
+
+// Keyset is the order of a listing by a key unique to each row, so that a page can start after the last
+// row of the one before instead of skipping OFFSET rows, which the database reads only to throw away.
+// Pages stay stable while rows are added or removed before them.
+type Keyset struct {
+	// Columns are the expressions of the key as the query names them, the last one unique
+	Columns []string
+	// Desc sorts every column of the key in descending order
+	Desc bool
+}
+
+// OrderBy returns the ORDER BY clause of the listing
+func (k Keyset) OrderBy() string {
+	columns := k.Columns
+	if k.Desc {
+		columns = make([]string, len(k.Columns))
+		for i, c := range k.Columns {
+			columns[i] = c + " DESC"
+		}
+	}
+	return " ORDER BY " + strings.Join(columns, ", ")
+}
+
+// After returns the condition selecting the rows that come after key, with arg adding each of its values
+// to the arguments of the query and returning its placeholder. Comparing the key as a row lets an index
+// on its columns find the first one.
+func (k Keyset) After(key []any, arg func(any) string) string {
+	placeholders := make([]string, len(key))
+	for i, v := range key {
+		placeholders[i] = arg(v)
+	}
+	op := " > "
+	if k.Desc {
+		op = " < "
+	}
+	return "(" + strings.Join(k.Columns, ", ") + ")" + op + "(" + strings.Join(placeholders, ", ") + ")"
+}
+
+// pageToken is what a page token encodes
+type pageToken struct {
+	// Scope is a hash of the listing the token was issued for
+	Scope uint64 `json:"s"`
+	// Key is the key of the last row of the page before
+	Key []any `json:"k"`
+}
+
+func scopeHash(scope string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(scope))
+	return h.Sum64()
+}
+
+// EncodePageToken returns the opaque token of the page starting after the row with key, in the listing
+// identified by scope: its name and filters, which a request for the next page must repeat
+func EncodePageToken(scope string, key ...any) string {
+	data, _ := json.Marshal(pageToken{Scope: scopeHash(scope), Key: key})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodePageToken returns the key of token, or ErrInvalidPageToken when it wasn't issued for scope or
+// doesn't have the shape of the listing's keys: one value of the type of each of their elements, as
+// EncodePageToken is given them. Integers come back as int64 and the rest as strings, times in RFC 3339
+// which PostgreSQL reads back.
+func DecodePageToken(token, scope string, shape ...any) ([]any, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, ErrInvalidPageToken
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var t pageToken
+	if err := dec.Decode(&t); err != nil || t.Scope != scopeHash(scope) || len(t.Key) == 0 || len(t.Key) != len(shape) {
+		return nil, ErrInvalidPageToken
+	}
+	for i, v := range t.Key {
+		var ok bool
+		switch shape[i].(type) {
+		case int, int32, int64:
+			var n json.Number
+			if n, ok = v.(json.Number); ok {
+				t.Key[i], err = n.Int64()
+				ok = err == nil
+			}
+		case time.Time:
+			var s string
+			if s, ok = v.(string); ok {
+				_, err = time.Parse(time.RFC3339Nano, s)
+				ok = err == nil
+			}
+		case string:
+			_, ok = v.(string)
+		}
+		if !ok {
+			return nil, ErrInvalidPageToken
+		}
+	}
+	return t.Key, nil
+}
+
+// NextPage takes the rows of a page fetched with a limit of one more than limit, and returns the page
+// without that extra row along with the token of the page after it, or "" for the last page
+func NextPage[T any](rows []T, limit int32, scope string, key func(T) []any) ([]T, string) {
+	if int32(len(rows)) <= limit {
+		return rows, ""
+	}
+	rows = rows[:limit]
+	return rows, EncodePageToken(scope, key(rows[len(rows)-1])...)
+}
@@ -0,0 +1,63 @@
+package storage
+
+import (
+	"errors"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestKeyset(t *testing.T) {
+	var args []any
+	arg := func(v any) string {
+		args = append(args, v)
+		return "$" + strconv.Itoa(len(args))
+	}
+	k := Keyset{Columns: []string{"r.created_at", "r.id"}, Desc: true}
+	if got := k.OrderBy(); got != " ORDER BY r.created_at DESC, r.id DESC" {
+		t.Errorf("OrderBy() = %q", got)
+	}
+	if got := k.After([]any{"2024-05-01T10:00:00Z", int64(7)}, arg); got != "(r.created_at, r.id) < ($1, $2)" || len(args) != 2 {
+		t.Errorf("After() = %q, args %v", got, args)
+	}
+	if got := (Keyset{Columns: []string{"name", "id"}}).After([]any{"Ace", int64(3)}, arg); got != "(name, id) > ($3, $4)" {
+		t.Errorf("After() ascending = %q", got)
+	}
+}
+
+func TestPageToken(t *testing.T) {
+	at := time.Date(2024, 5, 1, 10, 0, 0, 123456000, time.UTC)
+	token := EncodePageToken("reviews b1", at, int64(42))
+	key, err := DecodePageToken(token, "reviews b1", time.Time{}, int64(0))
+	if err != nil || !slices.Equal(key, []any{"2024-05-01T10:00:00.123456Z", int64(42)}) {
+		t.Errorf("DecodePageToken() = %#v, %v", key, err)
+	}
+
+	for _, tt := range []struct{ name, token string }{
+		{"another listing", EncodePageToken("reviews b2", at, int64(42))},
+		{"not base64", "not a token!"},
+		{"not JSON", "bm90IEpTT04"},
+		{"empty key", EncodePageToken("reviews b1")},
+		{"fraction", EncodePageToken("reviews b1", at, 1.5)},
+		{"shorter key", EncodePageToken("reviews b1", int64(42))},
+		{"longer key", EncodePageToken("reviews b1", at, int64(42), int64(1))},
+		{"string for an integer", EncodePageToken("reviews b1", at, "42")},
+		{"integer for a time", EncodePageToken("reviews b1", int64(42), int64(42))},
+		{"string that isn't a time", EncodePageToken("reviews b1", "yesterday", int64(42))},
+	} {
+		if _, err := DecodePageToken(tt.token, "reviews b1", time.Time{}, int64(0)); !errors.Is(err, ErrInvalidPageToken) {
+			t.Errorf("%s: DecodePageToken() error = %v, want ErrInvalidPageToken", tt.name, err)
+		}
+	}
+
+	rows := []int64{1, 2, 3}
+	key64 := func(n int64) []any { return []any{n} }
+	if page, next := NextPage(rows, 3, "s", key64); len(page) != 3 || next != "" {
+		t.Errorf("NextPage() of a last page = %v, %q", page, next)
+	}
+	page, next := NextPage(rows, 2, "s", key64)
+	if key, _ := DecodePageToken(next, "s", int64(0)); len(page) != 2 || !slices.Equal(key, []any{int64(2)}) {
+		t.Errorf("NextPage() = %v, key %v", page, key)
+	}
+}
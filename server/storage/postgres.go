@@ -0,0 +1,68 @@
+package storage
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Postgres keeps books and users in the PostgreSQL database of pool
+type Postgres struct {
+	pool *pgxpool.Pool
+}
+
+// NewPostgres returns the store of pool, whose schema is that of migrations.sql
+func NewPostgres(pool *pgxpool.Pool) *Postgres {
+	return &Postgres{pool: pool}
+}
+
+// Books returns the book repository of the store
+func (p *Postgres) Books() BookRepository {
+	return pgBooks{p}
+}
+
+// Users returns the user repository of the store
+func (p *Postgres) Users() UserRepository {
+	return pgUsers{p}
+}
+
+type txKey struct{}
+
+// InTx runs fn in a transaction carried by the context it is given
+func (p *Postgres) InTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(pgx.Tx); ok {
+		return fn(ctx)
+	}
+	tx, err := p.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+	if err := fn(context.WithValue(ctx, txKey{}, tx)); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// conn returns the transaction of ctx, or the pool outside of one
+func (p *Postgres) conn(ctx context.Context) Querier {
+	return Conn(ctx, p.pool)
+}
+
+// Conn returns the transaction of the unit of work of ctx, or fallback outside of one, so that SQL not
+// yet behind a repository can take part in the same unit of work
+func Conn(ctx context.Context, fallback Querier) Querier {
+	if tx, ok := ctx.Value(txKey{}).(pgx.Tx); ok {
+		return tx
+	}
+	return fallback
+}
+
+// isUniqueViolation reports whether err is a Postgres unique constraint violation
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
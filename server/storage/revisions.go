@@ -0,0 +1,132 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	pb "example/grpc_demo/library"
+
+	"github.com/jackc/pgx/v5"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// revisionActionNames maps revision actions to the values stored in book_revisions.action
+var revisionActionNames = map[pb.RevisionAction]string{
+	pb.RevisionAction_REVISION_ACTION_CREATE: "create",
+	pb.RevisionAction_REVISION_ACTION_UPDATE: "update",
+	pb.RevisionAction_REVISION_ACTION_DELETE: "delete",
+}
+
+// ParseRevisionAction converts a stored action back into its enum value
+func ParseRevisionAction(name string) pb.RevisionAction {
+	for action, n := range revisionActionNames {
+		if n == name {
+			return action
+		}
+	}
+	return pb.RevisionAction_REVISION_ACTION_UNSPECIFIED
+}
+
+// marshalRevisionBook serializes a book snapshot for a JSONB column; nil stays NULL
+func marshalRevisionBook(book *pb.Book) ([]byte, error) {
+	if book == nil {
+		return nil, nil
+	}
+	return protojson.Marshal(book)
+}
+
+// newRevision builds a revision from its stored values, decoding the book snapshots
+func newRevision(id int64, bookID, action string, userID int32, username string, oldValue, newValue []byte, changedAt time.Time) (*pb.BookRevision, error) {
+	rev := &pb.BookRevision{Id: id, BookId: bookID, Action: ParseRevisionAction(action), UserId: userID, Username: username, ChangedAt: timestamppb.New(changedAt)}
+	if len(oldValue) > 0 {
+		rev.OldBook = &pb.Book{}
+		if err := protojson.Unmarshal(oldValue, rev.OldBook); err != nil {
+			return nil, fmt.Errorf("failed to decode book revision: %w", err)
+		}
+	}
+	if len(newValue) > 0 {
+		rev.NewBook = &pb.Book{}
+		if err := protojson.Unmarshal(newValue, rev.NewBook); err != nil {
+			return nil, fmt.Errorf("failed to decode book revision: %w", err)
+		}
+	}
+	return rev, nil
+}
+
+func (r pgBooks) History(ctx context.Context, id string) ([]*pb.BookRevision, error) {
+	rows, err := r.db.conn(ctx).Query(ctx,
+		"SELECT id, action, COALESCE(user_id, 0), username, old_value, new_value, changed_at FROM book_revisions WHERE book_id=$1 ORDER BY id", id)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (*pb.BookRevision, error) {
+		var (
+			revID              int64
+			action, username   string
+			userID             int32
+			oldValue, newValue []byte
+			changedAt          time.Time
+		)
+		if err := row.Scan(&revID, &action, &userID, &username, &oldValue, &newValue, &changedAt); err != nil {
+			return nil, err
+		}
+		return newRevision(revID, id, action, userID, username, oldValue, newValue, changedAt)
+	})
+}
+
+// recordRevision stores a snapshot of a book change made by the actor of ctx, and its event in the outbox
+func recordRevision(ctx context.Context, q Querier, bookID string, action pb.RevisionAction, oldBook, newBook *pb.Book) error {
+	oldValue, err := marshalRevisionBook(oldBook)
+	if err != nil {
+		return fmt.Errorf("failed to encode old book: %w", err)
+	}
+	newValue, err := marshalRevisionBook(newBook)
+	if err != nil {
+		return fmt.Errorf("failed to encode new book: %w", err)
+	}
+
+	userID, username := revisionActor(ctx)
+	_, err = q.Exec(ctx,
+		"INSERT INTO book_revisions (book_id, action, user_id, username, old_value, new_value) VALUES ($1, $2, $3, $4, $5, $6)",
+		bookID, revisionActionNames[action], userID, username, oldValue, newValue)
+	if err != nil {
+		return err
+	}
+	return enqueueBookEvent(ctx, q, bookID, action, oldValue, newValue)
+}
+
+// recordCreations stores the revisions and events of books created by the actor of ctx, with a statement each
+func recordCreations(ctx context.Context, q Querier, books []*pb.Book) error {
+	if len(books) == 0 {
+		return nil
+	}
+	ids := make([]string, len(books))
+	values := make([]string, len(books))
+	for i, b := range books {
+		value, err := marshalRevisionBook(b)
+		if err != nil {
+			return fmt.Errorf("failed to encode new book: %w", err)
+		}
+		ids[i], values[i] = b.GetId(), string(value)
+	}
+	userID, username := revisionActor(ctx)
+	_, err := q.Exec(ctx,
+		`INSERT INTO book_revisions (book_id, action, user_id, username, new_value)
+		 SELECT id, $3::text, $4::int, $5::text, value FROM unnest($1::text[], $2::jsonb[]) WITH ORDINALITY AS r(id, value, n) ORDER BY n`,
+		ids, values, revisionActionNames[pb.RevisionAction_REVISION_ACTION_CREATE], userID, username)
+	if err != nil {
+		return err
+	}
+	return enqueueCreationEvents(ctx, q, ids, values)
+}
+
+// revisionActor returns the user ID, NULL for a service, and name recorded for the actor of ctx
+func revisionActor(ctx context.Context) (*int, string) {
+	actor := actorFromContext(ctx)
+	if actor.UserID == 0 {
+		return nil, actor.Name
+	}
+	return &actor.UserID, actor.Name
+}
@@ -0,0 +1,34 @@
+package storage
+
+import (
+	"testing"
+
+	pb "example/grpc_demo/library"
+)
+
+func TestRevisionActionRoundTrip(t *testing.T) {
+	for action, name := range revisionActionNames {
+		if got := ParseRevisionAction(name); got != action {
+			t.Errorf("ParseRevisionAction(%q) = %v, want %v", name, got, action)
+		}
+	}
+
+	if got := ParseRevisionAction("unknown"); got != pb.RevisionAction_REVISION_ACTION_UNSPECIFIED {
+		t.Errorf("ParseRevisionAction(unknown) = %v, want UNSPECIFIED", got)
+	}
+}
+
+func TestMarshalRevisionBook(t *testing.T) {
+	data, err := marshalRevisionBook(nil)
+	if err != nil || data != nil {
+		t.Errorf("marshalRevisionBook(nil) = %q, %v; want nil, nil", data, err)
+	}
+
+	data, err = marshalRevisionBook(&pb.Book{Id: "book1", Title: "Go Programming", Author: "John Doe"})
+	if err != nil {
+		t.Fatalf("marshalRevisionBook() error = %v", err)
+	}
+	if len(data) == 0 {
+		t.Error("marshalRevisionBook() returned empty JSON for a book")
+	}
+}
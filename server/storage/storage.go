@@ -0,0 +1,287 @@
+// Package storage keeps the library's books and users. Handlers depend on the repository interfaces
+// below rather than on a database driver, so that they can be tested with fakes and the backing store
+// swapped without touching them.
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	pb "example/grpc_demo/library"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+var (
+	// ErrNotFound is returned when the requested book or user does not exist
+	ErrNotFound = errors.New("not found")
+	// ErrAlreadyExists is returned when a record with the same key is already stored
+	ErrAlreadyExists = errors.New("already exists")
+	// ErrPublisherNotFound is returned when a book references a publisher ID that does not exist
+	ErrPublisherNotFound = errors.New("publisher not found")
+	// ErrNotOwner is returned when a user who is not an administrator changes a book someone else added
+	ErrNotOwner = errors.New("not the owner")
+)
+
+// Querier is the subset of pgx methods shared by *pgxpool.Pool and pgx.Tx,
+// so helpers can run either standalone or inside a transaction
+type Querier interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// Transactor runs units of work. Repository calls made with the context passed to fn take part in the
+// unit of work, which is committed when fn returns nil and rolled back otherwise. A unit of work started
+// within another joins it.
+type Transactor interface {
+	InTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// Store is a backend keeping books and users, with units of work spanning both
+type Store interface {
+	Transactor
+	Books() BookRepository
+	Users() UserRepository
+}
+
+// split keeps the books of one store and the users of another
+type split struct {
+	books, users Store
+}
+
+// Split returns the store keeping books in books and users in users. Its units of work are one of each
+// store, that of users nested in that of books: users is committed first, and books once it has. When the
+// commit of books then fails, the changes to users are kept: with the catalog in MongoDB, a failed book
+// change may still count against the quota kept in PostgreSQL, but no change to the catalog is kept
+// without its PostgreSQL side. Neither store retries a transaction, which would repeat the work done in
+// the other.
+func Split(books, users Store) Store {
+	return split{books: books, users: users}
+}
+
+func (s split) Books() BookRepository {
+	return s.books.Books()
+}
+
+func (s split) Users() UserRepository {
+	return s.users.Users()
+}
+
+func (s split) InTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return s.books.InTx(ctx, func(ctx context.Context) error {
+		return s.users.InTx(ctx, fn)
+	})
+}
+
+// BookFilter selects the books listed by BookRepository.List and counted by BookRepository.Count
+type BookFilter struct {
+	// Tags, normalized, all of which a book must have
+	Tags []string
+	// Branch and Section, trimmed, match the book's location
+	Branch  string
+	Section string
+	// PublisherID, when set, matches books of that publisher
+	PublisherID int32
+	// Author, when set, matches books whose author is exactly this
+	Author string
+	// Search, when it has any SearchTerms, matches books with words of their title, author or description
+	// starting with each of them, or equal to each of them with Mongo. List then orders books by how well
+	// they match.
+	Search string
+	// NewestFirst has List order books by when they were added, latest first, when it doesn't search
+	NewestFirst bool
+	// Limit and Offset page through the books, otherwise ordered by ID; Count ignores them
+	Limit  int32
+	Offset int32
+	// After, the key of a page token decoded for PageScope, has List start after that book in place of
+	// Offset. Searches, ordered by how well books match, don't take it.
+	After []any
+}
+
+// PageScope identifies the listing of filter, whose page tokens are good for that listing only
+func (f BookFilter) PageScope() string {
+	f.Limit, f.Offset, f.After = 0, 0, nil
+	return fmt.Sprintf("books %+v", f)
+}
+
+// PageKey returns the key of book in the order of filter, which the page after it starts after
+func (f BookFilter) PageKey(book *pb.Book) []any {
+	if f.NewestFirst {
+		return []any{book.GetCreatedAt().AsTime(), book.GetId()}
+	}
+	return []any{book.GetId()}
+}
+
+// keyset is the order of a listing of books that doesn't search
+func (f BookFilter) keyset() Keyset {
+	if f.NewestFirst {
+		return Keyset{Columns: []string{"created_at", "id"}, Desc: true}
+	}
+	return Keyset{Columns: []string{"id"}}
+}
+
+// MatchesAll reports whether filter selects every book, which EstimateCount can then count
+func (f BookFilter) MatchesAll() bool {
+	return len(f.Tags) == 0 && f.Branch == "" && f.Section == "" && f.PublisherID == 0 && f.Author == "" && len(SearchTerms(f.Search)) == 0
+}
+
+// BookKeys identify a book about to be added, for the checks made before adding it
+type BookKeys struct {
+	ID string
+	// ISBN is normalized, and TitleKey and AuthorKey are match keys (see MatchKey); an empty ISBN or
+	// TitleKey leaves that check out
+	ISBN      string
+	TitleKey  string
+	AuthorKey string
+}
+
+// BookMatches are the stored books found for BookKeys
+type BookMatches struct {
+	// Exists is set when a book with the ID is stored
+	Exists bool
+	// ISBN and TitleAuthor are the IDs of a book with the same ISBN and of one with the same title and
+	// author keys, or ""
+	ISBN        string
+	TitleAuthor string
+}
+
+// BookRepository stores books along with their series, publisher link and revision history. Books are
+// stored as given: callers validate and normalize them first.
+type BookRepository interface {
+	// Exists reports whether a book with id is stored
+	Exists(ctx context.Context, id string) (bool, error)
+	// FindByISBN returns the ID of a book with the normalized isbn, or "" if there is none
+	FindByISBN(ctx context.Context, isbn string) (string, error)
+	// FindByTitleAuthorKey returns the ID of a book whose title and author have the match keys given
+	// (lowercase letters and digits only), or "" if there is none
+	FindByTitleAuthorKey(ctx context.Context, titleKey, authorKey string) (string, error)
+	// Match looks up many books about to be added at once, as Exists, FindByISBN and FindByTitleAuthorKey would
+	Match(ctx context.Context, keys []BookKeys) ([]BookMatches, error)
+	// Create stores a new book, resolving its publisher, and records its creation. Its owner is the user
+	// of ctx, if any. It fails with ErrAlreadyExists or ErrPublisherNotFound.
+	Create(ctx context.Context, book *pb.Book) error
+	// CreateMany stores new books as Create does, with a few statements however many there are. It returns
+	// the outcome of each book, nil, ErrAlreadyExists or ErrPublisherNotFound, and fails as a whole otherwise.
+	CreateMany(ctx context.Context, books []*pb.Book) ([]error, error)
+	// Get returns a book with its tags, series and ratings, translated into the first of languages it
+	// has a translation for, or ErrNotFound
+	Get(ctx context.Context, id string, languages []string) (*pb.Book, error)
+	// GetForUpdate returns a book with its series, locked until the unit of work of ctx ends, or ErrNotFound
+	GetForUpdate(ctx context.Context, id string) (*pb.Book, error)
+	// List returns a page of the books matching filter, like Get
+	List(ctx context.Context, filter BookFilter, languages []string) ([]*pb.Book, error)
+	// Each calls fn with every book ordered by ID, reading them as it goes rather than all at once, and
+	// stops at the first error of fn, which it returns. The books are as stored, without their tags,
+	// series, ratings or translations.
+	Each(ctx context.Context, fn func(*pb.Book) error) error
+	// Count returns the number of books matching filter
+	Count(ctx context.Context, filter BookFilter) (int32, error)
+	// EstimateCount returns the number of books without counting them when the backend keeps an estimate,
+	// which can be off by the changes made since it was last refreshed. exact reports that they were
+	// counted instead.
+	EstimateCount(ctx context.Context) (count int32, exact bool, err error)
+	// Update replaces the stored book old with book, resolving its publisher, and records the change.
+	// Its cover and owner are kept. It fails with ErrNotFound, ErrNotOwner or ErrPublisherNotFound.
+	Update(ctx context.Context, old, book *pb.Book) error
+	// Delete removes a book and records its deletion, returning what was removed, or ErrNotFound or ErrNotOwner
+	Delete(ctx context.Context, id string) (*pb.Book, error)
+	// History returns the recorded changes of a book, oldest first, including those of a deleted book
+	History(ctx context.Context, id string) ([]*pb.BookRevision, error)
+}
+
+// User is a stored account, as needed to log it in
+type User struct {
+	ID           int
+	Username     string
+	PasswordHash string
+	State        string
+	// Role is the account's role, "user" unless it was granted another; GetByUsername leaves it out
+	Role string
+	// EmailUnverified is set when the account has an email address that hasn't been verified
+	EmailUnverified bool
+}
+
+// Revocation tells which tokens of an account were revoked
+type Revocation struct {
+	// SessionsRevokedAt, when set, is when every session of the account was last revoked
+	SessionsRevokedAt *time.Time
+	// TokenRevoked and SessionRevoked are set when the token and the session asked about were revoked
+	TokenRevoked   bool
+	SessionRevoked bool
+}
+
+// NewUser is an account to create
+type NewUser struct {
+	Username     string
+	PasswordHash string
+	// Email is the normalized address, or "" for none
+	Email string
+	State string
+}
+
+// UserRepository stores accounts
+type UserRepository interface {
+	// UsernameExists reports whether the username is taken
+	UsernameExists(ctx context.Context, username string) (bool, error)
+	// EmailExists reports whether an account has the address, compared case-insensitively
+	EmailExists(ctx context.Context, email string) (bool, error)
+	// Create stores a new account and returns its ID, or ErrAlreadyExists when the username or email
+	// address was taken meanwhile
+	Create(ctx context.Context, user NewUser) (int, error)
+	// GetByUsername returns the account with username, or ErrNotFound
+	GetByUsername(ctx context.Context, username string) (*User, error)
+	// GetByID returns the account with id and its role, without its password hash, or ErrNotFound
+	GetByID(ctx context.Context, id int) (*User, error)
+	// Revocation returns whether the sessions of the account id, its session sessionID and, when
+	// checkToken is set, its token tokenID were revoked, or ErrNotFound. Stores that don't keep sessions
+	// report none revoked.
+	Revocation(ctx context.Context, id int, tokenID string, sessionID int64, checkToken bool) (*Revocation, error)
+}
+
+type tenantKey struct{}
+
+// WithTenant returns a context whose repository calls read and write the rows of library id; "" is the
+// default library
+func WithTenant(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, id)
+}
+
+// Tenant returns the library of ctx, "" for the default library. Every row of the Postgres store belongs to
+// one library, in its tenant_id column.
+func Tenant(ctx context.Context) string {
+	id, _ := ctx.Value(tenantKey{}).(string)
+	return id
+}
+
+// Actor is who changes books, as recorded in their revision history: a user, or a service with no user ID
+type Actor struct {
+	UserID int
+	Name   string
+	// Admin lets a user update and delete any book, not only those they added
+	Admin bool
+}
+
+type actorKey struct{}
+
+// WithActor returns a context whose book changes are recorded as made by actor
+func WithActor(ctx context.Context, actor Actor) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// actorFromContext returns the actor of ctx, the zero Actor when anonymous
+func actorFromContext(ctx context.Context) Actor {
+	actor, _ := ctx.Value(actorKey{}).(Actor)
+	return actor
+}
+
+// mayChange reports whether the actor of ctx may update or delete a book added by the user owner, 0 when
+// it has none. Only users who aren't administrators are limited, to their own books; services, jobs and
+// anonymous contexts are not.
+func mayChange(ctx context.Context, owner int32) bool {
+	actor := actorFromContext(ctx)
+	return actor.UserID == 0 || actor.Admin || int32(actor.UserID) == owner
+}
@@ -0,0 +1,731 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	pb "example/grpc_demo/library"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// testStore checks the behavior every Store shares, on empty stores made by newStore
+func testStore(t *testing.T, newStore func(t *testing.T) Store) {
+	ctx := context.Background()
+
+	t.Run("books", func(t *testing.T) {
+		books := newStore(t).Books()
+		dune := &pb.Book{Id: "b1", Title: "Dune", Author: "Frank Herbert", Isbn: "9780441172719", Publisher: "Chilton ",
+			TotalCopies: 2, AvailableCopies: 2, Series: &pb.BookSeries{Name: "Dune", Volume: 1}, Location: &pb.Location{Branch: "Main"}}
+		if err := books.Create(ctx, dune); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+		if dune.GetPublisherId() == 0 || dune.GetPublisher() != "Chilton" {
+			t.Errorf("Create() resolved the publisher to %d %q", dune.GetPublisherId(), dune.GetPublisher())
+		}
+		if err := books.Create(ctx, &pb.Book{Id: "b1", Title: "Other"}); !errors.Is(err, ErrAlreadyExists) {
+			t.Errorf("Create() of a stored ID error = %v, want ErrAlreadyExists", err)
+		}
+		emma := &pb.Book{Id: "b2", Title: "Emma", Author: "Jane Austen", Publisher: "CHILTON", TotalCopies: 1, AvailableCopies: 1}
+		if err := books.Create(ctx, emma); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+		if emma.GetPublisherId() != dune.GetPublisherId() || emma.GetPublisher() != "Chilton" {
+			t.Errorf("a publisher named again resolved to %d %q, want %d", emma.GetPublisherId(), emma.GetPublisher(), dune.GetPublisherId())
+		}
+		if err := books.Create(ctx, &pb.Book{Id: "b3", Title: "Persuasion", PublisherId: 9999}); !errors.Is(err, ErrPublisherNotFound) {
+			t.Errorf("Create() with an unknown publisher error = %v, want ErrPublisherNotFound", err)
+		}
+
+		if ok, err := books.Exists(ctx, "b1"); !ok || err != nil {
+			t.Errorf("Exists(b1) = %v, %v", ok, err)
+		}
+		if ok, err := books.Exists(ctx, "b3"); ok || err != nil {
+			t.Errorf("Exists(b3) = %v, %v", ok, err)
+		}
+		if id, err := books.FindByISBN(ctx, "9780441172719"); id != "b1" || err != nil {
+			t.Errorf("FindByISBN() = %q, %v", id, err)
+		}
+		if id, err := books.FindByTitleAuthorKey(ctx, MatchKey("EMMA"), MatchKey("jane austen")); id != "b2" || err != nil {
+			t.Errorf("FindByTitleAuthorKey() = %q, %v", id, err)
+		}
+		if id, err := books.FindByISBN(ctx, "9780141439587"); id != "" || err != nil {
+			t.Errorf("FindByISBN() of an unknown ISBN = %q, %v", id, err)
+		}
+
+		got, err := books.Get(ctx, "b1", nil)
+		if err != nil {
+			t.Fatalf("Get() error = %v", err)
+		}
+		if got.GetTitle() != "Dune" || got.GetSeries().GetName() != "Dune" || got.GetLocation().GetBranch() != "Main" || got.GetAvailableCopies() != 2 {
+			t.Errorf("Get() = %v", got)
+		}
+		if got.GetCreatedAt() == nil || !got.GetUpdatedAt().AsTime().Equal(got.GetCreatedAt().AsTime()) {
+			t.Errorf("Get() of a new book created_at = %v, updated_at = %v, want both set alike", got.GetCreatedAt(), got.GetUpdatedAt())
+		}
+		if _, err := books.Get(ctx, "b3", nil); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Get() of an unknown book error = %v, want ErrNotFound", err)
+		}
+
+		list, err := books.List(ctx, BookFilter{Limit: 1, Offset: 1}, nil)
+		if err != nil || len(list) != 1 || list[0].GetId() != "b2" {
+			t.Errorf("List() of the second page = %v, %v", list, err)
+		}
+		filter := BookFilter{Branch: "Main", PublisherID: dune.GetPublisherId(), Limit: 10}
+		if list, err := books.List(ctx, filter, nil); err != nil || len(list) != 1 || list[0].GetId() != "b1" {
+			t.Errorf("List() in Main = %v, %v", list, err)
+		}
+		if n, err := books.Count(ctx, BookFilter{Limit: 1}); n != 2 || err != nil {
+			t.Errorf("Count() = %d, %v", n, err)
+		}
+		// A catalog this small is counted rather than estimated
+		if n, exact, err := books.EstimateCount(ctx); n != 2 || !exact || err != nil {
+			t.Errorf("EstimateCount() = %d, %t, %v, want 2 counted", n, exact, err)
+		}
+
+		old, err := books.GetForUpdate(ctx, "b1")
+		if err != nil || old.GetSeries().GetVolume() != 1 {
+			t.Fatalf("GetForUpdate() = %v, %v", old, err)
+		}
+		update := &pb.Book{Id: "b1", Title: "Dune Messiah", Author: "Frank Herbert", TotalCopies: 3, AvailableCopies: 3}
+		if err := books.Update(ctx, old, update); err != nil {
+			t.Fatalf("Update() error = %v", err)
+		}
+		got, _ = books.Get(ctx, "b1", nil)
+		if got.GetTitle() != "Dune Messiah" || got.GetSeries() != nil || got.GetLocation() != nil || got.GetPublisherId() != 0 {
+			t.Errorf("Get() after Update() = %v, want the whole book replaced", got)
+		}
+		if !got.GetCreatedAt().AsTime().Equal(old.GetCreatedAt().AsTime()) || got.GetUpdatedAt().AsTime().Before(old.GetUpdatedAt().AsTime()) {
+			t.Errorf("Update() changed created_at from %v to %v and updated_at from %v to %v, want only updated_at to move on",
+				old.GetCreatedAt(), got.GetCreatedAt(), old.GetUpdatedAt(), got.GetUpdatedAt())
+		}
+		if err := books.Update(ctx, old, &pb.Book{Id: "b3", Title: "Persuasion"}); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Update() of an unknown book error = %v, want ErrNotFound", err)
+		}
+
+		deleted, err := books.Delete(ctx, "b2")
+		if err != nil || deleted.GetTitle() != "Emma" {
+			t.Errorf("Delete() = %v, %v", deleted, err)
+		}
+		if _, err := books.Delete(ctx, "b2"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Delete() of a deleted book error = %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("many books", func(t *testing.T) {
+		books := newStore(t).Books()
+		if err := books.Create(ctx, &pb.Book{Id: "b1", Title: "Dune", Author: "Frank Herbert", Isbn: "9780441172719"}); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+		results, err := books.CreateMany(ctx, []*pb.Book{
+			{Id: "b2", Title: "Emma", Publisher: "Penguin", Series: &pb.BookSeries{Name: "Austen", Volume: 4}, TotalCopies: 1, AvailableCopies: 1},
+			{Id: "b1", Title: "Other"},
+			{Id: "b3", Title: "Persuasion", Publisher: "PENGUIN ", Series: &pb.BookSeries{Name: "Austen", Volume: 6}},
+			{Id: "b3", Title: "Persuasion again"},
+			{Id: "b4", Title: "Sense and Sensibility", PublisherId: 9999},
+		})
+		if err != nil {
+			t.Fatalf("CreateMany() error = %v", err)
+		}
+		want := []error{nil, ErrAlreadyExists, nil, ErrAlreadyExists, ErrPublisherNotFound}
+		for i := range want {
+			if !errors.Is(results[i], want[i]) {
+				t.Errorf("CreateMany() result %d = %v, want %v", i, results[i], want[i])
+			}
+		}
+		emma, err := books.Get(ctx, "b2", nil)
+		if err != nil || emma.GetPublisher() != "Penguin" || emma.GetSeries().GetVolume() != 4 || emma.GetTotalCopies() != 1 {
+			t.Errorf("Get() of a book created in bulk = %v, %v", emma, err)
+		}
+		persuasion, _ := books.Get(ctx, "b3", nil)
+		if persuasion.GetTitle() != "Persuasion" || persuasion.GetPublisherId() != emma.GetPublisherId() || persuasion.GetSeries().GetName() != "Austen" {
+			t.Errorf("Get() of a book created in bulk = %v, want the publisher and series of b2", persuasion)
+		}
+		if n, _ := books.Count(ctx, BookFilter{}); n != 3 {
+			t.Errorf("Count() = %d, want 3", n)
+		}
+
+		matches, err := books.Match(ctx, []BookKeys{
+			{ID: "b1"},
+			{ID: "b9", ISBN: "9780441172719", TitleKey: MatchKey("Emma"), AuthorKey: ""},
+			{ID: "b9", ISBN: "9780141439587", TitleKey: MatchKey("Dune"), AuthorKey: MatchKey("Jane Austen")},
+		})
+		wantMatches := []BookMatches{{Exists: true}, {ISBN: "b1", TitleAuthor: "b2"}, {}}
+		if err != nil || !slices.Equal(matches, wantMatches) {
+			t.Errorf("Match() = %v, %v, want %v", matches, err, wantMatches)
+		}
+	})
+
+	t.Run("list orders", func(t *testing.T) {
+		books := newStore(t).Books()
+		for _, id := range []string{"b2", "b3", "b1"} {
+			if err := books.Create(ctx, &pb.Book{Id: id, Title: "Title " + id, Author: "Jane Austen"}); err != nil {
+				t.Fatalf("Create() error = %v", err)
+			}
+			// created_at is the start of the transaction in Postgres, which must differ from book to book
+			time.Sleep(2 * time.Millisecond)
+		}
+		if err := books.Create(ctx, &pb.Book{Id: "b4", Title: "Dune", Author: "Frank Herbert"}); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+		tests := []struct {
+			filter BookFilter
+			want   []string
+		}{
+			{BookFilter{Limit: 10}, []string{"b1", "b2", "b3", "b4"}},
+			{BookFilter{NewestFirst: true, Limit: 10}, []string{"b4", "b1", "b3", "b2"}},
+			{BookFilter{Author: "Jane Austen", NewestFirst: true, Limit: 2, Offset: 1}, []string{"b3", "b2"}},
+			{BookFilter{Author: "jane austen", Limit: 10}, nil},
+		}
+		for _, tt := range tests {
+			list, err := books.List(ctx, tt.filter, nil)
+			if err != nil {
+				t.Fatalf("List(%+v) error = %v", tt.filter, err)
+			}
+			var got []string
+			for _, b := range list {
+				got = append(got, b.GetId())
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("List(%+v) = %v, want %v", tt.filter, got, tt.want)
+			}
+		}
+
+		// Page tokens walk through the same orders, a page of 3 fetched for each page of 2
+		for _, tt := range tests[:2] {
+			filter := tt.filter
+			var got []string
+			for {
+				filter.Limit = 3
+				list, err := books.List(ctx, filter, nil)
+				if err != nil {
+					t.Fatalf("List(%+v) error = %v", filter, err)
+				}
+				page, token := NextPage(list, 2, filter.PageScope(), filter.PageKey)
+				for _, b := range page {
+					got = append(got, b.GetId())
+				}
+				if token == "" {
+					break
+				}
+				if filter.After, err = DecodePageToken(token, filter.PageScope(), filter.PageKey(&pb.Book{})...); err != nil {
+					t.Fatalf("DecodePageToken() error = %v", err)
+				}
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("pages of %+v = %v, want %v", tt.filter, got, tt.want)
+			}
+		}
+
+		var each []string
+		stop := errors.New("stop")
+		err := books.Each(ctx, func(b *pb.Book) error {
+			each = append(each, b.GetId())
+			if len(each) == 3 {
+				return stop
+			}
+			return nil
+		})
+		if !errors.Is(err, stop) || !slices.Equal(each, []string{"b1", "b2", "b3"}) {
+			t.Errorf("Each() stopping at the third book = %v, %v, want b1-b3 and its error", each, err)
+		}
+	})
+
+	t.Run("search", func(t *testing.T) {
+		books := newStore(t).Books()
+		for _, b := range []*pb.Book{
+			{Id: "b1", Title: "The Hobbit", Author: "J. R. R. Tolkien", Description: "A hobbit goes on an adventure"},
+			{Id: "b2", Title: "Tolkien: A Biography", Author: "Humphrey Carpenter", Description: "The life of the author of The Hobbit"},
+			{Id: "b3", Title: "Emma", Author: "Jane Austen"},
+		} {
+			if err := books.Create(ctx, b); err != nil {
+				t.Fatalf("Create() error = %v", err)
+			}
+		}
+		// MongoDB's text index matches whole words only
+		_, wholeWords := books.(mongoBooks)
+		tests := []struct {
+			search string
+			want   []string
+			prefix bool
+		}{
+			// Matches in titles rank first
+			{"hobbit", []string{"b1", "b2"}, false},
+			{"Tolk", []string{"b2", "b1"}, true},
+			{"tolkien", []string{"b2", "b1"}, false},
+			{"hobbit carpenter", []string{"b2"}, false},
+			{"austen emma", []string{"b3"}, false},
+			{"dune", nil, false},
+		}
+		for _, tt := range tests {
+			if tt.prefix && wholeWords {
+				continue
+			}
+			filter := BookFilter{Search: tt.search, Limit: 10}
+			list, err := books.List(ctx, filter, nil)
+			if err != nil {
+				t.Fatalf("List(%q) error = %v", tt.search, err)
+			}
+			var got []string
+			for _, b := range list {
+				got = append(got, b.GetId())
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("List(%q) = %v, want %v", tt.search, got, tt.want)
+			}
+			if n, err := books.Count(ctx, filter); int(n) != len(tt.want) || err != nil {
+				t.Errorf("Count(%q) = %d, %v, want %d", tt.search, n, err, len(tt.want))
+			}
+		}
+	})
+
+	t.Run("users", func(t *testing.T) {
+		users := newStore(t).Users()
+		id, err := users.Create(ctx, NewUser{Username: "alice", PasswordHash: "hash", Email: "alice@example.com", State: "active"})
+		if err != nil || id == 0 {
+			t.Fatalf("Create() = %d, %v", id, err)
+		}
+		if _, err := users.Create(ctx, NewUser{Username: "alice", PasswordHash: "hash", State: "active"}); !errors.Is(err, ErrAlreadyExists) {
+			t.Errorf("Create() of a taken username error = %v, want ErrAlreadyExists", err)
+		}
+		if _, err := users.Create(ctx, NewUser{Username: "bob", PasswordHash: "hash", Email: "ALICE@example.com", State: "active"}); !errors.Is(err, ErrAlreadyExists) {
+			t.Errorf("Create() of a taken address error = %v, want ErrAlreadyExists", err)
+		}
+		if ok, err := users.UsernameExists(ctx, "alice"); !ok || err != nil {
+			t.Errorf("UsernameExists(alice) = %v, %v", ok, err)
+		}
+		if ok, err := users.EmailExists(ctx, "Alice@Example.com"); !ok || err != nil {
+			t.Errorf("EmailExists() = %v, %v", ok, err)
+		}
+		if ok, err := users.UsernameExists(ctx, "bob"); ok || err != nil {
+			t.Errorf("UsernameExists(bob) = %v, %v", ok, err)
+		}
+
+		u, err := users.GetByUsername(ctx, "alice")
+		if err != nil || u.ID != id || u.PasswordHash != "hash" || u.State != "active" || !u.EmailUnverified {
+			t.Errorf("GetByUsername() = %+v, %v", u, err)
+		}
+		if _, err := users.GetByUsername(ctx, "bob"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("GetByUsername() of an unknown user error = %v, want ErrNotFound", err)
+		}
+
+		u, err = users.GetByID(ctx, id)
+		if err != nil || u.Username != "alice" || u.PasswordHash != "" || u.Role != "user" || u.State != "active" {
+			t.Errorf("GetByID() = %+v, %v", u, err)
+		}
+		if _, err := users.GetByID(ctx, id+1); !errors.Is(err, ErrNotFound) {
+			t.Errorf("GetByID() of an unknown user error = %v, want ErrNotFound", err)
+		}
+		rev, err := users.Revocation(ctx, id, "jti", 1, true)
+		if err != nil || rev.TokenRevoked || rev.SessionRevoked || rev.SessionsRevokedAt != nil {
+			t.Errorf("Revocation() of a new account = %+v, %v, want nothing revoked", rev, err)
+		}
+		if _, err := users.Revocation(ctx, id+1, "jti", 1, true); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Revocation() of an unknown user error = %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("owners", func(t *testing.T) {
+		store := newStore(t)
+		books := store.Books()
+		aliceID, err := store.Users().Create(ctx, NewUser{Username: "alice", PasswordHash: "hash", State: "active"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		bobID, err := store.Users().Create(ctx, NewUser{Username: "bob", PasswordHash: "hash", State: "active"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		alice := WithActor(ctx, Actor{UserID: aliceID, Name: "alice"})
+		bob := WithActor(ctx, Actor{UserID: bobID, Name: "bob"})
+		admin := WithActor(ctx, Actor{UserID: bobID, Name: "bob", Admin: true})
+
+		dune := &pb.Book{Id: "b1", Title: "Dune", TotalCopies: 1, AvailableCopies: 1, OwnerId: 42}
+		if err := books.Create(alice, dune); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+		if got, _ := books.Get(ctx, "b1", nil); got.GetOwnerId() != int32(aliceID) {
+			t.Errorf("Get() owner = %d, want %d", got.GetOwnerId(), aliceID)
+		}
+		if err := books.Create(ctx, &pb.Book{Id: "b2", Title: "Emma"}); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+		if got, _ := books.Get(ctx, "b2", nil); got.GetOwnerId() != 0 {
+			t.Errorf("Get() of a book added without a user owner = %d, want 0", got.GetOwnerId())
+		}
+
+		old, _ := books.Get(ctx, "b1", nil)
+		update := &pb.Book{Id: "b1", Title: "Dune Messiah", TotalCopies: 1, AvailableCopies: 1}
+		if err := books.Update(bob, old, update); !errors.Is(err, ErrNotOwner) {
+			t.Errorf("Update() of another user's book error = %v, want ErrNotOwner", err)
+		}
+		if _, err := books.Delete(bob, "b1"); !errors.Is(err, ErrNotOwner) {
+			t.Errorf("Delete() of another user's book error = %v, want ErrNotOwner", err)
+		}
+		if _, err := books.Delete(alice, "b2"); !errors.Is(err, ErrNotOwner) {
+			t.Errorf("Delete() of a book with no owner error = %v, want ErrNotOwner", err)
+		}
+		if _, err := books.Delete(bob, "b3"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Delete() of an unknown book error = %v, want ErrNotFound", err)
+		}
+		if err := books.Update(alice, old, update); err != nil {
+			t.Fatalf("Update() by the owner error = %v", err)
+		}
+		if got, _ := books.Get(ctx, "b1", nil); got.GetTitle() != "Dune Messiah" || got.GetOwnerId() != int32(aliceID) {
+			t.Errorf("Get() after Update() = %v, want the title changed and the owner kept", got)
+		}
+		if _, err := books.Delete(admin, "b1"); err != nil {
+			t.Errorf("Delete() by an admin error = %v", err)
+		}
+		if _, err := books.Delete(ctx, "b2"); err != nil {
+			t.Errorf("Delete() without an actor error = %v", err)
+		}
+	})
+
+	t.Run("history", func(t *testing.T) {
+		store := newStore(t)
+		books := store.Books()
+		alice := WithActor(ctx, Actor{Name: "alice"})
+		if err := books.Create(alice, &pb.Book{Id: "b1", Title: "Dune", TotalCopies: 1, AvailableCopies: 1}); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+		old, _ := books.GetForUpdate(ctx, "b1")
+		if err := books.Update(alice, old, &pb.Book{Id: "b1", Title: "Dune Messiah", TotalCopies: 1, AvailableCopies: 1}); err != nil {
+			t.Fatalf("Update() error = %v", err)
+		}
+		// A change undone with its unit of work leaves no revision behind
+		failed := errors.New("failed")
+		err := store.InTx(alice, func(ctx context.Context) error {
+			old, err := books.GetForUpdate(ctx, "b1")
+			if err != nil {
+				return err
+			}
+			if err := books.Update(ctx, old, &pb.Book{Id: "b1", Title: "Children of Dune", TotalCopies: 1, AvailableCopies: 1}); err != nil {
+				return err
+			}
+			return failed
+		})
+		if !errors.Is(err, failed) {
+			t.Fatalf("InTx() error = %v, want that of the work", err)
+		}
+		if _, err := books.Delete(alice, "b1"); err != nil {
+			t.Fatalf("Delete() error = %v", err)
+		}
+
+		history, err := books.History(ctx, "b1")
+		if err != nil {
+			t.Fatalf("History() error = %v", err)
+		}
+		want := []pb.RevisionAction{pb.RevisionAction_REVISION_ACTION_CREATE, pb.RevisionAction_REVISION_ACTION_UPDATE, pb.RevisionAction_REVISION_ACTION_DELETE}
+		if len(history) != len(want) {
+			t.Fatalf("History() = %d revisions, want %d: %v", len(history), len(want), history)
+		}
+		for i, rev := range history {
+			if rev.GetAction() != want[i] || rev.GetBookId() != "b1" || rev.GetUsername() != "alice" || rev.GetChangedAt() == nil {
+				t.Errorf("revision %d = %v, want %s by alice", i, rev, want[i])
+			}
+			if i > 0 && rev.GetId() <= history[i-1].GetId() {
+				t.Errorf("revision %d has ID %d after %d", i, rev.GetId(), history[i-1].GetId())
+			}
+		}
+		if history[0].GetOldBook() != nil || history[0].GetNewBook().GetTitle() != "Dune" {
+			t.Errorf("creation = %v -> %v", history[0].GetOldBook(), history[0].GetNewBook())
+		}
+		if history[1].GetOldBook().GetTitle() != "Dune" || history[1].GetNewBook().GetTitle() != "Dune Messiah" {
+			t.Errorf("update = %v -> %v", history[1].GetOldBook(), history[1].GetNewBook())
+		}
+		if history[2].GetOldBook().GetTitle() != "Dune Messiah" || history[2].GetNewBook() != nil {
+			t.Errorf("deletion = %v -> %v", history[2].GetOldBook(), history[2].GetNewBook())
+		}
+		if history, err := books.History(ctx, "b2"); len(history) != 0 || err != nil {
+			t.Errorf("History() of an unknown book = %v, %v", history, err)
+		}
+	})
+
+	t.Run("units of work", func(t *testing.T) {
+		store := newStore(t)
+		books := store.Books()
+		failed := errors.New("failed")
+		err := store.InTx(ctx, func(ctx context.Context) error {
+			if err := books.Create(ctx, &pb.Book{Id: "b1", Title: "Dune"}); err != nil {
+				return err
+			}
+			// A nested unit of work joins the first, and is undone with it
+			if err := store.InTx(ctx, func(ctx context.Context) error {
+				_, err := store.Users().Create(ctx, NewUser{Username: "alice", PasswordHash: "hash", State: "active"})
+				return err
+			}); err != nil {
+				return err
+			}
+			if ok, err := books.Exists(ctx, "b1"); !ok || err != nil {
+				t.Errorf("Exists() within the unit of work = %v, %v", ok, err)
+			}
+			return failed
+		})
+		if !errors.Is(err, failed) {
+			t.Errorf("InTx() error = %v, want that of the work", err)
+		}
+		if ok, _ := books.Exists(ctx, "b1"); ok {
+			t.Error("the book of a failed unit of work was kept")
+		}
+		if ok, _ := store.Users().UsernameExists(ctx, "alice"); ok {
+			t.Error("the user of a failed unit of work was kept")
+		}
+
+		if err := store.InTx(ctx, func(ctx context.Context) error {
+			return books.Create(ctx, &pb.Book{Id: "b1", Title: "Dune"})
+		}); err != nil {
+			t.Fatalf("InTx() error = %v", err)
+		}
+		if ok, _ := books.Exists(ctx, "b1"); !ok {
+			t.Error("the book of a unit of work was not kept")
+		}
+	})
+
+	t.Run("concurrent units of work", func(t *testing.T) {
+		store := newStore(t)
+		var wg sync.WaitGroup
+		for i := range 20 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				err := store.InTx(ctx, func(ctx context.Context) error {
+					return store.Books().Create(ctx, &pb.Book{Id: fmt.Sprintf("b%02d", i), Title: "Dune"})
+				})
+				if err != nil {
+					t.Errorf("InTx() error = %v", err)
+				}
+			}()
+		}
+		wg.Wait()
+		if n, err := store.Books().Count(ctx, BookFilter{}); n != 20 || err != nil {
+			t.Errorf("Count() = %d, %v, want 20", n, err)
+		}
+	})
+}
+
+func TestMemoryStore(t *testing.T) {
+	testStore(t, func(t *testing.T) Store { return NewMemory() })
+}
+
+// TestSplitStore checks that a split store behaves as one, units of work included
+func TestSplitStore(t *testing.T) {
+	testStore(t, func(t *testing.T) Store { return Split(NewMemory(), NewMemory()) })
+}
+
+// committingStore logs its name to log when a unit of work of its Store commits
+type committingStore struct {
+	Store
+	name string
+	log  *[]string
+}
+
+func (s committingStore) InTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return s.Store.InTx(ctx, func(ctx context.Context) error {
+		if err := fn(ctx); err != nil {
+			return err
+		}
+		*s.log = append(*s.log, s.name)
+		return nil
+	})
+}
+
+// TestSplitStoreCommitOrder checks a split store commits users before books, and runs its work once
+func TestSplitStoreCommitOrder(t *testing.T) {
+	var log []string
+	store := Split(committingStore{NewMemory(), "books", &log}, committingStore{NewMemory(), "users", &log})
+	runs := 0
+	err := store.InTx(context.Background(), func(ctx context.Context) error {
+		runs++
+		return store.Books().Create(ctx, &pb.Book{Id: "b1", Title: "Dune"})
+	})
+	if err != nil || runs != 1 {
+		t.Fatalf("InTx() = %v, with %d runs of the work, want 1", err, runs)
+	}
+	if want := []string{"users", "books"}; !slices.Equal(log, want) {
+		t.Errorf("commits = %v, want %v", log, want)
+	}
+}
+
+// postgresTestPool connects to the database of STORAGE_TEST_DATABASE_URL and migrates it, or skips tb
+func postgresTestPool(tb testing.TB) *pgxpool.Pool {
+	dsn := os.Getenv("STORAGE_TEST_DATABASE_URL")
+	if dsn == "" {
+		tb.Skip("STORAGE_TEST_DATABASE_URL is not set")
+	}
+	ctx := context.Background()
+	pool, err := pgxpool.New(ctx, dsn)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	tb.Cleanup(pool.Close)
+	schema, err := os.ReadFile("../migrations.sql")
+	if err != nil {
+		tb.Fatal(err)
+	}
+	if _, err := pool.Exec(ctx, string(schema)); err != nil {
+		tb.Fatalf("migrations: %v", err)
+	}
+	return pool
+}
+
+// TestPostgresStore runs against the database of STORAGE_TEST_DATABASE_URL, whose tables it empties
+func TestPostgresStore(t *testing.T) {
+	pool := postgresTestPool(t)
+	ctx := context.Background()
+	testStore(t, func(t *testing.T) Store {
+		if _, err := pool.Exec(ctx, "TRUNCATE books, users, publishers, series, book_revisions CASCADE"); err != nil {
+			t.Fatal(err)
+		}
+		return NewPostgres(pool)
+	})
+}
+
+// TestMongoStore runs against the replica set of MONGODB_TEST_URI, in a library_test database it drops
+// before each test, e.g. MONGODB_TEST_URI=mongodb://localhost/?replicaSet=rs0
+func TestMongoStore(t *testing.T) {
+	uri := os.Getenv("MONGODB_TEST_URI")
+	if uri == "" {
+		t.Skip("MONGODB_TEST_URI is not set")
+	}
+	ctx := context.Background()
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.Disconnect(ctx) })
+	newStore := func(t *testing.T) *Mongo {
+		db := client.Database("library_test")
+		if err := db.Drop(ctx); err != nil {
+			t.Fatal(err)
+		}
+		store, err := NewMongo(ctx, db)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return store
+	}
+	testStore(t, func(t *testing.T) Store { return newStore(t) })
+
+	t.Run("events", func(t *testing.T) {
+		store := newStore(t)
+		if err := store.Books().Create(ctx, &pb.Book{Id: "b1", Title: "Dune"}); err != nil {
+			t.Fatal(err)
+		}
+		// A change rolled back leaves no event behind
+		err := store.InTx(ctx, func(ctx context.Context) error {
+			if err := store.Books().Create(ctx, &pb.Book{Id: "b2", Title: "Emma"}); err != nil {
+				return err
+			}
+			return errRollback
+		})
+		if !errors.Is(err, errRollback) {
+			t.Fatalf("InTx() error = %v", err)
+		}
+		if _, err := store.Books().Delete(ctx, "b1"); err != nil {
+			t.Fatal(err)
+		}
+
+		events, err := store.PendingEvents(ctx, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(events) != 2 || events[0].Type != "book.created" || events[1].Type != "book.deleted" || events[1].AggregateID != "b1" {
+			t.Fatalf("PendingEvents() = %+v, want the creation and deletion of b1", events)
+		}
+		if !strings.Contains(string(events[1].Payload), `"previous":{`) {
+			t.Errorf("deletion payload %s, want the deleted book", events[1].Payload)
+		}
+
+		if err := store.MarkEvent(ctx, events[0].ID, errors.New("unreachable")); err != nil {
+			t.Fatal(err)
+		}
+		if oldest, err := store.OldestPendingEvent(ctx); err != nil || !oldest.Equal(events[0].CreatedAt) {
+			t.Errorf("OldestPendingEvent() = %s, %v, want the failed event's %s", oldest, err, events[0].CreatedAt)
+		}
+		for _, e := range events {
+			if err := store.MarkEvent(ctx, e.ID, nil); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if pending, err := store.PendingEvents(ctx, 10); len(pending) != 0 || err != nil {
+			t.Errorf("PendingEvents() = %+v, %v after marking every event sent", pending, err)
+		}
+		if oldest, err := store.OldestPendingEvent(ctx); !oldest.IsZero() || err != nil {
+			t.Errorf("OldestPendingEvent() = %s, %v after marking every event sent", oldest, err)
+		}
+	})
+}
+
+// errRollback undoes the unit of work of a benchmark
+var errRollback = errors.New("rolled back")
+
+// BenchmarkPostgresList lists pages of a million books with and without the index each listing relies on,
+// which is dropped in a unit of work that is rolled back. The books replace those of the database of
+// STORAGE_TEST_DATABASE_URL, e.g.
+//
+//	STORAGE_TEST_DATABASE_URL=postgres://localhost/library_test go test -run '^$' -bench PostgresList ./server/storage
+func BenchmarkPostgresList(b *testing.B) {
+	pool := postgresTestPool(b)
+	ctx := context.Background()
+	if _, err := pool.Exec(ctx, "TRUNCATE books, publishers, series, book_revisions CASCADE"); err != nil {
+		b.Fatal(err)
+	}
+	_, err := pool.Exec(ctx,
+		`INSERT INTO books (id, title, author, description, created_at)
+		 SELECT 'b' || lpad(i::text, 7, '0'), 'Title ' || i, 'Author ' || (i % 20000), 'Book number ' || i, NOW() - i * INTERVAL '1 minute'
+		 FROM generate_series(1, 1000000) AS i`)
+	if err != nil {
+		b.Fatalf("seeding: %v", err)
+	}
+	if _, err := pool.Exec(ctx, "ANALYZE books"); err != nil {
+		b.Fatal(err)
+	}
+	store := NewPostgres(pool)
+
+	listings := []struct {
+		name   string
+		index  string
+		filter BookFilter
+	}{
+		{"author", "idx_books_author", BookFilter{Author: "Author 4242", Limit: 20}},
+		{"newest", "idx_books_created_at", BookFilter{NewestFirst: true, Limit: 20}},
+		{"newest page 50", "idx_books_created_at", BookFilter{NewestFirst: true, Limit: 20, Offset: 980}},
+		{"search", "idx_books_search_vector", BookFilter{Search: "title 4242", Limit: 20}},
+	}
+	list := func(b *testing.B, ctx context.Context, filter BookFilter) {
+		for i := 0; i < b.N; i++ {
+			if _, err := store.Books().List(ctx, filter, nil); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+	for _, l := range listings {
+		b.Run(l.name+"/indexed", func(b *testing.B) {
+			list(b, ctx, l.filter)
+		})
+		b.Run(l.name+"/unindexed", func(b *testing.B) {
+			err := store.InTx(ctx, func(ctx context.Context) error {
+				if _, err := store.conn(ctx).Exec(ctx, "DROP INDEX "+l.index); err != nil {
+					return err
+				}
+				b.ResetTimer()
+				list(b, ctx, l.filter)
+				b.StopTimer()
+				return errRollback
+			})
+			if !errors.Is(err, errRollback) {
+				b.Fatal(err)
+			}
+		})
+	}
+}
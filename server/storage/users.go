@@ -0,0 +1,89 @@
+package storage
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// userByIDQuery and revocationQuery run on every authenticated call, to check its token, so they are
+// prepared on every connection of the pool (see PreparedQueries)
+const (
+	userByIDQuery   = "SELECT username, role, state, email IS NOT NULL AND email_verified_at IS NULL FROM users WHERE tenant_id=$2 AND id=$1"
+	revocationQuery = `SELECT sessions_revoked_at,
+			$4::boolean AND EXISTS(SELECT 1 FROM revoked_tokens WHERE jti=$2),
+			EXISTS(SELECT 1 FROM sessions WHERE id=$3 AND user_id=$1 AND revoked_at IS NOT NULL)
+		 FROM users WHERE tenant_id=$5 AND id=$1`
+)
+
+// PreparedQueries are the statements worth preparing on every new connection, as every authenticated call
+// runs them. They are named by their SQL, so running the SQL uses the prepared statement, if there is one.
+var PreparedQueries = []string{userByIDQuery, revocationQuery}
+
+// pgUsers is the UserRepository of a Postgres store
+type pgUsers struct {
+	db *Postgres
+}
+
+func (r pgUsers) UsernameExists(ctx context.Context, username string) (bool, error) {
+	var exists bool
+	err := r.db.conn(ctx).QueryRow(ctx, "SELECT EXISTS(SELECT 1 FROM users WHERE tenant_id=$1 AND username=$2)", Tenant(ctx), username).Scan(&exists)
+	return exists, err
+}
+
+func (r pgUsers) EmailExists(ctx context.Context, email string) (bool, error) {
+	var exists bool
+	err := r.db.conn(ctx).QueryRow(ctx, "SELECT EXISTS(SELECT 1 FROM users WHERE tenant_id=$1 AND lower(email)=lower($2))", Tenant(ctx), email).Scan(&exists)
+	return exists, err
+}
+
+func (r pgUsers) Create(ctx context.Context, user NewUser) (int, error) {
+	var id int
+	err := r.db.conn(ctx).QueryRow(ctx,
+		"INSERT INTO users (tenant_id, username, password_hash, email, state) VALUES ($5, $1, $2, NULLIF($3, ''), $4) RETURNING id",
+		user.Username, user.PasswordHash, user.Email, user.State, Tenant(ctx)).Scan(&id)
+	if isUniqueViolation(err) {
+		return 0, ErrAlreadyExists
+	}
+	return id, err
+}
+
+func (r pgUsers) GetByUsername(ctx context.Context, username string) (*User, error) {
+	u := &User{Username: username}
+	err := r.db.conn(ctx).QueryRow(ctx,
+		"SELECT id, password_hash, state, email IS NOT NULL AND email_verified_at IS NULL FROM users WHERE tenant_id=$1 AND username=$2",
+		Tenant(ctx), username).Scan(&u.ID, &u.PasswordHash, &u.State, &u.EmailUnverified)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+func (r pgUsers) GetByID(ctx context.Context, id int) (*User, error) {
+	u := &User{ID: id}
+	err := r.db.conn(ctx).QueryRow(ctx, userByIDQuery, id, Tenant(ctx)).Scan(&u.Username, &u.Role, &u.State, &u.EmailUnverified)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+func (r pgUsers) Revocation(ctx context.Context, id int, tokenID string, sessionID int64, checkToken bool) (*Revocation, error) {
+	rev := &Revocation{}
+	err := r.db.conn(ctx).QueryRow(ctx, revocationQuery, id, tokenID, sessionID, checkToken, Tenant(ctx)).
+		Scan(&rev.SessionsRevokedAt, &rev.TokenRevoked, &rev.SessionRevoked)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return rev, nil
+}
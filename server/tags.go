@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	pb "example/grpc_demo/library"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// maxTagLength bounds tag names so they stay usable as filters and labels
+const maxTagLength = 64
+
+// normalizeTag lowercases and trims a tag name
+func normalizeTag(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// normalizeTags normalizes and de-duplicates tag names, dropping empty ones
+func normalizeTags(names []string) []string {
+	seen := make(map[string]bool)
+	var tags []string
+	for _, name := range names {
+		tag := normalizeTag(name)
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		tags = append(tags, tag)
+	}
+	return tags
+}
+
+// isUniqueViolation reports whether err is a Postgres unique constraint violation
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
+
+func (s *server) CreateTag(ctx context.Context, tag *pb.Tag) (*pb.TagResponse, error) {
+	name := normalizeTag(tag.GetName())
+	if name == "" {
+		return nil, badRequest("name", "Tag name is required")
+	}
+	if len(name) > maxTagLength {
+		return nil, badRequest("name", "Tag name is too long")
+	}
+
+	created := &pb.Tag{Name: name}
+	err := s.db.QueryRow(ctx, "INSERT INTO tags (name) VALUES ($1) RETURNING id", name).Scan(&created.Id)
+	if isUniqueViolation(err) {
+		return nil, alreadyExists(resourceTag, name, "Tag already exists")
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to create tag: %v", err)
+	}
+	return &pb.TagResponse{Tag: created}, nil
+}
+
+func (s *server) ListTags(ctx context.Context, req *pb.ListTagsRequest) (*pb.ListTagsResponse, error) {
+	rows, err := s.db.Query(ctx,
+		"SELECT t.id, t.name, COUNT(bt.book_id) FROM tags t LEFT JOIN book_tags bt ON bt.tag_id = t.id GROUP BY t.id ORDER BY t.name")
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "database error: %v", err)
+	}
+	defer rows.Close()
+
+	var tags []*pb.Tag
+	for rows.Next() {
+		var t pb.Tag
+		if err := rows.Scan(&t.Id, &t.Name, &t.BookCount); err != nil {
+			return nil, status.Errorf(codes.Internal, "database error: %v", err)
+		}
+		tags = append(tags, &t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, status.Errorf(codes.Internal, "database error: %v", err)
+	}
+	return &pb.ListTagsResponse{Tags: tags}, nil
+}
+
+func (s *server) TagBook(ctx context.Context, req *pb.BookTagRequest) (*pb.BookResponse, error) {
+	if req.GetBookId() == "" {
+		return nil, badRequest("book_id", "Book ID is required")
+	}
+	name := normalizeTag(req.GetTag())
+	if name == "" {
+		return nil, badRequest("tag", "Tag name is required")
+	}
+
+	var bookExists bool
+	var tagID int32
+	err := s.db.QueryRow(ctx,
+		"SELECT EXISTS(SELECT 1 FROM books WHERE id=$1), COALESCE((SELECT id FROM tags WHERE name=$2), 0)",
+		req.GetBookId(), name).Scan(&bookExists, &tagID)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "database error: %v", err)
+	}
+	if !bookExists {
+		return nil, notFound(resourceBook, req.GetBookId(), "Book not found")
+	}
+	if tagID == 0 {
+		return nil, notFound(resourceTag, name, "Tag not found")
+	}
+
+	_, err = s.db.Exec(ctx, "INSERT INTO book_tags (book_id, tag_id) VALUES ($1, $2) ON CONFLICT DO NOTHING", req.GetBookId(), tagID)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to tag book: %v", err)
+	}
+	return &pb.BookResponse{Id: req.GetBookId()}, nil
+}
+
+func (s *server) UntagBook(ctx context.Context, req *pb.BookTagRequest) (*pb.BookResponse, error) {
+	if req.GetBookId() == "" {
+		return nil, badRequest("book_id", "Book ID is required")
+	}
+	name := normalizeTag(req.GetTag())
+	if name == "" {
+		return nil, badRequest("tag", "Tag name is required")
+	}
+
+	res, err := s.db.Exec(ctx,
+		"DELETE FROM book_tags WHERE book_id=$1 AND tag_id=(SELECT id FROM tags WHERE name=$2)",
+		req.GetBookId(), name)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to untag book: %v", err)
+	}
+	if res.RowsAffected() == 0 {
+		return nil, notFound(resourceBookTag, name, "Book is not tagged with "+name)
+	}
+	return &pb.BookResponse{Id: req.GetBookId()}, nil
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	pb "example/grpc_demo/library"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestNormalizeTags(t *testing.T) {
+	got := normalizeTags([]string{" Sci-Fi ", "sci-fi", "", "  ", "Classics"})
+	if want := []string{"sci-fi", "classics"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("normalizeTags() = %v, want %v", got, want)
+	}
+}
+
+// TestTags runs against the database of STORAGE_TEST_DATABASE_URL
+func TestTags(t *testing.T) {
+	pool := testDatabase(t, "tags-test-")
+	s := &server{db: pool}
+	ctx := testUser(t, pool, "tags-test-alice")
+	testBook(t, pool, "tags-test-dune", 1)
+
+	if _, err := s.CreateTag(ctx, &pb.Tag{Name: " Tags-Test-SF "}); err != nil {
+		t.Fatalf("CreateTag() error = %v", err)
+	}
+	if _, err := s.CreateTag(ctx, &pb.Tag{Name: "tags-test-sf"}); status.Code(err) != codes.AlreadyExists {
+		t.Errorf("CreateTag() of an existing tag error = %v, want AlreadyExists", err)
+	}
+	if _, err := s.TagBook(ctx, &pb.BookTagRequest{BookId: "tags-test-dune", Tag: "TAGS-TEST-SF"}); err != nil {
+		t.Fatalf("TagBook() error = %v", err)
+	}
+	// Tagging again is a no-op
+	if _, err := s.TagBook(ctx, &pb.BookTagRequest{BookId: "tags-test-dune", Tag: "tags-test-sf"}); err != nil {
+		t.Errorf("TagBook() again error = %v", err)
+	}
+	if _, err := s.TagBook(ctx, &pb.BookTagRequest{BookId: "tags-test-missing", Tag: "tags-test-sf"}); status.Code(err) != codes.NotFound {
+		t.Errorf("TagBook() of an unknown book error = %v, want NotFound", err)
+	}
+	if _, err := s.TagBook(ctx, &pb.BookTagRequest{BookId: "tags-test-dune", Tag: "tags-test-unknown"}); status.Code(err) != codes.NotFound {
+		t.Errorf("TagBook() with an unknown tag error = %v, want NotFound", err)
+	}
+
+	resp, err := s.ListTags(ctx, &pb.ListTagsRequest{})
+	if err != nil {
+		t.Fatalf("ListTags() error = %v", err)
+	}
+	var found bool
+	for _, tag := range resp.GetTags() {
+		if tag.GetName() == "tags-test-sf" {
+			found = true
+			if tag.GetBookCount() != 1 {
+				t.Errorf("tag %v counts %d books, want 1", tag.GetName(), tag.GetBookCount())
+			}
+		}
+	}
+	if !found {
+		t.Errorf("ListTags() = %v, want tags-test-sf", resp.GetTags())
+	}
+
+	if _, err := s.UntagBook(ctx, &pb.BookTagRequest{BookId: "tags-test-dune", Tag: "tags-test-sf"}); err != nil {
+		t.Errorf("UntagBook() error = %v", err)
+	}
+	if _, err := s.UntagBook(ctx, &pb.BookTagRequest{BookId: "tags-test-dune", Tag: "tags-test-sf"}); status.Code(err) != codes.NotFound {
+		t.Errorf("UntagBook() of an untagged book error = %v, want NotFound", err)
+	}
+}
@@ -0,0 +1,328 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	pb "example/grpc_demo/library"
+	"example/grpc_demo/server/storage"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// tenantHeader names the library a call is for, when the server hosts several
+const tenantHeader = "x-tenant-id"
+
+// maxTenantNameLength bounds library names
+const maxTenantNameLength = 200
+
+// tenantIDPattern is what library IDs look like: they are kept to lowercase identifier characters, as they
+// appear in URLs, logs and tokens
+var tenantIDPattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,29}$`)
+
+// withTenant makes the queries run with ctx read and write the rows of library id; "" is the default library
+func withTenant(ctx context.Context, id string) context.Context {
+	return storage.WithTenant(ctx, id)
+}
+
+// tenantFromContext returns the library of ctx, "" for the default library
+func tenantFromContext(ctx context.Context) string {
+	return storage.Tenant(ctx)
+}
+
+// tenantSetting points the connections of a pool at the library of the call acquiring them, through the
+// app.tenant_id setting that the row level security policies of migrationsFile check. The library each
+// connection is on is remembered, so only a change of library costs a round trip.
+type tenantSetting struct {
+	mu sync.Mutex
+	// tenants is the library of each connection that was moved off the default one
+	tenants map[*pgx.Conn]string
+}
+
+// useTenantSetting makes the connections of config follow the library of the context they are acquired with
+func useTenantSetting(config *pgxpool.Config) {
+	p := &tenantSetting{tenants: make(map[*pgx.Conn]string)}
+	config.BeforeAcquire = p.beforeAcquire
+	config.BeforeClose = p.forget
+}
+
+func (p *tenantSetting) beforeAcquire(ctx context.Context, conn *pgx.Conn) bool {
+	tenant := tenantFromContext(ctx)
+	p.mu.Lock()
+	current := p.tenants[conn]
+	p.mu.Unlock()
+	if current == tenant {
+		return true
+	}
+
+	if _, err := conn.Exec(ctx, "SELECT set_config('app.tenant_id', $1, false)", tenant); err != nil {
+		// The pool closes the connection and acquires another
+		slog.WarnContext(ctx, "failed to switch a database connection to a library", "tenant", tenant, "error", err)
+		return false
+	}
+	p.mu.Lock()
+	if tenant == "" {
+		delete(p.tenants, conn)
+	} else {
+		p.tenants[conn] = tenant
+	}
+	p.mu.Unlock()
+	return true
+}
+
+func (p *tenantSetting) forget(conn *pgx.Conn) {
+	p.mu.Lock()
+	delete(p.tenants, conn)
+	p.mu.Unlock()
+}
+
+// checkTenantIsolation refuses to host several libraries as a role that row level security doesn't apply
+// to, as the statements of one library would then see the rows of all
+func checkTenantIsolation(ctx context.Context, db *pgxpool.Pool) error {
+	var bypass bool
+	err := db.QueryRow(ctx, "SELECT rolsuper OR rolbypassrls FROM pg_roles WHERE rolname = current_user").Scan(&bypass)
+	if err != nil {
+		return err
+	}
+	if bypass {
+		return errors.New("DB_USER is a superuser or has BYPASSRLS, which skips the row level security separating libraries")
+	}
+	return nil
+}
+
+// listTenantIDs returns the IDs of the libraries hosted besides the default one
+func listTenantIDs(ctx context.Context, db *pgxpool.Pool) ([]string, error) {
+	rows, err := db.Query(withTenant(ctx, ""), "SELECT id FROM tenants ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowTo[string])
+}
+
+// forEachTenant runs fn with the context of the default library, then of every other one, for the
+// background jobs that maintain them all. A library whose run fails doesn't stop the others.
+func forEachTenant(ctx context.Context, db *pgxpool.Pool, fn func(ctx context.Context) error) error {
+	errs := []error{fn(withTenant(ctx, ""))}
+	ids, err := listTenantIDs(ctx, db)
+	if err != nil {
+		return errors.Join(append(errs, fmt.Errorf("listing libraries: %w", err))...)
+	}
+	for _, id := range ids {
+		if err := fn(withTenant(ctx, id)); err != nil {
+			errs = append(errs, fmt.Errorf("library %s: %w", id, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// tenantRegistry answers whether libraries exist, for the calls naming one
+type tenantRegistry struct {
+	// find looks a library up in the tenants table
+	find func(ctx context.Context, id string) (bool, error)
+	mu   sync.RWMutex
+	// known caches the libraries found, as libraries are never removed
+	known map[string]bool
+}
+
+func newTenantRegistry(db *pgxpool.Pool) *tenantRegistry {
+	return &tenantRegistry{
+		find: func(ctx context.Context, id string) (bool, error) {
+			var exists bool
+			err := db.QueryRow(withTenant(ctx, ""), "SELECT EXISTS(SELECT 1 FROM tenants WHERE id=$1)", id).Scan(&exists)
+			return exists, err
+		},
+		known: make(map[string]bool),
+	}
+}
+
+func (r *tenantRegistry) exists(ctx context.Context, id string) (bool, error) {
+	r.mu.RLock()
+	known := r.known[id]
+	r.mu.RUnlock()
+	if known {
+		return true, nil
+	}
+	exists, err := r.find(ctx, id)
+	if err != nil || !exists {
+		return false, err
+	}
+	r.mu.Lock()
+	r.known[id] = true
+	r.mu.Unlock()
+	return true, nil
+}
+
+// requestedTenant is the library named by the x-tenant-id header of the call, or else by the tid claim of
+// its bearer token. The claim is only used once the token's signature is verified, so a forged token can't
+// route a call to another library; the claims themselves, expiry included, are left to the auth
+// interceptors, which also refuse tokens issued by another library than the call's.
+func requestedTenant(ctx context.Context) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if values := md.Get(tenantHeader); len(values) > 0 {
+		return strings.TrimSpace(values[0])
+	}
+	token, err := extractTokenFromMetadata(ctx)
+	if err != nil {
+		return ""
+	}
+	var claims Claims
+	if _, err := jwt.ParseWithClaims(token, &claims, jwtKeyFunc(), jwt.WithoutClaimsValidation()); err != nil {
+		return ""
+	}
+	return claims.TenantID
+}
+
+// resolveTenant returns ctx for the library the call asks for. A nil registry means the server hosts the
+// default library only, and refuses calls asking for another.
+func resolveTenant(ctx context.Context, registry *tenantRegistry) (context.Context, error) {
+	id := requestedTenant(ctx)
+	if id == "" {
+		return withTenant(ctx, ""), nil
+	}
+	if registry == nil {
+		return nil, status.Error(codes.FailedPrecondition, "Multiple libraries are not enabled")
+	}
+	if !tenantIDPattern.MatchString(id) {
+		return nil, badRequest(tenantHeader, "Invalid library ID")
+	}
+	exists, err := registry.exists(ctx, id)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "database error: %v", err)
+	}
+	if !exists {
+		return nil, notFound(resourceTenant, id, "Library not found")
+	}
+	return withTenant(ctx, id), nil
+}
+
+// CreateTenantInterceptor gives unary calls the context of the library they are for. It runs before
+// authentication, which looks the caller up among the users of that library.
+func CreateTenantInterceptor(registry *tenantRegistry) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, err := resolveTenant(ctx, registry)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// CreateStreamTenantInterceptor gives streaming calls the context of the library they are for
+func CreateStreamTenantInterceptor(registry *tenantRegistry) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := resolveTenant(ss.Context(), registry)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &contextServerStream{ss, ctx})
+	}
+}
+
+// requireTenantAdmin admits admins of the default library, who run the deployment, to the TenantService
+func (s *server) requireTenantAdmin(ctx context.Context) error {
+	if s.tenants == nil {
+		return status.Error(codes.FailedPrecondition, "Multiple libraries are not enabled")
+	}
+	if _, _, ok := userFromContext(ctx); !ok {
+		return status.Error(codes.Unauthenticated, "authentication required")
+	}
+	if role, _ := roleFromContext(ctx); role != adminRole || tenantFromContext(ctx) != "" {
+		return status.Error(codes.PermissionDenied, "Only admins of the default library can manage libraries")
+	}
+	return nil
+}
+
+func (s *server) CreateTenant(ctx context.Context, req *pb.CreateTenantRequest) (*pb.Tenant, error) {
+	if err := s.requireTenantAdmin(ctx); err != nil {
+		return nil, err
+	}
+	id, name := strings.TrimSpace(req.GetId()), strings.TrimSpace(req.GetName())
+	switch {
+	case id == "":
+		return nil, badRequest("id", "Library ID is required")
+	case !tenantIDPattern.MatchString(id):
+		return nil, badRequest("id", "Invalid library ID")
+	case name == "":
+		return nil, badRequest("name", "Library name is required")
+	case len(name) > maxTenantNameLength:
+		return nil, badRequest("name", "Library name is too long")
+	case req.GetAdminUsername() == "" || req.GetAdminPassword() == "":
+		return nil, badRequest("admin_username", "The library's admin username and password are required")
+	}
+	hash, err := s.passwords.Hash(req.GetAdminPassword())
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to hash password: %v", err)
+	}
+
+	// The library is registered and its admin added in one transaction, so a failure leaves nothing behind
+	ctx = withTenant(ctx, "")
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "database error: %v", err)
+	}
+	defer tx.Rollback(ctx)
+
+	tenant := &pb.Tenant{Id: id, Name: name}
+	var createdAt time.Time
+	err = tx.QueryRow(ctx, "INSERT INTO tenants (id, name) VALUES ($1, $2) RETURNING created_at", id, name).Scan(&createdAt)
+	if isUniqueViolation(err) {
+		return nil, alreadyExists(resourceTenant, id, "Library already exists")
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to create library: %v", err)
+	}
+	// The admin is the first row of the library. The setting ends with the transaction, so the connection
+	// goes back to the pool on the default library.
+	if _, err := tx.Exec(ctx, "SELECT set_config('app.tenant_id', $1, true)", id); err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to create library: %v", err)
+	}
+	_, err = tx.Exec(ctx, "INSERT INTO users (tenant_id, username, password_hash, role, state) VALUES ($1, $2, $3, $4, $5)",
+		id, req.GetAdminUsername(), hash, adminRole, accountActive)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to create the library's admin: %v", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, status.Errorf(codes.Internal, "database error: %v", err)
+	}
+	tenant.CreatedAt = timestamppb.New(createdAt)
+	slog.InfoContext(ctx, "library created", "tenant", id, "admin", req.GetAdminUsername())
+	return tenant, nil
+}
+
+func (s *server) ListTenants(ctx context.Context, req *pb.ListTenantsRequest) (*pb.ListTenantsResponse, error) {
+	if err := s.requireTenantAdmin(ctx); err != nil {
+		return nil, err
+	}
+	rows, err := s.db.Query(withTenant(ctx, ""), "SELECT id, name, created_at FROM tenants ORDER BY id")
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "database error: %v", err)
+	}
+	defer rows.Close()
+	resp := &pb.ListTenantsResponse{}
+	for rows.Next() {
+		tenant := &pb.Tenant{}
+		var createdAt time.Time
+		if err := rows.Scan(&tenant.Id, &tenant.Name, &createdAt); err != nil {
+			return nil, status.Errorf(codes.Internal, "database error: %v", err)
+		}
+		tenant.CreatedAt = timestamppb.New(createdAt)
+		resp.Tenants = append(resp.Tenants, tenant)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, status.Errorf(codes.Internal, "database error: %v", err)
+	}
+	return resp, nil
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// testTenantRegistry knows the libraries of ids, and counts its lookups in the tenants table
+func testTenantRegistry(lookups *int, ids ...string) *tenantRegistry {
+	return &tenantRegistry{
+		find: func(ctx context.Context, id string) (bool, error) {
+			*lookups++
+			for _, known := range ids {
+				if id == known {
+					return true, nil
+				}
+			}
+			return false, nil
+		},
+		known: make(map[string]bool),
+	}
+}
+
+func TestResolveTenant(t *testing.T) {
+	var lookups int
+	registry := testTenantRegistry(&lookups, "riverside")
+	riversideClaims := newAccessClaims(1, "ana", 1)
+	riversideClaims.TenantID = "riverside"
+	riversideToken, err := signAccessToken(riversideClaims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defaultToken, err := GenerateJWT(1, "ana", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A token that doesn't verify names no library, whatever its tid claim
+	forgedToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, riversideClaims).SignedString([]byte("not the server's secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// An expired token still routes to its library, where the auth interceptors refuse it
+	expiredClaims := newAccessClaims(1, "ana", 1)
+	expiredClaims.TenantID = "riverside"
+	expiredClaims.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Hour))
+	expiredToken, err := signAccessToken(expiredClaims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	incoming := func(kv ...string) context.Context {
+		return metadata.NewIncomingContext(context.Background(), metadata.Pairs(kv...))
+	}
+
+	tests := []struct {
+		name       string
+		ctx        context.Context
+		registry   *tenantRegistry
+		wantTenant string
+		wantCode   codes.Code
+	}{
+		{"no library", incoming(), registry, "", codes.OK},
+		{"header", incoming(tenantHeader, "riverside"), registry, "riverside", codes.OK},
+		{"token", incoming("authorization", "Bearer "+riversideToken), registry, "riverside", codes.OK},
+		{"forged token", incoming("authorization", "Bearer "+forgedToken), registry, "", codes.OK},
+		{"expired token", incoming("authorization", "Bearer "+expiredToken), registry, "riverside", codes.OK},
+		{"token of the default library", incoming("authorization", "Bearer "+defaultToken), registry, "", codes.OK},
+		// The auth interceptors then refuse the token, as it is not of the library of the header
+		{"header over token", incoming(tenantHeader, "riverside", "authorization", "Bearer "+defaultToken), registry, "riverside", codes.OK},
+		{"unknown library", incoming(tenantHeader, "hillcrest"), registry, "", codes.NotFound},
+		{"invalid ID", incoming(tenantHeader, "Hill Crest"), registry, "", codes.InvalidArgument},
+		{"not enabled", incoming(tenantHeader, "riverside"), nil, "", codes.FailedPrecondition},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, err := resolveTenant(tt.ctx, tt.registry)
+			if status.Code(err) != tt.wantCode {
+				t.Fatalf("resolveTenant() error = %v, want %v", err, tt.wantCode)
+			}
+			if err == nil && tenantFromContext(ctx) != tt.wantTenant {
+				t.Errorf("resolveTenant() library = %q, want %q", tenantFromContext(ctx), tt.wantTenant)
+			}
+		})
+	}
+
+	// Libraries found are not looked up again; unknown ones are, as they may be created meanwhile
+	lookups = 0
+	for range 3 {
+		registry.exists(context.Background(), "riverside")
+		registry.exists(context.Background(), "hillcrest")
+	}
+	if lookups != 3 {
+		t.Errorf("%d lookups, want 3 of the unknown library only", lookups)
+	}
+}
+
+func TestAuthenticateRefusesTokenOfAnotherLibrary(t *testing.T) {
+	claims := newAccessClaims(1, "ana", 1)
+	claims.TenantID = "riverside"
+	token, err := signAccessToken(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer "+token))
+
+	// User 1 of the default library is someone else: the token is refused before any lookup
+	_, err = authenticate(withTenant(ctx, ""), nil, authOptions{})
+	if status.Code(err) != codes.Unauthenticated {
+		t.Errorf("authenticate() error = %v, want Unauthenticated", err)
+	}
+}
+
+func TestTenantAdminOnly(t *testing.T) {
+	admin := context.WithValue(context.WithValue(context.Background(), userIDKey, 1), roleKey, adminRole)
+	s := &server{tenants: testTenantRegistry(new(int))}
+
+	if err := s.requireTenantAdmin(admin); err != nil {
+		t.Errorf("admin of the default library: %v", err)
+	}
+	if err := s.requireTenantAdmin(withTenant(admin, "riverside")); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("admin of another library: %v, want PermissionDenied", err)
+	}
+	user := context.WithValue(context.WithValue(context.Background(), userIDKey, 2), roleKey, userRole)
+	if err := s.requireTenantAdmin(user); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("user: %v, want PermissionDenied", err)
+	}
+	if err := (&server{}).requireTenantAdmin(admin); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("multiple libraries disabled: %v, want FailedPrecondition", err)
+	}
+}
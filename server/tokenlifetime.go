@@ -0,0 +1,22 @@
+package main
+
+import "time"
+
+const (
+	defaultAccessTokenTTL  = 24 * time.Hour
+	defaultRefreshTokenTTL = 30 * 24 * time.Hour
+)
+
+// tokenLifetimes are how long issued tokens stay valid, and how far the clocks of the services issuing
+// and checking access tokens may drift apart
+type tokenLifetimes struct {
+	// access is how long a JWT access token is valid; clients renew it with RefreshToken
+	access time.Duration
+	// refresh is how long a refresh token stays usable; each use replaces it with a new one
+	refresh time.Duration
+	// clockSkew is tolerated on the exp, nbf and iat claims of access tokens
+	clockSkew time.Duration
+}
+
+// tokenTTLs is set from the configuration at startup by useAuthConfig; tests use the defaults
+var tokenTTLs = tokenLifetimes{access: defaultAccessTokenTTL, refresh: defaultRefreshTokenTTL}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestUseAuthConfigTokenLifetimes(t *testing.T) {
+	saved := tokenTTLs
+	t.Cleanup(func() { tokenTTLs = saved })
+
+	cfg, err := loadTestConfig(t, map[string]string{"ACCESS_TOKEN_TTL": "15m", "REFRESH_TOKEN_TTL": "168h", "JWT_CLOCK_SKEW": "30s"})
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	useAuthConfig(cfg.Auth)
+	if tokenTTLs.access != 15*time.Minute || tokenTTLs.refresh != 168*time.Hour || tokenTTLs.clockSkew != 30*time.Second {
+		t.Fatalf("tokenTTLs = %+v", tokenTTLs)
+	}
+}
+
+func TestValidateJWTClockSkew(t *testing.T) {
+	saved := tokenTTLs
+	t.Cleanup(func() { tokenTTLs = saved })
+
+	// Expired ten seconds ago by this server's clock
+	claims := newAccessClaims(1, "testuser", 0)
+	claims.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-10 * time.Second))
+	token, err := signAccessToken(claims)
+	if err != nil {
+		t.Fatalf("signAccessToken() error = %v", err)
+	}
+
+	tokenTTLs.clockSkew = 0
+	if _, err := ValidateJWT(token); err == nil {
+		t.Error("without clock skew: expected the expired token to be rejected")
+	}
+	tokenTTLs.clockSkew = 30 * time.Second
+	if _, err := ValidateJWT(token); err != nil {
+		t.Errorf("with 30s clock skew: ValidateJWT() error = %v", err)
+	}
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerName identifies the spans created by this service's own instrumentation
+const tracerName = "example/grpc_demo/server"
+
+// defaultServiceName is reported in traces unless OTEL_SERVICE_NAME overrides it
+const defaultServiceName = "library-service"
+
+// setupTracing exports traces over OTLP/gRPC when OTEL_EXPORTER_OTLP_ENDPOINT (or
+// OTEL_EXPORTER_OTLP_TRACES_ENDPOINT) is set; the exporter reads the other standard OTEL_EXPORTER_OTLP_*
+// variables itself. Trace context is propagated either way, so callers' traces are continued downstream.
+// The returned function flushes the remaining spans at shutdown.
+func setupTracing(ctx context.Context) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	disabled := strings.EqualFold(os.Getenv("OTEL_SDK_DISABLED"), "true")
+	if disabled || (os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") == "" && os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT") == "") {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	exporter, err := otlptracegrpc.New(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// OTEL_SERVICE_NAME and OTEL_RESOURCE_ATTRIBUTES override the defaults
+	res, err := resource.New(ctx,
+		resource.WithAttributes(semconv.ServiceName(defaultServiceName)),
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+		resource.WithHost(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	provider := sdktrace.NewTracerProvider(sdktrace.WithBatcher(exporter), sdktrace.WithResource(res))
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+// dbTracer is a pgx query tracer recording a span for every statement, as a child of the RPC that ran it,
+// timing it in db_query_duration_seconds and reporting statements slower than slowQuery (0: none)
+type dbTracer struct {
+	slowQuery time.Duration
+}
+
+func (t dbTracer) TraceQueryStart(ctx context.Context, conn *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	ctx, _ = otel.Tracer(tracerName).Start(ctx, "db "+sqlOperation(data.SQL),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemPostgreSQL,
+			semconv.DBOperationName(sqlOperation(data.SQL)),
+			semconv.DBQueryText(data.SQL),
+		))
+	return context.WithValue(ctx, queryStartKey{}, queryStart{sql: data.SQL, at: time.Now()})
+}
+
+func (t dbTracer) TraceQueryEnd(ctx context.Context, conn *pgx.Conn, data pgx.TraceQueryEndData) {
+	span := trace.SpanFromContext(ctx)
+	if data.Err != nil {
+		span.RecordError(data.Err)
+		span.SetStatus(codes.Error, data.Err.Error())
+	}
+	span.End()
+	if start, ok := ctx.Value(queryStartKey{}).(queryStart); ok {
+		elapsed := time.Since(start.at)
+		dbQueryDuration.WithLabelValues(queryFamily(start.sql)).Observe(elapsed.Seconds())
+		if t.slowQuery > 0 && elapsed >= t.slowQuery {
+			reportSlowQuery(ctx, start.sql, elapsed)
+		}
+	}
+}
+
+// sqlOperation returns the statement's leading keyword, e.g. SELECT, used to name its span
+func sqlOperation(sql string) string {
+	fields := strings.Fields(sql)
+	if len(fields) == 0 {
+		return "QUERY"
+	}
+	return strings.ToUpper(strings.TrimLeft(fields[0], "("))
+}
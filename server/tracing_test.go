@@ -0,0 +1,127 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestSetupTracingDisabled(t *testing.T) {
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "")
+	t.Setenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "")
+	shutdown, err := setupTracing(context.Background())
+	if err != nil {
+		t.Fatalf("setupTracing() error = %v", err)
+	}
+	if err := shutdown(context.Background()); err != nil {
+		t.Errorf("shutdown() error = %v", err)
+	}
+}
+
+func TestDBTracer(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	saved := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() { otel.SetTracerProvider(saved) })
+
+	// The statement span is a child of the RPC's span
+	ctx, parent := provider.Tracer("test").Start(context.Background(), "rpc")
+	var tracer dbTracer
+	queryCtx := tracer.TraceQueryStart(ctx, nil, pgx.TraceQueryStartData{SQL: "  select id FROM books WHERE id=$1"})
+	tracer.TraceQueryEnd(queryCtx, nil, pgx.TraceQueryEndData{})
+	failedCtx := tracer.TraceQueryStart(ctx, nil, pgx.TraceQueryStartData{SQL: "INSERT INTO books (id) VALUES ($1)"})
+	tracer.TraceQueryEnd(failedCtx, nil, pgx.TraceQueryEndData{Err: errors.New("duplicate key")})
+	parent.End()
+
+	spans := recorder.Ended()
+	if len(spans) != 3 {
+		t.Fatalf("recorded %d spans, want 3", len(spans))
+	}
+	query, failed := spans[0], spans[1]
+	if query.Name() != "db SELECT" || query.Parent().SpanID() != parent.SpanContext().SpanID() {
+		t.Errorf("query span = %q with parent %s, want db SELECT under the RPC span", query.Name(), query.Parent().SpanID())
+	}
+	attrs := map[string]string{}
+	for _, kv := range query.Attributes() {
+		attrs[string(kv.Key)] = kv.Value.Emit()
+	}
+	if attrs[string(semconv.DBSystemKey)] != "postgresql" || attrs[string(semconv.DBQueryTextKey)] != "  select id FROM books WHERE id=$1" {
+		t.Errorf("query span attributes = %v", attrs)
+	}
+	if query.Status().Code != codes.Unset {
+		t.Errorf("query span status = %v, want unset", query.Status())
+	}
+	if failed.Name() != "db INSERT" || failed.Status().Code != codes.Error || len(failed.Events()) == 0 {
+		t.Errorf("failed span = %q, status %v, %d events; want an error status and recorded error", failed.Name(), failed.Status(), len(failed.Events()))
+	}
+}
+
+func TestSQLOperation(t *testing.T) {
+	for sql, want := range map[string]string{
+		"SELECT 1":                    "SELECT",
+		"\n\tupdate books SET x=1":    "UPDATE",
+		"(SELECT 1) UNION (SELECT 2)": "SELECT",
+		"":                            "QUERY",
+	} {
+		if got := sqlOperation(sql); got != want {
+			t.Errorf("sqlOperation(%q) = %q, want %q", sql, got, want)
+		}
+	}
+}
+
+// methodStream is the transport stream of a call of the method, so grpc.Method finds it
+type methodStream string
+
+func (m methodStream) Method() string                { return string(m) }
+func (methodStream) SetHeader(md metadata.MD) error  { return nil }
+func (methodStream) SendHeader(md metadata.MD) error { return nil }
+func (methodStream) SetTrailer(md metadata.MD) error { return nil }
+
+func TestDBTracerSlowQuery(t *testing.T) {
+	var buf bytes.Buffer
+	saved := slog.Default()
+	slog.SetDefault(slog.New(slog.NewJSONHandler(&buf, nil)))
+	t.Cleanup(func() { slog.SetDefault(saved) })
+
+	ctx, requestID := withRequestID(grpc.NewContextWithServerTransportStream(context.Background(),
+		methodStream("/library.LibraryService/ListBooks")))
+	counter := slowQueries.WithLabelValues("/library.LibraryService/ListBooks", "SELECT")
+	before := testutil.ToFloat64(counter)
+
+	fast := dbTracer{slowQuery: time.Hour}
+	fast.TraceQueryEnd(fast.TraceQueryStart(ctx, nil, pgx.TraceQueryStartData{SQL: "SELECT 1"}), nil, pgx.TraceQueryEndData{})
+	if buf.Len() != 0 {
+		t.Errorf("fast query logged: %s", buf.String())
+	}
+
+	slow := dbTracer{slowQuery: time.Nanosecond}
+	queryCtx := slow.TraceQueryStart(ctx, nil, pgx.TraceQueryStartData{SQL: "SELECT id\n\t FROM books WHERE title ILIKE $1"})
+	time.Sleep(time.Millisecond)
+	slow.TraceQueryEnd(queryCtx, nil, pgx.TraceQueryEndData{})
+	var entry map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("log output %q is not a single JSON entry: %v", buf.String(), err)
+	}
+	if entry["msg"] != "slow query" || entry["method"] != "/library.LibraryService/ListBooks" || entry["request_id"] != requestID ||
+		entry["sql"] != "SELECT id FROM books WHERE title ILIKE $1" {
+		t.Errorf("entry = %v", entry)
+	}
+	if got := testutil.ToFloat64(counter) - before; got != 1 {
+		t.Errorf("db_slow_queries_total increased by %v, want 1", got)
+	}
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"strings"
+
+	pb "example/grpc_demo/library"
+
+	"golang.org/x/text/language"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// localeHeaders are the metadata keys checked for a preferred language when the request has no locale:
+// accept-language from gRPC clients, then the Accept-Language header forwarded by the gateway
+var localeHeaders = []string{"accept-language", "grpcgateway-accept-language"}
+
+// normalizeLanguage validates a BCP 47 tag and returns the lowercase canonical form stored in book_translations
+func normalizeLanguage(tag string) (string, error) {
+	t, err := language.Parse(strings.TrimSpace(tag))
+	if err != nil {
+		return "", err
+	}
+	return strings.ToLower(t.String()), nil
+}
+
+// languagePreferences returns the translation languages to try, most preferred first.
+// Each regional tag is followed by its base language, so "pt-BR" also matches a "pt" translation.
+func languagePreferences(ctx context.Context, locale string) ([]string, error) {
+	var tags []language.Tag
+	if locale = strings.TrimSpace(locale); locale != "" {
+		t, err := language.Parse(locale)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid locale %q", locale)
+		}
+		tags = []language.Tag{t}
+	} else if md, ok := metadata.FromIncomingContext(ctx); ok {
+		for _, key := range localeHeaders {
+			if values := md.Get(key); len(values) > 0 {
+				// A malformed header is ignored rather than failing the request
+				tags, _, _ = language.ParseAcceptLanguage(strings.Join(values, ","))
+				break
+			}
+		}
+	}
+
+	var prefs []string
+	seen := make(map[string]bool)
+	add := func(tag string) {
+		tag = strings.ToLower(tag)
+		// "und" and "mul" come from empty or wildcard ("*") preferences and match nothing
+		if tag != "und" && tag != "mul" && !seen[tag] {
+			seen[tag] = true
+			prefs = append(prefs, tag)
+		}
+	}
+	for _, t := range tags {
+		add(t.String())
+		if base, confidence := t.Base(); confidence != language.No {
+			add(base.String())
+		}
+	}
+	return prefs, nil
+}
+
+func (s *server) SetBookTranslation(ctx context.Context, req *pb.BookTranslation) (*pb.BookTranslation, error) {
+	if req.GetBookId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "Book ID is required")
+	}
+	lang, err := normalizeLanguage(req.GetLanguage())
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid language %q", req.GetLanguage())
+	}
+	title := strings.TrimSpace(req.GetTitle())
+	if title == "" {
+		return nil, status.Error(codes.InvalidArgument, "Translated title is required")
+	}
+
+	var exists bool
+	if err := s.db.QueryRow(ctx, "SELECT EXISTS(SELECT 1 FROM books WHERE id=$1)", req.GetBookId()).Scan(&exists); err != nil {
+		return nil, status.Errorf(codes.Internal, "database error: %v", err)
+	}
+	if !exists {
+		return nil, notFound(resourceBook, req.GetBookId(), "Book not found")
+	}
+
+	_, err = s.db.Exec(ctx,
+		`INSERT INTO book_translations (book_id, language, title, description) VALUES ($1, $2, $3, $4)
+		 ON CONFLICT (tenant_id, book_id, language) DO UPDATE SET title=EXCLUDED.title, description=EXCLUDED.description, updated_at=NOW()`,
+		req.GetBookId(), lang, title, req.GetDescription())
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to save translation: %v", err)
+	}
+	return &pb.BookTranslation{BookId: req.GetBookId(), Language: lang, Title: title, Description: req.GetDescription()}, nil
+}
+
+func (s *server) DeleteBookTranslation(ctx context.Context, req *pb.BookTranslationRequest) (*pb.BookResponse, error) {
+	if req.GetBookId() == "" {
+		return nil, badRequest("book_id", "Book ID is required")
+	}
+	lang, err := normalizeLanguage(req.GetLanguage())
+	if err != nil {
+		return nil, badRequest("language", "Invalid language")
+	}
+	res, err := s.db.Exec(ctx, "DELETE FROM book_translations WHERE book_id=$1 AND language=$2", req.GetBookId(), lang)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to delete translation: %v", err)
+	}
+	if res.RowsAffected() == 0 {
+		return nil, notFound(resourceTranslation, req.GetBookId()+"/"+lang, "Translation not found")
+	}
+	return &pb.BookResponse{Id: req.GetBookId()}, nil
+}
+
+func (s *server) ListBookTranslations(ctx context.Context, req *pb.BookRequest) (*pb.ListBookTranslationsResponse, error) {
+	if req.GetId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "Book ID is required")
+	}
+	var exists bool
+	if err := s.db.QueryRow(ctx, "SELECT EXISTS(SELECT 1 FROM books WHERE id=$1)", req.GetId()).Scan(&exists); err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, notFound(resourceBook, req.GetId(), "Book not found")
+	}
+
+	rows, err := s.db.Query(ctx, "SELECT book_id, language, title, description FROM book_translations WHERE book_id=$1 ORDER BY language", req.GetId())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	resp := &pb.ListBookTranslationsResponse{}
+	for rows.Next() {
+		t := &pb.BookTranslation{}
+		if err := rows.Scan(&t.BookId, &t.Language, &t.Title, &t.Description); err != nil {
+			return nil, err
+		}
+		resp.Translations = append(resp.Translations, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"google.golang.org/grpc/metadata"
+)
+
+func TestNormalizeLanguage(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{"pt-BR", "pt-br", false},
+		{" en ", "en", false},
+		{"zh-Hant-TW", "zh-hant-tw", false},
+		{"not a language", "", true},
+		{"", "", true},
+	}
+	for _, tt := range tests {
+		got, err := normalizeLanguage(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("normalizeLanguage(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("normalizeLanguage(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestLanguagePreferences(t *testing.T) {
+	header := func(key, value string) context.Context {
+		return metadata.NewIncomingContext(context.Background(), metadata.Pairs(key, value))
+	}
+	tests := []struct {
+		name   string
+		ctx    context.Context
+		locale string
+		want   []string
+	}{
+		{"none", context.Background(), "", nil},
+		{"locale field", context.Background(), "pt-BR", []string{"pt-br", "pt"}},
+		{"locale wins over header", header("accept-language", "de"), "fr", []string{"fr"}},
+		{"grpc header", header("accept-language", "es-MX, en;q=0.5"), "", []string{"es-mx", "es", "en"}},
+		{"gateway header", header("grpcgateway-accept-language", "it"), "", []string{"it"}},
+		{"wildcard only", header("accept-language", "*"), "", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := languagePreferences(tt.ctx, tt.locale)
+			if err != nil {
+				t.Fatalf("languagePreferences() error = %v", err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("languagePreferences() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := languagePreferences(context.Background(), "not a language"); err == nil {
+		t.Error("languagePreferences() with an invalid locale should fail")
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	pb "example/grpc_demo/library"
+
+	"github.com/jackc/pgx/v5"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// emailVerificationTTL is how long an email verification token can be used
+const emailVerificationTTL = 24 * time.Hour
+
+// issueEmailVerification stores a new verification token for the user's address and returns it
+func issueEmailVerification(ctx context.Context, q querier, userID int, email string) (string, time.Time, error) {
+	token, hash, err := newOpaqueToken()
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	expiresAt := time.Now().Add(emailVerificationTTL)
+	_, err = q.Exec(ctx, "INSERT INTO email_verifications (user_id, email, token_hash, expires_at) VALUES ($1, $2, $3, $4)",
+		userID, email, hash, expiresAt)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return token, expiresAt, nil
+}
+
+func (s *server) VerifyEmail(ctx context.Context, req *pb.VerifyEmailRequest) (*pb.VerifyEmailResponse, error) {
+	if req.GetToken() == "" {
+		return nil, status.Error(codes.InvalidArgument, "verification token is required")
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "database error: %v", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var (
+		id        int64
+		userID    int
+		email     string
+		expiresAt time.Time
+		used      bool
+	)
+	err = tx.QueryRow(ctx,
+		"SELECT id, user_id, email, expires_at, used_at IS NOT NULL FROM email_verifications WHERE token_hash=$1 FOR UPDATE",
+		hashOpaqueToken(req.GetToken())).Scan(&id, &userID, &email, &expiresAt, &used)
+	if errors.Is(err, pgx.ErrNoRows) || used || !expiresAt.After(time.Now()) {
+		return nil, status.Error(codes.InvalidArgument, "invalid or expired verification token")
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "database error: %v", err)
+	}
+
+	// The token only verifies the address it was sent to, in case the user changed it since
+	// Verifying the address also activates an account still pending since registration
+	res, err := tx.Exec(ctx, "UPDATE users SET email_verified_at=NOW(), state=$3 WHERE id=$1 AND email=$2", userID, email, accountActive)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to verify email: %v", err)
+	}
+	if res.RowsAffected() == 0 {
+		return nil, status.Error(codes.FailedPrecondition, "the account no longer uses this email address")
+	}
+	if _, err := tx.Exec(ctx, "UPDATE email_verifications SET used_at=NOW() WHERE id=$1", id); err != nil {
+		return nil, status.Errorf(codes.Internal, "database error: %v", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, status.Errorf(codes.Internal, "database error: %v", err)
+	}
+	return &pb.VerifyEmailResponse{Message: localize(ctx, "Email address verified"), Email: email}, nil
+}
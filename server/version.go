@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"runtime"
+	"runtime/debug"
+	"slices"
+	"strings"
+	"time"
+
+	pb "example/grpc_demo/library"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Set when building a release, e.g.
+//
+//	go build -ldflags "-X main.version=1.4.0 -X main.gitCommit=$(git rev-parse HEAD) -X main.buildTime=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+//
+// Builds without them report the commit and time the Go toolchain stamped from the git checkout, if any.
+var (
+	version   = "dev"
+	gitCommit string
+	buildTime string
+)
+
+// newServerInfo describes this build, with the features its configuration turned on
+func newServerInfo(features []string) *pb.ServerInfo {
+	info := &pb.ServerInfo{Version: version, GitCommit: gitCommit, GoVersion: runtime.Version(), EnabledFeatures: features}
+	built := buildTime
+	if build, ok := debug.ReadBuildInfo(); ok {
+		var revision, modified string
+		for _, s := range build.Settings {
+			switch s.Key {
+			case "vcs.revision":
+				revision = s.Value
+			case "vcs.time":
+				if built == "" {
+					built = s.Value
+				}
+			case "vcs.modified":
+				modified = s.Value
+			}
+		}
+		if info.GitCommit == "" && revision != "" {
+			info.GitCommit = revision
+			if modified == "true" {
+				info.GitCommit += "-dirty"
+			}
+		}
+	}
+	if built != "" {
+		if t, err := time.Parse(time.RFC3339, built); err == nil {
+			info.BuildTime = timestamppb.New(t)
+		} else {
+			slog.Warn("ignoring build time that isn't in RFC 3339 format", "build_time", built)
+		}
+	}
+	return info
+}
+
+// describeBuild summarizes info on a line, e.g. "1.4.0 (commit 3f2a..., built 2025-01-02T15:04:05Z, go1.24.4)"
+func describeBuild(info *pb.ServerInfo) string {
+	var details []string
+	if info.GetGitCommit() != "" {
+		details = append(details, "commit "+info.GetGitCommit())
+	}
+	if info.GetBuildTime() != nil {
+		details = append(details, "built "+info.GetBuildTime().AsTime().UTC().Format(time.RFC3339))
+	}
+	details = append(details, info.GetGoVersion())
+	return info.GetVersion() + " (" + strings.Join(details, ", ") + ")"
+}
+
+// enabledFeatures lists the names of the features that are on, sorted
+func enabledFeatures(features map[string]bool) []string {
+	var names []string
+	for name, on := range features {
+		if on {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	return names
+}
+
+// GetServerInfo tells what is deployed: the version, commit and build time of the server and the optional
+// features it runs with
+func (s *server) GetServerInfo(ctx context.Context, req *pb.ServerInfoRequest) (*pb.ServerInfo, error) {
+	return s.info, nil
+}
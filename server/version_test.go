@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	pb "example/grpc_demo/library"
+)
+
+func TestNewServerInfo(t *testing.T) {
+	defer func(v, c, b string) { version, gitCommit, buildTime = v, c, b }(version, gitCommit, buildTime)
+	version, gitCommit, buildTime = "1.4.0", "3f2a9c1", "2025-01-02T15:04:05Z"
+
+	info := newServerInfo([]string{"tls"})
+	if info.GetVersion() != "1.4.0" || info.GetGitCommit() != "3f2a9c1" || !slices.Equal(info.GetEnabledFeatures(), []string{"tls"}) {
+		t.Errorf("newServerInfo() = %v", info)
+	}
+	if got := info.GetBuildTime().AsTime(); !got.Equal(time.Date(2025, 1, 2, 15, 4, 5, 0, time.UTC)) {
+		t.Errorf("build time = %s", got)
+	}
+	if got := describeBuild(info); !strings.HasPrefix(got, "1.4.0 (commit 3f2a9c1, built 2025-01-02T15:04:05Z, go") {
+		t.Errorf("describeBuild() = %q", got)
+	}
+
+	// A malformed build time is left out rather than failing the server
+	buildTime = "yesterday"
+	if info := newServerInfo(nil); info.GetBuildTime() != nil {
+		t.Errorf("build time of %q = %s", buildTime, info.GetBuildTime().AsTime())
+	}
+}
+
+func TestEnabledFeatures(t *testing.T) {
+	got := enabledFeatures(map[string]bool{"tls": true, "oidc": false, "channelz": true})
+	if want := []string{"channelz", "tls"}; !slices.Equal(got, want) {
+		t.Errorf("enabledFeatures() = %v, want %v", got, want)
+	}
+}
+
+func TestGetServerInfo(t *testing.T) {
+	s := &server{info: &pb.ServerInfo{Version: "1.4.0"}}
+	info, err := s.GetServerInfo(context.Background(), &pb.ServerInfoRequest{})
+	if err != nil || info.GetVersion() != "1.4.0" {
+		t.Errorf("GetServerInfo() = %v, %v", info, err)
+	}
+	// Anyone may ask, so that rollouts can be checked without credentials
+	if err := defaultAuthPolicy().authorize(context.Background(), pb.ServerInfoService_GetServerInfo_FullMethodName); err != nil {
+		t.Errorf("anonymous GetServerInfo: %v", err)
+	}
+}
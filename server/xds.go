@@ -0,0 +1,56 @@
+package main
+
+import (
+	"crypto/tls"
+	"log/slog"
+	"net"
+	"os"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	xdscreds "google.golang.org/grpc/credentials/xds"
+	"google.golang.org/grpc/xds"
+)
+
+// xdsEnabled reports whether the server runs under an xDS control plane, such as Traffic Director or Istio's
+// proxyless gRPC. gRPC reads its bootstrap configuration from the file named by GRPC_XDS_BOOTSTRAP, or from
+// the JSON in GRPC_XDS_BOOTSTRAP_CONFIG.
+func xdsEnabled() bool {
+	return os.Getenv("GRPC_XDS_BOOTSTRAP") != "" || os.Getenv("GRPC_XDS_BOOTSTRAP_CONFIG") != ""
+}
+
+// grpcServer is what the server needs of *grpc.Server and *xds.GRPCServer
+type grpcServer interface {
+	grpc.ServiceRegistrar
+	GetServiceInfo() map[string]grpc.ServiceInfo
+	Serve(lis net.Listener) error
+}
+
+// newGRPCServer creates the gRPC server with opts, serving TLS with tlsConfig unless it is nil. In xDS mode
+// the control plane configures the listener, routing and authorization policies, and mTLS between mesh
+// workloads; the TLS settings apply when it sends no security configuration.
+func newGRPCServer(tlsConfig *tls.Config, opts ...grpc.ServerOption) (grpcServer, error) {
+	var creds credentials.TransportCredentials = insecure.NewCredentials()
+	if tlsConfig != nil {
+		creds = credentials.NewTLS(tlsConfig)
+	}
+	if !xdsEnabled() {
+		return grpc.NewServer(append(opts, grpc.Creds(creds))...), nil
+	}
+	creds, err := xdscreds.NewServerCredentials(xdscreds.ServerOptions{FallbackCreds: creds})
+	if err != nil {
+		return nil, err
+	}
+	return xds.NewGRPCServer(append(opts, grpc.Creds(creds), xds.ServingModeCallback(logServingMode))...)
+}
+
+// logServingMode reports the xDS server starting or stopping to serve on addr, as its listener
+// configuration arrives or is withdrawn by the control plane
+func logServingMode(addr net.Addr, args xds.ServingModeChangeArgs) {
+	if args.Err != nil {
+		slog.Warn("xDS serving mode changed", "addr", addr.String(), "mode", args.Mode.String(), "error", args.Err)
+		return
+	}
+	slog.Info("xDS serving mode changed", "addr", addr.String(), "mode", args.Mode.String())
+}
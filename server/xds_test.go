@@ -0,0 +1,46 @@
+package main
+
+import (
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/xds"
+)
+
+// testXDSBootstrap is a bootstrap configuration naming a control plane that is never reached
+const testXDSBootstrap = `{
+	"xds_servers": [{"server_uri": "127.0.0.1:1", "channel_creds": [{"type": "insecure"}], "server_features": ["xds_v3"]}],
+	"node": {"id": "library-test"},
+	"server_listener_resource_name_template": "grpc/server?xds.resource.listening_address=%s"
+}`
+
+func TestNewGRPCServer(t *testing.T) {
+	t.Setenv("GRPC_XDS_BOOTSTRAP", "")
+	t.Setenv("GRPC_XDS_BOOTSTRAP_CONFIG", "")
+	if xdsEnabled() {
+		t.Error("xdsEnabled() = true without a bootstrap")
+	}
+	s, err := newGRPCServer(nil)
+	if err != nil {
+		t.Fatalf("newGRPCServer() error = %v", err)
+	}
+	if _, ok := s.(*grpc.Server); !ok {
+		t.Errorf("newGRPCServer() without a bootstrap = %T, want *grpc.Server", s)
+	}
+
+	// With a bootstrap the control plane configures the server. gRPC reads the bootstrap variables when it
+	// starts, so the server is given its contents too.
+	t.Setenv("GRPC_XDS_BOOTSTRAP_CONFIG", testXDSBootstrap)
+	if !xdsEnabled() {
+		t.Error("xdsEnabled() = false with a bootstrap")
+	}
+	s, err = newGRPCServer(nil, xds.BootstrapContentsForTesting([]byte(testXDSBootstrap)))
+	if err != nil {
+		t.Fatalf("newGRPCServer() in xDS mode error = %v", err)
+	}
+	xs, ok := s.(*xds.GRPCServer)
+	if !ok {
+		t.Fatalf("newGRPCServer() with a bootstrap = %T, want *xds.GRPCServer", s)
+	}
+	xs.Stop()
+}